@@ -44,6 +44,26 @@ func ConnectDatabase() {
 	_ = database.AutoMigrate(&reps.TxnInput{})
 	_ = database.AutoMigrate(&reps.TxnOutput{})
 	_ = database.AutoMigrate(&reps.Wallet{})
+	_ = database.AutoMigrate(&reps.ChainParams{})
+	_ = database.AutoMigrate(&reps.HDWallet{})
+	_ = database.AutoMigrate(&reps.MultisigAddress{})
+	_ = database.AutoMigrate(&reps.MultisigTransaction{})
+	_ = database.AutoMigrate(&reps.AddressBookEntry{})
+	_ = database.AutoMigrate(&reps.Account{})
+	_ = database.AutoMigrate(&reps.UnspentOutput{})
+	_ = database.AutoMigrate(&reps.AddressIndexEntry{})
+	_ = database.AutoMigrate(&reps.TxnLocation{})
+	_ = database.AutoMigrate(&reps.MempoolEntry{})
+	_ = database.AutoMigrate(&reps.MempoolReplacement{})
+	_ = database.AutoMigrate(&reps.Asset{})
+	_ = database.AutoMigrate(&reps.Schedule{})
+	_ = database.AutoMigrate(&reps.StaleBlock{})
+	_ = database.AutoMigrate(&reps.SideBlock{})
+	_ = database.AutoMigrate(&reps.Validator{})
+	_ = database.AutoMigrate(&reps.BlockVote{})
+	_ = database.AutoMigrate(&reps.Evidence{})
+	_ = database.AutoMigrate(&reps.SnapshotBase{})
+	_ = database.AutoMigrate(&reps.ValidatorChangeRecord{})
 
 	DB = database
 }
@@ -50,7 +50,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Create a blockchain by mining the genesis block",
+                "description": "Create a blockchain by mining the genesis block, paying the reward to the given address, and any allocations on top of it, e.g. to start a test network with funded addresses. Allocations can be to addresses without a wallet on this node. If GENESIS_FILE is set, its spec sets the chain id, the genesis difficulty, the block interval, the coinbase message and more allocations. Allocations are ignored if the blockchain already exists",
                 "tags": [
                     "Blocks"
                 ],
@@ -79,6 +79,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/representations.ReadableBlock"
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -88,21 +94,45 @@ const docTemplate = `{
                 }
             }
         },
-        "/blockchain/block": {
+        "/blockchain/accounts": {
+            "get": {
+                "description": "Get every account with its addresses and combined balance",
+                "tags": [
+                    "Accounts"
+                ],
+                "summary": "Get all accounts",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/representations.Account"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            },
             "post": {
-                "description": "Add a block to the end of the blockchain",
+                "description": "Create an empty named account. Addresses are then assigned to it, and its balance and sends span all of them",
                 "tags": [
-                    "Blocks"
+                    "Accounts"
                 ],
-                "summary": "Add a block",
+                "summary": "Create an account",
                 "parameters": [
                     {
-                        "description": "Mine block",
-                        "name": "BlockInput",
+                        "description": "Account name",
+                        "name": "CreateAccountInput",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/representations.CreateBlockInput"
+                            "$ref": "#/definitions/representations.CreateAccountInput"
                         }
                     }
                 ],
@@ -110,7 +140,7 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/representations.ReadableBlock"
+                            "$ref": "#/definitions/representations.Account"
                         }
                     },
                     "400": {
@@ -118,28 +148,31 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/handlers.HTTPError"
                         }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "$ref": "#/definitions/handlers.HTTPError"
-                        }
                     }
                 }
             }
         },
-        "/blockchain/block/genesis": {
+        "/blockchain/accounts/{name}": {
             "get": {
-                "description": "Get the genesis block on the blockchain",
+                "description": "Get an account's addresses and their combined balance",
                 "tags": [
-                    "Blocks"
+                    "Accounts"
+                ],
+                "summary": "Get an account",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Account name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get the genesis block",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/representations.ReadableBlock"
+                            "$ref": "#/definitions/representations.Account"
                         }
                     },
                     "404": {
@@ -151,22 +184,40 @@ const docTemplate = `{
                 }
             }
         },
-        "/blockchain/block/last": {
-            "get": {
-                "description": "Get the last block on the blockchain",
+        "/blockchain/accounts/{name}/addresses": {
+            "post": {
+                "description": "Add one of the node's wallets to an account. An address belongs to at most one account, and an account's signing addresses must share a signature algorithm",
                 "tags": [
-                    "Blocks"
+                    "Accounts"
+                ],
+                "summary": "Assign an address to an account",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Account name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Address to assign",
+                        "name": "AssignAddressInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.AssignAddressInput"
+                        }
+                    }
                 ],
-                "summary": "Get the last block",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/representations.ReadableBlock"
+                            "$ref": "#/definitions/representations.Account"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/handlers.HTTPError"
                         }
@@ -174,52 +225,73 @@ const docTemplate = `{
                 }
             }
         },
-        "/blockchain/block/{blockId}": {
-            "get": {
-                "description": "Get a block on the blockchain by block ID",
+        "/blockchain/accounts/{name}/send": {
+            "post": {
+                "description": "Send coins from any of an account's addresses and mine the transaction into a block. To can be an address book name. An optional fee or fee rate (coins per 1000 bytes) goes to the miner",
                 "tags": [
-                    "Blocks"
+                    "Accounts"
                 ],
-                "summary": "Get a block",
+                "summary": "Send from an account",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Block ID",
-                        "name": "blockId",
+                        "description": "Account name",
+                        "name": "name",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Recipient and amount",
+                        "name": "AccountSendInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.AccountSendInput"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
                             "$ref": "#/definitions/representations.ReadableBlock"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/handlers.HTTPError"
                         }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TxnVerificationError"
+                        }
                     }
                 }
             }
         },
-        "/blockchain/transactions": {
+        "/blockchain/addressbook": {
             "get": {
-                "description": "Get all transactions that exist on the blockchain",
+                "description": "Get every address book entry",
                 "tags": [
-                    "Transactions"
+                    "Address Book"
                 ],
-                "summary": "Get all transactions",
+                "summary": "Get the address book",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/representations.ReadableTransaction"
+                                "$ref": "#/definitions/representations.AddressBookEntry"
                             }
                         }
                     },
@@ -230,33 +302,33 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/blockchain/transactions/{transactionId}": {
-            "get": {
-                "description": "Get a transaction on the blockchain",
+            },
+            "post": {
+                "description": "Label an address with a human readable name, which can then be used in place of the address when sending coins. Saving an existing name updates its address",
                 "tags": [
-                    "Transactions"
+                    "Address Book"
                 ],
-                "summary": "Get a transaction",
+                "summary": "Add an address book entry",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Transaction ID",
-                        "name": "transactionId",
-                        "in": "path",
-                        "required": true
+                        "description": "Name and address",
+                        "name": "AddressBookInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.AddressBookInput"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/representations.ReadableTransaction"
+                            "$ref": "#/definitions/representations.AddressBookEntry"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/handlers.HTTPError"
                         }
@@ -264,40 +336,55 @@ const docTemplate = `{
                 }
             }
         },
-        "/blockchain/wallets": {
+        "/blockchain/addressbook/{name}": {
             "get": {
-                "description": "Get all wallets",
+                "description": "Get the address labelled with a name",
                 "tags": [
-                    "Wallets"
+                    "Address Book"
+                ],
+                "summary": "Get an address book entry",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get all wallets",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/representations.Wallet"
-                            }
+                            "$ref": "#/definitions/representations.AddressBookEntry"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/handlers.HTTPError"
                         }
                     }
                 }
             },
-            "post": {
-                "description": "Create a wallet to store an address and public / private key information",
+            "delete": {
+                "description": "Remove a name from the address book",
                 "tags": [
-                    "Wallets"
+                    "Address Book"
+                ],
+                "summary": "Delete an address book entry",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Create a wallet",
                 "responses": {
-                    "201": {
-                        "description": "address",
+                    "200": {
+                        "description": "message",
                         "schema": {
                             "type": "string"
                         }
@@ -311,25 +398,31 @@ const docTemplate = `{
                 }
             }
         },
-        "/blockchain/wallets/balances": {
+        "/blockchain/addresses/{address}/balance": {
             "get": {
-                "description": "Get the coin balances for each address on the blockchain",
+                "description": "Sum the unspent outputs of any address, not only wallets on the node. Confirmed is what's on the chain, pending is what unconfirmed transactions add or take away",
                 "tags": [
-                    "Wallets"
+                    "Addresses"
+                ],
+                "summary": "Get address balance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Address",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get coin balances",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/representations.AddressBalance"
-                            }
+                            "$ref": "#/definitions/representations.AddressBalanceSummary"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/handlers.HTTPError"
                         }
@@ -337,22 +430,40 @@ const docTemplate = `{
                 }
             }
         },
-        "/blockchain/wallets/{address}": {
+        "/blockchain/addresses/{address}/transactions": {
             "get": {
-                "description": "Get a wallet by address",
+                "description": "Get every transaction any address appears in as an input or output, oldest first, with the hash and height of its block, whether the address sent or received, and the net amount",
                 "tags": [
-                    "Wallets"
+                    "Addresses"
+                ],
+                "summary": "Get address history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Address",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get a wallet",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/representations.Wallet"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/representations.ReadableAddressTransaction"
+                            }
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/handlers.HTTPError"
                         }
@@ -360,150 +471,6101 @@ const docTemplate = `{
                 }
             }
         },
-        "/blockchain/wallets/{address}/balance": {
+        "/blockchain/addresses/{address}/utxos": {
             "get": {
-                "description": "Get the coin balance for an address on the blockchain",
+                "description": "Get every unspent output of any address, oldest first, with its value, the id and index of the transaction output it is, and its confirmations, so wallet software can build its own transactions. Coinbase outputs waiting on coinbase maturity aren't spendable yet",
                 "tags": [
-                    "Wallets"
+                    "Addresses"
+                ],
+                "summary": "Get address UTXOs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Address",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get coin balance",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "integer"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/representations.AddressUnspentOutput"
+                            }
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/handlers.HTTPError"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handlers.HTTPError": {
-            "type": "object",
-            "properties": {
-                "code": {
-                    "type": "integer",
-                    "example": 400
-                },
-                "message": {
-                    "type": "string",
-                    "example": "status bad request"
-                }
-            }
         },
-        "representations.AddressBalance": {
-            "type": "object",
-            "properties": {
-                "address": {
+        "/blockchain/admin/consolidate": {
+            "post": {
+                "description": "Queue a transaction in the mempool spending up to 100 of an address's outputs worth less than maxValue, smallest first, into a single output back to it, so later transactions from it need fewer inputs. It only goes ahead with at least 10 such outputs, and while the mempool is quiet unless forced. Without a fee or fee rate, it pays the low rate from the fee estimate",
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Consolidate unspent outputs",
+                "parameters": [
+                    {
+                        "description": "Address to consolidate",
+                        "name": "ConsolidateInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.ConsolidateInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/representations.ReadableTransaction"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TxnVerificationError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/admin/validators": {
+            "get": {
+                "description": "Get the validators of a BFT chain as of its last block, in the order they take turns proposing blocks, and every change made to them on the chain in the order they take effect, with the transaction and block each is on, the validator that signed it, and whether it's still scheduled or active",
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get validator schedule",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.ValidatorSchedule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Queue a transaction in the mempool adding an address to the validators of a BFT chain at a height after the next block, signed by authority, a validator with a wallet on this node. Without a height it takes effect 10 blocks after the next one. Once it's on a block the change is on the chain for every node, and the address takes its turn proposing blocks after the validators already in the set from that height on",
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Add validator",
+                "parameters": [
+                    {
+                        "description": "Validator to add, and who signs the change",
+                        "name": "ValidatorChangeInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.ValidatorChangeInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/representations.ReadableTransaction"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TxnVerificationError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/admin/validators/{address}": {
+            "delete": {
+                "description": "Queue a transaction in the mempool removing an address from the validators of a BFT chain at a height after the next block, signed by authority, a validator with a wallet on this node. Without a height it takes effect 10 blocks after the next one. From that height on, the address takes no more turns proposing blocks and its votes stop counting. The last validator can't be removed",
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Remove validator",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Validator to remove",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Who signs the change, and when it takes effect",
+                        "name": "ValidatorChangeInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.ValidatorChangeInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/representations.ReadableTransaction"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TxnVerificationError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/assets": {
+            "get": {
+                "description": "Get every registered asset, with the units of each issued on the chain so far",
+                "tags": [
+                    "Assets"
+                ],
+                "summary": "Get all assets",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/representations.Asset"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Register a token with a symbol of 1 to 12 capital letters or digits, and queue a transaction in the mempool issuing its first amount units to the issuer. An optional max supply caps the units that can ever be issued, and mintable lets the issuer issue more later. The transaction's fee is paid in coins from the issuer",
+                "tags": [
+                    "Assets"
+                ],
+                "summary": "Create an asset",
+                "parameters": [
+                    {
+                        "description": "Issuer, symbol and amount",
+                        "name": "AssetInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.CreateAssetInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/representations.Asset"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TxnVerificationError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/assets/{assetId}": {
+            "get": {
+                "description": "Get an asset, with the units issued on the chain so far",
+                "tags": [
+                    "Assets"
+                ],
+                "summary": "Get an asset",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Asset ID",
+                        "name": "assetId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.Asset"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/assets/{assetId}/issue": {
+            "post": {
+                "description": "Queue a transaction in the mempool issuing amount more units of a mintable asset to its issuer, up to its max supply. The transaction's fee is paid in coins from the issuer",
+                "tags": [
+                    "Assets"
+                ],
+                "summary": "Issue more of an asset",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Asset ID",
+                        "name": "assetId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Amount to issue",
+                        "name": "IssueAssetInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.IssueAssetInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/representations.ReadableTransaction"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TxnVerificationError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/assets/{assetId}/transfer": {
+            "post": {
+                "description": "Create and sign a transaction sending amount units of an asset from one address to another, and queue it in the mempool. The transaction's fee is paid in coins from the sender",
+                "tags": [
+                    "Assets"
+                ],
+                "summary": "Transfer an asset",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Asset ID",
+                        "name": "assetId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "From, to and amount",
+                        "name": "TransferAssetInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.TransferAssetInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/representations.ReadableTransaction"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TxnVerificationError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/block": {
+            "post": {
+                "description": "Create a transaction paying either to and amount, or every one of recipients, queue it in the mempool, and mine a block from the pending transactions paying the highest fee rates, with from collecting the reward. A block holds at most MAX_BLOCK_TXNS transactions besides the coinbase, so a transaction outbid by others, or with a lock time that hasn't passed yet, stays pending; txnId is returned to follow it. An optional fee or fee rate (coins per 1000 bytes) goes to the miner, and an optional memo of up to 256 bytes is stored with it on-chain. From and to can be address book names instead of addresses",
+                "tags": [
+                    "Blocks"
+                ],
+                "summary": "Add a block",
+                "parameters": [
+                    {
+                        "description": "Mine block",
+                        "name": "BlockInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.CreateBlockInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/representations.ReadableBlock"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TxnVerificationError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/block/genesis": {
+            "get": {
+                "description": "Get the genesis block on the blockchain",
+                "tags": [
+                    "Blocks"
+                ],
+                "summary": "Get the genesis block",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.ReadableBlock"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/block/height/{height}": {
+            "get": {
+                "description": "Get the block at a height on the blockchain. The genesis block is at height 0",
+                "tags": [
+                    "Blocks"
+                ],
+                "summary": "Get a block by height",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Height",
+                        "name": "height",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.ReadableBlock"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/block/last": {
+            "get": {
+                "description": "Get the last block on the blockchain",
+                "tags": [
+                    "Blocks"
+                ],
+                "summary": "Get the last block",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.ReadableBlock"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/block/{blockId}": {
+            "get": {
+                "description": "Get a block on the blockchain by block ID",
+                "tags": [
+                    "Blocks"
+                ],
+                "summary": "Get a block",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Block ID",
+                        "name": "blockId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.ReadableBlock"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/block/{blockId}/header": {
+            "get": {
+                "description": "Get the header fields of a block by block ID, without its transactions, for light clients",
+                "tags": [
+                    "Blocks"
+                ],
+                "summary": "Get a block header",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Block ID",
+                        "name": "blockId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.BlockHeader"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/block/{blockId}/proof/{txnId}": {
+            "get": {
+                "description": "Get the merkle branch from a transaction up to the merkle root of a block it's on, so light clients holding only the block header can check it's there. Hash leafData to get leafHash, then hash it with each step in turn, the step's hash going on the side it says, to get the merkle root",
+                "tags": [
+                    "Blocks"
+                ],
+                "summary": "Get a merkle proof",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Block ID",
+                        "name": "blockId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Transaction ID",
+                        "name": "txnId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.MerkleBranch"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/block/{blockId}/votes": {
+            "get": {
+                "description": "Get the validators' votes for a block on a BFT chain, how many votes finalize it, and whether it's final",
+                "tags": [
+                    "Blocks"
+                ],
+                "summary": "Get votes for a block",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Block ID",
+                        "name": "blockId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.BlockFinality"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Add a validator's vote for a block on a BFT chain, either signed here with the wallet of address, or signed elsewhere, with the hex publicKey, sigAlgorithm and hex signature of the sha256 of \"Blockchain Block Vote:\\n\" followed by the block's hash. Each validator votes for one block at each height. Once more than two thirds of the validators voted for a block it's final, and can't be reorganized away",
+                "tags": [
+                    "Blocks"
+                ],
+                "summary": "Vote for a block",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Block ID",
+                        "name": "blockId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Validator address, or a vote signed elsewhere",
+                        "name": "VoteInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.VoteInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.BlockFinality"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/blocks": {
+            "get": {
+                "description": "Get up to count blocks from height from on, lowest first, to page through the chain. count defaults to 20 and can be at most 100. Past the last block there are none",
+                "tags": [
+                    "Blocks"
+                ],
+                "summary": "Get blocks by height",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Height to start from",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of blocks",
+                        "name": "count",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/representations.ReadableBlock"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Add a solved block mined elsewhere, e.g. relayed by a peer, as it's serialized on the chain with base64 hashes. Its transactions are verified and taken out of the mempool. A block whose parent isn't known yet is held as an orphan, with 202, and added once the parent shows up. One building on a block other than the last is stored on a side branch, also with 202, and once that branch has more work than the chain from where they fork the chain is reorganized onto it: the blocks above the fork are taken off, returned as disconnected, and their transactions go back in the mempool unless the branch has them too. Blocks added are returned in order, including orphans that were waiting on it",
+                "tags": [
+                    "Blocks"
+                ],
+                "summary": "Receive a block",
+                "parameters": [
+                    {
+                        "description": "Solved block",
+                        "name": "Block",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.Block"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/representations.ReadableBlock"
+                            }
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/representations.ReadableBlock"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/blocks/finalized/latest": {
+            "get": {
+                "description": "Get the last block that can't be reorganized away, so neither it nor anything before it will change: the last one more than two thirds of the validators voted for on a BFT chain, or the last one with FINALITY_DEPTH confirmations on other chains. Blocks after it are tentative",
+                "tags": [
+                    "Blocks"
+                ],
+                "summary": "Get the last final block",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.ReadableBlock"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/blocks/stale": {
+            "get": {
+                "description": "Get up to count solved blocks, latest first, that built on a block on the chain but lost to another block on the same parent, along with the hash of the block that won and how many there have been in all. count defaults to 20 and can be at most 100",
+                "tags": [
+                    "Blocks"
+                ],
+                "summary": "Get stale blocks",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of stale blocks",
+                        "name": "count",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/representations.StaleBlock"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/evidence": {
+            "get": {
+                "description": "Get every validator caught signing two different blocks at the same height, as their proposer or with votes for both, latest first. On a proof of stake chain the stake it had bonded by then is burnt by a slashing transaction, which is pending until it's on the chain. Validators of a BFT chain have no stake, so their equivocations are only recorded",
+                "tags": [
+                    "Staking"
+                ],
+                "summary": "Get evidence of equivocation",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/representations.Evidence"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/evidence/{evidenceId}": {
+            "get": {
+                "description": "Get evidence of a validator signing two different blocks at the same height, and whether its stake was slashed for it",
+                "tags": [
+                    "Staking"
+                ],
+                "summary": "Get evidence of an equivocation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Evidence ID",
+                        "name": "evidenceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.Evidence"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/fees/estimate": {
+            "get": {
+                "description": "Suggest low, medium and high fee rates, in coins per 1000 bytes, from what transactions in recent blocks paid",
+                "tags": [
+                    "Fees"
+                ],
+                "summary": "Estimate fees",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.FeeEstimate"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/headers": {
+            "get": {
+                "description": "Get the headers of up to count blocks from height from on, lowest first, without their transactions, for light clients following the chain. count defaults to 20 and can be at most 2000. Past the last block there are none",
+                "tags": [
+                    "Blocks"
+                ],
+                "summary": "Get block headers by height",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Height to start from",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of headers",
+                        "name": "count",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/representations.BlockHeader"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/info": {
+            "get": {
+                "description": "Get the height and hash of the last block, the difficulty and reward of the next one, the height the reward next halves at, coins paid out in rewards so far, and the chain parameters",
+                "tags": [
+                    "Blocks"
+                ],
+                "summary": "Get chain info",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.ChainInfo"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/mempool": {
+            "get": {
+                "description": "Get every transaction waiting to be mined, oldest first, with its fee, fee rate (coins per 1000 bytes) and how many seconds it's been waiting",
+                "tags": [
+                    "Mempool"
+                ],
+                "summary": "Get the mempool",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/representations.ReadableMempoolEntry"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/mempool/stats": {
+            "get": {
+                "description": "Get how many transactions are pending with their total fees and size, the mempool's limits, and how many transactions have been evicted since the node started, for waiting too long, for paying too little when the mempool was full, or for being replaced by a higher fee",
+                "tags": [
+                    "Mempool"
+                ],
+                "summary": "Get mempool stats",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.MempoolStats"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/mempool/{txnId}": {
+            "get": {
+                "description": "Get a transaction waiting to be mined, with its fee, fee rate (coins per 1000 bytes) and how many seconds it's been waiting",
+                "tags": [
+                    "Mempool"
+                ],
+                "summary": "Get a mempool transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Transaction ID",
+                        "name": "txnId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.ReadableMempoolEntry"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/metrics": {
+            "get": {
+                "description": "Get counters of what the node has seen since it started: reorgs, how many of them took more blocks off the chain than REORG_ALERT_DEPTH and the deepest one, and chain events delivered to WEBHOOK_URLS or not",
+                "tags": [
+                    "Blockchain"
+                ],
+                "summary": "Get metrics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.Metrics"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/mine": {
+            "post": {
+                "description": "Mine a block from the pending transactions paying the highest fee rates, with a coinbase paying the reward and their fees to miner. The coinbase carries coinbaseMessage, up to 100 bytes, followed by a random extranonce, or the node's COINBASE_MESSAGE without one. On a proof of stake or BFT chain nothing is mined, the block is signed by miner, which has to be the validator elected for it, or whose turn it is",
+                "tags": [
+                    "Blocks"
+                ],
+                "summary": "Mine pending transactions",
+                "parameters": [
+                    {
+                        "description": "Mine pending transactions",
+                        "name": "MineInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.MineInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/representations.ReadableBlock"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TxnVerificationError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/mine/submit": {
+            "post": {
+                "description": "Add the block for a template from GET /blockchain/mine/template, with the nounce that solves it. A timestamp replaces the template's, for miners that run through every nounce, as long as it's after the median timestamp of the 11 blocks before it and no more than 2 hours ahead of the node's clock. Templates that no longer build on the last block are rejected, and the blocks solving them recorded as stale",
+                "tags": [
+                    "Miner"
+                ],
+                "summary": "Submit a solved block",
+                "parameters": [
+                    {
+                        "description": "Solved template",
+                        "name": "SubmitBlockInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.SubmitBlockInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/representations.ReadableBlock"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/mine/template": {
+            "get": {
+                "description": "Get the block the node would mine from the mempool next, for an external miner or pool to solve: its header fields, transactions, with a coinbase paying the reward and fees to miner first, and the target its hash has to be under. The hash is taken with hashAlgorithm over headerPrefix followed by the nounce in decimal digits. A template can be submitted until it has been, or 16 newer ones have been handed out. Once a block is added on top of the one it builds on, it's rejected, but if solved it's recorded at GET /blockchain/blocks/stale",
+                "tags": [
+                    "Miner"
+                ],
+                "summary": "Get a block template",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Address the coinbase pays",
+                        "name": "miner",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Message the coinbase carries, up to 100 bytes, in place of the node's own",
+                        "name": "message",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.BlockTemplate"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TxnVerificationError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/miner": {
+            "get": {
+                "description": "Get whether the background miner is running, paused or stopped, how many goroutines it mines with and the percent of the time they spend hashing, who it pays, how many blocks it's mined since it was started, the last of them, and why its last attempt failed if it did",
+                "tags": [
+                    "Miner"
+                ],
+                "summary": "Get miner status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.MinerStatus"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/miner/pause": {
+            "post": {
+                "description": "Stop producing blocks without stopping the background miner, until it's resumed. A block it's already mining is finished first. Blocks mined before the pause still count towards blocksMined",
+                "tags": [
+                    "Miner"
+                ],
+                "summary": "Pause the miner",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.MinerStatus"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/miner/payout": {
+            "put": {
+                "description": "Pay the coinbase of every block the background miner mines from now on to miner, without restarting it. A block it's already mining still pays the old address. The miner has to be running or paused",
+                "tags": [
+                    "Miner"
+                ],
+                "summary": "Change the miner's payout address",
+                "parameters": [
+                    {
+                        "description": "Address to pay",
+                        "name": "MinerInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.MinerInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.MinerStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/miner/resume": {
+            "post": {
+                "description": "Pick block production back up after the background miner was paused, paying whatever address it pays now",
+                "tags": [
+                    "Miner"
+                ],
+                "summary": "Resume the miner",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.MinerStatus"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/miner/start": {
+            "post": {
+                "description": "Start mining blocks from the mempool in the background, highest fee rates first, with every coinbase paying the reward and fees to miner. Blocks are mined for as long as transactions are pending, until the miner is stopped",
+                "tags": [
+                    "Miner"
+                ],
+                "summary": "Start the miner",
+                "parameters": [
+                    {
+                        "description": "Address to pay",
+                        "name": "MinerInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.MinerInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.MinerStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/miner/stop": {
+            "post": {
+                "description": "Stop the background miner. A block it's already mining is finished first",
+                "tags": [
+                    "Miner"
+                ],
+                "summary": "Stop the miner",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.MinerStatus"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/multisig": {
+            "post": {
+                "description": "Create an address whose coins can only be spent with signatures from requiredSigs of the given public keys",
+                "tags": [
+                    "Multisig"
+                ],
+                "summary": "Create a multisig address",
+                "parameters": [
+                    {
+                        "description": "Required signatures and public keys",
+                        "name": "MultisigInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.CreateMultisigInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/representations.MultisigAddress"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/multisig/transactions/{txnId}": {
+            "get": {
+                "description": "Get a multisig transaction and the signatures collected so far",
+                "tags": [
+                    "Multisig"
+                ],
+                "summary": "Get a multisig transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Transaction ID",
+                        "name": "txnId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.MultisigTransaction"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/multisig/transactions/{txnId}/broadcast": {
+            "post": {
+                "description": "Mine a multisig transaction into a new block once it has enough signatures",
+                "tags": [
+                    "Multisig"
+                ],
+                "summary": "Broadcast a multisig transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Transaction ID",
+                        "name": "txnId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Address receiving the block reward",
+                        "name": "BroadcastInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.BroadcastMultisigTxnInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/representations.ReadableBlock"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TxnVerificationError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/multisig/transactions/{txnId}/signatures": {
+            "post": {
+                "description": "Add the signature of one of the multisig keys, held by a wallet on this node",
+                "tags": [
+                    "Multisig"
+                ],
+                "summary": "Sign a multisig transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Transaction ID",
+                        "name": "txnId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Signing wallet address",
+                        "name": "SignInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.SignMultisigTxnInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.MultisigTransaction"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/multisig/{address}": {
+            "get": {
+                "description": "Get a multisig address with its public keys and balance",
+                "tags": [
+                    "Multisig"
+                ],
+                "summary": "Get a multisig address",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Multisig address",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.MultisigAddress"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/multisig/{address}/transactions": {
+            "post": {
+                "description": "Create an unsigned transaction spending from a multisig address. Signatures are added to it until the threshold is reached",
+                "tags": [
+                    "Multisig"
+                ],
+                "summary": "Create a multisig transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Multisig address",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Recipient and amount",
+                        "name": "TxnInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.CreateMultisigTxnInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/representations.MultisigTransaction"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/nfts": {
+            "post": {
+                "description": "Register a non-fungible token for a payload, given as the hex sha256 hash of it, and queue a transaction in the mempool minting its single unit to the issuer. Its id commits to the hash, so the metadata can't change once it's minted. The transaction's fee is paid in coins from the issuer",
+                "tags": [
+                    "NFTs"
+                ],
+                "summary": "Mint an NFT",
+                "parameters": [
+                    {
+                        "description": "Issuer and metadata hash",
+                        "name": "NFTInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.CreateNFTInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/representations.Asset"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TxnVerificationError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/nfts/{nftId}": {
+            "get": {
+                "description": "Get an NFT with its current owner, and every transaction on the chain that minted or moved it, oldest first",
+                "tags": [
+                    "NFTs"
+                ],
+                "summary": "Get an NFT",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "NFT ID",
+                        "name": "nftId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.NFTProvenance"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/nfts/{nftId}/transfer": {
+            "post": {
+                "description": "Create and sign a transaction sending an NFT from its current owner to another address, and queue it in the mempool. The owner must be a wallet on this node, and pays the transaction's fee in coins",
+                "tags": [
+                    "NFTs"
+                ],
+                "summary": "Transfer an NFT",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "NFT ID",
+                        "name": "nftId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Recipient",
+                        "name": "TransferNFTInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.TransferNFTInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/representations.ReadableTransaction"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TxnVerificationError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/output/{txnId}/{index}/status": {
+            "get": {
+                "description": "Get whether a transaction output is unspent, spent (and by which transaction) or nonexistent",
+                "tags": [
+                    "Transactions"
+                ],
+                "summary": "Get output status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Transaction ID",
+                        "name": "txnId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Output index",
+                        "name": "index",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.OutputStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/params": {
+            "get": {
+                "description": "Get the parameters the blockchain is running with, e.g. the network byte used in addresses",
+                "tags": [
+                    "Blocks"
+                ],
+                "summary": "Get chain parameters",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.ChainParams"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/peers": {
+            "get": {
+                "description": "Get the nodes this one is connected to, whether they connected to it or it connected to them, oldest connection first, with how far their chain goes as far as this node knows",
+                "tags": [
+                    "Peers"
+                ],
+                "summary": "Get peers",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/representations.Peer"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Connect to the node taking connections from peers at a host:port address. The two exchange versions in the background, and the peer is dropped if it's on another chain",
+                "tags": [
+                    "Peers"
+                ],
+                "summary": "Connect to a peer",
+                "parameters": [
+                    {
+                        "description": "Address of the peer",
+                        "name": "ConnectPeerInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.ConnectPeerInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/representations.Peer"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/peers/{peerId}": {
+            "delete": {
+                "description": "Drop the connection to a peer. If this node connected to it, it doesn't connect to it again on its own unless another peer tells it about its address",
+                "tags": [
+                    "Peers"
+                ],
+                "summary": "Disconnect from a peer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Peer ID",
+                        "name": "peerId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/schedules": {
+            "get": {
+                "description": "Get every recurring payment, cancelled ones included, with when each is next due and how its last run went",
+                "tags": [
+                    "Schedules"
+                ],
+                "summary": "Get scheduled payments",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/representations.Schedule"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Have the node pay amount from one address to another every interval, creating the transaction and queueing it in the mempool on its own. Interval is @every and a duration such as 36h, or @hourly, @daily or @weekly. The first payment is made one interval from now unless startAt says otherwise. A failed payment, e.g. while the wallet file is locked, is skipped until the next one. Address book names can be used in place of addresses",
+                "tags": [
+                    "Schedules"
+                ],
+                "summary": "Schedule a recurring payment",
+                "parameters": [
+                    {
+                        "description": "Payment and interval",
+                        "name": "ScheduleInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.ScheduleInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/representations.Schedule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/schedules/{scheduleId}": {
+            "get": {
+                "description": "Get a recurring payment, with when it's next due and how its last run went",
+                "tags": [
+                    "Schedules"
+                ],
+                "summary": "Get a scheduled payment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Schedule ID",
+                        "name": "scheduleId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.Schedule"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Stop making a recurring payment. Payments already queued aren't affected, and the schedule is kept with a cancelled status",
+                "tags": [
+                    "Schedules"
+                ],
+                "summary": "Cancel a scheduled payment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Schedule ID",
+                        "name": "scheduleId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.Schedule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/snapshot": {
+            "get": {
+                "description": "Get a snapshot of the chain at its last block, for a new node to start from with SNAPSHOT_FILE instead of syncing from the genesis block: the chain params, the headers up to the block the blocks after it are validated against, and the UTXO set as of it along with its hash. The new node only takes it if the block's hash is the SNAPSHOT_HASH its operator trusts",
+                "tags": [
+                    "Blockchain"
+                ],
+                "summary": "Get a chain snapshot",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.ChainSnapshot"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/stake": {
+            "post": {
+                "description": "Queue a transaction in the mempool bonding amount of an address's coins as its stake, on a proof of stake chain. Once it's on a block the address is a validator, elected to produce blocks with odds in proportion to its stake. Staked coins aren't part of its balance, and aren't spent by its transactions until they're unstaked",
+                "tags": [
+                    "Staking"
+                ],
+                "summary": "Stake coins",
+                "parameters": [
+                    {
+                        "description": "Address and amount to stake",
+                        "name": "StakeInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.StakeInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/representations.ReadableTransaction"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TxnVerificationError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/stats/blocks": {
+            "get": {
+                "description": "Get the average time between blocks in seconds, their average difficulty and an estimate of the network's hash rate in hashes per second, over each window of recent blocks, along with the difficulty the next block needs. windows is comma separated numbers of blocks, from 2 to 10000 and at most 5 of them, and defaults to 10,100,1000. A window longer than the chain covers all of it",
+                "tags": [
+                    "Blocks"
+                ],
+                "summary": "Get block stats",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma separated numbers of recent blocks",
+                        "name": "windows",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.BlockStats"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/sync": {
+            "get": {
+                "description": "Get the height of the node's chain against the best one any peer is known to have. A node that's behind downloads the headers of the chain of the peer furthest ahead first, then, if that chain has more work than its own, the blocks on it from every peer that has them, validating and adding each like any other received block. A peer that stops sending what it was asked for is dropped and the sync carries on from the others",
+                "tags": [
+                    "Peers"
+                ],
+                "summary": "Get sync status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.SyncStatus"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/tips": {
+            "get": {
+                "description": "Get the last block of the chain, with status active, and of every side branch the node has stored, with status side, along with the height each branch forks off the chain at and how many blocks it has since. Side branches come from blocks received that build on a block other than the last, or that a reorg took off the chain",
+                "tags": [
+                    "Blocks"
+                ],
+                "summary": "Get chain tips",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/representations.ChainTip"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/transactions": {
+            "get": {
+                "description": "Get all transactions that exist on the blockchain",
+                "tags": [
+                    "Transactions"
+                ],
+                "summary": "Get all transactions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/representations.ReadableTransaction"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create and sign a transaction paying either to and amount, or every one of recipients, and queue it in the mempool until a block is mined. An optional fee or fee rate (coins per 1000 bytes) goes to the miner, an optional memo of up to 256 bytes is stored with it on-chain, an optional lock time keeps it off the chain until that block height, or from 500000000 up that unix time in seconds, and replaceable lets a later transaction spending the same inputs replace it for a higher fee while it's pending. From and to can be address book names instead of addresses",
+                "tags": [
+                    "Transactions"
+                ],
+                "summary": "Create a transaction",
+                "parameters": [
+                    {
+                        "description": "Create transaction",
+                        "name": "TransactionInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.CreateTransactionInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/representations.ReadableTransaction"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TxnVerificationError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/transactions/batch": {
+            "post": {
+                "description": "Queue several transfers in the mempool in one call. Transfers from the same address are combined into one transaction, with the optional fee or fee rate (coins per 1000 bytes) applying to each transaction. Each transfer succeeds or fails on its own, e.g. once an address can't cover it on top of its earlier transfers. From and to can be address book names instead of addresses",
+                "tags": [
+                    "Transactions"
+                ],
+                "summary": "Create a batch of transactions",
+                "parameters": [
+                    {
+                        "description": "Create batch",
+                        "name": "BatchInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.CreateBatchInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/representations.BatchResult"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/transactions/raw": {
+            "post": {
+                "description": "Queue an already signed transaction in the mempool, so wallets can build and sign transactions offline and only broadcast through this node. The transaction is in the JSON form the node stores it in, hex or base64 encoded",
+                "tags": [
+                    "Transactions"
+                ],
+                "summary": "Submit a raw transaction",
+                "parameters": [
+                    {
+                        "description": "Raw transaction",
+                        "name": "RawTransactionInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.RawTransactionInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/representations.ReadableTransaction"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TxnVerificationError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/transactions/validate": {
+            "post": {
+                "description": "Run every check a signed transaction goes through before it's queued, i.e. balance, fee, signatures, lock time and inputs already spent on the chain or by a pending transaction, without queueing or persisting anything. The transaction is in the same JSON form the node stores it in. Returns its fee and the outputs it would spend",
+                "tags": [
+                    "Transactions"
+                ],
+                "summary": "Validate a transaction",
+                "parameters": [
+                    {
+                        "description": "Validate transaction",
+                        "name": "ValidateInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.ValidateTransactionInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.TxnValidation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TxnVerificationError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/transactions/{transactionId}": {
+            "get": {
+                "description": "Get a transaction on the blockchain, with the hash and height of the block it's on",
+                "tags": [
+                    "Transactions"
+                ],
+                "summary": "Get a transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Transaction ID",
+                        "name": "transactionId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.ReadableConfirmedTransaction"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/transactions/{transactionId}/receipt": {
+            "get": {
+                "description": "Get the hash, height and position of a confirmed transaction's block, with a merkle proof from the transaction to the block's merkle root. Hashing the version, merkle root, prevHash, timestamp and nounce, numbers in decimal digits, with the chain's hash algorithm from /params gives the block hash. Blocks from before versions have version 0, which is left out",
+                "tags": [
+                    "Transactions"
+                ],
+                "summary": "Get a transaction receipt",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Transaction ID",
+                        "name": "transactionId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.TxnReceipt"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/transactions/{transactionId}/status": {
+            "get": {
+                "description": "Get whether a transaction is pending in the mempool, confirmed on a block or not found. Confirmed transactions come with their block and how many blocks are built on top of it",
+                "tags": [
+                    "Transactions"
+                ],
+                "summary": "Get transaction status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Transaction ID",
+                        "name": "transactionId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.TxnStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/unstake": {
+            "post": {
+                "description": "Queue a transaction in the mempool unbonding amount of an address's stake back into its balance, less the fee, and bonding the rest again. An amount of 0 unbonds all of it",
+                "tags": [
+                    "Staking"
+                ],
+                "summary": "Unstake coins",
+                "parameters": [
+                    {
+                        "description": "Address and amount to unstake",
+                        "name": "StakeInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.StakeInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/representations.ReadableTransaction"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TxnVerificationError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/validators": {
+            "get": {
+                "description": "Get every address with coins staked on a proof of stake chain as of the last block, most stake first, with how much each has bonded and its share of all of it, and which address is elected to produce the next block",
+                "tags": [
+                    "Staking"
+                ],
+                "summary": "Get validators",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.ValidatorSet"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/verify": {
+            "post": {
+                "description": "Check that a message was signed by the key of an address. The address doesn't need to be a wallet on the node",
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Verify a message",
+                "parameters": [
+                    {
+                        "description": "Address, message and signature",
+                        "name": "VerifyMessageInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.VerifyMessageInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/versionbits": {
+            "get": {
+                "description": "Get how many of the last window blocks signal with each version bit, i.e. are mined by nodes ready for the rule change the bit stands for. Only versions with the top bits 001 signal. window defaults to 100 and can be at most 2016. Nodes signal with the bits in SIGNAL_BITS",
+                "tags": [
+                    "Blocks"
+                ],
+                "summary": "Get version bit signals",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of recent blocks",
+                        "name": "window",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.VersionBitsStats"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/wallets": {
+            "get": {
+                "description": "Get all wallets",
+                "tags": [
+                    "Wallets"
+                ],
+                "summary": "Get all wallets",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/representations.Wallet"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a wallet to store an address and public / private key information",
+                "tags": [
+                    "Wallets"
+                ],
+                "summary": "Create a wallet",
+                "parameters": [
+                    {
+                        "description": "Optional signature algorithm, ecdsa-p256 or ed25519",
+                        "name": "WalletInput",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/representations.CreateWalletInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/representations.Wallet"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/wallets/backup": {
+            "get": {
+                "description": "Get an encrypted archive of every wallet, private key and HD wallet seed on the node. It's encrypted with the wallet file passphrase, which is needed to restore it",
+                "tags": [
+                    "Wallets"
+                ],
+                "summary": "Back up wallets",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.WalletBackup"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/wallets/balances": {
+            "get": {
+                "description": "Get the coin balances for each address on the blockchain",
+                "tags": [
+                    "Wallets"
+                ],
+                "summary": "Get coin balances",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/representations.AddressBalance"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/wallets/hd": {
+            "post": {
+                "description": "Create an HD wallet from a new BIP39 mnemonic and derive its first address. The mnemonic is only shown once, write it down to be able to recover the wallet",
+                "tags": [
+                    "Wallets"
+                ],
+                "summary": "Create an HD wallet",
+                "parameters": [
+                    {
+                        "description": "Optional BIP39 passphrase",
+                        "name": "HDWalletInput",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/representations.CreateHDWalletInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/representations.HDWallet"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/wallets/hd/recover": {
+            "post": {
+                "description": "Restore an HD wallet and all of its used addresses from its BIP39 mnemonic",
+                "tags": [
+                    "Wallets"
+                ],
+                "summary": "Recover an HD wallet",
+                "parameters": [
+                    {
+                        "description": "Mnemonic and optional passphrase",
+                        "name": "RecoverInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.RecoverHDWalletInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.HDWallet"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/wallets/hd/{hdWalletId}": {
+            "get": {
+                "description": "Get an HD wallet and every address derived from it",
+                "tags": [
+                    "Wallets"
+                ],
+                "summary": "Get an HD wallet",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "HD wallet ID",
+                        "name": "hdWalletId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.HDWallet"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/wallets/hd/{hdWalletId}/addresses": {
+            "post": {
+                "description": "Derive the next receiving address of an HD wallet",
+                "tags": [
+                    "Wallets"
+                ],
+                "summary": "Derive an HD wallet address",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "HD wallet ID",
+                        "name": "hdWalletId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "address",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/wallets/import": {
+            "post": {
+                "description": "Import a private key in Wallet Import Format into the wallet file, creating a wallet for it",
+                "tags": [
+                    "Wallets"
+                ],
+                "summary": "Import a private key",
+                "parameters": [
+                    {
+                        "description": "WIF encoded private key",
+                        "name": "WIFInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.ImportWIFInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/representations.Wallet"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/wallets/lock": {
+            "post": {
+                "description": "Forget the decrypted private keys straight away. Sending coins fails until the wallet file is unlocked again",
+                "tags": [
+                    "Wallets"
+                ],
+                "summary": "Lock the wallet file",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/wallets/paper": {
+            "post": {
+                "description": "Generate a key pair and return its address and WIF private key, optionally as PNG QR codes, to print and keep offline. Nothing is stored on the node",
+                "tags": [
+                    "Wallets"
+                ],
+                "summary": "Create a paper wallet",
+                "parameters": [
+                    {
+                        "description": "Optional signature algorithm, and whether to include QR codes",
+                        "name": "PaperWalletInput",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/representations.CreatePaperWalletInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/representations.PaperWallet"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/wallets/pubkey": {
+            "post": {
+                "description": "Create a wallet for a public key whose private key is held by the remote signer at SIGNER_URL, e.g. in an HSM. Transactions from it are signed by the remote signer",
+                "tags": [
+                    "Wallets"
+                ],
+                "summary": "Import a public key",
+                "parameters": [
+                    {
+                        "description": "Hex encoded public key and its signature algorithm",
+                        "name": "PublicKeyInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.ImportPublicKeyInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/representations.Wallet"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/wallets/restore": {
+            "post": {
+                "description": "Load every wallet in a backup onto the node. Wallets that already exist are left as they are",
+                "tags": [
+                    "Wallets"
+                ],
+                "summary": "Restore wallets",
+                "parameters": [
+                    {
+                        "description": "Backup and the wallet file passphrase it was made with",
+                        "name": "RestoreInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.RestoreWalletsInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/wallets/unlock": {
+            "post": {
+                "description": "Decrypt the private keys in the wallet file into memory, so coins can be sent. It locks itself again once the ttl is up",
+                "tags": [
+                    "Wallets"
+                ],
+                "summary": "Unlock the wallet file",
+                "parameters": [
+                    {
+                        "description": "Wallet file passphrase and optional ttl",
+                        "name": "UnlockInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.UnlockWalletInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "expiresAt",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/wallets/vanity": {
+            "post": {
+                "description": "Generate key pairs in parallel until one's address starts with the given prefix, and save it as a wallet. Each extra character makes the search about 58 times longer",
+                "tags": [
+                    "Wallets"
+                ],
+                "summary": "Create a vanity wallet",
+                "parameters": [
+                    {
+                        "description": "Address prefix, and optional signature algorithm, timeout and worker count",
+                        "name": "VanityInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.CreateVanityWalletInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/representations.Wallet"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "408": {
+                        "description": "Request Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/wallets/watch": {
+            "post": {
+                "description": "Register an address without its private key, e.g. cold storage, so its balance and transactions can be looked up. Watch-only addresses can't send coins",
+                "tags": [
+                    "Wallets"
+                ],
+                "summary": "Watch an address",
+                "parameters": [
+                    {
+                        "description": "Address to watch",
+                        "name": "WatchInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.WatchAddressInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/representations.Wallet"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/wallets/{address}": {
+            "get": {
+                "description": "Get a wallet by address",
+                "tags": [
+                    "Wallets"
+                ],
+                "summary": "Get a wallet",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Wallet address",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/representations.Wallet"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/wallets/{address}/balance": {
+            "get": {
+                "description": "Get the coin balance for an address on the blockchain",
+                "tags": [
+                    "Wallets"
+                ],
+                "summary": "Get coin balance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Wallet address",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/wallets/{address}/sign": {
+            "post": {
+                "description": "Sign an arbitrary message with the key of a wallet on the node, to prove ownership of the address off-chain",
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Sign a message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Wallet address",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Message to sign",
+                        "name": "SignMessageInput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/representations.SignMessageInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "signature",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/wallets/{address}/transactions": {
+            "get": {
+                "description": "Get every transaction any address appears in as an input or output, oldest first, with the hash and height of its block, whether the address sent or received, and the net amount",
+                "tags": [
+                    "Addresses"
+                ],
+                "summary": "Get address history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Address",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/representations.ReadableAddressTransaction"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/wallets/{address}/wif": {
+            "get": {
+                "description": "Export the private key of a wallet in Wallet Import Format, to use it with other tools. Anyone with this key can spend the wallet's coins",
+                "tags": [
+                    "Wallets"
+                ],
+                "summary": "Export a private key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Wallet address",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "wif",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HTTPError"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "handlers.HTTPError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 400
+                },
+                "message": {
+                    "type": "string",
+                    "example": "status bad request"
+                }
+            }
+        },
+        "handlers.TxnVerificationError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 422
+                },
+                "inputIndex": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "message": {
+                    "type": "string",
+                    "example": "invalid transaction 4dc4..., input 0: signature could not be verified"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "invalid_signature"
+                },
+                "txnId": {
+                    "type": "string",
+                    "example": "4dc45ed831a7370e80366d63605841466e544a65277f96fa6a2403f97edd821c"
+                }
+            }
+        },
+        "representations.Account": {
+            "type": "object",
+            "properties": {
+                "addresses": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "balance": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "sigAlgorithm": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.AccountSendInput": {
+            "type": "object",
+            "required": [
+                "amount",
+                "to"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "coinSelection": {
+                    "type": "string",
+                    "enum": [
+                        "all",
+                        "largest-first",
+                        "smallest-first",
+                        "branch-and-bound"
+                    ]
+                },
+                "fee": {
+                    "type": "integer"
+                },
+                "feeRate": {
+                    "type": "integer"
+                },
+                "lockTime": {
+                    "type": "integer"
+                },
+                "memo": {
+                    "type": "string"
+                },
+                "replaceable": {
+                    "type": "boolean"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.AddressBalance": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "balance": {
+                    "type": "integer"
+                },
+                "publicKey": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.AddressBalanceSummary": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "assets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/representations.AssetBalance"
+                    }
+                },
+                "confirmed": {
+                    "type": "integer"
+                },
+                "pending": {
+                    "type": "integer"
+                },
+                "staked": {
+                    "type": "integer"
+                }
+            }
+        },
+        "representations.AddressBookEntry": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.AddressBookInput": {
+            "type": "object",
+            "required": [
+                "address",
+                "name"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.AddressUnspentOutput": {
+            "type": "object",
+            "properties": {
+                "assetId": {
+                    "type": "string"
+                },
+                "coinbase": {
+                    "type": "boolean"
+                },
+                "confirmations": {
+                    "type": "integer"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "pubKeyHash": {
+                    "type": "string"
+                },
+                "spendable": {
+                    "type": "boolean"
+                },
+                "staked": {
+                    "type": "boolean"
+                },
+                "txnId": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "integer"
+                },
+                "vout": {
+                    "type": "integer"
+                }
+            }
+        },
+        "representations.Asset": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "issuer": {
+                    "type": "string"
+                },
+                "maxSupply": {
+                    "type": "integer"
+                },
+                "metadataHash": {
+                    "type": "string"
+                },
+                "mintable": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "nonFungible": {
+                    "type": "boolean"
+                },
+                "supply": {
+                    "type": "integer"
+                },
+                "symbol": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.AssetBalance": {
+            "type": "object",
+            "properties": {
+                "assetId": {
+                    "type": "string"
+                },
+                "confirmed": {
+                    "type": "integer"
+                },
+                "pending": {
+                    "type": "integer"
+                },
+                "symbol": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.AssignAddressInput": {
+            "type": "object",
+            "required": [
+                "address"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.BatchResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "success": {
+                    "type": "boolean"
+                },
+                "txnId": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.Block": {
+            "type": "object",
+            "properties": {
+                "chainId": {
+                    "type": "string"
+                },
+                "chainWork": {
+                    "type": "string"
+                },
+                "difficulty": {
+                    "type": "integer"
+                },
+                "hash": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "merkleRoot": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "nounce": {
+                    "type": "integer"
+                },
+                "prevHash": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "proposer": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "proposerSig": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "round": {
+                    "type": "integer"
+                },
+                "sigAlgorithm": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "integer"
+                },
+                "transactions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/representations.Transaction"
+                    }
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "representations.BlockFinality": {
+            "type": "object",
+            "properties": {
+                "blockId": {
+                    "type": "string"
+                },
+                "final": {
+                    "type": "boolean"
+                },
+                "hash": {
+                    "type": "string"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "quorum": {
+                    "type": "integer"
+                },
+                "round": {
+                    "type": "integer"
+                },
+                "validators": {
+                    "type": "integer"
+                },
+                "votes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/representations.ReadableBlockVote"
+                    }
+                }
+            }
+        },
+        "representations.BlockHeader": {
+            "type": "object",
+            "properties": {
+                "chainId": {
+                    "type": "string"
+                },
+                "chainWork": {
+                    "type": "string"
+                },
+                "difficulty": {
+                    "type": "integer"
+                },
+                "finality": {
+                    "type": "string"
+                },
+                "hash": {
+                    "type": "string"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "merkleRoot": {
+                    "type": "string"
+                },
+                "nounce": {
+                    "type": "integer"
+                },
+                "prevHash": {
+                    "type": "string"
+                },
+                "proposer": {
+                    "type": "string"
+                },
+                "proposerSig": {
+                    "type": "string"
+                },
+                "round": {
+                    "type": "integer"
+                },
+                "timestamp": {
+                    "type": "integer"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "representations.BlockStats": {
+            "type": "object",
+            "properties": {
+                "difficulty": {
+                    "type": "integer"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "targetBlockTime": {
+                    "type": "integer"
+                },
+                "windows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/representations.BlockWindowStats"
+                    }
+                }
+            }
+        },
+        "representations.BlockTemplate": {
+            "type": "object",
+            "properties": {
+                "chainId": {
+                    "type": "string"
+                },
+                "coinbaseValue": {
+                    "type": "integer"
+                },
+                "difficulty": {
+                    "type": "integer"
+                },
+                "hashAlgorithm": {
+                    "type": "string"
+                },
+                "headerPrefix": {
+                    "type": "string"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "merkleRoot": {
+                    "type": "string"
+                },
+                "prevHash": {
+                    "type": "string"
+                },
+                "target": {
+                    "type": "string"
+                },
+                "templateId": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "integer"
+                },
+                "transactions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/representations.ReadableTransaction"
+                    }
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "representations.BlockWindowStats": {
+            "type": "object",
+            "properties": {
+                "averageBlockTime": {
+                    "type": "number"
+                },
+                "averageDifficulty": {
+                    "type": "number"
+                },
+                "blocks": {
+                    "type": "integer"
+                },
+                "from": {
+                    "type": "integer"
+                },
+                "hashRate": {
+                    "type": "number"
+                },
+                "to": {
+                    "type": "integer"
+                },
+                "window": {
+                    "type": "integer"
+                }
+            }
+        },
+        "representations.BroadcastMultisigTxnInput": {
+            "type": "object",
+            "required": [
+                "miner"
+            ],
+            "properties": {
+                "miner": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.ChainInfo": {
+            "type": "object",
+            "properties": {
+                "bestBlockHash": {
+                    "type": "string"
+                },
+                "chainWork": {
+                    "type": "string"
+                },
+                "difficulty": {
+                    "type": "integer"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "nextHalvingHeight": {
+                    "type": "integer"
+                },
+                "orphans": {
+                    "type": "integer"
+                },
+                "params": {
+                    "$ref": "#/definitions/representations.ChainParams"
+                },
+                "reward": {
+                    "type": "integer"
+                },
+                "rules": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/representations.ConsensusRule"
+                    }
+                },
+                "supply": {
+                    "type": "integer"
+                }
+            }
+        },
+        "representations.ChainParams": {
+            "type": "object",
+            "properties": {
+                "activations": {
+                    "type": "string"
+                },
+                "chainId": {
+                    "type": "string"
+                },
+                "coinbaseMaturity": {
+                    "type": "integer"
+                },
+                "consensus": {
+                    "type": "string"
+                },
+                "difficultyInterval": {
+                    "type": "integer"
+                },
+                "dustThreshold": {
+                    "type": "integer"
+                },
+                "halvingInterval": {
+                    "type": "integer"
+                },
+                "hashAlgorithm": {
+                    "type": "string"
+                },
+                "initialDifficulty": {
+                    "type": "integer"
+                },
+                "initialReward": {
+                    "type": "integer"
+                },
+                "maxBlockSize": {
+                    "type": "integer"
+                },
+                "networkByte": {
+                    "type": "integer"
+                },
+                "premine": {
+                    "type": "integer"
+                },
+                "replayProtection": {
+                    "type": "boolean"
+                },
+                "targetBlockTime": {
+                    "type": "integer"
+                },
+                "validators": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.ChainSnapshot": {
+            "type": "object",
+            "properties": {
+                "hash": {
+                    "type": "string"
+                },
+                "headers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/representations.Block"
+                    }
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "params": {
+                    "$ref": "#/definitions/representations.ChainParams"
+                },
+                "unspentOutputs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/representations.UnspentOutput"
+                    }
+                },
+                "utxoHash": {
+                    "type": "string"
+                },
+                "validatorChanges": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/representations.ValidatorChangeRecord"
+                    }
+                }
+            }
+        },
+        "representations.ChainTip": {
+            "type": "object",
+            "properties": {
+                "branchLength": {
+                    "type": "integer"
+                },
+                "chainWork": {
+                    "type": "string"
+                },
+                "forkHeight": {
+                    "type": "integer"
+                },
+                "hash": {
+                    "type": "string"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.ConnectPeerInput": {
+            "type": "object",
+            "required": [
+                "address"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.ConsensusRule": {
+            "type": "object",
+            "properties": {
+                "activationHeight": {
+                    "type": "integer"
+                },
+                "active": {
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.ConsolidateInput": {
+            "type": "object",
+            "required": [
+                "address"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "coinSelection": {
+                    "type": "string",
+                    "enum": [
+                        "all",
+                        "largest-first",
+                        "smallest-first",
+                        "branch-and-bound"
+                    ]
+                },
+                "fee": {
+                    "type": "integer"
+                },
+                "feeRate": {
+                    "type": "integer"
+                },
+                "force": {
+                    "type": "boolean"
+                },
+                "lockTime": {
+                    "type": "integer"
+                },
+                "maxValue": {
+                    "type": "integer"
+                },
+                "memo": {
+                    "type": "string"
+                },
+                "replaceable": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "representations.CreateAccountInput": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.CreateAssetInput": {
+            "type": "object",
+            "required": [
+                "amount",
+                "issuer",
+                "symbol"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "coinSelection": {
+                    "type": "string",
+                    "enum": [
+                        "all",
+                        "largest-first",
+                        "smallest-first",
+                        "branch-and-bound"
+                    ]
+                },
+                "fee": {
+                    "type": "integer"
+                },
+                "feeRate": {
+                    "type": "integer"
+                },
+                "issuer": {
+                    "type": "string"
+                },
+                "lockTime": {
+                    "type": "integer"
+                },
+                "maxSupply": {
+                    "type": "integer"
+                },
+                "memo": {
+                    "type": "string"
+                },
+                "mintable": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "replaceable": {
+                    "type": "boolean"
+                },
+                "symbol": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.CreateBatchInput": {
+            "type": "object",
+            "required": [
+                "transfers"
+            ],
+            "properties": {
+                "coinSelection": {
+                    "type": "string",
+                    "enum": [
+                        "all",
+                        "largest-first",
+                        "smallest-first",
+                        "branch-and-bound"
+                    ]
+                },
+                "fee": {
+                    "type": "integer"
+                },
+                "feeRate": {
+                    "type": "integer"
+                },
+                "lockTime": {
+                    "type": "integer"
+                },
+                "memo": {
+                    "type": "string"
+                },
+                "replaceable": {
+                    "type": "boolean"
+                },
+                "transfers": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/representations.Transfer"
+                    }
+                }
+            }
+        },
+        "representations.CreateBlockInput": {
+            "type": "object",
+            "required": [
+                "from"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "coinSelection": {
+                    "type": "string",
+                    "enum": [
+                        "all",
+                        "largest-first",
+                        "smallest-first",
+                        "branch-and-bound"
+                    ]
+                },
+                "fee": {
+                    "type": "integer"
+                },
+                "feeRate": {
+                    "type": "integer"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "lockTime": {
+                    "type": "integer"
+                },
+                "memo": {
+                    "type": "string"
+                },
+                "recipients": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/representations.Recipient"
+                    }
+                },
+                "replaceable": {
+                    "type": "boolean"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.CreateBlockchainInput": {
+            "type": "object",
+            "required": [
+                "to"
+            ],
+            "properties": {
+                "allocations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/representations.GenesisAllocation"
+                    }
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.CreateHDWalletInput": {
+            "type": "object",
+            "properties": {
+                "passphrase": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.CreateMultisigInput": {
+            "type": "object",
+            "required": [
+                "publicKeys",
+                "requiredSigs"
+            ],
+            "properties": {
+                "publicKeys": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "requiredSigs": {
+                    "type": "integer"
+                }
+            }
+        },
+        "representations.CreateMultisigTxnInput": {
+            "type": "object",
+            "required": [
+                "amount",
+                "to"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.CreateNFTInput": {
+            "type": "object",
+            "required": [
+                "issuer",
+                "metadataHash"
+            ],
+            "properties": {
+                "coinSelection": {
+                    "type": "string",
+                    "enum": [
+                        "all",
+                        "largest-first",
+                        "smallest-first",
+                        "branch-and-bound"
+                    ]
+                },
+                "fee": {
+                    "type": "integer"
+                },
+                "feeRate": {
+                    "type": "integer"
+                },
+                "issuer": {
+                    "type": "string"
+                },
+                "lockTime": {
+                    "type": "integer"
+                },
+                "memo": {
+                    "type": "string"
+                },
+                "metadataHash": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "replaceable": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "representations.CreatePaperWalletInput": {
+            "type": "object",
+            "properties": {
+                "qrCode": {
+                    "type": "boolean"
+                },
+                "sigAlgorithm": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.CreateTransactionInput": {
+            "type": "object",
+            "required": [
+                "from"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "coinSelection": {
+                    "type": "string",
+                    "enum": [
+                        "all",
+                        "largest-first",
+                        "smallest-first",
+                        "branch-and-bound"
+                    ]
+                },
+                "fee": {
+                    "type": "integer"
+                },
+                "feeRate": {
+                    "type": "integer"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "lockTime": {
+                    "type": "integer"
+                },
+                "memo": {
+                    "type": "string"
+                },
+                "recipients": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/representations.Recipient"
+                    }
+                },
+                "replaceable": {
+                    "type": "boolean"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.CreateVanityWalletInput": {
+            "type": "object",
+            "required": [
+                "prefix"
+            ],
+            "properties": {
+                "prefix": {
+                    "type": "string"
+                },
+                "sigAlgorithm": {
+                    "type": "string"
+                },
+                "timeoutSeconds": {
+                    "type": "integer"
+                },
+                "workers": {
+                    "type": "integer"
+                }
+            }
+        },
+        "representations.CreateWalletInput": {
+            "type": "object",
+            "properties": {
+                "sigAlgorithm": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.Evidence": {
+            "type": "object",
+            "properties": {
+                "blockId": {
+                    "type": "string"
+                },
+                "firstHash": {
+                    "type": "string"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "offender": {
+                    "type": "string"
+                },
+                "reportedAt": {
+                    "type": "integer"
+                },
+                "secondHash": {
+                    "type": "string"
+                },
+                "stake": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "txnId": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.FeeEstimate": {
+            "type": "object",
+            "properties": {
+                "blocks": {
+                    "type": "integer"
+                },
+                "high": {
+                    "type": "integer"
+                },
+                "low": {
+                    "type": "integer"
+                },
+                "medium": {
+                    "type": "integer"
+                },
+                "mempoolDepth": {
+                    "type": "integer"
+                },
+                "samples": {
+                    "type": "integer"
+                }
+            }
+        },
+        "representations.GenesisAllocation": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "amount": {
+                    "type": "integer"
+                }
+            }
+        },
+        "representations.HDWallet": {
+            "type": "object",
+            "properties": {
+                "account": {
+                    "type": "integer"
+                },
+                "fingerprint": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "nextChangeIndex": {
+                    "type": "integer"
+                },
+                "nextIndex": {
+                    "type": "integer"
+                }
+            }
+        },
+        "representations.ImportPublicKeyInput": {
+            "type": "object",
+            "required": [
+                "publicKey"
+            ],
+            "properties": {
+                "publicKey": {
+                    "type": "string"
+                },
+                "sigAlgorithm": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.ImportWIFInput": {
+            "type": "object",
+            "required": [
+                "wif"
+            ],
+            "properties": {
+                "wif": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.IssueAssetInput": {
+            "type": "object",
+            "required": [
+                "amount"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "coinSelection": {
+                    "type": "string",
+                    "enum": [
+                        "all",
+                        "largest-first",
+                        "smallest-first",
+                        "branch-and-bound"
+                    ]
+                },
+                "fee": {
+                    "type": "integer"
+                },
+                "feeRate": {
+                    "type": "integer"
+                },
+                "lockTime": {
+                    "type": "integer"
+                },
+                "memo": {
+                    "type": "string"
+                },
+                "replaceable": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "representations.MempoolStats": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "evictedForSpace": {
+                    "type": "integer"
+                },
+                "expired": {
+                    "type": "integer"
+                },
+                "maxSize": {
+                    "type": "integer"
+                },
+                "replaced": {
+                    "type": "integer"
+                },
+                "totalFees": {
+                    "type": "integer"
+                },
+                "totalSize": {
+                    "type": "integer"
+                },
+                "ttl": {
+                    "type": "integer"
+                }
+            }
+        },
+        "representations.MerkleBranch": {
+            "type": "object",
+            "properties": {
+                "blockHash": {
+                    "type": "string"
+                },
+                "leafData": {
+                    "type": "string"
+                },
+                "leafHash": {
+                    "type": "string"
+                },
+                "merkleRoot": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "proof": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/representations.ReadableProofStep"
+                    }
+                },
+                "txnId": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.Metrics": {
+            "type": "object",
+            "properties": {
+                "deepReorgs": {
+                    "type": "integer"
+                },
+                "deepestReorg": {
+                    "type": "integer"
+                },
+                "eventsSent": {
+                    "type": "integer"
+                },
+                "reorgs": {
+                    "type": "integer"
+                },
+                "webhookFailures": {
+                    "type": "integer"
+                }
+            }
+        },
+        "representations.MineInput": {
+            "type": "object",
+            "required": [
+                "miner"
+            ],
+            "properties": {
+                "coinbaseMessage": {
+                    "type": "string"
+                },
+                "miner": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.MinerInput": {
+            "type": "object",
+            "required": [
+                "miner"
+            ],
+            "properties": {
+                "miner": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.MinerStatus": {
+            "type": "object",
+            "properties": {
+                "blocksMined": {
+                    "type": "integer"
+                },
+                "dutyCycle": {
+                    "type": "integer"
+                },
+                "lastBlockAt": {
+                    "type": "integer"
+                },
+                "lastBlockHash": {
+                    "type": "string"
+                },
+                "lastBlockHeight": {
+                    "type": "integer"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "miner": {
+                    "type": "string"
+                },
+                "pausedAt": {
+                    "type": "integer"
+                },
+                "startedAt": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "workers": {
+                    "type": "integer"
+                }
+            }
+        },
+        "representations.MultisigAddress": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "balance": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "publicKeys": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "redeemScript": {
+                    "type": "string"
+                },
+                "requiredSigs": {
+                    "type": "integer"
+                }
+            }
+        },
+        "representations.MultisigTransaction": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "requiredSigs": {
+                    "type": "integer"
+                },
+                "signatures": {
+                    "type": "integer"
+                },
+                "signers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                },
+                "transaction": {
+                    "$ref": "#/definitions/representations.ReadableTransaction"
+                }
+            }
+        },
+        "representations.NFTProvenance": {
+            "type": "object",
+            "properties": {
+                "history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/representations.NFTTransfer"
+                    }
+                },
+                "nft": {
+                    "$ref": "#/definitions/representations.Asset"
+                },
+                "owner": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.NFTTransfer": {
+            "type": "object",
+            "properties": {
+                "blockHash": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "timestamp": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "txnId": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.OutputStatus": {
+            "type": "object",
+            "properties": {
+                "assetId": {
+                    "type": "string"
+                },
+                "outIdx": {
+                    "type": "integer"
+                },
+                "pubKeyHash": {
+                    "type": "string"
+                },
+                "spentByTxnId": {
+                    "type": "string"
+                },
+                "spentInBlockId": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "txnId": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "integer"
+                }
+            }
+        },
+        "representations.PaperWallet": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "addressQR": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "publicKey": {
+                    "type": "string"
+                },
+                "sigAlgorithm": {
+                    "type": "string"
+                },
+                "wif": {
+                    "type": "string"
+                },
+                "wifQR": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "representations.Peer": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "connectedAt": {
+                    "type": "integer"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "inbound": {
+                    "type": "boolean"
+                },
+                "lastSeen": {
+                    "type": "integer"
+                },
+                "listenPort": {
+                    "type": "integer"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "representations.RawTransactionInput": {
+            "type": "object",
+            "required": [
+                "raw"
+            ],
+            "properties": {
+                "raw": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.ReadableAddressTransaction": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "blockHash": {
+                    "type": "string"
+                },
+                "direction": {
+                    "type": "string"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "transaction": {
+                    "$ref": "#/definitions/representations.ReadableTransaction"
+                }
+            }
+        },
+        "representations.ReadableBlock": {
+            "type": "object",
+            "properties": {
+                "chainId": {
+                    "type": "string"
+                },
+                "chainWork": {
+                    "type": "string"
+                },
+                "coinbaseMessage": {
+                    "type": "string"
+                },
+                "difficulty": {
+                    "type": "integer"
+                },
+                "finality": {
+                    "type": "string"
+                },
+                "hash": {
+                    "type": "string"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "merkleRoot": {
+                    "type": "string"
+                },
+                "nounce": {
+                    "type": "integer"
+                },
+                "prevHash": {
+                    "type": "string"
+                },
+                "proposer": {
+                    "type": "string"
+                },
+                "round": {
+                    "type": "integer"
+                },
+                "timestamp": {
+                    "type": "integer"
+                },
+                "transactions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/representations.ReadableTransaction"
+                    }
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "representations.ReadableBlockVote": {
+            "type": "object",
+            "properties": {
+                "publicKey": {
+                    "type": "string"
+                },
+                "sigAlgorithm": {
+                    "type": "string"
+                },
+                "signature": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "integer"
+                },
+                "validator": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.ReadableConfirmedTransaction": {
+            "type": "object",
+            "properties": {
+                "blockHash": {
+                    "type": "string"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "transaction": {
+                    "$ref": "#/definitions/representations.ReadableTransaction"
+                }
+            }
+        },
+        "representations.ReadableMempoolEntry": {
+            "type": "object",
+            "properties": {
+                "addedAt": {
+                    "type": "integer"
+                },
+                "fee": {
+                    "type": "integer"
+                },
+                "feeRate": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "transaction": {
+                    "$ref": "#/definitions/representations.ReadableTransaction"
+                },
+                "waiting": {
+                    "type": "integer"
+                }
+            }
+        },
+        "representations.ReadableProofStep": {
+            "type": "object",
+            "properties": {
+                "hash": {
+                    "type": "string"
+                },
+                "side": {
+                    "type": "string",
+                    "enum": [
+                        "left",
+                        "right"
+                    ]
+                }
+            }
+        },
+        "representations.ReadableTransaction": {
+            "type": "object",
+            "properties": {
+                "blockId": {
+                    "type": "string"
+                },
+                "fee": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lockTime": {
+                    "type": "integer"
+                },
+                "memo": {
+                    "type": "string"
+                },
+                "replaceable": {
+                    "type": "boolean"
+                },
+                "sigAlgorithm": {
+                    "type": "string"
+                },
+                "txnInputs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/representations.ReadableTxnInput"
+                    }
+                },
+                "txnOutputs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/representations.ReadableTxnOutput"
+                    }
+                }
+            }
+        },
+        "representations.ReadableTxnInput": {
+            "type": "object",
+            "properties": {
+                "currTxnId": {
+                    "type": "string"
+                },
+                "outIdx": {
+                    "type": "integer"
+                },
+                "prevTxnId": {
+                    "type": "string"
+                },
+                "pubKey": {
+                    "type": "string"
+                },
+                "signature": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.ReadableTxnOutput": {
+            "type": "object",
+            "properties": {
+                "assetId": {
+                    "type": "string"
+                },
+                "currTxnId": {
+                    "type": "string"
+                },
+                "pubKeyHash": {
+                    "type": "string"
+                },
+                "staked": {
+                    "type": "boolean"
+                },
+                "value": {
+                    "type": "integer"
+                }
+            }
+        },
+        "representations.Recipient": {
+            "type": "object",
+            "required": [
+                "amount",
+                "to"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.RecoverHDWalletInput": {
+            "type": "object",
+            "required": [
+                "mnemonic"
+            ],
+            "properties": {
+                "mnemonic": {
+                    "type": "string"
+                },
+                "passphrase": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.RestoreWalletsInput": {
+            "type": "object",
+            "required": [
+                "backup"
+            ],
+            "properties": {
+                "backup": {
+                    "$ref": "#/definitions/representations.WalletBackup"
+                },
+                "passphrase": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.Schedule": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "integer"
+                },
+                "fee": {
+                    "type": "integer"
+                },
+                "feeRate": {
+                    "type": "integer"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "interval": {
+                    "type": "string"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "lastRunAt": {
+                    "type": "integer"
+                },
+                "lastTxnId": {
+                    "type": "string"
+                },
+                "memo": {
+                    "type": "string"
+                },
+                "nextRunAt": {
+                    "type": "integer"
+                },
+                "runs": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "cancelled"
+                    ]
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.ScheduleInput": {
+            "type": "object",
+            "required": [
+                "amount",
+                "from",
+                "interval",
+                "to"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "fee": {
+                    "type": "integer"
+                },
+                "feeRate": {
+                    "type": "integer"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "interval": {
+                    "type": "string",
+                    "example": "@every 24h"
+                },
+                "memo": {
+                    "type": "string"
+                },
+                "startAt": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.SignMessageInput": {
+            "type": "object",
+            "required": [
+                "message"
+            ],
+            "properties": {
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.SignMultisigTxnInput": {
+            "type": "object",
+            "required": [
+                "signer"
+            ],
+            "properties": {
+                "signer": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.StakeInput": {
+            "type": "object",
+            "required": [
+                "address"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "amount": {
+                    "type": "integer"
+                },
+                "coinSelection": {
+                    "type": "string",
+                    "enum": [
+                        "all",
+                        "largest-first",
+                        "smallest-first",
+                        "branch-and-bound"
+                    ]
+                },
+                "fee": {
+                    "type": "integer"
+                },
+                "feeRate": {
+                    "type": "integer"
+                },
+                "lockTime": {
+                    "type": "integer"
+                },
+                "memo": {
+                    "type": "string"
+                },
+                "replaceable": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "representations.StaleBlock": {
+            "type": "object",
+            "properties": {
+                "blockId": {
+                    "type": "string"
+                },
+                "difficulty": {
+                    "type": "integer"
+                },
+                "hash": {
+                    "type": "string"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "prevHash": {
+                    "type": "string"
+                },
+                "receivedAt": {
+                    "type": "integer"
+                },
+                "timestamp": {
+                    "type": "integer"
+                },
+                "txnCount": {
+                    "type": "integer"
+                },
+                "winnerHash": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.SubmitBlockInput": {
+            "type": "object",
+            "required": [
+                "templateId"
+            ],
+            "properties": {
+                "nounce": {
+                    "type": "integer"
+                },
+                "templateId": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "integer"
+                }
+            }
+        },
+        "representations.SyncStatus": {
+            "type": "object",
+            "properties": {
+                "blocksDownloaded": {
+                    "type": "integer"
+                },
+                "downloadPeers": {
+                    "type": "integer"
+                },
+                "headersDownloaded": {
+                    "type": "integer"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "pendingBlocks": {
+                    "type": "integer"
+                },
+                "progress": {
+                    "type": "number"
+                },
+                "startHeight": {
+                    "type": "integer"
+                },
+                "startedAt": {
+                    "type": "integer"
+                },
+                "syncPeer": {
+                    "type": "string"
+                },
+                "syncing": {
+                    "type": "boolean"
+                },
+                "targetHeight": {
+                    "type": "integer"
+                }
+            }
+        },
+        "representations.Transaction": {
+            "type": "object",
+            "properties": {
+                "blockId": {
+                    "type": "string"
+                },
+                "evidence": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "fee": {
+                    "type": "integer"
+                },
+                "lockTime": {
+                    "type": "integer"
+                },
+                "memo": {
+                    "type": "string"
+                },
+                "replaceable": {
+                    "type": "boolean"
+                },
+                "sigAlgorithm": {
+                    "type": "string"
+                },
+                "txnId": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "txnInputs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/representations.TxnInput"
+                    }
+                },
+                "txnOutputs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/representations.TxnOutput"
+                    }
+                },
+                "validatorChange": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "representations.Transfer": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.TransferAssetInput": {
+            "type": "object",
+            "required": [
+                "amount",
+                "from",
+                "to"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "coinSelection": {
+                    "type": "string",
+                    "enum": [
+                        "all",
+                        "largest-first",
+                        "smallest-first",
+                        "branch-and-bound"
+                    ]
+                },
+                "fee": {
+                    "type": "integer"
+                },
+                "feeRate": {
+                    "type": "integer"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "lockTime": {
+                    "type": "integer"
+                },
+                "memo": {
+                    "type": "string"
+                },
+                "replaceable": {
+                    "type": "boolean"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.TransferNFTInput": {
+            "type": "object",
+            "required": [
+                "to"
+            ],
+            "properties": {
+                "coinSelection": {
+                    "type": "string",
+                    "enum": [
+                        "all",
+                        "largest-first",
+                        "smallest-first",
+                        "branch-and-bound"
+                    ]
+                },
+                "fee": {
+                    "type": "integer"
+                },
+                "feeRate": {
+                    "type": "integer"
+                },
+                "lockTime": {
+                    "type": "integer"
+                },
+                "memo": {
+                    "type": "string"
+                },
+                "replaceable": {
+                    "type": "boolean"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.TxnInput": {
+            "type": "object",
+            "properties": {
+                "currTxnId": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "inputId": {
+                    "type": "string"
+                },
+                "outIdx": {
+                    "type": "integer"
+                },
+                "prevTxnId": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "pubKey": {
+                    "description": "not hashed",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "signature": {
+                    "description": "ScriptSig string ` + "`" + `json:\"scriptSig\"` + "`" + `",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "representations.TxnOutput": {
+            "type": "object",
+            "properties": {
+                "assetId": {
+                    "type": "string"
+                },
+                "currTxnId": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "outputId": {
+                    "type": "string"
+                },
+                "pubKeyHash": {
+                    "description": "locks the output",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "staked": {
+                    "type": "boolean"
+                },
+                "value": {
+                    "type": "integer"
+                }
+            }
+        },
+        "representations.TxnReceipt": {
+            "type": "object",
+            "properties": {
+                "blockHash": {
                     "type": "string"
                 },
-                "balance": {
+                "confirmations": {
                     "type": "integer"
                 },
-                "publicKey": {
+                "difficulty": {
+                    "type": "integer"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "leafData": {
+                    "type": "string"
+                },
+                "leafHash": {
+                    "type": "string"
+                },
+                "merkleRoot": {
+                    "type": "string"
+                },
+                "nounce": {
+                    "type": "integer"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "prevHash": {
+                    "type": "string"
+                },
+                "proof": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/representations.ReadableProofStep"
+                    }
+                },
+                "timestamp": {
+                    "type": "integer"
+                },
+                "txnId": {
                     "type": "string"
+                },
+                "version": {
+                    "type": "integer"
                 }
             }
         },
-        "representations.CreateBlockInput": {
+        "representations.TxnStatus": {
             "type": "object",
-            "required": [
-                "amount",
-                "from",
-                "to"
-            ],
             "properties": {
-                "amount": {
+                "blockHash": {
+                    "type": "string"
+                },
+                "confirmations": {
                     "type": "integer"
                 },
-                "from": {
+                "height": {
+                    "type": "integer"
+                },
+                "replacedBy": {
                     "type": "string"
                 },
-                "to": {
+                "status": {
+                    "type": "string"
+                },
+                "txnId": {
                     "type": "string"
                 }
             }
         },
-        "representations.CreateBlockchainInput": {
+        "representations.TxnValidation": {
+            "type": "object",
+            "properties": {
+                "consumed": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/representations.OutputStatus"
+                    }
+                },
+                "fee": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "txnId": {
+                    "type": "string"
+                },
+                "valid": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "representations.UnlockWalletInput": {
             "type": "object",
             "required": [
-                "to"
+                "passphrase"
             ],
             "properties": {
-                "to": {
+                "passphrase": {
                     "type": "string"
+                },
+                "ttlSeconds": {
+                    "type": "integer"
                 }
             }
         },
-        "representations.ReadableBlock": {
+        "representations.UnspentOutput": {
             "type": "object",
             "properties": {
-                "hash": {
+                "assetId": {
                     "type": "string"
                 },
-                "id": {
+                "blockId": {
                     "type": "string"
                 },
-                "nounce": {
+                "coinbase": {
+                    "type": "boolean"
+                },
+                "height": {
                     "type": "integer"
                 },
-                "prevHash": {
+                "id": {
                     "type": "string"
                 },
-                "timestamp": {
+                "outIdx": {
                     "type": "integer"
                 },
-                "transactions": {
+                "outputId": {
+                    "type": "string"
+                },
+                "pubKeyHash": {
+                    "type": "string"
+                },
+                "staked": {
+                    "type": "boolean"
+                },
+                "txnId": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/representations.ReadableTransaction"
+                        "type": "integer"
                     }
+                },
+                "value": {
+                    "type": "integer"
                 }
             }
         },
-        "representations.ReadableTransaction": {
+        "representations.ValidateTransactionInput": {
+            "type": "object",
+            "required": [
+                "transaction"
+            ],
+            "properties": {
+                "transaction": {
+                    "$ref": "#/definitions/representations.Transaction"
+                }
+            }
+        },
+        "representations.Validator": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "bonded": {
+                    "type": "integer"
+                },
+                "deposits": {
+                    "type": "integer"
+                },
+                "pubKeyHash": {
+                    "type": "string"
+                },
+                "share": {
+                    "type": "number"
+                }
+            }
+        },
+        "representations.ValidatorChangeInput": {
+            "type": "object",
+            "required": [
+                "authority"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "authority": {
+                    "type": "string"
+                },
+                "height": {
+                    "type": "integer"
+                }
+            }
+        },
+        "representations.ValidatorChangeRecord": {
             "type": "object",
             "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "authority": {
+                    "type": "string"
+                },
+                "authorityAddress": {
+                    "type": "string"
+                },
                 "blockId": {
                     "type": "string"
                 },
-                "id": {
+                "height": {
+                    "type": "integer"
+                },
+                "minedHeight": {
+                    "type": "integer"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "status": {
                     "type": "string"
                 },
-                "txnInputs": {
+                "txnId": {
+                    "type": "string"
+                },
+                "validator": {
+                    "type": "string"
+                }
+            }
+        },
+        "representations.ValidatorSchedule": {
+            "type": "object",
+            "properties": {
+                "changes": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/representations.ReadableTxnInput"
+                        "$ref": "#/definitions/representations.ValidatorChangeRecord"
                     }
                 },
-                "txnOutputs": {
+                "height": {
+                    "type": "integer"
+                },
+                "validators": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/representations.ReadableTxnOutput"
+                        "type": "string"
                     }
                 }
             }
         },
-        "representations.ReadableTxnInput": {
+        "representations.ValidatorSet": {
             "type": "object",
             "properties": {
-                "currTxnId": {
+                "height": {
+                    "type": "integer"
+                },
+                "nextProposer": {
                     "type": "string"
                 },
-                "outIdx": {
+                "totalBonded": {
                     "type": "integer"
                 },
-                "prevTxnId": {
+                "validators": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/representations.Validator"
+                    }
+                }
+            }
+        },
+        "representations.VerifyMessageInput": {
+            "type": "object",
+            "required": [
+                "address",
+                "message",
+                "signature"
+            ],
+            "properties": {
+                "address": {
                     "type": "string"
                 },
-                "pubKey": {
+                "message": {
                     "type": "string"
                 },
                 "signature": {
@@ -511,26 +6573,72 @@ const docTemplate = `{
                 }
             }
         },
-        "representations.ReadableTxnOutput": {
+        "representations.VersionBitCount": {
             "type": "object",
             "properties": {
-                "currTxnId": {
+                "bit": {
+                    "type": "integer"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "share": {
+                    "type": "number"
+                }
+            }
+        },
+        "representations.VersionBitsStats": {
+            "type": "object",
+            "properties": {
+                "bits": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/representations.VersionBitCount"
+                    }
+                },
+                "blocks": {
+                    "type": "integer"
+                },
+                "from": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "integer"
+                }
+            }
+        },
+        "representations.VoteInput": {
+            "type": "object",
+            "properties": {
+                "address": {
                     "type": "string"
                 },
-                "pubKeyHash": {
+                "publicKey": {
                     "type": "string"
                 },
-                "value": {
-                    "type": "integer"
+                "sigAlgorithm": {
+                    "type": "string"
+                },
+                "signature": {
+                    "type": "string"
                 }
             }
         },
         "representations.Wallet": {
             "type": "object",
             "properties": {
+                "accountId": {
+                    "type": "string"
+                },
                 "address": {
                     "type": "string"
                 },
+                "derivationPath": {
+                    "type": "string"
+                },
+                "hdWalletId": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "string"
                 },
@@ -542,6 +6650,58 @@ const docTemplate = `{
                 },
                 "publicKey": {
                     "type": "string"
+                },
+                "sigAlgorithm": {
+                    "type": "string"
+                },
+                "watchOnly": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "representations.WalletBackup": {
+            "type": "object",
+            "properties": {
+                "ciphertext": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "nonce": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "salt": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "scryptN": {
+                    "type": "integer"
+                },
+                "scryptP": {
+                    "type": "integer"
+                },
+                "scryptR": {
+                    "type": "integer"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "representations.WatchAddressInput": {
+            "type": "object",
+            "required": [
+                "address"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string"
                 }
             }
         }
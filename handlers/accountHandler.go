@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type AccountHandler struct {
+	accountService     services.AccountService
+	addressBookService services.AddressBookService
+	assemblerService   services.BlockAssemblerFac
+}
+
+func NewAccountHandler(accountService services.AccountService, addressBookService services.AddressBookService) *AccountHandler {
+	return &AccountHandler{
+		accountService:     accountService,
+		addressBookService: addressBookService,
+		assemblerService:   services.BlockAssembler,
+	}
+}
+
+// CreateAccount ... Create a named account
+// @Summary      Create an account
+// @Description  Create an empty named account. Addresses are then assigned to it, and its balance and sends span all of them
+// @Tags         Accounts
+// @Param        CreateAccountInput  body      representations.CreateAccountInput  true  "Account name"
+// @Success      201                 {object}  representations.Account
+// @Failure      400                 {object}  HTTPError
+// @Router       /blockchain/accounts [post]
+func (ah *AccountHandler) CreateAccount(ctx *gin.Context) {
+	log.Info("CreateAccount handler called")
+
+	var input reps.CreateAccountInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	account, err := ah.accountService.CreateAccount(input.Name)
+	if err != nil {
+		log.Error("error creating account: ", err.Error())
+		NewError(ctx, http.StatusBadRequest, err)
+	} else {
+		ctx.JSON(http.StatusCreated, gin.H{"account": account})
+	}
+}
+
+// GetAccounts ... Get every account
+// @Summary      Get all accounts
+// @Description  Get every account with its addresses and combined balance
+// @Tags         Accounts
+// @Success      200  {array}   representations.Account
+// @Failure      500  {object}  HTTPError
+// @Router       /blockchain/accounts [get]
+func (ah *AccountHandler) GetAccounts(ctx *gin.Context) {
+	log.Info("GetAccounts handler called")
+
+	accounts, err := ah.accountService.GetAccounts()
+	if err != nil {
+		log.Error("error getting accounts: ", err.Error())
+		NewError(ctx, http.StatusInternalServerError, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"accounts": accounts})
+	}
+}
+
+// GetAccount ... Get an account by name
+// @Summary      Get an account
+// @Description  Get an account's addresses and their combined balance
+// @Tags         Accounts
+// @Param        name  path      string  true  "Account name"
+// @Success      200   {object}  representations.Account
+// @Failure      404   {object}  HTTPError
+// @Router       /blockchain/accounts/{name} [get]
+func (ah *AccountHandler) GetAccount(ctx *gin.Context) {
+	name := ctx.Param("name")
+	log.Info("GetAccount handler called with name: ", name)
+
+	account, err := ah.accountService.GetAccount(name)
+	if err != nil {
+		log.Error("error getting account: ", err.Error())
+		NewError(ctx, http.StatusNotFound, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"account": account})
+	}
+}
+
+// AssignAddress ... Add an address to an account
+// @Summary      Assign an address to an account
+// @Description  Add one of the node's wallets to an account. An address belongs to at most one account, and an account's signing addresses must share a signature algorithm
+// @Tags         Accounts
+// @Param        name                path      string                              true  "Account name"
+// @Param        AssignAddressInput  body      representations.AssignAddressInput  true  "Address to assign"
+// @Success      200                 {object}  representations.Account
+// @Failure      400                 {object}  HTTPError
+// @Router       /blockchain/accounts/{name}/addresses [post]
+func (ah *AccountHandler) AssignAddress(ctx *gin.Context) {
+	name := ctx.Param("name")
+	log.Info("AssignAddress handler called with name: ", name)
+
+	var input reps.AssignAddressInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	account, err := ah.accountService.AssignAddress(name, input.Address)
+	if err != nil {
+		log.Error("error assigning address to account: ", err.Error())
+		NewError(ctx, http.StatusBadRequest, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"account": account})
+	}
+}
+
+// SendFromAccount ... Send coins from an account
+// @Summary      Send from an account
+// @Description  Send coins from any of an account's addresses and mine the transaction into a block. To can be an address book name. An optional fee or fee rate (coins per 1000 bytes) goes to the miner
+// @Tags         Accounts
+// @Param        name              path      string                            true  "Account name"
+// @Param        AccountSendInput  body      representations.AccountSendInput  true  "Recipient and amount"
+// @Success      201               {object}  representations.ReadableBlock
+// @Failure      400               {object}  HTTPError
+// @Failure      403               {object}  HTTPError
+// @Failure      422               {object}  TxnVerificationError
+// @Router       /blockchain/accounts/{name}/send [post]
+func (ah *AccountHandler) SendFromAccount(ctx *gin.Context) {
+	name := ctx.Param("name")
+	log.Info("SendFromAccount handler called with name: ", name)
+
+	var input reps.AccountSendInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	to, err := ah.addressBookService.ResolveAddress(input.To)
+	if err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	newBlock, err := ah.accountService.SendFromAccount(name, to, input.Amount, input.TxnOptions)
+	if err != nil {
+		log.Error("error sending from account: ", err.Error())
+		var verificationErr *services.TxnVerificationError
+		if errors.As(err, &verificationErr) {
+			NewTxnVerificationError(ctx, verificationErr)
+			return
+		}
+		if errors.Is(err, services.ErrWatchOnly) || errors.Is(err, services.ErrWalletLocked) {
+			NewError(ctx, http.StatusForbidden, err)
+			return
+		}
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"block": ah.assemblerService.ToReadableBlock(newBlock)})
+}
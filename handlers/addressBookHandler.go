@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"net/http"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type AddressBookHandler struct {
+	addressBookService services.AddressBookService
+}
+
+func NewAddressBookHandler(addressBookService services.AddressBookService) *AddressBookHandler {
+	return &AddressBookHandler{
+		addressBookService: addressBookService,
+	}
+}
+
+// SaveEntry ... Label an address with a name
+// @Summary      Add an address book entry
+// @Description  Label an address with a human readable name, which can then be used in place of the address when sending coins. Saving an existing name updates its address
+// @Tags         Address Book
+// @Param        AddressBookInput  body      representations.AddressBookInput  true  "Name and address"
+// @Success      201               {object}  representations.AddressBookEntry
+// @Failure      400               {object}  HTTPError
+// @Router       /blockchain/addressbook [post]
+func (ah *AddressBookHandler) SaveEntry(ctx *gin.Context) {
+	log.Info("SaveEntry handler called")
+
+	var input reps.AddressBookInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	entry, err := ah.addressBookService.SaveEntry(input.Name, input.Address)
+	if err != nil {
+		log.Error("error saving address book entry: ", err.Error())
+		NewError(ctx, http.StatusBadRequest, err)
+	} else {
+		ctx.JSON(http.StatusCreated, gin.H{"entry": entry})
+	}
+}
+
+// GetEntries ... Get every address book entry
+// @Summary      Get the address book
+// @Description  Get every address book entry
+// @Tags         Address Book
+// @Success      200  {array}   representations.AddressBookEntry
+// @Failure      500  {object}  HTTPError
+// @Router       /blockchain/addressbook [get]
+func (ah *AddressBookHandler) GetEntries(ctx *gin.Context) {
+	log.Info("GetEntries handler called")
+
+	entries, err := ah.addressBookService.GetEntries()
+	if err != nil {
+		log.Error("error getting address book: ", err.Error())
+		NewError(ctx, http.StatusInternalServerError, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"entries": entries})
+	}
+}
+
+// GetEntry ... Get an address book entry by name
+// @Summary      Get an address book entry
+// @Description  Get the address labelled with a name
+// @Tags         Address Book
+// @Param        name  path      string  true  "Name"
+// @Success      200   {object}  representations.AddressBookEntry
+// @Failure      404   {object}  HTTPError
+// @Router       /blockchain/addressbook/{name} [get]
+func (ah *AddressBookHandler) GetEntry(ctx *gin.Context) {
+	name := ctx.Param("name")
+	log.Info("GetEntry handler called with name: ", name)
+
+	entry, err := ah.addressBookService.GetEntry(name)
+	if err != nil {
+		log.Error("error getting address book entry: ", err.Error())
+		NewError(ctx, http.StatusNotFound, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"entry": entry})
+	}
+}
+
+// DeleteEntry ... Remove an address book entry
+// @Summary      Delete an address book entry
+// @Description  Remove a name from the address book
+// @Tags         Address Book
+// @Param        name  path      string  true  "Name"
+// @Success      200   {string}  string  "message"
+// @Failure      404   {object}  HTTPError
+// @Router       /blockchain/addressbook/{name} [delete]
+func (ah *AddressBookHandler) DeleteEntry(ctx *gin.Context) {
+	name := ctx.Param("name")
+	log.Info("DeleteEntry handler called with name: ", name)
+
+	err := ah.addressBookService.DeleteEntry(name)
+	if err != nil {
+		log.Error("error deleting address book entry: ", err.Error())
+		NewError(ctx, http.StatusNotFound, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"message": "Address book entry deleted."})
+	}
+}
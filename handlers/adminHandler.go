@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/brucetieu/blockchain/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type AdminHandler struct {
+	consolidationService services.ConsolidationService
+	validatorSetService  services.ValidatorSetService
+	walletService        services.WalletService
+	txnAssembler         services.TxnAssemblerFac
+}
+
+func NewAdminHandler(consolidationService services.ConsolidationService, validatorSetService services.ValidatorSetService,
+	walletService services.WalletService) *AdminHandler {
+	return &AdminHandler{
+		consolidationService: consolidationService,
+		validatorSetService:  validatorSetService,
+		walletService:        walletService,
+		txnAssembler:         services.TxnAssembler,
+	}
+}
+
+// ConsolidateAddress ... Sweep an address's small outputs into one
+// @Summary      Consolidate unspent outputs
+// @Description  Queue a transaction in the mempool spending up to 100 of an address's outputs worth less than maxValue, smallest first, into a single output back to it, so later transactions from it need fewer inputs. It only goes ahead with at least 10 such outputs, and while the mempool is quiet unless forced. Without a fee or fee rate, it pays the low rate from the fee estimate
+// @Tags         Admin
+// @Param        ConsolidateInput  body      representations.ConsolidateInput  true  "Address to consolidate"
+// @Success      202               {object}  representations.ReadableTransaction
+// @Failure      400               {object}  HTTPError
+// @Failure      403               {object}  HTTPError
+// @Failure      422               {object}  TxnVerificationError
+// @Failure      500               {object}  HTTPError
+// @Failure      503               {object}  HTTPError
+// @Router       /blockchain/admin/consolidate [post]
+func (ah *AdminHandler) ConsolidateAddress(ctx *gin.Context) {
+	var input reps.ConsolidateInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if !ValidAddresses(ctx, ah.walletService, input.Address) {
+		return
+	}
+
+	log.Info("Consolidating address: ", utils.Pretty(input))
+
+	txn, err := ah.consolidationService.ConsolidateAddress(input.Address, input.MaxValue, input.Force, input.TxnOptions)
+	if errors.Is(err, services.ErrNothingToConsolidate) {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error consolidating address")
+		TxnError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"transaction": ah.txnAssembler.ToReadableTransaction(txn)})
+}
+
+// AddValidator ... Add a validator to a BFT chain
+// @Summary      Add validator
+// @Description  Queue a transaction in the mempool adding an address to the validators of a BFT chain at a height after the next block, signed by authority, a validator with a wallet on this node. Without a height it takes effect 10 blocks after the next one. Once it's on a block the change is on the chain for every node, and the address takes its turn proposing blocks after the validators already in the set from that height on
+// @Tags         Admin
+// @Param        ValidatorChangeInput  body      representations.ValidatorChangeInput  true  "Validator to add, and who signs the change"
+// @Success      202                   {object}  representations.ReadableTransaction
+// @Failure      400                   {object}  HTTPError
+// @Failure      403                   {object}  HTTPError
+// @Failure      422                   {object}  TxnVerificationError
+// @Failure      500                   {object}  HTTPError
+// @Router       /blockchain/admin/validators [post]
+func (ah *AdminHandler) AddValidator(ctx *gin.Context) {
+	var input reps.ValidatorChangeInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if !ValidAddresses(ctx, ah.walletService, input.Address, input.Authority) {
+		return
+	}
+
+	log.Info("Adding validator: ", utils.Pretty(input))
+
+	txn, err := ah.validatorSetService.AddValidator(input)
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error adding validator")
+		TxnError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"transaction": ah.txnAssembler.ToReadableTransaction(txn)})
+}
+
+// RemoveValidator ... Remove a validator from a BFT chain
+// @Summary      Remove validator
+// @Description  Queue a transaction in the mempool removing an address from the validators of a BFT chain at a height after the next block, signed by authority, a validator with a wallet on this node. Without a height it takes effect 10 blocks after the next one. From that height on, the address takes no more turns proposing blocks and its votes stop counting. The last validator can't be removed
+// @Tags         Admin
+// @Param        address               path      string                                true  "Validator to remove"
+// @Param        ValidatorChangeInput  body      representations.ValidatorChangeInput  true  "Who signs the change, and when it takes effect"
+// @Success      202                   {object}  representations.ReadableTransaction
+// @Failure      400                   {object}  HTTPError
+// @Failure      403                   {object}  HTTPError
+// @Failure      422                   {object}  TxnVerificationError
+// @Failure      500                   {object}  HTTPError
+// @Router       /blockchain/admin/validators/{address} [delete]
+func (ah *AdminHandler) RemoveValidator(ctx *gin.Context) {
+	var input reps.ValidatorChangeInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	input.Address = ctx.Param("address")
+
+	if !ValidAddresses(ctx, ah.walletService, input.Address, input.Authority) {
+		return
+	}
+
+	log.Info("Removing validator: ", utils.Pretty(input))
+
+	txn, err := ah.validatorSetService.RemoveValidator(input)
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error removing validator")
+		TxnError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"transaction": ah.txnAssembler.ToReadableTransaction(txn)})
+}
+
+// GetValidatorSchedule ... Get the validators of a BFT chain and every change made to them
+// @Summary      Get validator schedule
+// @Description  Get the validators of a BFT chain as of its last block, in the order they take turns proposing blocks, and every change made to them on the chain in the order they take effect, with the transaction and block each is on, the validator that signed it, and whether it's still scheduled or active
+// @Tags         Admin
+// @Success      200  {object}  representations.ValidatorSchedule
+// @Failure      400  {object}  HTTPError
+// @Router       /blockchain/admin/validators [get]
+func (ah *AdminHandler) GetValidatorSchedule(ctx *gin.Context) {
+	log.Info("GetValidatorSchedule handler called")
+
+	schedule, err := ah.validatorSetService.GetValidatorSchedule()
+	if err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"schedule": schedule})
+}
@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/brucetieu/blockchain/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type AssetHandler struct {
+	assetService  services.AssetService
+	walletService services.WalletService
+	txnAssembler  services.TxnAssemblerFac
+}
+
+func NewAssetHandler(assetService services.AssetService, walletService services.WalletService) *AssetHandler {
+	return &AssetHandler{
+		assetService:  assetService,
+		walletService: walletService,
+		txnAssembler:  services.TxnAssembler,
+	}
+}
+
+// CreateAsset ... Register an asset and issue its first units
+// @Summary      Create an asset
+// @Description  Register a token with a symbol of 1 to 12 capital letters or digits, and queue a transaction in the mempool issuing its first amount units to the issuer. An optional max supply caps the units that can ever be issued, and mintable lets the issuer issue more later. The transaction's fee is paid in coins from the issuer
+// @Tags         Assets
+// @Param        AssetInput  body      representations.CreateAssetInput  true  "Issuer, symbol and amount"
+// @Success      202         {object}  representations.Asset
+// @Failure      400         {object}  HTTPError
+// @Failure      403         {object}  HTTPError
+// @Failure      422         {object}  TxnVerificationError
+// @Failure      500         {object}  HTTPError
+// @Router       /blockchain/assets [post]
+func (ah *AssetHandler) CreateAsset(ctx *gin.Context) {
+	var input reps.CreateAssetInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if !ValidAddresses(ctx, ah.walletService, input.Issuer) {
+		return
+	}
+	if !services.IsValidAssetSymbol(input.Symbol) {
+		NewError(ctx, http.StatusBadRequest, fmt.Errorf("asset symbol must be 1 to 12 capital letters or digits, not %s", input.Symbol))
+		return
+	}
+
+	log.Info("Creating asset: ", utils.Pretty(input))
+
+	asset, txn, err := ah.assetService.CreateAsset(input.Issuer, input.Symbol, input.Name, input.Amount, input.MaxSupply, input.Mintable, input.TxnOptions)
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error creating asset")
+		TxnError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"asset": asset, "transaction": ah.txnAssembler.ToReadableTransaction(txn)})
+}
+
+// GetAssets ... Get every registered asset
+// @Summary      Get all assets
+// @Description  Get every registered asset, with the units of each issued on the chain so far
+// @Tags         Assets
+// @Success      200  {array}   representations.Asset
+// @Failure      500  {object}  HTTPError
+// @Router       /blockchain/assets [get]
+func (ah *AssetHandler) GetAssets(ctx *gin.Context) {
+	log.Info("GetAssets handler called")
+
+	assets, err := ah.assetService.GetAssets()
+	if err != nil {
+		log.Error("error getting assets: ", err.Error())
+		NewError(ctx, http.StatusInternalServerError, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"assets": assets})
+	}
+}
+
+// GetAsset ... Get an asset
+// @Summary      Get an asset
+// @Description  Get an asset, with the units issued on the chain so far
+// @Tags         Assets
+// @Param        assetId  path      string  true  "Asset ID"
+// @Success      200      {object}  representations.Asset
+// @Failure      404      {object}  HTTPError
+// @Router       /blockchain/assets/{assetId} [get]
+func (ah *AssetHandler) GetAsset(ctx *gin.Context) {
+	assetId := ctx.Param("assetId")
+	log.Info("GetAsset handler called with assetId: ", assetId)
+
+	asset, err := ah.assetService.GetAsset(assetId)
+	if err != nil {
+		log.Error("error getting asset: ", err.Error())
+		NewError(ctx, http.StatusNotFound, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"asset": asset})
+	}
+}
+
+// IssueAsset ... Issue more units of an asset
+// @Summary      Issue more of an asset
+// @Description  Queue a transaction in the mempool issuing amount more units of a mintable asset to its issuer, up to its max supply. The transaction's fee is paid in coins from the issuer
+// @Tags         Assets
+// @Param        assetId          path      string                           true  "Asset ID"
+// @Param        IssueAssetInput  body      representations.IssueAssetInput  true  "Amount to issue"
+// @Success      202              {object}  representations.ReadableTransaction
+// @Failure      400              {object}  HTTPError
+// @Failure      403              {object}  HTTPError
+// @Failure      404              {object}  HTTPError
+// @Failure      422              {object}  TxnVerificationError
+// @Failure      500              {object}  HTTPError
+// @Router       /blockchain/assets/{assetId}/issue [post]
+func (ah *AssetHandler) IssueAsset(ctx *gin.Context) {
+	assetId := ctx.Param("assetId")
+
+	var input reps.IssueAssetInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if _, err := ah.assetService.GetAsset(assetId); err != nil {
+		NewError(ctx, http.StatusNotFound, err)
+		return
+	}
+
+	log.WithField("assetId", assetId).Info("Issuing asset: ", utils.Pretty(input))
+
+	txn, err := ah.assetService.IssueAsset(assetId, input.Amount, input.TxnOptions)
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error issuing asset")
+		TxnError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"transaction": ah.txnAssembler.ToReadableTransaction(txn)})
+}
+
+// TransferAsset ... Send units of an asset
+// @Summary      Transfer an asset
+// @Description  Create and sign a transaction sending amount units of an asset from one address to another, and queue it in the mempool. The transaction's fee is paid in coins from the sender
+// @Tags         Assets
+// @Param        assetId             path      string                              true  "Asset ID"
+// @Param        TransferAssetInput  body      representations.TransferAssetInput  true  "From, to and amount"
+// @Success      202                 {object}  representations.ReadableTransaction
+// @Failure      400                 {object}  HTTPError
+// @Failure      403                 {object}  HTTPError
+// @Failure      404                 {object}  HTTPError
+// @Failure      422                 {object}  TxnVerificationError
+// @Failure      500                 {object}  HTTPError
+// @Router       /blockchain/assets/{assetId}/transfer [post]
+func (ah *AssetHandler) TransferAsset(ctx *gin.Context) {
+	assetId := ctx.Param("assetId")
+
+	var input reps.TransferAssetInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if !ValidAddresses(ctx, ah.walletService, input.From, input.To) {
+		return
+	}
+
+	if _, err := ah.assetService.GetAsset(assetId); err != nil {
+		NewError(ctx, http.StatusNotFound, err)
+		return
+	}
+
+	log.WithField("assetId", assetId).Info("Transferring asset: ", utils.Pretty(input))
+
+	txn, err := ah.assetService.TransferAsset(assetId, input.From, input.To, input.Amount, input.TxnOptions)
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error transferring asset")
+		TxnError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"transaction": ah.txnAssembler.ToReadableTransaction(txn)})
+}
+
+// MintNFT ... Mint an NFT
+// @Summary      Mint an NFT
+// @Description  Register a non-fungible token for a payload, given as the hex sha256 hash of it, and queue a transaction in the mempool minting its single unit to the issuer. Its id commits to the hash, so the metadata can't change once it's minted. The transaction's fee is paid in coins from the issuer
+// @Tags         NFTs
+// @Param        NFTInput  body      representations.CreateNFTInput  true  "Issuer and metadata hash"
+// @Success      202       {object}  representations.Asset
+// @Failure      400       {object}  HTTPError
+// @Failure      403       {object}  HTTPError
+// @Failure      422       {object}  TxnVerificationError
+// @Failure      500       {object}  HTTPError
+// @Router       /blockchain/nfts [post]
+func (ah *AssetHandler) MintNFT(ctx *gin.Context) {
+	var input reps.CreateNFTInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if !ValidAddresses(ctx, ah.walletService, input.Issuer) {
+		return
+	}
+	if !services.IsValidMetadataHash(strings.ToLower(input.MetadataHash)) {
+		NewError(ctx, http.StatusBadRequest, fmt.Errorf("metadata hash must be a hex sha256 hash, not %s", input.MetadataHash))
+		return
+	}
+
+	log.Info("Minting NFT: ", utils.Pretty(input))
+
+	nft, txn, err := ah.assetService.MintNFT(input.Issuer, input.Name, input.MetadataHash, input.TxnOptions)
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error minting NFT")
+		TxnError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"nft": nft, "transaction": ah.txnAssembler.ToReadableTransaction(txn)})
+}
+
+// GetNFT ... Get an NFT and its provenance
+// @Summary      Get an NFT
+// @Description  Get an NFT with its current owner, and every transaction on the chain that minted or moved it, oldest first
+// @Tags         NFTs
+// @Param        nftId  path      string  true  "NFT ID"
+// @Success      200    {object}  representations.NFTProvenance
+// @Failure      404    {object}  HTTPError
+// @Router       /blockchain/nfts/{nftId} [get]
+func (ah *AssetHandler) GetNFT(ctx *gin.Context) {
+	nftId := ctx.Param("nftId")
+	log.Info("GetNFT handler called with nftId: ", nftId)
+
+	provenance, err := ah.assetService.GetNFTProvenance(nftId)
+	if err != nil {
+		log.Error("error getting NFT: ", err.Error())
+		NewError(ctx, http.StatusNotFound, err)
+	} else {
+		ctx.JSON(http.StatusOK, provenance)
+	}
+}
+
+// TransferNFT ... Send an NFT on
+// @Summary      Transfer an NFT
+// @Description  Create and sign a transaction sending an NFT from its current owner to another address, and queue it in the mempool. The owner must be a wallet on this node, and pays the transaction's fee in coins
+// @Tags         NFTs
+// @Param        nftId             path      string                            true  "NFT ID"
+// @Param        TransferNFTInput  body      representations.TransferNFTInput  true  "Recipient"
+// @Success      202               {object}  representations.ReadableTransaction
+// @Failure      400               {object}  HTTPError
+// @Failure      403               {object}  HTTPError
+// @Failure      404               {object}  HTTPError
+// @Failure      422               {object}  TxnVerificationError
+// @Failure      500               {object}  HTTPError
+// @Router       /blockchain/nfts/{nftId}/transfer [post]
+func (ah *AssetHandler) TransferNFT(ctx *gin.Context) {
+	nftId := ctx.Param("nftId")
+
+	var input reps.TransferNFTInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if !ValidAddresses(ctx, ah.walletService, input.To) {
+		return
+	}
+
+	if _, err := ah.assetService.GetNFTProvenance(nftId); err != nil {
+		NewError(ctx, http.StatusNotFound, err)
+		return
+	}
+
+	log.WithField("nftId", nftId).Info("Transferring NFT: ", utils.Pretty(input))
+
+	txn, err := ah.assetService.TransferNFT(nftId, input.To, input.TxnOptions)
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error transferring NFT")
+		TxnError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"transaction": ah.txnAssembler.ToReadableTransaction(txn)})
+}
@@ -1,7 +1,12 @@
 package handlers
 
 import (
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	reps "github.com/brucetieu/blockchain/representations"
 	"github.com/brucetieu/blockchain/services"
@@ -11,14 +16,23 @@ import (
 )
 
 type BlockchainHandler struct {
-	blockchainService services.BlockchainService
-	assemblerService  services.BlockAssemblerFac
+	blockchainService  services.BlockchainService
+	mempoolService     services.MempoolService
+	walletService      services.WalletService
+	addressBookService services.AddressBookService
+	assemblerService   services.BlockAssemblerFac
+	headerAssembler    services.HeaderAssemblerFac
 }
 
-func NewBlockchainHandler(blockchainService services.BlockchainService) *BlockchainHandler {
+func NewBlockchainHandler(blockchainService services.BlockchainService, mempoolService services.MempoolService,
+	walletService services.WalletService, addressBookService services.AddressBookService) *BlockchainHandler {
 	return &BlockchainHandler{
-		blockchainService: blockchainService,
-		assemblerService:  services.BlockAssembler,
+		blockchainService:  blockchainService,
+		mempoolService:     mempoolService,
+		walletService:      walletService,
+		addressBookService: addressBookService,
+		assemblerService:   services.BlockAssembler,
+		headerAssembler:    services.HeaderAssembler,
 	}
 }
 
@@ -29,11 +43,12 @@ func (bch *BlockchainHandler) BlockchainHome(ctx *gin.Context) {
 
 // CreateBlockchain ... Create the blockchain
 // @Summary      Create the blockchain
-// @Description  Create a blockchain by mining the genesis block
+// @Description  Create a blockchain by mining the genesis block, paying the reward to the given address, and any allocations on top of it, e.g. to start a test network with funded addresses. Allocations can be to addresses without a wallet on this node. If GENESIS_FILE is set, its spec sets the chain id, the genesis difficulty, the block interval, the coinbase message and more allocations. Allocations are ignored if the blockchain already exists
 // @Tags         Blocks
 // @Param        BlockchainInput  body      representations.CreateBlockchainInput  true  "Create Blockchain"
 // @Success      201              {object}  representations.ReadableBlock
 // @Success      200              {object}  representations.ReadableBlock
+// @Failure      400              {object}  HTTPError
 // @Failure      404              {object}  HTTPError
 // @Router       /blockchain [post]
 func (bch *BlockchainHandler) CreateBlockchain(ctx *gin.Context) {
@@ -47,8 +62,12 @@ func (bch *BlockchainHandler) CreateBlockchain(ctx *gin.Context) {
 		return
 	}
 
+	if !ValidAddresses(ctx, bch.walletService, input.To) {
+		return
+	}
+
 	// Create the genesis if it doesn't exist. Otherwise return a message that blockchain already exists
-	decodedGenesis, exists, err := bch.blockchainService.CreateBlockchain(input.To)
+	decodedGenesis, exists, err := bch.blockchainService.CreateBlockchain(input.To, input.Allocations)
 	if err != nil {
 		log.WithField("error", err.Error()).Error("Error creating blockchain")
 		NewError(ctx, http.StatusNotFound, err)
@@ -67,11 +86,13 @@ func (bch *BlockchainHandler) CreateBlockchain(ctx *gin.Context) {
 
 // AddToBlockchain ... Mine or add a block to the blockchain
 // @Summary      Add a block
-// @Description  Add a block to the end of the blockchain
+// @Description  Create a transaction paying either to and amount, or every one of recipients, queue it in the mempool, and mine a block from the pending transactions paying the highest fee rates, with from collecting the reward. A block holds at most MAX_BLOCK_TXNS transactions besides the coinbase, so a transaction outbid by others, or with a lock time that hasn't passed yet, stays pending; txnId is returned to follow it. An optional fee or fee rate (coins per 1000 bytes) goes to the miner, and an optional memo of up to 256 bytes is stored with it on-chain. From and to can be address book names instead of addresses
 // @Tags         Blocks
 // @Param        BlockInput  body      representations.CreateBlockInput  true  "Mine block"
 // @Success      201         {object}  representations.ReadableBlock
 // @Failure      400         {object}  HTTPError
+// @Failure      403         {object}  HTTPError
+// @Failure      422         {object}  TxnVerificationError
 // @Failure      500         {object}  HTTPError
 // @Router       /blockchain/block [post]
 func (bch *BlockchainHandler) AddToBlockchain(ctx *gin.Context) {
@@ -82,20 +103,38 @@ func (bch *BlockchainHandler) AddToBlockchain(ctx *gin.Context) {
 		return
 	}
 
+	recipients, ok := PaymentRecipients(ctx, bch.walletService, bch.addressBookService, &input.From, input.To, input.Amount, input.Recipients)
+	if !ok {
+		return
+	}
+
 	log.Info("Adding Block to blockchain: ", utils.Pretty(input))
 
-	// Create block and persist to db
-	newBlock, err := bch.blockchainService.AddToBlockChain(input.From, input.To, input.Amount)
+	txn, err := bch.blockchainService.CreatePayment(input.From, recipients, input.TxnOptions)
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error creating transaction")
+		TxnError(ctx, err)
+		return
+	}
+
+	if _, err := bch.mempoolService.AddTransaction(txn); err != nil {
+		log.WithField("error", err.Error()).Error("Error queueing transaction")
+		TxnError(ctx, err)
+		return
+	}
+
+	// Mined along with whatever else is pending, highest fee rates first
+	newBlock, err := bch.mempoolService.MinePendingTransactions(input.From, "")
 	if err != nil {
 		log.WithField("error", err.Error()).Error("Error adding block")
-		NewError(ctx, http.StatusInternalServerError, err)
+		TxnError(ctx, err)
 		return
 	}
 
 	// Format return data to be readable
 	data := bch.assemblerService.ToReadableBlock(newBlock)
 
-	ctx.JSON(http.StatusCreated, gin.H{"block": data})
+	ctx.JSON(http.StatusCreated, gin.H{"block": data, "txnId": hex.EncodeToString(txn.ID)})
 }
 
 // GetBlockchain ... Print out all blocks in blockchain
@@ -114,10 +153,11 @@ func (bch *BlockchainHandler) GetBlockchain(ctx *gin.Context) {
 		return
 	}
 
+	finalizedHeight := bch.finalizedHeight()
 	data := make([]reps.ReadableBlock, 0)
 
 	for _, block := range blockchain {
-		data = append(data, bch.assemblerService.ToReadableBlock(block))
+		data = append(data, bch.readableBlock(block, finalizedHeight))
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{"blockchain": data})
@@ -137,7 +177,7 @@ func (bch *BlockchainHandler) GetGenesisBlock(ctx *gin.Context) {
 		log.WithField("error", err.Error()).Error("Error getting genesis block")
 		NewError(ctx, http.StatusNotFound, err)
 	} else {
-		formattedGenesis := bch.assemblerService.ToReadableBlock(genesis)
+		formattedGenesis := bch.readableBlock(genesis, bch.finalizedHeight())
 		ctx.JSON(http.StatusOK, gin.H{"genesis": formattedGenesis})
 	}
 }
@@ -159,7 +199,212 @@ func (bch *BlockchainHandler) GetBlock(ctx *gin.Context) {
 		log.WithField("error", err.Error()).Error("Error getting block")
 		NewError(ctx, http.StatusNotFound, err)
 	} else {
-		ctx.JSON(http.StatusOK, gin.H{"block": bch.assemblerService.ToReadableBlock(block)})
+		ctx.JSON(http.StatusOK, gin.H{"block": bch.readableBlock(block, bch.finalizedHeight())})
+	}
+}
+
+// GetBlockByHeight ... Get block by height
+// @Summary      Get a block by height
+// @Description  Get the block at a height on the blockchain. The genesis block is at height 0
+// @Tags         Blocks
+// @Param        height  path      int  true  "Height"
+// @Success      200     {object}  representations.ReadableBlock
+// @Failure      400     {object}  HTTPError
+// @Failure      404     {object}  HTTPError
+// @Router       /blockchain/block/height/{height} [get]
+func (bch *BlockchainHandler) GetBlockByHeight(ctx *gin.Context) {
+	log.Info("Getting block at height: ", ctx.Param("height"))
+
+	height, err := strconv.Atoi(ctx.Param("height"))
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error parsing height")
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	block, err := bch.blockchainService.GetBlockByHeight(height)
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error getting block")
+		NewError(ctx, http.StatusNotFound, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"block": bch.readableBlock(block, bch.finalizedHeight())})
+	}
+}
+
+// GetBlocks ... Get a page of blocks by height
+// @Summary      Get blocks by height
+// @Description  Get up to count blocks from height from on, lowest first, to page through the chain. count defaults to 20 and can be at most 100. Past the last block there are none
+// @Tags         Blocks
+// @Param        from   query     int  false  "Height to start from"
+// @Param        count  query     int  false  "Number of blocks"
+// @Success      200    {array}   representations.ReadableBlock
+// @Failure      400    {object}  HTTPError
+// @Failure      500    {object}  HTTPError
+// @Router       /blockchain/blocks [get]
+func (bch *BlockchainHandler) GetBlocks(ctx *gin.Context) {
+	log.Infof("Getting %s blocks from height %s", ctx.Query("count"), ctx.Query("from"))
+
+	from, err := strconv.Atoi(ctx.DefaultQuery("from", "0"))
+	if err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	count, err := strconv.Atoi(ctx.DefaultQuery("count", "0"))
+	if err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if from < 0 || count < 0 || count > services.MaxBlocksPerPage {
+		NewError(ctx, http.StatusBadRequest, fmt.Errorf("from can't be negative, and count must be between 1 and %d", services.MaxBlocksPerPage))
+		return
+	}
+
+	blocks, err := bch.blockchainService.GetBlocksByHeight(from, count)
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error getting blocks")
+		NewError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	finalizedHeight := bch.finalizedHeight()
+	data := make([]reps.ReadableBlock, 0)
+	for _, block := range blocks {
+		data = append(data, bch.readableBlock(block, finalizedHeight))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"blocks": data})
+}
+
+// GetStaleBlocks ... Get blocks that lost a fork race
+// @Summary      Get stale blocks
+// @Description  Get up to count solved blocks, latest first, that built on a block on the chain but lost to another block on the same parent, along with the hash of the block that won and how many there have been in all. count defaults to 20 and can be at most 100
+// @Tags         Blocks
+// @Param        count  query     int  false  "Number of stale blocks"
+// @Success      200    {array}   representations.StaleBlock
+// @Failure      400    {object}  HTTPError
+// @Failure      500    {object}  HTTPError
+// @Router       /blockchain/blocks/stale [get]
+func (bch *BlockchainHandler) GetStaleBlocks(ctx *gin.Context) {
+	log.Info("Getting stale blocks")
+
+	count, err := strconv.Atoi(ctx.DefaultQuery("count", "0"))
+	if err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if count < 0 || count > services.MaxBlocksPerPage {
+		NewError(ctx, http.StatusBadRequest, fmt.Errorf("count must be between 1 and %d", services.MaxBlocksPerPage))
+		return
+	}
+
+	staleBlocks, total, err := bch.blockchainService.GetStaleBlocks(count)
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error getting stale blocks")
+		NewError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"staleBlocks": staleBlocks, "total": total})
+}
+
+// GetBlockHeader ... Get a block's header by block ID
+// @Summary      Get a block header
+// @Description  Get the header fields of a block by block ID, without its transactions, for light clients
+// @Tags         Blocks
+// @Param        blockId  path      string  true  "Block ID"
+// @Success      200      {object}  representations.BlockHeader
+// @Failure      404      {object}  HTTPError
+// @Router       /blockchain/block/{blockId}/header [get]
+func (bch *BlockchainHandler) GetBlockHeader(ctx *gin.Context) {
+	blockId := ctx.Param("blockId")
+	log.Info("Getting header of block with blockId: ", blockId)
+
+	block, err := bch.blockchainService.GetBlockHeader(blockId)
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error getting block header")
+		NewError(ctx, http.StatusNotFound, err)
+	} else {
+		header := bch.headerAssembler.ToBlockHeader(block)
+		header.Finality = services.BlockFinalityStatus(block.Height, bch.finalizedHeight())
+		ctx.JSON(http.StatusOK, gin.H{"header": header})
+	}
+}
+
+// GetBlockHeaders ... Get a page of block headers by height
+// @Summary      Get block headers by height
+// @Description  Get the headers of up to count blocks from height from on, lowest first, without their transactions, for light clients following the chain. count defaults to 20 and can be at most 2000. Past the last block there are none
+// @Tags         Blocks
+// @Param        from   query     int  false  "Height to start from"
+// @Param        count  query     int  false  "Number of headers"
+// @Success      200    {array}   representations.BlockHeader
+// @Failure      400    {object}  HTTPError
+// @Failure      500    {object}  HTTPError
+// @Router       /blockchain/headers [get]
+func (bch *BlockchainHandler) GetBlockHeaders(ctx *gin.Context) {
+	log.Infof("Getting %s block headers from height %s", ctx.Query("count"), ctx.Query("from"))
+
+	from, err := strconv.Atoi(ctx.DefaultQuery("from", "0"))
+	if err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	count, err := strconv.Atoi(ctx.DefaultQuery("count", "0"))
+	if err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if from < 0 || count < 0 || count > services.MaxHeadersPerPage {
+		NewError(ctx, http.StatusBadRequest, fmt.Errorf("from can't be negative, and count must be between 1 and %d", services.MaxHeadersPerPage))
+		return
+	}
+
+	blocks, err := bch.blockchainService.GetBlockHeaders(from, count)
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error getting block headers")
+		NewError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	finalizedHeight := bch.finalizedHeight()
+	headers := bch.headerAssembler.ToBlockHeaders(blocks)
+	for i := range headers {
+		headers[i].Finality = services.BlockFinalityStatus(headers[i].Height, finalizedHeight)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"headers": headers})
+}
+
+// GetMerkleBranch ... Get the merkle branch proving a transaction is on a block
+// @Summary      Get a merkle proof
+// @Description  Get the merkle branch from a transaction up to the merkle root of a block it's on, so light clients holding only the block header can check it's there. Hash leafData to get leafHash, then hash it with each step in turn, the step's hash going on the side it says, to get the merkle root
+// @Tags         Blocks
+// @Param        blockId  path      string  true  "Block ID"
+// @Param        txnId    path      string  true  "Transaction ID"
+// @Success      200      {object}  representations.MerkleBranch
+// @Failure      404      {object}  HTTPError
+// @Failure      500      {object}  HTTPError
+// @Router       /blockchain/block/{blockId}/proof/{txnId} [get]
+func (bch *BlockchainHandler) GetMerkleBranch(ctx *gin.Context) {
+	blockId := ctx.Param("blockId")
+	txnId := ctx.Param("txnId")
+	log.WithFields(log.Fields{"blockId": blockId, "txnId": txnId}).Info("Getting merkle branch")
+
+	if _, err := bch.blockchainService.GetBlock(blockId); err != nil {
+		log.WithField("error", err.Error()).Error("Error getting block")
+		NewError(ctx, http.StatusNotFound, err)
+		return
+	}
+
+	branch, err := bch.blockchainService.GetMerkleBranch(blockId, txnId)
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error getting merkle branch")
+		if errors.Is(err, services.ErrTxnNotOnBlock) {
+			NewError(ctx, http.StatusNotFound, err)
+		} else {
+			NewError(ctx, http.StatusInternalServerError, err)
+		}
+	} else {
+		ctx.JSON(http.StatusOK, branch)
 	}
 }
 
@@ -178,6 +423,222 @@ func (bch *BlockchainHandler) GetLastBlock(ctx *gin.Context) {
 		log.WithField("error", err.Error()).Error("Error getting last block")
 		NewError(ctx, http.StatusNotFound, err)
 	} else {
-		ctx.JSON(http.StatusOK, gin.H{"block": bch.assemblerService.ToReadableBlock(lastBlock)})
+		ctx.JSON(http.StatusOK, gin.H{"block": bch.readableBlock(lastBlock, bch.finalizedHeight())})
+	}
+}
+
+// GetLastFinalizedBlock ... Get the last block that can't be reorganized away
+// @Summary      Get the last final block
+// @Description  Get the last block that can't be reorganized away, so neither it nor anything before it will change: the last one more than two thirds of the validators voted for on a BFT chain, or the last one with FINALITY_DEPTH confirmations on other chains. Blocks after it are tentative
+// @Tags         Blocks
+// @Success      200  {object}  representations.ReadableBlock
+// @Failure      404  {object}  HTTPError
+// @Router       /blockchain/blocks/finalized/latest [get]
+func (bch *BlockchainHandler) GetLastFinalizedBlock(ctx *gin.Context) {
+	log.Info("Getting last finalized block")
+
+	block, err := bch.blockchainService.GetLastFinalizedBlock()
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error getting last finalized block")
+		NewError(ctx, http.StatusNotFound, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"block": bch.readableBlock(block, block.Height)})
+	}
+}
+
+// Height of the last final block, -1 if it can't be told
+func (bch *BlockchainHandler) finalizedHeight() int {
+	height, err := bch.blockchainService.GetFinalizedHeight()
+	if err != nil {
+		return -1
+	}
+	return height
+}
+
+// Readable block marked final or tentative, against the last final block at finalizedHeight
+func (bch *BlockchainHandler) readableBlock(block reps.Block, finalizedHeight int) reps.ReadableBlock {
+	readable := bch.assemblerService.ToReadableBlock(block)
+	readable.Finality = services.BlockFinalityStatus(block.Height, finalizedHeight)
+	return readable
+}
+
+// GetOutputStatus ... Get the status of a single transaction output
+// @Summary      Get output status
+// @Description  Get whether a transaction output is unspent, spent (and by which transaction) or nonexistent
+// @Tags         Transactions
+// @Param        txnId  path      string  true  "Transaction ID"
+// @Param        index  path      int     true  "Output index"
+// @Success      200    {object}  representations.OutputStatus
+// @Failure      400    {object}  HTTPError
+// @Failure      500    {object}  HTTPError
+// @Router       /blockchain/output/{txnId}/{index}/status [get]
+func (bch *BlockchainHandler) GetOutputStatus(ctx *gin.Context) {
+	txnId := ctx.Param("txnId")
+	log.Infof("Getting status of output %s for transaction %s", ctx.Param("index"), txnId)
+
+	index, err := strconv.Atoi(ctx.Param("index"))
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error parsing output index")
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if _, err := hex.DecodeString(txnId); err != nil {
+		log.WithField("error", err.Error()).Error("Error parsing transaction id")
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	outputStatus, err := bch.blockchainService.GetOutputStatus(txnId, index)
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error getting output status")
+		NewError(ctx, http.StatusInternalServerError, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"output": outputStatus})
+	}
+}
+
+// GetChainParams ... Get the chain parameters
+// @Summary      Get chain parameters
+// @Description  Get the parameters the blockchain is running with, e.g. the network byte used in addresses
+// @Tags         Blocks
+// @Success      200  {object}  representations.ChainParams
+// @Router       /blockchain/params [get]
+func (bch *BlockchainHandler) GetChainParams(ctx *gin.Context) {
+	log.Info("Getting chain params")
+	ctx.JSON(http.StatusOK, gin.H{"params": bch.blockchainService.GetChainParams()})
+}
+
+// GetChainInfo ... Get where the chain is at
+// @Summary      Get chain info
+// @Description  Get the height and hash of the last block, the difficulty and reward of the next one, the height the reward next halves at, coins paid out in rewards so far, and the chain parameters
+// @Tags         Blocks
+// @Success      200  {object}  representations.ChainInfo
+// @Failure      404  {object}  HTTPError
+// @Router       /blockchain/info [get]
+func (bch *BlockchainHandler) GetChainInfo(ctx *gin.Context) {
+	log.Info("Getting chain info")
+
+	info, err := bch.blockchainService.GetChainInfo()
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error getting chain info")
+		NewError(ctx, http.StatusNotFound, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"info": info})
+	}
+}
+
+// GetChainTips ... Get the last block of every branch
+// @Summary      Get chain tips
+// @Description  Get the last block of the chain, with status active, and of every side branch the node has stored, with status side, along with the height each branch forks off the chain at and how many blocks it has since. Side branches come from blocks received that build on a block other than the last, or that a reorg took off the chain
+// @Tags         Blocks
+// @Success      200  {array}   representations.ChainTip
+// @Failure      404  {object}  HTTPError
+// @Router       /blockchain/tips [get]
+func (bch *BlockchainHandler) GetChainTips(ctx *gin.Context) {
+	log.Info("Getting chain tips")
+
+	tips, err := bch.blockchainService.GetChainTips()
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error getting chain tips")
+		NewError(ctx, http.StatusNotFound, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"tips": tips})
+	}
+}
+
+// GetSnapshot ... Take a snapshot of the chain
+// @Summary      Get a chain snapshot
+// @Description  Get a snapshot of the chain at its last block, for a new node to start from with SNAPSHOT_FILE instead of syncing from the genesis block: the chain params, the headers up to the block the blocks after it are validated against, and the UTXO set as of it along with its hash. The new node only takes it if the block's hash is the SNAPSHOT_HASH its operator trusts
+// @Tags         Blockchain
+// @Success      200  {object}  representations.ChainSnapshot
+// @Failure      404  {object}  HTTPError
+// @Router       /blockchain/snapshot [get]
+func (bch *BlockchainHandler) GetSnapshot(ctx *gin.Context) {
+	log.Info("Taking chain snapshot")
+
+	snapshot, err := bch.blockchainService.GetSnapshot()
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error taking chain snapshot")
+		NewError(ctx, http.StatusNotFound, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"snapshot": snapshot})
+	}
+}
+
+// GetMetrics ... Get the node's counters
+// @Summary      Get metrics
+// @Description  Get counters of what the node has seen since it started: reorgs, how many of them took more blocks off the chain than REORG_ALERT_DEPTH and the deepest one, and chain events delivered to WEBHOOK_URLS or not
+// @Tags         Blockchain
+// @Success      200  {object}  representations.Metrics
+// @Router       /blockchain/metrics [get]
+func (bch *BlockchainHandler) GetMetrics(ctx *gin.Context) {
+	log.Info("Getting metrics")
+
+	ctx.JSON(http.StatusOK, gin.H{"metrics": services.GetMetrics()})
+}
+
+// GetVersionBitsStats ... Count version bit signals
+// @Summary      Get version bit signals
+// @Description  Get how many of the last window blocks signal with each version bit, i.e. are mined by nodes ready for the rule change the bit stands for. Only versions with the top bits 001 signal. window defaults to 100 and can be at most 2016. Nodes signal with the bits in SIGNAL_BITS
+// @Tags         Blocks
+// @Param        window  query     int  false  "Number of recent blocks"
+// @Success      200     {object}  representations.VersionBitsStats
+// @Failure      400     {object}  HTTPError
+// @Failure      404     {object}  HTTPError
+// @Router       /blockchain/versionbits [get]
+func (bch *BlockchainHandler) GetVersionBitsStats(ctx *gin.Context) {
+	log.Info("Counting version bits over window: ", ctx.Query("window"))
+
+	window, err := strconv.Atoi(ctx.DefaultQuery("window", "0"))
+	if err != nil || window < 0 || window > services.MaxSignalWindow {
+		NewError(ctx, http.StatusBadRequest, fmt.Errorf("window must be between 1 and %d", services.MaxSignalWindow))
+		return
+	}
+
+	stats, err := bch.blockchainService.GetVersionBitsStats(window)
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error counting version bits")
+		NewError(ctx, http.StatusNotFound, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"versionBits": stats})
+	}
+}
+
+// GetBlockStats ... Block time, difficulty and hash rate over recent blocks
+// @Summary      Get block stats
+// @Description  Get the average time between blocks in seconds, their average difficulty and an estimate of the network's hash rate in hashes per second, over each window of recent blocks, along with the difficulty the next block needs. windows is comma separated numbers of blocks, from 2 to 10000 and at most 5 of them, and defaults to 10,100,1000. A window longer than the chain covers all of it
+// @Tags         Blocks
+// @Param        windows  query     string  false  "Comma separated numbers of recent blocks"
+// @Success      200      {object}  representations.BlockStats
+// @Failure      400      {object}  HTTPError
+// @Failure      404      {object}  HTTPError
+// @Router       /blockchain/stats/blocks [get]
+func (bch *BlockchainHandler) GetBlockStats(ctx *gin.Context) {
+	log.Info("Getting block stats over windows: ", ctx.Query("windows"))
+
+	windows := make([]int, 0)
+	for _, field := range strings.Split(ctx.Query("windows"), ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		window, err := strconv.Atoi(field)
+		if err != nil || window < 2 || window > services.MaxStatsWindow {
+			NewError(ctx, http.StatusBadRequest, fmt.Errorf("window must be between 2 and %d, not %s", services.MaxStatsWindow, field))
+			return
+		}
+		windows = append(windows, window)
+	}
+	if len(windows) > services.MaxStatsWindows {
+		NewError(ctx, http.StatusBadRequest, fmt.Errorf("stats can be taken over at most %d windows", services.MaxStatsWindows))
+		return
+	}
+
+	stats, err := bch.blockchainService.GetBlockStats(windows)
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error getting block stats")
+		NewError(ctx, http.StatusNotFound, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"stats": stats})
 	}
 }
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/brucetieu/blockchain/services"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type FeeHandler struct {
+	feeService services.FeeService
+}
+
+func NewFeeHandler(feeService services.FeeService) *FeeHandler {
+	return &FeeHandler{
+		feeService: feeService,
+	}
+}
+
+// EstimateFees ... Suggest fee rates
+// @Summary      Estimate fees
+// @Description  Suggest low, medium and high fee rates, in coins per 1000 bytes, from what transactions in recent blocks paid
+// @Tags         Fees
+// @Success      200  {object}  representations.FeeEstimate
+// @Failure      500  {object}  HTTPError
+// @Router       /blockchain/fees/estimate [get]
+func (fh *FeeHandler) EstimateFees(ctx *gin.Context) {
+	log.Info("EstimateFees handler called")
+
+	estimate, err := fh.feeService.EstimateFees()
+	if err != nil {
+		log.Error("error estimating fees: ", err.Error())
+		NewError(ctx, http.StatusInternalServerError, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"estimate": estimate})
+	}
+}
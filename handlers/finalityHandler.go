@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type FinalityHandler struct {
+	finalityService services.FinalityService
+	walletService   services.WalletService
+}
+
+func NewFinalityHandler(finalityService services.FinalityService, walletService services.WalletService) *FinalityHandler {
+	return &FinalityHandler{
+		finalityService: finalityService,
+		walletService:   walletService,
+	}
+}
+
+// VoteForBlock ... Vote for a block as a validator
+// @Summary      Vote for a block
+// @Description  Add a validator's vote for a block on a BFT chain, either signed here with the wallet of address, or signed elsewhere, with the hex publicKey, sigAlgorithm and hex signature of the sha256 of "Blockchain Block Vote:\n" followed by the block's hash. Each validator votes for one block at each height. Once more than two thirds of the validators voted for a block it's final, and can't be reorganized away
+// @Tags         Blocks
+// @Param        blockId    path      string                      true  "Block ID"
+// @Param        VoteInput  body      representations.VoteInput  true  "Validator address, or a vote signed elsewhere"
+// @Success      200        {object}  representations.BlockFinality
+// @Failure      400        {object}  HTTPError
+// @Failure      403        {object}  HTTPError
+// @Failure      404        {object}  HTTPError
+// @Failure      409        {object}  HTTPError
+// @Router       /blockchain/block/{blockId}/votes [post]
+func (fh *FinalityHandler) VoteForBlock(ctx *gin.Context) {
+	blockId := ctx.Param("blockId")
+	log.Info("VoteForBlock handler called with blockId: ", blockId)
+
+	var input reps.VoteInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if input.Address != "" && !ValidAddresses(ctx, fh.walletService, input.Address) {
+		return
+	}
+
+	if _, err := fh.finalityService.GetBlockFinality(blockId); err != nil {
+		log.Error("error getting block: ", err.Error())
+		NewError(ctx, http.StatusNotFound, err)
+		return
+	}
+
+	finality, err := fh.finalityService.Vote(blockId, input)
+	if err != nil {
+		log.Error("error voting for block: ", err.Error())
+		VoteError(ctx, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"finality": finality})
+	}
+}
+
+// GetBlockVotes ... Get the votes for a block
+// @Summary      Get votes for a block
+// @Description  Get the validators' votes for a block on a BFT chain, how many votes finalize it, and whether it's final
+// @Tags         Blocks
+// @Param        blockId  path      string  true  "Block ID"
+// @Success      200      {object}  representations.BlockFinality
+// @Failure      404      {object}  HTTPError
+// @Router       /blockchain/block/{blockId}/votes [get]
+func (fh *FinalityHandler) GetBlockVotes(ctx *gin.Context) {
+	blockId := ctx.Param("blockId")
+	log.Info("GetBlockVotes handler called with blockId: ", blockId)
+
+	finality, err := fh.finalityService.GetBlockFinality(blockId)
+	if err != nil {
+		log.Error("error getting block votes: ", err.Error())
+		NewError(ctx, http.StatusNotFound, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"finality": finality})
+	}
+}
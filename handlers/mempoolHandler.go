@@ -0,0 +1,394 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/brucetieu/blockchain/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type MempoolHandler struct {
+	mempoolService     services.MempoolService
+	transactionService services.TransactionService
+	walletService      services.WalletService
+	addressBookService services.AddressBookService
+	blockAssembler     services.BlockAssemblerFac
+	txnAssembler       services.TxnAssemblerFac
+}
+
+func NewMempoolHandler(mempoolService services.MempoolService, transactionService services.TransactionService,
+	walletService services.WalletService, addressBookService services.AddressBookService) *MempoolHandler {
+	return &MempoolHandler{
+		mempoolService:     mempoolService,
+		transactionService: transactionService,
+		walletService:      walletService,
+		addressBookService: addressBookService,
+		blockAssembler:     services.BlockAssembler,
+		txnAssembler:       services.TxnAssembler,
+	}
+}
+
+// CreateTransaction ... Submit a transaction to the mempool
+// @Summary      Create a transaction
+// @Description  Create and sign a transaction paying either to and amount, or every one of recipients, and queue it in the mempool until a block is mined. An optional fee or fee rate (coins per 1000 bytes) goes to the miner, an optional memo of up to 256 bytes is stored with it on-chain, an optional lock time keeps it off the chain until that block height, or from 500000000 up that unix time in seconds, and replaceable lets a later transaction spending the same inputs replace it for a higher fee while it's pending. From and to can be address book names instead of addresses
+// @Tags         Transactions
+// @Param        TransactionInput  body      representations.CreateTransactionInput  true  "Create transaction"
+// @Success      202               {object}  representations.ReadableTransaction
+// @Failure      400               {object}  HTTPError
+// @Failure      403               {object}  HTTPError
+// @Failure      422               {object}  TxnVerificationError
+// @Failure      500               {object}  HTTPError
+// @Router       /blockchain/transactions [post]
+func (mh *MempoolHandler) CreateTransaction(ctx *gin.Context) {
+	var input reps.CreateTransactionInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	recipients, ok := PaymentRecipients(ctx, mh.walletService, mh.addressBookService, &input.From, input.To, input.Amount, input.Recipients)
+	if !ok {
+		return
+	}
+
+	log.Info("Submitting transaction to mempool: ", utils.Pretty(input))
+
+	txn, err := mh.transactionService.CreateTransactionToRecipients(input.From, recipients, input.TxnOptions)
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error creating transaction")
+		TxnError(ctx, err)
+		return
+	}
+
+	if _, err := mh.mempoolService.AddTransaction(txn); err != nil {
+		log.WithField("error", err.Error()).Error("Error adding transaction to mempool")
+		TxnError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"transaction": mh.txnAssembler.ToReadableTransaction(txn)})
+}
+
+// SubmitRawTransaction ... Submit a transaction signed elsewhere
+// @Summary      Submit a raw transaction
+// @Description  Queue an already signed transaction in the mempool, so wallets can build and sign transactions offline and only broadcast through this node. The transaction is in the JSON form the node stores it in, hex or base64 encoded
+// @Tags         Transactions
+// @Param        RawTransactionInput  body      representations.RawTransactionInput  true  "Raw transaction"
+// @Success      202                  {object}  representations.ReadableTransaction
+// @Failure      400                  {object}  HTTPError
+// @Failure      422                  {object}  TxnVerificationError
+// @Failure      503                  {object}  HTTPError
+// @Router       /blockchain/transactions/raw [post]
+func (mh *MempoolHandler) SubmitRawTransaction(ctx *gin.Context) {
+	var input reps.RawTransactionInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	txn, err := services.DecodeRawTransaction(input.Raw)
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error decoding raw transaction")
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if _, err := mh.mempoolService.AddTransaction(txn); err != nil {
+		log.WithField("error", err.Error()).Error("Error adding raw transaction to mempool")
+		TxnError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"transaction": mh.txnAssembler.ToReadableTransaction(txn)})
+}
+
+// CreateBatch ... Submit several transfers to the mempool at once
+// @Summary      Create a batch of transactions
+// @Description  Queue several transfers in the mempool in one call. Transfers from the same address are combined into one transaction, with the optional fee or fee rate (coins per 1000 bytes) applying to each transaction. Each transfer succeeds or fails on its own, e.g. once an address can't cover it on top of its earlier transfers. From and to can be address book names instead of addresses
+// @Tags         Transactions
+// @Param        BatchInput  body      representations.CreateBatchInput  true  "Create batch"
+// @Success      202         {array}   representations.BatchResult
+// @Failure      400         {object}  HTTPError
+// @Router       /blockchain/transactions/batch [post]
+func (mh *MempoolHandler) CreateBatch(ctx *gin.Context) {
+	var input reps.CreateBatchInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	log.Info("Submitting batch to mempool: ", utils.Pretty(input))
+
+	// Address book names are resolved to their addresses. Anything that doesn't resolve is left to fail as a malformed address
+	for i := range input.Transfers {
+		for _, nameOrAddress := range []*string{&input.Transfers[i].From, &input.Transfers[i].To} {
+			if address, err := mh.addressBookService.ResolveAddress(*nameOrAddress); err == nil {
+				*nameOrAddress = address
+			}
+		}
+	}
+
+	results, err := mh.mempoolService.SubmitBatch(input.Transfers, input.TxnOptions)
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error submitting batch")
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"results": results})
+}
+
+// ValidateTransaction ... Check a signed transaction without submitting it
+// @Summary      Validate a transaction
+// @Description  Run every check a signed transaction goes through before it's queued, i.e. balance, fee, signatures, lock time and inputs already spent on the chain or by a pending transaction, without queueing or persisting anything. The transaction is in the same JSON form the node stores it in. Returns its fee and the outputs it would spend
+// @Tags         Transactions
+// @Param        ValidateInput  body      representations.ValidateTransactionInput  true  "Validate transaction"
+// @Success      200            {object}  representations.TxnValidation
+// @Failure      400            {object}  HTTPError
+// @Failure      422            {object}  TxnVerificationError
+// @Failure      500            {object}  HTTPError
+// @Router       /blockchain/transactions/validate [post]
+func (mh *MempoolHandler) ValidateTransaction(ctx *gin.Context) {
+	var input reps.ValidateTransactionInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	validation, err := mh.mempoolService.ValidateTransaction(input.Transaction)
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Transaction failed validation")
+		TxnError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"validation": validation})
+}
+
+// MinePendingTransactions ... Mine the mempool into a block
+// @Summary      Mine pending transactions
+// @Description  Mine a block from the pending transactions paying the highest fee rates, with a coinbase paying the reward and their fees to miner. The coinbase carries coinbaseMessage, up to 100 bytes, followed by a random extranonce, or the node's COINBASE_MESSAGE without one. On a proof of stake or BFT chain nothing is mined, the block is signed by miner, which has to be the validator elected for it, or whose turn it is
+// @Tags         Blocks
+// @Param        MineInput  body      representations.MineInput  true  "Mine pending transactions"
+// @Success      201        {object}  representations.ReadableBlock
+// @Failure      400        {object}  HTTPError
+// @Failure      403        {object}  HTTPError
+// @Failure      422        {object}  TxnVerificationError
+// @Failure      500        {object}  HTTPError
+// @Router       /blockchain/mine [post]
+func (mh *MempoolHandler) MinePendingTransactions(ctx *gin.Context) {
+	var input reps.MineInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if !ValidAddresses(ctx, mh.walletService, input.Miner) {
+		return
+	}
+	if len(input.CoinbaseMessage) > services.MaxCoinbaseMessageLen {
+		NewError(ctx, http.StatusBadRequest, fmt.Errorf("coinbaseMessage can be at most %d bytes", services.MaxCoinbaseMessageLen))
+		return
+	}
+
+	block, err := mh.mempoolService.MinePendingTransactions(input.Miner, input.CoinbaseMessage)
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error mining pending transactions")
+		TxnError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"block": mh.blockAssembler.ToReadableBlock(block)})
+}
+
+// GetBlockTemplate ... Get a block to mine elsewhere
+// @Summary      Get a block template
+// @Description  Get the block the node would mine from the mempool next, for an external miner or pool to solve: its header fields, transactions, with a coinbase paying the reward and fees to miner first, and the target its hash has to be under. The hash is taken with hashAlgorithm over headerPrefix followed by the nounce in decimal digits. A template can be submitted until it has been, or 16 newer ones have been handed out. Once a block is added on top of the one it builds on, it's rejected, but if solved it's recorded at GET /blockchain/blocks/stale
+// @Tags         Miner
+// @Param        miner    query     string  true   "Address the coinbase pays"
+// @Param        message  query     string  false  "Message the coinbase carries, up to 100 bytes, in place of the node's own"
+// @Success      200      {object}  representations.BlockTemplate
+// @Failure      400      {object}  HTTPError
+// @Failure      422      {object}  TxnVerificationError
+// @Failure      500      {object}  HTTPError
+// @Router       /blockchain/mine/template [get]
+func (mh *MempoolHandler) GetBlockTemplate(ctx *gin.Context) {
+	miner := ctx.Query("miner")
+	log.Info("GetBlockTemplate called for miner: ", miner)
+
+	if miner == "" {
+		NewError(ctx, http.StatusBadRequest, fmt.Errorf("miner is required"))
+		return
+	}
+	if !ValidAddresses(ctx, mh.walletService, miner) {
+		return
+	}
+	message := ctx.Query("message")
+	if len(message) > services.MaxCoinbaseMessageLen {
+		NewError(ctx, http.StatusBadRequest, fmt.Errorf("message can be at most %d bytes", services.MaxCoinbaseMessageLen))
+		return
+	}
+
+	template, err := mh.mempoolService.GetBlockTemplate(miner, message)
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error assembling block template")
+		TxnError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"template": template})
+}
+
+// SubmitBlock ... Submit a block solved elsewhere
+// @Summary      Submit a solved block
+// @Description  Add the block for a template from GET /blockchain/mine/template, with the nounce that solves it. A timestamp replaces the template's, for miners that run through every nounce, as long as it's after the median timestamp of the 11 blocks before it and no more than 2 hours ahead of the node's clock. Templates that no longer build on the last block are rejected, and the blocks solving them recorded as stale
+// @Tags         Miner
+// @Param        SubmitBlockInput  body      representations.SubmitBlockInput  true  "Solved template"
+// @Success      201               {object}  representations.ReadableBlock
+// @Failure      400               {object}  HTTPError
+// @Failure      409               {object}  HTTPError
+// @Failure      500               {object}  HTTPError
+// @Router       /blockchain/mine/submit [post]
+func (mh *MempoolHandler) SubmitBlock(ctx *gin.Context) {
+	var input reps.SubmitBlockInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	block, err := mh.mempoolService.SubmitBlock(input)
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error adding submitted block")
+		if errors.Is(err, services.ErrStaleTemplate) {
+			NewError(ctx, http.StatusConflict, err)
+		} else if errors.Is(err, services.ErrInvalidBlock) {
+			NewError(ctx, http.StatusBadRequest, err)
+		} else {
+			NewError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"block": mh.blockAssembler.ToReadableBlock(block)})
+}
+
+// ReceiveBlock ... Add a block mined elsewhere
+// @Summary      Receive a block
+// @Description  Add a solved block mined elsewhere, e.g. relayed by a peer, as it's serialized on the chain with base64 hashes. Its transactions are verified and taken out of the mempool. A block whose parent isn't known yet is held as an orphan, with 202, and added once the parent shows up. One building on a block other than the last is stored on a side branch, also with 202, and once that branch has more work than the chain from where they fork the chain is reorganized onto it: the blocks above the fork are taken off, returned as disconnected, and their transactions go back in the mempool unless the branch has them too. Blocks added are returned in order, including orphans that were waiting on it
+// @Tags         Blocks
+// @Param        Block  body      representations.Block  true  "Solved block"
+// @Success      201    {array}   representations.ReadableBlock
+// @Success      202    {array}   representations.ReadableBlock
+// @Failure      400    {object}  HTTPError
+// @Failure      409    {object}  HTTPError
+// @Failure      500    {object}  HTTPError
+// @Router       /blockchain/blocks [post]
+func (mh *MempoolHandler) ReceiveBlock(ctx *gin.Context) {
+	var block reps.Block
+	if err := ctx.ShouldBindJSON(&block); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	update, err := mh.mempoolService.ReceiveBlock(block)
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error adding received block")
+		var verificationErr *services.TxnVerificationError
+		if errors.As(err, &verificationErr) {
+			NewTxnVerificationError(ctx, verificationErr)
+		} else if errors.Is(err, services.ErrStaleTemplate) || errors.Is(err, services.ErrKnownBlock) {
+			NewError(ctx, http.StatusConflict, err)
+		} else if errors.Is(err, services.ErrInvalidBlock) {
+			NewError(ctx, http.StatusBadRequest, err)
+		} else {
+			NewError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	blocks := make([]reps.ReadableBlock, 0, len(update.Connected))
+	for _, block := range update.Connected {
+		blocks = append(blocks, mh.blockAssembler.ToReadableBlock(block))
+	}
+	disconnected := make([]reps.ReadableBlock, 0, len(update.Disconnected))
+	for _, block := range update.Disconnected {
+		disconnected = append(disconnected, mh.blockAssembler.ToReadableBlock(block))
+	}
+
+	status := http.StatusCreated
+	if len(update.Connected) == 0 {
+		status = http.StatusAccepted
+	}
+	ctx.JSON(status, gin.H{"blocks": blocks, "disconnected": disconnected, "orphan": update.Orphaned, "sideBranch": len(update.Side) > 0})
+}
+
+// GetMempool ... Get every pending transaction
+// @Summary      Get the mempool
+// @Description  Get every transaction waiting to be mined, oldest first, with its fee, fee rate (coins per 1000 bytes) and how many seconds it's been waiting
+// @Tags         Mempool
+// @Success      200  {array}  representations.ReadableMempoolEntry
+// @Router       /blockchain/mempool [get]
+func (mh *MempoolHandler) GetMempool(ctx *gin.Context) {
+	log.Info("GetMempool called")
+	entries := mh.mempoolService.GetEntries()
+
+	ctx.JSON(http.StatusOK, gin.H{"mempool": mh.txnAssembler.ToReadableMempoolEntries(entries), "count": len(entries)})
+}
+
+// GetMempoolStats ... Get mempool statistics
+// @Summary      Get mempool stats
+// @Description  Get how many transactions are pending with their total fees and size, the mempool's limits, and how many transactions have been evicted since the node started, for waiting too long, for paying too little when the mempool was full, or for being replaced by a higher fee
+// @Tags         Mempool
+// @Success      200  {object}  representations.MempoolStats
+// @Router       /blockchain/mempool/stats [get]
+func (mh *MempoolHandler) GetMempoolStats(ctx *gin.Context) {
+	log.Info("GetMempoolStats called")
+	ctx.JSON(http.StatusOK, gin.H{"stats": mh.mempoolService.GetStats()})
+}
+
+// GetMempoolTransaction ... Get a pending transaction
+// @Summary      Get a mempool transaction
+// @Description  Get a transaction waiting to be mined, with its fee, fee rate (coins per 1000 bytes) and how many seconds it's been waiting
+// @Tags         Mempool
+// @Param        txnId  path      string  true  "Transaction ID"
+// @Success      200    {object}  representations.ReadableMempoolEntry
+// @Failure      404    {object}  HTTPError
+// @Router       /blockchain/mempool/{txnId} [get]
+func (mh *MempoolHandler) GetMempoolTransaction(ctx *gin.Context) {
+	txnId := ctx.Param("txnId")
+	log.Info("GetMempoolTransaction called with txnId: ", txnId)
+
+	entry, ok := mh.mempoolService.GetEntry(txnId)
+	if !ok {
+		NewError(ctx, http.StatusNotFound, fmt.Errorf("transaction %s is not in the mempool", txnId))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"transaction": mh.txnAssembler.ToReadableMempoolEntry(entry)})
+}
+
+// GetTransactionStatus ... Get whether a transaction is pending or confirmed
+// @Summary      Get transaction status
+// @Description  Get whether a transaction is pending in the mempool, confirmed on a block or not found. Confirmed transactions come with their block and how many blocks are built on top of it
+// @Tags         Transactions
+// @Param        transactionId  path      string  true  "Transaction ID"
+// @Success      200            {object}  representations.TxnStatus
+// @Failure      400            {object}  HTTPError
+// @Failure      500            {object}  HTTPError
+// @Router       /blockchain/transactions/{transactionId}/status [get]
+func (mh *MempoolHandler) GetTransactionStatus(ctx *gin.Context) {
+	txnId := ctx.Param("transactionId")
+
+	status, err := mh.mempoolService.GetTransactionStatus(txnId)
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error getting transaction status")
+		NewError(ctx, http.StatusBadRequest, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"status": status})
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type MessageHandler struct {
+	messageService services.MessageService
+	walletService  services.WalletService
+}
+
+func NewMessageHandler(messageService services.MessageService, walletService services.WalletService) *MessageHandler {
+	return &MessageHandler{
+		messageService: messageService,
+		walletService:  walletService,
+	}
+}
+
+// SignMessage ... Sign a message with a wallet's key
+// @Summary      Sign a message
+// @Description  Sign an arbitrary message with the key of a wallet on the node, to prove ownership of the address off-chain
+// @Tags         Messages
+// @Param        address           path      string                             true  "Wallet address"
+// @Param        SignMessageInput  body      representations.SignMessageInput  true  "Message to sign"
+// @Success      200               {string}  string  "signature"
+// @Failure      400               {object}  HTTPError
+// @Failure      403               {object}  HTTPError
+// @Router       /blockchain/wallets/{address}/sign [post]
+func (mh *MessageHandler) SignMessage(ctx *gin.Context) {
+	address := ctx.Param("address")
+	log.Info("SignMessage handler called with address: ", address)
+
+	var input reps.SignMessageInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if !ValidAddresses(ctx, mh.walletService, address) {
+		return
+	}
+
+	signature, err := mh.messageService.SignMessage(address, input.Message)
+	if err != nil {
+		log.Error("error signing message: ", err.Error())
+		if errors.Is(err, services.ErrWatchOnly) || errors.Is(err, services.ErrWalletLocked) {
+			NewError(ctx, http.StatusForbidden, err)
+			return
+		}
+		NewError(ctx, http.StatusBadRequest, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"address": address, "message": input.Message, "signature": signature})
+	}
+}
+
+// VerifyMessage ... Verify a signed message
+// @Summary      Verify a message
+// @Description  Check that a message was signed by the key of an address. The address doesn't need to be a wallet on the node
+// @Tags         Messages
+// @Param        VerifyMessageInput  body      representations.VerifyMessageInput  true  "Address, message and signature"
+// @Success      200                 {boolean}  boolean
+// @Failure      400                 {object}   HTTPError
+// @Router       /blockchain/verify [post]
+func (mh *MessageHandler) VerifyMessage(ctx *gin.Context) {
+	log.Info("VerifyMessage handler called")
+
+	var input reps.VerifyMessageInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	valid, err := mh.messageService.VerifyMessage(input.Address, input.Message, input.Signature)
+	if err != nil {
+		log.Error("error verifying message: ", err.Error())
+		NewError(ctx, http.StatusBadRequest, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"valid": valid})
+	}
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"net/http"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type MinerHandler struct {
+	minerService  services.MinerService
+	walletService services.WalletService
+}
+
+func NewMinerHandler(minerService services.MinerService, walletService services.WalletService) *MinerHandler {
+	return &MinerHandler{
+		minerService:  minerService,
+		walletService: walletService,
+	}
+}
+
+// StartMiner ... Start mining in the background
+// @Summary      Start the miner
+// @Description  Start mining blocks from the mempool in the background, highest fee rates first, with every coinbase paying the reward and fees to miner. Blocks are mined for as long as transactions are pending, until the miner is stopped
+// @Tags         Miner
+// @Param        MinerInput  body      representations.MinerInput  true  "Address to pay"
+// @Success      200         {object}  representations.MinerStatus
+// @Failure      400         {object}  HTTPError
+// @Failure      409         {object}  HTTPError
+// @Router       /blockchain/miner/start [post]
+func (mh *MinerHandler) StartMiner(ctx *gin.Context) {
+	log.Info("StartMiner handler called")
+
+	var input reps.MinerInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if !ValidAddresses(ctx, mh.walletService, input.Miner) {
+		return
+	}
+
+	status, err := mh.minerService.Start(input.Miner)
+	if err != nil {
+		log.Error("error starting miner: ", err.Error())
+		MinerError(ctx, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"miner": status})
+	}
+}
+
+// StopMiner ... Stop mining in the background
+// @Summary      Stop the miner
+// @Description  Stop the background miner. A block it's already mining is finished first
+// @Tags         Miner
+// @Success      200  {object}  representations.MinerStatus
+// @Failure      409  {object}  HTTPError
+// @Router       /blockchain/miner/stop [post]
+func (mh *MinerHandler) StopMiner(ctx *gin.Context) {
+	log.Info("StopMiner handler called")
+
+	status, err := mh.minerService.Stop()
+	if err != nil {
+		log.Error("error stopping miner: ", err.Error())
+		MinerError(ctx, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"miner": status})
+	}
+}
+
+// PauseMiner ... Pause mining in the background
+// @Summary      Pause the miner
+// @Description  Stop producing blocks without stopping the background miner, until it's resumed. A block it's already mining is finished first. Blocks mined before the pause still count towards blocksMined
+// @Tags         Miner
+// @Success      200  {object}  representations.MinerStatus
+// @Failure      409  {object}  HTTPError
+// @Router       /blockchain/miner/pause [post]
+func (mh *MinerHandler) PauseMiner(ctx *gin.Context) {
+	log.Info("PauseMiner handler called")
+
+	status, err := mh.minerService.Pause()
+	if err != nil {
+		log.Error("error pausing miner: ", err.Error())
+		MinerError(ctx, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"miner": status})
+	}
+}
+
+// ResumeMiner ... Resume mining in the background
+// @Summary      Resume the miner
+// @Description  Pick block production back up after the background miner was paused, paying whatever address it pays now
+// @Tags         Miner
+// @Success      200  {object}  representations.MinerStatus
+// @Failure      409  {object}  HTTPError
+// @Router       /blockchain/miner/resume [post]
+func (mh *MinerHandler) ResumeMiner(ctx *gin.Context) {
+	log.Info("ResumeMiner handler called")
+
+	status, err := mh.minerService.Resume()
+	if err != nil {
+		log.Error("error resuming miner: ", err.Error())
+		MinerError(ctx, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"miner": status})
+	}
+}
+
+// SetMinerPayout ... Change who the background miner pays
+// @Summary      Change the miner's payout address
+// @Description  Pay the coinbase of every block the background miner mines from now on to miner, without restarting it. A block it's already mining still pays the old address. The miner has to be running or paused
+// @Tags         Miner
+// @Param        MinerInput  body      representations.MinerInput  true  "Address to pay"
+// @Success      200         {object}  representations.MinerStatus
+// @Failure      400         {object}  HTTPError
+// @Failure      409         {object}  HTTPError
+// @Router       /blockchain/miner/payout [put]
+func (mh *MinerHandler) SetMinerPayout(ctx *gin.Context) {
+	log.Info("SetMinerPayout handler called")
+
+	var input reps.MinerInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if !ValidAddresses(ctx, mh.walletService, input.Miner) {
+		return
+	}
+
+	status, err := mh.minerService.SetPayoutAddress(input.Miner)
+	if err != nil {
+		log.Error("error changing miner payout address: ", err.Error())
+		MinerError(ctx, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"miner": status})
+	}
+}
+
+// GetMinerStatus ... Get what the background miner is up to
+// @Summary      Get miner status
+// @Description  Get whether the background miner is running, paused or stopped, how many goroutines it mines with and the percent of the time they spend hashing, who it pays, how many blocks it's mined since it was started, the last of them, and why its last attempt failed if it did
+// @Tags         Miner
+// @Success      200  {object}  representations.MinerStatus
+// @Router       /blockchain/miner [get]
+func (mh *MinerHandler) GetMinerStatus(ctx *gin.Context) {
+	log.Info("GetMinerStatus handler called")
+	ctx.JSON(http.StatusOK, gin.H{"miner": mh.minerService.GetStatus()})
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type MultisigHandler struct {
+	multisigService services.MultisigService
+	walletService   services.WalletService
+	blockAssembler  services.BlockAssemblerFac
+}
+
+func NewMultisigHandler(multisigService services.MultisigService, walletService services.WalletService) *MultisigHandler {
+	return &MultisigHandler{
+		multisigService: multisigService,
+		walletService:   walletService,
+		blockAssembler:  services.BlockAssembler,
+	}
+}
+
+// CreateMultisigAddress ... Create an M-of-N multisig address
+// @Summary      Create a multisig address
+// @Description  Create an address whose coins can only be spent with signatures from requiredSigs of the given public keys
+// @Tags         Multisig
+// @Param        MultisigInput  body      representations.CreateMultisigInput  true  "Required signatures and public keys"
+// @Success      201            {object}  representations.MultisigAddress
+// @Failure      400            {object}  HTTPError
+// @Router       /blockchain/multisig [post]
+func (mh *MultisigHandler) CreateMultisigAddress(ctx *gin.Context) {
+	log.Info("CreateMultisigAddress handler called")
+
+	var input reps.CreateMultisigInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	multisigAddress, err := mh.multisigService.CreateMultisigAddress(input.RequiredSigs, input.PublicKeys)
+	if err != nil {
+		log.Error("error creating multisig address: ", err.Error())
+		NewError(ctx, http.StatusBadRequest, err)
+	} else {
+		ctx.JSON(http.StatusCreated, gin.H{"multisig": multisigAddress})
+	}
+}
+
+// GetMultisigAddress ... Get a multisig address
+// @Summary      Get a multisig address
+// @Description  Get a multisig address with its public keys and balance
+// @Tags         Multisig
+// @Param        address  path      string  true  "Multisig address"
+// @Success      200      {object}  representations.MultisigAddress
+// @Failure      400      {object}  HTTPError
+// @Failure      404      {object}  HTTPError
+// @Router       /blockchain/multisig/{address} [get]
+func (mh *MultisigHandler) GetMultisigAddress(ctx *gin.Context) {
+	address := ctx.Param("address")
+	log.Info("GetMultisigAddress handler called with address: ", address)
+
+	if !ValidAddresses(ctx, mh.walletService, address) {
+		return
+	}
+
+	multisigAddress, err := mh.multisigService.GetMultisigAddress(address)
+	if err != nil {
+		log.Error("error getting multisig address: ", err.Error())
+		NewError(ctx, http.StatusNotFound, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"multisig": multisigAddress})
+	}
+}
+
+// CreateMultisigTransaction ... Start a transaction spending from a multisig address
+// @Summary      Create a multisig transaction
+// @Description  Create an unsigned transaction spending from a multisig address. Signatures are added to it until the threshold is reached
+// @Tags         Multisig
+// @Param        address   path      string                                  true  "Multisig address"
+// @Param        TxnInput  body      representations.CreateMultisigTxnInput  true  "Recipient and amount"
+// @Success      201       {object}  representations.MultisigTransaction
+// @Failure      400       {object}  HTTPError
+// @Router       /blockchain/multisig/{address}/transactions [post]
+func (mh *MultisigHandler) CreateMultisigTransaction(ctx *gin.Context) {
+	address := ctx.Param("address")
+	log.Info("CreateMultisigTransaction handler called with address: ", address)
+
+	var input reps.CreateMultisigTxnInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if !ValidAddresses(ctx, mh.walletService, address, input.To) {
+		return
+	}
+
+	multisigTxn, err := mh.multisigService.CreateMultisigTransaction(address, input.To, input.Amount)
+	if err != nil {
+		log.Error("error creating multisig transaction: ", err.Error())
+		NewError(ctx, http.StatusBadRequest, err)
+	} else {
+		ctx.JSON(http.StatusCreated, gin.H{"transaction": multisigTxn})
+	}
+}
+
+// GetMultisigTransaction ... Get a multisig transaction
+// @Summary      Get a multisig transaction
+// @Description  Get a multisig transaction and the signatures collected so far
+// @Tags         Multisig
+// @Param        txnId  path      string  true  "Transaction ID"
+// @Success      200    {object}  representations.MultisigTransaction
+// @Failure      404    {object}  HTTPError
+// @Router       /blockchain/multisig/transactions/{txnId} [get]
+func (mh *MultisigHandler) GetMultisigTransaction(ctx *gin.Context) {
+	txnId := ctx.Param("txnId")
+	log.Info("GetMultisigTransaction handler called with txnId: ", txnId)
+
+	multisigTxn, err := mh.multisigService.GetMultisigTransaction(txnId)
+	if err != nil {
+		log.Error("error getting multisig transaction: ", err.Error())
+		NewError(ctx, http.StatusNotFound, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"transaction": multisigTxn})
+	}
+}
+
+// SignMultisigTransaction ... Add a signature to a multisig transaction
+// @Summary      Sign a multisig transaction
+// @Description  Add the signature of one of the multisig keys, held by a wallet on this node
+// @Tags         Multisig
+// @Param        txnId      path      string                                true  "Transaction ID"
+// @Param        SignInput  body      representations.SignMultisigTxnInput  true  "Signing wallet address"
+// @Success      200        {object}  representations.MultisigTransaction
+// @Failure      400        {object}  HTTPError
+// @Failure      403        {object}  HTTPError
+// @Router       /blockchain/multisig/transactions/{txnId}/signatures [post]
+func (mh *MultisigHandler) SignMultisigTransaction(ctx *gin.Context) {
+	txnId := ctx.Param("txnId")
+	log.Info("SignMultisigTransaction handler called with txnId: ", txnId)
+
+	var input reps.SignMultisigTxnInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if !ValidAddresses(ctx, mh.walletService, input.Signer) {
+		return
+	}
+
+	multisigTxn, err := mh.multisigService.SignMultisigTransaction(txnId, input.Signer)
+	if err != nil {
+		log.Error("error signing multisig transaction: ", err.Error())
+		if errors.Is(err, services.ErrWatchOnly) || errors.Is(err, services.ErrWalletLocked) {
+			NewError(ctx, http.StatusForbidden, err)
+			return
+		}
+		NewError(ctx, http.StatusBadRequest, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"transaction": multisigTxn})
+	}
+}
+
+// BroadcastMultisigTransaction ... Mine a fully signed multisig transaction
+// @Summary      Broadcast a multisig transaction
+// @Description  Mine a multisig transaction into a new block once it has enough signatures
+// @Tags         Multisig
+// @Param        txnId           path      string                                     true  "Transaction ID"
+// @Param        BroadcastInput  body      representations.BroadcastMultisigTxnInput  true  "Address receiving the block reward"
+// @Success      201             {object}  representations.ReadableBlock
+// @Failure      400             {object}  HTTPError
+// @Failure      422             {object}  TxnVerificationError
+// @Router       /blockchain/multisig/transactions/{txnId}/broadcast [post]
+func (mh *MultisigHandler) BroadcastMultisigTransaction(ctx *gin.Context) {
+	txnId := ctx.Param("txnId")
+	log.Info("BroadcastMultisigTransaction handler called with txnId: ", txnId)
+
+	var input reps.BroadcastMultisigTxnInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if !ValidAddresses(ctx, mh.walletService, input.Miner) {
+		return
+	}
+
+	block, err := mh.multisigService.BroadcastMultisigTransaction(txnId, input.Miner)
+	if err != nil {
+		log.Error("error broadcasting multisig transaction: ", err.Error())
+		var verificationErr *services.TxnVerificationError
+		if errors.As(err, &verificationErr) {
+			NewTxnVerificationError(ctx, verificationErr)
+			return
+		}
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"block": mh.blockAssembler.ToReadableBlock(block)})
+}
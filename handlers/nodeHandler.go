@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/brucetieu/blockchain/node"
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type NodeHandler struct {
+	node node.Node
+}
+
+func NewNodeHandler(node node.Node) *NodeHandler {
+	return &NodeHandler{
+		node: node,
+	}
+}
+
+// GetSyncStatus ... Get how far the node is in catching up with its peers
+// @Summary      Get sync status
+// @Description  Get the height of the node's chain against the best one any peer is known to have. A node that's behind downloads the headers of the chain of the peer furthest ahead first, then, if that chain has more work than its own, the blocks on it from every peer that has them, validating and adding each like any other received block. A peer that stops sending what it was asked for is dropped and the sync carries on from the others
+// @Tags         Peers
+// @Success      200  {object}  representations.SyncStatus
+// @Router       /blockchain/sync [get]
+func (nh *NodeHandler) GetSyncStatus(ctx *gin.Context) {
+	log.Info("GetSyncStatus handler called")
+
+	ctx.JSON(http.StatusOK, gin.H{"sync": nh.node.GetSyncStatus()})
+}
+
+// GetPeers ... Get the peers the node is connected to
+// @Summary      Get peers
+// @Description  Get the nodes this one is connected to, whether they connected to it or it connected to them, oldest connection first, with how far their chain goes as far as this node knows
+// @Tags         Peers
+// @Success      200  {array}  representations.Peer
+// @Router       /blockchain/peers [get]
+func (nh *NodeHandler) GetPeers(ctx *gin.Context) {
+	log.Info("GetPeers handler called")
+
+	ctx.JSON(http.StatusOK, gin.H{"peers": nh.node.GetPeers()})
+}
+
+// ConnectPeer ... Connect to a peer
+// @Summary      Connect to a peer
+// @Description  Connect to the node taking connections from peers at a host:port address. The two exchange versions in the background, and the peer is dropped if it's on another chain
+// @Tags         Peers
+// @Param        ConnectPeerInput  body      representations.ConnectPeerInput  true  "Address of the peer"
+// @Success      201               {object}  representations.Peer
+// @Failure      400               {object}  HTTPError
+// @Router       /blockchain/peers [post]
+func (nh *NodeHandler) ConnectPeer(ctx *gin.Context) {
+	log.Info("ConnectPeer handler called")
+
+	var input reps.ConnectPeerInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	peer, err := nh.node.Connect(input.Address)
+	if err != nil {
+		log.Error("error connecting to peer: ", err.Error())
+		NewError(ctx, http.StatusBadRequest, err)
+	} else {
+		ctx.JSON(http.StatusCreated, gin.H{"peer": peer})
+	}
+}
+
+// DisconnectPeer ... Drop a peer
+// @Summary      Disconnect from a peer
+// @Description  Drop the connection to a peer. If this node connected to it, it doesn't connect to it again on its own unless another peer tells it about its address
+// @Tags         Peers
+// @Param        peerId  path      string  true  "Peer ID"
+// @Success      200     {string}  string  "message"
+// @Failure      404     {object}  HTTPError
+// @Router       /blockchain/peers/{peerId} [delete]
+func (nh *NodeHandler) DisconnectPeer(ctx *gin.Context) {
+	peerId := ctx.Param("peerId")
+	log.Info("DisconnectPeer handler called with peerId: ", peerId)
+
+	if err := nh.node.Disconnect(peerId); err != nil {
+		log.Error("error disconnecting from peer: ", err.Error())
+		NewError(ctx, http.StatusNotFound, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"message": "Peer disconnected."})
+	}
+}
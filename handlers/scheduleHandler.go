@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type ScheduleHandler struct {
+	scheduleService    services.ScheduleService
+	walletService      services.WalletService
+	addressBookService services.AddressBookService
+}
+
+func NewScheduleHandler(scheduleService services.ScheduleService, walletService services.WalletService, addressBookService services.AddressBookService) *ScheduleHandler {
+	return &ScheduleHandler{
+		scheduleService:    scheduleService,
+		walletService:      walletService,
+		addressBookService: addressBookService,
+	}
+}
+
+// CreateSchedule ... Schedule a recurring payment
+// @Summary      Schedule a recurring payment
+// @Description  Have the node pay amount from one address to another every interval, creating the transaction and queueing it in the mempool on its own. Interval is @every and a duration such as 36h, or @hourly, @daily or @weekly. The first payment is made one interval from now unless startAt says otherwise. A failed payment, e.g. while the wallet file is locked, is skipped until the next one. Address book names can be used in place of addresses
+// @Tags         Schedules
+// @Param        ScheduleInput  body      representations.ScheduleInput  true  "Payment and interval"
+// @Success      201            {object}  representations.Schedule
+// @Failure      400            {object}  HTTPError
+// @Failure      403            {object}  HTTPError
+// @Router       /blockchain/schedules [post]
+func (sh *ScheduleHandler) CreateSchedule(ctx *gin.Context) {
+	log.Info("CreateSchedule handler called")
+
+	var input reps.ScheduleInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	recipients, ok := PaymentRecipients(ctx, sh.walletService, sh.addressBookService, &input.From, input.To, input.Amount, nil)
+	if !ok {
+		return
+	}
+	input.To = recipients[0].To
+
+	schedule, err := sh.scheduleService.CreateSchedule(input)
+	if err != nil {
+		log.Error("error scheduling payment: ", err.Error())
+		if errors.Is(err, services.ErrWatchOnly) {
+			NewError(ctx, http.StatusForbidden, err)
+		} else {
+			NewError(ctx, http.StatusBadRequest, err)
+		}
+	} else {
+		ctx.JSON(http.StatusCreated, gin.H{"schedule": schedule})
+	}
+}
+
+// GetSchedules ... Get every recurring payment
+// @Summary      Get scheduled payments
+// @Description  Get every recurring payment, cancelled ones included, with when each is next due and how its last run went
+// @Tags         Schedules
+// @Success      200  {array}   representations.Schedule
+// @Failure      500  {object}  HTTPError
+// @Router       /blockchain/schedules [get]
+func (sh *ScheduleHandler) GetSchedules(ctx *gin.Context) {
+	log.Info("GetSchedules handler called")
+
+	schedules, err := sh.scheduleService.GetSchedules()
+	if err != nil {
+		log.Error("error getting schedules: ", err.Error())
+		NewError(ctx, http.StatusInternalServerError, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"schedules": schedules})
+	}
+}
+
+// GetSchedule ... Get a recurring payment
+// @Summary      Get a scheduled payment
+// @Description  Get a recurring payment, with when it's next due and how its last run went
+// @Tags         Schedules
+// @Param        scheduleId  path      string  true  "Schedule ID"
+// @Success      200         {object}  representations.Schedule
+// @Failure      404         {object}  HTTPError
+// @Router       /blockchain/schedules/{scheduleId} [get]
+func (sh *ScheduleHandler) GetSchedule(ctx *gin.Context) {
+	scheduleId := ctx.Param("scheduleId")
+	log.Info("GetSchedule handler called with scheduleId: ", scheduleId)
+
+	schedule, err := sh.scheduleService.GetSchedule(scheduleId)
+	if err != nil {
+		log.Error("error getting schedule: ", err.Error())
+		NewError(ctx, http.StatusNotFound, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"schedule": schedule})
+	}
+}
+
+// CancelSchedule ... Stop a recurring payment
+// @Summary      Cancel a scheduled payment
+// @Description  Stop making a recurring payment. Payments already queued aren't affected, and the schedule is kept with a cancelled status
+// @Tags         Schedules
+// @Param        scheduleId  path      string  true  "Schedule ID"
+// @Success      200         {object}  representations.Schedule
+// @Failure      400         {object}  HTTPError
+// @Failure      404         {object}  HTTPError
+// @Router       /blockchain/schedules/{scheduleId} [delete]
+func (sh *ScheduleHandler) CancelSchedule(ctx *gin.Context) {
+	scheduleId := ctx.Param("scheduleId")
+	log.Info("CancelSchedule handler called with scheduleId: ", scheduleId)
+
+	if _, err := sh.scheduleService.GetSchedule(scheduleId); err != nil {
+		log.Error("error getting schedule: ", err.Error())
+		NewError(ctx, http.StatusNotFound, err)
+		return
+	}
+
+	schedule, err := sh.scheduleService.CancelSchedule(scheduleId)
+	if err != nil {
+		log.Error("error cancelling schedule: ", err.Error())
+		NewError(ctx, http.StatusBadRequest, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"schedule": schedule})
+	}
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/brucetieu/blockchain/services"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type SlashingHandler struct {
+	slashingService services.SlashingService
+}
+
+func NewSlashingHandler(slashingService services.SlashingService) *SlashingHandler {
+	return &SlashingHandler{
+		slashingService: slashingService,
+	}
+}
+
+// GetEvidence ... Get evidence of equivocating validators
+// @Summary      Get evidence of equivocation
+// @Description  Get every validator caught signing two different blocks at the same height, as their proposer or with votes for both, latest first. On a proof of stake chain the stake it had bonded by then is burnt by a slashing transaction, which is pending until it's on the chain. Validators of a BFT chain have no stake, so their equivocations are only recorded
+// @Tags         Staking
+// @Success      200  {array}   representations.Evidence
+// @Failure      500  {object}  HTTPError
+// @Router       /blockchain/evidence [get]
+func (sh *SlashingHandler) GetEvidence(ctx *gin.Context) {
+	log.Info("GetEvidence handler called")
+
+	evidence, err := sh.slashingService.GetEvidence()
+	if err != nil {
+		log.Error("error getting evidence: ", err.Error())
+		NewError(ctx, http.StatusInternalServerError, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"evidence": evidence})
+	}
+}
+
+// GetEvidenceById ... Get evidence of an equivocation
+// @Summary      Get evidence of an equivocation
+// @Description  Get evidence of a validator signing two different blocks at the same height, and whether its stake was slashed for it
+// @Tags         Staking
+// @Param        evidenceId  path      string  true  "Evidence ID"
+// @Success      200         {object}  representations.Evidence
+// @Failure      404         {object}  HTTPError
+// @Router       /blockchain/evidence/{evidenceId} [get]
+func (sh *SlashingHandler) GetEvidenceById(ctx *gin.Context) {
+	evidenceId := ctx.Param("evidenceId")
+	log.Info("GetEvidenceById handler called with evidenceId: ", evidenceId)
+
+	evidence, err := sh.slashingService.GetEvidenceById(evidenceId)
+	if err != nil {
+		log.Error("error getting evidence: ", err.Error())
+		NewError(ctx, http.StatusNotFound, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"evidence": evidence})
+	}
+}
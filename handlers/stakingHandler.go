@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/brucetieu/blockchain/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type StakingHandler struct {
+	stakingService services.StakingService
+	walletService  services.WalletService
+	txnAssembler   services.TxnAssemblerFac
+}
+
+func NewStakingHandler(stakingService services.StakingService, walletService services.WalletService) *StakingHandler {
+	return &StakingHandler{
+		stakingService: stakingService,
+		walletService:  walletService,
+		txnAssembler:   services.TxnAssembler,
+	}
+}
+
+// Stake ... Bond coins as stake
+// @Summary      Stake coins
+// @Description  Queue a transaction in the mempool bonding amount of an address's coins as its stake, on a proof of stake chain. Once it's on a block the address is a validator, elected to produce blocks with odds in proportion to its stake. Staked coins aren't part of its balance, and aren't spent by its transactions until they're unstaked
+// @Tags         Staking
+// @Param        StakeInput  body      representations.StakeInput  true  "Address and amount to stake"
+// @Success      202         {object}  representations.ReadableTransaction
+// @Failure      400         {object}  HTTPError
+// @Failure      403         {object}  HTTPError
+// @Failure      422         {object}  TxnVerificationError
+// @Failure      500         {object}  HTTPError
+// @Router       /blockchain/stake [post]
+func (sh *StakingHandler) Stake(ctx *gin.Context) {
+	var input reps.StakeInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if !ValidAddresses(ctx, sh.walletService, input.Address) {
+		return
+	}
+
+	log.Info("Staking coins: ", utils.Pretty(input))
+
+	txn, err := sh.stakingService.Stake(input.Address, input.Amount, input.TxnOptions)
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error staking coins")
+		TxnError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"transaction": sh.txnAssembler.ToReadableTransaction(txn)})
+}
+
+// Unstake ... Unbond staked coins
+// @Summary      Unstake coins
+// @Description  Queue a transaction in the mempool unbonding amount of an address's stake back into its balance, less the fee, and bonding the rest again. An amount of 0 unbonds all of it
+// @Tags         Staking
+// @Param        StakeInput  body      representations.StakeInput  true  "Address and amount to unstake"
+// @Success      202         {object}  representations.ReadableTransaction
+// @Failure      400         {object}  HTTPError
+// @Failure      403         {object}  HTTPError
+// @Failure      422         {object}  TxnVerificationError
+// @Failure      500         {object}  HTTPError
+// @Router       /blockchain/unstake [post]
+func (sh *StakingHandler) Unstake(ctx *gin.Context) {
+	var input reps.StakeInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if !ValidAddresses(ctx, sh.walletService, input.Address) {
+		return
+	}
+
+	log.Info("Unstaking coins: ", utils.Pretty(input))
+
+	txn, err := sh.stakingService.Unstake(input.Address, input.Amount, input.TxnOptions)
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error unstaking coins")
+		TxnError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"transaction": sh.txnAssembler.ToReadableTransaction(txn)})
+}
+
+// GetValidators ... Get the validator set
+// @Summary      Get validators
+// @Description  Get every address with coins staked on a proof of stake chain as of the last block, most stake first, with how much each has bonded and its share of all of it, and which address is elected to produce the next block
+// @Tags         Staking
+// @Success      200  {object}  representations.ValidatorSet
+// @Failure      400  {object}  HTTPError
+// @Router       /blockchain/validators [get]
+func (sh *StakingHandler) GetValidators(ctx *gin.Context) {
+	log.Info("GetValidators handler called")
+
+	validators, err := sh.stakingService.GetValidators()
+	if err != nil {
+		log.Error("error getting validators: ", err.Error())
+		NewError(ctx, http.StatusBadRequest, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"validatorSet": validators})
+	}
+}
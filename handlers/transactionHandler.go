@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"encoding/hex"
 	"net/http"
 
+	reps "github.com/brucetieu/blockchain/representations"
 	"github.com/brucetieu/blockchain/services"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
@@ -10,12 +12,16 @@ import (
 
 type TransactionHandler struct {
 	transactionService services.TransactionService
+	mempoolService     services.MempoolService
+	walletService      services.WalletService
 	assemblerService   services.TxnAssemblerFac
 }
 
-func NewTransactionHandler(transactionService services.TransactionService) *TransactionHandler {
+func NewTransactionHandler(transactionService services.TransactionService, mempoolService services.MempoolService, walletService services.WalletService) *TransactionHandler {
 	return &TransactionHandler{
 		transactionService: transactionService,
+		mempoolService:     mempoolService,
+		walletService:      walletService,
 		assemblerService:   services.TxnAssembler,
 	}
 }
@@ -40,22 +46,61 @@ func (th *TransactionHandler) GetTransactions(ctx *gin.Context) {
 
 // GetTransactions ... Get a single transaction
 // @Summary      Get a transaction
-// @Description  Get a transaction on the blockchain
+// @Description  Get a transaction on the blockchain, with the hash and height of the block it's on
 // @Tags         Transactions
 // @Param        transactionId  path      string  true  "Transaction ID"
-// @Success      200            {object}  representations.ReadableTransaction
+// @Success      200            {object}  representations.ReadableConfirmedTransaction
 // @Failure      404            {object}  HTTPError
 // @Router       /blockchain/transactions/{transactionId} [get]
 func (th *TransactionHandler) GetTransaction(ctx *gin.Context) {
 	txnId := ctx.Param("transactionId")
 	log.Info("GetTransaction called with transactionId: " + txnId)
 
+	location, err := th.transactionService.GetTransactionLocation(txnId)
+	if err != nil {
+		log.Error("error getting transaction: ", err.Error())
+		NewError(ctx, http.StatusNotFound, err)
+		return
+	}
+
 	txn, err := th.transactionService.GetTransaction(txnId)
 	if err != nil {
 		log.Error("error getting transaction: ", err.Error())
 		NewError(ctx, http.StatusNotFound, err)
 	} else {
-		ctx.JSON(http.StatusOK, gin.H{"transaction": th.assemblerService.ToReadableTransaction(txn)})
+		ctx.JSON(http.StatusOK, reps.ReadableConfirmedTransaction{
+			Transaction: th.assemblerService.ToReadableTransaction(txn),
+			BlockHash:   hex.EncodeToString(location.BlockHash),
+			Height:      location.Height,
+		})
+	}
+}
+
+// GetTransactionReceipt ... Get a receipt proving a transaction is on the chain
+// @Summary      Get a transaction receipt
+// @Description  Get the hash, height and position of a confirmed transaction's block, with a merkle proof from the transaction to the block's merkle root. Hashing the version, merkle root, prevHash, timestamp and nounce, numbers in decimal digits, with the chain's hash algorithm from /params gives the block hash. Blocks from before versions have version 0, which is left out
+// @Tags         Transactions
+// @Param        transactionId  path      string  true  "Transaction ID"
+// @Success      200            {object}  representations.TxnReceipt
+// @Failure      404            {object}  HTTPError
+// @Failure      500            {object}  HTTPError
+// @Router       /blockchain/transactions/{transactionId}/receipt [get]
+func (th *TransactionHandler) GetTransactionReceipt(ctx *gin.Context) {
+	txnId := ctx.Param("transactionId")
+	log.Info("GetTransactionReceipt called with transactionId: " + txnId)
+
+	if _, err := th.transactionService.GetTransactionLocation(txnId); err != nil {
+		log.Error("error getting transaction: ", err.Error())
+		NewError(ctx, http.StatusNotFound, err)
+		return
+	}
+
+	receipt, err := th.transactionService.GetTransactionReceipt(txnId)
+	if err != nil {
+		log.Error("error getting transaction receipt: ", err.Error())
+		NewError(ctx, http.StatusInternalServerError, err)
+	} else {
+		ctx.JSON(http.StatusOK, receipt)
 	}
 }
 
@@ -78,17 +123,101 @@ func (th *TransactionHandler) GetBalances(ctx *gin.Context) {
 	}
 }
 
+// GetAddressBalance ... Get the confirmed and pending balance of any address
+// @Summary      Get address balance
+// @Description  Sum the unspent outputs of any address, not only wallets on the node. Confirmed is what's on the chain, pending is what unconfirmed transactions add or take away
+// @Tags         Addresses
+// @Param        address  path      string  true  "Address"
+// @Success      200      {object}  representations.AddressBalanceSummary
+// @Failure      400      {object}  HTTPError
+// @Router       /blockchain/addresses/{address}/balance [get]
+func (th *TransactionHandler) GetAddressBalance(ctx *gin.Context) {
+	address := ctx.Param("address")
+	log.Info("GetAddressBalance called with address: ", address)
+
+	if !ValidAddresses(ctx, th.walletService, address) {
+		return
+	}
+
+	balance, err := th.mempoolService.GetAddressBalance(address)
+	if err != nil {
+		log.Error("error getting address balance: ", err.Error())
+		NewError(ctx, http.StatusBadRequest, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"balance": balance})
+	}
+}
+
+// GetAddressUnspentOutputs ... Get the unspent outputs of any address
+// @Summary      Get address UTXOs
+// @Description  Get every unspent output of any address, oldest first, with its value, the id and index of the transaction output it is, and its confirmations, so wallet software can build its own transactions. Coinbase outputs waiting on coinbase maturity aren't spendable yet
+// @Tags         Addresses
+// @Param        address  path      string  true  "Address"
+// @Success      200      {array}   representations.AddressUnspentOutput
+// @Failure      400      {object}  HTTPError
+// @Failure      500      {object}  HTTPError
+// @Router       /blockchain/addresses/{address}/utxos [get]
+func (th *TransactionHandler) GetAddressUnspentOutputs(ctx *gin.Context) {
+	address := ctx.Param("address")
+	log.Info("GetAddressUnspentOutputs called with address: ", address)
+
+	if !ValidAddresses(ctx, th.walletService, address) {
+		return
+	}
+
+	utxos, err := th.transactionService.GetAddressUnspentOutputs(address)
+	if err != nil {
+		log.Error("error getting address unspent outputs: ", err.Error())
+		NewError(ctx, http.StatusInternalServerError, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"utxos": utxos})
+	}
+}
+
+// GetAddressHistory ... Get the transaction history of any address
+// @Summary      Get address history
+// @Description  Get every transaction any address appears in as an input or output, oldest first, with the hash and height of its block, whether the address sent or received, and the net amount
+// @Tags         Addresses
+// @Param        address  path      string  true  "Address"
+// @Success      200      {array}   representations.ReadableAddressTransaction
+// @Failure      400      {object}  HTTPError
+// @Failure      500      {object}  HTTPError
+// @Router       /blockchain/addresses/{address}/transactions [get]
+// @Router       /blockchain/wallets/{address}/transactions [get]
+func (th *TransactionHandler) GetAddressHistory(ctx *gin.Context) {
+	address := ctx.Param("address")
+	log.Info("GetAddressHistory called with address: ", address)
+
+	if !ValidAddresses(ctx, th.walletService, address) {
+		return
+	}
+
+	history, err := th.transactionService.GetAddressHistory(address)
+	if err != nil {
+		log.Error("error getting address history: ", err.Error())
+		NewError(ctx, http.StatusInternalServerError, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"transactions": th.assemblerService.ToReadableAddressTransactions(history)})
+	}
+}
+
 // GetBalances ... Get the coin balance for a single address on the blockchain
 // @Summary      Get coin balance
 // @Description  Get the coin balance for an address on the blockchain
 // @Tags         Wallets
-// @Success      200  {integer}  integer
-// @Failure      404  {object}   HTTPError
+// @Param        address  path      string  true  "Wallet address"
+// @Success      200      {integer}  integer
+// @Failure      400      {object}   HTTPError
+// @Failure      404      {object}   HTTPError
 // @Router       /blockchain/wallets/{address}/balance [get]
 func (th *TransactionHandler) GetBalance(ctx *gin.Context) {
 	log.Info("GetBalances called")
 	address := ctx.Param("address")
 
+	if !ValidAddresses(ctx, th.walletService, address) {
+		return
+	}
+
 	balance, err := th.transactionService.GetBalance(address)
 	if err != nil {
 		log.Error("error getting transaction: ", err.Error())
@@ -1,6 +1,14 @@
 package handlers
 
-import "github.com/gin-gonic/gin"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/gin-gonic/gin"
+)
 
 // NewError example
 func NewError(ctx *gin.Context, status int, err error) {
@@ -16,3 +24,113 @@ type HTTPError struct {
 	Code    int    `json:"code" example:"400"`
 	Message string `json:"message" example:"status bad request"`
 }
+
+// Respond with a 422 describing which transaction input failed verification
+func NewTxnVerificationError(ctx *gin.Context, err *services.TxnVerificationError) {
+	er := TxnVerificationError{
+		Code:       http.StatusUnprocessableEntity,
+		Message:    err.Error(),
+		TxnID:      err.TxnID,
+		InputIndex: err.InputIndex,
+		Reason:     err.Reason,
+	}
+	ctx.JSON(http.StatusUnprocessableEntity, er)
+}
+
+// TxnVerificationError example
+type TxnVerificationError struct {
+	Code       int    `json:"code" example:"422"`
+	Message    string `json:"message" example:"invalid transaction 4dc4..., input 0: signature could not be verified"`
+	TxnID      string `json:"txnId" example:"4dc45ed831a7370e80366d63605841466e544a65277f96fa6a2403f97edd821c"`
+	InputIndex int    `json:"inputIndex" example:"0"`
+	Reason     string `json:"reason" example:"invalid_signature"`
+}
+
+// Respond with a 400 if any address isn't a well formed Base58Check address with a valid checksum
+func ValidAddresses(ctx *gin.Context, walletService services.WalletService, addresses ...string) bool {
+	for _, address := range addresses {
+		if !walletService.IsValidAddress(address) {
+			NewError(ctx, http.StatusBadRequest, fmt.Errorf("malformed address: %s", address))
+			return false
+		}
+	}
+	return true
+}
+
+// Respond with a 400 unless exactly one of to and amount, or recipients, is given. Address book names
+// in from and the recipients are resolved to their addresses, and every address is checked
+func PaymentRecipients(ctx *gin.Context, walletService services.WalletService, addressBookService services.AddressBookService,
+	from *string, to string, amount int, recipients []reps.Recipient) ([]reps.Recipient, bool) {
+	if len(recipients) == 0 {
+		if to == "" || amount == 0 {
+			NewError(ctx, http.StatusBadRequest, errors.New("either to and amount, or recipients, are required"))
+			return nil, false
+		}
+		recipients = []reps.Recipient{{To: to, Amount: amount}}
+	} else if to != "" || amount != 0 {
+		NewError(ctx, http.StatusBadRequest, errors.New("to and amount can't be given along with recipients"))
+		return nil, false
+	}
+
+	nameOrAddresses := []*string{from}
+	for i := range recipients {
+		nameOrAddresses = append(nameOrAddresses, &recipients[i].To)
+	}
+	for _, nameOrAddress := range nameOrAddresses {
+		address, err := addressBookService.ResolveAddress(*nameOrAddress)
+		if err != nil {
+			NewError(ctx, http.StatusBadRequest, err)
+			return nil, false
+		}
+		*nameOrAddress = address
+	}
+
+	addresses := []string{*from}
+	for _, recipient := range recipients {
+		addresses = append(addresses, recipient.To)
+	}
+	if !ValidAddresses(ctx, walletService, addresses...) {
+		return nil, false
+	}
+
+	return recipients, true
+}
+
+// Respond with the status matching an error from creating or verifying a transaction
+func TxnError(ctx *gin.Context, err error) {
+	var verificationErr *services.TxnVerificationError
+	if errors.As(err, &verificationErr) {
+		NewTxnVerificationError(ctx, verificationErr)
+		return
+	}
+	if errors.Is(err, services.ErrWatchOnly) || errors.Is(err, services.ErrWalletLocked) || errors.Is(err, services.ErrNotProposer) ||
+		errors.Is(err, services.ErrNotValidator) {
+		NewError(ctx, http.StatusForbidden, err)
+		return
+	}
+	if errors.Is(err, services.ErrMempoolFull) || errors.Is(err, services.ErrNetworkBusy) {
+		NewError(ctx, http.StatusServiceUnavailable, err)
+		return
+	}
+	NewError(ctx, http.StatusInternalServerError, err)
+}
+
+// Conflicts with the state the miner is in are 409s
+func MinerError(ctx *gin.Context, err error) {
+	if errors.Is(err, services.ErrMinerRunning) || errors.Is(err, services.ErrMinerStopped) || errors.Is(err, services.ErrMinerPaused) {
+		NewError(ctx, http.StatusConflict, err)
+	} else {
+		NewError(ctx, http.StatusInternalServerError, err)
+	}
+}
+
+// Votes by anyone but a validator signing here are 403s, and votes for a second block at a height 409s
+func VoteError(ctx *gin.Context, err error) {
+	if errors.Is(err, services.ErrNotValidator) || errors.Is(err, services.ErrWatchOnly) || errors.Is(err, services.ErrWalletLocked) {
+		NewError(ctx, http.StatusForbidden, err)
+	} else if errors.Is(err, services.ErrConflictingVote) {
+		NewError(ctx, http.StatusConflict, err)
+	} else {
+		NewError(ctx, http.StatusBadRequest, err)
+	}
+}
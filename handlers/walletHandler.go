@@ -1,20 +1,26 @@
 package handlers
 
 import (
+	"encoding/hex"
+	"errors"
 	"net/http"
+	"time"
 
+	reps "github.com/brucetieu/blockchain/representations"
 	"github.com/brucetieu/blockchain/services"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 )
 
 type WalletHandler struct {
-	walletService services.WalletService
+	walletService   services.WalletService
+	hdWalletService services.HDWalletService
 }
 
-func NewWalletHandler(walletService services.WalletService) *WalletHandler {
+func NewWalletHandler(walletService services.WalletService, hdWalletService services.HDWalletService) *WalletHandler {
 	return &WalletHandler{
-		walletService: walletService,
+		walletService:   walletService,
+		hdWalletService: hdWalletService,
 	}
 }
 
@@ -22,18 +28,33 @@ func NewWalletHandler(walletService services.WalletService) *WalletHandler {
 // @Summary      Create a wallet
 // @Description  Create a wallet to store an address and public / private key information
 // @Tags         Wallets
-// @Success      201  {string}  string     "address"
-// @Failure      404  {object}  HTTPError
+// @Param        WalletInput  body      representations.CreateWalletInput  false  "Optional signature algorithm, ecdsa-p256 or ed25519"
+// @Success      201          {object}  representations.Wallet
+// @Failure      400          {object}  HTTPError
+// @Failure      500          {object}  HTTPError
 // @Router       /blockchain/wallets [post]
 func (wh *WalletHandler) CreateWallet(ctx *gin.Context) {
 	log.Info("CreateWallet handler called")
+
+	// Algorithm is optional, so an empty body is fine
+	var input reps.CreateWalletInput
+	_ = ctx.ShouldBindJSON(&input)
+	if input.SigAlgorithm == "" {
+		input.SigAlgorithm = services.DefaultSigAlgorithm
+	}
+
+	if _, err := services.GetSignatureScheme(input.SigAlgorithm); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
 	// Create wallet with private / public key pair
-	wallet, err := wh.walletService.CreateWallet()
+	wallet, err := wh.walletService.CreateWalletWithAlgorithm(input.SigAlgorithm)
 	if err != nil {
 		log.Error("error creating wallet: ", err.Error())
 		NewError(ctx, http.StatusInternalServerError, err)
 	} else {
-		ctx.JSON(http.StatusCreated, gin.H{"address": wallet.Address})
+		ctx.JSON(http.StatusCreated, gin.H{"address": wallet.Address, "publicKey": wallet.PublicKey, "sigAlgorithm": wallet.SigAlgorithm})
 	}
 }
 
@@ -41,13 +62,19 @@ func (wh *WalletHandler) CreateWallet(ctx *gin.Context) {
 // @Summary      Get a wallet
 // @Description  Get a wallet by address
 // @Tags         Wallets
-// @Success      200  {object}  representations.Wallet
-// @Failure      404  {object}  HTTPError
+// @Param        address  path      string  true  "Wallet address"
+// @Success      200      {object}  representations.Wallet
+// @Failure      400      {object}  HTTPError
+// @Failure      404      {object}  HTTPError
 // @Router       /blockchain/wallets/{address} [get]
 func (wh *WalletHandler) GetWallet(ctx *gin.Context) {
 	address := ctx.Param("address")
 	log.Infof("GetWallet handler called with address: %s", address)
 
+	if !ValidAddresses(ctx, wh.walletService, address) {
+		return
+	}
+
 	wallet, err := wh.walletService.GetWallet(address)
 
 	// Don't display private key to user
@@ -84,3 +111,363 @@ func (wh *WalletHandler) GetWallets(ctx *gin.Context) {
 		ctx.JSON(http.StatusOK, gin.H{"wallets": wallets})
 	}
 }
+
+// ExportWIF ... Export a wallet's private key in Wallet Import Format
+// @Summary      Export a private key
+// @Description  Export the private key of a wallet in Wallet Import Format, to use it with other tools. Anyone with this key can spend the wallet's coins
+// @Tags         Wallets
+// @Param        address  path      string  true  "Wallet address"
+// @Success      200      {string}  string  "wif"
+// @Failure      400      {object}  HTTPError
+// @Failure      403      {object}  HTTPError
+// @Failure      404      {object}  HTTPError
+// @Router       /blockchain/wallets/{address}/wif [get]
+func (wh *WalletHandler) ExportWIF(ctx *gin.Context) {
+	address := ctx.Param("address")
+	log.Infof("ExportWIF handler called with address: %s", address)
+
+	if !ValidAddresses(ctx, wh.walletService, address) {
+		return
+	}
+
+	wif, err := wh.walletService.ExportWIF(address)
+	if err != nil {
+		log.Errorf("error exporting key for address: %s %s", address, err.Error())
+		if errors.Is(err, services.ErrWatchOnly) || errors.Is(err, services.ErrWalletLocked) {
+			NewError(ctx, http.StatusForbidden, err)
+			return
+		}
+		NewError(ctx, http.StatusNotFound, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"address": address, "wif": wif})
+	}
+}
+
+// WatchAddress ... Track an address without its private key
+// @Summary      Watch an address
+// @Description  Register an address without its private key, e.g. cold storage, so its balance and transactions can be looked up. Watch-only addresses can't send coins
+// @Tags         Wallets
+// @Param        WatchInput  body      representations.WatchAddressInput  true  "Address to watch"
+// @Success      201         {object}  representations.Wallet
+// @Failure      400         {object}  HTTPError
+// @Router       /blockchain/wallets/watch [post]
+func (wh *WalletHandler) WatchAddress(ctx *gin.Context) {
+	log.Info("WatchAddress handler called")
+
+	var input reps.WatchAddressInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	wallet, err := wh.walletService.WatchAddress(input.Address)
+	if err != nil {
+		log.Error("error watching address: ", err.Error())
+		NewError(ctx, http.StatusBadRequest, err)
+	} else {
+		ctx.JSON(http.StatusCreated, gin.H{"address": wallet.Address, "watchOnly": wallet.WatchOnly})
+	}
+}
+
+// UnlockWallet ... Unlock the wallet file for a while
+// @Summary      Unlock the wallet file
+// @Description  Decrypt the private keys in the wallet file into memory, so coins can be sent. It locks itself again once the ttl is up
+// @Tags         Wallets
+// @Param        UnlockInput  body      representations.UnlockWalletInput  true  "Wallet file passphrase and optional ttl"
+// @Success      200          {string}  string  "expiresAt"
+// @Failure      400          {object}  HTTPError
+// @Failure      403          {object}  HTTPError
+// @Router       /blockchain/wallets/unlock [post]
+func (wh *WalletHandler) UnlockWallet(ctx *gin.Context) {
+	log.Info("UnlockWallet handler called")
+
+	var input reps.UnlockWalletInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	expiresAt, err := wh.walletService.Unlock(input.Passphrase, time.Duration(input.TTLSeconds)*time.Second)
+	if err != nil {
+		log.Error("error unlocking wallet file: ", err.Error())
+		NewError(ctx, http.StatusForbidden, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"unlocked": true, "expiresAt": expiresAt})
+	}
+}
+
+// LockWallet ... Lock the wallet file
+// @Summary      Lock the wallet file
+// @Description  Forget the decrypted private keys straight away. Sending coins fails until the wallet file is unlocked again
+// @Tags         Wallets
+// @Success      200  {boolean}  boolean
+// @Router       /blockchain/wallets/lock [post]
+func (wh *WalletHandler) LockWallet(ctx *gin.Context) {
+	log.Info("LockWallet handler called")
+
+	wh.walletService.Lock()
+	ctx.JSON(http.StatusOK, gin.H{"unlocked": false})
+}
+
+// BackupWallets ... Back up every wallet on the node
+// @Summary      Back up wallets
+// @Description  Get an encrypted archive of every wallet, private key and HD wallet seed on the node. It's encrypted with the wallet file passphrase, which is needed to restore it
+// @Tags         Wallets
+// @Success      200  {object}  representations.WalletBackup
+// @Failure      500  {object}  HTTPError
+// @Router       /blockchain/wallets/backup [get]
+func (wh *WalletHandler) BackupWallets(ctx *gin.Context) {
+	log.Info("BackupWallets handler called")
+
+	backup, err := wh.walletService.BackupWallets()
+	if err != nil {
+		log.Error("error backing up wallets: ", err.Error())
+		NewError(ctx, http.StatusInternalServerError, err)
+	} else {
+		ctx.JSON(http.StatusOK, gin.H{"backup": backup})
+	}
+}
+
+// RestoreWallets ... Restore wallets from a backup
+// @Summary      Restore wallets
+// @Description  Load every wallet in a backup onto the node. Wallets that already exist are left as they are
+// @Tags         Wallets
+// @Param        RestoreInput  body      representations.RestoreWalletsInput  true  "Backup and the wallet file passphrase it was made with"
+// @Success      201           {integer}  integer
+// @Failure      400           {object}   HTTPError
+// @Router       /blockchain/wallets/restore [post]
+func (wh *WalletHandler) RestoreWallets(ctx *gin.Context) {
+	log.Info("RestoreWallets handler called")
+
+	var input reps.RestoreWalletsInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	restored, err := wh.walletService.RestoreWallets(input.Backup, input.Passphrase)
+	if err != nil {
+		log.Error("error restoring wallets: ", err.Error())
+		NewError(ctx, http.StatusBadRequest, err)
+	} else {
+		ctx.JSON(http.StatusCreated, gin.H{"restored": restored})
+	}
+}
+
+// CreateVanityWallet ... Create a wallet whose address starts with a prefix
+// @Summary      Create a vanity wallet
+// @Description  Generate key pairs in parallel until one's address starts with the given prefix, and save it as a wallet. Each extra character makes the search about 58 times longer
+// @Tags         Wallets
+// @Param        VanityInput  body      representations.CreateVanityWalletInput  true  "Address prefix, and optional signature algorithm, timeout and worker count"
+// @Success      201          {object}  representations.Wallet
+// @Failure      400          {object}  HTTPError
+// @Failure      408          {object}  HTTPError
+// @Router       /blockchain/wallets/vanity [post]
+func (wh *WalletHandler) CreateVanityWallet(ctx *gin.Context) {
+	log.Info("CreateVanityWallet handler called")
+
+	var input reps.CreateVanityWalletInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	timeout := time.Duration(input.TimeoutSeconds) * time.Second
+	wallet, err := wh.walletService.CreateVanityWallet(input.Prefix, input.SigAlgorithm, timeout, input.Workers)
+	if err != nil {
+		log.Error("error creating vanity wallet: ", err.Error())
+		if errors.Is(err, services.ErrVanityTimeout) {
+			NewError(ctx, http.StatusRequestTimeout, err)
+			return
+		}
+		NewError(ctx, http.StatusBadRequest, err)
+	} else {
+		ctx.JSON(http.StatusCreated, gin.H{"address": wallet.Address, "publicKey": wallet.PublicKey, "sigAlgorithm": wallet.SigAlgorithm})
+	}
+}
+
+// CreatePaperWallet ... Generate a key pair for cold storage
+// @Summary      Create a paper wallet
+// @Description  Generate a key pair and return its address and WIF private key, optionally as PNG QR codes, to print and keep offline. Nothing is stored on the node
+// @Tags         Wallets
+// @Param        PaperWalletInput  body      representations.CreatePaperWalletInput  false  "Optional signature algorithm, and whether to include QR codes"
+// @Success      201               {object}  representations.PaperWallet
+// @Failure      400               {object}  HTTPError
+// @Router       /blockchain/wallets/paper [post]
+func (wh *WalletHandler) CreatePaperWallet(ctx *gin.Context) {
+	log.Info("CreatePaperWallet handler called")
+
+	// Body is optional
+	var input reps.CreatePaperWalletInput
+	_ = ctx.ShouldBindJSON(&input)
+
+	paperWallet, err := wh.walletService.CreatePaperWallet(input.SigAlgorithm, input.QRCode)
+	if err != nil {
+		log.Error("error creating paper wallet: ", err.Error())
+		NewError(ctx, http.StatusBadRequest, err)
+	} else {
+		ctx.JSON(http.StatusCreated, gin.H{"paperWallet": paperWallet})
+	}
+}
+
+// ImportPublicKey ... Import a public key whose private key is held by a remote signer
+// @Summary      Import a public key
+// @Description  Create a wallet for a public key whose private key is held by the remote signer at SIGNER_URL, e.g. in an HSM. Transactions from it are signed by the remote signer
+// @Tags         Wallets
+// @Param        PublicKeyInput  body      representations.ImportPublicKeyInput  true  "Hex encoded public key and its signature algorithm"
+// @Success      201             {object}  representations.Wallet
+// @Failure      400             {object}  HTTPError
+// @Router       /blockchain/wallets/pubkey [post]
+func (wh *WalletHandler) ImportPublicKey(ctx *gin.Context) {
+	log.Info("ImportPublicKey handler called")
+
+	var input reps.ImportPublicKeyInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	pubKey, err := hex.DecodeString(input.PublicKey)
+	if err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	wallet, err := wh.walletService.ImportPublicKey(pubKey, input.SigAlgorithm)
+	if err != nil {
+		log.Error("error importing public key: ", err.Error())
+		NewError(ctx, http.StatusBadRequest, err)
+	} else {
+		ctx.JSON(http.StatusCreated, gin.H{"address": wallet.Address, "publicKey": wallet.PublicKey, "sigAlgorithm": wallet.SigAlgorithm})
+	}
+}
+
+// ImportWIF ... Import a private key in Wallet Import Format
+// @Summary      Import a private key
+// @Description  Import a private key in Wallet Import Format into the wallet file, creating a wallet for it
+// @Tags         Wallets
+// @Param        WIFInput  body      representations.ImportWIFInput  true  "WIF encoded private key"
+// @Success      201       {object}  representations.Wallet
+// @Failure      400       {object}  HTTPError
+// @Router       /blockchain/wallets/import [post]
+func (wh *WalletHandler) ImportWIF(ctx *gin.Context) {
+	log.Info("ImportWIF handler called")
+
+	var input reps.ImportWIFInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	wallet, err := wh.walletService.ImportWIF(input.WIF)
+	if err != nil {
+		log.Error("error importing wif key: ", err.Error())
+		NewError(ctx, http.StatusBadRequest, err)
+	} else {
+		ctx.JSON(http.StatusCreated, gin.H{"address": wallet.Address, "publicKey": wallet.PublicKey, "sigAlgorithm": wallet.SigAlgorithm})
+	}
+}
+
+// CreateHDWallet ... Create a hierarchical deterministic wallet
+// @Summary      Create an HD wallet
+// @Description  Create an HD wallet from a new BIP39 mnemonic and derive its first address. The mnemonic is only shown once, write it down to be able to recover the wallet
+// @Tags         Wallets
+// @Param        HDWalletInput  body      representations.CreateHDWalletInput  false  "Optional BIP39 passphrase"
+// @Success      201            {object}  representations.HDWallet
+// @Failure      500            {object}  HTTPError
+// @Router       /blockchain/wallets/hd [post]
+func (wh *WalletHandler) CreateHDWallet(ctx *gin.Context) {
+	log.Info("CreateHDWallet handler called")
+
+	// Passphrase is optional, so an empty body is fine
+	var input reps.CreateHDWalletInput
+	_ = ctx.ShouldBindJSON(&input)
+
+	hdWallet, mnemonic, wallet, err := wh.hdWalletService.CreateHDWallet(input.Passphrase)
+	if err != nil {
+		log.Error("error creating hd wallet: ", err.Error())
+		NewError(ctx, http.StatusInternalServerError, err)
+	} else {
+		ctx.JSON(http.StatusCreated, gin.H{"hdWallet": hdWallet, "mnemonic": mnemonic, "address": wallet.Address})
+	}
+}
+
+// DeriveHDWalletAddress ... Derive the next address of an HD wallet
+// @Summary      Derive an HD wallet address
+// @Description  Derive the next receiving address of an HD wallet
+// @Tags         Wallets
+// @Param        hdWalletId  path      string  true  "HD wallet ID"
+// @Success      201         {string}  string  "address"
+// @Failure      404         {object}  HTTPError
+// @Router       /blockchain/wallets/hd/{hdWalletId}/addresses [post]
+func (wh *WalletHandler) DeriveHDWalletAddress(ctx *gin.Context) {
+	hdWalletId := ctx.Param("hdWalletId")
+	log.Info("DeriveHDWalletAddress handler called with hdWalletId: ", hdWalletId)
+
+	wallet, err := wh.hdWalletService.DeriveNextWallet(hdWalletId)
+	if err != nil {
+		log.Error("error deriving address: ", err.Error())
+		NewError(ctx, http.StatusNotFound, err)
+	} else {
+		ctx.JSON(http.StatusCreated, gin.H{"address": wallet.Address, "derivationPath": wallet.DerivationPath})
+	}
+}
+
+// GetHDWallet ... Get an HD wallet and its addresses
+// @Summary      Get an HD wallet
+// @Description  Get an HD wallet and every address derived from it
+// @Tags         Wallets
+// @Param        hdWalletId  path      string  true  "HD wallet ID"
+// @Success      200         {object}  representations.HDWallet
+// @Failure      404         {object}  HTTPError
+// @Router       /blockchain/wallets/hd/{hdWalletId} [get]
+func (wh *WalletHandler) GetHDWallet(ctx *gin.Context) {
+	hdWalletId := ctx.Param("hdWalletId")
+	log.Info("GetHDWallet handler called with hdWalletId: ", hdWalletId)
+
+	hdWallet, wallets, err := wh.hdWalletService.GetHDWallet(hdWalletId)
+	if err != nil {
+		log.Error("error getting hd wallet: ", err.Error())
+		NewError(ctx, http.StatusNotFound, err)
+		return
+	}
+
+	// Don't display private keys to user
+	for i := 0; i < len(wallets); i++ {
+		wallets[i].PrivateKey = nil
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"hdWallet": hdWallet, "wallets": wallets})
+}
+
+// RecoverHDWallet ... Recover an HD wallet from its mnemonic
+// @Summary      Recover an HD wallet
+// @Description  Restore an HD wallet and all of its used addresses from its BIP39 mnemonic
+// @Tags         Wallets
+// @Param        RecoverInput  body      representations.RecoverHDWalletInput  true  "Mnemonic and optional passphrase"
+// @Success      200           {object}  representations.HDWallet
+// @Failure      400           {object}  HTTPError
+// @Router       /blockchain/wallets/hd/recover [post]
+func (wh *WalletHandler) RecoverHDWallet(ctx *gin.Context) {
+	log.Info("RecoverHDWallet handler called")
+
+	var input reps.RecoverHDWalletInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	hdWallet, wallets, err := wh.hdWalletService.RecoverHDWallet(input.Mnemonic, input.Passphrase)
+	if err != nil {
+		log.Error("error recovering hd wallet: ", err.Error())
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	addresses := make([]string, 0)
+	for _, wallet := range wallets {
+		addresses = append(addresses, wallet.Address)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"hdWallet": hdWallet, "addresses": addresses})
+}
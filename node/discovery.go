@@ -0,0 +1,146 @@
+package node
+
+import (
+	"net"
+	"sort"
+	"time"
+
+	reps "github.com/brucetieu/blockchain/representations"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	SeedPeers   = []string{} // Host:port addresses of nodes asked for others to connect to when the node has no peers
+	MaxOutbound = 8          // Peers the node connects out to on its own, out of the addresses it learns
+	MaxAddrs    = 1000       // Most addresses in an addr message, and most the node remembers
+)
+
+// Remember addresses peers can be reached on, to connect to later. Past MaxAddrs, the ones heard of least recently
+// are forgotten
+func (n *node) learnAddresses(addresses []string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := time.Now().UnixMilli()
+	for _, address := range addresses {
+		if _, _, err := net.SplitHostPort(address); err != nil || n.selfAddresses[address] {
+			continue
+		}
+		n.addresses[address] = now
+	}
+
+	if len(n.addresses) <= MaxAddrs {
+		return
+	}
+	for _, address := range n.knownAddressesLocked()[MaxAddrs:] {
+		delete(n.addresses, address)
+	}
+}
+
+// Stop connecting to address, e.g. because nothing answers there or it's this node
+func (n *node) forgetAddress(address string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.addresses, address)
+}
+
+// Addresses the node remembers, heard of most recently first.
+// Must hold mu
+func (n *node) knownAddressesLocked() []string {
+	addresses := make([]string, 0, len(n.addresses))
+	for address := range n.addresses {
+		addresses = append(addresses, address)
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return n.addresses[addresses[i]] > n.addresses[addresses[j]]
+	})
+	return addresses
+}
+
+// Whether there's a peer reachable on address already
+// Must hold mu
+func (n *node) connectedToLocked(address string) bool {
+	for _, p := range n.peers {
+		if p.reachableOn() == address {
+			return true
+		}
+	}
+	return false
+}
+
+// Connect out to addresses the node remembers until it has MaxOutbound peers it connected to, or runs out of them.
+// With no peers and no addresses left, it starts over from the seeds, at most once every SeedInterval
+func (n *node) connectMore() {
+	n.mu.Lock()
+	if n.closed || n.discovering {
+		n.mu.Unlock()
+		return
+	}
+	n.discovering = true
+
+	outbound := 0
+	for _, p := range n.peers {
+		if !p.inbound {
+			outbound++
+		}
+	}
+	candidates := make([]string, 0)
+	for _, address := range n.knownAddressesLocked() {
+		if !n.connectedToLocked(address) {
+			candidates = append(candidates, address)
+		}
+	}
+	if len(candidates) == 0 && len(n.peers) == 0 && time.Since(n.lastSeeded) >= SeedInterval {
+		candidates = append(candidates, SeedPeers...)
+		n.lastSeeded = time.Now()
+	}
+	n.mu.Unlock()
+
+	defer func() {
+		n.mu.Lock()
+		n.discovering = false
+		n.mu.Unlock()
+	}()
+
+	for _, address := range candidates {
+		if outbound >= MaxOutbound {
+			return
+		}
+		if _, err := n.Connect(address); err != nil {
+			log.WithFields(log.Fields{"address": address, "error": err.Error()}).Info("Couldn't connect to peer, forgetting its address")
+			n.forgetAddress(address)
+			continue
+		}
+		outbound++
+	}
+}
+
+// Remember the addresses the peer sent, and connect to them if the node could use more peers
+func (n *node) handleAddr(p *peer, addr reps.AddrMessage) error {
+	if len(addr.Addresses) > MaxAddrs {
+		log.WithField("peer", p.id).Infof("Peer sent %d addresses, only taking the first %d", len(addr.Addresses), MaxAddrs)
+		addr.Addresses = addr.Addresses[:MaxAddrs]
+	}
+
+	n.learnAddresses(addr.Addresses)
+	go n.connectMore()
+
+	return nil
+}
+
+// Send the peer the addresses the node remembers, other than its own
+func (n *node) handleGetAddr(p *peer) error {
+	n.mu.Lock()
+	known := n.knownAddressesLocked()
+	n.mu.Unlock()
+
+	addr := reps.AddrMessage{Addresses: make([]string, 0, len(known))}
+	for _, address := range known {
+		if address != p.reachableOn() && len(addr.Addresses) < MaxAddrs {
+			addr.Addresses = append(addr.Addresses, address)
+		}
+	}
+
+	return p.send(CmdAddr, addr)
+}
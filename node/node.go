@@ -0,0 +1,673 @@
+package node
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Commands of the messages peers exchange
+const (
+	CmdVersion    = "version"    // First message each side sends, describing its chain
+	CmdGetHeaders = "getheaders" // Asks for the headers of the blocks after the last one both sides have
+	CmdHeaders    = "headers"    // Headers of blocks, in answer to getheaders
+	CmdInv        = "inv"        // Announces blocks or transactions by hash
+	CmdGetData    = "getdata"    // Asks for blocks or transactions by hash
+	CmdBlock      = "block"      // A block, in answer to getdata
+	CmdTx         = "tx"         // A transaction, in answer to getdata
+	CmdNotFound   = "notfound"   // Hashes of the blocks or transactions asked for with getdata that the sender doesn't have
+	CmdGetAddr    = "getaddr"    // Asks for the addresses of other nodes the receiver knows of
+	CmdAddr       = "addr"       // Addresses of other nodes, in answer to getaddr
+)
+
+// What inv and getdata messages refer to
+const (
+	InvBlock = "block"
+	InvTx    = "tx"
+)
+
+var (
+	ProtocolVersion  = 2                // Version of the protocol this node speaks. 2 syncs headers first
+	MaxPeers         = 32               // Most peers connected at once, inbound and outbound together
+	MaxInvHashes     = 500              // Most hashes in an inv, getdata or notfound
+	MaxMessageSize   = 32 * 1024 * 1024 // Most bytes a message can take up. A peer sending a bigger one is dropped
+	DialTimeout      = 10 * time.Second // How long to wait on a peer to take a connection
+	HandshakeTimeout = 30 * time.Second // How long a peer has to send its version after connecting
+	WriteTimeout     = 30 * time.Second // How long to wait on a peer to take a message
+	SeedInterval     = time.Minute      // How long to wait before going back to the seeds for peers
+
+	MaxKnownInventory = 5000 // Most hashes of blocks and transactions remembered as known to each peer
+)
+
+// Keeps the node's chain in sync with its peers' over TCP. On connecting, each side sends its version, and whichever
+// is behind asks for the headers of the blocks it's missing with getheaders. Once it has checked them, and that they
+// make a chain with more work than its own, it fetches the blocks with getdata from every peer that has them, and adds
+// them like any other received block. Nodes ask the peers they connect to
+// for the addresses of others with getaddr, and connect to those too. Blocks added to the chain, whether mined here or
+// received, are announced to every peer that doesn't have them yet, so the network converges without polling, and so
+// are transactions let into the mempool, so they reach the miners wherever they're submitted
+type Node interface {
+	Listen(address string) (string, error)
+	Connect(address string) (reps.Peer, error)
+	Disconnect(peerId string) error
+	GetPeers() []reps.Peer
+	GetSyncStatus() reps.SyncStatus
+	Close()
+}
+
+type node struct {
+	blockchainService services.BlockchainService
+	mempoolService    services.MempoolService
+	params            *reps.ChainParams
+	nonce             uint64
+	seenTxns          *seenCache
+
+	mu            sync.Mutex
+	listener      net.Listener
+	listenPort    int
+	peers         map[string]*peer // By id
+	addresses     map[string]int64 // Addresses peers can be reached on, and when the node last heard of each
+	selfAddresses map[string]bool  // Addresses that turned out to be this node's own
+	discovering   bool             // Whether the node is connecting to addresses it learned already
+	lastSeeded    time.Time        // When the node last went to the seeds for peers
+	closed        bool
+	done          chan struct{} // Closed once the node is shut down
+
+	syncMu sync.Mutex
+	sync   syncState
+}
+
+func NewNode(blockchainService services.BlockchainService, mempoolService services.MempoolService,
+	params *reps.ChainParams) Node {
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		log.Fatal("Error picking node nonce: ", err.Error())
+	}
+
+	n := &node{
+		blockchainService: blockchainService,
+		mempoolService:    mempoolService,
+		params:            params,
+		nonce:             binary.BigEndian.Uint64(nonce),
+		seenTxns:          newSeenCache(),
+		peers:             make(map[string]*peer),
+		addresses:         make(map[string]int64),
+		selfAddresses:     make(map[string]bool),
+		done:              make(chan struct{}),
+	}
+	blockchainService.OnBlockConnected(n.announceBlock)
+	mempoolService.OnTransactionAccepted(n.relayTransaction)
+	go n.watchSync(SyncStallTimeout)
+
+	return n
+}
+
+// Take connections from peers on P2P_PORT, connect to the comma separated PEERS, and learn the addresses of others
+// from the comma separated SEED_PEERS, if they're set. Without any of them, the node is standalone
+func StartAtStartup(n Node) {
+	if port := os.Getenv("P2P_PORT"); port != "" {
+		address, err := n.Listen(":" + port)
+		if err != nil {
+			log.Fatal("Error listening for peers: ", err.Error())
+		}
+		log.Info("Listening for peers on ", address)
+	}
+
+	for _, address := range strings.Split(os.Getenv("SEED_PEERS"), ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			SeedPeers = append(SeedPeers, address)
+		}
+	}
+
+	for _, address := range append(strings.Split(os.Getenv("PEERS"), ","), SeedPeers...) {
+		if address = strings.TrimSpace(address); address == "" {
+			continue
+		}
+		if _, err := n.Connect(address); err != nil {
+			log.WithFields(log.Fields{"address": address, "error": err.Error()}).Warn("Couldn't connect to peer")
+		}
+	}
+}
+
+// Take connections from peers on address, in the background. Returns the address listened on, which has the port
+// picked when address doesn't give one
+func (n *node) Listen(address string) (string, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return "", err
+	}
+
+	n.mu.Lock()
+	if n.listener != nil {
+		n.mu.Unlock()
+		listener.Close()
+		return "", fmt.Errorf("already listening for peers on %s", n.listener.Addr().String())
+	}
+	n.listener = listener
+	n.listenPort = listener.Addr().(*net.TCPAddr).Port
+	n.mu.Unlock()
+
+	go n.accept(listener)
+	return listener.Addr().String(), nil
+}
+
+func (n *node) accept(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Error("Error taking connection from peer: ", err.Error())
+			}
+			return
+		}
+
+		p, err := n.addPeer(conn, true, "")
+		if err != nil {
+			log.WithFields(log.Fields{"address": conn.RemoteAddr().String(), "error": err.Error()}).Info("Turning away peer")
+			conn.Close()
+			continue
+		}
+		go n.run(p)
+	}
+}
+
+// Connect to the node at address, and start syncing with it in the background
+func (n *node) Connect(address string) (reps.Peer, error) {
+	n.mu.Lock()
+	connected := n.connectedToLocked(address)
+	n.mu.Unlock()
+	if connected {
+		return reps.Peer{}, fmt.Errorf("already connected to %s", address)
+	}
+
+	conn, err := net.DialTimeout("tcp", address, DialTimeout)
+	if err != nil {
+		return reps.Peer{}, fmt.Errorf("%s, address: %s", err.Error(), address)
+	}
+
+	p, err := n.addPeer(conn, false, address)
+	if err != nil {
+		conn.Close()
+		return reps.Peer{}, err
+	}
+	go n.run(p)
+
+	return p.info(), nil
+}
+
+// Drop the peer with peerId. A peer the node connected to isn't connected to again unless its address comes up again
+func (n *node) Disconnect(peerId string) error {
+	n.mu.Lock()
+	p, ok := n.peers[peerId]
+	delete(n.peers, peerId)
+	n.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("not connected to a peer with id %s", peerId)
+	}
+
+	log.WithFields(log.Fields{"peer": p.id, "address": p.conn.RemoteAddr().String()}).Info("Disconnecting from peer")
+	return p.conn.Close()
+}
+
+// Peers the node is connected to, oldest connection first
+func (n *node) GetPeers() []reps.Peer {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	peers := make([]reps.Peer, 0, len(n.peers))
+	for _, p := range n.peers {
+		peers = append(peers, p.info())
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].ConnectedAt < peers[j].ConnectedAt
+	})
+
+	return peers
+}
+
+// Stop taking connections and drop every peer
+func (n *node) Close() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if !n.closed {
+		close(n.done)
+	}
+	n.closed = true
+	if n.listener != nil {
+		n.listener.Close()
+	}
+	for _, p := range n.peers {
+		p.conn.Close()
+	}
+}
+
+func (n *node) addPeer(conn net.Conn, inbound bool, address string) (*peer, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.closed {
+		return nil, fmt.Errorf("node is shut down")
+	}
+	if len(n.peers) >= MaxPeers {
+		return nil, fmt.Errorf("already connected to the most peers there can be, %d", MaxPeers)
+	}
+
+	p := newPeer(conn, inbound, address)
+	n.peers[p.id] = p
+	return p, nil
+}
+
+// Drop the peer. When it's one the node connected to, its address is forgotten, and another is connected to instead
+func (n *node) removePeer(p *peer) {
+	n.mu.Lock()
+	delete(n.peers, p.id)
+	n.mu.Unlock()
+
+	p.conn.Close()
+	n.stopSyncingFrom(p)
+	if !p.inbound {
+		n.forgetAddress(p.reachableOn())
+		go n.connectMore()
+	}
+}
+
+// Peers that finished their handshake
+func (n *node) readyPeers() []*peer {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	peers := make([]*peer, 0, len(n.peers))
+	for _, p := range n.peers {
+		if p.ready() {
+			peers = append(peers, p)
+		}
+	}
+	return peers
+}
+
+// Exchange versions with the peer, then handle what it sends until it disconnects or misbehaves
+func (n *node) run(p *peer) {
+	logger := log.WithFields(log.Fields{"peer": p.id, "address": p.conn.RemoteAddr().String()})
+	defer n.removePeer(p)
+
+	if err := p.conn.SetReadDeadline(time.Now().Add(HandshakeTimeout)); err != nil {
+		return
+	}
+	if err := p.send(CmdVersion, n.version()); err != nil {
+		logger.Info("Couldn't send version to peer: ", err.Error())
+		return
+	}
+
+	scanner := bufio.NewScanner(p.conn)
+	scanner.Buffer(make([]byte, 64*1024), MaxMessageSize)
+	for scanner.Scan() {
+		var msg reps.PeerMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			logger.Warn("Dropping peer for malformed message: ", err.Error())
+			return
+		}
+		p.touch()
+
+		if err := n.handleMessage(p, msg); err != nil {
+			logger.WithField("command", msg.Command).Warn("Dropping peer: ", err.Error())
+			return
+		}
+	}
+
+	if err := scanner.Err(); err != nil && !errors.Is(err, net.ErrClosed) {
+		logger.Info("Peer connection failed: ", err.Error())
+		return
+	}
+	logger.Info("Peer disconnected")
+}
+
+// Act on a message from the peer. An error means the peer misbehaved, and is dropped
+func (n *node) handleMessage(p *peer, msg reps.PeerMessage) error {
+	if msg.Command != CmdVersion && !p.ready() {
+		return fmt.Errorf("sent %s before its version", msg.Command)
+	}
+
+	switch msg.Command {
+	case CmdVersion:
+		var version reps.VersionMessage
+		if err := json.Unmarshal(msg.Payload, &version); err != nil {
+			return err
+		}
+		return n.handleVersion(p, version)
+	case CmdGetHeaders:
+		var getHeaders reps.GetHeadersMessage
+		if err := json.Unmarshal(msg.Payload, &getHeaders); err != nil {
+			return err
+		}
+		return n.handleGetHeaders(p, getHeaders)
+	case CmdHeaders:
+		var headers reps.HeadersMessage
+		if err := json.Unmarshal(msg.Payload, &headers); err != nil {
+			return err
+		}
+		return n.handleHeaders(p, headers)
+	case CmdInv:
+		var inv reps.InvMessage
+		if err := json.Unmarshal(msg.Payload, &inv); err != nil {
+			return err
+		}
+		return n.handleInv(p, inv)
+	case CmdGetData:
+		var getData reps.InvMessage
+		if err := json.Unmarshal(msg.Payload, &getData); err != nil {
+			return err
+		}
+		return n.handleGetData(p, getData)
+	case CmdBlock:
+		var block reps.Block
+		if err := json.Unmarshal(msg.Payload, &block); err != nil {
+			return err
+		}
+		return n.handleBlock(p, block)
+	case CmdTx:
+		var txn reps.Transaction
+		if err := json.Unmarshal(msg.Payload, &txn); err != nil {
+			return err
+		}
+		return n.handleTx(p, txn)
+	case CmdNotFound:
+		var notFound reps.InvMessage
+		if err := json.Unmarshal(msg.Payload, &notFound); err != nil {
+			return err
+		}
+		return n.handleNotFound(p, notFound)
+	case CmdGetAddr:
+		return n.handleGetAddr(p)
+	case CmdAddr:
+		var addr reps.AddrMessage
+		if err := json.Unmarshal(msg.Payload, &addr); err != nil {
+			return err
+		}
+		return n.handleAddr(p, addr)
+	}
+
+	// Newer peers may know commands this node doesn't
+	log.WithFields(log.Fields{"peer": p.id, "command": msg.Command}).Debug("Ignoring unknown command from peer")
+	return nil
+}
+
+// Version describing this node's chain
+func (n *node) version() reps.VersionMessage {
+	n.mu.Lock()
+	listenPort := n.listenPort
+	n.mu.Unlock()
+
+	version := reps.VersionMessage{
+		Version:    ProtocolVersion,
+		ChainID:    services.ChainIdentifier(n.params),
+		Height:     -1,
+		ListenPort: listenPort,
+		Nonce:      n.nonce,
+	}
+	if genesis, err := n.blockchainService.GetGenesisBlock(); err == nil {
+		version.GenesisHash = genesis.Hash
+	}
+	if lastBlock, err := n.blockchainService.GetLastBlock(); err == nil {
+		version.Height = lastBlock.Height
+		version.BestHash = lastBlock.Hash
+	}
+
+	return version
+}
+
+// Only peers on the same chain are kept. The address the peer takes connections on is remembered, and peers the node
+// connected to are asked for the addresses they know. If the peer is ahead, the node may sync from it
+func (n *node) handleVersion(p *peer, version reps.VersionMessage) error {
+	if p.ready() {
+		return fmt.Errorf("sent its version twice")
+	}
+	if version.Nonce == n.nonce {
+		if !p.inbound {
+			n.mu.Lock()
+			n.selfAddresses[p.reachableOn()] = true
+			n.mu.Unlock()
+		}
+		return fmt.Errorf("connected to itself")
+	}
+	if version.ChainID != services.ChainIdentifier(n.params) {
+		return fmt.Errorf("on chain %q, not %q", version.ChainID, services.ChainIdentifier(n.params))
+	}
+	if genesis, err := n.blockchainService.GetGenesisBlock(); err == nil && len(version.GenesisHash) > 0 &&
+		!bytes.Equal(genesis.Hash, version.GenesisHash) {
+		return fmt.Errorf("has genesis block %x, not %x", version.GenesisHash, genesis.Hash)
+	}
+
+	p.mu.Lock()
+	p.version = &version
+	p.height = version.Height
+	if p.inbound && version.ListenPort > 0 {
+		p.address = net.JoinHostPort(p.conn.RemoteAddr().(*net.TCPAddr).IP.String(), strconv.Itoa(version.ListenPort))
+	}
+	p.mu.Unlock()
+
+	// The peer is who it says it is, so it can stay connected as long as it likes
+	if err := p.conn.SetReadDeadline(time.Time{}); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{"peer": p.id, "address": p.conn.RemoteAddr().String(), "height": version.Height}).Info("Connected to peer")
+
+	if address := p.reachableOn(); address != "" {
+		n.learnAddresses([]string{address})
+	}
+	if !p.inbound {
+		if err := p.send(CmdGetAddr, nil); err != nil {
+			return err
+		}
+	}
+
+	n.maybeSync()
+	return nil
+}
+
+// Hashes of the last 10 blocks on the chain, then of blocks twice as far apart each time back to its first. However
+// far back the peer's chain forks off, one of them is close to where
+func (n *node) blockLocator() [][]byte {
+	locator := make([][]byte, 0)
+	lastBlock, err := n.blockchainService.GetLastBlock()
+	if err != nil {
+		return locator
+	}
+
+	heights := make([]int, 0)
+	step := 1
+	for height := lastBlock.Height; height > 0; height -= step {
+		heights = append(heights, height)
+		if len(heights) >= 10 {
+			step *= 2
+		}
+	}
+	heights = append(heights, 0)
+
+	for _, height := range heights {
+		// A chain started from a snapshot has no blocks below it
+		headers, err := n.blockchainService.GetBlockHeaders(height, 1)
+		if err != nil || len(headers) == 0 {
+			continue
+		}
+		locator = append(locator, headers[0].Hash)
+	}
+
+	return locator
+}
+
+// Height after the first block in the locator that's on this node's chain. If none of them are, the peer's chain has
+// nothing in common with this one, and it's sent blocks from the start
+func (n *node) locate(locator [][]byte) int {
+	for _, hash := range locator {
+		if block, err := n.blockchainService.GetBlockByHash(hash); err == nil {
+			return block.Height + 1
+		}
+	}
+	return 0
+}
+
+// Ask the peer for whatever it announced that this node doesn't have yet. Blocks announced while the node is syncing
+// wait until it's done
+func (n *node) handleInv(p *peer, inv reps.InvMessage) error {
+	if len(inv.Hashes) > MaxInvHashes {
+		return fmt.Errorf("announced %d hashes, more than the %d there can be", len(inv.Hashes), MaxInvHashes)
+	}
+	for _, hash := range inv.Hashes {
+		p.addKnown(hash)
+	}
+
+	getData := reps.InvMessage{Type: inv.Type, Hashes: make([][]byte, 0, len(inv.Hashes))}
+	switch inv.Type {
+	case InvBlock:
+		if n.isSyncing() {
+			return nil
+		}
+		for _, hash := range inv.Hashes {
+			if !n.blockchainService.HasBlock(hash) {
+				getData.Hashes = append(getData.Hashes, hash)
+			}
+		}
+	case InvTx:
+		for _, hash := range inv.Hashes {
+			if n.seenTxns.has(hash) {
+				continue
+			}
+			if _, ok := n.mempoolService.GetEntry(hex.EncodeToString(hash)); !ok {
+				getData.Hashes = append(getData.Hashes, hash)
+			}
+		}
+	default:
+		return fmt.Errorf("announced %s, which isn't a block or tx", inv.Type)
+	}
+
+	if len(getData.Hashes) == 0 {
+		return nil
+	}
+	return p.send(CmdGetData, getData)
+}
+
+// Send the peer the blocks on the chain, or transactions in the mempool, it asked for. The hashes of the ones this
+// node doesn't have are sent back in a notfound, so the peer can ask another
+func (n *node) handleGetData(p *peer, getData reps.InvMessage) error {
+	if len(getData.Hashes) > MaxInvHashes {
+		return fmt.Errorf("asked for %d hashes, more than the %d there can be", len(getData.Hashes), MaxInvHashes)
+	}
+
+	notFound := reps.InvMessage{Type: getData.Type, Hashes: make([][]byte, 0)}
+	for _, hash := range getData.Hashes {
+		switch getData.Type {
+		case InvBlock:
+			block, err := n.blockchainService.GetBlockByHash(hash)
+			if err != nil {
+				notFound.Hashes = append(notFound.Hashes, hash)
+				continue
+			}
+			p.addKnown(hash)
+			if err := p.send(CmdBlock, block); err != nil {
+				return err
+			}
+		case InvTx:
+			entry, ok := n.mempoolService.GetEntry(hex.EncodeToString(hash))
+			if !ok {
+				notFound.Hashes = append(notFound.Hashes, hash)
+				continue
+			}
+			p.addKnown(hash)
+			if err := p.send(CmdTx, entry.Transaction); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("asked for %s, which isn't a block or tx", getData.Type)
+		}
+	}
+
+	if len(notFound.Hashes) == 0 {
+		return nil
+	}
+	return p.send(CmdNotFound, notFound)
+}
+
+// Add a block from the peer to the chain, or hold it until its parent shows up, in which case the peer has blocks
+// this node is missing, and it may sync from it. Peers sending invalid blocks are dropped, as are peers sending any
+// block they were asked for while syncing that can't go on the chain
+func (n *node) handleBlock(p *peer, block reps.Block) error {
+	if err := services.CheckBlockContents(block); err != nil {
+		return err
+	}
+	p.sawHeight(block.Height)
+	p.addKnown(block.Hash)
+	syncing := n.requestedFrom(p, block.Hash)
+
+	update, err := n.mempoolService.ReceiveBlock(block)
+	switch {
+	case errors.Is(err, services.ErrKnownBlock):
+	case errors.Is(err, services.ErrInvalidBlock):
+		return err
+	case err != nil && syncing:
+		return err
+	case err != nil:
+		log.WithFields(log.Fields{"peer": p.id, "hash": hex.EncodeToString(block.Hash), "error": err.Error()}).Warn("Rejected block from peer")
+		return nil
+	}
+
+	if syncing {
+		n.syncedBlock(block)
+		return nil
+	}
+	if update.Orphaned {
+		n.maybeSync()
+	}
+	return nil
+}
+
+// Queue a transaction from the peer in the mempool, unless it was seen already. One that doesn't check out is left
+// out, since it may only be because this node's chain is behind or ahead of the peer's. One that does is relayed on
+func (n *node) handleTx(p *peer, txn reps.Transaction) error {
+	p.addKnown(txn.ID)
+	if !n.seenTxns.add(txn.ID) {
+		return nil
+	}
+	if _, err := n.mempoolService.AddTransaction(txn); err != nil {
+		log.WithFields(log.Fields{"peer": p.id, "txnId": hex.EncodeToString(txn.ID), "error": err.Error()}).Info("Rejected transaction from peer")
+	}
+	return nil
+}
+
+// Announce a block added to the chain to the peers that don't have it yet, in the background, since it's called
+// while the block is being added. Blocks downloaded while syncing aren't, only where the sync got to once it's done
+func (n *node) announceBlock(block reps.Block) {
+	if n.isSyncing() {
+		return
+	}
+	go n.announce(InvBlock, block.Hash)
+}
+
+// Send an inv of the block or transaction with hash to every peer that finished its handshake and doesn't have it
+func (n *node) announce(invType string, hash []byte) {
+	for _, p := range n.readyPeers() {
+		if !p.addKnown(hash) {
+			continue
+		}
+		if err := p.send(CmdInv, reps.InvMessage{Type: invType, Hashes: [][]byte{hash}}); err != nil {
+			log.WithFields(log.Fields{"peer": p.id, "hash": hex.EncodeToString(hash), "error": err.Error()}).Info("Couldn't announce to peer")
+		}
+	}
+}
@@ -0,0 +1,618 @@
+package node_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brucetieu/blockchain/node"
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/stretchr/testify/assert"
+)
+
+// Chain of blocks that only link by hash. Blocks whose parent isn't on it yet are held until it is
+type fakeChain struct {
+	services.BlockchainService
+
+	mu        sync.Mutex
+	blocks    []reps.Block
+	orphans   []reps.Block
+	listeners []services.BlockListener
+	fetched   int // Blocks looked up by hash, e.g. to send to a peer
+}
+
+func newFakeChain(genesis reps.Block) *fakeChain {
+	return &fakeChain{blocks: []reps.Block{genesis}}
+}
+
+// Mine count blocks on top of the last one, told apart from other chains' by tag
+func (fc *fakeChain) extend(count int, tag byte) {
+	fc.mu.Lock()
+	mined := make([]reps.Block, 0, count)
+	for i := 0; i < count; i++ {
+		last := fc.blocks[len(fc.blocks)-1]
+		block := reps.Block{
+			ID:           fmt.Sprintf("%c%d", tag, last.Height+1),
+			Height:       last.Height + 1,
+			PrevHash:     last.Hash,
+			Hash:         []byte{tag, byte(last.Height + 1)},
+			MerkleRoot:   []byte{tag, byte(last.Height + 1)},
+			Transactions: []reps.Transaction{{ID: []byte{tag, byte(last.Height + 1)}}},
+		}
+		fc.blocks = append(fc.blocks, block)
+		mined = append(mined, block)
+	}
+	fc.mu.Unlock()
+
+	fc.connected(mined)
+}
+
+func (fc *fakeChain) connected(blocks []reps.Block) {
+	fc.mu.Lock()
+	listeners := fc.listeners
+	fc.mu.Unlock()
+
+	for _, block := range blocks {
+		for _, listener := range listeners {
+			listener(block)
+		}
+	}
+}
+
+func (fc *fakeChain) OnBlockConnected(listener services.BlockListener) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	fc.listeners = append(fc.listeners, listener)
+}
+
+func (fc *fakeChain) hashes() [][]byte {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	hashes := make([][]byte, 0, len(fc.blocks))
+	for _, block := range fc.blocks {
+		hashes = append(hashes, block.Hash)
+	}
+	return hashes
+}
+
+func (fc *fakeChain) GetGenesisBlock() (reps.Block, error) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return fc.blocks[0], nil
+}
+
+func (fc *fakeChain) GetLastBlock() (reps.Block, error) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return fc.blocks[len(fc.blocks)-1], nil
+}
+
+func (fc *fakeChain) GetBlockByHash(hash []byte) (reps.Block, error) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	fc.fetched++
+	for _, block := range fc.blocks {
+		if bytes.Equal(block.Hash, hash) {
+			return block, nil
+		}
+	}
+	return reps.Block{}, fmt.Errorf("record not found")
+}
+
+func (fc *fakeChain) timesFetched() int {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return fc.fetched
+}
+
+func (fc *fakeChain) HasBlock(hash []byte) bool {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	for _, block := range append(fc.blocks, fc.orphans...) {
+		if bytes.Equal(block.Hash, hash) {
+			return true
+		}
+	}
+	return false
+}
+
+func (fc *fakeChain) CheckHeader(header reps.Block) error {
+	if len(header.Hash) == 0 {
+		return fmt.Errorf("%w: header has no hash", services.ErrInvalidBlock)
+	}
+	return nil
+}
+
+func (fc *fakeChain) GetBlockHeaders(from int, count int) ([]reps.Block, error) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	headers := make([]reps.Block, 0)
+	for _, block := range fc.blocks {
+		if block.Height >= from && block.Height < from+count {
+			headers = append(headers, block)
+		}
+	}
+	return headers, nil
+}
+
+// Takes blocks onto its chain, and every transaction it's given once
+type fakeMempool struct {
+	services.MempoolService
+	chain *fakeChain
+
+	mu        sync.Mutex
+	entries   map[string]reps.MempoolEntry
+	added     int
+	listeners []services.TxnListener
+}
+
+func (fm *fakeMempool) ReceiveBlock(block reps.Block) (reps.ChainUpdate, error) {
+	if fm.chain.HasBlock(block.Hash) {
+		return reps.ChainUpdate{}, fmt.Errorf("%w: block %x", services.ErrKnownBlock, block.Hash)
+	}
+
+	fc := fm.chain
+	fc.mu.Lock()
+	if !bytes.Equal(fc.blocks[len(fc.blocks)-1].Hash, block.PrevHash) {
+		fc.orphans = append(fc.orphans, block)
+		fc.mu.Unlock()
+		return reps.ChainUpdate{Orphaned: true}, nil
+	}
+
+	update := reps.ChainUpdate{Connected: []reps.Block{block}}
+	fc.blocks = append(fc.blocks, block)
+	for attached := true; attached; {
+		attached = false
+		for i, orphan := range fc.orphans {
+			if bytes.Equal(fc.blocks[len(fc.blocks)-1].Hash, orphan.PrevHash) {
+				fc.blocks = append(fc.blocks, orphan)
+				fc.orphans = append(fc.orphans[:i], fc.orphans[i+1:]...)
+				update.Connected = append(update.Connected, orphan)
+				attached = true
+				break
+			}
+		}
+	}
+	fc.mu.Unlock()
+
+	fc.connected(update.Connected)
+	return update, nil
+}
+
+func (fm *fakeMempool) AddTransaction(txn reps.Transaction) (reps.MempoolEntry, error) {
+	fm.mu.Lock()
+	fm.added++
+	if _, ok := fm.entries[hex.EncodeToString(txn.ID)]; ok {
+		fm.mu.Unlock()
+		return reps.MempoolEntry{}, fmt.Errorf("transaction %x is already in the mempool", txn.ID)
+	}
+	entry := reps.MempoolEntry{TxnID: hex.EncodeToString(txn.ID), Transaction: txn}
+	fm.entries[entry.TxnID] = entry
+	listeners := fm.listeners
+	fm.mu.Unlock()
+
+	for _, listener := range listeners {
+		listener(txn)
+	}
+	return entry, nil
+}
+
+func (fm *fakeMempool) GetEntry(txnId string) (reps.MempoolEntry, bool) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	entry, ok := fm.entries[txnId]
+	return entry, ok
+}
+
+func (fm *fakeMempool) OnTransactionAccepted(listener services.TxnListener) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	fm.listeners = append(fm.listeners, listener)
+}
+
+// How many transactions the mempool was given, counting ones it already had
+func (fm *fakeMempool) timesAdded() int {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	return fm.added
+}
+
+func newTestNode(chain *fakeChain) node.Node {
+	return newTestNodeWithMempool(chain, &fakeMempool{chain: chain})
+}
+
+func newTestNodeWithMempool(chain *fakeChain, mempool *fakeMempool) node.Node {
+	if mempool.entries == nil {
+		mempool.entries = make(map[string]reps.MempoolEntry)
+	}
+	return node.NewNode(chain, mempool, &reps.ChainParams{})
+}
+
+func TestNodesSyncTheirChainsWhenTheyConnect(t *testing.T) {
+	defaultMaxHeaders := node.MaxHeaders
+	node.MaxHeaders = 2
+	defer func() { node.MaxHeaders = defaultMaxHeaders }()
+
+	genesis := reps.Block{ID: "genesis", Hash: []byte{0}}
+	ahead := newFakeChain(genesis)
+	ahead.extend(5, 'a')
+	behind := newFakeChain(genesis)
+
+	aheadNode := newTestNode(ahead)
+	defer aheadNode.Close()
+	behindNode := newTestNode(behind)
+	defer behindNode.Close()
+
+	address, err := aheadNode.Listen("127.0.0.1:0")
+	assert.NoError(t, err)
+
+	peer, err := behindNode.Connect(address)
+	assert.NoError(t, err)
+	assert.False(t, peer.Inbound)
+
+	// Two blocks are announced at a time, so it takes a few rounds
+	assert.Eventually(t, func() bool {
+		return len(behind.hashes()) == 6
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, ahead.hashes(), behind.hashes())
+
+	peers := aheadNode.GetPeers()
+	assert.Len(t, peers, 1)
+	assert.True(t, peers[0].Inbound)
+	assert.Equal(t, node.ProtocolVersion, peers[0].Version)
+	assert.Equal(t, 0, peers[0].Height)
+	assert.Equal(t, 5, behindNode.GetPeers()[0].Height)
+
+	// A node on another chain is dropped as soon as it sends its version
+	other := newFakeChain(reps.Block{ID: "other", Hash: []byte{1}})
+	otherNode := newTestNode(other)
+	defer otherNode.Close()
+
+	_, err = otherNode.Connect(address)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return len(otherNode.GetPeers()) == 0 && len(aheadNode.GetPeers()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Len(t, other.hashes(), 1)
+}
+
+func TestNodesLearnAboutPeersFromSeeds(t *testing.T) {
+	defer func() { node.SeedPeers = []string{} }()
+
+	genesis := reps.Block{ID: "genesis", Hash: []byte{0}}
+	seed := newTestNode(newFakeChain(genesis))
+	defer seed.Close()
+	listening := newTestNode(newFakeChain(genesis))
+	defer listening.Close()
+	joining := newTestNode(newFakeChain(genesis))
+	defer joining.Close()
+
+	seedAddress, err := seed.Listen("127.0.0.1:0")
+	assert.NoError(t, err)
+	_, err = listening.Listen("127.0.0.1:0")
+	assert.NoError(t, err)
+
+	// The seed learns where the listening node takes connections from its version
+	_, err = listening.Connect(seedAddress)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		peers := seed.GetPeers()
+		return len(peers) == 1 && peers[0].ListenPort > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// A node only told about the seed asks it for others, and connects to them
+	t.Setenv("SEED_PEERS", seedAddress)
+	node.StartAtStartup(joining)
+	assert.Equal(t, []string{seedAddress}, node.SeedPeers)
+
+	assert.Eventually(t, func() bool {
+		return len(joining.GetPeers()) == 2 && len(listening.GetPeers()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	for _, peer := range joining.GetPeers() {
+		assert.False(t, peer.Inbound)
+	}
+}
+
+func TestDisconnectDropsPeer(t *testing.T) {
+	genesis := reps.Block{ID: "genesis", Hash: []byte{0}}
+	listening := newTestNode(newFakeChain(genesis))
+	defer listening.Close()
+	connecting := newTestNode(newFakeChain(genesis))
+	defer connecting.Close()
+
+	address, err := listening.Listen("127.0.0.1:0")
+	assert.NoError(t, err)
+	peer, err := connecting.Connect(address)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return len(listening.GetPeers()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, connecting.Disconnect(peer.ID))
+	assert.Empty(t, connecting.GetPeers())
+	assert.Eventually(t, func() bool {
+		return len(listening.GetPeers()) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Error(t, connecting.Disconnect(peer.ID))
+}
+
+func TestBlocksAreAnnouncedAcrossTheNetwork(t *testing.T) {
+	genesis := reps.Block{ID: "genesis", Hash: []byte{0}}
+	chains := []*fakeChain{newFakeChain(genesis), newFakeChain(genesis), newFakeChain(genesis)}
+	nodes := make([]node.Node, len(chains))
+	for i, chain := range chains {
+		nodes[i] = newTestNode(chain)
+		defer nodes[i].Close()
+	}
+
+	// In a line, so the last node only hears of blocks through the one in the middle
+	for i := 1; i < len(nodes); i++ {
+		address, err := nodes[i-1].Listen("127.0.0.1:0")
+		assert.NoError(t, err)
+		_, err = nodes[i].Connect(address)
+		assert.NoError(t, err)
+	}
+	assert.Eventually(t, func() bool {
+		return len(nodes[1].GetPeers()) == 2 && nodes[1].GetPeers()[0].Version > 0 && nodes[1].GetPeers()[1].Version > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	chains[0].extend(2, 'a')
+	assert.Eventually(t, func() bool {
+		return len(chains[2].hashes()) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, chains[0].hashes(), chains[1].hashes())
+	assert.Equal(t, chains[0].hashes(), chains[2].hashes())
+
+	// Blocks mined at the other end make it back the other way
+	chains[2].extend(1, 'c')
+	assert.Eventually(t, func() bool {
+		return len(chains[0].hashes()) == 4
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, chains[2].hashes(), chains[0].hashes())
+}
+
+func TestTransactionsAreRelayedOnceAroundTheNetwork(t *testing.T) {
+	genesis := reps.Block{ID: "genesis", Hash: []byte{0}}
+	mempools := make([]*fakeMempool, 3)
+	nodes := make([]node.Node, 3)
+	addresses := make([]string, 3)
+	for i := range nodes {
+		chain := newFakeChain(genesis)
+		mempools[i] = &fakeMempool{chain: chain}
+		nodes[i] = newTestNodeWithMempool(chain, mempools[i])
+		defer nodes[i].Close()
+
+		address, err := nodes[i].Listen("127.0.0.1:0")
+		assert.NoError(t, err)
+		addresses[i] = address
+	}
+
+	// In a triangle, so each node hears of a transaction from both of the others
+	for i := range nodes {
+		_, err := nodes[i].Connect(addresses[(i+1)%len(nodes)])
+		assert.NoError(t, err)
+	}
+	assert.Eventually(t, func() bool {
+		for _, n := range nodes {
+			peers := n.GetPeers()
+			if len(peers) != 2 || peers[0].Version == 0 || peers[1].Version == 0 {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Submitted to one node, it reaches the others, and none of them takes it twice
+	txn := reps.Transaction{ID: []byte("txn")}
+	_, err := mempools[0].AddTransaction(txn)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		_, first := mempools[1].GetEntry(hex.EncodeToString(txn.ID))
+		_, second := mempools[2].GetEntry(hex.EncodeToString(txn.ID))
+		return first && second
+	}, 5*time.Second, 10*time.Millisecond)
+
+	time.Sleep(100 * time.Millisecond)
+	for _, mempool := range mempools {
+		assert.Equal(t, 1, mempool.timesAdded())
+	}
+}
+
+// Takes connections like a node with a chain up to height would, and answers each message it's sent with the command
+// and payload reply gives, or nothing if the command is empty
+func listenRaw(t *testing.T, genesis reps.Block, height int, reply func(msg reps.PeerMessage) (string, interface{})) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	send := func(conn net.Conn, command string, payload interface{}) {
+		data, _ := json.Marshal(payload)
+		line, _ := json.Marshal(reps.PeerMessage{Command: command, Payload: data})
+		conn.Write(append(line, '\n'))
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			send(conn, node.CmdVersion, reps.VersionMessage{Version: node.ProtocolVersion, GenesisHash: genesis.Hash, Height: height, Nonce: 1})
+
+			go func() {
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					var msg reps.PeerMessage
+					json.Unmarshal(scanner.Bytes(), &msg)
+					if command, payload := reply(msg); command != "" {
+						send(conn, command, payload)
+					}
+				}
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func TestNodeBehindSyncsHeadersThenBlocksFromEveryPeer(t *testing.T) {
+	defaultInFlight, defaultMaxHeaders, defaultStallTimeout := node.MaxBlocksInFlight, node.MaxHeaders, node.SyncStallTimeout
+	node.MaxBlocksInFlight, node.MaxHeaders, node.SyncStallTimeout = 2, 4, 200*time.Millisecond
+	defer func() {
+		node.MaxBlocksInFlight, node.MaxHeaders, node.SyncStallTimeout = defaultInFlight, defaultMaxHeaders, defaultStallTimeout
+	}()
+
+	genesis := reps.Block{ID: "genesis", Hash: []byte{0}}
+	ahead := newFakeChain(genesis)
+	ahead.extend(10, 'a')
+	alsoAhead := newFakeChain(genesis)
+	alsoAhead.extend(9, 'a')
+	behind := newFakeChain(genesis)
+
+	aheadNode := newTestNode(ahead)
+	defer aheadNode.Close()
+	alsoAheadNode := newTestNode(alsoAhead)
+	defer alsoAheadNode.Close()
+	behindNode := newTestNode(behind)
+	defer behindNode.Close()
+	aheadAddress, err := aheadNode.Listen("127.0.0.1:0")
+	assert.NoError(t, err)
+	alsoAheadAddress, err := alsoAheadNode.Listen("127.0.0.1:0")
+	assert.NoError(t, err)
+
+	// The peer furthest ahead is synced from first
+	stalling, err := behindNode.Connect(listenRaw(t, genesis, 100, func(reps.PeerMessage) (string, interface{}) {
+		return "", nil
+	}))
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return behindNode.GetSyncStatus().Syncing
+	}, 5*time.Second, 10*time.Millisecond)
+
+	status := behindNode.GetSyncStatus()
+	assert.Equal(t, stalling.ID, status.SyncPeer)
+	assert.Equal(t, 0, status.Height)
+	assert.Equal(t, 100, status.TargetHeight)
+	assert.InDelta(t, 100.0/101, status.Progress, 0.001)
+
+	// Once it stalls, it's dropped, and the node catches up with the next furthest ahead, downloading blocks from
+	// the peer that's a block behind it too
+	_, err = behindNode.Connect(aheadAddress)
+	assert.NoError(t, err)
+	_, err = behindNode.Connect(alsoAheadAddress)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return len(behind.hashes()) == 11 && !behindNode.GetSyncStatus().Syncing
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, ahead.hashes(), behind.hashes())
+	assert.Greater(t, alsoAhead.timesFetched(), 0)
+
+	status = behindNode.GetSyncStatus()
+	assert.Equal(t, reps.SyncStatus{Height: 10, TargetHeight: 10, Progress: 100}, status)
+	peers := behindNode.GetPeers()
+	assert.Len(t, peers, 2)
+	for _, peer := range peers {
+		assert.NotEqual(t, stalling.ID, peer.ID)
+	}
+}
+
+func TestPeerSendingHeadersThatDontConnectIsDropped(t *testing.T) {
+	genesis := reps.Block{ID: "genesis", Hash: []byte{0}}
+	behind := newFakeChain(genesis)
+	behindNode := newTestNode(behind)
+	defer behindNode.Close()
+
+	_, err := behindNode.Connect(listenRaw(t, genesis, 100, func(msg reps.PeerMessage) (string, interface{}) {
+		if msg.Command != node.CmdGetHeaders {
+			return "", nil
+		}
+		junk := reps.Block{ID: "junk", Height: 1, PrevHash: []byte{'x', 0}, Hash: []byte{'x', 1}, MerkleRoot: []byte{'x', 1}}
+		return node.CmdHeaders, reps.HeadersMessage{Headers: []reps.Block{junk}}
+	}))
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return len(behindNode.GetPeers()) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.False(t, behindNode.GetSyncStatus().Syncing)
+	assert.Equal(t, [][]byte{genesis.Hash}, behind.hashes())
+}
+
+func TestPeerSendingBlockWithoutTransactionsIsDropped(t *testing.T) {
+	genesis := reps.Block{ID: "genesis", Hash: []byte{0}}
+	chain := newFakeChain(genesis)
+	testNode := newTestNode(chain)
+	defer testNode.Close()
+
+	_, err := testNode.Connect(listenRaw(t, genesis, 1, func(msg reps.PeerMessage) (string, interface{}) {
+		if msg.Command != node.CmdVersion {
+			return "", nil
+		}
+		empty := reps.Block{ID: "empty", Height: 1, PrevHash: genesis.Hash, Hash: []byte{'x', 1}}
+		return node.CmdBlock, empty
+	}))
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return len(testNode.GetPeers()) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, [][]byte{genesis.Hash}, chain.hashes())
+
+	// The node's still up for other peers
+	other := newTestNode(newFakeChain(genesis))
+	defer other.Close()
+	address, err := other.Listen("127.0.0.1:0")
+	assert.NoError(t, err)
+	_, err = testNode.Connect(address)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return len(testNode.GetPeers()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestPeerSendingHeaderWithoutMerkleRootIsDropped(t *testing.T) {
+	genesis := reps.Block{ID: "genesis", Hash: []byte{0}}
+	behind := newFakeChain(genesis)
+	behindNode := newTestNode(behind)
+	defer behindNode.Close()
+
+	_, err := behindNode.Connect(listenRaw(t, genesis, 100, func(msg reps.PeerMessage) (string, interface{}) {
+		if msg.Command != node.CmdGetHeaders {
+			return "", nil
+		}
+		header := reps.Block{ID: "header", Height: 1, PrevHash: genesis.Hash, Hash: []byte{'x', 1}}
+		return node.CmdHeaders, reps.HeadersMessage{Headers: []reps.Block{header}}
+	}))
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return len(behindNode.GetPeers()) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.False(t, behindNode.GetSyncStatus().Syncing)
+	assert.Equal(t, [][]byte{genesis.Hash}, behind.hashes())
+
+	// The node's still up for other peers
+	ahead := newFakeChain(genesis)
+	ahead.extend(2, 'a')
+	aheadNode := newTestNode(ahead)
+	defer aheadNode.Close()
+	address, err := aheadNode.Listen("127.0.0.1:0")
+	assert.NoError(t, err)
+	_, err = behindNode.Connect(address)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return len(behind.hashes()) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+}
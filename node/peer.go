@@ -0,0 +1,156 @@
+package node
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"sync"
+	"time"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/google/uuid"
+)
+
+// Connection to another node, and what it told us about its chain
+type peer struct {
+	id          string
+	conn        net.Conn
+	inbound     bool
+	connectedAt int64
+
+	writeMu sync.Mutex // Messages are written whole, one at a time
+
+	mu         sync.Mutex
+	address    string               // Where the peer takes connections. Empty for inbound peers that don't
+	version    *reps.VersionMessage // Nil until the peer sends it
+	height     int                  // Of the best block the peer is known to have
+	caughtUpAt int                  // Height the peer was at when the node last caught up with it, -1 if it hasn't
+	lastSeen   int64
+	known      map[string]bool // Hex hashes of blocks and transactions the peer has, so they aren't announced to it
+	knownOrder []string        // Hex hashes in known, oldest first
+}
+
+func newPeer(conn net.Conn, inbound bool, address string) *peer {
+	now := time.Now().UnixMilli()
+	return &peer{
+		id:          uuid.Must(uuid.NewRandom()).String(),
+		conn:        conn,
+		inbound:     inbound,
+		address:     address,
+		connectedAt: now,
+		lastSeen:    now,
+		caughtUpAt:  -1,
+		known:       make(map[string]bool),
+	}
+}
+
+// Send the peer a message with command and payload
+func (p *peer) send(command string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(reps.PeerMessage{Command: command, Payload: data})
+	if err != nil {
+		return err
+	}
+
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+
+	if err := p.conn.SetWriteDeadline(time.Now().Add(WriteTimeout)); err != nil {
+		return err
+	}
+	_, err = p.conn.Write(append(line, '\n'))
+	return err
+}
+
+// Whether the peer sent its version, so it can be sent anything else
+func (p *peer) ready() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.version != nil
+}
+
+func (p *peer) touch() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastSeen = time.Now().UnixMilli()
+}
+
+// Address the peer takes connections on: the one dialed to connect to it, or for a peer that connected to this node,
+// its host and the port it said it listens on
+func (p *peer) reachableOn() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.address
+}
+
+// Record that the peer has the block or transaction with hash, because it sent or announced it, or was sent it.
+// False if that was known already. Past MaxKnownInventory, the oldest are forgotten
+func (p *peer) addKnown(hash []byte) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := hex.EncodeToString(hash)
+	if p.known[key] {
+		return false
+	}
+	p.known[key] = true
+	p.knownOrder = append(p.knownOrder, key)
+	for len(p.knownOrder) > MaxKnownInventory {
+		delete(p.known, p.knownOrder[0])
+		p.knownOrder = p.knownOrder[1:]
+	}
+
+	return true
+}
+
+func (p *peer) bestHeight() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.height
+}
+
+// Record that the peer has a block at height
+func (p *peer) sawHeight(height int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if height > p.height {
+		p.height = height
+	}
+}
+
+// Record that the node caught up with the peer, so it isn't synced from again until it has blocks past where it is now
+func (p *peer) caughtUp() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.caughtUpAt = p.height
+}
+
+// Whether the peer got further since the node last caught up with it
+func (p *peer) aheadOfSync() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.height > p.caughtUpAt
+}
+
+func (p *peer) info() reps.Peer {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	info := reps.Peer{
+		ID:          p.id,
+		Address:     p.conn.RemoteAddr().String(),
+		Inbound:     p.inbound,
+		Height:      p.height,
+		ConnectedAt: p.connectedAt,
+		LastSeen:    p.lastSeen,
+	}
+	if p.version != nil {
+		info.Version = p.version.Version
+		info.ListenPort = p.version.ListenPort
+	}
+
+	return info
+}
@@ -0,0 +1,56 @@
+package node
+
+import (
+	"encoding/hex"
+	"sync"
+
+	reps "github.com/brucetieu/blockchain/representations"
+)
+
+var MaxSeenTxns = 10000 // Most transaction hashes remembered as seen. Past that, the oldest are forgotten
+
+// Hashes of transactions the node was sent or let into its mempool lately, whether it took them or not, so ones
+// going around the network aren't fetched and checked again each time another peer announces them
+type seenCache struct {
+	mu    sync.Mutex
+	seen  map[string]bool // By hex hash
+	order []string        // Hex hashes, oldest first
+}
+
+func newSeenCache() *seenCache {
+	return &seenCache{
+		seen: make(map[string]bool),
+	}
+}
+
+// Record the transaction with hash as seen. False if it was already
+func (sc *seenCache) add(hash []byte) bool {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	key := hex.EncodeToString(hash)
+	if sc.seen[key] {
+		return false
+	}
+	sc.seen[key] = true
+	sc.order = append(sc.order, key)
+	for len(sc.order) > MaxSeenTxns {
+		delete(sc.seen, sc.order[0])
+		sc.order = sc.order[1:]
+	}
+
+	return true
+}
+
+func (sc *seenCache) has(hash []byte) bool {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.seen[hex.EncodeToString(hash)]
+}
+
+// Relay a transaction let into the mempool to the peers that don't have it yet, in the background, since it's called
+// while the mempool is locked
+func (n *node) relayTransaction(txn reps.Transaction) {
+	n.seenTxns.add(txn.ID)
+	go n.announce(InvTx, txn.ID)
+}
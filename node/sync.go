@@ -0,0 +1,457 @@
+package node
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"time"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	MaxHeaders          = services.MaxHeadersPerPage // Most headers in a headers message
+	BlockDownloadWindow = 64                         // How far past the first block not in yet blocks are asked for. Under MaxOrphanBlocks, so ones that come in early are held
+	MaxBlocksInFlight   = 16                         // Most blocks asked of one peer at once while syncing
+	SyncStallTimeout    = 30 * time.Second           // How long a peer has to send the headers or blocks it was asked for while syncing before it's dropped
+)
+
+// Where the node is in catching up with the peers ahead of it. It downloads the headers of the chain of the one
+// furthest ahead first, which are small and checked on their own, and once it knows that chain has more work than its
+// own, downloads the blocks on it from every peer that has them, a window at a time
+type syncState struct {
+	peer         *peer                    // Headers are downloaded from. Nil while the node is caught up
+	headers      []reps.Block             // Of the blocks on the peer's chain after the last one it shares with this node's, lowest first
+	index        map[string]int           // Position of each header in headers, by hex hash
+	have         []bool                   // Whether each header's block is in, on the chain, a side branch or held as an orphan
+	next         int                      // Position of the first header whose block isn't in. Everything before it is on the chain or a side branch
+	downloading  bool                     // Whether the headers are all in, so the blocks are being downloaded
+	requested    map[string]*blockRequest // Blocks asked for that aren't in yet, by hex hash
+	notFound     map[*peer]bool           // Peers that turned out not to have blocks they were asked for, e.g. because they're on another branch
+	startHeight  int
+	startedAt    int64
+	headerCount  int
+	downloaded   int
+	lastProgress time.Time // When the peer last sent headers, or was asked for more
+}
+
+type blockRequest struct {
+	peer *peer
+	at   time.Time
+}
+
+// Start syncing from the peer with the most blocks, if any is further ahead of this node than it was the last time
+// the node caught up with it, and the node isn't syncing already
+func (n *node) maybeSync() {
+	lastBlock, err := n.blockchainService.GetLastBlock()
+	height := -1
+	if err == nil {
+		height = lastBlock.Height
+	}
+
+	n.syncMu.Lock()
+	if n.sync.peer != nil {
+		n.syncMu.Unlock()
+		return
+	}
+
+	var best *peer
+	for _, p := range n.readyPeers() {
+		if p.bestHeight() > height && p.aheadOfSync() && (best == nil || p.bestHeight() > best.bestHeight()) {
+			best = p
+		}
+	}
+	if best == nil {
+		n.syncMu.Unlock()
+		return
+	}
+
+	n.sync = syncState{
+		peer:         best,
+		index:        make(map[string]int),
+		requested:    make(map[string]*blockRequest),
+		notFound:     make(map[*peer]bool),
+		startHeight:  height,
+		startedAt:    time.Now().UnixMilli(),
+		lastProgress: time.Now(),
+	}
+	n.syncMu.Unlock()
+	log.WithFields(log.Fields{"peer": best.id, "height": height, "peerHeight": best.bestHeight()}).Info("Syncing from peer")
+
+	if err := n.requestHeaders(best, nil); err != nil {
+		best.conn.Close()
+	}
+}
+
+func (n *node) isSyncing() bool {
+	n.syncMu.Lock()
+	defer n.syncMu.Unlock()
+	return n.sync.peer != nil
+}
+
+// Ask the peer for the headers after the last block this node shares with it, or after the header with hash after,
+// if it isn't nil
+func (n *node) requestHeaders(p *peer, after []byte) error {
+	locator := n.blockLocator()
+	if after != nil {
+		locator = append([][]byte{after}, locator...)
+	}
+	return p.send(CmdGetHeaders, reps.GetHeadersMessage{Locator: locator})
+}
+
+// Send the headers of the blocks after the first one in the locator on this node's chain, up to MaxHeaders of them.
+// With none after it, there are none in the headers, so the peer knows it's caught up
+func (n *node) handleGetHeaders(p *peer, getHeaders reps.GetHeadersMessage) error {
+	headers, err := n.blockchainService.GetBlockHeaders(n.locate(getHeaders.Locator), MaxHeaders)
+	if err != nil {
+		headers = []reps.Block{}
+	}
+	return p.send(CmdHeaders, reps.HeadersMessage{Headers: headers})
+}
+
+// Check the headers from the peer synced from follow on from the last ones it sent, or from a block on this node's
+// chain, and add them. Peers sending headers that don't are dropped. Past a full message, the peer's asked for more.
+// Once they're all in, the blocks are downloaded if the peer's chain has more work than this node's, or else the node
+// has caught up with it
+func (n *node) handleHeaders(p *peer, headers reps.HeadersMessage) error {
+	if len(headers.Headers) > MaxHeaders {
+		return fmt.Errorf("sent %d headers, more than the %d there can be", len(headers.Headers), MaxHeaders)
+	}
+
+	n.syncMu.Lock()
+	if n.sync.peer != p || n.sync.downloading {
+		n.syncMu.Unlock()
+		return nil
+	}
+	count := len(n.sync.headers)
+	var prev *reps.Block
+	if count > 0 {
+		last := n.sync.headers[count-1]
+		prev = &last
+	}
+	n.syncMu.Unlock()
+
+	for _, header := range headers.Headers {
+		if err := services.CheckHeaderContents(header); err != nil {
+			return err
+		}
+		if prev == nil {
+			parent, err := n.blockchainService.GetBlockByHash(header.PrevHash)
+			if err != nil {
+				return fmt.Errorf("sent header %x building on a block that isn't on this node's chain", header.Hash)
+			}
+			prev = &parent
+		}
+		if !bytes.Equal(header.PrevHash, prev.Hash) || header.Height != prev.Height+1 {
+			return fmt.Errorf("sent header %x that doesn't follow on from %x", header.Hash, prev.Hash)
+		}
+		if err := n.blockchainService.CheckHeader(header); err != nil {
+			return err
+		}
+		p.addKnown(header.Hash)
+		p.sawHeight(header.Height)
+		header := header
+		prev = &header
+	}
+
+	n.syncMu.Lock()
+	if n.sync.peer != p || len(n.sync.headers) != count {
+		n.syncMu.Unlock()
+		return nil
+	}
+	for _, header := range headers.Headers {
+		n.sync.index[hex.EncodeToString(header.Hash)] = len(n.sync.headers)
+		n.sync.headers = append(n.sync.headers, header)
+		n.sync.have = append(n.sync.have, n.blockchainService.HasBlock(header.Hash))
+	}
+	n.sync.headerCount += len(headers.Headers)
+	n.sync.lastProgress = time.Now()
+	all := n.sync.headers
+	n.syncMu.Unlock()
+
+	if len(headers.Headers) == MaxHeaders {
+		return n.requestHeaders(p, headers.Headers[len(headers.Headers)-1].Hash)
+	}
+	if len(all) == 0 {
+		n.finishSync(p)
+		return nil
+	}
+
+	// Blocks are only downloaded for a chain the node would switch to, so a peer can't waste its bandwidth on others
+	fork, err := n.blockchainService.GetBlockByHash(all[0].PrevHash)
+	if err != nil {
+		return err
+	}
+	lastBlock, err := n.blockchainService.GetLastBlock()
+	if err != nil {
+		return err
+	}
+	ours := new(big.Int).Sub(services.ChainWork(lastBlock), services.ChainWork(fork))
+	theirs := new(big.Int)
+	for _, header := range all {
+		theirs.Add(theirs, services.BlockWork(header))
+	}
+	if theirs.Cmp(ours) <= 0 {
+		log.WithFields(log.Fields{"peer": p.id, "headers": len(all)}).Info("Peer's chain has no more work than this node's")
+		n.finishSync(p)
+		return nil
+	}
+
+	n.syncMu.Lock()
+	if n.sync.peer == p {
+		n.sync.downloading = true
+		for n.sync.next < len(n.sync.headers) && n.sync.have[n.sync.next] {
+			n.sync.next++
+		}
+	}
+	n.syncMu.Unlock()
+	log.WithFields(log.Fields{"peer": p.id, "headers": len(all), "height": all[len(all)-1].Height}).Info("Downloading blocks on peer's chain")
+
+	n.requestBlocks()
+	return nil
+}
+
+// Ask peers for the blocks in the download window that aren't in or asked for yet, each of the peer with the fewest
+// in flight out of the ones that may have it. Once they're all in, the peer synced from is asked for the headers after
+// them
+func (n *node) requestBlocks() {
+	peers := n.readyPeers()
+
+	n.syncMu.Lock()
+	if n.sync.peer == nil || !n.sync.downloading {
+		n.syncMu.Unlock()
+		return
+	}
+
+	if n.sync.next == len(n.sync.headers) {
+		p := n.sync.peer
+		last := n.sync.headers[len(n.sync.headers)-1].Hash
+		n.sync.headers = nil
+		n.sync.index = make(map[string]int)
+		n.sync.have = nil
+		n.sync.next = 0
+		n.sync.downloading = false
+		n.sync.lastProgress = time.Now()
+		n.syncMu.Unlock()
+
+		if err := n.requestHeaders(p, last); err != nil {
+			p.conn.Close()
+		}
+		return
+	}
+
+	inFlight := make(map[*peer]int)
+	for _, request := range n.sync.requested {
+		inFlight[request.peer]++
+	}
+	getData := make(map[*peer][][]byte)
+	end := n.sync.next + BlockDownloadWindow
+	if end > len(n.sync.headers) {
+		end = len(n.sync.headers)
+	}
+	for i := n.sync.next; i < end; i++ {
+		header := n.sync.headers[i]
+		key := hex.EncodeToString(header.Hash)
+		if n.sync.have[i] || n.sync.requested[key] != nil {
+			continue
+		}
+
+		var pick *peer
+		for _, p := range peers {
+			if p.bestHeight() < header.Height || n.sync.notFound[p] || inFlight[p] >= MaxBlocksInFlight {
+				continue
+			}
+			if pick == nil || inFlight[p] < inFlight[pick] {
+				pick = p
+			}
+		}
+		if pick == nil {
+			continue
+		}
+		n.sync.requested[key] = &blockRequest{peer: pick, at: time.Now()}
+		inFlight[pick]++
+		getData[pick] = append(getData[pick], header.Hash)
+	}
+	n.syncMu.Unlock()
+
+	for p, hashes := range getData {
+		if err := p.send(CmdGetData, reps.InvMessage{Type: InvBlock, Hashes: hashes}); err != nil {
+			p.conn.Close()
+		}
+	}
+}
+
+// Whether the block with hash was asked of p while syncing
+func (n *node) requestedFrom(p *peer, hash []byte) bool {
+	n.syncMu.Lock()
+	defer n.syncMu.Unlock()
+	request := n.sync.requested[hex.EncodeToString(hash)]
+	return request != nil && request.peer == p
+}
+
+// Record a block on the chain being synced as in, and ask for more
+func (n *node) syncedBlock(block reps.Block) {
+	n.syncMu.Lock()
+	key := hex.EncodeToString(block.Hash)
+	delete(n.sync.requested, key)
+	i, ok := n.sync.index[key]
+	if !ok || n.sync.have[i] {
+		n.syncMu.Unlock()
+		return
+	}
+	n.sync.have[i] = true
+	n.sync.downloaded++
+	for n.sync.next < len(n.sync.headers) && n.sync.have[n.sync.next] {
+		n.sync.next++
+	}
+	n.syncMu.Unlock()
+
+	n.requestBlocks()
+}
+
+// The peer doesn't have blocks it was asked for, so they're asked of others instead. The peer synced from sent their
+// headers, so it has to have them
+func (n *node) handleNotFound(p *peer, notFound reps.InvMessage) error {
+	if len(notFound.Hashes) > MaxInvHashes {
+		return fmt.Errorf("sent %d hashes it doesn't have, more than the %d there can be", len(notFound.Hashes), MaxInvHashes)
+	}
+	if notFound.Type != InvBlock {
+		return nil
+	}
+
+	n.syncMu.Lock()
+	if n.sync.peer == p {
+		n.syncMu.Unlock()
+		return fmt.Errorf("doesn't have blocks on the chain it sent the headers of")
+	}
+	for _, hash := range notFound.Hashes {
+		key := hex.EncodeToString(hash)
+		if request := n.sync.requested[key]; request != nil && request.peer == p {
+			delete(n.sync.requested, key)
+			n.sync.notFound[p] = true
+		}
+	}
+	n.syncMu.Unlock()
+
+	n.requestBlocks()
+	return nil
+}
+
+// Done syncing from the peer. It isn't synced from again until it gets further ahead
+func (n *node) finishSync(p *peer) {
+	n.syncMu.Lock()
+	if n.sync.peer != p {
+		n.syncMu.Unlock()
+		return
+	}
+	log.WithFields(log.Fields{"peer": p.id, "headers": n.sync.headerCount, "blocks": n.sync.downloaded}).Info("Caught up with peer")
+	n.sync = syncState{}
+	n.syncMu.Unlock()
+	p.caughtUp()
+
+	// Peers that fell behind while this node was syncing find out how far it got, and other peers may be further on
+	if lastBlock, err := n.blockchainService.GetLastBlock(); err == nil {
+		go n.announce(InvBlock, lastBlock.Hash)
+	}
+	go n.maybeSync()
+}
+
+// Give up on the peer, e.g. because it disconnected. If it's the one synced from, another is picked, and otherwise
+// the blocks asked of it are asked of others
+func (n *node) stopSyncingFrom(p *peer) {
+	n.syncMu.Lock()
+	if n.sync.peer == p {
+		n.sync = syncState{}
+		n.syncMu.Unlock()
+		go n.maybeSync()
+		return
+	}
+	for key, request := range n.sync.requested {
+		if request.peer == p {
+			delete(n.sync.requested, key)
+		}
+	}
+	delete(n.sync.notFound, p)
+	n.syncMu.Unlock()
+
+	go n.requestBlocks()
+}
+
+// Drop peers that go stallTimeout without sending the headers or blocks they were asked for while syncing, until the
+// node is shut down
+func (n *node) watchSync(stallTimeout time.Duration) {
+	ticker := time.NewTicker(stallTimeout / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-n.done:
+			return
+		case <-ticker.C:
+		}
+
+		stalled := make(map[*peer]bool)
+		n.syncMu.Lock()
+		if n.sync.peer != nil && !n.sync.downloading && time.Since(n.sync.lastProgress) >= stallTimeout {
+			stalled[n.sync.peer] = true
+		}
+		for _, request := range n.sync.requested {
+			if time.Since(request.at) >= stallTimeout {
+				stalled[request.peer] = true
+			}
+		}
+		n.syncMu.Unlock()
+
+		for p := range stalled {
+			log.WithField("peer", p.id).Warn("Dropping peer that stalled the sync")
+			p.conn.Close()
+		}
+	}
+}
+
+// Where the node is in catching up with its peers
+func (n *node) GetSyncStatus() reps.SyncStatus {
+	status := reps.SyncStatus{Height: -1}
+	if lastBlock, err := n.blockchainService.GetLastBlock(); err == nil {
+		status.Height = lastBlock.Height
+	}
+
+	status.TargetHeight = status.Height
+	for _, p := range n.readyPeers() {
+		if p.bestHeight() > status.TargetHeight {
+			status.TargetHeight = p.bestHeight()
+		}
+	}
+
+	status.Progress = 100
+	if status.TargetHeight > 0 && status.Height < status.TargetHeight {
+		status.Progress = float64(status.Height+1) / float64(status.TargetHeight+1) * 100
+	}
+
+	n.syncMu.Lock()
+	defer n.syncMu.Unlock()
+	if n.sync.peer != nil {
+		status.Syncing = true
+		status.SyncPeer = n.sync.peer.id
+		status.StartHeight = n.sync.startHeight
+		status.StartedAt = n.sync.startedAt
+		status.HeadersDownloaded = n.sync.headerCount
+		status.BlocksDownloaded = n.sync.downloaded
+		for _, have := range n.sync.have {
+			if !have {
+				status.PendingBlocks++
+			}
+		}
+		downloadPeers := make(map[*peer]bool)
+		for _, request := range n.sync.requested {
+			downloadPeers[request.peer] = true
+		}
+		status.DownloadPeers = len(downloadPeers)
+	}
+
+	return status
+}
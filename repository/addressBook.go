@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"github.com/brucetieu/blockchain/db"
+	"github.com/jinzhu/gorm"
+
+	reps "github.com/brucetieu/blockchain/representations"
+)
+
+type AddressBookRepository interface {
+	CreateEntry(entry reps.AddressBookEntry) error
+	UpdateEntry(entry reps.AddressBookEntry) error
+	GetEntry(name string) (reps.AddressBookEntry, error)
+	GetEntries() ([]reps.AddressBookEntry, error)
+	DeleteEntry(name string) error
+}
+
+type addressBookRepository struct{}
+
+func NewAddressBookRepository() AddressBookRepository {
+	return &addressBookRepository{}
+}
+
+func (repo *addressBookRepository) CreateEntry(entry reps.AddressBookEntry) error {
+	if err := db.DB.Create(&entry).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Update every field of an address book entry
+func (repo *addressBookRepository) UpdateEntry(entry reps.AddressBookEntry) error {
+	if err := db.DB.Save(&entry).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Get address book entry by name
+func (repo *addressBookRepository) GetEntry(name string) (reps.AddressBookEntry, error) {
+	var entry reps.AddressBookEntry
+
+	err := db.DB.
+		Where("name = ?", name).
+		First(&entry).
+		Error
+	if err != nil {
+		return reps.AddressBookEntry{}, err
+	}
+
+	return entry, nil
+}
+
+// Get every address book entry, ordered by name
+func (repo *addressBookRepository) GetEntries() ([]reps.AddressBookEntry, error) {
+	var entries []reps.AddressBookEntry
+
+	err := db.DB.Order("name").Find(&entries).Error
+	if err != nil {
+		return []reps.AddressBookEntry{}, err
+	}
+
+	return entries, nil
+}
+
+func (repo *addressBookRepository) DeleteEntry(name string) error {
+	result := db.DB.Where("name = ?", name).Delete(&reps.AddressBookEntry{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -1,7 +1,10 @@
 package repository
 
 import (
+	"encoding/hex"
+
 	"github.com/brucetieu/blockchain/db"
+	"github.com/jinzhu/gorm"
 
 	reps "github.com/brucetieu/blockchain/representations"
 )
@@ -18,7 +21,31 @@ type BlockchainRepository interface {
 	GetGenesisBlock() (reps.Block, error)
 	GetBlockchain() ([]reps.Block, error)
 	GetLastBlock() (reps.Block, error)
+	CountBlocks() (int, error)
 	GetBlockById(blockId string) (reps.Block, error)
+	GetBlockByHash(hash []byte) (reps.Block, error)
+	GetBlockByHeight(height int) (reps.Block, error)
+	GetBlocksByHeight(from int, count int) ([]reps.Block, error)
+	GetBlockHeader(blockId string) (reps.Block, error)
+	GetBlockHeadersByHeight(from int, count int) ([]reps.Block, error)
+	SetBlockHeights(heights map[string]int) error
+	SetBlockChainWork(chainWork map[string]string) error
+	DeleteBlocksFrom(height int) error
+
+	GetUnspentOutputs(pubKeyHash []byte) ([]reps.UnspentOutput, error)
+	GetUnspentAssetOutputs(assetId string) ([]reps.UnspentOutput, error)
+	GetValidators() ([]reps.Validator, error)
+	GetUnspentOutput(txnId []byte, outIdx int) (reps.UnspentOutput, error)
+	CountUnspentOutputs() (int, error)
+	ReplaceUnspentOutputs(unspentOutputs []reps.UnspentOutput) error
+
+	GetTxnLocation(txnId []byte) (reps.TxnLocation, error)
+	CountTxnLocations() (int, error)
+	ReplaceTxnLocations(locations []reps.TxnLocation) error
+
+	GetAddressIndex(pubKeyHash []byte) ([]reps.AddressIndexEntry, error)
+	CountAddressIndex() (int, error)
+	ReplaceAddressIndex(entries []reps.AddressIndexEntry) error
 
 	CreateTxnOutput(txnOutput reps.TxnOutput) error
 	CreateTxnInput(txnInput reps.TxnInput) error
@@ -26,8 +53,60 @@ type BlockchainRepository interface {
 	// GetTxnOutputs(txnId []byte) ([]reps.TxnOutput, error)
 
 	CreateWallet(wallet reps.Wallet) error
+	UpdateWallet(wallet reps.Wallet) error
 	GetWallet(address string) (reps.Wallet, error)
 	GetWallets() ([]reps.Wallet, error)
+	GetWalletsByHDWalletId(hdWalletId string) ([]reps.Wallet, error)
+	GetWalletsByAccountId(accountId string) ([]reps.Wallet, error)
+
+	CreateAccount(account reps.Account) error
+	UpdateAccount(account reps.Account) error
+	GetAccount(name string) (reps.Account, error)
+	GetAccounts() ([]reps.Account, error)
+
+	CreateHDWallet(hdWallet reps.HDWallet) error
+	UpdateHDWallet(hdWallet reps.HDWallet) error
+	GetHDWallet(hdWalletId string) (reps.HDWallet, error)
+	GetHDWalletByFingerprint(fingerprint string) (reps.HDWallet, error)
+
+	CreateMultisigAddress(multisigAddress reps.MultisigAddress) error
+	GetMultisigAddress(address string) (reps.MultisigAddress, error)
+	CreateMultisigTransaction(multisigTxn reps.MultisigTransaction) error
+	UpdateMultisigTransaction(multisigTxn reps.MultisigTransaction) error
+	GetMultisigTransaction(txnId string) (reps.MultisigTransaction, error)
+
+	CreateChainParams(params reps.ChainParams) error
+	GetChainParams() (reps.ChainParams, error)
+
+	CreateAsset(asset reps.Asset) error
+	GetAsset(assetId string) (reps.Asset, error)
+	GetAssets() ([]reps.Asset, error)
+
+	CreateStaleBlock(staleBlock reps.StaleBlock) error
+	GetStaleBlocks(count int) ([]reps.StaleBlock, error)
+	CountStaleBlocks() (int, error)
+
+	CreateSideBlock(sideBlock reps.SideBlock) error
+	GetSideBlock(hash string) (reps.SideBlock, error)
+	GetSideBlocks() ([]reps.SideBlock, error)
+	DeleteSideBlock(hash string) error
+
+	CreateBlockVote(vote reps.BlockVote) error
+	GetBlockVotes(blockId string) ([]reps.BlockVote, error)
+	GetValidatorVote(height int, validator string) (reps.BlockVote, error)
+	FinalizeBlock(blockId string) error
+	GetLastFinalizedBlock() (reps.Block, error)
+
+	CreateEvidence(evidence reps.Evidence) error
+	GetEvidence(id string) (reps.Evidence, error)
+	GetAllEvidence() ([]reps.Evidence, error)
+
+	LoadSnapshot(base reps.SnapshotBase, params reps.ChainParams, headers []reps.Block, unspentOutputs []reps.UnspentOutput,
+		validatorChanges []reps.ValidatorChangeRecord) error
+	GetSnapshotBase() (reps.SnapshotBase, error)
+	GetAllUnspentOutputs() ([]reps.UnspentOutput, error)
+
+	GetValidatorChanges() ([]reps.ValidatorChangeRecord, error)
 }
 
 type blockchainRepository struct{}
@@ -87,7 +166,7 @@ func (repo *blockchainRepository) GetLastBlock() (reps.Block, error) {
 
 	err := db.DB.
 		Limit(1).
-		Order("timestamp desc").
+		Order("height desc").
 		First(&lastBlock).
 		Error
 	if err != nil {
@@ -125,6 +204,187 @@ func (repo *blockchainRepository) GetBlockById(blockId string) (reps.Block, erro
 	return block, nil
 }
 
+// Get a block in the block chain by its hash
+func (repo *blockchainRepository) GetBlockByHash(hash []byte) (reps.Block, error) {
+	var block reps.Block
+
+	res := db.DB.
+		Where("hash = ?", hash).
+		First(&block)
+	if res.Error != nil {
+		return reps.Block{}, res.Error
+	}
+
+	txns, err := repo.GetTransactionsByBlockId(block.ID)
+	if err != nil {
+		return reps.Block{}, err
+	}
+
+	block.Transactions = txns
+
+	return block, nil
+}
+
+// Get the block at a height in the block chain
+func (repo *blockchainRepository) GetBlockByHeight(height int) (reps.Block, error) {
+	var block reps.Block
+
+	res := db.DB.
+		Where("height = ?", height).
+		First(&block)
+	if res.Error != nil {
+		return reps.Block{}, res.Error
+	}
+
+	txns, err := repo.GetTransactionsByBlockId(block.ID)
+	if err != nil {
+		return reps.Block{}, err
+	}
+
+	block.Transactions = txns
+
+	return block, nil
+}
+
+// Get up to count blocks from height from on, lowest first
+func (repo *blockchainRepository) GetBlocksByHeight(from int, count int) ([]reps.Block, error) {
+	var blocks []reps.Block
+
+	err := db.DB.
+		Where("height >= ? AND height < ?", from, from+count).
+		Order("height").
+		Find(&blocks).
+		Error
+	if err != nil {
+		return []reps.Block{}, err
+	}
+
+	for i := 0; i < len(blocks); i++ {
+		txns, err := repo.GetTransactionsByBlockId(blocks[i].ID)
+		if err != nil {
+			return []reps.Block{}, err
+		}
+
+		blocks[i].Transactions = txns
+	}
+
+	return blocks, nil
+}
+
+// Get a block without its transactions
+func (repo *blockchainRepository) GetBlockHeader(blockId string) (reps.Block, error) {
+	var block reps.Block
+
+	res := db.DB.
+		Where("block_id = ?", blockId).
+		First(&block)
+	if res.Error != nil {
+		return reps.Block{}, res.Error
+	}
+
+	return block, nil
+}
+
+// Get up to count blocks from height from on, lowest first, without their transactions
+func (repo *blockchainRepository) GetBlockHeadersByHeight(from int, count int) ([]reps.Block, error) {
+	var blocks []reps.Block
+
+	err := db.DB.
+		Where("height >= ? AND height < ?", from, from+count).
+		Order("height").
+		Find(&blocks).
+		Error
+	if err != nil {
+		return []reps.Block{}, err
+	}
+
+	return blocks, nil
+}
+
+// Set the height of each block, keyed by block id, all at once
+func (repo *blockchainRepository) SetBlockHeights(heights map[string]int) error {
+	tx := db.DB.Begin()
+	if err := tx.Error; err != nil {
+		return err
+	}
+
+	for blockId, height := range heights {
+		if err := tx.Model(&reps.Block{}).Where("block_id = ?", blockId).Update("height", height).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+// Set the chain work of each block, keyed by block id, all at once
+func (repo *blockchainRepository) SetBlockChainWork(chainWork map[string]string) error {
+	tx := db.DB.Begin()
+	if err := tx.Error; err != nil {
+		return err
+	}
+
+	for blockId, work := range chainWork {
+		if err := tx.Model(&reps.Block{}).Where("block_id = ?", blockId).Update("chain_work", work).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+// Take every block from height up off the chain, along with their transactions and the validator changes they made.
+// The UTXO set, transaction index and address index are left as they were, for the caller to rebuild
+func (repo *blockchainRepository) DeleteBlocksFrom(height int) error {
+	tx := db.DB.Begin()
+	if err := tx.Error; err != nil {
+		return err
+	}
+
+	var blockIds []string
+	if err := tx.Model(&reps.Block{}).Where("height >= ?", height).Pluck("block_id", &blockIds).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if len(blockIds) == 0 {
+		return tx.Commit().Error
+	}
+
+	var txnIds [][]byte
+	if err := tx.Model(&reps.Transaction{}).Where("block_id IN (?)", blockIds).Pluck("id", &txnIds).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if len(txnIds) > 0 {
+		if err := tx.Where("curr_txn_id IN (?)", txnIds).Delete(reps.TxnInput{}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Where("curr_txn_id IN (?)", txnIds).Delete(reps.TxnOutput{}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Where("block_id IN (?)", blockIds).Delete(reps.Transaction{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Where("mined_height >= ?", height).Delete(reps.ValidatorChangeRecord{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Where("height >= ?", height).Delete(reps.Block{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
 // Get all transactions
 func (repo *blockchainRepository) GetTransactions() ([]reps.Transaction, error) {
 	var transactions []reps.Transaction
@@ -225,73 +485,889 @@ func (repo *blockchainRepository) GetGenesisBlock() (reps.Block, error) {
 }
 
 // Save block to db
+// Save a block, and apply it to the UTXO set, transaction index, address index, staking index and validator changes in the same db transaction so they can't drift apart
 func (repo *blockchainRepository) CreateBlock(block reps.Block) error {
-	if err := db.DB.Create(&block).Error; err != nil {
+	tx := db.DB.Begin()
+	if err := tx.Error; err != nil {
 		return err
 	}
 
-	return nil
+	height := block.Height
+	if err := tx.Create(&block).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for position, txn := range block.Transactions {
+		// Kept to tell the address index who the inputs spend from
+		prevOutputs := make(map[string]reps.UnspentOutput)
+		for _, input := range txn.Inputs {
+			// Coinbase inputs don't spend anything
+			if len(input.PrevTxnID) == 0 {
+				continue
+			}
+
+			var prevOutput reps.UnspentOutput
+			outpointId := reps.OutpointID(input.PrevTxnID, input.OutIdx)
+			if err := tx.Where("id = ?", outpointId).First(&prevOutput).Error; err == nil {
+				prevOutputs[outpointId] = prevOutput
+			}
+			if err := tx.Where("id = ?", outpointId).Delete(reps.UnspentOutput{}).Error; err != nil {
+				tx.Rollback()
+				return err
+			}
+			if prevOutput.Staked {
+				if err := adjustStake(tx, prevOutput.PubKeyHash, -prevOutput.Value, -1); err != nil {
+					tx.Rollback()
+					return err
+				}
+			}
+		}
+
+		for outIdx := range txn.Outputs {
+			unspentOutput := reps.NewUnspentOutput(txn, outIdx, block.ID, height)
+			if err := tx.Create(&unspentOutput).Error; err != nil {
+				tx.Rollback()
+				return err
+			}
+			if unspentOutput.Staked {
+				if err := adjustStake(tx, unspentOutput.PubKeyHash, unspentOutput.Value, 1); err != nil {
+					tx.Rollback()
+					return err
+				}
+			}
+		}
+
+		location := reps.NewTxnLocation(txn, block, height, position)
+		if err := tx.Create(&location).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		for _, entry := range reps.NewAddressIndexEntries(txn, block, height, position, prevOutputs) {
+			if err := tx.Create(&entry).Error; err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+
+		if change, ok := reps.NewValidatorChangeRecord(txn, block, height, position); ok {
+			if err := tx.Create(&change).Error; err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+
+	return tx.Commit().Error
 }
 
-// Get all blocks in blockchain
-func (repo *blockchainRepository) GetBlockchain() ([]reps.Block, error) {
-	var blocks []reps.Block
+// Get every unspent output locked with pubKeyHash
+func (repo *blockchainRepository) GetUnspentOutputs(pubKeyHash []byte) ([]reps.UnspentOutput, error) {
+	var unspentOutputs []reps.UnspentOutput
 
-	if err := db.DB.
-		Preload("Transactions").
-		Find(&blocks).Error; err != nil {
-		return []reps.Block{}, err
+	err := db.DB.
+		Where("pub_key_hash = ?", hex.EncodeToString(pubKeyHash)).
+		Find(&unspentOutputs).
+		Error
+	if err != nil {
+		return []reps.UnspentOutput{}, err
 	}
 
-	for i := 0; i < len(blocks); i++ {
-		blockId := blocks[i].ID
+	return unspentOutputs, nil
+}
 
-		// Get the transactions for each block
-		txns, err := repo.GetTransactionsByBlockId(blockId)
-		if err != nil {
-			return []reps.Block{}, err
-		}
+// Get every address with stake bonded from the staking index, most stake first
+func (repo *blockchainRepository) GetValidators() ([]reps.Validator, error) {
+	var validators []reps.Validator
 
-		blocks[i].Transactions = txns
+	err := db.DB.
+		Order("bonded desc, pub_key_hash").
+		Find(&validators).
+		Error
+	if err != nil {
+		return []reps.Validator{}, err
 	}
 
-	return blocks, nil
+	return validators, nil
 }
 
-// Save a Wallet to the db
-func (repo *blockchainRepository) CreateWallet(wallet reps.Wallet) error {
-	if err := db.DB.Create(&wallet).Error; err != nil {
+// Add value, held by deposits staked outputs, to the stake of pubKeyHash in the staking index. Negative to take it away,
+// and an address left without any is dropped
+func adjustStake(tx *gorm.DB, pubKeyHash string, value int, deposits int) error {
+	var validator reps.Validator
+	if err := tx.Where("pub_key_hash = ?", pubKeyHash).First(&validator).Error; err != nil {
+		validator = reps.Validator{PubKeyHash: pubKeyHash}
+	}
+
+	validator.Bonded += value
+	validator.Deposits += deposits
+	if validator.Deposits <= 0 {
+		return tx.Where("pub_key_hash = ?", pubKeyHash).Delete(reps.Validator{}).Error
+	}
+
+	return tx.Save(&validator).Error
+}
+
+// Get every unspent output holding units of an asset
+func (repo *blockchainRepository) GetUnspentAssetOutputs(assetId string) ([]reps.UnspentOutput, error) {
+	var unspentOutputs []reps.UnspentOutput
+
+	err := db.DB.
+		Where("asset_id = ?", assetId).
+		Find(&unspentOutputs).
+		Error
+	if err != nil {
+		return []reps.UnspentOutput{}, err
+	}
+
+	return unspentOutputs, nil
+}
+
+// Get an output from the UTXO set. Errors if it was spent or never existed
+func (repo *blockchainRepository) GetUnspentOutput(txnId []byte, outIdx int) (reps.UnspentOutput, error) {
+	var unspentOutput reps.UnspentOutput
+
+	err := db.DB.
+		Where("id = ?", reps.OutpointID(txnId, outIdx)).
+		First(&unspentOutput).
+		Error
+	if err != nil {
+		return reps.UnspentOutput{}, err
+	}
+
+	return unspentOutput, nil
+}
+
+func (repo *blockchainRepository) CountBlocks() (int, error) {
+	var count int
+
+	if err := db.DB.Model(&reps.Block{}).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (repo *blockchainRepository) CountUnspentOutputs() (int, error) {
+	var count int
+
+	if err := db.DB.Model(&reps.UnspentOutput{}).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Throw away the UTXO set and replace it with unspentOutputs, rebuilding the staking index from the staked ones
+func (repo *blockchainRepository) ReplaceUnspentOutputs(unspentOutputs []reps.UnspentOutput) error {
+	tx := db.DB.Begin()
+	if err := tx.Error; err != nil {
 		return err
 	}
 
-	return nil
+	if err := tx.Delete(reps.UnspentOutput{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Delete(reps.Validator{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, unspentOutput := range unspentOutputs {
+		if err := tx.Create(&unspentOutput).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		if unspentOutput.Staked {
+			if err := adjustStake(tx, unspentOutput.PubKeyHash, unspentOutput.Value, 1); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+
+	return tx.Commit().Error
 }
 
-// Get Wallet by address
-func (repo *blockchainRepository) GetWallet(address string) (reps.Wallet, error) {
-	var wallet reps.Wallet
+// Get where on the chain a transaction is from the transaction index
+func (repo *blockchainRepository) GetTxnLocation(txnId []byte) (reps.TxnLocation, error) {
+	var location reps.TxnLocation
 
 	err := db.DB.
-		Where("address = ?", address).
-		First(&wallet).
+		Where("txn_id = ?", hex.EncodeToString(txnId)).
+		First(&location).
 		Error
 	if err != nil {
-		return reps.Wallet{}, err
+		return reps.TxnLocation{}, err
 	}
 
-	return wallet, nil
+	return location, nil
 }
 
-// Get all Wallets
-func (repo *blockchainRepository) GetWallets() ([]reps.Wallet, error) {
-	var wallets []reps.Wallet
+func (repo *blockchainRepository) CountTxnLocations() (int, error) {
+	var count int
+
+	if err := db.DB.Model(&reps.TxnLocation{}).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Throw away the transaction index and replace it with locations
+func (repo *blockchainRepository) ReplaceTxnLocations(locations []reps.TxnLocation) error {
+	tx := db.DB.Begin()
+	if err := tx.Error; err != nil {
+		return err
+	}
+
+	if err := tx.Delete(reps.TxnLocation{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, location := range locations {
+		if err := tx.Create(&location).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+// Get the address index entries for pubKeyHash, oldest first
+func (repo *blockchainRepository) GetAddressIndex(pubKeyHash []byte) ([]reps.AddressIndexEntry, error) {
+	var entries []reps.AddressIndexEntry
 
 	err := db.DB.
-		Find(&wallets).
+		Where("pub_key_hash = ?", hex.EncodeToString(pubKeyHash)).
+		Order("height, position").
+		Find(&entries).
 		Error
 	if err != nil {
-		return []reps.Wallet{}, err
+		return []reps.AddressIndexEntry{}, err
 	}
 
-	return wallets, nil
+	return entries, nil
+}
+
+func (repo *blockchainRepository) CountAddressIndex() (int, error) {
+	var count int
+
+	if err := db.DB.Model(&reps.AddressIndexEntry{}).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Throw away the address index and replace it with entries
+func (repo *blockchainRepository) ReplaceAddressIndex(entries []reps.AddressIndexEntry) error {
+	tx := db.DB.Begin()
+	if err := tx.Error; err != nil {
+		return err
+	}
+
+	if err := tx.Delete(reps.AddressIndexEntry{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := tx.Create(&entry).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+// Get all blocks in blockchain, genesis first
+func (repo *blockchainRepository) GetBlockchain() ([]reps.Block, error) {
+	var blocks []reps.Block
+
+	if err := db.DB.
+		Preload("Transactions").
+		Order("height").
+		Find(&blocks).Error; err != nil {
+		return []reps.Block{}, err
+	}
+
+	for i := 0; i < len(blocks); i++ {
+		blockId := blocks[i].ID
+
+		// Get the transactions for each block
+		txns, err := repo.GetTransactionsByBlockId(blockId)
+		if err != nil {
+			return []reps.Block{}, err
+		}
+
+		blocks[i].Transactions = txns
+	}
+
+	return blocks, nil
+}
+
+// Save a Wallet to the db
+func (repo *blockchainRepository) CreateWallet(wallet reps.Wallet) error {
+	if err := db.DB.Create(&wallet).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Update every field of a Wallet
+func (repo *blockchainRepository) UpdateWallet(wallet reps.Wallet) error {
+	if err := db.DB.Save(&wallet).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Get Wallet by address
+func (repo *blockchainRepository) GetWallet(address string) (reps.Wallet, error) {
+	var wallet reps.Wallet
+
+	err := db.DB.
+		Where("address = ?", address).
+		First(&wallet).
+		Error
+	if err != nil {
+		return reps.Wallet{}, err
+	}
+
+	return wallet, nil
+}
+
+// Get all Wallets
+func (repo *blockchainRepository) GetWallets() ([]reps.Wallet, error) {
+	var wallets []reps.Wallet
+
+	err := db.DB.
+		Find(&wallets).
+		Error
+	if err != nil {
+		return []reps.Wallet{}, err
+	}
+
+	return wallets, nil
+}
+
+// Get all Wallets derived from an HD wallet
+func (repo *blockchainRepository) GetWalletsByHDWalletId(hdWalletId string) ([]reps.Wallet, error) {
+	var wallets []reps.Wallet
+
+	err := db.DB.
+		Where("hd_wallet_id = ?", hdWalletId).
+		Find(&wallets).
+		Error
+	if err != nil {
+		return []reps.Wallet{}, err
+	}
+
+	return wallets, nil
+}
+
+// Get all Wallets assigned to an account
+func (repo *blockchainRepository) GetWalletsByAccountId(accountId string) ([]reps.Wallet, error) {
+	var wallets []reps.Wallet
+
+	err := db.DB.
+		Where("account_id = ?", accountId).
+		Find(&wallets).
+		Error
+	if err != nil {
+		return []reps.Wallet{}, err
+	}
+
+	return wallets, nil
+}
+
+// Save an account to the db
+func (repo *blockchainRepository) CreateAccount(account reps.Account) error {
+	if err := db.DB.Create(&account).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Update every field of an account
+func (repo *blockchainRepository) UpdateAccount(account reps.Account) error {
+	if err := db.DB.Save(&account).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Get account by name
+func (repo *blockchainRepository) GetAccount(name string) (reps.Account, error) {
+	var account reps.Account
+
+	err := db.DB.
+		Where("name = ?", name).
+		First(&account).
+		Error
+	if err != nil {
+		return reps.Account{}, err
+	}
+
+	return account, nil
+}
+
+// Get all accounts
+func (repo *blockchainRepository) GetAccounts() ([]reps.Account, error) {
+	var accounts []reps.Account
+
+	err := db.DB.
+		Order("name").
+		Find(&accounts).
+		Error
+	if err != nil {
+		return []reps.Account{}, err
+	}
+
+	return accounts, nil
+}
+
+// Save an HD wallet to the db
+func (repo *blockchainRepository) CreateHDWallet(hdWallet reps.HDWallet) error {
+	if err := db.DB.Create(&hdWallet).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Update every field of an HD wallet
+func (repo *blockchainRepository) UpdateHDWallet(hdWallet reps.HDWallet) error {
+	if err := db.DB.Save(&hdWallet).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Get HD wallet by id
+func (repo *blockchainRepository) GetHDWallet(hdWalletId string) (reps.HDWallet, error) {
+	var hdWallet reps.HDWallet
+
+	err := db.DB.
+		Where("id = ?", hdWalletId).
+		First(&hdWallet).
+		Error
+	if err != nil {
+		return reps.HDWallet{}, err
+	}
+
+	return hdWallet, nil
+}
+
+// Get HD wallet by the fingerprint of its seed
+func (repo *blockchainRepository) GetHDWalletByFingerprint(fingerprint string) (reps.HDWallet, error) {
+	var hdWallet reps.HDWallet
+
+	err := db.DB.
+		Where("fingerprint = ?", fingerprint).
+		First(&hdWallet).
+		Error
+	if err != nil {
+		return reps.HDWallet{}, err
+	}
+
+	return hdWallet, nil
+}
+
+// Save the chain parameters to the db
+func (repo *blockchainRepository) CreateChainParams(params reps.ChainParams) error {
+	if err := db.DB.Create(&params).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Get the chain parameters stored with the genesis block
+func (repo *blockchainRepository) GetChainParams() (reps.ChainParams, error) {
+	var params reps.ChainParams
+
+	err := db.DB.
+		First(&params).
+		Error
+	if err != nil {
+		return reps.ChainParams{}, err
+	}
+
+	return params, nil
+}
+
+func (repo *blockchainRepository) CreateAsset(asset reps.Asset) error {
+	if err := db.DB.Create(&asset).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Get an asset by its id
+func (repo *blockchainRepository) GetAsset(assetId string) (reps.Asset, error) {
+	var asset reps.Asset
+
+	err := db.DB.
+		Where("id = ?", assetId).
+		First(&asset).
+		Error
+	if err != nil {
+		return reps.Asset{}, err
+	}
+
+	return asset, nil
+}
+
+// Get every registered asset
+func (repo *blockchainRepository) GetAssets() ([]reps.Asset, error) {
+	var assets []reps.Asset
+
+	err := db.DB.
+		Order("symbol").
+		Find(&assets).
+		Error
+	if err != nil {
+		return []reps.Asset{}, err
+	}
+
+	return assets, nil
+}
+
+func (repo *blockchainRepository) CreateStaleBlock(staleBlock reps.StaleBlock) error {
+	if err := db.DB.Create(&staleBlock).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Get the count stale blocks received last, latest first
+func (repo *blockchainRepository) GetStaleBlocks(count int) ([]reps.StaleBlock, error) {
+	var staleBlocks []reps.StaleBlock
+
+	err := db.DB.
+		Order("received_at desc").
+		Limit(count).
+		Find(&staleBlocks).
+		Error
+	if err != nil {
+		return []reps.StaleBlock{}, err
+	}
+
+	return staleBlocks, nil
+}
+
+func (repo *blockchainRepository) CountStaleBlocks() (int, error) {
+	var count int
+
+	if err := db.DB.Model(&reps.StaleBlock{}).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (repo *blockchainRepository) CreateSideBlock(sideBlock reps.SideBlock) error {
+	if err := db.DB.Create(&sideBlock).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Get a block on a side branch by its hex hash
+func (repo *blockchainRepository) GetSideBlock(hash string) (reps.SideBlock, error) {
+	var sideBlock reps.SideBlock
+
+	if err := db.DB.Where("hash = ?", hash).First(&sideBlock).Error; err != nil {
+		return reps.SideBlock{}, err
+	}
+
+	return sideBlock, nil
+}
+
+// Get every block on a side branch, lowest first
+func (repo *blockchainRepository) GetSideBlocks() ([]reps.SideBlock, error) {
+	var sideBlocks []reps.SideBlock
+
+	if err := db.DB.Order("height").Find(&sideBlocks).Error; err != nil {
+		return []reps.SideBlock{}, err
+	}
+
+	return sideBlocks, nil
+}
+
+func (repo *blockchainRepository) DeleteSideBlock(hash string) error {
+	if err := db.DB.Where("hash = ?", hash).Delete(reps.SideBlock{}).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repo *blockchainRepository) CreateBlockVote(vote reps.BlockVote) error {
+	if err := db.DB.Create(&vote).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Get the votes for a block, in the order they were cast
+func (repo *blockchainRepository) GetBlockVotes(blockId string) ([]reps.BlockVote, error) {
+	var votes []reps.BlockVote
+
+	err := db.DB.
+		Where("block_id = ?", blockId).
+		Order("timestamp").
+		Find(&votes).
+		Error
+	if err != nil {
+		return []reps.BlockVote{}, err
+	}
+
+	return votes, nil
+}
+
+// Get the vote a validator cast at height, for whichever block it was
+func (repo *blockchainRepository) GetValidatorVote(height int, validator string) (reps.BlockVote, error) {
+	var vote reps.BlockVote
+
+	err := db.DB.
+		Where("height = ? AND validator = ?", height, validator).
+		First(&vote).
+		Error
+	if err != nil {
+		return reps.BlockVote{}, err
+	}
+
+	return vote, nil
+}
+
+func (repo *blockchainRepository) FinalizeBlock(blockId string) error {
+	if err := db.DB.Model(&reps.Block{}).Where("block_id = ?", blockId).Update("finalized", true).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Get the header of the highest block a quorum of validators voted for
+func (repo *blockchainRepository) GetLastFinalizedBlock() (reps.Block, error) {
+	var block reps.Block
+
+	err := db.DB.
+		Where("finalized = ?", true).
+		Order("height desc").
+		First(&block).
+		Error
+	if err != nil {
+		return reps.Block{}, err
+	}
+
+	return block, nil
+}
+
+func (repo *blockchainRepository) CreateEvidence(evidence reps.Evidence) error {
+	if err := db.DB.Create(&evidence).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Get evidence of an equivocation by its id
+func (repo *blockchainRepository) GetEvidence(id string) (reps.Evidence, error) {
+	var evidence reps.Evidence
+
+	err := db.DB.
+		Where("id = ?", id).
+		First(&evidence).
+		Error
+	if err != nil {
+		return reps.Evidence{}, err
+	}
+
+	return evidence, nil
+}
+
+// Get every equivocation caught, latest first
+func (repo *blockchainRepository) GetAllEvidence() ([]reps.Evidence, error) {
+	var evidence []reps.Evidence
+
+	err := db.DB.
+		Order("reported_at desc").
+		Find(&evidence).
+		Error
+	if err != nil {
+		return []reps.Evidence{}, err
+	}
+
+	return evidence, nil
+}
+
+// Start the chain from a snapshot: store its params, the headers up to it, its UTXO set and staking index, the
+// validator changes made up to it, and the snapshot itself, in the same db transaction so a node is never left with half of it
+func (repo *blockchainRepository) LoadSnapshot(base reps.SnapshotBase, params reps.ChainParams, headers []reps.Block, unspentOutputs []reps.UnspentOutput,
+	validatorChanges []reps.ValidatorChangeRecord) error {
+	tx := db.DB.Begin()
+	if err := tx.Error; err != nil {
+		return err
+	}
+
+	if err := tx.Create(&params).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, header := range headers {
+		if err := tx.Create(&header).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	for _, unspentOutput := range unspentOutputs {
+		if err := tx.Create(&unspentOutput).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		if unspentOutput.Staked {
+			if err := adjustStake(tx, unspentOutput.PubKeyHash, unspentOutput.Value, 1); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+
+	for _, change := range validatorChanges {
+		if err := tx.Create(&change).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Create(&base).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// Get the snapshot the chain was started from, if it was
+func (repo *blockchainRepository) GetSnapshotBase() (reps.SnapshotBase, error) {
+	var base reps.SnapshotBase
+
+	err := db.DB.
+		First(&base).
+		Error
+	if err != nil {
+		return reps.SnapshotBase{}, err
+	}
+
+	return base, nil
+}
+
+// Get the whole UTXO set, in order of outpoint
+func (repo *blockchainRepository) GetAllUnspentOutputs() ([]reps.UnspentOutput, error) {
+	var unspentOutputs []reps.UnspentOutput
+
+	err := db.DB.
+		Order("id").
+		Find(&unspentOutputs).
+		Error
+	if err != nil {
+		return []reps.UnspentOutput{}, err
+	}
+
+	return unspentOutputs, nil
+}
+
+// Get every validator change on the chain, in the order they take effect
+func (repo *blockchainRepository) GetValidatorChanges() ([]reps.ValidatorChangeRecord, error) {
+	var changes []reps.ValidatorChangeRecord
+
+	err := db.DB.
+		Order("height, mined_height, position").
+		Find(&changes).
+		Error
+	if err != nil {
+		return []reps.ValidatorChangeRecord{}, err
+	}
+
+	return changes, nil
+}
+
+func (repo *blockchainRepository) CreateMultisigAddress(multisigAddress reps.MultisigAddress) error {
+	if err := db.DB.Create(&multisigAddress).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Get multisig address by its address
+func (repo *blockchainRepository) GetMultisigAddress(address string) (reps.MultisigAddress, error) {
+	var multisigAddress reps.MultisigAddress
+
+	err := db.DB.
+		Where("address = ?", address).
+		First(&multisigAddress).
+		Error
+	if err != nil {
+		return reps.MultisigAddress{}, err
+	}
+
+	return multisigAddress, nil
+}
+
+func (repo *blockchainRepository) CreateMultisigTransaction(multisigTxn reps.MultisigTransaction) error {
+	if err := db.DB.Create(&multisigTxn).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Update every field of a multisig transaction
+func (repo *blockchainRepository) UpdateMultisigTransaction(multisigTxn reps.MultisigTransaction) error {
+	if err := db.DB.Save(&multisigTxn).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Get multisig transaction by its hex transaction id
+func (repo *blockchainRepository) GetMultisigTransaction(txnId string) (reps.MultisigTransaction, error) {
+	var multisigTxn reps.MultisigTransaction
+
+	err := db.DB.
+		Where("id = ?", txnId).
+		First(&multisigTxn).
+		Error
+	if err != nil {
+		return reps.MultisigTransaction{}, err
+	}
+
+	return multisigTxn, nil
+}
+
+// Whether err is from looking up a record that doesn't exist, rather than from the lookup itself failing
+func IsNotFound(err error) bool {
+	return gorm.IsRecordNotFoundError(err)
 }
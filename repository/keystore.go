@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	reps "github.com/brucetieu/blockchain/representations"
+)
+
+// Stores the encrypted wallet file on disk
+type KeystoreRepository interface {
+	GetKeystore() (reps.Keystore, bool, error)
+	SaveKeystore(keystore reps.Keystore) error
+}
+
+type keystoreRepository struct {
+	path string
+}
+
+func NewKeystoreRepository(path string) KeystoreRepository {
+	return &keystoreRepository{
+		path: path,
+	}
+}
+
+// Read the wallet file. The bool is false if the file doesn't exist yet
+func (repo *keystoreRepository) GetKeystore() (reps.Keystore, bool, error) {
+	var keystore reps.Keystore
+
+	content, err := os.ReadFile(repo.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return reps.Keystore{}, false, nil
+	}
+	if err != nil {
+		return reps.Keystore{}, false, err
+	}
+
+	if err := json.Unmarshal(content, &keystore); err != nil {
+		return reps.Keystore{}, false, err
+	}
+
+	return keystore, true, nil
+}
+
+// Write the wallet file. Written to a temp file first so a crash can't leave a half written wallet file behind
+func (repo *keystoreRepository) SaveKeystore(keystore reps.Keystore) error {
+	content, err := json.MarshalIndent(keystore, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(repo.path), ".wallet-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(content); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+
+	// Only the owner can read the wallet file
+	if err := os.Chmod(tmpFile.Name(), 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), repo.path)
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"github.com/brucetieu/blockchain/db"
+
+	reps "github.com/brucetieu/blockchain/representations"
+)
+
+// Persists the mempool, so pending transactions survive a restart
+type MempoolRepository interface {
+	CreateEntry(entry reps.MempoolEntry) error
+	GetEntries() ([]reps.MempoolEntry, error)
+	DeleteEntry(txnId string) error
+
+	CreateReplacement(replacement reps.MempoolReplacement) error
+	GetReplacement(txnId string) (reps.MempoolReplacement, error)
+}
+
+type mempoolRepository struct{}
+
+func NewMempoolRepository() MempoolRepository {
+	return &mempoolRepository{}
+}
+
+func (repo *mempoolRepository) CreateEntry(entry reps.MempoolEntry) error {
+	if err := db.DB.Create(&entry).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Get every mempool entry, oldest first
+func (repo *mempoolRepository) GetEntries() ([]reps.MempoolEntry, error) {
+	var entries []reps.MempoolEntry
+
+	err := db.DB.Order("added_at").Find(&entries).Error
+	if err != nil {
+		return []reps.MempoolEntry{}, err
+	}
+
+	return entries, nil
+}
+
+func (repo *mempoolRepository) DeleteEntry(txnId string) error {
+	if err := db.DB.Where("txn_id = ?", txnId).Delete(&reps.MempoolEntry{}).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repo *mempoolRepository) CreateReplacement(replacement reps.MempoolReplacement) error {
+	if err := db.DB.Create(&replacement).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Get what replaced the transaction with txnId, if anything did
+func (repo *mempoolRepository) GetReplacement(txnId string) (reps.MempoolReplacement, error) {
+	var replacement reps.MempoolReplacement
+
+	err := db.DB.Where("txn_id = ?", txnId).First(&replacement).Error
+	if err != nil {
+		return reps.MempoolReplacement{}, err
+	}
+
+	return replacement, nil
+}
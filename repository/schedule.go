@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"github.com/brucetieu/blockchain/db"
+
+	reps "github.com/brucetieu/blockchain/representations"
+)
+
+// Persists recurring payments, so they carry on after a restart
+type ScheduleRepository interface {
+	CreateSchedule(schedule reps.Schedule) error
+	UpdateSchedule(schedule reps.Schedule) error
+	GetSchedule(id string) (reps.Schedule, error)
+	GetSchedules() ([]reps.Schedule, error)
+}
+
+type scheduleRepository struct{}
+
+func NewScheduleRepository() ScheduleRepository {
+	return &scheduleRepository{}
+}
+
+func (repo *scheduleRepository) CreateSchedule(schedule reps.Schedule) error {
+	if err := db.DB.Create(&schedule).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Update every field of a schedule
+func (repo *scheduleRepository) UpdateSchedule(schedule reps.Schedule) error {
+	if err := db.DB.Save(&schedule).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repo *scheduleRepository) GetSchedule(id string) (reps.Schedule, error) {
+	var schedule reps.Schedule
+
+	err := db.DB.
+		Where("id = ?", id).
+		First(&schedule).
+		Error
+	if err != nil {
+		return reps.Schedule{}, err
+	}
+
+	return schedule, nil
+}
+
+// Get every schedule, oldest first
+func (repo *scheduleRepository) GetSchedules() ([]reps.Schedule, error) {
+	var schedules []reps.Schedule
+
+	err := db.DB.Order("created_at").Find(&schedules).Error
+	if err != nil {
+		return []reps.Schedule{}, err
+	}
+
+	return schedules, nil
+}
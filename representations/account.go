@@ -0,0 +1,30 @@
+package representations
+
+// A named group of addresses that are treated as one. Balances add up across the addresses, and sends can spend from any of them
+// SigAlgorithm -> Scheme of the account's keys. Set by the first address with a key, and every other address must match,
+// since a transaction is signed with a single scheme
+// Addresses and Balance -> Filled in when an account is looked up, not stored
+type Account struct {
+	ID           string   `json:"id" gorm:"primary_key"`
+	Name         string   `json:"name" gorm:"unique"`
+	SigAlgorithm string   `json:"sigAlgorithm,omitempty"`
+	Addresses    []string `json:"addresses,omitempty" gorm:"-"`
+	Balance      int      `json:"balance" gorm:"-"`
+}
+
+// Format of payload when creating an account
+type CreateAccountInput struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// Format of payload when assigning an address to an account
+type AssignAddressInput struct {
+	Address string `json:"address" binding:"required"`
+}
+
+// Format of payload when sending coins from an account. To can be an address book name
+type AccountSendInput struct {
+	To     string `json:"to" binding:"required"`
+	Amount int    `json:"amount" binding:"required"`
+	TxnOptions
+}
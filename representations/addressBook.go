@@ -0,0 +1,14 @@
+package representations
+
+// A human readable name for an address. Names can be used in place of addresses when sending coins
+type AddressBookEntry struct {
+	ID      string `json:"id" gorm:"primary_key"`
+	Name    string `json:"name" gorm:"unique"`
+	Address string `json:"address"`
+}
+
+// Format of payload when adding an address book entry
+type AddressBookInput struct {
+	Name    string `json:"name" binding:"required"`
+	Address string `json:"address" binding:"required"`
+}
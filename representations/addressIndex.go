@@ -0,0 +1,77 @@
+package representations
+
+import "encoding/hex"
+
+// Address index entry: a transaction on the chain an address appears in, keyed by the address's pubKeyHash
+// so its history is a lookup instead of a scan of the chain
+// ID -> Hex pubKeyHash and hex transaction id, joined by a colon
+// Position -> Index of the transaction on its block, to order transactions on the same block
+// Direction and Amount -> As on AddressTransaction
+type AddressIndexEntry struct {
+	ID         string `json:"id" gorm:"primary_key"`
+	PubKeyHash string `json:"pubKeyHash" gorm:"index"`
+	TxnID      []byte `json:"txnId"`
+	BlockID    string `json:"blockId"`
+	BlockHash  []byte `json:"blockHash"`
+	Height     int    `json:"height"`
+	Position   int    `json:"position"`
+	Direction  string `json:"direction"`
+	Amount     int    `json:"amount"`
+}
+
+// Address index entries for every address txn spends from or pays to. txn is at position on block, which is at height.
+// prevOutputs holds the outputs its inputs spend, by outpoint id. Amounts are in the chain's own coin
+func NewAddressIndexEntries(txn Transaction, block Block, height int, position int, prevOutputs map[string]UnspentOutput) []AddressIndexEntry {
+	pubKeyHashes := make([]string, 0)
+	amounts := make(map[string]int)
+	spends := make(map[string]bool)
+
+	involve := func(pubKeyHash string) {
+		if _, ok := amounts[pubKeyHash]; !ok {
+			pubKeyHashes = append(pubKeyHashes, pubKeyHash)
+			amounts[pubKeyHash] = 0
+		}
+	}
+
+	for _, input := range txn.Inputs {
+		prevOutput, ok := prevOutputs[OutpointID(input.PrevTxnID, input.OutIdx)]
+		if !ok {
+			continue
+		}
+		involve(prevOutput.PubKeyHash)
+		spends[prevOutput.PubKeyHash] = true
+		if prevOutput.AssetID == "" {
+			amounts[prevOutput.PubKeyHash] -= prevOutput.Value
+		}
+	}
+
+	for _, output := range txn.Outputs {
+		pubKeyHash := hex.EncodeToString(output.PubKeyHash)
+		involve(pubKeyHash)
+		if output.AssetID == "" {
+			amounts[pubKeyHash] += output.Value
+		}
+	}
+
+	entries := make([]AddressIndexEntry, 0, len(pubKeyHashes))
+	for _, pubKeyHash := range pubKeyHashes {
+		direction := DirectionReceived
+		if spends[pubKeyHash] {
+			direction = DirectionSent
+		}
+
+		entries = append(entries, AddressIndexEntry{
+			ID:         pubKeyHash + ":" + hex.EncodeToString(txn.ID),
+			PubKeyHash: pubKeyHash,
+			TxnID:      txn.ID,
+			BlockID:    block.ID,
+			BlockHash:  block.Hash,
+			Height:     height,
+			Position:   position,
+			Direction:  direction,
+			Amount:     amounts[pubKeyHash],
+		})
+	}
+
+	return entries
+}
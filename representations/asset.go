@@ -0,0 +1,86 @@
+package representations
+
+// A token tracked on the chain alongside its own coin. Its units are held in outputs carrying its id
+// ID -> Hex hash of the issuer's pubKeyHash and the symbol, so an issuer can only register a symbol once
+// Issuer -> Address that can issue units. Issuing takes a transaction signed with its key
+// MaxSupply -> Most units that can ever be issued, 0 means there's no cap
+// Mintable -> Whether more units can be issued once the first issuance is on the chain
+// NonFungible -> Whether it's an NFT, a single unit minted once that can't be split
+// MetadataHash -> Hex hash of an NFT's payload. Its id is derived from it in place of the symbol, so it can't change
+// Supply -> Units issued so far, on the chain
+type Asset struct {
+	ID           string `json:"id" gorm:"primary_key"`
+	Symbol       string `json:"symbol"`
+	Name         string `json:"name"`
+	Issuer       string `json:"issuer"`
+	MaxSupply    int    `json:"maxSupply"`
+	Mintable     bool   `json:"mintable"`
+	NonFungible  bool   `json:"nonFungible"`
+	MetadataHash string `json:"metadataHash,omitempty"`
+	Supply       int    `json:"supply" gorm:"-"`
+}
+
+// Balance of an address in one asset. Confirmed and Pending are as on AddressBalanceSummary
+type AssetBalance struct {
+	AssetID   string `json:"assetId"`
+	Symbol    string `json:"symbol"`
+	Confirmed int    `json:"confirmed"`
+	Pending   int    `json:"pending"`
+}
+
+// Format of payload when registering an asset and issuing its first units to the issuer
+type CreateAssetInput struct {
+	Issuer    string `json:"issuer" binding:"required"`
+	Symbol    string `json:"symbol" binding:"required"`
+	Name      string `json:"name"`
+	Amount    int    `json:"amount" binding:"required"`
+	MaxSupply int    `json:"maxSupply"`
+	Mintable  bool   `json:"mintable"`
+	TxnOptions
+}
+
+// Format of payload when issuing more units of a mintable asset. They go to the issuer
+type IssueAssetInput struct {
+	Amount int `json:"amount" binding:"required"`
+	TxnOptions
+}
+
+// Format of payload when sending units of an asset
+type TransferAssetInput struct {
+	From   string `json:"from" binding:"required"`
+	To     string `json:"to" binding:"required"`
+	Amount int    `json:"amount" binding:"required"`
+	TxnOptions
+}
+
+// Format of payload when minting an NFT to its issuer. MetadataHash is the hex sha256 of its payload
+type CreateNFTInput struct {
+	Issuer       string `json:"issuer" binding:"required"`
+	Name         string `json:"name"`
+	MetadataHash string `json:"metadataHash" binding:"required"`
+	TxnOptions
+}
+
+// Format of payload when sending an NFT on from its current owner
+type TransferNFTInput struct {
+	To string `json:"to" binding:"required"`
+	TxnOptions
+}
+
+// An NFT with who owns it now and every transaction that moved it, oldest first
+// Owner -> Address holding it on the chain. Empty until its mint is mined
+type NFTProvenance struct {
+	NFT     Asset         `json:"nft"`
+	Owner   string        `json:"owner"`
+	History []NFTTransfer `json:"history"`
+}
+
+// A transaction on the chain that minted or moved an NFT. From is empty for the mint
+type NFTTransfer struct {
+	TxnID     string `json:"txnId"`
+	BlockHash string `json:"blockHash"`
+	Height    int    `json:"height"`
+	Timestamp int64  `json:"timestamp"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+}
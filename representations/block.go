@@ -1,28 +1,207 @@
 package representations
 
-// Format of payload when mining a block
+// Format of payload when mining a block. Either send to a single address with To and Amount,
+// or to several at once with Recipients
 type CreateBlockInput struct {
-	From   string `json:"from" binding:"required"`
+	From       string      `json:"from" binding:"required"`
+	To         string      `json:"to"`
+	Amount     int         `json:"amount"`
+	Recipients []Recipient `json:"recipients" binding:"omitempty,dive"`
+	TxnOptions
+}
+
+// An address and how much to send it
+// Stake -> Bond the amount as stake of To instead of paying it. Set by the node for staking deposits, not taken from payloads
+type Recipient struct {
 	To     string `json:"to" binding:"required"`
 	Amount int    `json:"amount" binding:"required"`
+	Stake  bool   `json:"-"`
 }
 
 // Block representation in bitcoin blockchain
+// Difficulty -> Number of leading zero bits the hash needed, for the block to be mined. 0 on blocks mined before it
+// was recorded, which needed the default
+// Height -> Position on the chain, one more than the parent's. The genesis block is at height 0
+// Hash and PrevHash -> Indexed, so blocks can be looked up by hash and followed from parent to child
+// ChainWork -> Hashes it took on average to mine the block and every block before it, hex encoded. Which of two
+// branches is the chain comes down to which has more. Worked out by the node, not taken from whoever sent the block
+// MerkleRoot -> Root of the merkle tree over the ids of the transactions, which the hash covers. Empty on blocks
+// mined before it was in the header, whose hash covers a merkle tree over the whole serialized transactions
+// Proposer, SigAlgorithm and ProposerSig -> On a proof of stake or BFT chain, public key of the validator elected to
+// produce the block, and its signature of the hash. Empty on proof of work chains
+// Round -> On a BFT chain, how many times the turn to propose the block moved on to the next validator, because the
+// ones before didn't propose it in time
+// Finalized -> On a BFT chain, whether a quorum of validators voted for the block. The node's own bookkeeping
+// ChainID -> Identifier of the chain the block is for, which the hash covers. Empty on chains without replay protection
 type Block struct {
 	ID           string        `gorm:"primary_key;type:char(36);column:block_id"`
 	Timestamp    int64         `json:"timestamp"`
 	Transactions []Transaction `json:"transactions" gorm:"foreignKey:BlockID"`
-	PrevHash     []byte        `json:"prevHash"`
-	Hash         []byte        `json:"hash"`
+	PrevHash     []byte        `json:"prevHash" gorm:"index"`
+	Hash         []byte        `json:"hash" gorm:"index"`
 	Nounce       int64         `json:"nounce"`
+	Difficulty   int           `json:"difficulty"`
+	MerkleRoot   []byte        `json:"merkleRoot"`
+	Height       int           `json:"height" gorm:"index"`
+	Version      int           `json:"version"`
+	ChainWork    string        `json:"chainWork,omitempty"`
+	Proposer     []byte        `json:"proposer,omitempty"`
+	SigAlgorithm string        `json:"sigAlgorithm,omitempty"`
+	ProposerSig  []byte        `json:"proposerSig,omitempty"`
+	Round        int           `json:"round,omitempty"`
+	ChainID      string        `json:"chainId,omitempty"`
+	Finalized    bool          `json:"-" gorm:"index"`
 }
 
 
+// A block's header fields, without its transactions, for light clients
+// Proposer and ProposerSig -> Hex public key and signature of the validator that produced the block, on a proof of stake
+// or BFT chain
+// Finality -> final once the block can't be reorganized away, tentative until then. Set on blocks read from the chain
+type BlockHeader struct {
+	ID          string `json:"id"`
+	Height      int    `json:"height"`
+	Hash        string `json:"hash"`
+	PrevHash    string `json:"prevHash"`
+	MerkleRoot  string `json:"merkleRoot"`
+	Timestamp   int64  `json:"timestamp"`
+	Nounce      int64  `json:"nounce"`
+	Difficulty  int    `json:"difficulty"`
+	Version     int    `json:"version"`
+	ChainWork   string `json:"chainWork"`
+	Proposer    string `json:"proposer,omitempty"`
+	ProposerSig string `json:"proposerSig,omitempty"`
+	Round       int    `json:"round,omitempty"`
+	ChainID     string `json:"chainId,omitempty"`
+	Finality    string `json:"finality,omitempty"`
+}
+
+// CoinbaseMessage -> Message the miner put in the coinbase, without the extranonce after it
+// Proposer -> Hex public key of the validator that produced the block, on a proof of stake or BFT chain
+// Finality -> final once the block can't be reorganized away, tentative until then. Set on blocks read from the chain
 type ReadableBlock struct {
-	ID           string                `gorm:"primary_key;type:char(36);column:block_id"`
-	Timestamp    int64                 `json:"timestamp"`
-	Transactions []ReadableTransaction `json:"transactions" gorm:"foreignKey:BlockID"`
-	PrevHash     string                `json:"prevHash"`
-	Hash         string                `json:"hash"`
-	Nounce       int64                 `json:"nounce"`
+	ID              string                `gorm:"primary_key;type:char(36);column:block_id"`
+	Timestamp       int64                 `json:"timestamp"`
+	Transactions    []ReadableTransaction `json:"transactions" gorm:"foreignKey:BlockID"`
+	PrevHash        string                `json:"prevHash"`
+	Hash            string                `json:"hash"`
+	Nounce          int64                 `json:"nounce"`
+	Difficulty      int                   `json:"difficulty"`
+	MerkleRoot      string                `json:"merkleRoot"`
+	Height          int                   `json:"height"`
+	Version         int                   `json:"version"`
+	ChainWork       string                `json:"chainWork"`
+	CoinbaseMessage string                `json:"coinbaseMessage,omitempty"`
+	Proposer        string                `json:"proposer,omitempty"`
+	Round           int                   `json:"round,omitempty"`
+	ChainID         string                `json:"chainId,omitempty"`
+	Finality        string                `json:"finality,omitempty"`
+}
+
+// How many recent blocks signal with each version bit, for rule changes waiting on enough of the network to be ready
+// From and To -> Heights of the first and last blocks counted
+// Bits -> Every bit at least one of the blocks signals with
+type VersionBitsStats struct {
+	From   int               `json:"from"`
+	To     int               `json:"to"`
+	Blocks int               `json:"blocks"`
+	Bits   []VersionBitCount `json:"bits"`
 }
+
+// Share -> Fraction of the blocks counted that signal with Bit
+type VersionBitCount struct {
+	Bit   int     `json:"bit"`
+	Count int     `json:"count"`
+	Share float64 `json:"share"`
+}
+
+// How fast blocks have been coming and how much work went into them, over windows of recent blocks
+// Height -> Of the last block
+// Difficulty -> Leading zero bits the next block's hash needs
+// TargetBlockTime -> Seconds blocks should come apart
+type BlockStats struct {
+	Height          int                `json:"height"`
+	Difficulty      int                `json:"difficulty"`
+	TargetBlockTime int                `json:"targetBlockTime"`
+	Windows         []BlockWindowStats `json:"windows"`
+}
+
+// Stats over the last Window blocks, or as many as there are
+// From and To -> Heights of the first and last blocks
+// AverageBlockTime -> Seconds between consecutive blocks, on average
+// AverageDifficulty -> Difficulty the blocks after the first were mined at, on average
+// HashRate -> Hashes per second it took to mine the blocks after the first in that time, 2^difficulty for each on average
+type BlockWindowStats struct {
+	Window            int     `json:"window"`
+	From              int     `json:"from"`
+	To                int     `json:"to"`
+	Blocks            int     `json:"blocks"`
+	AverageBlockTime  float64 `json:"averageBlockTime"`
+	AverageDifficulty float64 `json:"averageDifficulty"`
+	HashRate          float64 `json:"hashRate"`
+}
+
+// A block that lost a fork race: it was solved on top of a block that was no longer the last one by the time it
+// reached the node, so another block at its height is on the chain instead. Kept to monitor how often that happens
+// Hash and PrevHash -> Hex encoded
+// Height -> Height it would have had
+// WinnerHash -> Hex hash of the block on the chain at that height
+// ReceivedAt -> When it reached the node, unix ms
+type StaleBlock struct {
+	Hash       string `json:"hash" gorm:"primary_key"`
+	BlockID    string `json:"blockId"`
+	PrevHash   string `json:"prevHash"`
+	Height     int    `json:"height"`
+	Timestamp  int64  `json:"timestamp"`
+	Difficulty int    `json:"difficulty"`
+	TxnCount   int    `json:"txnCount"`
+	WinnerHash string `json:"winnerHash"`
+	ReceivedAt int64  `json:"receivedAt" gorm:"index"`
+}
+
+// A block on a side branch: it builds on a block the node knows, but the chain it's on doesn't have more work than
+// the node's own, so it isn't part of it. Kept whole, in case its branch overtakes the chain and it's reorganized onto it
+// Hash and PrevHash -> Hex encoded
+// Data -> The block, transactions included, serialized as JSON
+// ReceivedAt -> When it was stored, unix ms
+type SideBlock struct {
+	Hash       string `json:"hash" gorm:"primary_key"`
+	PrevHash   string `json:"prevHash" gorm:"index"`
+	Height     int    `json:"height"`
+	Data       []byte `json:"-"`
+	ReceivedAt int64  `json:"receivedAt"`
+}
+
+// What adding a received block did to the chain
+// Connected -> Blocks added to the chain, oldest first: the block, orphans that were waiting on it and, on a reorg,
+// the rest of the branch that overtook the chain
+// Disconnected -> Blocks a reorg took off the chain, oldest first. They're kept on a side branch
+// Side -> Blocks stored on a side branch, which doesn't have more work than the chain
+// Orphaned -> Whether the block was held until its parent shows up
+// Slashings -> Transactions slashing the proposer of the block for signing another block on the same parent, to be queued
+type ChainUpdate struct {
+	Connected    []Block
+	Disconnected []Block
+	Side         []Block
+	Orphaned     bool
+	Slashings    []Transaction
+}
+
+// The last block of a branch the node knows of
+// Status -> active for the last block on the chain, side for the last block of a side branch
+// ForkHeight and BranchLength -> Height of the block on the chain the branch builds on, and how many blocks it has since.
+// The chain itself has a branch length of 0
+// ChainWork -> Of the branch up to its last block, hex encoded
+type ChainTip struct {
+	Hash         string `json:"hash"`
+	Height       int    `json:"height"`
+	ForkHeight   int    `json:"forkHeight"`
+	BranchLength int    `json:"branchLength"`
+	ChainWork    string `json:"chainWork"`
+	Status       string `json:"status"`
+}
+
+const (
+	ChainTipActive = "active"
+	ChainTipSide   = "side"
+)
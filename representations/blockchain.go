@@ -1,5 +1,76 @@
 package representations
 
+// Format of payload when creating the blockchain
+// To -> Address the genesis block's reward goes to
+// Allocations -> Coins the genesis block pays out on top of the reward, along with any in the genesis spec
 type CreateBlockchainInput struct {
-	To string `json:"to" binding:"required"`
+	To          string              `json:"to" binding:"required"`
+	Allocations []GenesisAllocation `json:"allocations"`
+}
+
+// Parameters every node on a chain has to agree on. Stored alongside the genesis block
+// NetworkByte -> Version byte prepended to addresses so addresses from different networks don't validate against each other
+// CoinbaseMaturity -> Confirmations a coinbase output needs before it can be spent. 0 means it can be spent right away
+// DustThreshold -> Smallest output a transaction can create, outputs worth less cost more to spend than they hold. 0 means no limit
+// DifficultyInterval -> Blocks between difficulty retargets. 0 means the difficulty never changes
+// TargetBlockTime -> Seconds blocks should come apart, which retargeting steers towards
+// InitialReward -> Coins the coinbase of the first blocks pays out, on top of fees. 0 means the node's default
+// HalvingInterval -> Blocks between halvings of the reward. 0 means the reward never halves
+// MaxBlockSize -> Most bytes a block can take up serialized, transactions included. 0 means no limit
+// HashAlgorithm -> What block hashes are taken with: sha256, sha256d or blake2b. Empty means sha256
+// ChainID -> Name of the chain, from CHAIN_ID or its genesis spec. Empty for chains created without one
+// InitialDifficulty -> Difficulty the genesis block is mined at. 0 means the node's default
+// Premine -> Coins the genesis block allocated on top of its reward
+// Consensus -> How block producers are picked: pow, by proof of work, pos, by stake weight, or bft, in turn out of
+// Validators, who vote blocks final. Empty means pow
+// Validators -> Comma separated addresses of the validators of a BFT chain
+// Activations -> Comma separated consensus rules and the heights they turn on at, e.g. ed25519:1000
+// ReplayProtection -> Whether transaction signatures and block headers commit to the chain id and network byte, so
+// neither can be replayed on another network. Chains created before it have it off
+type ChainParams struct {
+	ID                 string `json:"-" gorm:"primary_key"`
+	ChainID            string `json:"chainId"`
+	NetworkByte        byte   `json:"networkByte"`
+	CoinbaseMaturity   int    `json:"coinbaseMaturity"`
+	DustThreshold      int    `json:"dustThreshold"`
+	DifficultyInterval int    `json:"difficultyInterval"`
+	TargetBlockTime    int    `json:"targetBlockTime"`
+	InitialReward      int    `json:"initialReward"`
+	HalvingInterval    int    `json:"halvingInterval"`
+	MaxBlockSize       int    `json:"maxBlockSize"`
+	HashAlgorithm      string `json:"hashAlgorithm"`
+	InitialDifficulty  int    `json:"initialDifficulty"`
+	Premine            int    `json:"premine"`
+	Consensus          string `json:"consensus"`
+	Validators         string `json:"validators,omitempty"`
+	Activations        string `json:"activations,omitempty"`
+	ReplayProtection   bool   `json:"replayProtection"`
+}
+
+// Where the chain is at, and what the next block is worth
+// Height, BestBlockHash and ChainWork -> Of the last block on the chain
+// Difficulty and Reward -> Leading zero bits the next block's hash needs, and what its coinbase pays besides fees
+// NextHalvingHeight -> Height of the first block paying half the current reward. 0 if it never halves
+// Supply -> Coins paid out in rewards so far, and allocated by the genesis block
+// Orphans -> Blocks received whose parent the node hasn't seen yet
+// Rules -> Consensus rules the chain can turn on, and whether they're on for the next block
+type ChainInfo struct {
+	Height            int             `json:"height"`
+	BestBlockHash     string          `json:"bestBlockHash"`
+	ChainWork         string          `json:"chainWork"`
+	Difficulty        int             `json:"difficulty"`
+	Reward            int             `json:"reward"`
+	NextHalvingHeight int             `json:"nextHalvingHeight"`
+	Supply            int             `json:"supply"`
+	Orphans           int             `json:"orphans"`
+	Rules             []ConsensusRule `json:"rules"`
+	Params            ChainParams     `json:"params"`
+}
+
+// A consensus rule, the height it turns on at and whether it's on
+type ConsensusRule struct {
+	Name             string `json:"name"`
+	Description      string `json:"description"`
+	ActivationHeight int    `json:"activationHeight"`
+	Active           bool   `json:"active"`
 }
@@ -0,0 +1,34 @@
+package representations
+
+// Something happening on the chain that operators should hear about, as it's POSTed to webhooks
+// Type -> What happened, e.g. deep_reorg
+// Data -> Details, depending on the type
+type ChainEvent struct {
+	Type      string      `json:"type"`
+	Timestamp int64       `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+// A reorganization taking more blocks off the chain than the alert threshold, which can mean a 51% attack or a
+// network partition healing
+// Depth -> Blocks taken off the chain
+// Disconnected -> Hex hashes of the blocks taken off, oldest first
+type ReorgEvent struct {
+	ForkHeight   int      `json:"forkHeight"`
+	Depth        int      `json:"depth"`
+	Threshold    int      `json:"threshold"`
+	OldTip       string   `json:"oldTip"`
+	NewTip       string   `json:"newTip"`
+	Disconnected []string `json:"disconnected"`
+}
+
+// Counters of what the node has seen since it started
+// DeepReorgs -> Reorgs deeper than the alert threshold
+// WebhookFailures -> Events a webhook couldn't be reached for, or refused
+type Metrics struct {
+	Reorgs          int `json:"reorgs"`
+	DeepReorgs      int `json:"deepReorgs"`
+	DeepestReorg    int `json:"deepestReorg"`
+	EventsSent      int `json:"eventsSent"`
+	WebhookFailures int `json:"webhookFailures"`
+}
@@ -0,0 +1,13 @@
+package representations
+
+// Suggested fee rates, in coins per 1000 bytes of signed transaction
+// Blocks and Samples -> How many recent blocks were looked at, and how many of their transactions
+// MempoolDepth -> How many transactions are waiting to be mined
+type FeeEstimate struct {
+	Low          int `json:"low"`
+	Medium       int `json:"medium"`
+	High         int `json:"high"`
+	Blocks       int `json:"blocks"`
+	Samples      int `json:"samples"`
+	MempoolDepth int `json:"mempoolDepth"`
+}
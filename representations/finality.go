@@ -0,0 +1,50 @@
+package representations
+
+// Format of payload when voting for a block on a BFT chain. Either Address, a validator with a wallet on this node
+// that signs the vote, or the PublicKey, SigAlgorithm and Signature of a vote signed elsewhere, keys and signature
+// hex encoded
+type VoteInput struct {
+	Address      string `json:"address"`
+	PublicKey    string `json:"publicKey"`
+	SigAlgorithm string `json:"sigAlgorithm"`
+	Signature    string `json:"signature"`
+}
+
+// A validator's vote for a block on a BFT chain, stored alongside the block. A validator votes for one block at each height
+// Validator -> Address of the validator, which PublicKey hashes to
+// Signature -> Of the block's hash, prefixed so it can't double as any other signature
+type BlockVote struct {
+	ID           string `json:"id" gorm:"primary_key;type:char(36)"`
+	BlockID      string `json:"blockId" gorm:"index"`
+	BlockHash    []byte `json:"blockHash"`
+	Height       int    `json:"height" gorm:"index"`
+	Validator    string `json:"validator" gorm:"index"`
+	PublicKey    []byte `json:"publicKey"`
+	SigAlgorithm string `json:"sigAlgorithm"`
+	Signature    []byte `json:"signature"`
+	Timestamp    int64  `json:"timestamp"`
+}
+
+// BlockVote with keys, hashes and signature hex encoded
+type ReadableBlockVote struct {
+	Validator    string `json:"validator"`
+	PublicKey    string `json:"publicKey"`
+	SigAlgorithm string `json:"sigAlgorithm"`
+	Signature    string `json:"signature"`
+	Timestamp    int64  `json:"timestamp"`
+}
+
+// The votes a block has, and whether they finalize it
+// Validators and Quorum -> How many validators the chain has, and how many votes finalize a block: more than two
+// thirds of them, i.e. 2f+1 out of 3f+1
+// Final -> Whether the block has a quorum of votes, or a block after it does. A final block can't be reorganized away
+type BlockFinality struct {
+	BlockID    string              `json:"blockId"`
+	Hash       string              `json:"hash"`
+	Height     int                 `json:"height"`
+	Round      int                 `json:"round"`
+	Validators int                 `json:"validators"`
+	Quorum     int                 `json:"quorum"`
+	Final      bool                `json:"final"`
+	Votes      []ReadableBlockVote `json:"votes"`
+}
@@ -0,0 +1,25 @@
+package representations
+
+// What a new chain starts out with, read from a JSON or YAML file when the genesis block is mined
+// ChainID -> Name of the chain, stored with its params
+// Difficulty -> Leading zero bits the genesis block's hash needs, and blocks after it until the first retarget. 0 means the node's default
+// BlockInterval -> Seconds blocks should come apart, which retargeting steers towards. 0 means the node's default
+// Message -> Data put in the genesis coinbase's input
+// Allocations -> Coins the genesis coinbase pays out on top of the reward, e.g. to fund accounts on a test network
+// Validators -> Addresses of the validators of a BFT chain
+// Activations -> Height each consensus rule turns on at, by rule name. Rules left out are on from the genesis block
+type GenesisSpec struct {
+	ChainID       string              `json:"chainId" yaml:"chainId"`
+	Difficulty    int                 `json:"difficulty" yaml:"difficulty"`
+	BlockInterval int                 `json:"blockInterval" yaml:"blockInterval"`
+	Message       string              `json:"message" yaml:"message"`
+	Allocations   []GenesisAllocation `json:"allocations" yaml:"allocations"`
+	Validators    []string            `json:"validators" yaml:"validators"`
+	Activations   map[string]int      `json:"activations" yaml:"activations"`
+}
+
+// Coins paid to an address by the genesis block
+type GenesisAllocation struct {
+	Address string `json:"address" yaml:"address"`
+	Amount  int    `json:"amount" yaml:"amount"`
+}
@@ -0,0 +1,43 @@
+package representations
+
+// On-disk format of the encrypted wallet file.
+// Salt, ScryptN, ScryptR, ScryptP -> Parameters used to derive the encryption key from the passphrase
+// Check -> A known value sealed with the derived key, used to tell if a passphrase is correct
+// Keys -> Private keys sealed with AES-GCM, one per wallet address
+// Seeds -> HD wallet seeds sealed the same way, keyed by HD wallet id
+type Keystore struct {
+	Version int            `json:"version"`
+	Salt    []byte         `json:"salt"`
+	ScryptN int            `json:"scryptN"`
+	ScryptR int            `json:"scryptR"`
+	ScryptP int            `json:"scryptP"`
+	Check   EncryptedKey   `json:"check"`
+	Keys    []EncryptedKey `json:"keys"`
+	Seeds   []EncryptedKey `json:"seeds,omitempty"`
+}
+
+type EncryptedKey struct {
+	Address    string `json:"address,omitempty"`
+	HDWalletID string `json:"hdWalletId,omitempty"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Encrypted archive of every wallet on a node, sealed with a key derived from the wallet file passphrase.
+// Version -> Format of the sealed contents, so backups made by older versions can still be restored
+// Salt, ScryptN, ScryptR, ScryptP -> Parameters used to derive the encryption key from the passphrase
+type WalletBackup struct {
+	Version    int    `json:"version"`
+	Salt       []byte `json:"salt"`
+	ScryptN    int    `json:"scryptN"`
+	ScryptR    int    `json:"scryptR"`
+	ScryptP    int    `json:"scryptP"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Format of payload when restoring wallets. Passphrase is the wallet file passphrase of the node the backup was made on
+type RestoreWalletsInput struct {
+	Backup     WalletBackup `json:"backup" binding:"required"`
+	Passphrase string       `json:"passphrase"`
+}
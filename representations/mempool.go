@@ -0,0 +1,130 @@
+package representations
+
+// A transaction waiting in the mempool to be mined
+// Data -> The transaction serialized as JSON, which is what's persisted. Transaction is only filled in memory
+// Size -> Bytes of the serialized transaction, for its fee rate
+// AddedAt -> When it entered the mempool, in unix milliseconds
+type MempoolEntry struct {
+	TxnID       string      `json:"txnId" gorm:"primary_key"`
+	Data        []byte      `json:"-"`
+	Fee         int         `json:"fee"`
+	Size        int         `json:"size"`
+	AddedAt     int64       `json:"addedAt"`
+	Transaction Transaction `json:"-" gorm:"-"`
+}
+
+// FeeRate -> Fee per 1000 bytes, rounded down
+// AddedAt and Waiting -> When it entered the mempool in unix milliseconds, and for how many seconds it's waited since
+type ReadableMempoolEntry struct {
+	Transaction ReadableTransaction `json:"transaction"`
+	Fee         int                 `json:"fee"`
+	Size        int                 `json:"size"`
+	FeeRate     int                 `json:"feeRate"`
+	AddedAt     int64               `json:"addedAt"`
+	Waiting     int64               `json:"waiting"`
+}
+
+// Count, TotalFees and TotalSize -> What's pending right now
+// MaxSize and TTL -> Most transactions held at once, and how many seconds one can wait before it's evicted
+// Expired, EvictedForSpace and Replaced -> Transactions evicted since the node started, for waiting too long,
+// for paying the lowest fee rate when the mempool was full, and for being replaced by a higher fee
+type MempoolStats struct {
+	Count           int   `json:"count"`
+	TotalFees       int   `json:"totalFees"`
+	TotalSize       int   `json:"totalSize"`
+	MaxSize         int   `json:"maxSize"`
+	TTL             int64 `json:"ttl"`
+	Expired         int   `json:"expired"`
+	EvictedForSpace int   `json:"evictedForSpace"`
+	Replaced        int   `json:"replaced"`
+}
+
+// Records that a pending transaction was evicted from the mempool for one paying a higher fee
+// ReplacedAt -> In unix milliseconds
+type MempoolReplacement struct {
+	TxnID      string `json:"txnId" gorm:"primary_key"`
+	ReplacedBy string `json:"replacedBy"`
+	ReplacedAt int64  `json:"replacedAt"`
+}
+
+// Format of payload when submitting a transaction to the mempool. Either send to a single address
+// with To and Amount, or to several at once with Recipients
+type CreateTransactionInput struct {
+	From       string      `json:"from" binding:"required"`
+	To         string      `json:"to"`
+	Amount     int         `json:"amount"`
+	Recipients []Recipient `json:"recipients" binding:"omitempty,dive"`
+	TxnOptions
+}
+
+// Format of payload when submitting a transaction signed elsewhere. Raw is the transaction
+// in the JSON form the node stores it in, hex or base64 encoded
+type RawTransactionInput struct {
+	Raw string `json:"raw" binding:"required"`
+}
+
+// Format of payload when mining the mempool into a block
+// CoinbaseMessage -> Carried in the block's coinbase, in place of the node's own message
+type MineInput struct {
+	Miner           string `json:"miner" binding:"required"`
+	CoinbaseMessage string `json:"coinbaseMessage"`
+}
+
+// Status -> One of pending, confirmed, replaced or not_found
+// BlockHash, Height and Confirmations -> Block the transaction is on, and how many blocks are built on top of it. Only set when confirmed
+// ReplacedBy -> Pending transaction that replaced it for a higher fee. Only set when replaced
+type TxnStatus struct {
+	TxnID         string `json:"txnId"`
+	Status        string `json:"status"`
+	ReplacedBy    string `json:"replacedBy,omitempty"`
+	BlockHash     string `json:"blockHash,omitempty"`
+	Height        int    `json:"height"`
+	Confirmations int    `json:"confirmations"`
+}
+
+const (
+	TxnPending   = "pending"
+	TxnConfirmed = "confirmed"
+	TxnReplaced  = "replaced"
+	TxnNotFound  = "not_found"
+)
+
+// One payment in a batch
+type Transfer struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Amount int    `json:"amount"`
+}
+
+// Format of payload when submitting several transfers at once. Transfers from the same address
+// are combined into one transaction, and TxnOptions applies to each of those
+type CreateBatchInput struct {
+	Transfers []Transfer `json:"transfers" binding:"required,min=1"`
+	TxnOptions
+}
+
+// Outcome of one transfer in a batch
+// Index -> Position of the transfer in the batch
+// TxnID -> Transaction the transfer went out in, only set on success
+type BatchResult struct {
+	Index   int    `json:"index"`
+	Success bool   `json:"success"`
+	TxnID   string `json:"txnId,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Format of payload when validating a signed transaction without submitting it. The transaction
+// is in the same JSON form the node stores it in
+type ValidateTransactionInput struct {
+	Transaction Transaction `json:"transaction" binding:"required"`
+}
+
+// Outcome of validating a transaction without submitting it
+// Consumed -> Outputs its inputs would spend
+type TxnValidation struct {
+	TxnID    string         `json:"txnId"`
+	Valid    bool           `json:"valid"`
+	Fee      int            `json:"fee"`
+	Size     int            `json:"size"`
+	Consumed []OutputStatus `json:"consumed"`
+}
@@ -2,10 +2,14 @@ package representations
 
 import (
 	"crypto/sha256"
+	"errors"
 
 	log "github.com/sirupsen/logrus"
 )
 
+// A merkle tree needs a leaf to have a root
+var ErrEmptyMerkleTree = errors.New("can't build a merkle tree from no transactions")
+
 type MerkleTree struct {
 	Root *MerkleNode
 }
@@ -35,13 +39,12 @@ func NewMerkleNode(left, right *MerkleNode, data []byte) *MerkleNode {
 }
 
 // Data is a list of transactions
-func NewMerkleTree(txns [][]byte) *MerkleTree {
+func NewMerkleTree(txns [][]byte) (*MerkleTree, error) {
 	log.Info("Creating new merkle tree")
-	merkleNodes := make([]*MerkleNode, 0)
-	// If number of transactions is odd, make a copy of the last transaction and append it to the transactions to satisfy merkle tree structure
-	if len(txns)%2 != 0 {
-		txns = append(txns, txns[len(txns)-1])
+	if len(txns) == 0 {
+		return nil, ErrEmptyMerkleTree
 	}
+	merkleNodes := make([]*MerkleNode, 0)
 
 	// Create a leaf merkle tree node for each transaction
 	for _, txn := range txns {
@@ -49,9 +52,10 @@ func NewMerkleTree(txns [][]byte) *MerkleTree {
 		merkleNodes = append(merkleNodes, merkleNode)
 	}
 
-	// Build merkle tree from bottom up
+	// Build merkle tree from bottom up, until the root is all that's left. A single leaf is paired with itself
 	// e.g. 4 leafs = 7 nodes = 3 levels
-	for i := 0; i < len(txns)/2; i++ {
+	for len(merkleNodes) > 1 || merkleNodes[0].Left == nil {
+		merkleNodes = padLevel(merkleNodes)
 		treeLevel := make([]*MerkleNode, 0)
 
 		for j := 0; j < len(merkleNodes); j += 2 {
@@ -62,5 +66,49 @@ func NewMerkleTree(txns [][]byte) *MerkleTree {
 		merkleNodes = treeLevel
 	}
 
-	return &MerkleTree{merkleNodes[0]}
+	return &MerkleTree{merkleNodes[0]}, nil
+}
+
+// If number of nodes on a level is odd, make a copy of the last one to satisfy merkle tree structure
+func padLevel(merkleNodes []*MerkleNode) []*MerkleNode {
+	if len(merkleNodes)%2 != 0 {
+		return append(merkleNodes, merkleNodes[len(merkleNodes)-1])
+	}
+	return merkleNodes
+}
+
+// A node hashed with the path from a leaf to the root. Left is whether it goes on the left of the path so far
+type MerkleProofStep struct {
+	Hash []byte
+	Left bool
+}
+
+// Siblings on the path from the leaf for txns[index] up to the root of the tree NewMerkleTree builds from txns
+func NewMerkleProof(txns [][]byte, index int) ([]MerkleProofStep, error) {
+	if len(txns) == 0 {
+		return nil, ErrEmptyMerkleTree
+	}
+	merkleNodes := make([]*MerkleNode, 0)
+	for _, txn := range txns {
+		merkleNodes = append(merkleNodes, NewMerkleNode(nil, nil, txn))
+	}
+
+	proof := make([]MerkleProofStep, 0)
+	for len(merkleNodes) > 1 || merkleNodes[0].Left == nil {
+		merkleNodes = padLevel(merkleNodes)
+		if index%2 == 0 {
+			proof = append(proof, MerkleProofStep{Hash: merkleNodes[index+1].Data, Left: false})
+		} else {
+			proof = append(proof, MerkleProofStep{Hash: merkleNodes[index-1].Data, Left: true})
+		}
+
+		treeLevel := make([]*MerkleNode, 0)
+		for j := 0; j < len(merkleNodes); j += 2 {
+			treeLevel = append(treeLevel, NewMerkleNode(merkleNodes[j], merkleNodes[j+1], nil))
+		}
+		merkleNodes = treeLevel
+		index /= 2
+	}
+
+	return proof, nil
 }
@@ -0,0 +1,13 @@
+package representations
+
+// Format of payload when signing a message with a wallet's key
+type SignMessageInput struct {
+	Message string `json:"message" binding:"required"`
+}
+
+// Format of payload when verifying a signed message. Signature is as returned when the message was signed
+type VerifyMessageInput struct {
+	Address   string `json:"address" binding:"required"`
+	Message   string `json:"message" binding:"required"`
+	Signature string `json:"signature" binding:"required"`
+}
@@ -0,0 +1,66 @@
+package representations
+
+const (
+	MinerRunning = "running"
+	MinerPaused  = "paused"
+	MinerStopped = "stopped"
+)
+
+// Format of payload when starting the background miner, or changing who it pays
+// Miner -> Address the coinbase of every block it mines pays
+type MinerInput struct {
+	Miner string `json:"miner" binding:"required"`
+}
+
+// What the background miner is up to
+// Status -> running, paused or stopped
+// StartedAt -> When it was last started, unix ms
+// PausedAt -> When it was paused, unix ms, while it's paused
+// BlocksMined -> Blocks mined since it was last started
+// LastBlockHash, LastBlockHeight and LastBlockAt -> Last block it mined, and when
+// LastError -> Why its last attempt at a block failed, if it did. Cleared by the next block
+// Workers and DutyCycle -> Goroutines it searches for nounces with, and the percent of the time they spend hashing
+type MinerStatus struct {
+	Status          string `json:"status"`
+	Miner           string `json:"miner,omitempty"`
+	StartedAt       int64  `json:"startedAt,omitempty"`
+	PausedAt        int64  `json:"pausedAt,omitempty"`
+	BlocksMined     int    `json:"blocksMined"`
+	LastBlockHash   string `json:"lastBlockHash,omitempty"`
+	LastBlockHeight int    `json:"lastBlockHeight"`
+	LastBlockAt     int64  `json:"lastBlockAt,omitempty"`
+	LastError       string `json:"lastError,omitempty"`
+	Workers         int    `json:"workers"`
+	DutyCycle       int    `json:"dutyCycle"`
+}
+
+// A block to be solved by a miner elsewhere. Its hash is taken with HashAlgorithm over HeaderPrefix followed by the
+// nounce in decimal digits, and has to be under Target. HeaderPrefix is the version, merkle root, previous hash,
+// timestamp, difficulty and height, numbers in decimal digits, followed by the chain id on chains with replay
+// protection, so it changes if the timestamp is changed when submitting
+// TemplateID -> Passed back along with the nounce when submitting the solved block
+// HashAlgorithm -> sha256, sha256d or blake2b, as the chain params say
+// CoinbaseValue -> What the coinbase, the first of transactions, pays the miner: the reward plus fees
+type BlockTemplate struct {
+	TemplateID    string                `json:"templateId"`
+	Height        int                   `json:"height"`
+	PrevHash      string                `json:"prevHash"`
+	Timestamp     int64                 `json:"timestamp"`
+	Difficulty    int                   `json:"difficulty"`
+	Version       int                   `json:"version"`
+	ChainID       string                `json:"chainId,omitempty"`
+	Target        string                `json:"target"`
+	HashAlgorithm string                `json:"hashAlgorithm"`
+	MerkleRoot    string                `json:"merkleRoot"`
+	HeaderPrefix  string                `json:"headerPrefix"`
+	CoinbaseValue int                   `json:"coinbaseValue"`
+	Transactions  []ReadableTransaction `json:"transactions"`
+}
+
+// Format of payload when submitting a solved block template
+// Timestamp -> Replaces the template's when set, unix ms
+type SubmitBlockInput struct {
+	TemplateID string `json:"templateId" binding:"required"`
+	Nounce     int64  `json:"nounce"`
+	Timestamp  int64  `json:"timestamp"`
+}
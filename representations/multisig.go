@@ -0,0 +1,56 @@
+package representations
+
+// An M-of-N multisig address. Outputs sent to it are locked with the hash of the redeem script,
+// and spending them takes RequiredSigs signatures from the keys in PublicKeys
+// RedeemScript -> M, N and the N public keys. Spending inputs reveal it in place of a single public key
+type MultisigAddress struct {
+	ID           string   `json:"id" gorm:"primary_key"`
+	Address      string   `json:"address" gorm:"unique"`
+	RequiredSigs int      `json:"requiredSigs"`
+	RedeemScript string   `json:"redeemScript"`
+	PublicKeys   []string `json:"publicKeys" gorm:"-"`
+	Balance      int      `json:"balance" gorm:"-"`
+}
+
+// A transaction spending from a multisig address, collecting signatures until it can be broadcast
+// ID -> Hex id of the transaction. Adding signatures doesn't change it
+// Status -> One of pending, ready or broadcast
+// RawTxn -> The transaction with the signatures collected so far
+type MultisigTransaction struct {
+	ID           string              `json:"id" gorm:"primary_key"`
+	Address      string              `json:"address"`
+	Status       string              `json:"status"`
+	Signatures   int                 `json:"signatures"`
+	RequiredSigs int                 `json:"requiredSigs"`
+	Signers      []string            `json:"signers" gorm:"-"`
+	RawTxn       []byte              `json:"-"`
+	Transaction  ReadableTransaction `json:"transaction" gorm:"-"`
+}
+
+const (
+	MultisigPending   = "pending"
+	MultisigReady     = "ready"
+	MultisigBroadcast = "broadcast"
+)
+
+// Format of payload when creating a multisig address. PublicKeys are hex, as shown on wallets
+type CreateMultisigInput struct {
+	RequiredSigs int      `json:"requiredSigs" binding:"required"`
+	PublicKeys   []string `json:"publicKeys" binding:"required"`
+}
+
+// Format of payload when spending from a multisig address
+type CreateMultisigTxnInput struct {
+	To     string `json:"to" binding:"required"`
+	Amount int    `json:"amount" binding:"required"`
+}
+
+// Format of payload when adding a signature. Signer is the address of a wallet on this node holding one of the keys
+type SignMultisigTxnInput struct {
+	Signer string `json:"signer" binding:"required"`
+}
+
+// Format of payload when broadcasting a fully signed multisig transaction. Miner gets the block reward
+type BroadcastMultisigTxnInput struct {
+	Miner string `json:"miner" binding:"required"`
+}
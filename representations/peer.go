@@ -0,0 +1,101 @@
+package representations
+
+import "encoding/json"
+
+// Envelope of every message peers exchange over their connection, sent one JSON object per line
+// Command -> What the message is, e.g. version or inv
+// Payload -> Depends on the command: a block for block, a transaction for tx, and the messages below for the rest
+type PeerMessage struct {
+	Command string          `json:"command"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// Sent by each side as soon as they connect, before anything else
+// Version -> Of the protocol the sender speaks
+// ChainID and GenesisHash -> Chain the sender is on. Peers on another chain are dropped. GenesisHash is empty on a
+// node without a genesis block, e.g. one started from a snapshot
+// Height and BestHash -> Of the sender's last block. Height is -1 on a node without a chain
+// ListenPort -> Port the sender takes connections from peers on, 0 if it doesn't
+// Nonce -> Random number the sender picked when it started, so a node that connected to itself can tell
+type VersionMessage struct {
+	Version     int    `json:"version"`
+	ChainID     string `json:"chainId"`
+	GenesisHash []byte `json:"genesisHash"`
+	Height      int    `json:"height"`
+	BestHash    []byte `json:"bestHash"`
+	ListenPort  int    `json:"listenPort"`
+	Nonce       uint64 `json:"nonce"`
+}
+
+// Asks for the headers of the blocks after the last one both peers have on their chain
+// Locator -> Hashes of blocks on the sender's chain, from its last block back towards its first, one after the other
+// at first and exponentially further apart after that. May start with the last header the sender was sent, to carry on
+// from there
+type GetHeadersMessage struct {
+	Locator [][]byte `json:"locator"`
+}
+
+// Headers of blocks on the sender's chain, in answer to getheaders
+// Headers -> Blocks without their transactions, lowest first
+type HeadersMessage struct {
+	Headers []Block `json:"headers"`
+}
+
+// Announces blocks or transactions by hash with inv, asks for them with getdata, or says they aren't there with notfound
+// Type -> block or tx
+type InvMessage struct {
+	Type   string   `json:"type"`
+	Hashes [][]byte `json:"hashes"`
+}
+
+// A peer the node is connected to
+// Address -> Host and port of the other end of the connection
+// Inbound -> Whether the peer connected to this node, rather than the other way around
+// Version, Height and ListenPort -> From the peer's version message, with Height going up as it sends blocks. 0 until
+// the peer sends it
+// ConnectedAt and LastSeen -> When the connection was made and when the peer last sent a message, in unix millis
+type Peer struct {
+	ID          string `json:"id"`
+	Address     string `json:"address"`
+	Inbound     bool   `json:"inbound"`
+	Version     int    `json:"version"`
+	Height      int    `json:"height"`
+	ListenPort  int    `json:"listenPort"`
+	ConnectedAt int64  `json:"connectedAt"`
+	LastSeen    int64  `json:"lastSeen"`
+}
+
+// Format of payload when connecting to a peer
+type ConnectPeerInput struct {
+	Address string `json:"address" binding:"required"`
+}
+
+// Addresses of other nodes, in answer to getaddr
+// Addresses -> Host and port each takes connections from peers on
+type AddrMessage struct {
+	Addresses []string `json:"addresses"`
+}
+
+// Where the node is in catching up with its peers
+// Syncing -> Whether it's downloading blocks from a peer that's ahead of it
+// Height -> Of the node's last block, -1 without a chain
+// TargetHeight -> Of the best block any peer is known to have. The node is caught up once it gets there
+// Progress -> Percent of the blocks up to TargetHeight the node has
+// SyncPeer, StartHeight and StartedAt -> Id of the peer headers are downloaded from, and the height and unix millis
+// the sync started at
+// HeadersDownloaded and BlocksDownloaded -> Downloaded since
+// PendingBlocks -> Of the headers downloaded, how many blocks aren't in yet
+// DownloadPeers -> Peers blocks are being downloaded from right now
+type SyncStatus struct {
+	Syncing           bool    `json:"syncing"`
+	Height            int     `json:"height"`
+	TargetHeight      int     `json:"targetHeight"`
+	Progress          float64 `json:"progress"`
+	SyncPeer          string  `json:"syncPeer,omitempty"`
+	StartHeight       int     `json:"startHeight,omitempty"`
+	StartedAt         int64   `json:"startedAt,omitempty"`
+	HeadersDownloaded int     `json:"headersDownloaded"`
+	BlocksDownloaded  int     `json:"blocksDownloaded"`
+	PendingBlocks     int     `json:"pendingBlocks"`
+	DownloadPeers     int     `json:"downloadPeers"`
+}
@@ -0,0 +1,43 @@
+package representations
+
+// Statuses of a schedule
+const (
+	ScheduleActive    = "active"
+	ScheduleCancelled = "cancelled"
+)
+
+// A payment the node makes on its own, over and over
+// Interval -> How often it's made: @every and a duration such as 36h, or @hourly, @daily or @weekly
+// CreatedAt, NextRunAt and LastRunAt -> Unix milliseconds. LastRunAt is 0 until the first run
+// Runs -> How many times the payment was made
+// LastTxnID and LastError -> Hex id of the transaction the last run queued, or why it failed
+type Schedule struct {
+	ID        string `json:"id" gorm:"primary_key"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Amount    int    `json:"amount"`
+	Fee       int    `json:"fee"`
+	FeeRate   int    `json:"feeRate"`
+	Memo      string `json:"memo"`
+	Interval  string `json:"interval"`
+	Status    string `json:"status" enums:"active,cancelled"`
+	CreatedAt int64  `json:"createdAt"`
+	NextRunAt int64  `json:"nextRunAt"`
+	LastRunAt int64  `json:"lastRunAt"`
+	Runs      int    `json:"runs"`
+	LastTxnID string `json:"lastTxnId"`
+	LastError string `json:"lastError"`
+}
+
+// Format of payload when scheduling a recurring payment
+// StartAt -> Unix milliseconds of the first payment. 0 means one interval from now
+type ScheduleInput struct {
+	From     string `json:"from" binding:"required"`
+	To       string `json:"to" binding:"required"`
+	Amount   int    `json:"amount" binding:"required"`
+	Interval string `json:"interval" binding:"required" example:"@every 24h"`
+	StartAt  int64  `json:"startAt"`
+	Fee      int    `json:"fee"`
+	FeeRate  int    `json:"feeRate"`
+	Memo     string `json:"memo"`
+}
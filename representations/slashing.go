@@ -0,0 +1,41 @@
+package representations
+
+// Proof that a validator signed two different blocks at the same height, which nothing but a faulty or dishonest
+// validator does
+// Kind -> proposal when it signed both blocks as their proposer, vote when it voted for both on a BFT chain
+// PublicKey and SigAlgorithm -> Key of the validator, and the scheme it signs with
+// First and Second -> Headers of the two blocks, without their transactions
+// FirstSig and SecondSig -> The validator's signatures over each block, as a proposal or as a vote
+type Equivocation struct {
+	Kind         string `json:"kind"`
+	PublicKey    []byte `json:"publicKey"`
+	SigAlgorithm string `json:"sigAlgorithm"`
+	First        Block  `json:"first"`
+	FirstSig     []byte `json:"firstSig"`
+	Second       Block  `json:"second"`
+	SecondSig    []byte `json:"secondSig"`
+}
+
+// Equivocation the node caught a validator in, and what came of it
+// ID -> Hex hash of the kind, validator key and the two blocks' hashes, the same whichever block came first
+// Offender -> Address of the validator
+// FirstHash and SecondHash -> Hex hashes of the two blocks it signed
+// Proof -> The equivocation as JSON, as carried by the transaction slashing the offender
+// TxnID -> Hex id of the transaction slashing the offender's stake. Empty when it had nothing to slash
+// Stake -> Coins the transaction burns
+// Status -> recorded when there was nothing to slash, pending until the transaction is on the chain, then slashed
+// BlockID -> Block the transaction is on, once it's slashed
+type Evidence struct {
+	ID         string `json:"id" gorm:"primary_key"`
+	Kind       string `json:"kind"`
+	Height     int    `json:"height"`
+	Offender   string `json:"offender" gorm:"index"`
+	FirstHash  string `json:"firstHash"`
+	SecondHash string `json:"secondHash"`
+	Proof      []byte `json:"-"`
+	TxnID      string `json:"txnId,omitempty"`
+	Stake      int    `json:"stake"`
+	ReportedAt int64  `json:"reportedAt"`
+	Status     string `json:"status" gorm:"-"`
+	BlockID    string `json:"blockId,omitempty" gorm:"-"`
+}
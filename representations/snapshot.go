@@ -0,0 +1,30 @@
+package representations
+
+// A trusted point a new node can start its chain from instead of the genesis block, so it only has to validate the
+// blocks after it
+// Hash and Height -> Of the block the snapshot is taken at, the last of Headers
+// Headers -> Blocks up to and including that block, oldest first and without transactions, which validating the
+// blocks after it looks back at
+// UnspentOutputs -> The UTXO set as of that block
+// UTXOHash -> Hex hash of UnspentOutputs, so a snapshot that got corrupted on its way is caught
+// ValidatorChanges -> Changes made to the validator set of a BFT chain up to that block
+type ChainSnapshot struct {
+	Hash             string                  `json:"hash"`
+	Height           int                     `json:"height"`
+	Params           ChainParams             `json:"params"`
+	Headers          []Block                 `json:"headers"`
+	UnspentOutputs   []UnspentOutput         `json:"unspentOutputs"`
+	UTXOHash         string                  `json:"utxoHash"`
+	ValidatorChanges []ValidatorChangeRecord `json:"validatorChanges,omitempty"`
+}
+
+// The snapshot a node's chain was started from. Stored once it's loaded, since the UTXO set is rebuilt from its
+// outputs instead of from the genesis block
+// Outputs -> The snapshot's UTXO set, JSON encoded
+type SnapshotBase struct {
+	Hash     string `json:"hash" gorm:"primary_key"`
+	Height   int    `json:"height"`
+	UTXOHash string `json:"utxoHash"`
+	Outputs  []byte `json:"-"`
+	LoadedAt int64  `json:"loadedAt"`
+}
@@ -0,0 +1,33 @@
+package representations
+
+// Format of payload when bonding or unbonding stake
+// Amount -> Coins to bond or unbond. Unbonding 0 unbonds all of the address's stake
+type StakeInput struct {
+	Address string `json:"address" binding:"required"`
+	Amount  int    `json:"amount"`
+	TxnOptions
+}
+
+// Entry in the staking index: coins an address has bonded as stake on a proof of stake chain. Kept up to date as
+// blocks are added, so electing a block's proposer doesn't have to scan the UTXO set
+// PubKeyHash -> Hex encoded
+// Address and Share -> Filled in when handed out, not stored. Share is the fraction of all stake that's its
+// Bonded -> Coins staked, which its odds of being elected to produce a block are in proportion to
+// Deposits -> Staked outputs holding them
+type Validator struct {
+	PubKeyHash string  `json:"pubKeyHash" gorm:"primary_key"`
+	Address    string  `json:"address" gorm:"-"`
+	Bonded     int     `json:"bonded"`
+	Deposits   int     `json:"deposits"`
+	Share      float64 `json:"share" gorm:"-"`
+}
+
+// The validators of a proof of stake chain as of its last block, most stake first
+// Height -> Of the last block
+// NextProposer -> Address elected to produce the next block. Empty while nothing is staked, when anyone can
+type ValidatorSet struct {
+	Height       int         `json:"height"`
+	TotalBonded  int         `json:"totalBonded"`
+	NextProposer string      `json:"nextProposer"`
+	Validators   []Validator `json:"validators"`
+}
@@ -1,22 +1,43 @@
 package representations
 
+import "encoding/hex"
+
 // import "github.com/google/uuid"
 
 // ID -> Unique id of this transaction
 // BlockID -> Which block is this transaction in?
 // Inputs and Outputs -> In both these tables, curr_txn_id is equal to id of transaction. This helps us to track which transaction did these inputs and outputs come from
+// SigAlgorithm -> Signature scheme the inputs are signed with. Empty on coinbase transactions and ones signed before it existed, which are ECDSA
+// Fee -> What the inputs hold beyond the outputs, paid to whoever mines the transaction
+// Memo -> Optional data the sender attached. It's part of what's hashed into the id and signed
+// LockTime -> Earliest block the transaction can be mined into. Below LockTimeThreshold it's a block height, otherwise a unix time in seconds. 0 means no lock
+// Replaceable -> Whether, while pending, it can be replaced by a transaction spending the same inputs for a higher fee
+// Evidence -> Equivocation, as JSON, of the validator whose stake the transaction slashes. Only set on slashing transactions
+// ValidatorChange -> Change to the validator set of a BFT chain, as JSON. Only set on transactions making one
 type Transaction struct {
-	ID      []byte      `json:"txnId" gorm:"primary_key"`
-	BlockID string      `json:"blockId"`
-	Inputs  []TxnInput  `json:"txnInputs" gorm:"foreignKey:CurrTxnID;association_foreignkey:ID"`
-	Outputs []TxnOutput `json:"txnOutputs" gorm:"foreignKey:CurrTxnID;association_foreignkey:ID"`
+	ID              []byte      `json:"txnId" gorm:"primary_key"`
+	BlockID         string      `json:"blockId"`
+	SigAlgorithm    string      `json:"sigAlgorithm,omitempty"`
+	Fee             int         `json:"fee,omitempty"`
+	Memo            string      `json:"memo,omitempty"`
+	LockTime        int64       `json:"lockTime,omitempty"`
+	Replaceable     bool        `json:"replaceable,omitempty"`
+	Evidence        []byte      `json:"evidence,omitempty"`
+	ValidatorChange []byte      `json:"validatorChange,omitempty"`
+	Inputs          []TxnInput  `json:"txnInputs" gorm:"foreignKey:CurrTxnID;association_foreignkey:ID"`
+	Outputs         []TxnOutput `json:"txnOutputs" gorm:"foreignKey:CurrTxnID;association_foreignkey:ID"`
 }
 
 type ReadableTransaction struct {
-	ID      string              `json:"id"`
-	BlockID string              `json:"blockId"`
-	Inputs  []ReadableTxnInput  `json:"txnInputs"`
-	Outputs []ReadableTxnOutput `json:"txnOutputs"`
+	ID           string              `json:"id"`
+	BlockID      string              `json:"blockId"`
+	SigAlgorithm string              `json:"sigAlgorithm,omitempty"`
+	Fee          int                 `json:"fee"`
+	Memo         string              `json:"memo,omitempty"`
+	LockTime     int64               `json:"lockTime,omitempty"`
+	Replaceable  bool                `json:"replaceable,omitempty"`
+	Inputs       []ReadableTxnInput  `json:"txnInputs"`
+	Outputs      []ReadableTxnOutput `json:"txnOutputs"`
 }
 
 type ReadableTxnInput struct {
@@ -31,6 +52,8 @@ type ReadableTxnOutput struct {
 	CurrTxnID  string `json:"currTxnId"`
 	Value      int    `json:"value"`
 	PubKeyHash string `json:"pubKeyHash"`
+	AssetID    string `json:"assetId,omitempty"`
+	Staked     bool   `json:"staked,omitempty"`
 }
 
 // InputID -> unique id of the TxnInput
@@ -51,7 +74,9 @@ type TxnInput struct {
 
 // OutputID -> Unique id representing the output
 // CurrTxnID -> What transaction is this output currently in?
-// Value -> Stores coins
+// Value -> Stores coins, or units of the asset
+// AssetID -> Asset the output holds units of. Empty for the chain's own coin
+// Staked -> Coins bonded as stake of whoever the output is locked to, which makes them a validator on a proof of stake chain
 // ScriptPubKey -> Value needed to unlock a transaction
 type TxnOutput struct {
 	OutputID string `json:"outputId" gorm:"primary_key"`
@@ -59,5 +84,129 @@ type TxnOutput struct {
 	CurrTxnID  []byte `json:"currTxnId" gorm:"column:curr_txn_id"`
 	Value      int    `json:"value"`
 	PubKeyHash []byte `json:"pubKeyHash"` // locks the output
+	AssetID    string `json:"assetId,omitempty"`
+	Staked     bool   `json:"staked,omitempty"`
 	// ScriptPubKey string `json:"scriptPubKey"`
 }
+
+// Status -> One of unspent, spent or nonexistent
+// SpentByTxnID and SpentInBlockID -> Which transaction (and block) spent this output, only set when spent
+type OutputStatus struct {
+	TxnID          string `json:"txnId"`
+	OutIdx         int    `json:"outIdx"`
+	Status         string `json:"status"`
+	Value          int    `json:"value,omitempty"`
+	PubKeyHash     string `json:"pubKeyHash,omitempty"`
+	AssetID        string `json:"assetId,omitempty"`
+	SpentByTxnID   string `json:"spentByTxnId,omitempty"`
+	SpentInBlockID string `json:"spentInBlockId,omitempty"`
+}
+
+const (
+	OutputUnspent     = "unspent"
+	OutputSpent       = "spent"
+	OutputNonexistent = "nonexistent"
+)
+
+// Optional extras of a new transaction
+// Fee and FeeRate -> How much fee it pays, either exactly in coins or in coins per 1000 bytes of the signed transaction, rounded up. At most one is set, and neither means no fee
+// Memo -> Data anchored on-chain with it, at most MaxMemoLen bytes
+// LockTime -> Block height, or unix time in seconds, before which it can't be mined
+// Replaceable -> Lets it be replaced for a higher fee while it's pending
+// CoinSelection -> Strategy for picking which unspent outputs to spend: all, largest-first, smallest-first or branch-and-bound. The node's default if empty
+type TxnOptions struct {
+	Fee           int    `json:"fee"`
+	FeeRate       int    `json:"feeRate"`
+	Memo          string `json:"memo"`
+	LockTime      int64  `json:"lockTime"`
+	Replaceable   bool   `json:"replaceable"`
+	CoinSelection string `json:"coinSelection" enums:"all,largest-first,smallest-first,branch-and-bound"`
+}
+
+// Transaction index entry: where on the chain a transaction is, so it can be found without scanning blocks
+// TxnID -> Hex id of the transaction
+// Position -> Index of the transaction on its block
+type TxnLocation struct {
+	TxnID     string `json:"txnId" gorm:"primary_key"`
+	BlockID   string `json:"blockId"`
+	BlockHash []byte `json:"blockHash"`
+	Height    int    `json:"height"`
+	Position  int    `json:"position"`
+}
+
+// Transaction index entry for txn, at position on block, which is at height
+func NewTxnLocation(txn Transaction, block Block, height int, position int) TxnLocation {
+	return TxnLocation{
+		TxnID:     hex.EncodeToString(txn.ID),
+		BlockID:   block.ID,
+		BlockHash: block.Hash,
+		Height:    height,
+		Position:  position,
+	}
+}
+
+// A transaction on the chain, with the hash and height of its block
+type ReadableConfirmedTransaction struct {
+	Transaction ReadableTransaction `json:"transaction"`
+	BlockHash   string              `json:"blockHash"`
+	Height      int                 `json:"height"`
+}
+
+// Merkle branch proving a transaction is on a block: the path from the transaction's leaf up to the block's merkle root
+// LeafData -> Hex of what the leaf the proof starts from is the hash of: the transaction id, or the serialized
+// transaction on blocks mined before the merkle root was in the header
+// Proof -> Siblings on the path from the leaf up to MerkleRoot, lowest first. Side says which side of the path each goes on
+type MerkleBranch struct {
+	TxnID      string              `json:"txnId"`
+	BlockHash  string              `json:"blockHash"`
+	Position   int                 `json:"position"`
+	LeafData   string              `json:"leafData"`
+	LeafHash   string              `json:"leafHash"`
+	MerkleRoot string              `json:"merkleRoot"`
+	Proof      []ReadableProofStep `json:"proof"`
+}
+
+// Proof that a transaction is on a block, checkable without the rest of the block
+// Version, PrevHash, Timestamp, Difficulty, Height and Nounce -> With MerkleRoot, what the block hash is taken over
+type TxnReceipt struct {
+	MerkleBranch
+	Height        int    `json:"height"`
+	Confirmations int    `json:"confirmations"`
+	Version       int    `json:"version"`
+	PrevHash      string `json:"prevHash"`
+	Timestamp     int64  `json:"timestamp"`
+	Difficulty    int    `json:"difficulty"`
+	Nounce        int64  `json:"nounce"`
+}
+
+// A step of a merkle proof
+// Side -> left or right
+type ReadableProofStep struct {
+	Hash string `json:"hash"`
+	Side string `json:"side" enums:"left,right"`
+}
+
+// A transaction as seen from one address
+// Height -> Position of the transaction's block in the chain, genesis is 0
+// Direction -> Sent if the address spends any of the transaction's inputs, received otherwise
+// Amount -> Net change to the address's balance, negative when sent
+type AddressTransaction struct {
+	Transaction Transaction
+	BlockHash   []byte
+	Height      int
+	Direction   string
+	Amount      int
+}
+
+type ReadableAddressTransaction struct {
+	Transaction ReadableTransaction `json:"transaction"`
+	BlockHash   string              `json:"blockHash"`
+	Height      int                 `json:"height"`
+	Direction   string              `json:"direction"`
+	Amount      int                 `json:"amount"`
+}
+
+const (
+	DirectionSent     = "sent"
+	DirectionReceived = "received"
+)
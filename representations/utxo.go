@@ -0,0 +1,92 @@
+package representations
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+// An entry in the UTXO set: an output on the chain that no input has spent yet.
+// Kept up to date as blocks are added, so balances and coin selection don't have to scan the chain
+// ID -> Outpoint of the output, see OutpointID
+// PubKeyHash -> Hex encoded, for looking up every unspent output locked to an address
+// Height and Coinbase -> Height of the block the output is on, and whether a coinbase created it, for coinbase maturity
+// AssetID -> Asset the output holds units of, empty for the chain's own coin
+// Staked -> Whether the output is bonded stake, which coin selection leaves alone
+type UnspentOutput struct {
+	ID         string `json:"id" gorm:"primary_key"`
+	TxnID      []byte `json:"txnId"`
+	OutIdx     int    `json:"outIdx"`
+	OutputID   string `json:"outputId"`
+	Value      int    `json:"value"`
+	PubKeyHash string `json:"pubKeyHash" gorm:"index"`
+	BlockID    string `json:"blockId"`
+	Height     int    `json:"height"`
+	Coinbase   bool   `json:"coinbase"`
+	AssetID    string `json:"assetId,omitempty" gorm:"index"`
+	Staked     bool   `json:"staked,omitempty" gorm:"index"`
+}
+
+// Identifies an output by the hex id of its transaction and its index, joined by a colon
+func OutpointID(txnId []byte, outIdx int) string {
+	return fmt.Sprintf("%s:%d", hex.EncodeToString(txnId), outIdx)
+}
+
+// UTXO set entry for output outIdx of txn, which is on block blockId at height
+func NewUnspentOutput(txn Transaction, outIdx int, blockId string, height int) UnspentOutput {
+	output := txn.Outputs[outIdx]
+	coinbase := len(txn.Inputs) == 1 && len(txn.Inputs[0].PrevTxnID) == 0 && txn.Inputs[0].OutIdx == -1
+	return UnspentOutput{
+		ID:         OutpointID(txn.ID, outIdx),
+		TxnID:      txn.ID,
+		OutIdx:     outIdx,
+		OutputID:   output.OutputID,
+		Value:      output.Value,
+		PubKeyHash: hex.EncodeToString(output.PubKeyHash),
+		BlockID:    blockId,
+		Height:     height,
+		Coinbase:   coinbase,
+		AssetID:    output.AssetID,
+		Staked:     output.Staked,
+	}
+}
+
+// The output this entry stands for
+func (uo UnspentOutput) TxnOutput() TxnOutput {
+	pubKeyHash, _ := hex.DecodeString(uo.PubKeyHash)
+	return TxnOutput{
+		OutputID:   uo.OutputID,
+		CurrTxnID:  uo.TxnID,
+		Value:      uo.Value,
+		PubKeyHash: pubKeyHash,
+		AssetID:    uo.AssetID,
+		Staked:     uo.Staked,
+	}
+}
+
+// Format of payload when sweeping an address's small outputs into one
+// MaxValue -> Outputs worth less than this are swept. 0 means the node's default
+// Force -> Consolidate even while the mempool is busy
+type ConsolidateInput struct {
+	Address  string `json:"address" binding:"required"`
+	MaxValue int    `json:"maxValue"`
+	Force    bool   `json:"force"`
+	TxnOptions
+}
+
+// An unspent output of an address, as handed to wallet software building its own transactions
+// TxnID and Vout -> Outpoint to reference in an input: hex id of the transaction that created it and its index there
+// Confirmations -> Blocks on top of the one it's on
+// Staked -> Bonded as stake, so it isn't picked to pay for transactions
+// Spendable -> False for coinbase outputs still waiting on coinbase maturity
+type AddressUnspentOutput struct {
+	TxnID         string `json:"txnId"`
+	Vout          int    `json:"vout"`
+	Value         int    `json:"value"`
+	AssetID       string `json:"assetId,omitempty"`
+	PubKeyHash    string `json:"pubKeyHash"`
+	Height        int    `json:"height"`
+	Confirmations int    `json:"confirmations"`
+	Coinbase      bool   `json:"coinbase"`
+	Staked        bool   `json:"staked,omitempty"`
+	Spendable     bool   `json:"spendable"`
+}
@@ -0,0 +1,84 @@
+package representations
+
+import (
+	"encoding/hex"
+	"encoding/json"
+)
+
+// Format of payload when adding or removing a validator of a BFT chain
+// Address -> Validator to add or remove. Taken from the path when removing
+// Authority -> Address of a validator with a wallet on this node, which signs the change
+// Height -> Block the change takes effect at, which has to come after the next one. A few blocks after the next one without it
+type ValidatorChangeInput struct {
+	Address   string `json:"address"`
+	Authority string `json:"authority" binding:"required"`
+	Height    int    `json:"height"`
+}
+
+// Change to the validator set of a BFT chain, as the transaction making it carries it
+// Action -> add or remove
+// Validator -> Address added or removed
+// Height -> Block the change takes effect at
+// Authority and SigAlgorithm -> Public key of the validator that signed it, and the scheme it signs with. It has to
+// be in the set when the transaction is mined
+// Signature -> Over everything else, for this chain
+type ValidatorChange struct {
+	Action       string `json:"action"`
+	Validator    string `json:"validator"`
+	Height       int    `json:"height"`
+	Authority    []byte `json:"authority"`
+	SigAlgorithm string `json:"sigAlgorithm"`
+	Signature    []byte `json:"signature"`
+}
+
+// Change to the validator set recorded from a transaction on the chain. Kept up to date as blocks are added and
+// taken off, so working out the validators at a height doesn't have to scan the chain
+// TxnID -> Hex id of the transaction making the change
+// Authority -> Hex public key of the validator that signed it
+// BlockID, MinedHeight and Position -> Block the transaction is on, its height and where on it the transaction is
+// AuthorityAddress and Status -> Filled in when handed out, not stored. Status is scheduled until the chain reaches
+// Height, then active
+type ValidatorChangeRecord struct {
+	TxnID            string `json:"txnId" gorm:"primary_key"`
+	Action           string `json:"action"`
+	Validator        string `json:"validator"`
+	Height           int    `json:"height"`
+	Authority        string `json:"authority"`
+	AuthorityAddress string `json:"authorityAddress" gorm:"-"`
+	BlockID          string `json:"blockId"`
+	MinedHeight      int    `json:"minedHeight" gorm:"index"`
+	Position         int    `json:"position"`
+	Status           string `json:"status" gorm:"-"`
+}
+
+// Record of the validator change txn makes, at position on block at height. False if it makes none
+func NewValidatorChangeRecord(txn Transaction, block Block, height int, position int) (ValidatorChangeRecord, bool) {
+	if len(txn.ValidatorChange) == 0 {
+		return ValidatorChangeRecord{}, false
+	}
+
+	var change ValidatorChange
+	if err := json.Unmarshal(txn.ValidatorChange, &change); err != nil {
+		return ValidatorChangeRecord{}, false
+	}
+
+	return ValidatorChangeRecord{
+		TxnID:       hex.EncodeToString(txn.ID),
+		Action:      change.Action,
+		Validator:   change.Validator,
+		Height:      change.Height,
+		Authority:   hex.EncodeToString(change.Authority),
+		BlockID:     block.ID,
+		MinedHeight: height,
+		Position:    position,
+	}, true
+}
+
+// Validators of a BFT chain as of its last block, in the order they take turns, and every change made to them on
+// the chain, in the order they take effect
+// Height -> Of the last block
+type ValidatorSchedule struct {
+	Height     int                     `json:"height"`
+	Validators []string                `json:"validators"`
+	Changes    []ValidatorChangeRecord `json:"changes"`
+}
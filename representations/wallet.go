@@ -1,10 +1,96 @@
 package representations
 
+// HDWalletID and DerivationPath -> Only set for addresses derived from an HD wallet
+// SigAlgorithm -> Signature scheme of the key pair. Empty on wallets created before it existed, which are ECDSA
+// WatchOnly -> Address is tracked without a private key, e.g. cold storage. Never used to sign
+// AccountID -> Account the address is assigned to, if any
 type Wallet struct {
-	ID         string `json:"id,omitempty" gorm:"primary_key"`
-	Address    string `json:"address,omitempty"`
-	PrivateKey []byte `json:"privateKey,omitempty"`
-	PublicKey  string `json:"publicKey,omitempty"`
+	ID             string `json:"id,omitempty" gorm:"primary_key"`
+	Address        string `json:"address,omitempty"`
+	PrivateKey     []byte `json:"privateKey,omitempty"`
+	PublicKey      string `json:"publicKey,omitempty"`
+	SigAlgorithm   string `json:"sigAlgorithm,omitempty"`
+	HDWalletID     string `json:"hdWalletId,omitempty"`
+	DerivationPath string `json:"derivationPath,omitempty"`
+	WatchOnly      bool   `json:"watchOnly,omitempty"`
+	AccountID      string `json:"accountId,omitempty"`
+}
+
+// Format of payload when creating a wallet. SigAlgorithm is ecdsa-p256 (default) or ed25519
+type CreateWalletInput struct {
+	SigAlgorithm string `json:"sigAlgorithm"`
+}
+
+// A key pair that only exists in this response, to print and keep offline. Nothing is stored on the node
+// AddressQR and WIFQR -> PNG QR codes of the address and private key, only set when asked for
+type PaperWallet struct {
+	Address      string `json:"address"`
+	PublicKey    string `json:"publicKey"`
+	SigAlgorithm string `json:"sigAlgorithm"`
+	WIF          string `json:"wif"`
+	AddressQR    []byte `json:"addressQR,omitempty"`
+	WIFQR        []byte `json:"wifQR,omitempty"`
+}
+
+// Format of payload when searching for a vanity address. Prefix includes the network's leading character, e.g. 1 on mainnet.
+// TimeoutSeconds and Workers fall back to the node's defaults when left out
+type CreateVanityWalletInput struct {
+	Prefix         string `json:"prefix" binding:"required"`
+	SigAlgorithm   string `json:"sigAlgorithm"`
+	TimeoutSeconds int    `json:"timeoutSeconds"`
+	Workers        int    `json:"workers"`
+}
+
+// Format of payload when creating a paper wallet
+type CreatePaperWalletInput struct {
+	SigAlgorithm string `json:"sigAlgorithm"`
+	QRCode       bool   `json:"qrCode"`
+}
+
+// Format of payload when importing a public key held by a remote signer. PublicKey is hex encoded
+type ImportPublicKeyInput struct {
+	PublicKey    string `json:"publicKey" binding:"required"`
+	SigAlgorithm string `json:"sigAlgorithm"`
+}
+
+// Format of payload when unlocking the wallet file. TTLSeconds falls back to the node's default when left out
+type UnlockWalletInput struct {
+	Passphrase string `json:"passphrase" binding:"required"`
+	TTLSeconds int    `json:"ttlSeconds"`
+}
+
+// Format of payload when registering a watch-only address
+type WatchAddressInput struct {
+	Address string `json:"address" binding:"required"`
+}
+
+// Format of payload when importing a private key in Wallet Import Format
+type ImportWIFInput struct {
+	WIF string `json:"wif" binding:"required"`
+}
+
+// Hierarchical deterministic wallet. Addresses are derived from a BIP39 seed along BIP44 paths m/44'/coinType'/account'/change/index,
+// where change is 0 for receiving addresses and 1 for change addresses
+// Fingerprint -> Identifies the seed, so recovering the same mnemonic again maps to the same HD wallet
+// NextIndex -> Index of the next receiving address to derive
+// NextChangeIndex -> Index of the next change address to derive
+type HDWallet struct {
+	ID              string `json:"id" gorm:"primary_key"`
+	Fingerprint     string `json:"fingerprint" gorm:"unique"`
+	Account         int    `json:"account"`
+	NextIndex       int    `json:"nextIndex"`
+	NextChangeIndex int    `json:"nextChangeIndex"`
+}
+
+// Format of payload when creating an HD wallet. The passphrase is the optional BIP39 passphrase
+type CreateHDWalletInput struct {
+	Passphrase string `json:"passphrase"`
+}
+
+// Format of payload when recovering an HD wallet from its mnemonic
+type RecoverHDWalletInput struct {
+	Mnemonic   string `json:"mnemonic" binding:"required"`
+	Passphrase string `json:"passphrase"`
 }
 
 // This represents balance information for a wallet (address)
@@ -13,3 +99,16 @@ type AddressBalance struct {
 	PublicKey string `json:"publicKey,omitempty"`
 	Balance   int    `json:"balance"`
 }
+
+// Balance of any address, split by whether it's on the chain yet
+// Confirmed -> Sum of the address's unspent outputs on the chain
+// Pending -> How much unconfirmed transactions add to or, if negative, take away from the confirmed balance
+// Staked -> Coins the address has bonded as stake, which aren't part of the confirmed balance
+// Assets -> The same for each asset the address holds, or has pending
+type AddressBalanceSummary struct {
+	Address   string         `json:"address"`
+	Confirmed int            `json:"confirmed"`
+	Pending   int            `json:"pending"`
+	Staked    int            `json:"staked,omitempty"`
+	Assets    []AssetBalance `json:"assets"`
+}
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"github.com/brucetieu/blockchain/handlers"
+	"github.com/brucetieu/blockchain/node"
 	"github.com/brucetieu/blockchain/repository"
 	"github.com/brucetieu/blockchain/services"
 	"github.com/gin-gonic/gin"
@@ -13,17 +14,70 @@ func InitRoutes(route *gin.Engine) {
 	services.BlockAssembler = services.NewBlockAssemblerFac()
 	services.TxnAssembler = services.NewTxnAssemblerFac()
 	services.WalletAssembler = services.NewWalletAssemblerFac()
+	services.HeaderAssembler = services.NewHeaderAssemblerFac()
 
 	blockchainRepo := repository.NewBlockchainRepository()
-	blockService := services.NewBlockService(blockchainRepo)
+	keystoreRepo := repository.NewKeystoreRepository(services.WalletFilePath())
+	addressBookRepo := repository.NewAddressBookRepository()
+	mempoolRepo := repository.NewMempoolRepository()
+	scheduleRepo := repository.NewScheduleRepository()
+	services.SnapshotAtStartup(blockchainRepo)
+	chainParams := services.LoadChainParams(blockchainRepo)
+	blockService := services.NewBlockService(blockchainRepo, chainParams)
 
-	walletService := services.NewWalletService(blockchainRepo)
-	transactionService := services.NewTransactionService(blockchainRepo, walletService)
-	blockchainService := services.NewBlockchainService(blockchainRepo, blockService, transactionService, walletService)
+	keystoreService := services.NewKeystoreService(keystoreRepo)
+	walletService := services.NewWalletService(blockchainRepo, keystoreService, chainParams)
+	services.UnlockWalletsAtStartup(keystoreService, walletService)
+	signer := services.SignerAtStartup(keystoreService)
+	hdWalletService := services.NewHDWalletService(blockchainRepo, walletService, keystoreService)
+	transactionService := services.NewTransactionService(blockchainRepo, walletService, hdWalletService, signer, chainParams)
+	services.IndexBlockHeightsAtStartup(blockchainRepo)
+	services.IndexChainWorkAtStartup(blockchainRepo)
+	services.CheckpointsAtStartup(blockchainRepo)
+	services.IndexUnspentOutputsAtStartup(blockchainRepo, transactionService)
+	services.CoinSelectionAtStartup()
+	services.SignalBitsAtStartup()
+	services.GenesisFileAtStartup()
+	services.MiningAtStartup()
+	services.FinalityAtStartup()
+	services.EventsAtStartup()
+	blockchainService := services.NewBlockchainService(blockchainRepo, blockService, transactionService, walletService, chainParams)
+	multisigService := services.NewMultisigService(blockchainRepo, transactionService, walletService, blockchainService, signer, chainParams)
+	addressBookService := services.NewAddressBookService(addressBookRepo, chainParams)
+	messageService := services.NewMessageService(walletService, signer, chainParams)
+	mempoolService := services.NewMempoolService(mempoolRepo, transactionService, blockchainService, chainParams)
+	services.RestoreMempoolAtStartup(mempoolService)
+	feeService := services.NewFeeService(blockchainRepo, mempoolService)
+	accountService := services.NewAccountService(blockchainRepo, walletService, transactionService, blockchainService, chainParams)
+	assetService := services.NewAssetService(blockchainRepo, transactionService, mempoolService, chainParams)
+	consolidationService := services.NewConsolidationService(transactionService, mempoolService, feeService)
+	scheduleService := services.NewScheduleService(scheduleRepo, transactionService, mempoolService, walletService)
+	services.StartSchedulerAtStartup(scheduleService)
+	minerService := services.NewMinerService(mempoolService)
+	stakingService := services.NewStakingService(blockchainRepo, transactionService, mempoolService, chainParams)
+	validatorSetService := services.NewValidatorSetService(blockchainRepo, transactionService, mempoolService, chainParams)
+	finalityService := services.NewFinalityService(blockchainRepo, walletService, signer, chainParams)
+	slashingService := services.NewSlashingService(blockchainRepo)
+	p2pNode := node.NewNode(blockchainService, mempoolService, chainParams)
+	node.StartAtStartup(p2pNode)
 
-	blockchainHandler := handlers.NewBlockchainHandler(blockchainService)
-	transactionHandler := handlers.NewTransactionHandler(transactionService)
-	walletHandler := handlers.NewWalletHandler(walletService)
+	blockchainHandler := handlers.NewBlockchainHandler(blockchainService, mempoolService, walletService, addressBookService)
+	transactionHandler := handlers.NewTransactionHandler(transactionService, mempoolService, walletService)
+	walletHandler := handlers.NewWalletHandler(walletService, hdWalletService)
+	multisigHandler := handlers.NewMultisigHandler(multisigService, walletService)
+	addressBookHandler := handlers.NewAddressBookHandler(addressBookService)
+	messageHandler := handlers.NewMessageHandler(messageService, walletService)
+	accountHandler := handlers.NewAccountHandler(accountService, addressBookService)
+	feeHandler := handlers.NewFeeHandler(feeService)
+	mempoolHandler := handlers.NewMempoolHandler(mempoolService, transactionService, walletService, addressBookService)
+	assetHandler := handlers.NewAssetHandler(assetService, walletService)
+	adminHandler := handlers.NewAdminHandler(consolidationService, validatorSetService, walletService)
+	scheduleHandler := handlers.NewScheduleHandler(scheduleService, walletService, addressBookService)
+	minerHandler := handlers.NewMinerHandler(minerService, walletService)
+	stakingHandler := handlers.NewStakingHandler(stakingService, walletService)
+	finalityHandler := handlers.NewFinalityHandler(finalityService, walletService)
+	slashingHandler := handlers.NewSlashingHandler(slashingService)
+	nodeHandler := handlers.NewNodeHandler(p2pNode)
 
 	groupRoute := route.Group("/")
 
@@ -33,23 +87,150 @@ func InitRoutes(route *gin.Engine) {
 	// Blockchain handlers
 	groupRoute.POST("/bitcoin/blockchain", blockchainHandler.CreateBlockchain)
 	groupRoute.GET("/bitcoin/blockchain", blockchainHandler.GetBlockchain)
+	groupRoute.GET("/bitcoin/blockchain/params", blockchainHandler.GetChainParams)
+	groupRoute.GET("/bitcoin/blockchain/info", blockchainHandler.GetChainInfo)
+	groupRoute.GET("/bitcoin/blockchain/tips", blockchainHandler.GetChainTips)
+	groupRoute.GET("/bitcoin/blockchain/metrics", blockchainHandler.GetMetrics)
+	groupRoute.GET("/bitcoin/blockchain/snapshot", blockchainHandler.GetSnapshot)
+	groupRoute.GET("/bitcoin/blockchain/versionbits", blockchainHandler.GetVersionBitsStats)
+	groupRoute.GET("/bitcoin/blockchain/stats/blocks", blockchainHandler.GetBlockStats)
 
 	// Block handlers
 	groupRoute.POST("/bitcoin/blockchain/block", blockchainHandler.AddToBlockchain)
 	groupRoute.GET("/bitcoin/blockchain/block/genesis", blockchainHandler.GetGenesisBlock)
 	groupRoute.GET("/bitcoin/blockchain/block/last", blockchainHandler.GetLastBlock)
+	groupRoute.GET("/bitcoin/blockchain/block/height/:height", blockchainHandler.GetBlockByHeight)
+	groupRoute.GET("/bitcoin/blockchain/blocks", blockchainHandler.GetBlocks)
+	groupRoute.POST("/bitcoin/blockchain/blocks", mempoolHandler.ReceiveBlock)
+	groupRoute.GET("/bitcoin/blockchain/blocks/stale", blockchainHandler.GetStaleBlocks)
+	groupRoute.GET("/bitcoin/blockchain/blocks/finalized/latest", blockchainHandler.GetLastFinalizedBlock)
+	groupRoute.GET("/bitcoin/blockchain/headers", blockchainHandler.GetBlockHeaders)
 	groupRoute.GET("/bitcoin/blockchain/block/:blockId", blockchainHandler.GetBlock)
+	groupRoute.GET("/bitcoin/blockchain/block/:blockId/proof/:txnId", blockchainHandler.GetMerkleBranch)
+	groupRoute.GET("/bitcoin/blockchain/block/:blockId/header", blockchainHandler.GetBlockHeader)
+	groupRoute.POST("/bitcoin/blockchain/block/:blockId/votes", finalityHandler.VoteForBlock)
+	groupRoute.GET("/bitcoin/blockchain/block/:blockId/votes", finalityHandler.GetBlockVotes)
+	groupRoute.POST("/bitcoin/blockchain/mine", mempoolHandler.MinePendingTransactions)
+	groupRoute.GET("/bitcoin/blockchain/mine/template", mempoolHandler.GetBlockTemplate)
+	groupRoute.POST("/bitcoin/blockchain/mine/submit", mempoolHandler.SubmitBlock)
 
 	// Transaction handlers
+	groupRoute.POST("/bitcoin/blockchain/transactions", mempoolHandler.CreateTransaction)
+	groupRoute.POST("/bitcoin/blockchain/transactions/batch", mempoolHandler.CreateBatch)
+	groupRoute.POST("/bitcoin/blockchain/transactions/raw", mempoolHandler.SubmitRawTransaction)
+	groupRoute.POST("/bitcoin/blockchain/transactions/validate", mempoolHandler.ValidateTransaction)
 	groupRoute.GET("/bitcoin/blockchain/transactions", transactionHandler.GetTransactions)
 	groupRoute.GET("/bitcoin/blockchain/transactions/:transactionId", transactionHandler.GetTransaction)
+	groupRoute.GET("/bitcoin/blockchain/transactions/:transactionId/status", mempoolHandler.GetTransactionStatus)
+	groupRoute.GET("/bitcoin/blockchain/transactions/:transactionId/receipt", transactionHandler.GetTransactionReceipt)
+	groupRoute.GET("/bitcoin/blockchain/output/:txnId/:index/status", blockchainHandler.GetOutputStatus)
 
 	// Wallet handlers
 	groupRoute.POST("/bitcoin/blockchain/wallets", walletHandler.CreateWallet)
 	groupRoute.GET("/bitcoin/blockchain/wallets", walletHandler.GetWallets)
 	groupRoute.GET("/bitcoin/blockchain/wallets/balances", transactionHandler.GetBalances)
+	groupRoute.POST("/bitcoin/blockchain/wallets/unlock", walletHandler.UnlockWallet)
+	groupRoute.POST("/bitcoin/blockchain/wallets/lock", walletHandler.LockWallet)
+	groupRoute.GET("/bitcoin/blockchain/wallets/backup", walletHandler.BackupWallets)
+	groupRoute.POST("/bitcoin/blockchain/wallets/restore", walletHandler.RestoreWallets)
 	groupRoute.GET("/bitcoin/blockchain/wallets/:address", walletHandler.GetWallet)
 	groupRoute.GET("/bitcoin/blockchain/wallets/:address/balance", transactionHandler.GetBalance)
+	groupRoute.GET("/bitcoin/blockchain/wallets/:address/transactions", transactionHandler.GetAddressHistory)
+	groupRoute.GET("/bitcoin/blockchain/wallets/:address/wif", walletHandler.ExportWIF)
+	groupRoute.POST("/bitcoin/blockchain/wallets/:address/sign", messageHandler.SignMessage)
+	groupRoute.POST("/bitcoin/blockchain/wallets/import", walletHandler.ImportWIF)
+	groupRoute.POST("/bitcoin/blockchain/wallets/watch", walletHandler.WatchAddress)
+	groupRoute.POST("/bitcoin/blockchain/wallets/pubkey", walletHandler.ImportPublicKey)
+	groupRoute.POST("/bitcoin/blockchain/wallets/paper", walletHandler.CreatePaperWallet)
+	groupRoute.POST("/bitcoin/blockchain/wallets/vanity", walletHandler.CreateVanityWallet)
+
+	// HD wallet handlers
+	groupRoute.POST("/bitcoin/blockchain/wallets/hd", walletHandler.CreateHDWallet)
+	groupRoute.POST("/bitcoin/blockchain/wallets/hd/recover", walletHandler.RecoverHDWallet)
+	groupRoute.GET("/bitcoin/blockchain/wallets/hd/:hdWalletId", walletHandler.GetHDWallet)
+	groupRoute.POST("/bitcoin/blockchain/wallets/hd/:hdWalletId/addresses", walletHandler.DeriveHDWalletAddress)
+
+	// Multisig handlers
+	groupRoute.POST("/bitcoin/blockchain/multisig", multisigHandler.CreateMultisigAddress)
+	groupRoute.GET("/bitcoin/blockchain/multisig/:address", multisigHandler.GetMultisigAddress)
+	groupRoute.POST("/bitcoin/blockchain/multisig/:address/transactions", multisigHandler.CreateMultisigTransaction)
+	groupRoute.GET("/bitcoin/blockchain/multisig/transactions/:txnId", multisigHandler.GetMultisigTransaction)
+	groupRoute.POST("/bitcoin/blockchain/multisig/transactions/:txnId/signatures", multisigHandler.SignMultisigTransaction)
+	groupRoute.POST("/bitcoin/blockchain/multisig/transactions/:txnId/broadcast", multisigHandler.BroadcastMultisigTransaction)
+
+	// Address book handlers
+	groupRoute.POST("/bitcoin/blockchain/addressbook", addressBookHandler.SaveEntry)
+	groupRoute.GET("/bitcoin/blockchain/addressbook", addressBookHandler.GetEntries)
+	groupRoute.GET("/bitcoin/blockchain/addressbook/:name", addressBookHandler.GetEntry)
+	groupRoute.DELETE("/bitcoin/blockchain/addressbook/:name", addressBookHandler.DeleteEntry)
+
+	// Address handlers
+	groupRoute.GET("/bitcoin/blockchain/addresses/:address/balance", transactionHandler.GetAddressBalance)
+	groupRoute.GET("/bitcoin/blockchain/addresses/:address/transactions", transactionHandler.GetAddressHistory)
+	groupRoute.GET("/bitcoin/blockchain/addresses/:address/utxos", transactionHandler.GetAddressUnspentOutputs)
+
+	// Mempool handlers
+	groupRoute.GET("/bitcoin/blockchain/mempool", mempoolHandler.GetMempool)
+	groupRoute.GET("/bitcoin/blockchain/mempool/stats", mempoolHandler.GetMempoolStats)
+	groupRoute.GET("/bitcoin/blockchain/mempool/:txnId", mempoolHandler.GetMempoolTransaction)
+
+	// Fee handlers
+	groupRoute.GET("/bitcoin/blockchain/fees/estimate", feeHandler.EstimateFees)
+
+	// Account handlers
+	groupRoute.POST("/bitcoin/blockchain/accounts", accountHandler.CreateAccount)
+	groupRoute.GET("/bitcoin/blockchain/accounts", accountHandler.GetAccounts)
+	groupRoute.GET("/bitcoin/blockchain/accounts/:name", accountHandler.GetAccount)
+	groupRoute.POST("/bitcoin/blockchain/accounts/:name/addresses", accountHandler.AssignAddress)
+	groupRoute.POST("/bitcoin/blockchain/accounts/:name/send", accountHandler.SendFromAccount)
+
+	// Asset handlers
+	groupRoute.POST("/bitcoin/blockchain/assets", assetHandler.CreateAsset)
+	groupRoute.GET("/bitcoin/blockchain/assets", assetHandler.GetAssets)
+	groupRoute.GET("/bitcoin/blockchain/assets/:assetId", assetHandler.GetAsset)
+	groupRoute.POST("/bitcoin/blockchain/assets/:assetId/issue", assetHandler.IssueAsset)
+	groupRoute.POST("/bitcoin/blockchain/assets/:assetId/transfer", assetHandler.TransferAsset)
+
+	// NFT handlers
+	groupRoute.POST("/bitcoin/blockchain/nfts", assetHandler.MintNFT)
+	groupRoute.GET("/bitcoin/blockchain/nfts/:nftId", assetHandler.GetNFT)
+	groupRoute.POST("/bitcoin/blockchain/nfts/:nftId/transfer", assetHandler.TransferNFT)
+
+	// Schedule handlers
+	groupRoute.POST("/bitcoin/blockchain/schedules", scheduleHandler.CreateSchedule)
+	groupRoute.GET("/bitcoin/blockchain/schedules", scheduleHandler.GetSchedules)
+	groupRoute.GET("/bitcoin/blockchain/schedules/:scheduleId", scheduleHandler.GetSchedule)
+	groupRoute.DELETE("/bitcoin/blockchain/schedules/:scheduleId", scheduleHandler.CancelSchedule)
+
+	// Miner handlers
+	groupRoute.POST("/bitcoin/blockchain/miner/start", minerHandler.StartMiner)
+	groupRoute.POST("/bitcoin/blockchain/miner/stop", minerHandler.StopMiner)
+	groupRoute.POST("/bitcoin/blockchain/miner/pause", minerHandler.PauseMiner)
+	groupRoute.POST("/bitcoin/blockchain/miner/resume", minerHandler.ResumeMiner)
+	groupRoute.PUT("/bitcoin/blockchain/miner/payout", minerHandler.SetMinerPayout)
+	groupRoute.GET("/bitcoin/blockchain/miner", minerHandler.GetMinerStatus)
+
+	// Staking handlers
+	groupRoute.POST("/bitcoin/blockchain/stake", stakingHandler.Stake)
+	groupRoute.POST("/bitcoin/blockchain/unstake", stakingHandler.Unstake)
+	groupRoute.GET("/bitcoin/blockchain/validators", stakingHandler.GetValidators)
+	groupRoute.GET("/bitcoin/blockchain/evidence", slashingHandler.GetEvidence)
+	groupRoute.GET("/bitcoin/blockchain/evidence/:evidenceId", slashingHandler.GetEvidenceById)
+
+	// Peer handlers
+	groupRoute.GET("/bitcoin/blockchain/sync", nodeHandler.GetSyncStatus)
+	groupRoute.GET("/bitcoin/blockchain/peers", nodeHandler.GetPeers)
+	groupRoute.POST("/bitcoin/blockchain/peers", nodeHandler.ConnectPeer)
+	groupRoute.DELETE("/bitcoin/blockchain/peers/:peerId", nodeHandler.DisconnectPeer)
+
+	// Admin handlers
+	groupRoute.POST("/bitcoin/blockchain/admin/consolidate", adminHandler.ConsolidateAddress)
+	groupRoute.GET("/bitcoin/blockchain/admin/validators", adminHandler.GetValidatorSchedule)
+	groupRoute.POST("/bitcoin/blockchain/admin/validators", adminHandler.AddValidator)
+	groupRoute.DELETE("/bitcoin/blockchain/admin/validators/:address", adminHandler.RemoveValidator)
+
+	// Message handlers
+	groupRoute.POST("/bitcoin/blockchain/verify", messageHandler.VerifyMessage)
 
 	// swagger
 	groupRoute.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/brucetieu/blockchain/repository"
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/google/uuid"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var MaxAccountNameLen = 64
+
+type AccountService interface {
+	CreateAccount(name string) (reps.Account, error)
+	GetAccount(name string) (reps.Account, error)
+	GetAccounts() ([]reps.Account, error)
+	AssignAddress(name string, address string) (reps.Account, error)
+	SendFromAccount(name string, to string, amount int, opts reps.TxnOptions) (reps.Block, error)
+}
+
+type accountService struct {
+	blockchainRepo     repository.BlockchainRepository
+	walletService      WalletService
+	transactionService TransactionService
+	blockchainService  BlockchainService
+	params             *reps.ChainParams
+}
+
+func NewAccountService(blockchainRepo repository.BlockchainRepository, walletService WalletService, transactionService TransactionService,
+	blockchainService BlockchainService, params *reps.ChainParams) AccountService {
+	return &accountService{
+		blockchainRepo:     blockchainRepo,
+		walletService:      walletService,
+		transactionService: transactionService,
+		blockchainService:  blockchainService,
+		params:             params,
+	}
+}
+
+// Create an empty account. Addresses are added to it with AssignAddress
+func (as *accountService) CreateAccount(name string) (reps.Account, error) {
+	log.Info("Creating account: ", name)
+	name = strings.TrimSpace(name)
+
+	if name == "" || len(name) > MaxAccountNameLen {
+		return reps.Account{}, fmt.Errorf("name must be between 1 and %d characters", MaxAccountNameLen)
+	}
+
+	if _, err := as.blockchainRepo.GetAccount(name); err == nil {
+		return reps.Account{}, fmt.Errorf("account named %s already exists", name)
+	}
+
+	account := reps.Account{
+		ID:        uuid.Must(uuid.NewRandom()).String(),
+		Name:      name,
+		Addresses: make([]string, 0),
+	}
+
+	if err := as.blockchainRepo.CreateAccount(account); err != nil {
+		return reps.Account{}, err
+	}
+
+	return account, nil
+}
+
+// Get an account with its addresses and their combined balance
+func (as *accountService) GetAccount(name string) (reps.Account, error) {
+	account, err := as.blockchainRepo.GetAccount(name)
+	if err != nil {
+		return reps.Account{}, fmt.Errorf("%s, no account named %s", err.Error(), name)
+	}
+
+	return as.withAddresses(account)
+}
+
+func (as *accountService) GetAccounts() ([]reps.Account, error) {
+	accounts, err := as.blockchainRepo.GetAccounts()
+	if err != nil {
+		return []reps.Account{}, err
+	}
+
+	for i := range accounts {
+		accounts[i], err = as.withAddresses(accounts[i])
+		if err != nil {
+			return []reps.Account{}, err
+		}
+	}
+
+	return accounts, nil
+}
+
+// Fill in an account's addresses and balance
+func (as *accountService) withAddresses(account reps.Account) (reps.Account, error) {
+	wallets, err := as.blockchainRepo.GetWalletsByAccountId(account.ID)
+	if err != nil {
+		return reps.Account{}, err
+	}
+
+	account.Addresses = make([]string, 0, len(wallets))
+	account.Balance = 0
+	for _, wallet := range wallets {
+		balance, err := as.transactionService.GetBalance(wallet.Address)
+		if err != nil {
+			return reps.Account{}, err
+		}
+		account.Addresses = append(account.Addresses, wallet.Address)
+		account.Balance += balance
+	}
+
+	return account, nil
+}
+
+// Add one of this node's wallets to an account. An address can only be in one account at a time,
+// and every address that can sign must use the same signature scheme. Watch-only addresses count
+// towards the balance but are never spent from
+func (as *accountService) AssignAddress(name string, address string) (reps.Account, error) {
+	log.WithFields(log.Fields{"account": name, "address": address}).Info("Assigning address to account")
+	account, err := as.blockchainRepo.GetAccount(name)
+	if err != nil {
+		return reps.Account{}, fmt.Errorf("%s, no account named %s", err.Error(), name)
+	}
+
+	wallet, err := as.blockchainRepo.GetWallet(address)
+	if err != nil {
+		return reps.Account{}, fmt.Errorf("%s, wallet with address %s does not exist", err.Error(), address)
+	}
+
+	if wallet.AccountID == account.ID {
+		return as.withAddresses(account)
+	}
+	if wallet.AccountID != "" {
+		return reps.Account{}, fmt.Errorf("address %s is already assigned to another account", address)
+	}
+
+	if !wallet.WatchOnly {
+		scheme, err := GetSignatureScheme(wallet.SigAlgorithm)
+		if err != nil {
+			return reps.Account{}, err
+		}
+
+		// A transaction is signed with a single scheme, so an account can't mix them
+		if account.SigAlgorithm == "" {
+			account.SigAlgorithm = scheme.Algorithm()
+			if err := as.blockchainRepo.UpdateAccount(account); err != nil {
+				return reps.Account{}, err
+			}
+		} else if account.SigAlgorithm != scheme.Algorithm() {
+			return reps.Account{}, fmt.Errorf("address %s uses %s signatures, but account %s uses %s", address, scheme.Algorithm(), name, account.SigAlgorithm)
+		}
+	}
+
+	wallet.AccountID = account.ID
+	if err := as.blockchainRepo.UpdateWallet(wallet); err != nil {
+		return reps.Account{}, err
+	}
+
+	return as.withAddresses(account)
+}
+
+// Send coins from any of an account's addresses and mine the transaction into a block.
+// The reward and fee go to the first address spent from
+func (as *accountService) SendFromAccount(name string, to string, amount int, opts reps.TxnOptions) (reps.Block, error) {
+	log.WithFields(log.Fields{"account": name, "to": to, "amount": amount}).Info("Sending from account")
+	account, err := as.blockchainRepo.GetAccount(name)
+	if err != nil {
+		return reps.Block{}, fmt.Errorf("%s, no account named %s", err.Error(), name)
+	}
+
+	wallets, err := as.blockchainRepo.GetWalletsByAccountId(account.ID)
+	if err != nil {
+		return reps.Block{}, err
+	}
+
+	spendable := make([]reps.Wallet, 0, len(wallets))
+	for _, wallet := range wallets {
+		if !wallet.WatchOnly {
+			spendable = append(spendable, wallet)
+		}
+	}
+	if len(spendable) == 0 {
+		return reps.Block{}, fmt.Errorf("account %s has no addresses to send from", name)
+	}
+
+	txn, err := as.transactionService.CreateTransactionFromWallets(spendable, to, amount, opts)
+	if err != nil {
+		return reps.Block{}, err
+	}
+
+	return as.blockchainService.MineTransactions([]reps.Transaction{txn}, spendable[0].Address, "")
+}
@@ -0,0 +1,55 @@
+package services_test
+
+import (
+	"testing"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountBalanceAndSendsSpanItsAddresses(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+	accountService := services.NewAccountService(repo, walletService, txnService, nil, &mainnet)
+
+	first, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	second, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+
+	// Both addresses get a block reward
+	repo.blocks = []reps.Block{{ID: "genesis", Transactions: []reps.Transaction{
+		txnService.CreateCoinbaseTxn(first.Address, ""),
+		txnService.CreateCoinbaseTxn(second.Address, ""),
+	}}}
+
+	_, err = accountService.CreateAccount("savings")
+	assert.NoError(t, err)
+	_, err = accountService.AssignAddress("savings", first.Address)
+	assert.NoError(t, err)
+	account, err := accountService.AssignAddress("savings", second.Address)
+	assert.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{first.Address, second.Address}, account.Addresses)
+	assert.Equal(t, 2*services.Reward, account.Balance)
+
+	// More than either address holds on its own
+	wallets, err := repo.GetWalletsByAccountId(account.ID)
+	assert.NoError(t, err)
+	txn, err := txnService.CreateTransactionFromWallets(wallets, to.Address, services.Reward+10, reps.TxnOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, txn.Inputs, 2)
+
+	valid, err := txnService.VerifyTransaction(txn)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	// An address can only be in one account
+	_, err = accountService.CreateAccount("checking")
+	assert.NoError(t, err)
+	_, err = accountService.AssignAddress("checking", first.Address)
+	assert.Error(t, err)
+}
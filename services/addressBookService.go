@@ -0,0 +1,110 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/brucetieu/blockchain/repository"
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/google/uuid"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var MaxAddressBookNameLen = 64
+
+type AddressBookService interface {
+	SaveEntry(name string, address string) (reps.AddressBookEntry, error)
+	GetEntry(name string) (reps.AddressBookEntry, error)
+	GetEntries() ([]reps.AddressBookEntry, error)
+	DeleteEntry(name string) error
+
+	ResolveAddress(nameOrAddress string) (string, error)
+}
+
+type addressBookService struct {
+	addressBookRepo repository.AddressBookRepository
+	params          *reps.ChainParams
+}
+
+func NewAddressBookService(addressBookRepo repository.AddressBookRepository, params *reps.ChainParams) AddressBookService {
+	return &addressBookService{
+		addressBookRepo: addressBookRepo,
+		params:          params,
+	}
+}
+
+// Label an address with a name. Saving an existing name points it at the new address
+func (as *addressBookService) SaveEntry(name string, address string) (reps.AddressBookEntry, error) {
+	log.WithFields(log.Fields{"name": name, "address": address}).Info("Saving address book entry")
+	name = strings.TrimSpace(name)
+
+	if name == "" || len(name) > MaxAddressBookNameLen {
+		return reps.AddressBookEntry{}, fmt.Errorf("name must be between 1 and %d characters", MaxAddressBookNameLen)
+	}
+
+	// Otherwise it would be ambiguous whether a name or an address is meant
+	if IsValidAddress(name, as.params.NetworkByte) {
+		return reps.AddressBookEntry{}, fmt.Errorf("name %s cannot itself be an address", name)
+	}
+
+	if !IsValidAddress(address, as.params.NetworkByte) {
+		return reps.AddressBookEntry{}, fmt.Errorf("malformed address: %s", address)
+	}
+
+	entry, err := as.addressBookRepo.GetEntry(name)
+	if err == nil {
+		entry.Address = address
+		return entry, as.addressBookRepo.UpdateEntry(entry)
+	}
+
+	entry = reps.AddressBookEntry{
+		ID:      uuid.Must(uuid.NewRandom()).String(),
+		Name:    name,
+		Address: address,
+	}
+
+	err = as.addressBookRepo.CreateEntry(entry)
+	if err != nil {
+		return reps.AddressBookEntry{}, err
+	}
+
+	return entry, nil
+}
+
+func (as *addressBookService) GetEntry(name string) (reps.AddressBookEntry, error) {
+	entry, err := as.addressBookRepo.GetEntry(name)
+	if err != nil {
+		return reps.AddressBookEntry{}, fmt.Errorf("%s, no address book entry named %s", err.Error(), name)
+	}
+
+	return entry, nil
+}
+
+func (as *addressBookService) GetEntries() ([]reps.AddressBookEntry, error) {
+	return as.addressBookRepo.GetEntries()
+}
+
+func (as *addressBookService) DeleteEntry(name string) error {
+	err := as.addressBookRepo.DeleteEntry(name)
+	if err != nil {
+		return fmt.Errorf("%s, no address book entry named %s", err.Error(), name)
+	}
+
+	return nil
+}
+
+// Turn an address book name into its address. Anything that's already an address is returned as is
+func (as *addressBookService) ResolveAddress(nameOrAddress string) (string, error) {
+	if IsValidAddress(nameOrAddress, as.params.NetworkByte) {
+		return nameOrAddress, nil
+	}
+
+	entry, err := as.GetEntry(strings.TrimSpace(nameOrAddress))
+	if err != nil {
+		return "", err
+	}
+
+	log.Infof("Resolved address book name %s to %s", entry.Name, entry.Address)
+	return entry.Address, nil
+}
@@ -0,0 +1,44 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/brucetieu/blockchain/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveAddressByName(t *testing.T) {
+	walletService := newTestServices(t).walletService
+	addressBookService := services.NewAddressBookService(newFakeAddressBookRepository(), &mainnet)
+
+	wallet, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+
+	_, err = addressBookService.SaveEntry("alice", wallet.Address)
+	assert.NoError(t, err)
+
+	address, err := addressBookService.ResolveAddress("alice")
+	assert.NoError(t, err)
+	assert.Equal(t, wallet.Address, address)
+
+	// Addresses resolve to themselves
+	address, err = addressBookService.ResolveAddress(wallet.Address)
+	assert.NoError(t, err)
+	assert.Equal(t, wallet.Address, address)
+
+	_, err = addressBookService.ResolveAddress("bob")
+	assert.Error(t, err)
+}
+
+func TestSaveEntryRejectsAmbiguousNames(t *testing.T) {
+	walletService := newTestServices(t).walletService
+	addressBookService := services.NewAddressBookService(newFakeAddressBookRepository(), &mainnet)
+
+	wallet, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+
+	_, err = addressBookService.SaveEntry(wallet.Address, wallet.Address)
+	assert.Error(t, err)
+	_, err = addressBookService.SaveEntry("alice", "not an address")
+	assert.Error(t, err)
+}
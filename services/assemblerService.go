@@ -8,6 +8,8 @@ import (
 	"encoding/gob"
 	"encoding/hex"
 	"encoding/json"
+	"math/big"
+	"time"
 
 	// "fmt"
 
@@ -21,12 +23,14 @@ var (
 	BlockAssembler  BlockAssemblerFac
 	TxnAssembler    TxnAssemblerFac
 	WalletAssembler WalletAssemblerFac
+	HeaderAssembler HeaderAssemblerFac
 )
 
 type (
 	blockAssembler  struct{}
 	txnAssembler    struct{}
 	walletAssembler struct{}
+	headerAssembler struct{}
 )
 
 func NewBlockAssemblerFac() BlockAssemblerFac {
@@ -37,6 +41,17 @@ type BlockAssemblerFac interface {
 	ToBlockBytes(block *reps.Block) []byte
 	ToBlockStructure(data []byte) *reps.Block
 	ToReadableBlock(block reps.Block) reps.ReadableBlock
+	ToBlockTemplate(block reps.Block, hasher BlockHasher) reps.BlockTemplate
+}
+
+func NewHeaderAssemblerFac() HeaderAssemblerFac {
+	return &headerAssembler{}
+}
+
+// Block headers only, so transactions never have to be loaded or decoded
+type HeaderAssemblerFac interface {
+	ToBlockHeader(block reps.Block) reps.BlockHeader
+	ToBlockHeaders(blocks []reps.Block) []reps.BlockHeader
 }
 
 func NewTxnAssemblerFac() TxnAssemblerFac {
@@ -44,10 +59,15 @@ func NewTxnAssemblerFac() TxnAssemblerFac {
 }
 
 type TxnAssemblerFac interface {
-	HashTransactions(txns []reps.Transaction) []byte
+	HashTransactions(txns []reps.Transaction) ([]byte, error)
+	MerkleRoot(txns []reps.Transaction) ([]byte, error)
 	HashTransaction(txn reps.Transaction) []byte
+	TxnID(txn reps.Transaction) []byte
 	ToReadableTransactions(txns []reps.Transaction) []reps.ReadableTransaction
 	ToReadableTransaction(txn reps.Transaction) reps.ReadableTransaction
+	ToReadableAddressTransactions(addressTxns []reps.AddressTransaction) []reps.ReadableAddressTransaction
+	ToReadableMempoolEntries(entries []reps.MempoolEntry) []reps.ReadableMempoolEntry
+	ToReadableMempoolEntry(entry reps.MempoolEntry) reps.ReadableMempoolEntry
 	ToTxnBytes(txn reps.Transaction) []byte
 	// ToCoinbaseTxn(to string, data string) reps.Transaction
 	SetID(txnRep reps.Transaction) []byte
@@ -100,8 +120,33 @@ func (t *txnAssembler) HashTransaction(txn reps.Transaction) []byte {
 	return hash[:]
 }
 
+// Id of a transaction: the hash of everything but the ids of it and its inputs and outputs, which are only
+// row keys, and the signatures. Every node computes the same id for the same transaction, and anyone can check it
+func (t *txnAssembler) TxnID(txn reps.Transaction) []byte {
+	canonical := reps.Transaction{
+		SigAlgorithm:    txn.SigAlgorithm,
+		Fee:             txn.Fee,
+		Memo:            txn.Memo,
+		LockTime:        txn.LockTime,
+		Replaceable:     txn.Replaceable,
+		Evidence:        txn.Evidence,
+		ValidatorChange: txn.ValidatorChange,
+		Inputs:          make([]reps.TxnInput, 0, len(txn.Inputs)),
+		Outputs:         make([]reps.TxnOutput, 0, len(txn.Outputs)),
+	}
+	for _, input := range txn.Inputs {
+		canonical.Inputs = append(canonical.Inputs, reps.TxnInput{PrevTxnID: input.PrevTxnID, OutIdx: input.OutIdx, PubKey: input.PubKey})
+	}
+	for _, output := range txn.Outputs {
+		canonical.Outputs = append(canonical.Outputs, reps.TxnOutput{Value: output.Value, PubKeyHash: output.PubKeyHash, AssetID: output.AssetID, Staked: output.Staked})
+	}
+
+	hash := sha256.Sum256(t.ToTxnBytes(canonical))
+	return hash[:]
+}
+
 // Hash all transaction ids
-func (t *txnAssembler) HashTransactions(txns []reps.Transaction) []byte {
+func (t *txnAssembler) HashTransactions(txns []reps.Transaction) ([]byte, error) {
 	allTxns := make([][]byte, 0)
 
 	// Create a list of serialized transactions
@@ -110,14 +155,47 @@ func (t *txnAssembler) HashTransactions(txns []reps.Transaction) []byte {
 	}
 
 	// Now transactions are stored in merkle tree
-	merkleTree := reps.NewMerkleTree(allTxns)
+	merkleTree, err := reps.NewMerkleTree(allTxns)
+	if err != nil {
+		return nil, err
+	}
 
 	// Serves as unique identifier for each blocks transactions
-	return merkleTree.Root.Data
+	return merkleTree.Root.Data, nil
 	// hashedTxns := sha256.Sum256(bytes.Join(allTxns, []byte{}))
 	// return hashedTxns[:]
 }
 
+// Root of the merkle tree over transaction ids, which goes in the block header
+func (t *txnAssembler) MerkleRoot(txns []reps.Transaction) ([]byte, error) {
+	txnIds := make([][]byte, 0, len(txns))
+	for _, txn := range txns {
+		txnIds = append(txnIds, txn.ID)
+	}
+
+	merkleTree, err := reps.NewMerkleTree(txnIds)
+	if err != nil {
+		return nil, err
+	}
+
+	return merkleTree.Root.Data, nil
+}
+
+// What the leaves of a block's merkle tree are the hashes of: the ids of its transactions, or the serialized
+// transactions on blocks mined before the merkle root was in the header
+func MerkleLeaves(block reps.Block) [][]byte {
+	leaves := make([][]byte, 0, len(block.Transactions))
+	for _, txn := range block.Transactions {
+		if len(block.MerkleRoot) == 0 {
+			leaves = append(leaves, TxnAssembler.ToTxnBytes(txn))
+		} else {
+			leaves = append(leaves, txn.ID)
+		}
+	}
+
+	return leaves
+}
+
 // Create txn id
 func (t *txnAssembler) SetID(txnRep reps.Transaction) []byte {
 	txnRepInBytes, err := json.Marshal(txnRep)
@@ -166,6 +244,17 @@ func (b *blockAssembler) ToReadableBlock(block reps.Block) reps.ReadableBlock {
 	readableBlock.PrevHash = hex.EncodeToString(block.PrevHash)
 	readableBlock.Hash = hex.EncodeToString(block.Hash)
 	readableBlock.Nounce = block.Nounce
+	readableBlock.Difficulty = block.Difficulty
+	readableBlock.MerkleRoot = hex.EncodeToString(block.MerkleRoot)
+	readableBlock.Height = block.Height
+	readableBlock.Version = block.Version
+	readableBlock.ChainWork = block.ChainWork
+	readableBlock.Proposer = hex.EncodeToString(block.Proposer)
+	readableBlock.Round = block.Round
+	readableBlock.ChainID = block.ChainID
+	if len(block.Transactions) > 0 {
+		readableBlock.CoinbaseMessage = CoinbaseMessageOf(block.Transactions[0])
+	}
 
 	var transactions []reps.ReadableTransaction
 	for _, txn := range block.Transactions {
@@ -188,6 +277,8 @@ func (b *blockAssembler) ToReadableBlock(block reps.Block) reps.ReadableBlock {
 				CurrTxnID:  hex.EncodeToString(txn.ID),
 				Value:      out.Value,
 				PubKeyHash: hex.EncodeToString(out.PubKeyHash),
+				AssetID:    out.AssetID,
+				Staked:     out.Staked,
 			}
 			outputs = append(outputs, output)
 		}
@@ -207,6 +298,33 @@ func (b *blockAssembler) ToReadableBlock(block reps.Block) reps.ReadableBlock {
 	return readableBlock
 }
 
+// Unsolved block as handed to a miner elsewhere, which has to hash it with hasher
+func (b *blockAssembler) ToBlockTemplate(block reps.Block, hasher BlockHasher) reps.BlockTemplate {
+	coinbaseValue := 0
+	if len(block.Transactions) > 0 && len(block.Transactions[0].Outputs) > 0 {
+		coinbaseValue = block.Transactions[0].Outputs[0].Value
+	}
+
+	target := make([]byte, 32)
+	DifficultyTarget(BlockDifficulty(block)).FillBytes(target)
+
+	return reps.BlockTemplate{
+		TemplateID:    block.ID,
+		Height:        block.Height,
+		PrevHash:      hex.EncodeToString(block.PrevHash),
+		Timestamp:     block.Timestamp,
+		Difficulty:    block.Difficulty,
+		Version:       block.Version,
+		ChainID:       block.ChainID,
+		Target:        hex.EncodeToString(target),
+		HashAlgorithm: hasher.Algorithm(),
+		MerkleRoot:    hex.EncodeToString(block.MerkleRoot),
+		HeaderPrefix:  hex.EncodeToString(HeaderPrefix(block)),
+		CoinbaseValue: coinbaseValue,
+		Transactions:  TxnAssembler.ToReadableTransactions(block.Transactions),
+	}
+}
+
 func (t *txnAssembler) ToReadableTransactions(txns []reps.Transaction) []reps.ReadableTransaction {
 	var transactions []reps.ReadableTransaction
 
@@ -229,6 +347,8 @@ func (t *txnAssembler) ToReadableTransactions(txns []reps.Transaction) []reps.Re
 				CurrTxnID:  hex.EncodeToString(txn.ID),
 				Value:      out.Value,
 				PubKeyHash: hex.EncodeToString(out.PubKeyHash),
+				AssetID:    out.AssetID,
+				Staked:     out.Staked,
 			}
 			outputs = append(outputs, output)
 		}
@@ -236,6 +356,7 @@ func (t *txnAssembler) ToReadableTransactions(txns []reps.Transaction) []reps.Re
 		transaction := reps.ReadableTransaction{
 			BlockID: txn.BlockID,
 			ID:      hex.EncodeToString(txn.ID),
+			Fee:     txn.Fee,
 			Inputs:  inputs,
 			Outputs: outputs,
 		}
@@ -248,8 +369,13 @@ func (t *txnAssembler) ToReadableTransactions(txns []reps.Transaction) []reps.Re
 
 func (t *txnAssembler) ToReadableTransaction(txn reps.Transaction) reps.ReadableTransaction {
 	readableTxn := reps.ReadableTransaction{
-		ID:      hex.EncodeToString(txn.ID),
-		BlockID: txn.BlockID,
+		ID:           hex.EncodeToString(txn.ID),
+		BlockID:      txn.BlockID,
+		SigAlgorithm: txn.SigAlgorithm,
+		Fee:          txn.Fee,
+		Memo:         txn.Memo,
+		LockTime:     txn.LockTime,
+		Replaceable:  txn.Replaceable,
 	}
 
 	var inputs []reps.ReadableTxnInput
@@ -270,6 +396,8 @@ func (t *txnAssembler) ToReadableTransaction(txn reps.Transaction) reps.Readable
 			CurrTxnID:  hex.EncodeToString(txn.ID),
 			Value:      out.Value,
 			PubKeyHash: hex.EncodeToString(out.PubKeyHash),
+			AssetID:    out.AssetID,
+			Staked:     out.Staked,
 		}
 		outputs = append(outputs, output)
 	}
@@ -280,31 +408,114 @@ func (t *txnAssembler) ToReadableTransaction(txn reps.Transaction) reps.Readable
 	return readableTxn
 }
 
-// Convert ecdsa.PrivateKey to slice of bytes
-func (w *walletAssembler) ToPrivateKeyBytes(privateKey ecdsa.PrivateKey) []byte {
-	gob.Register(elliptic.P256())
-	var content bytes.Buffer
+func (t *txnAssembler) ToReadableAddressTransactions(addressTxns []reps.AddressTransaction) []reps.ReadableAddressTransaction {
+	readableTxns := make([]reps.ReadableAddressTransaction, 0, len(addressTxns))
+
+	for _, addressTxn := range addressTxns {
+		readableTxns = append(readableTxns, reps.ReadableAddressTransaction{
+			Transaction: t.ToReadableTransaction(addressTxn.Transaction),
+			BlockHash:   hex.EncodeToString(addressTxn.BlockHash),
+			Height:      addressTxn.Height,
+			Direction:   addressTxn.Direction,
+			Amount:      addressTxn.Amount,
+		})
+	}
 
-	encoder := gob.NewEncoder(&content)
-	err := encoder.Encode(privateKey)
-	if err != nil {
-		log.Error("unable to encode", err.Error())
+	return readableTxns
+}
+
+func (t *txnAssembler) ToReadableMempoolEntries(entries []reps.MempoolEntry) []reps.ReadableMempoolEntry {
+	readableEntries := make([]reps.ReadableMempoolEntry, 0, len(entries))
+
+	for _, entry := range entries {
+		readableEntries = append(readableEntries, t.ToReadableMempoolEntry(entry))
+	}
+
+	return readableEntries
+}
+
+// Wait time is measured up to now
+func (t *txnAssembler) ToReadableMempoolEntry(entry reps.MempoolEntry) reps.ReadableMempoolEntry {
+	feeRate := 0
+	if entry.Size > 0 {
+		feeRate = entry.Fee * 1000 / entry.Size
+	}
+
+	return reps.ReadableMempoolEntry{
+		Transaction: t.ToReadableTransaction(entry.Transaction),
+		Fee:         entry.Fee,
+		Size:        entry.Size,
+		FeeRate:     feeRate,
+		AddedAt:     entry.AddedAt,
+		Waiting:     int64(time.Since(time.UnixMilli(entry.AddedAt)).Seconds()),
 	}
+}
 
-	return content.Bytes()
+// Convert ecdsa.PrivateKey to slice of bytes. Only the private scalar is kept, padded to the curve size
+func (w *walletAssembler) ToPrivateKeyBytes(privateKey ecdsa.PrivateKey) []byte {
+	return toPrivateKeyBytes(privateKey)
 }
 
 // Convert byte representation of the private key to a ecdsa.PrivateKey
 func (w *walletAssembler) ToECDSAPrivateKey(privKeyBytes []byte) ecdsa.PrivateKey {
-	var privKey ecdsa.PrivateKey
-	gob.Register(elliptic.P256())
+	return toECDSAPrivateKey(privKeyBytes)
+}
 
-	buf := bytes.NewBuffer(privKeyBytes)
-	decoder := gob.NewDecoder(buf)
-	err := decoder.Decode(&privKey)
-	if err != nil {
-		log.Error("Unable to decode: ", err.Error())
+func toPrivateKeyBytes(privateKey ecdsa.PrivateKey) []byte {
+	coordLen := (privateKey.Curve.Params().BitSize + 7) / 8
+	return privateKey.D.FillBytes(make([]byte, coordLen))
+}
+
+func toECDSAPrivateKey(privKeyBytes []byte) ecdsa.PrivateKey {
+	curve := elliptic.P256()
+	coordLen := (curve.Params().BitSize + 7) / 8
+
+	// Keys saved before the fixed width encoding were gob encoded
+	if len(privKeyBytes) != coordLen {
+		var privKey ecdsa.PrivateKey
+		gob.Register(elliptic.P256())
+
+		buf := bytes.NewBuffer(privKeyBytes)
+		decoder := gob.NewDecoder(buf)
+		err := decoder.Decode(&privKey)
+		if err != nil {
+			log.Error("Unable to decode: ", err.Error())
+		}
+
+		return privKey
+	}
+
+	x, y := curve.ScalarBaseMult(privKeyBytes)
+	return ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{Curve: curve, X: x, Y: y},
+		D:         new(big.Int).SetBytes(privKeyBytes),
+	}
+}
+
+func (h *headerAssembler) ToBlockHeader(block reps.Block) reps.BlockHeader {
+	return reps.BlockHeader{
+		ID:          block.ID,
+		Height:      block.Height,
+		Hash:        hex.EncodeToString(block.Hash),
+		PrevHash:    hex.EncodeToString(block.PrevHash),
+		MerkleRoot:  hex.EncodeToString(block.MerkleRoot),
+		Timestamp:   block.Timestamp,
+		Nounce:      block.Nounce,
+		Difficulty:  block.Difficulty,
+		Version:     block.Version,
+		ChainWork:   block.ChainWork,
+		Proposer:    hex.EncodeToString(block.Proposer),
+		ProposerSig: hex.EncodeToString(block.ProposerSig),
+		Round:       block.Round,
+		ChainID:     block.ChainID,
+	}
+}
+
+func (h *headerAssembler) ToBlockHeaders(blocks []reps.Block) []reps.BlockHeader {
+	headers := make([]reps.BlockHeader, 0, len(blocks))
+	for _, block := range blocks {
+		headers = append(headers, h.ToBlockHeader(block))
 	}
 
-	return privKey
+	return headers
 }
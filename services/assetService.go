@@ -0,0 +1,358 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/brucetieu/blockchain/repository"
+	reps "github.com/brucetieu/blockchain/representations"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	MaxAssetNameLen = 64
+
+	assetSymbolPattern  = regexp.MustCompile(`^[A-Z0-9]{1,12}$`)
+	metadataHashPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+)
+
+const NFTSymbol = "NFT" // Symbol every NFT is listed under, as they're told apart by their metadata
+
+// Registers assets, and queues the transactions that issue and transfer their units in the mempool
+type AssetService interface {
+	CreateAsset(issuer string, symbol string, name string, amount int, maxSupply int, mintable bool, opts reps.TxnOptions) (reps.Asset, reps.Transaction, error)
+	IssueAsset(assetId string, amount int, opts reps.TxnOptions) (reps.Transaction, error)
+	TransferAsset(assetId string, from string, to string, amount int, opts reps.TxnOptions) (reps.Transaction, error)
+	GetAsset(assetId string) (reps.Asset, error)
+	GetAssets() ([]reps.Asset, error)
+	MintNFT(issuer string, name string, metadataHash string, opts reps.TxnOptions) (reps.Asset, reps.Transaction, error)
+	TransferNFT(nftId string, to string, opts reps.TxnOptions) (reps.Transaction, error)
+	GetNFTProvenance(nftId string) (reps.NFTProvenance, error)
+}
+
+type assetService struct {
+	blockchainRepo     repository.BlockchainRepository
+	transactionService TransactionService
+	mempoolService     MempoolService
+	params             *reps.ChainParams
+}
+
+func NewAssetService(blockchainRepo repository.BlockchainRepository, transactionService TransactionService,
+	mempoolService MempoolService, params *reps.ChainParams) AssetService {
+	return &assetService{
+		blockchainRepo:     blockchainRepo,
+		transactionService: transactionService,
+		mempoolService:     mempoolService,
+		params:             params,
+	}
+}
+
+// Whether symbol can name an asset: 1 to 12 capital letters or digits
+func IsValidAssetSymbol(symbol string) bool {
+	return assetSymbolPattern.MatchString(symbol)
+}
+
+// Id of the asset an issuer registers under symbol
+func AssetID(issuerPubKeyHash []byte, symbol string) string {
+	hash := sha256.Sum256(append(append([]byte{}, issuerPubKeyHash...), []byte(symbol)...))
+	return hex.EncodeToString(hash[:])
+}
+
+// Register an asset and queue the transaction issuing its first amount units to the issuer.
+// If the transaction doesn't make it onto the chain, the issuer can issue them again with IssueAsset
+func (as *assetService) CreateAsset(issuer string, symbol string, name string, amount int, maxSupply int, mintable bool,
+	opts reps.TxnOptions) (reps.Asset, reps.Transaction, error) {
+	log.WithFields(log.Fields{"issuer": issuer, "symbol": symbol, "amount": amount, "maxSupply": maxSupply}).Info("Creating asset")
+
+	if !IsValidAssetSymbol(symbol) {
+		return reps.Asset{}, reps.Transaction{}, fmt.Errorf("asset symbol must be 1 to 12 capital letters or digits, not %s", symbol)
+	}
+	if len(name) > MaxAssetNameLen {
+		return reps.Asset{}, reps.Transaction{}, fmt.Errorf("asset name can be at most %d bytes, not %d", MaxAssetNameLen, len(name))
+	}
+	if amount <= 0 {
+		return reps.Asset{}, reps.Transaction{}, fmt.Errorf("amount issued must be positive, not %d", amount)
+	}
+	if maxSupply < 0 || (maxSupply > 0 && amount > maxSupply) {
+		return reps.Asset{}, reps.Transaction{}, fmt.Errorf("max supply must be 0 for no cap, or at least the %d units issued, not %d", amount, maxSupply)
+	}
+	if !IsValidAddress(issuer, as.params.NetworkByte) {
+		return reps.Asset{}, reps.Transaction{}, fmt.Errorf("malformed address: %s", issuer)
+	}
+
+	decoded := base58Decode([]byte(issuer))
+	asset := reps.Asset{
+		ID:        AssetID(decoded[1:len(decoded)-ChecksumLen], symbol),
+		Symbol:    symbol,
+		Name:      name,
+		Issuer:    issuer,
+		MaxSupply: maxSupply,
+		Mintable:  mintable,
+	}
+	if _, err := as.blockchainRepo.GetAsset(asset.ID); err == nil {
+		return reps.Asset{}, reps.Transaction{}, fmt.Errorf("%s already registered asset %s", issuer, symbol)
+	}
+
+	txn, err := as.register(asset, amount, opts)
+	if err != nil {
+		return reps.Asset{}, reps.Transaction{}, err
+	}
+
+	return asset, txn, nil
+}
+
+// Register an asset and queue the transaction issuing its first amount units to the issuer
+func (as *assetService) register(asset reps.Asset, amount int, opts reps.TxnOptions) (reps.Transaction, error) {
+	txn, err := as.transactionService.CreateAssetTransaction(asset.Issuer, asset.ID, []reps.Recipient{{To: asset.Issuer, Amount: amount}}, amount, opts)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	// Registered before the transaction is queued, it's checked against the asset
+	if err := as.blockchainRepo.CreateAsset(asset); err != nil {
+		return reps.Transaction{}, fmt.Errorf("%s, unable to register asset %s", err.Error(), asset.ID)
+	}
+
+	if _, err := as.mempoolService.AddTransaction(txn); err != nil {
+		return reps.Transaction{}, err
+	}
+
+	return txn, nil
+}
+
+// Queue a transaction issuing amount more units of an asset to its issuer
+func (as *assetService) IssueAsset(assetId string, amount int, opts reps.TxnOptions) (reps.Transaction, error) {
+	log.WithFields(log.Fields{"assetId": assetId, "amount": amount}).Info("Issuing asset")
+	asset, err := as.GetAsset(assetId)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	if amount <= 0 {
+		return reps.Transaction{}, fmt.Errorf("amount issued must be positive, not %d", amount)
+	}
+	if err := as.transactionService.VerifyIssuance(assetId, amount); err != nil {
+		return reps.Transaction{}, err
+	}
+
+	txn, err := as.transactionService.CreateAssetTransaction(asset.Issuer, assetId, []reps.Recipient{{To: asset.Issuer, Amount: amount}}, amount, opts)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	if _, err := as.mempoolService.AddTransaction(txn); err != nil {
+		return reps.Transaction{}, err
+	}
+
+	return txn, nil
+}
+
+// Queue a transaction sending amount units of an asset from one address to another
+func (as *assetService) TransferAsset(assetId string, from string, to string, amount int, opts reps.TxnOptions) (reps.Transaction, error) {
+	log.WithFields(log.Fields{"assetId": assetId, "from": from, "to": to, "amount": amount}).Info("Transferring asset")
+	if _, err := as.GetAsset(assetId); err != nil {
+		return reps.Transaction{}, err
+	}
+
+	txn, err := as.transactionService.CreateAssetTransaction(from, assetId, []reps.Recipient{{To: to, Amount: amount}}, 0, opts)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	if _, err := as.mempoolService.AddTransaction(txn); err != nil {
+		return reps.Transaction{}, err
+	}
+
+	return txn, nil
+}
+
+// Get an asset, with its supply on the chain
+func (as *assetService) GetAsset(assetId string) (reps.Asset, error) {
+	asset, err := as.blockchainRepo.GetAsset(assetId)
+	if err != nil {
+		return reps.Asset{}, fmt.Errorf("%s, asset %s does not exist", err.Error(), assetId)
+	}
+
+	asset.Supply, err = as.transactionService.GetAssetSupply(assetId)
+	if err != nil {
+		return reps.Asset{}, err
+	}
+
+	return asset, nil
+}
+
+// Get every registered asset, with their supplies on the chain
+func (as *assetService) GetAssets() ([]reps.Asset, error) {
+	assets, err := as.blockchainRepo.GetAssets()
+	if err != nil {
+		return []reps.Asset{}, err
+	}
+
+	for i := range assets {
+		assets[i].Supply, err = as.transactionService.GetAssetSupply(assets[i].ID)
+		if err != nil {
+			return []reps.Asset{}, err
+		}
+	}
+
+	return assets, nil
+}
+
+// Whether metadataHash is a hex sha256 hash, as an NFT's metadata is
+func IsValidMetadataHash(metadataHash string) bool {
+	return metadataHashPattern.MatchString(metadataHash)
+}
+
+// Register an NFT for a payload with the given hash, and queue the transaction minting its single unit to the issuer.
+// Its id commits to the hash, so the same issuer can only mint a payload once
+func (as *assetService) MintNFT(issuer string, name string, metadataHash string, opts reps.TxnOptions) (reps.Asset, reps.Transaction, error) {
+	log.WithFields(log.Fields{"issuer": issuer, "name": name, "metadataHash": metadataHash}).Info("Minting NFT")
+
+	metadataHash = strings.ToLower(metadataHash)
+	if !IsValidMetadataHash(metadataHash) {
+		return reps.Asset{}, reps.Transaction{}, fmt.Errorf("metadata hash must be a hex sha256 hash, not %s", metadataHash)
+	}
+	if len(name) > MaxAssetNameLen {
+		return reps.Asset{}, reps.Transaction{}, fmt.Errorf("NFT name can be at most %d bytes, not %d", MaxAssetNameLen, len(name))
+	}
+	if !IsValidAddress(issuer, as.params.NetworkByte) {
+		return reps.Asset{}, reps.Transaction{}, fmt.Errorf("malformed address: %s", issuer)
+	}
+
+	decoded := base58Decode([]byte(issuer))
+	nft := reps.Asset{
+		ID:           AssetID(decoded[1:len(decoded)-ChecksumLen], metadataHash),
+		Symbol:       NFTSymbol,
+		Name:         name,
+		Issuer:       issuer,
+		MaxSupply:    1,
+		NonFungible:  true,
+		MetadataHash: metadataHash,
+	}
+	if _, err := as.blockchainRepo.GetAsset(nft.ID); err == nil {
+		return reps.Asset{}, reps.Transaction{}, fmt.Errorf("%s already minted an NFT for metadata %s", issuer, metadataHash)
+	}
+
+	txn, err := as.register(nft, 1, opts)
+	if err != nil {
+		return reps.Asset{}, reps.Transaction{}, err
+	}
+
+	return nft, txn, nil
+}
+
+// Queue a transaction sending an NFT from its current owner to another address
+func (as *assetService) TransferNFT(nftId string, to string, opts reps.TxnOptions) (reps.Transaction, error) {
+	log.WithFields(log.Fields{"nftId": nftId, "to": to}).Info("Transferring NFT")
+	nft, err := as.getNFT(nftId)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	owner, err := as.getOwner(nft)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+	if owner == "" {
+		return reps.Transaction{}, fmt.Errorf("NFT %s isn't minted on the chain yet", nftId)
+	}
+
+	txn, err := as.transactionService.CreateAssetTransaction(owner, nftId, []reps.Recipient{{To: to, Amount: 1}}, 0, opts)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	if _, err := as.mempoolService.AddTransaction(txn); err != nil {
+		return reps.Transaction{}, err
+	}
+
+	return txn, nil
+}
+
+// Get an NFT, who owns it, and every transaction on the chain that minted or moved it
+func (as *assetService) GetNFTProvenance(nftId string) (reps.NFTProvenance, error) {
+	nft, err := as.getNFT(nftId)
+	if err != nil {
+		return reps.NFTProvenance{}, err
+	}
+
+	owner, err := as.getOwner(nft)
+	if err != nil {
+		return reps.NFTProvenance{}, err
+	}
+
+	blocks, err := as.blockchainRepo.GetBlockchain()
+	if err != nil {
+		return reps.NFTProvenance{}, err
+	}
+
+	// Oldest first, so a block's position is its height
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].Height < blocks[j].Height
+	})
+
+	// Whoever held the unit in each output that did, for telling who a transaction moved it from
+	holders := make(map[string]string)
+
+	history := make([]reps.NFTTransfer, 0)
+	for height, block := range blocks {
+		for _, txn := range block.Transactions {
+			for outIdx, output := range txn.Outputs {
+				if output.AssetID != nftId {
+					continue
+				}
+
+				from := ""
+				for _, input := range txn.Inputs {
+					if holder, ok := holders[reps.OutpointID(input.PrevTxnID, input.OutIdx)]; ok {
+						from = holder
+					}
+				}
+
+				to := addressFromPubKeyHash(output.PubKeyHash, as.params.NetworkByte)
+				holders[reps.OutpointID(txn.ID, outIdx)] = to
+				history = append(history, reps.NFTTransfer{
+					TxnID:     hex.EncodeToString(txn.ID),
+					BlockHash: hex.EncodeToString(block.Hash),
+					Height:    height,
+					Timestamp: block.Timestamp,
+					From:      from,
+					To:        to,
+				})
+			}
+		}
+	}
+
+	return reps.NFTProvenance{NFT: nft, Owner: owner, History: history}, nil
+}
+
+func (as *assetService) getNFT(nftId string) (reps.Asset, error) {
+	nft, err := as.GetAsset(nftId)
+	if err != nil {
+		return reps.Asset{}, err
+	}
+	if !nft.NonFungible {
+		return reps.Asset{}, fmt.Errorf("asset %s is not an NFT", nftId)
+	}
+
+	return nft, nil
+}
+
+// Address holding an NFT on the chain, empty if its mint isn't mined yet
+func (as *assetService) getOwner(nft reps.Asset) (string, error) {
+	unspentOutputs, err := as.blockchainRepo.GetUnspentAssetOutputs(nft.ID)
+	if err != nil {
+		return "", err
+	}
+	if len(unspentOutputs) == 0 {
+		return "", nil
+	}
+
+	pubKeyHash, _ := hex.DecodeString(unspentOutputs[0].PubKeyHash)
+	return addressFromPubKeyHash(pubKeyHash, as.params.NetworkByte), nil
+}
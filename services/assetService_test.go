@@ -0,0 +1,167 @@
+package services_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssetIssuanceAndTransfer(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+	mempoolService := ts.mempoolService
+	assetService := services.NewAssetService(repo, txnService, mempoolService, &mainnet)
+
+	issuer, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, issuer.Address)
+
+	_, _, err = assetService.CreateAsset(issuer.Address, "gold", "Gold", 100, 150, true, reps.TxnOptions{})
+	assert.Error(t, err)
+
+	asset, _, err := assetService.CreateAsset(issuer.Address, "GOLD", "Gold", 100, 150, true, reps.TxnOptions{})
+	assert.NoError(t, err)
+	_, _, err = assetService.CreateAsset(issuer.Address, "GOLD", "Gold", 100, 150, true, reps.TxnOptions{})
+	assert.Error(t, err)
+
+	// Pending until it's mined
+	asset, err = assetService.GetAsset(asset.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, asset.Supply)
+	balance, err := mempoolService.GetAddressBalance(issuer.Address)
+	assert.NoError(t, err)
+	assert.Len(t, balance.Assets, 1)
+	assert.Equal(t, 100, balance.Assets[0].Pending)
+
+	_, err = mempoolService.MinePendingTransactions(issuer.Address, "")
+	assert.NoError(t, err)
+
+	asset, err = assetService.GetAsset(asset.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, asset.Supply)
+
+	_, err = assetService.TransferAsset(asset.ID, issuer.Address, to.Address, 30, reps.TxnOptions{})
+	assert.NoError(t, err)
+	_, err = mempoolService.MinePendingTransactions(issuer.Address, "")
+	assert.NoError(t, err)
+
+	// Coins and units of the asset are counted apart
+	balance, err = txnService.GetAddressBalance(to.Address)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, balance.Confirmed)
+	assert.Equal(t, []reps.AssetBalance{{AssetID: asset.ID, Symbol: "GOLD", Confirmed: 30}}, balance.Assets)
+	balance, err = txnService.GetAddressBalance(issuer.Address)
+	assert.NoError(t, err)
+	assert.Equal(t, 70, balance.Assets[0].Confirmed)
+
+	_, err = assetService.TransferAsset(asset.ID, to.Address, issuer.Address, 40, reps.TxnOptions{})
+	assert.Error(t, err)
+
+	// Capped by the max supply
+	_, err = assetService.IssueAsset(asset.ID, 60, reps.TxnOptions{})
+	assert.Error(t, err)
+	_, err = assetService.IssueAsset(asset.ID, 50, reps.TxnOptions{})
+	assert.NoError(t, err)
+	_, err = mempoolService.MinePendingTransactions(issuer.Address, "")
+	assert.NoError(t, err)
+
+	asset, err = assetService.GetAsset(asset.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 150, asset.Supply)
+}
+
+func TestOnlyIssuerCanIssueAsset(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+	mempoolService := ts.mempoolService
+	assetService := services.NewAssetService(repo, txnService, mempoolService, &mainnet)
+
+	issuer, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	other, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, issuer.Address)
+
+	asset, _, err := assetService.CreateAsset(issuer.Address, "SILVER", "", 10, 0, false, reps.TxnOptions{})
+	assert.NoError(t, err)
+	_, err = mempoolService.MinePendingTransactions(other.Address, "")
+	assert.NoError(t, err)
+
+	// Signed by its sender, but they didn't issue the asset
+	txn, err := txnService.CreateAssetTransaction(other.Address, asset.ID, []reps.Recipient{{To: other.Address, Amount: 10}}, 10, reps.TxnOptions{})
+	assert.NoError(t, err)
+	valid, err := txnService.VerifyTransaction(txn)
+	assert.False(t, valid)
+	var verificationErr *services.TxnVerificationError
+	assert.True(t, errors.As(err, &verificationErr))
+	assert.Equal(t, services.InvalidTxnAsset, verificationErr.Reason)
+
+	// Nor can the issuer issue more once it's out, unless it's mintable
+	_, err = assetService.IssueAsset(asset.ID, 5, reps.TxnOptions{})
+	assert.Error(t, err)
+}
+
+func TestNFTProvenanceFollowsTransfers(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+	mempoolService := ts.mempoolService
+	assetService := services.NewAssetService(repo, txnService, mempoolService, &mainnet)
+
+	issuer, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	buyer, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, issuer.Address)
+
+	metadataHash := fmt.Sprintf("%x", sha256.Sum256([]byte("artwork")))
+	_, _, err = assetService.MintNFT(issuer.Address, "Artwork", "artwork", reps.TxnOptions{})
+	assert.Error(t, err)
+
+	nft, mint, err := assetService.MintNFT(issuer.Address, "Artwork", metadataHash, reps.TxnOptions{})
+	assert.NoError(t, err)
+	_, _, err = assetService.MintNFT(issuer.Address, "Copy", metadataHash, reps.TxnOptions{})
+	assert.Error(t, err)
+
+	// Not the owner's to send until the mint is on the chain
+	_, err = assetService.TransferNFT(nft.ID, buyer.Address, reps.TxnOptions{})
+	assert.Error(t, err)
+
+	_, err = mempoolService.MinePendingTransactions(buyer.Address, "")
+	assert.NoError(t, err)
+	transfer, err := assetService.TransferNFT(nft.ID, buyer.Address, reps.TxnOptions{})
+	assert.NoError(t, err)
+	_, err = mempoolService.MinePendingTransactions(buyer.Address, "")
+	assert.NoError(t, err)
+
+	// Only ever one of it
+	_, err = assetService.IssueAsset(nft.ID, 1, reps.TxnOptions{})
+	assert.Error(t, err)
+
+	provenance, err := assetService.GetNFTProvenance(nft.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, buyer.Address, provenance.Owner)
+	assert.Equal(t, metadataHash, provenance.NFT.MetadataHash)
+	assert.Equal(t, 1, provenance.NFT.Supply)
+	assert.Len(t, provenance.History, 2)
+	assert.Equal(t, hex.EncodeToString(mint.ID), provenance.History[0].TxnID)
+	assert.Equal(t, "", provenance.History[0].From)
+	assert.Equal(t, issuer.Address, provenance.History[0].To)
+	assert.Equal(t, 1, provenance.History[0].Height)
+	assert.Equal(t, hex.EncodeToString(transfer.ID), provenance.History[1].TxnID)
+	assert.Equal(t, issuer.Address, provenance.History[1].From)
+	assert.Equal(t, buyer.Address, provenance.History[1].To)
+
+	// Fungible assets don't have one
+	asset, _, err := assetService.CreateAsset(issuer.Address, "GOLD", "", 10, 0, false, reps.TxnOptions{})
+	assert.NoError(t, err)
+	_, err = assetService.GetNFTProvenance(asset.ID)
+	assert.Error(t, err)
+}
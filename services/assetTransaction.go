@@ -0,0 +1,259 @@
+package services
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Create and sign a transaction sending units of an asset from a wallet to recipients. issue is how many of those units are
+// newly issued, which only the asset's issuer can do, and the rest come out of the wallet's own units.
+// Fees are paid in the chain's own coin. Change of either goes back to the wallet, or a fresh change address if it's from an HD wallet
+func (ts *transactionService) CreateAssetTransaction(from string, assetId string, recipients []reps.Recipient, issue int, opts reps.TxnOptions) (reps.Transaction, error) {
+	log.WithFields(log.Fields{"from": from, "assetId": assetId, "recipients": utils.Pretty(recipients), "issue": issue}).Info("Creating asset transaction...")
+
+	amount, err := totalAmount(recipients)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+	for _, recipient := range recipients {
+		if !IsValidAddress(recipient.To, ts.params.NetworkByte) {
+			err := fmt.Errorf("address %s is not a valid address for network %d, cancelling transaction", recipient.To, ts.params.NetworkByte)
+			log.Error(err)
+			return reps.Transaction{}, err
+		}
+	}
+	if issue < 0 || issue > amount {
+		return reps.Transaction{}, fmt.Errorf("can only issue between 0 and the %d units sent, not %d", amount, issue)
+	}
+	if err := validateOptions(opts); err != nil {
+		return reps.Transaction{}, err
+	}
+
+	wallet, err := ts.walletService.GetWallet(from)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+	if wallet.WatchOnly {
+		err := fmt.Errorf("%w: %s", ErrWatchOnly, from)
+		log.Error(err)
+		return reps.Transaction{}, err
+	}
+
+	scheme, err := GetSignatureScheme(wallet.SigAlgorithm)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	selector, err := GetCoinSelector(opts.CoinSelection)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	pubKey, _ := hex.DecodeString(wallet.PublicKey)
+	pubKeyHash, _ := createPubKeyHash(pubKey)
+
+	// Units that aren't issued come out of the wallet's own
+	assetInputs := make([]reps.TxnInput, 0)
+	assetIn := 0
+	spent := amount - issue
+	if spent > 0 {
+		for _, unspent := range selector.Select(ts.findAssetOutputs(pubKeyHash, assetId), spent) {
+			assetInputs = append(assetInputs, newTxnInput(unspent, pubKey))
+			assetIn += unspent.Value
+		}
+		if assetIn < spent {
+			err := fmt.Errorf("%s only has %d units of asset %s to send, not %d, Cancelling transaction", from, assetIn, assetId, spent)
+			log.Error(err)
+			return reps.Transaction{}, err
+		}
+	}
+
+	// Both kinds of change go to the same address
+	changeAddress := ""
+	getChangeAddress := func() (string, error) {
+		if changeAddress == "" {
+			changeAddress, err = ts.getChangeAddress(wallet)
+		}
+		return changeAddress, err
+	}
+
+	assetOutputs := make([]reps.TxnOutput, 0, len(recipients)+1)
+	for _, recipient := range recipients {
+		assetOutputs = append(assetOutputs, ts.newAssetOutput(recipient.Amount, recipient.To, assetId))
+	}
+	if assetIn > spent {
+		address, err := getChangeAddress()
+		if err != nil {
+			return reps.Transaction{}, err
+		}
+		assetOutputs = append(assetOutputs, ts.newAssetOutput(assetIn-spent, address, assetId))
+	}
+
+	// An issuance spends at least one of the issuer's coins, as the signature on it is what allows the new units
+	target := opts.Fee
+	if issue > 0 && target == 0 {
+		target = 1
+	}
+
+	var txn reps.Transaction
+	nativeIn := 0
+	fee := opts.Fee
+	for {
+		txn = reps.Transaction{
+			SigAlgorithm: scheme.Algorithm(),
+			Memo:         opts.Memo,
+			LockTime:     opts.LockTime,
+			Replaceable:  opts.Replaceable,
+			Inputs:       append([]reps.TxnInput{}, assetInputs...),
+			Outputs:      append([]reps.TxnOutput{}, assetOutputs...),
+		}
+		nativeIn = 0
+		if target > 0 {
+			for _, unspent := range ts.selectOutputs(pubKeyHash, target, selector) {
+				txn.Inputs = append(txn.Inputs, newTxnInput(unspent, pubKey))
+				nativeIn += unspent.Value
+			}
+		}
+		if nativeIn < target {
+			err := fmt.Errorf("%s only has %d coins, not the %d needed, Cancelling transaction", from, nativeIn, target)
+			log.Error(err)
+			return reps.Transaction{}, err
+		}
+		if opts.FeeRate == 0 {
+			break
+		}
+
+		// Sized with a change output, like any other transaction paying by rate. The fee is only known once the inputs are
+		sized := txn
+		sized.Outputs = append(append([]reps.TxnOutput{}, txn.Outputs...), ts.NewTxnOutput(nativeIn, from))
+		fee = ts.sizedFee(sized, opts.FeeRate)
+		if fee <= nativeIn {
+			break
+		}
+		target = fee
+	}
+
+	txn.Fee = fee
+	// Change too small to be worth an output goes to the miner instead
+	if change := nativeIn - fee; change > 0 && change < ts.params.DustThreshold {
+		txn.Fee += change
+	} else if change > 0 {
+		address, err := getChangeAddress()
+		if err != nil {
+			return reps.Transaction{}, err
+		}
+		txn.Outputs = append(txn.Outputs, ts.NewTxnOutput(change, address))
+	}
+
+	ts.setID(&txn)
+
+	prevTxns, err := ts.GetPrevTransactions(txn)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	return ts.Sign(wallet, txn, prevTxns)
+}
+
+// Output holding value units of an asset, locked to address
+func (ts *transactionService) newAssetOutput(value int, address string, assetId string) reps.TxnOutput {
+	output := ts.NewTxnOutput(value, address)
+	output.AssetID = assetId
+	return output
+}
+
+// Units of each asset txn issues, those it sends on beyond what its inputs hold
+func (ts *transactionService) GetIssuedAssets(txn reps.Transaction) (map[string]int, error) {
+	issued := make(map[string]int)
+	if ts.IsCoinbaseTransaction(txn) {
+		return issued, nil
+	}
+
+	prevTxns, err := ts.GetPrevTransactions(txn)
+	if err != nil {
+		return map[string]int{}, err
+	}
+
+	for _, output := range txn.Outputs {
+		if output.AssetID != "" {
+			issued[output.AssetID] += output.Value
+		}
+	}
+	for _, input := range txn.Inputs {
+		prevTxn := prevTxns[hex.EncodeToString(input.PrevTxnID)]
+		if input.OutIdx >= 0 && input.OutIdx < len(prevTxn.Outputs) && prevTxn.Outputs[input.OutIdx].AssetID != "" {
+			issued[prevTxn.Outputs[input.OutIdx].AssetID] -= prevTxn.Outputs[input.OutIdx].Value
+		}
+	}
+
+	for assetId, amount := range issued {
+		if amount <= 0 {
+			delete(issued, assetId)
+		}
+	}
+
+	return issued, nil
+}
+
+// Check amount more units of an asset can be issued on top of its supply on the chain
+func (ts *transactionService) VerifyIssuance(assetId string, amount int) error {
+	asset, err := ts.blockchainRepo.GetAsset(assetId)
+	if err != nil {
+		return fmt.Errorf("%s, asset %s does not exist", err.Error(), assetId)
+	}
+
+	supply, err := ts.GetAssetSupply(assetId)
+	if err != nil {
+		return err
+	}
+
+	if !asset.Mintable && supply > 0 {
+		return fmt.Errorf("asset %s was already issued and can't be issued again", assetId)
+	}
+	if asset.MaxSupply > 0 && supply+amount > asset.MaxSupply {
+		return fmt.Errorf("issuing %d units of asset %s would take its supply of %d past the maximum of %d", amount, assetId, supply, asset.MaxSupply)
+	}
+
+	return nil
+}
+
+// Units of an asset issued so far. They can't be destroyed, so that's every unit held in the UTXO set
+func (ts *transactionService) GetAssetSupply(assetId string) (int, error) {
+	unspentOutputs, err := ts.blockchainRepo.GetUnspentAssetOutputs(assetId)
+	if err != nil {
+		return 0, err
+	}
+
+	supply := 0
+	for _, unspent := range unspentOutputs {
+		supply += unspent.Value
+	}
+
+	return supply, nil
+}
+
+// Fails unless one of txn's inputs spends an output of the asset's issuer. Signatures must already be verified
+func (ts *transactionService) verifyIssuer(txn reps.Transaction, prevTxns map[string]reps.Transaction, assetId string) error {
+	asset, err := ts.blockchainRepo.GetAsset(assetId)
+	if err != nil {
+		return fmt.Errorf("%s, asset %s does not exist", err.Error(), assetId)
+	}
+
+	decoded := base58Decode([]byte(asset.Issuer))
+	issuerPubKeyHash := decoded[1 : len(decoded)-ChecksumLen]
+
+	for _, input := range txn.Inputs {
+		prevOutput := prevTxns[hex.EncodeToString(input.PrevTxnID)].Outputs[input.OutIdx]
+		if bytes.Equal(prevOutput.PubKeyHash, issuerPubKeyHash) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("only its issuer %s can issue units of asset %s", asset.Issuer, assetId)
+}
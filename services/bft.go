@@ -0,0 +1,116 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"github.com/brucetieu/blockchain/repository"
+	reps "github.com/brucetieu/blockchain/representations"
+)
+
+// Prepended to a block's hash before a validator signs its vote for it, so a vote can never double as any other signature
+var blockVotePrefix = []byte("Blockchain Block Vote:\n")
+
+// Whether blocks on the chain are proposed in turn by a fixed set of validators, who vote them final
+func IsBFT(params *reps.ChainParams) bool {
+	return params.Consensus == ConsensusBFT
+}
+
+// Whether blocks on the chain are signed by the validator elected to propose them, instead of mined
+func HasProposers(params *reps.ChainParams) bool {
+	return IsProofOfStake(params) || IsBFT(params)
+}
+
+// Addresses of the validators a BFT chain starts with, in the order they take turns proposing blocks. They're changed
+// on the chain from there, see ValidatorsAt
+func BFTValidators(params *reps.ChainParams) []string {
+	validators := make([]string, 0)
+	for _, validator := range strings.Split(params.Validators, ",") {
+		if validator = strings.TrimSpace(validator); validator != "" {
+			validators = append(validators, validator)
+		}
+	}
+	return validators
+}
+
+// Votes that finalize a block out of validators: more than two thirds of them, 2f+1 when there are 3f+1. Two blocks
+// at the same height can't both get that many unless over a third of the validators vote for both
+func BFTQuorum(validators int) int {
+	return validators*2/3 + 1
+}
+
+// Public key hash of the validator whose turn it is to propose the block at height in round. Turns go round the
+// validators one height at a time, and each round moves the turn on to the next one
+func bftProposer(validators []string, height int, round int) []byte {
+	if len(validators) == 0 {
+		return nil
+	}
+	return pubKeyHashFromAddress(validators[(height+round)%len(validators)])
+}
+
+// Round a block with timestamp on top of parent can be proposed in at the latest: one more for every TargetBlockTime
+// gone by since the parent, so a validator that doesn't propose its block in time is skipped
+func bftRound(params *reps.ChainParams, parent reps.Block, timestamp int64) int {
+	interval := int64(params.TargetBlockTime) * 1000
+	if interval <= 0 || timestamp <= parent.Timestamp {
+		return 0
+	}
+	return int((timestamp - parent.Timestamp) / interval)
+}
+
+// First round, up to the one timestamp is in, in which it's the turn of the validator with pubKeyHash, out of
+// validators, to propose the block at height on top of parent. -1 if it's in none of them, or the chain isn't BFT
+func bftTurn(params *reps.ChainParams, validators []string, parent reps.Block, timestamp int64, height int, pubKeyHash []byte) int {
+	if !IsBFT(params) {
+		return -1
+	}
+
+	latest := bftRound(params, parent, timestamp)
+	for round := 0; round <= latest && round < len(validators); round++ {
+		if bytes.Equal(bftProposer(validators, height, round), pubKeyHash) {
+			return round
+		}
+	}
+	return -1
+}
+
+// Public key hash of the validator that has to propose block on top of parent: the one elected by stake on a proof
+// of stake chain, or whose turn it is in the block's round on a BFT chain, out of its validators at the block's
+// height. Nil when anyone can
+func electedProposer(blockchainRepo repository.BlockchainRepository, params *reps.ChainParams, parent reps.Block, block reps.Block) ([]byte, error) {
+	if !IsBFT(params) {
+		return ElectProposer(blockchainRepo, block.PrevHash, block.Height)
+	}
+
+	if block.Round < 0 || block.Round > bftRound(params, parent, block.Timestamp) {
+		return nil, fmt.Errorf("%w: block %s is proposed in round %d, which hadn't started by its timestamp", ErrInvalidBlock, block.ID, block.Round)
+	}
+
+	validators, err := ValidatorsAt(blockchainRepo, params, block.Height)
+	if err != nil {
+		return nil, err
+	}
+	return bftProposer(validators, block.Height, block.Round), nil
+}
+
+// What a validator signs to vote for a block
+func VoteHash(block reps.Block) []byte {
+	hash := sha256.Sum256(append(append([]byte{}, blockVotePrefix...), block.Hash...))
+	return hash[:]
+}
+
+// Once a block on a BFT chain is final, nothing can branch off at or below it
+func (bs *blockService) checkFinalized(block reps.Block, height int) error {
+	if !IsBFT(bs.params) {
+		return nil
+	}
+
+	finalized, err := bs.blockchainRepo.GetLastFinalizedBlock()
+	if err == nil && height <= finalized.Height {
+		return fmt.Errorf("%w: block %s at height %d branches off below the block finalized at height %d", ErrInvalidBlock, block.ID, height, finalized.Height)
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"golang.org/x/crypto/blake2b"
+)
+
+// Algorithms a chain's block hashes can be taken with. Picked when the genesis block is mined, and fixed from then on
+const (
+	HashSHA256  = "sha256"
+	HashSHA256d = "sha256d"
+	HashBLAKE2b = "blake2b"
+)
+
+var (
+	blockHashers = map[string]BlockHasher{
+		HashSHA256:  &sha256Hasher{},
+		HashSHA256d: &sha256dHasher{},
+		HashBLAKE2b: &blake2bHasher{},
+	}
+)
+
+// Hashes block headers for proof of work. Every hash is 32 bytes, so difficulty targets work the same whatever the algorithm
+type BlockHasher interface {
+	Algorithm() string
+	Hash(data []byte) []byte
+}
+
+// Get the hasher for an algorithm. Chains from before the algorithm could be picked have none, and are sha256
+func GetBlockHasher(algorithm string) (BlockHasher, error) {
+	if algorithm == "" {
+		algorithm = HashSHA256
+	}
+
+	hasher, ok := blockHashers[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("unsupported hash algorithm: %s", algorithm)
+	}
+
+	return hasher, nil
+}
+
+// Hasher for the chain's algorithm. The algorithm is checked when the chain params are loaded, so it's always known
+func chainHasher(params *reps.ChainParams) BlockHasher {
+	hasher, err := GetBlockHasher(params.HashAlgorithm)
+	if err != nil {
+		return blockHashers[HashSHA256]
+	}
+	return hasher
+}
+
+type sha256Hasher struct{}
+
+func (h *sha256Hasher) Algorithm() string {
+	return HashSHA256
+}
+
+func (h *sha256Hasher) Hash(data []byte) []byte {
+	hash := sha256.Sum256(data)
+	return hash[:]
+}
+
+// sha256 twice, as Bitcoin does
+type sha256dHasher struct{}
+
+func (h *sha256dHasher) Algorithm() string {
+	return HashSHA256d
+}
+
+func (h *sha256dHasher) Hash(data []byte) []byte {
+	first := sha256.Sum256(data)
+	hash := sha256.Sum256(first[:])
+	return hash[:]
+}
+
+// BLAKE2b with a 256 bit digest
+type blake2bHasher struct{}
+
+func (h *blake2bHasher) Algorithm() string {
+	return HashBLAKE2b
+}
+
+func (h *blake2bHasher) Hash(data []byte) []byte {
+	hash := blake2b.Sum256(data)
+	return hash[:]
+}
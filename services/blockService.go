@@ -1,6 +1,11 @@
 package services
 
 import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
 	"time"
 
 	"github.com/brucetieu/blockchain/repository"
@@ -9,23 +14,56 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+var (
+	MedianTimeSpan     = 11            // Blocks before a new one whose median timestamp it has to come after
+	MaxFutureBlockTime = 2 * time.Hour // How far ahead of the node's clock a block's timestamp can be
+)
+
 type BlockService interface {
 	CreateBlock(txns []reps.Transaction, prevHash []byte) (reps.Block, error)
+	AssembleBlock(txns []reps.Transaction, prevHash []byte) (reps.Block, error)
+	AddBlock(block reps.Block) error
+	ValidateBlock(block reps.Block) error
+	NextDifficulty(prevHash []byte) (int, error)
 }
 
 type blockService struct {
 	blockchainRepo repository.BlockchainRepository
+	engine         ConsensusEngine
+	params         *reps.ChainParams
 }
 
-func NewBlockService(blockchainRepo repository.BlockchainRepository) BlockService {
+func NewBlockService(blockchainRepo repository.BlockchainRepository, params *reps.ChainParams) BlockService {
 	return &blockService{
 		blockchainRepo: blockchainRepo,
+		engine:         NewConsensusEngine(blockchainRepo, nil, nil, params),
+		params:         params,
 	}
 }
 
 // Create a single block in the block chain.
 func (bs *blockService) CreateBlock(txns []reps.Transaction, prevHash []byte) (reps.Block, error) {
 	log.Info("Mining block...")
+	newBlock, err := bs.AssembleBlock(txns, prevHash)
+	if err != nil {
+		return reps.Block{}, err
+	}
+
+	// Nobody signs it, on a chain with proposers it's only ever the genesis
+	newBlock, err = bs.engine.Seal(newBlock, "")
+	if err != nil {
+		return reps.Block{}, err
+	}
+
+	if err := bs.AddBlock(newBlock); err != nil {
+		return reps.Block{}, err
+	}
+
+	return newBlock, nil
+}
+
+// Block with txns on top of the one with prevHash, at the difficulty the chain calls for there, with its proof of work left to solve
+func (bs *blockService) AssembleBlock(txns []reps.Transaction, prevHash []byte) (reps.Block, error) {
 	id := uuid.Must(uuid.NewRandom()).String()
 
 	// Set BlockID in transactions to be Id of block
@@ -33,23 +71,312 @@ func (bs *blockService) CreateBlock(txns []reps.Transaction, prevHash []byte) (r
 		txns[i].BlockID = id
 	}
 
-	newBlock := reps.Block{
+	parent, height, err := bs.parentBlock(prevHash)
+	if err != nil {
+		return reps.Block{}, err
+	}
+	difficulty, err := bs.engine.NextDifficulty(parent, height)
+	if err != nil {
+		return reps.Block{}, err
+	}
+
+	// Blocks mined in quick succession, or on a clock that's behind, still have to come after the median
+	timestamp := time.Now().UnixMilli()
+	if height > 0 {
+		medianTime, err := bs.medianTimePast(prevHash)
+		if err != nil {
+			return reps.Block{}, err
+		}
+		if timestamp <= medianTime {
+			timestamp = medianTime + 1
+		}
+	}
+
+	merkleRoot, err := TxnAssembler.MerkleRoot(txns)
+	if err != nil {
+		return reps.Block{}, err
+	}
+
+	block := reps.Block{
 		ID:           id,
-		Timestamp:    time.Now().UnixMilli(),
+		Timestamp:    timestamp,
 		Transactions: txns,
 		PrevHash:     prevHash,
+		Difficulty:   difficulty,
+		MerkleRoot:   merkleRoot,
+		Height:       height,
+		Version:      BlockVersion(),
+		ChainID:      ChainIdentifier(bs.params),
+	}
+	block.ChainWork = nextChainWork(parent, block)
+	if err := bs.checkBlockSize(block); err != nil {
+		return reps.Block{}, err
 	}
-	// proof := bs.powService.Solve()
-	proof := NewProofOfWorkService(&newBlock)
-	nounce, hash := proof.Solve()
-	newBlock.Nounce = nounce
-	newBlock.Hash = hash
 
-	// Persist
-	err := bs.blockchainRepo.CreateBlock(newBlock)
+	return block, nil
+}
+
+// Validate a solved block and persist it, with its chain work on top of its parent's
+func (bs *blockService) AddBlock(block reps.Block) error {
+	if err := bs.ValidateBlock(block); err != nil {
+		return err
+	}
+
+	parent, _, err := bs.parentBlock(block.PrevHash)
 	if err != nil {
-		return reps.Block{}, err
+		return err
 	}
+	block.ChainWork = nextChainWork(parent, block)
 
-	return newBlock, nil
+	return bs.blockchainRepo.CreateBlock(block)
+}
+
+// Recompute a block's merkle root and check its header through the chain's consensus engine: the merkle root is over
+// its transactions, and the header has to hash to the block's hash and prove what the engine calls for on top of its
+// parent, e.g. a hash under the target for the difficulty after the parent. It has to be for this chain, its height one more than its parent's, and its timestamp after the
+// median of the MedianTimeSpan blocks before it and no more than MaxFutureBlockTime ahead of the node's clock
+func (bs *blockService) ValidateBlock(block reps.Block) error {
+	parent, height, err := bs.parentBlock(block.PrevHash)
+	if err != nil {
+		return err
+	}
+	if block.Height != height {
+		return fmt.Errorf("%w: block %s has height %d, expected %d", ErrInvalidBlock, block.ID, block.Height, height)
+	}
+	if block.ChainID != ChainIdentifier(bs.params) {
+		return fmt.Errorf("%w: block %s is for chain %q, not %q", ErrInvalidBlock, block.ID, block.ChainID, ChainIdentifier(bs.params))
+	}
+
+	if err := bs.checkTimestamp(block, height); err != nil {
+		return err
+	}
+
+	if err := bs.checkCheckpoints(block, height); err != nil {
+		return err
+	}
+
+	if err := bs.checkFinalized(block, height); err != nil {
+		return err
+	}
+
+	if err := bs.checkBlockSize(block); err != nil {
+		return err
+	}
+
+	// Otherwise transactions could be swapped out from under the hash
+	merkleRoot, err := TxnAssembler.MerkleRoot(block.Transactions)
+	if err != nil {
+		return fmt.Errorf("%w: block %s: %s", ErrInvalidBlock, block.ID, err.Error())
+	}
+	if !bytes.Equal(block.MerkleRoot, merkleRoot) {
+		return fmt.Errorf("%w: merkle root of block %s doesn't match its transactions", ErrInvalidBlock, block.ID)
+	}
+
+	return bs.engine.ValidateHeader(block, &parent)
+}
+
+// Bytes block takes up serialized, transactions included. Chain work is the node's own bookkeeping, so it isn't counted
+func BlockSize(block reps.Block) int {
+	block.ChainWork = ""
+	return len(BlockAssembler.ToBlockBytes(&block))
+}
+
+// Blocks can be no bigger than the chain's MaxBlockSize
+func (bs *blockService) checkBlockSize(block reps.Block) error {
+	if bs.params.MaxBlockSize <= 0 {
+		return nil
+	}
+
+	if size := BlockSize(block); size > bs.params.MaxBlockSize {
+		return fmt.Errorf("%w: block %s takes up %d bytes, more than the limit of %d", ErrInvalidBlock, block.ID, size, bs.params.MaxBlockSize)
+	}
+
+	return nil
+}
+
+// A block's timestamp can't go back past the median of the blocks before it, which a single miner can't drag back
+// on their own, nor run far ahead of the clock, which would throw off retargeting
+func (bs *blockService) checkTimestamp(block reps.Block, height int) error {
+	latest := time.Now().Add(MaxFutureBlockTime).UnixMilli()
+	if block.Timestamp > latest {
+		return fmt.Errorf("%w: block %s has timestamp %d, more than %s in the future", ErrInvalidBlock, block.ID, block.Timestamp, MaxFutureBlockTime)
+	}
+
+	if height == 0 {
+		return nil
+	}
+
+	medianTime, err := bs.medianTimePast(block.PrevHash)
+	if err != nil {
+		return err
+	}
+	if block.Timestamp <= medianTime {
+		return fmt.Errorf("%w: block %s has timestamp %d, not after the median of %d of the blocks before it", ErrInvalidBlock, block.ID, block.Timestamp, medianTime)
+	}
+
+	return nil
+}
+
+// A block at a checkpoint's height has to be the checkpointed block, and once the chain is past the last checkpoint
+// nothing can branch off below it
+func (bs *blockService) checkCheckpoints(block reps.Block, height int) error {
+	if hash, ok := Checkpoints[height]; ok && hex.EncodeToString(block.Hash) != hash {
+		return fmt.Errorf("%w: block %s at height %d doesn't match checkpoint %s", ErrInvalidBlock, block.ID, height, hash)
+	}
+
+	last := LastCheckpoint()
+	if height > last {
+		return nil
+	}
+
+	lastBlock, err := bs.blockchainRepo.GetLastBlock()
+	if err == nil && lastBlock.Height >= last {
+		return fmt.Errorf("%w: block %s at height %d branches off below the checkpoint at height %d", ErrInvalidBlock, block.ID, height, last)
+	}
+
+	return nil
+}
+
+// Median timestamp of the MedianTimeSpan blocks up to the one with prevHash, or as many as there are. They're found
+// by following each block's link to its parent, through side branches too, so a block is held to its own ancestors
+// rather than whatever's on the chain at their heights. The walk stops at the genesis block, or the snapshot the chain
+// was started from
+func (bs *blockService) medianTimePast(prevHash []byte) (int64, error) {
+	timestamps := make([]int64, 0, MedianTimeSpan)
+	for hash := prevHash; len(hash) > 0 && len(timestamps) < MedianTimeSpan; {
+		block, err := knownBlock(bs.blockchainRepo, hash)
+		if err != nil {
+			if len(timestamps) == 0 {
+				return 0, err
+			}
+			break
+		}
+		timestamps = append(timestamps, block.Timestamp)
+		hash = block.PrevHash
+	}
+	if len(timestamps) == 0 {
+		return 0, nil
+	}
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i] < timestamps[j] })
+
+	return timestamps[len(timestamps)/2], nil
+}
+
+// Difficulty of the block mined on top of the one with prevHash
+func (bs *blockService) NextDifficulty(prevHash []byte) (int, error) {
+	parent, height, err := bs.parentBlock(prevHash)
+	if err != nil {
+		return 0, err
+	}
+
+	return bs.engine.NextDifficulty(parent, height)
+}
+
+// Difficulty after retargeting, when blocks took actual milliseconds instead of expected. Each bit of difficulty
+// doubles the work, so it changes by the log2 of how far off they were, by at most 2 bits, i.e. 4 times as much work
+func RetargetDifficulty(difficulty int, actual int64, expected int64) int {
+	if actual <= 0 {
+		actual = 1
+	}
+
+	change := int(math.Round(math.Log2(float64(expected) / float64(actual))))
+	if change > 2 {
+		change = 2
+	} else if change < -2 {
+		change = -2
+	}
+
+	difficulty += change
+	if difficulty < MinDifficulty {
+		return MinDifficulty
+	}
+	if difficulty > MaxDifficulty {
+		return MaxDifficulty
+	}
+	return difficulty
+}
+
+// The block with prevHash, and the height of the block after it. No prevHash means the next block is the genesis
+func (bs *blockService) parentBlock(prevHash []byte) (reps.Block, int, error) {
+	if len(prevHash) == 0 {
+		return reps.Block{}, 0, nil
+	}
+
+	parent, err := bs.blockchainRepo.GetBlockByHash(prevHash)
+	if err != nil {
+		return reps.Block{}, 0, fmt.Errorf("%w: no block with hash %x to build on", ErrInvalidBlock, prevHash)
+	}
+
+	return parent, parent.Height + 1, nil
+}
+
+// Give every block its height if there's a chain from before blocks had one, by following the links from the
+// genesis block to each block's child
+func IndexBlockHeightsAtStartup(blockchainRepo repository.BlockchainRepository) {
+	count, err := blockchainRepo.CountBlocks()
+	if err != nil {
+		log.Fatal("Error counting blocks: ", err.Error())
+	}
+	if count <= 1 {
+		return
+	}
+	if _, err := blockchainRepo.GetBlockByHeight(count - 1); err == nil {
+		return
+	}
+	// Blocks of a chain started from a snapshot came with their heights, and don't link back to a genesis block
+	if _, err := blockchainRepo.GetSnapshotBase(); err == nil {
+		return
+	}
+
+	log.Info("Indexing block heights")
+	blocks, err := blockchainRepo.GetBlockchain()
+	if err != nil {
+		log.Fatal("Error reading blockchain: ", err.Error())
+	}
+
+	children := make(map[string]reps.Block)
+	for _, block := range blocks {
+		children[hex.EncodeToString(block.PrevHash)] = block
+	}
+
+	heights := make(map[string]int)
+	block, ok := children[""]
+	for height := 0; ok; height++ {
+		heights[block.ID] = height
+		block, ok = children[hex.EncodeToString(block.Hash)]
+	}
+	if len(heights) != count {
+		log.Warnf("Only %d of %d blocks link back to the genesis block", len(heights), count)
+	}
+
+	if err := blockchainRepo.SetBlockHeights(heights); err != nil {
+		log.Fatal("Error indexing block heights: ", err.Error())
+	}
+}
+
+// Give every block its chain work if there's a chain from before it was recorded, adding up the work of the blocks
+// from the genesis block on
+func IndexChainWorkAtStartup(blockchainRepo repository.BlockchainRepository) {
+	lastBlock, err := blockchainRepo.GetLastBlock()
+	if err != nil || lastBlock.ChainWork != "" {
+		return
+	}
+
+	log.Info("Indexing chain work")
+	blocks, err := blockchainRepo.GetBlockHeadersByHeight(0, lastBlock.Height+1)
+	if err != nil {
+		log.Fatal("Error reading block headers: ", err.Error())
+	}
+
+	chainWork := make(map[string]string)
+	parent := reps.Block{}
+	for _, block := range blocks {
+		block.ChainWork = nextChainWork(parent, block)
+		chainWork[block.ID] = block.ChainWork
+		parent = block
+	}
+
+	if err := blockchainRepo.SetBlockChainWork(chainWork); err != nil {
+		log.Fatal("Error indexing chain work: ", err.Error())
+	}
 }
@@ -0,0 +1,712 @@
+package services_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/stretchr/testify/assert"
+)
+
+var sha256Hasher, _ = services.GetBlockHasher(services.HashSHA256)
+
+func TestCreateBlockSolvesProofOfWork(t *testing.T) {
+	ts := newTestServices(t)
+	walletService, txnService, blockService := ts.walletService, ts.txnService, ts.blockService
+
+	miner, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+
+	block, err := blockService.CreateBlock([]reps.Transaction{txnService.CreateCoinbaseTxn(miner.Address, "")}, []byte{})
+	assert.NoError(t, err)
+	assert.Equal(t, services.TargetBits, block.Difficulty)
+	merkleRoot, err := services.TxnAssembler.MerkleRoot(block.Transactions)
+	assert.NoError(t, err)
+	assert.Equal(t, merkleRoot, block.MerkleRoot)
+	assert.True(t, services.NewProofOfWorkService(&block, sha256Hasher).ValidateProof())
+	assert.NoError(t, blockService.ValidateBlock(block))
+
+	// Any change to the block undoes the work
+	tampered := block
+	tampered.Timestamp++
+	assert.True(t, errors.Is(blockService.ValidateBlock(tampered), services.ErrInvalidBlock))
+
+	tampered = block
+	tampered.Nounce++
+	assert.True(t, errors.Is(blockService.ValidateBlock(tampered), services.ErrInvalidBlock))
+
+	// Its transactions are covered by the merkle root, which is covered by the hash
+	other := txnService.CreateCoinbaseTxn(miner.Address, "other")
+	tampered = block
+	tampered.Transactions = []reps.Transaction{other}
+	assert.True(t, errors.Is(blockService.ValidateBlock(tampered), services.ErrInvalidBlock))
+	tampered.MerkleRoot, _ = services.TxnAssembler.MerkleRoot(tampered.Transactions)
+	assert.True(t, errors.Is(blockService.ValidateBlock(tampered), services.ErrInvalidBlock))
+
+	// Heights go up from the parent
+	assert.Equal(t, 0, block.Height)
+	child, err := blockService.CreateBlock([]reps.Transaction{txnService.CreateCoinbaseTxn(miner.Address, "child")}, block.Hash)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, child.Height)
+
+	// Nor can it claim less work than new blocks need
+	tampered = block
+	tampered.Difficulty = services.TargetBits - 1
+	assert.True(t, errors.Is(blockService.ValidateBlock(tampered), services.ErrInvalidBlock))
+}
+
+func TestRetargetDifficulty(t *testing.T) {
+	// On time
+	assert.Equal(t, 12, services.RetargetDifficulty(12, 60000, 60000))
+	// Twice as fast, so twice as much work
+	assert.Equal(t, 13, services.RetargetDifficulty(12, 30000, 60000))
+	assert.Equal(t, 11, services.RetargetDifficulty(12, 120000, 60000))
+	// By no more than 4 times at once
+	assert.Equal(t, 14, services.RetargetDifficulty(12, 1, 60000))
+	assert.Equal(t, 10, services.RetargetDifficulty(12, 6000000, 60000))
+	assert.Equal(t, services.MinDifficulty, services.RetargetDifficulty(services.MinDifficulty, 6000000, 60000))
+}
+
+func TestDifficultyIsRetargetedEveryInterval(t *testing.T) {
+	params := mainnet
+	params.DifficultyInterval = 2
+	params.TargetBlockTime = 60
+
+	repo := newFakeBlockchainRepository()
+	blockService := services.NewBlockService(repo, &params)
+	repo.blocks = []reps.Block{
+		{ID: "genesis", Hash: []byte("genesis"), Timestamp: 0, Difficulty: 12},
+		{ID: "second", Hash: []byte("second"), PrevHash: []byte("genesis"), Timestamp: 600000, Difficulty: 12},
+		{ID: "third", Hash: []byte("third"), PrevHash: []byte("second"), Timestamp: 630000, Difficulty: 12},
+		{ID: "fourth", Hash: []byte("fourth"), PrevHash: []byte("third"), Timestamp: 720000, Difficulty: 12},
+	}
+
+	// The first interval isn't timed, and between retargets it stays put
+	for _, prevHash := range []string{"second", "third"} {
+		difficulty, err := blockService.NextDifficulty([]byte(prevHash))
+		assert.NoError(t, err)
+		assert.Equal(t, 12, difficulty)
+	}
+
+	// The last two blocks took 120 seconds, on time
+	difficulty, err := blockService.NextDifficulty([]byte("fourth"))
+	assert.NoError(t, err)
+	assert.Equal(t, 12, difficulty)
+
+	// Now 60, twice as fast
+	repo.blocks[3].Timestamp = 660000
+	difficulty, err = blockService.NextDifficulty([]byte("fourth"))
+	assert.NoError(t, err)
+	assert.Equal(t, 13, difficulty)
+
+	// Blocks have to be mined at it
+	block := reps.Block{ID: "fifth", PrevHash: []byte("fourth"), Height: 4, Timestamp: 700000, Difficulty: 12, Transactions: []reps.Transaction{{ID: []byte("coinbase")}}}
+	block.MerkleRoot, _ = services.TxnAssembler.MerkleRoot(block.Transactions)
+	block.Nounce, block.Hash = services.NewProofOfWorkService(&block, sha256Hasher).Solve()
+	assert.True(t, errors.Is(blockService.ValidateBlock(block), services.ErrInvalidBlock))
+
+	block.Difficulty = 13
+	block.Nounce, block.Hash = services.NewProofOfWorkService(&block, sha256Hasher).Solve()
+	assert.NoError(t, blockService.ValidateBlock(block))
+
+	// One above its parent
+	block.Height = 5
+	assert.True(t, errors.Is(blockService.ValidateBlock(block), services.ErrInvalidBlock))
+
+	_, err = blockService.NextDifficulty([]byte("unknown"))
+	assert.Error(t, err)
+}
+
+func TestGetMerkleBranchLeadsToMerkleRoot(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+	blockchainService := ts.blockchainService
+
+	miner, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+
+	txns := make([]reps.Transaction, 0)
+	for _, data := range []string{"a", "b", "c"} {
+		txns = append(txns, txnService.CreateCoinbaseTxn(miner.Address, data))
+	}
+	block, err := services.NewBlockService(repo, &mainnet).CreateBlock(txns, []byte{})
+	assert.NoError(t, err)
+
+	for position, txn := range block.Transactions {
+		branch, err := blockchainService.GetMerkleBranch(block.ID, hex.EncodeToString(txn.ID))
+		assert.NoError(t, err)
+		assert.Equal(t, position, branch.Position)
+		assert.Len(t, branch.Proof, 2)
+
+		hash := sha256.Sum256(txn.ID)
+		for _, step := range branch.Proof {
+			sibling, _ := hex.DecodeString(step.Hash)
+			if step.Side == "left" {
+				hash = sha256.Sum256(append(sibling, hash[:]...))
+			} else {
+				hash = sha256.Sum256(append(hash[:], sibling...))
+			}
+		}
+		assert.Equal(t, block.MerkleRoot, hash[:])
+	}
+
+	_, err = blockchainService.GetMerkleBranch(block.ID, hex.EncodeToString([]byte("unknown")))
+	assert.True(t, errors.Is(err, services.ErrTxnNotOnBlock))
+	_, err = blockchainService.GetMerkleBranch("unknown", hex.EncodeToString(txns[0].ID))
+	assert.Error(t, err)
+}
+
+func TestGetBlocksByHeightPagesThroughChain(t *testing.T) {
+	ts := newTestServices(t)
+	repo, blockchainService := ts.repo, ts.blockchainService
+
+	for _, id := range []string{"genesis", "second", "third", "fourth", "fifth"} {
+		repo.blocks = append(repo.blocks, reps.Block{ID: id})
+	}
+
+	block, err := blockchainService.GetBlockByHeight(2)
+	assert.NoError(t, err)
+	assert.Equal(t, "third", block.ID)
+	_, err = blockchainService.GetBlockByHeight(5)
+	assert.Error(t, err)
+
+	page, err := blockchainService.GetBlocksByHeight(1, 3)
+	assert.NoError(t, err)
+	assert.Len(t, page, 3)
+	assert.Equal(t, "second", page[0].ID)
+	assert.Equal(t, "fourth", page[2].ID)
+
+	page, err = blockchainService.GetBlocksByHeight(4, 3)
+	assert.NoError(t, err)
+	assert.Len(t, page, 1)
+
+	_, err = blockchainService.GetBlocksByHeight(0, services.MaxBlocksPerPage+1)
+	assert.Error(t, err)
+}
+
+func TestCheckHeaderNeedsNoTransactions(t *testing.T) {
+	ts := newTestServices(t)
+	walletService, txnService, blockService := ts.walletService, ts.txnService, ts.blockService
+	blockchainService := ts.blockchainService
+
+	miner, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	block, err := blockService.CreateBlock([]reps.Transaction{txnService.CreateCoinbaseTxn(miner.Address, "")}, []byte{})
+	assert.NoError(t, err)
+
+	// The merkle root stands in for the transactions
+	header := block
+	header.Transactions = nil
+	assert.NoError(t, blockchainService.CheckHeader(header))
+
+	tampered := header
+	tampered.Timestamp++
+	assert.ErrorIs(t, blockchainService.CheckHeader(tampered), services.ErrInvalidBlock)
+
+	tampered = header
+	tampered.ChainID = "other"
+	assert.ErrorIs(t, blockchainService.CheckHeader(tampered), services.ErrInvalidBlock)
+
+	// Nor can it claim to be at another difficulty or height than it was mined at
+	tampered = header
+	tampered.Difficulty--
+	assert.ErrorIs(t, blockchainService.CheckHeader(tampered), services.ErrInvalidBlock)
+
+	tampered = header
+	tampered.Height++
+	assert.ErrorIs(t, blockchainService.CheckHeader(tampered), services.ErrInvalidBlock)
+
+	// Without a merkle root there's nothing to hash it with
+	tampered = header
+	tampered.MerkleRoot = nil
+	assert.ErrorIs(t, blockchainService.CheckHeader(tampered), services.ErrInvalidBlock)
+}
+
+func TestBlockRewardHalvesEveryInterval(t *testing.T) {
+	params := reps.ChainParams{InitialReward: 40, HalvingInterval: 3}
+	for height, reward := range []int{40, 40, 40, 20, 20, 20, 10} {
+		assert.Equal(t, reward, services.BlockReward(&params, height))
+	}
+	assert.Equal(t, 0, services.BlockReward(&params, 3*64))
+	assert.Equal(t, 190, services.RewardSupply(&params, 6))
+
+	// Chains from before rewards halved keep paying the same
+	assert.Equal(t, services.Reward, services.BlockReward(&mainnet, 1000000))
+
+	ts := newTestServicesWithParams(t, &params)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+	blockchainService := ts.blockchainService
+
+	miner, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, miner.Address)
+	repo.blocks = append(repo.blocks,
+		reps.Block{ID: "second", Hash: []byte("second"), PrevHash: []byte("genesis")},
+		reps.Block{ID: "third", Hash: []byte("third"), PrevHash: []byte("second")})
+
+	block, err := blockchainService.MineTransactions([]reps.Transaction{}, miner.Address, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, block.Height)
+	assert.Equal(t, 20, block.Transactions[0].Outputs[0].Value)
+
+	// Nor can a coinbase pay out the reward from before the halving
+	err = blockchainService.VerifyTransactions([]reps.Transaction{txnService.CreateCoinbaseTxnWithFees(miner.Address, "", 2, 0)}, 3)
+	var verificationErr *services.TxnVerificationError
+	assert.True(t, errors.As(err, &verificationErr))
+	assert.Equal(t, services.InvalidTxnCoinbase, verificationErr.Reason)
+
+	// Nor fees so big that added to the reward they'd wrap around
+	spend := reps.Transaction{ID: []byte("spend"), Inputs: []reps.TxnInput{{PrevTxnID: []byte("prev")}}, Fee: services.MaxMoney}
+	err = blockchainService.VerifyTransactions([]reps.Transaction{txnService.CreateCoinbaseTxnWithFees(miner.Address, "", 3, 0), spend}, 3)
+	assert.True(t, errors.As(err, &verificationErr))
+	assert.Equal(t, services.InvalidTxnValue, verificationErr.Reason)
+
+	info, err := blockchainService.GetChainInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, info.Height)
+	assert.Equal(t, hex.EncodeToString(block.Hash), info.BestBlockHash)
+	assert.Equal(t, 20, info.Reward)
+	assert.Equal(t, 6, info.NextHalvingHeight)
+	assert.Equal(t, 140, info.Supply)
+}
+
+func TestGetBlockHeadersLeaveOutTransactions(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+	blockchainService := ts.blockchainService
+
+	miner, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, miner.Address)
+	block, err := blockchainService.MineTransactions([]reps.Transaction{}, miner.Address, "")
+	assert.NoError(t, err)
+
+	header, err := blockchainService.GetBlockHeader(block.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, header.Transactions)
+	readable := services.HeaderAssembler.ToBlockHeader(header)
+	assert.Equal(t, hex.EncodeToString(block.Hash), readable.Hash)
+	assert.Equal(t, hex.EncodeToString(block.MerkleRoot), readable.MerkleRoot)
+	assert.Equal(t, 1, readable.Height)
+
+	_, err = blockchainService.GetBlockHeader("unknown")
+	assert.Error(t, err)
+
+	headers, err := blockchainService.GetBlockHeaders(0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, headers, 2)
+	assert.Empty(t, headers[1].Transactions)
+
+	// The genesis block is from before merkle roots were stored, so it gets the hash of its transactions instead
+	legacyRoot, err := services.TxnAssembler.HashTransactions(repo.blocks[0].Transactions)
+	assert.NoError(t, err)
+	assert.Equal(t, legacyRoot, headers[0].MerkleRoot)
+
+	// Headers come in bigger pages than blocks
+	_, err = blockchainService.GetBlockHeaders(0, services.MaxBlocksPerPage+1)
+	assert.NoError(t, err)
+	_, err = blockchainService.GetBlockHeaders(0, services.MaxHeadersPerPage+1)
+	assert.Error(t, err)
+}
+
+func TestMinedBlocksSignalVersionBits(t *testing.T) {
+	defer func(bits []int) { services.SignalBits = bits }(services.SignalBits)
+	bits, err := services.ParseSignalBits("4, 1,4")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 4}, bits)
+	_, err = services.ParseSignalBits("29")
+	assert.Error(t, err)
+	services.SignalBits = bits
+
+	// Versions without the top bits don't signal
+	assert.False(t, services.SignalsBit(1<<1, 1))
+	assert.True(t, services.SignalsBit(services.BlockVersion(), 1))
+	assert.False(t, services.SignalsBit(services.BlockVersion(), 2))
+
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+	blockService, blockchainService := ts.blockService, ts.blockchainService
+
+	miner, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, miner.Address)
+	for i := 0; i < 2; i++ {
+		block, err := blockchainService.MineTransactions([]reps.Transaction{}, miner.Address, "")
+		assert.NoError(t, err)
+		assert.Equal(t, services.BlockVersion(), block.Version)
+	}
+
+	stats, err := blockchainService.GetVersionBitsStats(0)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, stats.From)
+	assert.Equal(t, 2, stats.To)
+	assert.Equal(t, 3, stats.Blocks)
+	assert.Equal(t, []reps.VersionBitCount{{Bit: 1, Count: 2, Share: 2.0 / 3}, {Bit: 4, Count: 2, Share: 2.0 / 3}}, stats.Bits)
+
+	stats, err = blockchainService.GetVersionBitsStats(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stats.From)
+	assert.Equal(t, 1, stats.Bits[0].Count)
+
+	// The version is part of what's hashed, so it can't be changed after mining
+	block := repo.blocks[2]
+	block.Version = services.VersionBitsTopBits
+	assert.ErrorIs(t, blockService.ValidateBlock(block), services.ErrInvalidBlock)
+}
+
+func TestBlocksAreHashedWithChainAlgorithm(t *testing.T) {
+	_, err := services.GetBlockHasher("md5")
+	assert.Error(t, err)
+
+	legacy, err := services.GetBlockHasher("")
+	assert.NoError(t, err)
+	assert.Equal(t, services.HashSHA256, legacy.Algorithm())
+
+	sha256d, err := services.GetBlockHasher(services.HashSHA256d)
+	assert.NoError(t, err)
+	once := sha256.Sum256([]byte("header"))
+	twice := sha256.Sum256(once[:])
+	assert.Equal(t, twice[:], sha256d.Hash([]byte("header")))
+
+	params := mainnet
+	params.HashAlgorithm = services.HashBLAKE2b
+	ts := newTestServicesWithParams(t, &params)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+	blockService := ts.blockService
+
+	miner, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	block, err := blockService.CreateBlock([]reps.Transaction{txnService.CreateCoinbaseTxn(miner.Address, "")}, []byte{})
+	assert.NoError(t, err)
+	blake2b, err := services.GetBlockHasher(services.HashBLAKE2b)
+	assert.NoError(t, err)
+	assert.Len(t, block.Hash, 32)
+	assert.Equal(t, block.Hash, services.NewProofOfWorkService(&block, blake2b).HashData())
+	assert.NoError(t, blockService.ValidateBlock(block))
+
+	// A block hashed with sha256 has no place on a blake2b chain, nor the other way around
+	mixed := block
+	mixed.Nounce, mixed.Hash = services.NewProofOfWorkService(&mixed, sha256Hasher).Solve()
+	assert.ErrorIs(t, blockService.ValidateBlock(mixed), services.ErrInvalidBlock)
+	assert.ErrorIs(t, services.NewBlockService(repo, &mainnet).ValidateBlock(block), services.ErrInvalidBlock)
+}
+
+func TestBlockTimestampsComeAfterMedianTimePast(t *testing.T) {
+	repo := newFakeBlockchainRepository()
+	blockService := services.NewBlockService(repo, &mainnet)
+
+	// Out of order, as miners' clocks disagree. The median of the last 11 is 15000
+	timestamps := []int64{0, 20000, 1000, 2000, 3000, 9000, 4000, 15000, 16000, 17000, 18000, 19000}
+	for i, timestamp := range timestamps {
+		block := reps.Block{ID: strconv.Itoa(i), Hash: []byte(strconv.Itoa(i)), Timestamp: timestamp, Difficulty: 8}
+		if i > 0 {
+			block.PrevHash = []byte(strconv.Itoa(i - 1))
+		}
+		repo.blocks = append(repo.blocks, block)
+	}
+
+	solve := func(timestamp int64) reps.Block {
+		block := reps.Block{ID: "next", PrevHash: []byte("11"), Height: 12, Timestamp: timestamp, Difficulty: 8, Transactions: []reps.Transaction{{ID: []byte("coinbase")}}}
+		block.MerkleRoot, _ = services.TxnAssembler.MerkleRoot(block.Transactions)
+		block.Nounce, block.Hash = services.NewProofOfWorkService(&block, sha256Hasher).Solve()
+		return block
+	}
+
+	// Before its parent is fine, at the median isn't
+	assert.NoError(t, blockService.ValidateBlock(solve(15001)))
+	assert.ErrorIs(t, blockService.ValidateBlock(solve(15000)), services.ErrInvalidBlock)
+	assert.ErrorIs(t, blockService.ValidateBlock(solve(500)), services.ErrInvalidBlock)
+
+	// Nor can it be far in the future
+	assert.NoError(t, blockService.ValidateBlock(solve(time.Now().Add(time.Hour).UnixMilli())))
+	assert.ErrorIs(t, blockService.ValidateBlock(solve(time.Now().Add(services.MaxFutureBlockTime+time.Minute).UnixMilli())), services.ErrInvalidBlock)
+
+	// A node whose clock is behind still mines blocks that pass
+	for i := range repo.blocks[1:] {
+		repo.blocks[i+1].Timestamp = time.Now().Add(time.Hour).UnixMilli()
+	}
+	block, err := blockService.AssembleBlock([]reps.Transaction{{ID: []byte("coinbase")}}, []byte("11"))
+	assert.NoError(t, err)
+	assert.Greater(t, block.Timestamp, time.Now().UnixMilli())
+}
+
+func TestBlocksAreFinalOnceDeepEnough(t *testing.T) {
+	ts := newTestServices(t)
+	repo, blockchainService := ts.repo, ts.blockchainService
+
+	defer func(depth int) { services.FinalityDepth = depth }(services.FinalityDepth)
+	services.FinalityDepth = 3
+
+	repo.blocks = append(repo.blocks, reps.Block{ID: "genesis"}, reps.Block{ID: "second"})
+	height, err := blockchainService.GetFinalizedHeight()
+	assert.NoError(t, err)
+	assert.Equal(t, -1, height)
+	_, err = blockchainService.GetLastFinalizedBlock()
+	assert.Error(t, err)
+
+	// The genesis block has 3 confirmations once there are 3 blocks
+	repo.blocks = append(repo.blocks, reps.Block{ID: "third"}, reps.Block{ID: "fourth"}, reps.Block{ID: "fifth"})
+	block, err := blockchainService.GetLastFinalizedBlock()
+	assert.NoError(t, err)
+	assert.Equal(t, "third", block.ID)
+	assert.Equal(t, services.BlockFinal, services.BlockFinalityStatus(2, block.Height))
+	assert.Equal(t, services.BlockTentative, services.BlockFinalityStatus(3, block.Height))
+
+	// A checkpoint the chain is past is final however recent
+	defer func(checkpoints map[int]string) { services.Checkpoints = checkpoints }(services.Checkpoints)
+	services.Checkpoints = map[int]string{3: "00ab"}
+	height, err = blockchainService.GetFinalizedHeight()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, height)
+}
+
+func TestCheckpointsPinBlocksAndHistory(t *testing.T) {
+	_, err := services.ParseCheckpoints("1000")
+	assert.Error(t, err)
+	_, err = services.ParseCheckpoints("x:00ab")
+	assert.Error(t, err)
+	_, err = services.ParseCheckpoints("1000:zz")
+	assert.Error(t, err)
+	checkpoints, err := services.ParseCheckpoints(" 1000:00AB, 2000:00cd,")
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]string{1000: "00ab", 2000: "00cd"}, checkpoints)
+
+	ts := newTestServices(t)
+	walletService, txnService, blockService := ts.walletService, ts.txnService, ts.blockService
+
+	miner, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	mine := func(data string, prevHash []byte) (reps.Block, error) {
+		return blockService.CreateBlock([]reps.Transaction{txnService.CreateCoinbaseTxn(miner.Address, data)}, prevHash)
+	}
+	genesis, err := mine("", []byte{})
+	assert.NoError(t, err)
+
+	defer func(checkpoints map[int]string) { services.Checkpoints = checkpoints }(services.Checkpoints)
+	services.Checkpoints = map[int]string{1: "00ab"}
+	_, err = mine("first", genesis.Hash)
+	assert.ErrorIs(t, err, services.ErrInvalidBlock)
+
+	services.Checkpoints = map[int]string{}
+	first, err := mine("first", genesis.Hash)
+	assert.NoError(t, err)
+	second, err := mine("second", first.Hash)
+	assert.NoError(t, err)
+	services.Checkpoints = map[int]string{1: hex.EncodeToString(first.Hash)}
+	assert.Equal(t, 1, services.LastCheckpoint())
+
+	solve := func(data string, prevHash []byte, height int) reps.Block {
+		block, err := blockService.AssembleBlock([]reps.Transaction{txnService.CreateCoinbaseTxn(miner.Address, data)}, prevHash)
+		assert.NoError(t, err)
+		assert.Equal(t, height, block.Height)
+		block.Nounce, block.Hash = services.NewProofOfWorkService(&block, sha256Hasher).Solve()
+		return block
+	}
+
+	// Above the checkpoint the chain can still branch, below it it can't
+	assert.NoError(t, blockService.ValidateBlock(solve("other second", first.Hash, 2)))
+	assert.ErrorIs(t, blockService.ValidateBlock(solve("other first", genesis.Hash, 1)), services.ErrInvalidBlock)
+
+	services.Checkpoints = map[int]string{2: hex.EncodeToString(second.Hash)}
+	assert.ErrorIs(t, blockService.ValidateBlock(solve("other second", first.Hash, 2)), services.ErrInvalidBlock)
+}
+
+func TestGetBlockStatsOverWindows(t *testing.T) {
+	params := mainnet
+	params.TargetBlockTime = 30
+	repo := newFakeBlockchainRepository()
+	blockchainService := services.NewBlockchainService(repo, services.NewBlockService(repo, &params), nil, nil, &params)
+
+	// Blocks 30 seconds apart at difficulty 4, except the last, 60 seconds after the one before it at difficulty 5
+	for i := 0; i <= 10; i++ {
+		block := reps.Block{ID: strconv.Itoa(i), Hash: []byte(strconv.Itoa(i)), Timestamp: int64(i) * 30000, Difficulty: 4}
+		if i > 0 {
+			block.PrevHash = []byte(strconv.Itoa(i - 1))
+		}
+		repo.blocks = append(repo.blocks, block)
+	}
+	repo.blocks[10].Timestamp, repo.blocks[10].Difficulty = 330000, 5
+
+	stats, err := blockchainService.GetBlockStats([]int{2, 10, 100})
+	assert.NoError(t, err)
+	assert.Equal(t, 10, stats.Height)
+	assert.Equal(t, 5, stats.Difficulty)
+	assert.Equal(t, 30, stats.TargetBlockTime)
+	assert.Len(t, stats.Windows, 3)
+
+	last := stats.Windows[0]
+	assert.Equal(t, 9, last.From)
+	assert.Equal(t, 10, last.To)
+	assert.Equal(t, 60.0, last.AverageBlockTime)
+	assert.Equal(t, 5.0, last.AverageDifficulty)
+	assert.InDelta(t, 32.0/60, last.HashRate, 1e-9)
+
+	ten := stats.Windows[1]
+	assert.Equal(t, 1, ten.From)
+	assert.Equal(t, 10, ten.Blocks)
+	assert.InDelta(t, 300.0/9, ten.AverageBlockTime, 1e-9)
+	assert.InDelta(t, (8*16.0+32)/300, ten.HashRate, 1e-9)
+
+	// Longer than the chain covers all of it
+	all := stats.Windows[2]
+	assert.Equal(t, 0, all.From)
+	assert.Equal(t, 11, all.Blocks)
+	assert.Equal(t, 33.0, all.AverageBlockTime)
+
+	_, err = blockchainService.GetBlockStats([]int{1})
+	assert.Error(t, err)
+	_, err = blockchainService.GetBlockStats([]int{2, 3, 4, 5, 6, 7})
+	assert.Error(t, err)
+	stats, err = blockchainService.GetBlockStats(nil)
+	assert.NoError(t, err)
+	assert.Len(t, stats.Windows, len(services.DefaultStatsWindows))
+}
+
+func TestSolveSplitsNounceSpaceAcrossWorkers(t *testing.T) {
+	defer func(workers int) { services.MiningWorkers = workers }(services.MiningWorkers)
+
+	for _, workers := range []int{1, 4, 0} {
+		services.MiningWorkers = workers
+		block := reps.Block{ID: "block", PrevHash: []byte("parent"), Timestamp: 1, Difficulty: 10, Transactions: []reps.Transaction{{ID: []byte("coinbase")}}}
+		block.MerkleRoot, _ = services.TxnAssembler.MerkleRoot(block.Transactions)
+
+		pow := services.NewProofOfWorkService(&block, sha256Hasher)
+		nounce, hash := pow.Solve()
+		assert.Equal(t, nounce, block.Nounce)
+		assert.Equal(t, hash, pow.HashData())
+		assert.True(t, pow.ValidateProof())
+
+		// A single worker searches in order, so no lower nounce works
+		if workers == 1 {
+			for block.Nounce = 0; block.Nounce < nounce; block.Nounce++ {
+				assert.False(t, pow.ValidateProof())
+			}
+		}
+	}
+}
+
+func TestSolveThrottledByDutyCycle(t *testing.T) {
+	defer func(workers int, dutyCycle int, batch int) {
+		services.MiningWorkers, services.MiningDutyCycle, services.MiningThrottleBatch = workers, dutyCycle, batch
+	}(services.MiningWorkers, services.MiningDutyCycle, services.MiningThrottleBatch)
+	services.MiningWorkers, services.MiningDutyCycle, services.MiningThrottleBatch = 2, 25, 10
+
+	block := reps.Block{ID: "block", PrevHash: []byte("parent"), Timestamp: 1, Difficulty: 8, Transactions: []reps.Transaction{{ID: []byte("coinbase")}}}
+	block.MerkleRoot, _ = services.TxnAssembler.MerkleRoot(block.Transactions)
+
+	// Sleeping between batches doesn't change what's found
+	pow := services.NewProofOfWorkService(&block, sha256Hasher)
+	nounce, hash := pow.Solve()
+	assert.Equal(t, nounce, block.Nounce)
+	assert.Equal(t, hash, pow.HashData())
+	assert.True(t, pow.ValidateProof())
+
+	status := services.NewMinerService(nil).GetStatus()
+	assert.Equal(t, 2, status.Workers)
+	assert.Equal(t, 25, status.DutyCycle)
+}
+
+func TestProofOfStakeBlocksAreSignedByElectedValidator(t *testing.T) {
+	params := mainnet
+	params.Consensus = services.ConsensusPoS
+	ts := newTestServicesWithParams(t, &params)
+	repo, keystore, walletService := ts.repo, ts.keystore, ts.walletService
+	txnService, blockService, blockchainService := ts.txnService, ts.blockService, ts.blockchainService
+
+	validator, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	other, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+
+	// Nothing to mine, and nobody to elect yet
+	genesis, _, err := blockchainService.CreateBlockchain(validator.Address, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, genesis.Proposer)
+
+	stake, err := txnService.CreateStakeTransaction(validator.Address, 30, reps.TxnOptions{})
+	assert.NoError(t, err)
+	assert.True(t, stake.Outputs[0].Staked)
+	assert.False(t, stake.Outputs[1].Staked)
+
+	// Until something is staked, anyone can produce a block
+	block, err := blockchainService.MineTransactions([]reps.Transaction{stake}, other.Address, "")
+	assert.NoError(t, err)
+	assert.Equal(t, other.PublicKey, hex.EncodeToString(block.Proposer))
+	assert.Zero(t, block.Nounce)
+	assert.Equal(t, "1001", block.ChainWork)
+
+	balance, err := txnService.GetAddressBalance(validator.Address)
+	assert.NoError(t, err)
+	assert.Equal(t, 30, balance.Staked)
+	assert.Equal(t, services.Reward-30, balance.Confirmed)
+
+	// Now only the one validator can be elected
+	elected, err := services.ElectProposer(repo, block.Hash, block.Height+1)
+	assert.NoError(t, err)
+	assert.Equal(t, hex.EncodeToString(elected), hex.EncodeToString(repo.blocks[0].Transactions[0].Outputs[0].PubKeyHash))
+
+	_, err = blockchainService.MineTransactions(nil, other.Address, "")
+	assert.True(t, errors.Is(err, services.ErrNotProposer))
+
+	next, err := blockchainService.AssembleBlock(nil, other.Address, "")
+	assert.NoError(t, err)
+	next.Hash = services.NewProofOfWorkService(&next, sha256Hasher).HashData()
+	forged, err := txnService.SignBlock(next, other)
+	assert.NoError(t, err)
+	assert.True(t, errors.Is(blockService.ValidateBlock(forged), services.ErrInvalidBlock))
+
+	signed, err := txnService.SignBlock(next, validator)
+	assert.NoError(t, err)
+	assert.NoError(t, blockService.ValidateBlock(signed))
+	signed.ProposerSig[0] ^= 0xff
+	assert.True(t, errors.Is(blockService.ValidateBlock(signed), services.ErrInvalidBlock))
+
+	block, err = blockchainService.MineTransactions(nil, validator.Address, "")
+	assert.NoError(t, err)
+	assert.Equal(t, validator.PublicKey, hex.EncodeToString(block.Proposer))
+
+	// Stake means nothing to a proof of work chain
+	_, err = services.NewTransactionService(repo, walletService, nil, services.NewLocalSigner(keystore), &mainnet).CreateStakeTransaction(validator.Address, 10, reps.TxnOptions{})
+	assert.Error(t, err)
+}
+
+// Fails every transaction lookup, the way a database that's gone away would
+type unreachableTxnRepository struct {
+	*fakeBlockchainRepository
+}
+
+func (repo unreachableTxnRepository) GetTransaction(txnId []byte) (reps.Transaction, error) {
+	return reps.Transaction{}, errors.New("database is closed")
+}
+
+func TestGetOutputStatusOnlyCallsMissingOutputsNonexistent(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+	blockchainService := ts.blockchainService
+
+	miner, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, miner.Address)
+	coinbaseId := hex.EncodeToString(repo.blocks[0].Transactions[0].ID)
+
+	status, err := blockchainService.GetOutputStatus(coinbaseId, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, reps.OutputUnspent, status.Status)
+	status, err = blockchainService.GetOutputStatus(coinbaseId, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, reps.OutputNonexistent, status.Status)
+	status, err = blockchainService.GetOutputStatus("00ab", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, reps.OutputNonexistent, status.Status)
+
+	// Not being able to look isn't the same as there being nothing to find
+	unreachable := unreachableTxnRepository{repo}
+	unreachableService := services.NewBlockchainService(unreachable, services.NewBlockService(unreachable, &mainnet), txnService, walletService, &mainnet)
+	_, err = unreachableService.GetOutputStatus(coinbaseId, 0)
+	assert.Error(t, err)
+}
@@ -1,24 +1,68 @@
 package services
 
 import (
+	"bytes"
 	// "fmt"
+	"encoding/hex"
 	"fmt"
+	"math"
 	"sort"
+	"sync"
+	"time"
 
 	"github.com/brucetieu/blockchain/repository"
 	reps "github.com/brucetieu/blockchain/representations"
 	"github.com/brucetieu/blockchain/utils"
+	"github.com/google/uuid"
 
 	log "github.com/sirupsen/logrus"
 )
 
+var (
+	DefaultBlocksPerPage = 20   // Blocks in a page when the caller doesn't say
+	MaxBlocksPerPage     = 100  // Most blocks in a page
+	MaxHeadersPerPage    = 2000 // Most block headers in a page
+
+	DefaultStatsWindows = []int{10, 100, 1000} // Windows of recent blocks stats are taken over when the caller doesn't say
+	MaxStatsWindow      = 10000                // Most recent blocks stats can be taken over
+	MaxStatsWindows     = 5                    // Most windows stats can be taken over at once
+)
+
+// Called with each block added to the chain, whether it was mined here or received from elsewhere
+type BlockListener func(block reps.Block)
+
 type BlockchainService interface {
-	AddToBlockChain(from string, to string, amount int) (reps.Block, error)
-	CreateBlockchain(address string) (reps.Block, bool, error)
+	CreatePayment(from string, recipients []reps.Recipient, opts reps.TxnOptions) (reps.Transaction, error)
+	MineTransactions(txns []reps.Transaction, miner string, message string) (reps.Block, error)
+	AssembleBlock(txns []reps.Transaction, miner string, message string) (reps.Block, error)
+	SubmitBlock(block reps.Block) error
+	ProcessBlock(block reps.Block) (reps.ChainUpdate, error)
+	CreateBlockchain(address string, allocations []reps.GenesisAllocation) (reps.Block, bool, error)
 	GetBlockchain() ([]reps.Block, error)
 	GetGenesisBlock() (reps.Block, error)
 	GetBlock(blockId string) (reps.Block, error)
+	GetBlockByHash(hash []byte) (reps.Block, error)
+	HasBlock(hash []byte) bool
+	CheckHeader(header reps.Block) error
+	GetBlockByHeight(height int) (reps.Block, error)
+	GetBlocksByHeight(from int, count int) ([]reps.Block, error)
+	GetBlockHeader(blockId string) (reps.Block, error)
+	GetBlockHeaders(from int, count int) ([]reps.Block, error)
+	GetMerkleBranch(blockId string, txnId string) (reps.MerkleBranch, error)
 	GetLastBlock() (reps.Block, error)
+	GetFinalizedHeight() (int, error)
+	GetLastFinalizedBlock() (reps.Block, error)
+	GetNextBlockHeight() (int, error)
+	GetOutputStatus(txnId string, index int) (reps.OutputStatus, error)
+	GetChainParams() reps.ChainParams
+	VerifyTransactions(txns []reps.Transaction, height int) error
+	GetChainInfo() (reps.ChainInfo, error)
+	GetVersionBitsStats(window int) (reps.VersionBitsStats, error)
+	GetBlockStats(windows []int) (reps.BlockStats, error)
+	GetStaleBlocks(count int) ([]reps.StaleBlock, int, error)
+	GetChainTips() ([]reps.ChainTip, error)
+	GetSnapshot() (reps.ChainSnapshot, error)
+	OnBlockConnected(listener BlockListener)
 }
 
 type blockchainService struct {
@@ -26,23 +70,34 @@ type blockchainService struct {
 	blockService       BlockService
 	transactionService TransactionService
 	walletService      WalletService
+	engine             ConsensusEngine
 	blockAssembler     BlockAssemblerFac
+	params             *reps.ChainParams
+	orphans            *orphanPool
+
+	listenersMu    sync.RWMutex
+	blockListeners []BlockListener
 }
 
 func NewBlockchainService(blockchainRepo repository.BlockchainRepository,
 	blockService BlockService, transactionService TransactionService, walletService WalletService,
+	params *reps.ChainParams,
 ) BlockchainService {
 	return &blockchainService{
 		blockchainRepo:     blockchainRepo,
 		blockService:       blockService,
 		transactionService: transactionService,
 		walletService:      walletService,
+		engine:             NewConsensusEngine(blockchainRepo, walletService, transactionService, params),
 		blockAssembler:     BlockAssembler,
+		params:             params,
+		orphans:            newOrphanPool(),
 	}
 }
 
-// Address is wallet address
-func (bc *blockchainService) CreateBlockchain(address string) (reps.Block, bool, error) {
+// Address is wallet address. Allocations are paid out by the genesis block on top of the reward to it,
+// along with any in the genesis spec
+func (bc *blockchainService) CreateBlockchain(address string, allocations []reps.GenesisAllocation) (reps.Block, bool, error) {
 	// Check address is in db to begin with
 	addressValid, err := bc.walletService.ValidateAddress(address)
 	if err != nil {
@@ -56,11 +111,34 @@ func (bc *blockchainService) CreateBlockchain(address string) (reps.Block, bool,
 		return reps.Block{}, false, fmt.Errorf("error: address of %s is not valid", address)
 	}
 
+	// A node started from a snapshot has a chain, but not its genesis block
+	if base, err := bc.blockchainRepo.GetSnapshotBase(); err == nil {
+		return reps.Block{}, true, fmt.Errorf("chain was started from the snapshot at height %d, it has no genesis block", base.Height)
+	}
+
 	// Try to get genesis block
 	genesis, err := bc.GetGenesisBlock()
 	if err != nil {
 		log.Info("Genesis doesn't exist, so creating it now...")
-		coinbaseTxn := bc.transactionService.CreateCoinbaseTxn(address, "First transaction in Blockchain")
+
+		var spec reps.GenesisSpec
+		if GenesisFile != "" {
+			log.Info("Creating blockchain from genesis spec ", GenesisFile)
+			spec, err = LoadGenesisSpec(GenesisFile)
+			if err != nil {
+				return reps.Block{}, false, err
+			}
+		}
+		spec.Allocations = append(spec.Allocations, allocations...)
+		if err := ApplyGenesisSpec(bc.params, spec); err != nil {
+			return reps.Block{}, false, err
+		}
+		message := spec.Message
+		if message == "" {
+			message = GenesisMessage
+		}
+
+		coinbaseTxn := bc.transactionService.CreateGenesisTxn(address, message, spec.Allocations)
 		newBlock, err := bc.blockService.CreateBlock([]reps.Transaction{coinbaseTxn}, []byte{})
 		// Persist
 		if err != nil {
@@ -68,6 +146,15 @@ func (bc *blockchainService) CreateBlockchain(address string) (reps.Block, bool,
 			return reps.Block{}, false, err
 		}
 
+		// Chain params are fixed once the genesis exists
+		params := *bc.params
+		params.ID = uuid.Must(uuid.NewRandom()).String()
+		err = bc.blockchainRepo.CreateChainParams(params)
+		if err != nil {
+			log.Error("Error saving chain params: ", err.Error())
+			return reps.Block{}, false, err
+		}
+
 		return newBlock, false, nil
 	}
 
@@ -75,58 +162,397 @@ func (bc *blockchainService) CreateBlockchain(address string) (reps.Block, bool,
 	return genesis, true, nil
 }
 
-// Mine a block
-func (bc *blockchainService) AddToBlockChain(from string, to string, amount int) (reps.Block, error) {
+// Create a transaction paying recipients from an address, to be queued in the mempool and mined with the next block
+func (bc *blockchainService) CreatePayment(from string, recipients []reps.Recipient, opts reps.TxnOptions) (reps.Transaction, error) {
 	// Validate from and to exist in the db and are valid addresses
 	addressValid, err := bc.walletService.ValidateAddress(from)
 	if err != nil {
-		return reps.Block{}, err
+		return reps.Transaction{}, err
 	}
 	if !addressValid {
-		return reps.Block{}, fmt.Errorf("error: address of %s is not valid", from)
+		return reps.Transaction{}, fmt.Errorf("error: address of %s is not valid", from)
+	}
+
+	// Coins can also go to a multisig address, which has no wallet
+	for _, recipient := range recipients {
+		if _, err := bc.blockchainRepo.GetMultisigAddress(recipient.To); err == nil {
+			continue
+		}
+		addressValid, err = bc.walletService.ValidateAddress(recipient.To)
+		if err != nil {
+			return reps.Transaction{}, err
+		}
+		if !addressValid {
+			return reps.Transaction{}, fmt.Errorf("error: address of %s is not valid", recipient.To)
+		}
+	}
+
+	// Check if there is at least a genesis block in the blockchain
+	if _, err := bc.blockchainRepo.GetLastBlock(); err != nil {
+		errMsg := fmt.Errorf("%s, cannot create a block without genesis", err.Error())
+		return reps.Transaction{}, errMsg
+	}
+
+	return bc.transactionService.CreateTransactionToRecipients(from, recipients, opts)
+}
+
+// Mine a block with the given transactions, plus a coinbase transaction paying the reward and their fees to miner
+// and carrying message. On a proof of stake or BFT chain miner has to be the validator elected for the block, and signs it
+func (bc *blockchainService) MineTransactions(txns []reps.Transaction, miner string, message string) (reps.Block, error) {
+	newBlock, err := bc.AssembleBlock(txns, miner, message)
+	if err != nil {
+		return reps.Block{}, err
 	}
 
-	addressValid, err = bc.walletService.ValidateAddress(to)
+	newBlock, err = bc.engine.Seal(newBlock, miner)
 	if err != nil {
 		return reps.Block{}, err
 	}
-	if !addressValid {
-		return reps.Block{}, fmt.Errorf("error: address of %s is not valid", to)
+
+	// Persist
+	if err := bc.blockService.AddBlock(newBlock); err != nil {
+		return reps.Block{}, err
 	}
+	bc.blockConnected(newBlock)
 
-	// Check if there is at least a genesis block in the blockchain
+	return newBlock, nil
+}
+
+// Block on top of the last one with the given transactions, plus a coinbase transaction paying the reward and their fees
+// to miner, with its proof of work left to solve. The coinbase carries message, or CoinbaseMessage without one
+func (bc *blockchainService) AssembleBlock(txns []reps.Transaction, miner string, message string) (reps.Block, error) {
 	lastBlock, err := bc.blockchainRepo.GetLastBlock()
 	if err != nil {
 		errMsg := fmt.Errorf("%s, cannot create a block without genesis", err.Error())
 		return reps.Block{}, errMsg
 	}
 
-	// Create a new transaction.
-	newTxn, err := bc.transactionService.CreateTransaction(from, to, amount)
+	// Nothing can be mined before its lock time
+	height, err := bc.GetNextBlockHeight()
 	if err != nil {
 		return reps.Block{}, err
 	}
+	blockTime := time.Now().UnixMilli()
+	for _, txn := range txns {
+		if !IsFinalTransaction(txn, height, blockTime) {
+			return reps.Block{}, &TxnVerificationError{TxnID: hex.EncodeToString(txn.ID), InputIndex: -1, Reason: InvalidTxnLocked, Message: fmt.Sprintf("locked until %d, can't go on block %d", txn.LockTime, height)}
+		}
+	}
 
-	// Also create a new coinbase transaction
-	coinbaseTxn := bc.transactionService.CreateCoinbaseTxn(from, "")
+	// Also create a new coinbase transaction, collecting the fees. They're checked against the inputs when verifying
+	fees := 0
+	for _, txn := range txns {
+		fees += txn.Fee
+	}
+	if message == "" {
+		message = CoinbaseMessage
+	}
+	data, err := CoinbaseData(message)
+	if err != nil {
+		return reps.Block{}, err
+	}
+	coinbaseTxn := bc.transactionService.CreateCoinbaseTxnWithFees(miner, data, height, fees)
 
 	// Verify the signatures on transaction inputs
-	txns := []reps.Transaction{coinbaseTxn, newTxn}
+	txns = append([]reps.Transaction{coinbaseTxn}, txns...)
+	err = bc.VerifyTransactions(txns, height)
+	if err != nil {
+		return reps.Block{}, err
+	}
+
+	return bc.blockService.AssembleBlock(txns, lastBlock.Hash)
+}
+
+// Add a block solved elsewhere, from a template assembled here. It has to build on the last block,
+// since its transactions were only verified against the chain up to there. One that lost the race to another
+// block on the same parent is recorded as stale
+func (bc *blockchainService) SubmitBlock(block reps.Block) error {
+	if err := bc.submitBlock(block); err != nil {
+		return err
+	}
+	bc.blockConnected(block)
+
+	return nil
+}
+
+// SubmitBlock without telling listeners, for blocks added while processing others, which tells them once it's done
+func (bc *blockchainService) submitBlock(block reps.Block) error {
+	lastBlock, err := bc.blockchainRepo.GetLastBlock()
+	if err != nil {
+		return fmt.Errorf("%s, cannot add a block without genesis", err.Error())
+	}
+	if !bytes.Equal(block.PrevHash, lastBlock.Hash) {
+		bc.recordStaleBlock(block)
+		return fmt.Errorf("%w: block %s builds on %x, not the last block %x", ErrStaleTemplate, block.ID, block.PrevHash, lastBlock.Hash)
+	}
+
+	return bc.blockService.AddBlock(block)
+}
+
+// Record a solved block building on a block that's on the chain, but not the last one. Blocks already on the chain,
+// or whose parent isn't, didn't lose a fork race, and ones without valid proof of work never entered one
+func (bc *blockchainService) recordStaleBlock(block reps.Block) {
+	if _, err := bc.blockchainRepo.GetBlockByHash(block.Hash); err == nil {
+		return
+	}
+	parent, err := bc.blockchainRepo.GetBlockByHash(block.PrevHash)
+	if err != nil {
+		return
+	}
+	if err := bc.checkProof(block); err != nil {
+		return
+	}
+
+	staleBlock := reps.StaleBlock{
+		Hash:       hex.EncodeToString(block.Hash),
+		BlockID:    block.ID,
+		PrevHash:   hex.EncodeToString(block.PrevHash),
+		Height:     parent.Height + 1,
+		Timestamp:  block.Timestamp,
+		Difficulty: BlockDifficulty(block),
+		TxnCount:   len(block.Transactions),
+		ReceivedAt: time.Now().UnixMilli(),
+	}
+	if winner, err := bc.blockchainRepo.GetBlockByHeight(staleBlock.Height); err == nil {
+		staleBlock.WinnerHash = hex.EncodeToString(winner.Hash)
+	}
+
+	log.WithFields(log.Fields{"hash": staleBlock.Hash, "height": staleBlock.Height, "winner": staleBlock.WinnerHash}).Warn("Block lost a fork race")
+	if err := bc.blockchainRepo.CreateStaleBlock(staleBlock); err != nil {
+		log.Error("Error recording stale block: ", err.Error())
+	}
+}
+
+// The count blocks that lost a fork race last, latest first, and how many there have been in all
+func (bc *blockchainService) GetStaleBlocks(count int) ([]reps.StaleBlock, int, error) {
+	if count == 0 {
+		count = DefaultBlocksPerPage
+	}
+	if count < 0 || count > MaxBlocksPerPage {
+		return nil, 0, fmt.Errorf("count must be between 1 and %d, not %d", MaxBlocksPerPage, count)
+	}
+
+	staleBlocks, err := bc.blockchainRepo.GetStaleBlocks(count)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := bc.blockchainRepo.CountStaleBlocks()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return staleBlocks, total, nil
+}
+
+// Add a block received from elsewhere, e.g. a peer. One whose parent isn't known yet is held as an orphan until the
+// parent shows up, instead of being rejected, and orphans building on a block are added right after it. One building
+// on a block other than the last goes on a side branch, and the chain is reorganized onto the branch once it has more
+// work than the chain from where they fork
+func (bc *blockchainService) ProcessBlock(block reps.Block) (reps.ChainUpdate, error) {
+	if _, err := bc.blockchainRepo.GetBlockByHash(block.Hash); err == nil {
+		return reps.ChainUpdate{}, fmt.Errorf("%w: block %x is already on the chain", ErrKnownBlock, block.Hash)
+	}
+	if _, err := bc.blockchainRepo.GetSideBlock(hex.EncodeToString(block.Hash)); err == nil {
+		return reps.ChainUpdate{}, fmt.Errorf("%w: block %x is already on a side branch", ErrKnownBlock, block.Hash)
+	}
+	if len(block.PrevHash) == 0 {
+		return reps.ChainUpdate{}, fmt.Errorf("%w: block %s has no parent, and there's already a genesis block", ErrInvalidBlock, block.ID)
+	}
+
+	if err := CheckBlockContents(block); err != nil {
+		return reps.ChainUpdate{}, err
+	}
+
+	// Orphans and side blocks can't be fully validated yet, but they can't be held without doing the work they claim to
+	if err := bc.checkProof(block); err != nil {
+		return reps.ChainUpdate{}, err
+	}
+
+	if !bc.isKnownBlock(block.PrevHash) {
+		if !bc.orphans.add(block) {
+			return reps.ChainUpdate{}, fmt.Errorf("%w: block %x is already waiting on its parent", ErrKnownBlock, block.Hash)
+		}
+		log.WithFields(log.Fields{"hash": hex.EncodeToString(block.Hash), "prevHash": hex.EncodeToString(block.PrevHash)}).Info("Holding orphan block until its parent shows up")
+		return reps.ChainUpdate{Orphaned: true}, nil
+	}
+
+	var update reps.ChainUpdate
+	if err := bc.connectBlock(block, &update); err != nil {
+		return reps.ChainUpdate{}, err
+	}
+	update.Slashings = bc.checkEquivocation(block)
+
+	attached := []reps.Block{block}
+	for i := 0; i < len(attached); i++ {
+		for _, orphan := range bc.orphans.takeChildren(attached[i].Hash) {
+			if err := bc.connectBlock(orphan, &update); err != nil {
+				log.WithFields(log.Fields{"hash": hex.EncodeToString(orphan.Hash), "error": err.Error()}).Warn("Dropping orphan block")
+				continue
+			}
+			log.WithField("hash", hex.EncodeToString(orphan.Hash)).Info("Attached orphan block to its parent")
+			attached = append(attached, orphan)
+		}
+	}
+
+	for _, block := range update.Connected {
+		bc.blockConnected(block)
+	}
+
+	return update, nil
+}
+
+// Have listener called with every block added to the chain from now on, e.g. to tell peers about it. It's called
+// before the block is handed back to whoever added it, so it mustn't hold things up
+func (bc *blockchainService) OnBlockConnected(listener BlockListener) {
+	bc.listenersMu.Lock()
+	defer bc.listenersMu.Unlock()
+	bc.blockListeners = append(bc.blockListeners, listener)
+}
+
+func (bc *blockchainService) blockConnected(block reps.Block) {
+	bc.listenersMu.RLock()
+	defer bc.listenersMu.RUnlock()
+	for _, listener := range bc.blockListeners {
+		listener(block)
+	}
+}
+
+// Whether a block with hash is on the chain or a side branch
+func (bc *blockchainService) isKnownBlock(hash []byte) bool {
+	if _, err := bc.blockchainRepo.GetBlockByHash(hash); err == nil {
+		return true
+	}
+	_, err := bc.blockchainRepo.GetSideBlock(hex.EncodeToString(hash))
+	return err == nil
+}
+
+// Block is for this chain, and its header proves what the chain's consensus engine can check without its parent,
+// e.g. its hash matches its contents and meets the difficulty it claims, or it's signed by its proposer
+func (bc *blockchainService) checkProof(block reps.Block) error {
+	if block.ChainID != ChainIdentifier(bc.params) {
+		return fmt.Errorf("%w: block %s is for chain %q, not %q", ErrInvalidBlock, block.ID, block.ChainID, ChainIdentifier(bc.params))
+	}
+
+	return bc.engine.ValidateHeader(block, nil)
+}
+
+// A block has a coinbase at least, and its header can't be hashed without a merkle root committing to its
+// transactions. Checked before anything else is done with a block from elsewhere
+func CheckBlockContents(block reps.Block) error {
+	if len(block.Transactions) == 0 {
+		return fmt.Errorf("%w: block %s has no transactions", ErrInvalidBlock, block.ID)
+	}
+	return CheckHeaderContents(block)
+}
+
+// A header can't be hashed without a merkle root, since it doesn't come with the transactions to work it out from
+func CheckHeaderContents(header reps.Block) error {
+	if len(header.MerkleRoot) == 0 {
+		return fmt.Errorf("%w: block %s has no merkle root", ErrInvalidBlock, header.ID)
+	}
+	return nil
+}
+
+// Verify the transactions on a block received from elsewhere, then add it on top of the last block
+func (bc *blockchainService) addReceivedBlock(block reps.Block) error {
+	for _, txn := range block.Transactions {
+		if txn.BlockID != block.ID {
+			return fmt.Errorf("%w: transaction %x isn't on block %s", ErrInvalidBlock, txn.ID, block.ID)
+		}
+		if !IsFinalTransaction(txn, block.Height, block.Timestamp) {
+			return &TxnVerificationError{TxnID: hex.EncodeToString(txn.ID), InputIndex: -1, Reason: InvalidTxnLocked, Message: fmt.Sprintf("locked until %d, can't go on block %d", txn.LockTime, block.Height)}
+		}
+	}
+
+	if err := bc.VerifyTransactions(block.Transactions, block.Height); err != nil {
+		return err
+	}
+
+	return bc.submitBlock(block)
+}
+
+// Check every transaction's signatures against the outputs they spend, for a block at height. Must pass before any block is persisted
+func (bc *blockchainService) VerifyTransactions(txns []reps.Transaction, height int) error {
+	// Each transaction is checked against the chain alone, so outputs spent twice within the batch are caught here,
+	// as are issuances that together go past an asset's supply controls, and validators changed twice
+	spending := make(map[string]string)
+	issued := make(map[string]int)
+	changed := make(map[string]bool)
+
+	// The coinbase can pay out the block's reward plus whatever fees the other transactions leave. The genesis
+	// block's also pays out the chain's premine, in an output per allocation
+	coinbaseLimit := BlockReward(bc.params, height)
+	if height == 0 {
+		coinbaseLimit += bc.params.Premine
+	}
 	for _, txn := range txns {
+		if !bc.transactionService.IsCoinbaseTransaction(txn) {
+			var err error
+			if coinbaseLimit, err = addAmount(coinbaseLimit, txn.Fee); err != nil {
+				return &TxnVerificationError{TxnID: hex.EncodeToString(txn.ID), InputIndex: -1, Reason: InvalidTxnValue, Message: fmt.Sprintf("fees %s", err.Error())}
+			}
+		}
+	}
+
+	for i, txn := range txns {
+		if bc.transactionService.IsCoinbaseTransaction(txn) {
+			if i != 0 {
+				return &TxnVerificationError{TxnID: hex.EncodeToString(txn.ID), InputIndex: -1, Reason: InvalidTxnCoinbase, Message: "only the first transaction in a block can be a coinbase"}
+			}
+			if height != 0 && len(txn.Outputs) != 1 {
+				return &TxnVerificationError{TxnID: hex.EncodeToString(txn.ID), InputIndex: -1, Reason: InvalidTxnCoinbase, Message: "coinbase must have a single output"}
+			}
+			paid := 0
+			for _, output := range txn.Outputs {
+				var err error
+				if paid, err = addAmount(paid, output.Value); err != nil {
+					return &TxnVerificationError{TxnID: hex.EncodeToString(txn.ID), InputIndex: -1, Reason: InvalidTxnCoinbase, Message: fmt.Sprintf("outputs %s", err.Error())}
+				}
+			}
+			if paid > coinbaseLimit {
+				return &TxnVerificationError{TxnID: hex.EncodeToString(txn.ID), InputIndex: -1, Reason: InvalidTxnCoinbase, Message: fmt.Sprintf("coinbase pays %d, more than the reward and fees of %d", paid, coinbaseLimit)}
+			}
+		} else {
+			for inIdx, input := range txn.Inputs {
+				outpoint := reps.OutpointID(input.PrevTxnID, input.OutIdx)
+				if spender, ok := spending[outpoint]; ok {
+					return &TxnVerificationError{TxnID: hex.EncodeToString(txn.ID), InputIndex: inIdx, Reason: InvalidTxnDoubleSpend, Message: fmt.Sprintf("output %s is also spent by transaction %s", outpoint, spender)}
+				}
+				spending[outpoint] = hex.EncodeToString(txn.ID)
+			}
+		}
+
+		if validator := changedValidator(txn); validator != "" {
+			if changed[validator] {
+				return &TxnVerificationError{TxnID: hex.EncodeToString(txn.ID), InputIndex: -1, Reason: InvalidTxnValidatorChange, Message: fmt.Sprintf("validator %s is also changed by another transaction", validator)}
+			}
+			changed[validator] = true
+		}
+
 		verifiedTxn, err := bc.transactionService.VerifyTransaction(txn)
-		if !verifiedTxn {
+		if err != nil {
 			log.WithField("error", err.Error()).Error("error: invalid transaction")
-			return reps.Block{}, err
+			return err
+		}
+		if !verifiedTxn {
+			return fmt.Errorf("error: transaction %x could not be verified", txn.ID)
 		}
-	}
 
-	// Create a new block with new transaction and persist
-	newBlock, err := bc.blockService.CreateBlock(txns, lastBlock.Hash)
-	if err != nil {
-		return reps.Block{}, err
+		txnIssued, err := bc.transactionService.GetIssuedAssets(txn)
+		if err != nil {
+			return err
+		}
+		for assetId, amount := range txnIssued {
+			if issued[assetId] > 0 {
+				if err := bc.transactionService.VerifyIssuance(assetId, issued[assetId]+amount); err != nil {
+					return &TxnVerificationError{TxnID: hex.EncodeToString(txn.ID), InputIndex: -1, Reason: InvalidTxnAsset, Message: err.Error()}
+				}
+			}
+			issued[assetId] += amount
+		}
 	}
 
-	return newBlock, nil
+	return nil
 }
 
 // Get all blocks in the blockchain
@@ -139,7 +565,7 @@ func (bc *blockchainService) GetBlockchain() ([]reps.Block, error) {
 
 	// Ensure that genesis block is last
 	sort.Slice(blocks, func(i, j int) bool {
-		return blocks[i].Timestamp > blocks[j].Timestamp
+		return blocks[i].Height > blocks[j].Height
 	})
 
 	return blocks, nil
@@ -171,6 +597,145 @@ func (bc *blockchainService) GetBlock(blockId string) (reps.Block, error) {
 	return block, nil
 }
 
+// Get the block with hash on the chain. Blocks on side branches aren't looked at
+func (bc *blockchainService) GetBlockByHash(hash []byte) (reps.Block, error) {
+	block, err := bc.blockchainRepo.GetBlockByHash(hash)
+	if err != nil {
+		return reps.Block{}, fmt.Errorf("%s, hash: %x", err.Error(), hash)
+	}
+
+	return block, nil
+}
+
+// Whether the block with hash is on the chain or a side branch, or held until its parent shows up, so there's no
+// need to fetch it again
+func (bc *blockchainService) HasBlock(hash []byte) bool {
+	return bc.isKnownBlock(hash) || bc.orphans.has(hash)
+}
+
+// Check a block's header, received without its transactions, as far as it can be without them or its parent: it has
+// a merkle root, it's for this chain, and its hash matches it and does the work it claims to, or is signed by its proposer
+func (bc *blockchainService) CheckHeader(header reps.Block) error {
+	if err := CheckHeaderContents(header); err != nil {
+		return err
+	}
+	return bc.checkProof(header)
+}
+
+// Get the block at a height on the blockchain
+func (bc *blockchainService) GetBlockByHeight(height int) (reps.Block, error) {
+	block, err := bc.blockchainRepo.GetBlockByHeight(height)
+	if err != nil {
+		return reps.Block{}, fmt.Errorf("%s, height: %d", err.Error(), height)
+	}
+
+	return block, nil
+}
+
+// Get a page of blocks by height: up to count of them from height from on, lowest first. A count of 0 means
+// DefaultBlocksPerPage. Past the last block there are none
+func (bc *blockchainService) GetBlocksByHeight(from int, count int) ([]reps.Block, error) {
+	count, err := blocksPage(from, count, MaxBlocksPerPage)
+	if err != nil {
+		return []reps.Block{}, err
+	}
+
+	return bc.blockchainRepo.GetBlocksByHeight(from, count)
+}
+
+// Get a block without its transactions
+func (bc *blockchainService) GetBlockHeader(blockId string) (reps.Block, error) {
+	block, err := bc.blockchainRepo.GetBlockHeader(blockId)
+	if err != nil {
+		return reps.Block{}, fmt.Errorf("%s, id: %s", err.Error(), blockId)
+	}
+
+	return block, nil
+}
+
+// Get up to count blocks from height from on, lowest first, without their transactions. Headers are small, so pages can be bigger
+func (bc *blockchainService) GetBlockHeaders(from int, count int) ([]reps.Block, error) {
+	count, err := blocksPage(from, count, MaxHeadersPerPage)
+	if err != nil {
+		return []reps.Block{}, err
+	}
+
+	headers, err := bc.blockchainRepo.GetBlockHeadersByHeight(from, count)
+	if err != nil {
+		return []reps.Block{}, err
+	}
+	if err := fillMerkleRoots(bc.blockchainRepo, headers); err != nil {
+		return []reps.Block{}, err
+	}
+
+	return headers, nil
+}
+
+// Blocks from before merkle roots were stored only hash right along with their transactions. Their headers get the
+// hash of the transactions as the merkle root, so they can be hashed without them
+func fillMerkleRoots(blockchainRepo repository.BlockchainRepository, headers []reps.Block) error {
+	for i, header := range headers {
+		if len(header.MerkleRoot) > 0 {
+			continue
+		}
+		block, err := blockchainRepo.GetBlockById(header.ID)
+		if err != nil {
+			return err
+		}
+		headers[i].MerkleRoot, err = TxnAssembler.HashTransactions(block.Transactions)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Number of blocks in a page from height from on, checked against max
+func blocksPage(from int, count int, max int) (int, error) {
+	if count == 0 {
+		count = DefaultBlocksPerPage
+	}
+	if from < 0 {
+		return 0, fmt.Errorf("height to start from can't be negative")
+	}
+	if count < 0 || count > max {
+		return 0, fmt.Errorf("count must be between 1 and %d, not %d", max, count)
+	}
+
+	return count, nil
+}
+
+// Merkle branch proving the transaction with hex id txnId is on a block, for checking without the whole block
+func (bc *blockchainService) GetMerkleBranch(blockId string, txnId string) (reps.MerkleBranch, error) {
+	block, err := bc.GetBlock(blockId)
+	if err != nil {
+		return reps.MerkleBranch{}, err
+	}
+
+	return merkleBranch(block, txnId, chainHasher(bc.params))
+}
+
+// Height the next block mined will have. The genesis block is at height 0
+func (bc *blockchainService) GetNextBlockHeight() (int, error) {
+	return nextBlockHeight(bc.blockchainRepo)
+}
+
+// Height of the block after the last one, or 0 before there's a genesis block. A node started from a snapshot
+// has none of the blocks below it, so this can't be the number of blocks stored
+func nextBlockHeight(blockchainRepo repository.BlockchainRepository) (int, error) {
+	count, err := blockchainRepo.CountBlocks()
+	if err != nil || count == 0 {
+		return 0, err
+	}
+
+	lastBlock, err := blockchainRepo.GetLastBlock()
+	if err != nil {
+		return 0, err
+	}
+
+	return lastBlock.Height + 1, nil
+}
+
 // Get the last block in the blockchain
 func (bc *blockchainService) GetLastBlock() (reps.Block, error) {
 	lastBlock, err := bc.blockchainRepo.GetLastBlock()
@@ -181,3 +746,240 @@ func (bc *blockchainService) GetLastBlock() (reps.Block, error) {
 
 	return lastBlock, nil
 }
+
+// Height of the last final block, which can't be reorganized away: the last one a quorum of validators voted for
+// on a BFT chain, or the last one with FinalityDepth confirmations on others. The last checkpoint counts, once the
+// chain is past it. -1 while there's none
+func (bc *blockchainService) GetFinalizedHeight() (int, error) {
+	lastBlock, err := bc.blockchainRepo.GetLastBlock()
+	if err != nil {
+		return -1, fmt.Errorf("%s, genesis does not exist", err.Error())
+	}
+
+	height := lastBlock.Height - FinalityDepth + 1
+	if IsBFT(bc.params) {
+		height = -1
+		if finalized, err := bc.blockchainRepo.GetLastFinalizedBlock(); err == nil {
+			height = finalized.Height
+		}
+	}
+
+	if last := LastCheckpoint(); last > height && lastBlock.Height >= last {
+		height = last
+	}
+	if height < 0 {
+		return -1, nil
+	}
+
+	return height, nil
+}
+
+// Get the last final block
+func (bc *blockchainService) GetLastFinalizedBlock() (reps.Block, error) {
+	height, err := bc.GetFinalizedHeight()
+	if err != nil {
+		return reps.Block{}, err
+	}
+	if height < 0 {
+		return reps.Block{}, fmt.Errorf("no block is final yet")
+	}
+
+	return bc.GetBlockByHeight(height)
+}
+
+// Find out whether a single transaction output is unspent, spent or doesn't exist
+func (bc *blockchainService) GetOutputStatus(txnId string, index int) (reps.OutputStatus, error) {
+	log.WithFields(log.Fields{"txnId": txnId, "index": index}).Info("Getting output status")
+	outputStatus := reps.OutputStatus{TxnID: txnId, OutIdx: index, Status: reps.OutputNonexistent}
+
+	txnIdBytes, err := hex.DecodeString(txnId)
+	if err != nil {
+		return reps.OutputStatus{}, fmt.Errorf("%s, invalid transaction id: %s", err.Error(), txnId)
+	}
+
+	// Transaction or output index isn't on the blockchain. Failing to look is an error, not an answer
+	txn, err := bc.blockchainRepo.GetTransaction(txnIdBytes)
+	if err != nil {
+		if repository.IsNotFound(err) {
+			return outputStatus, nil
+		}
+		return reps.OutputStatus{}, fmt.Errorf("%s, looking up transaction %s", err.Error(), txnId)
+	}
+	if index < 0 || index >= len(txn.Outputs) {
+		return outputStatus, nil
+	}
+
+	output := txn.Outputs[index]
+	outputStatus.Value = output.Value
+	outputStatus.PubKeyHash = hex.EncodeToString(output.PubKeyHash)
+	outputStatus.AssetID = output.AssetID
+
+	blocks, err := bc.blockchainRepo.GetBlockchain()
+	if err != nil {
+		return reps.OutputStatus{}, err
+	}
+
+	spentOutputs := bc.transactionService.GetSpentOutputs(blocks)
+	if spendingTxn, spent := spentOutputs[txnId][index]; spent {
+		outputStatus.Status = reps.OutputSpent
+		outputStatus.SpentByTxnID = hex.EncodeToString(spendingTxn.ID)
+		outputStatus.SpentInBlockID = spendingTxn.BlockID
+		return outputStatus, nil
+	}
+
+	outputStatus.Status = reps.OutputUnspent
+	return outputStatus, nil
+}
+
+// Get the parameters this chain is running with
+func (bc *blockchainService) GetChainParams() reps.ChainParams {
+	return *bc.params
+}
+
+// Where the chain is at: its last block, and the difficulty and reward of the next one
+func (bc *blockchainService) GetChainInfo() (reps.ChainInfo, error) {
+	lastBlock, err := bc.GetLastBlock()
+	if err != nil {
+		return reps.ChainInfo{}, err
+	}
+
+	difficulty, err := bc.blockService.NextDifficulty(lastBlock.Hash)
+	if err != nil {
+		return reps.ChainInfo{}, err
+	}
+
+	nextHeight := lastBlock.Height + 1
+	nextHalvingHeight := 0
+	if bc.params.HalvingInterval > 0 && BlockReward(bc.params, nextHeight) > 0 {
+		nextHalvingHeight = (nextHeight/bc.params.HalvingInterval + 1) * bc.params.HalvingInterval
+	}
+
+	return reps.ChainInfo{
+		Height:            lastBlock.Height,
+		BestBlockHash:     hex.EncodeToString(lastBlock.Hash),
+		ChainWork:         lastBlock.ChainWork,
+		Difficulty:        difficulty,
+		Reward:            BlockReward(bc.params, nextHeight),
+		NextHalvingHeight: nextHalvingHeight,
+		Supply:            RewardSupply(bc.params, lastBlock.Height) + bc.params.Premine,
+		Orphans:           bc.orphans.size(),
+		Rules:             ConsensusRuleStatus(bc.params, nextHeight),
+		Params:            *bc.params,
+	}, nil
+}
+
+// How many of the last window blocks signal with each version bit
+func (bc *blockchainService) GetVersionBitsStats(window int) (reps.VersionBitsStats, error) {
+	if window == 0 {
+		window = DefaultSignalWindow
+	}
+	if window < 0 || window > MaxSignalWindow {
+		return reps.VersionBitsStats{}, fmt.Errorf("window must be between 1 and %d, not %d", MaxSignalWindow, window)
+	}
+
+	lastBlock, err := bc.GetLastBlock()
+	if err != nil {
+		return reps.VersionBitsStats{}, err
+	}
+
+	from := lastBlock.Height - window + 1
+	if from < 0 {
+		from = 0
+	}
+	blocks, err := bc.blockchainRepo.GetBlockHeadersByHeight(from, window)
+	if err != nil {
+		return reps.VersionBitsStats{}, err
+	}
+
+	return reps.VersionBitsStats{
+		From:   from,
+		To:     lastBlock.Height,
+		Blocks: len(blocks),
+		Bits:   CountSignals(blocks),
+	}, nil
+}
+
+// Average block time, difficulty and hash rate over each window of recent blocks, from their headers
+func (bc *blockchainService) GetBlockStats(windows []int) (reps.BlockStats, error) {
+	if len(windows) == 0 {
+		windows = DefaultStatsWindows
+	}
+	if len(windows) > MaxStatsWindows {
+		return reps.BlockStats{}, fmt.Errorf("stats can be taken over at most %d windows, not %d", MaxStatsWindows, len(windows))
+	}
+
+	largest := 0
+	for _, window := range windows {
+		if window < 2 || window > MaxStatsWindow {
+			return reps.BlockStats{}, fmt.Errorf("window must be between 2 and %d, not %d", MaxStatsWindow, window)
+		}
+		if window > largest {
+			largest = window
+		}
+	}
+
+	lastBlock, err := bc.GetLastBlock()
+	if err != nil {
+		return reps.BlockStats{}, err
+	}
+	difficulty, err := bc.blockService.NextDifficulty(lastBlock.Hash)
+	if err != nil {
+		return reps.BlockStats{}, err
+	}
+
+	from := lastBlock.Height - largest + 1
+	if from < 0 {
+		from = 0
+	}
+	blocks, err := bc.blockchainRepo.GetBlockHeadersByHeight(from, largest)
+	if err != nil {
+		return reps.BlockStats{}, err
+	}
+
+	stats := reps.BlockStats{
+		Height:          lastBlock.Height,
+		Difficulty:      difficulty,
+		TargetBlockTime: bc.params.TargetBlockTime,
+		Windows:         make([]reps.BlockWindowStats, 0, len(windows)),
+	}
+	for _, window := range windows {
+		start := len(blocks) - window
+		if start < 0 {
+			start = 0
+		}
+		stats.Windows = append(stats.Windows, blockWindowStats(window, blocks[start:]))
+	}
+
+	return stats, nil
+}
+
+// Stats over blocks, lowest first. Times are taken between consecutive blocks, so the first only marks the start
+func blockWindowStats(window int, blocks []reps.Block) reps.BlockWindowStats {
+	stats := reps.BlockWindowStats{Window: window, Blocks: len(blocks)}
+	if len(blocks) == 0 {
+		return stats
+	}
+
+	first, last := blocks[0], blocks[len(blocks)-1]
+	stats.From, stats.To = first.Height, last.Height
+	if len(blocks) < 2 {
+		return stats
+	}
+
+	work := 0.0
+	difficulties := 0
+	for _, block := range blocks[1:] {
+		difficulty := BlockDifficulty(block)
+		difficulties += difficulty
+		work += math.Pow(2, float64(difficulty))
+	}
+	stats.AverageDifficulty = float64(difficulties) / float64(len(blocks)-1)
+
+	seconds := float64(last.Timestamp-first.Timestamp) / 1000
+	if seconds > 0 {
+		stats.AverageBlockTime = seconds / float64(len(blocks)-1)
+		stats.HashRate = work / seconds
+	}
+
+	return stats
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/brucetieu/blockchain/repository"
+	reps "github.com/brucetieu/blockchain/representations"
+	log "github.com/sirupsen/logrus"
+)
+
+// Chain parameters used when nothing is configured
+func DefaultChainParams() reps.ChainParams {
+	return reps.ChainParams{
+		NetworkByte:        byte(0),
+		CoinbaseMaturity:   0,
+		DustThreshold:      0,
+		DifficultyInterval: 10,
+		TargetBlockTime:    60,
+		InitialReward:      Reward,
+		HalvingInterval:    210000,
+		MaxBlockSize:       1000000,
+		HashAlgorithm:      HashSHA256,
+		Consensus:          ConsensusPoW,
+		ReplayProtection:   true,
+	}
+}
+
+// Identifier of the chain that transaction signatures and block headers commit to: the chain id, if it has one, and
+// the network byte. Empty on chains without replay protection
+func ChainIdentifier(params *reps.ChainParams) string {
+	if !params.ReplayProtection {
+		return ""
+	}
+	if params.ChainID == "" {
+		return strconv.Itoa(int(params.NetworkByte))
+	}
+	return fmt.Sprintf("%s:%d", params.ChainID, params.NetworkByte)
+}
+
+// What the coinbase of the block at height pays besides fees: the initial reward, halved every halving interval
+// until it reaches 0. Chains created before rewards halved have neither param set, and keep paying Reward
+func BlockReward(params *reps.ChainParams, height int) int {
+	reward := params.InitialReward
+	if reward == 0 {
+		reward = Reward
+	}
+	if params.HalvingInterval == 0 {
+		return reward
+	}
+
+	halvings := height / params.HalvingInterval
+	if halvings >= 63 {
+		return 0
+	}
+
+	return reward >> halvings
+}
+
+// Coins the blocks up to and including height pay out in rewards
+func RewardSupply(params *reps.ChainParams, height int) int {
+	supply := 0
+	for start := 0; start <= height; {
+		reward := BlockReward(params, start)
+		if reward == 0 || params.HalvingInterval == 0 {
+			return supply + reward*(height+1-start)
+		}
+
+		// Every block up to the next halving pays the same
+		end := (start/params.HalvingInterval + 1) * params.HalvingInterval
+		if end > height+1 {
+			end = height + 1
+		}
+		supply += reward * (end - start)
+		start = end
+	}
+
+	return supply
+}
+
+// Load the chain parameters. If the blockchain already exists, the parameters stored with its genesis block are used,
+// otherwise the defaults are used, overridden by any env variables that are set
+func LoadChainParams(blockchainRepo repository.BlockchainRepository) *reps.ChainParams {
+	envHashAlgorithm := os.Getenv("HASH_ALGORITHM")
+
+	params, err := blockchainRepo.GetChainParams()
+	if err == nil {
+		log.Info("Using chain params stored with the blockchain")
+
+		// Blocks hashed one way can't follow blocks hashed another
+		if _, err := GetBlockHasher(params.HashAlgorithm); err != nil {
+			log.Fatal("Blockchain was created with ", err.Error())
+		}
+		if envHashAlgorithm != "" && chainHasher(&params).Algorithm() != envHashAlgorithm {
+			log.Fatalf("Blockchain was created with hash algorithm %s, it can't be switched to HASH_ALGORITHM %s", chainHasher(&params).Algorithm(), envHashAlgorithm)
+		}
+		return &params
+	}
+
+	params = DefaultChainParams()
+
+	envNetworkByte := os.Getenv("NETWORK_BYTE")
+	if envNetworkByte != "" {
+		// Accepts decimal or hex, e.g. 111 or 0x6f
+		networkByte, err := strconv.ParseUint(envNetworkByte, 0, 8)
+		if err != nil {
+			log.WithField("error", err.Error()).Warn("Invalid NETWORK_BYTE, using default of ", params.NetworkByte)
+		} else {
+			params.NetworkByte = byte(networkByte)
+		}
+	}
+
+	envCoinbaseMaturity := os.Getenv("COINBASE_MATURITY")
+	if envCoinbaseMaturity != "" {
+		coinbaseMaturity, err := strconv.Atoi(envCoinbaseMaturity)
+		if err != nil || coinbaseMaturity < 0 {
+			log.Warn("Invalid COINBASE_MATURITY, using default of ", params.CoinbaseMaturity)
+		} else {
+			params.CoinbaseMaturity = coinbaseMaturity
+		}
+	}
+
+	envDustThreshold := os.Getenv("DUST_THRESHOLD")
+	if envDustThreshold != "" {
+		dustThreshold, err := strconv.Atoi(envDustThreshold)
+		if err != nil || dustThreshold < 0 {
+			log.Warn("Invalid DUST_THRESHOLD, using default of ", params.DustThreshold)
+		} else {
+			params.DustThreshold = dustThreshold
+		}
+	}
+
+	envDifficultyInterval := os.Getenv("DIFFICULTY_INTERVAL")
+	if envDifficultyInterval != "" {
+		difficultyInterval, err := strconv.Atoi(envDifficultyInterval)
+		if err != nil || difficultyInterval < 0 {
+			log.Warn("Invalid DIFFICULTY_INTERVAL, using default of ", params.DifficultyInterval)
+		} else {
+			params.DifficultyInterval = difficultyInterval
+		}
+	}
+
+	envTargetBlockTime := os.Getenv("TARGET_BLOCK_TIME")
+	if envTargetBlockTime != "" {
+		targetBlockTime, err := strconv.Atoi(envTargetBlockTime)
+		if err != nil || targetBlockTime <= 0 {
+			log.Warn("Invalid TARGET_BLOCK_TIME, using default of ", params.TargetBlockTime)
+		} else {
+			params.TargetBlockTime = targetBlockTime
+		}
+	}
+
+	envInitialReward := os.Getenv("INITIAL_REWARD")
+	if envInitialReward != "" {
+		initialReward, err := strconv.Atoi(envInitialReward)
+		if err != nil || initialReward <= 0 {
+			log.Warn("Invalid INITIAL_REWARD, using default of ", params.InitialReward)
+		} else {
+			params.InitialReward = initialReward
+		}
+	}
+
+	envHalvingInterval := os.Getenv("HALVING_INTERVAL")
+	if envHalvingInterval != "" {
+		halvingInterval, err := strconv.Atoi(envHalvingInterval)
+		if err != nil || halvingInterval < 0 {
+			log.Warn("Invalid HALVING_INTERVAL, using default of ", params.HalvingInterval)
+		} else {
+			params.HalvingInterval = halvingInterval
+		}
+	}
+
+	if envHashAlgorithm != "" {
+		if _, err := GetBlockHasher(envHashAlgorithm); err != nil {
+			log.Fatal("Invalid HASH_ALGORITHM: ", err.Error())
+		}
+		params.HashAlgorithm = envHashAlgorithm
+	}
+
+	envMaxBlockSize := os.Getenv("MAX_BLOCK_SIZE")
+	if envMaxBlockSize != "" {
+		maxBlockSize, err := strconv.Atoi(envMaxBlockSize)
+		if err != nil || maxBlockSize < 0 {
+			log.Warn("Invalid MAX_BLOCK_SIZE, using default of ", params.MaxBlockSize)
+		} else {
+			params.MaxBlockSize = maxBlockSize
+		}
+	}
+
+	// A genesis spec can name it instead
+	if envChainID := os.Getenv("CHAIN_ID"); envChainID != "" {
+		params.ChainID = envChainID
+	}
+
+	if envConsensus := os.Getenv("CONSENSUS"); envConsensus != "" {
+		if !IsConsensusEngine(envConsensus) {
+			log.Warn("Invalid CONSENSUS, using default of ", params.Consensus)
+		} else {
+			params.Consensus = envConsensus
+		}
+	}
+
+	// A genesis spec can list them instead
+	if envValidators := os.Getenv("VALIDATORS"); envValidators != "" {
+		params.Validators = envValidators
+		for _, validator := range BFTValidators(&params) {
+			if !IsValidAddress(validator, params.NetworkByte) {
+				log.Fatalf("Invalid VALIDATORS, %s isn't an address on network %d", validator, params.NetworkByte)
+			}
+		}
+	}
+
+	// Scheduled the same on every node, so rules turn on at the same height everywhere
+	if envActivations := os.Getenv("ACTIVATIONS"); envActivations != "" {
+		activations, err := ParseActivations(envActivations)
+		if err != nil {
+			log.Fatal("Invalid ACTIVATIONS: ", err.Error())
+		}
+		params.Activations = FormatActivations(activations)
+	}
+
+	return &params
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/brucetieu/blockchain/repository"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Hex hash the block at each height has to have, for deployments with history worth protecting. Once the chain
+// is past a checkpoint, no block can branch off below it, however much work is behind it
+var Checkpoints = map[int]string{}
+
+// Parse a comma separated list of checkpoints, each a height and a hex block hash joined by a colon, e.g. 1000:00ab...
+func ParseCheckpoints(checkpoints string) (map[int]string, error) {
+	parsed := make(map[int]string)
+	for _, field := range strings.Split(checkpoints, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		parts := strings.Split(field, ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid checkpoint %s, expected <height>:<hash>", field)
+		}
+
+		height, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+		if err != nil || height < 0 {
+			return nil, fmt.Errorf("invalid height in checkpoint %s", field)
+		}
+
+		hash := strings.ToLower(strings.TrimSpace(parts[1]))
+		if decoded, err := hex.DecodeString(hash); err != nil || len(decoded) == 0 {
+			return nil, fmt.Errorf("invalid hash in checkpoint %s", field)
+		}
+
+		if other, ok := parsed[height]; ok && other != hash {
+			return nil, fmt.Errorf("height %d has two checkpoints", height)
+		}
+		parsed[height] = hash
+	}
+
+	return parsed, nil
+}
+
+// Height of the highest checkpoint, or -1 if there are none
+func LastCheckpoint() int {
+	last := -1
+	for height := range Checkpoints {
+		if height > last {
+			last = height
+		}
+	}
+	return last
+}
+
+// Use the checkpoints in CHECKPOINTS, if it's set, and make sure the stored chain agrees with them.
+// A node on a chain that doesn't refuses to start
+func CheckpointsAtStartup(blockchainRepo repository.BlockchainRepository) {
+	if envCheckpoints := os.Getenv("CHECKPOINTS"); envCheckpoints != "" {
+		checkpoints, err := ParseCheckpoints(envCheckpoints)
+		if err != nil {
+			log.Fatal("Invalid CHECKPOINTS: ", err.Error())
+		}
+		Checkpoints = checkpoints
+	}
+
+	for height, hash := range Checkpoints {
+		block, err := blockchainRepo.GetBlockByHeight(height)
+		if err != nil {
+			continue
+		}
+		if hex.EncodeToString(block.Hash) != hash {
+			log.Fatalf("Block at height %d has hash %x, not checkpoint %s", height, block.Hash, hash)
+		}
+	}
+
+	if len(Checkpoints) > 0 {
+		log.Infof("Using %d checkpoints, up to height %d", len(Checkpoints), LastCheckpoint())
+	}
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	reps "github.com/brucetieu/blockchain/representations"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Strategies for picking which unspent outputs pay for a transaction
+const (
+	CoinSelectionAll            = "all"
+	CoinSelectionLargestFirst   = "largest-first"
+	CoinSelectionSmallestFirst  = "smallest-first"
+	CoinSelectionBranchAndBound = "branch-and-bound"
+)
+
+var (
+	DefaultCoinSelection   = CoinSelectionAll
+	MaxBranchAndBoundTries = 100000 // Subsets branch-and-bound looks at before settling for the best so far
+
+	coinSelectors = map[string]CoinSelector{
+		CoinSelectionAll:            &allSelector{},
+		CoinSelectionLargestFirst:   &largestFirstSelector{},
+		CoinSelectionSmallestFirst:  &smallestFirstSelector{},
+		CoinSelectionBranchAndBound: &branchAndBoundSelector{},
+	}
+)
+
+// Picks which of a sender's unspent outputs to spend to cover target. When they can't cover it,
+// all of them are returned and it's up to the caller to turn the transaction down
+type CoinSelector interface {
+	Strategy() string
+	Select(unspent []reps.UnspentOutput, target int) []reps.UnspentOutput
+}
+
+// Get the selector for a strategy. No strategy means the node's default
+func GetCoinSelector(strategy string) (CoinSelector, error) {
+	if strategy == "" {
+		strategy = DefaultCoinSelection
+	}
+
+	selector, ok := coinSelectors[strategy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported coin selection strategy: %s", strategy)
+	}
+
+	return selector, nil
+}
+
+// Spends every output, sweeping the sender into a single change output
+type allSelector struct{}
+
+func (s *allSelector) Strategy() string {
+	return CoinSelectionAll
+}
+
+func (s *allSelector) Select(unspent []reps.UnspentOutput, target int) []reps.UnspentOutput {
+	return unspent
+}
+
+// Spends as few outputs as it can, leaving small ones behind
+type largestFirstSelector struct{}
+
+func (s *largestFirstSelector) Strategy() string {
+	return CoinSelectionLargestFirst
+}
+
+func (s *largestFirstSelector) Select(unspent []reps.UnspentOutput, target int) []reps.UnspentOutput {
+	return takeUntil(sortByValue(unspent, true), target)
+}
+
+// Spends small outputs first, consolidating them at the cost of bigger transactions
+type smallestFirstSelector struct{}
+
+func (s *smallestFirstSelector) Strategy() string {
+	return CoinSelectionSmallestFirst
+}
+
+func (s *smallestFirstSelector) Select(unspent []reps.UnspentOutput, target int) []reps.UnspentOutput {
+	return takeUntil(sortByValue(unspent, false), target)
+}
+
+// Searches for the outputs that overshoot target the least, ideally matching it exactly so there's no change output.
+// Falls back to largest-first if the search gives up before finding any
+type branchAndBoundSelector struct{}
+
+func (s *branchAndBoundSelector) Strategy() string {
+	return CoinSelectionBranchAndBound
+}
+
+func (s *branchAndBoundSelector) Select(unspent []reps.UnspentOutput, target int) []reps.UnspentOutput {
+	sorted := sortByValue(unspent, true)
+
+	// remaining[i] is what sorted[i:] holds, to prune branches that can't reach target
+	remaining := make([]int, len(sorted)+1)
+	for i := len(sorted) - 1; i >= 0; i-- {
+		remaining[i] = remaining[i+1] + sorted[i].Value
+	}
+	if remaining[0] < target {
+		return sorted
+	}
+
+	selected := make([]bool, len(sorted))
+	var best []reps.UnspentOutput
+	bestExcess := -1
+	tries := 0
+
+	// Returns true once the search should stop
+	var search func(i int, total int) bool
+	search = func(i int, total int) bool {
+		tries++
+		if tries > MaxBranchAndBoundTries {
+			return true
+		}
+
+		if total >= target {
+			if excess := total - target; bestExcess < 0 || excess < bestExcess {
+				bestExcess = excess
+				best = make([]reps.UnspentOutput, 0)
+				for j := 0; j < i; j++ {
+					if selected[j] {
+						best = append(best, sorted[j])
+					}
+				}
+			}
+			return bestExcess == 0
+		}
+		if i == len(sorted) || total+remaining[i] < target {
+			return false
+		}
+
+		selected[i] = true
+		if search(i+1, total+sorted[i].Value) {
+			return true
+		}
+		selected[i] = false
+		return search(i+1, total)
+	}
+	search(0, 0)
+
+	if best == nil {
+		return takeUntil(sorted, target)
+	}
+	return best
+}
+
+// Copy of unspent sorted by value. Outputs of the same value are ordered by outpoint, so selection is repeatable
+func sortByValue(unspent []reps.UnspentOutput, descending bool) []reps.UnspentOutput {
+	sorted := append([]reps.UnspentOutput{}, unspent...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Value != sorted[j].Value {
+			return (sorted[i].Value > sorted[j].Value) == descending
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+	return sorted
+}
+
+// Leading outputs of sorted holding at least target
+func takeUntil(sorted []reps.UnspentOutput, target int) []reps.UnspentOutput {
+	total := 0
+	for i, unspent := range sorted {
+		if total >= target {
+			return sorted[:i]
+		}
+		total += unspent.Value
+	}
+	return sorted
+}
+
+// Use the strategy in COIN_SELECTION by default, if it's set
+func CoinSelectionAtStartup() {
+	strategy := os.Getenv("COIN_SELECTION")
+	if strategy == "" {
+		return
+	}
+
+	if _, err := GetCoinSelector(strategy); err != nil {
+		log.Warn("Invalid COIN_SELECTION, using default of ", DefaultCoinSelection)
+		return
+	}
+	DefaultCoinSelection = strategy
+}
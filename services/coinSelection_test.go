@@ -0,0 +1,89 @@
+package services_test
+
+import (
+	"fmt"
+	"testing"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func unspentOutputs(values ...int) []reps.UnspentOutput {
+	unspent := make([]reps.UnspentOutput, 0, len(values))
+	for i, value := range values {
+		unspent = append(unspent, reps.UnspentOutput{ID: fmt.Sprintf("txn:%d", i), OutIdx: i, Value: value})
+	}
+	return unspent
+}
+
+func selectedValues(selected []reps.UnspentOutput) []int {
+	values := make([]int, 0, len(selected))
+	for _, unspent := range selected {
+		values = append(values, unspent.Value)
+	}
+	return values
+}
+
+func TestCoinSelectors(t *testing.T) {
+	unspent := unspentOutputs(5, 40, 10, 25, 1)
+
+	cases := []struct {
+		strategy string
+		target   int
+		expected []int
+	}{
+		{services.CoinSelectionAll, 10, []int{5, 40, 10, 25, 1}},
+		{services.CoinSelectionLargestFirst, 50, []int{40, 25}},
+		{services.CoinSelectionSmallestFirst, 12, []int{1, 5, 10}},
+		// 40 + 10 matches exactly, so there's no change
+		{services.CoinSelectionBranchAndBound, 50, []int{40, 10}},
+		{services.CoinSelectionBranchAndBound, 36, []int{25, 10, 1}},
+		// Not enough to cover it, everything is handed back
+		{services.CoinSelectionLargestFirst, 100, []int{40, 25, 10, 5, 1}},
+		{services.CoinSelectionBranchAndBound, 100, []int{40, 25, 10, 5, 1}},
+	}
+
+	for _, c := range cases {
+		selector, err := services.GetCoinSelector(c.strategy)
+		assert.NoError(t, err)
+		assert.Equal(t, c.expected, selectedValues(selector.Select(unspent, c.target)), "%s for %d", c.strategy, c.target)
+	}
+
+	selector, err := services.GetCoinSelector("")
+	assert.NoError(t, err)
+	assert.Equal(t, services.DefaultCoinSelection, selector.Strategy())
+
+	_, err = services.GetCoinSelector("random")
+	assert.Error(t, err)
+}
+
+func TestCreateTransactionWithCoinSelection(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+	repo.blocks = append(repo.blocks, reps.Block{ID: "second", PrevHash: []byte("genesis"), Timestamp: 1,
+		Transactions: []reps.Transaction{txnService.CreateCoinbaseTxn(from.Address, "")}})
+
+	recipients := []reps.Recipient{{To: to.Address, Amount: 10}}
+
+	// One of the two rewards covers it
+	txn, err := txnService.CreateTransactionToRecipients(from.Address, recipients, reps.TxnOptions{CoinSelection: services.CoinSelectionLargestFirst, FeeRate: 1})
+	assert.NoError(t, err)
+	assert.Len(t, txn.Inputs, 1)
+	valid, err := txnService.VerifyTransaction(txn)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	txn, err = txnService.CreateTransactionToRecipients(from.Address, recipients, reps.TxnOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, txn.Inputs, 2)
+
+	_, err = txnService.CreateTransactionToRecipients(from.Address, recipients, reps.TxnOptions{CoinSelection: "random"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/brucetieu/blockchain/repository"
+	reps "github.com/brucetieu/blockchain/representations"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	consensusEngines = map[string]ConsensusEngineFactory{
+		ConsensusPoW: newPowEngine,
+		ConsensusPoS: newProposerEngine,
+		ConsensusBFT: newProposerEngine,
+	}
+)
+
+// Decides which blocks a chain accepts: what a block's header has to prove, how a block is produced that proves it,
+// and which of two chains the node follows. Blocks are only produced and checked through the chain's engine
+type ConsensusEngine interface {
+	// Difficulty of the block at height on top of parent
+	NextDifficulty(parent reps.Block, height int) (int, error)
+	// Check block's hash matches its header and it carries the proof the chain calls for. Without its parent, only
+	// what the block proves by itself is checked
+	ValidateHeader(block reps.Block, parent *reps.Block) error
+	// Make an assembled block valid, as produced by the address producer
+	Seal(block reps.Block, producer string) (reps.Block, error)
+	// Positive if the chain ending at candidate beats the one ending at current, 0 if neither does
+	CompareChains(current reps.Block, candidate reps.Block) int
+}
+
+// Signs blocks as their proposer
+type BlockSigner interface {
+	SignBlock(block reps.Block, wallet reps.Wallet) (reps.Block, error)
+}
+
+// Builds a chain's engine. Engines that only validate blocks get no walletService or signer to produce them with
+type ConsensusEngineFactory func(blockchainRepo repository.BlockchainRepository, walletService WalletService,
+	signer BlockSigner, params *reps.ChainParams) ConsensusEngine
+
+// Make an engine available to chains whose params name it as their consensus
+func RegisterConsensusEngine(consensus string, factory ConsensusEngineFactory) {
+	consensusEngines[consensus] = factory
+}
+
+// Whether there's an engine for consensus
+func IsConsensusEngine(consensus string) bool {
+	_, ok := consensusEngines[consensus]
+	return ok
+}
+
+// Engine of the chain with params. Chains from before the consensus could be picked have none, and are proof of work
+func NewConsensusEngine(blockchainRepo repository.BlockchainRepository, walletService WalletService, signer BlockSigner,
+	params *reps.ChainParams) ConsensusEngine {
+	factory, ok := consensusEngines[params.Consensus]
+	if !ok {
+		factory = newPowEngine
+	}
+	return factory(blockchainRepo, walletService, signer, params)
+}
+
+// Blocks are mined by whoever solves their proof of work first, and the chain with the most work wins
+type powEngine struct {
+	blockchainRepo repository.BlockchainRepository
+	params         *reps.ChainParams
+}
+
+func newPowEngine(blockchainRepo repository.BlockchainRepository, walletService WalletService, signer BlockSigner,
+	params *reps.ChainParams) ConsensusEngine {
+	return &powEngine{
+		blockchainRepo: blockchainRepo,
+		params:         params,
+	}
+}
+
+// Difficulty of the block at height on top of parent. It's the parent's difficulty, except every
+// DifficultyInterval blocks, when it's retargeted from how long the last DifficultyInterval blocks took to mine
+func (e *powEngine) NextDifficulty(parent reps.Block, height int) (int, error) {
+	if height == 0 {
+		if e.params.InitialDifficulty > 0 {
+			return e.params.InitialDifficulty, nil
+		}
+		return TargetBits, nil
+	}
+
+	// The genesis block isn't mined to a schedule, so the first interval isn't timed
+	interval := e.params.DifficultyInterval
+	if interval <= 0 || height%interval != 0 || height <= interval {
+		return BlockDifficulty(parent), nil
+	}
+
+	start, err := ancestorAt(e.blockchainRepo, parent, height-1-interval)
+	if err != nil {
+		return 0, fmt.Errorf("%s, height: %d", err.Error(), height-1-interval)
+	}
+
+	actual := parent.Timestamp - start.Timestamp
+	expected := int64(interval) * int64(e.params.TargetBlockTime) * 1000
+	difficulty := RetargetDifficulty(BlockDifficulty(parent), actual, expected)
+	log.WithFields(log.Fields{"height": height, "actual": actual, "expected": expected, "difficulty": difficulty}).Info("Retargeting difficulty")
+
+	return difficulty, nil
+}
+
+// Ancestor of block at height, whether block is on the chain or a side branch. Side blocks are walked back one at a
+// time, and once the branch meets the chain, the rest is looked up by height
+func ancestorAt(blockchainRepo repository.BlockchainRepository, block reps.Block, height int) (reps.Block, error) {
+	for block.Height > height {
+		if onChain, err := blockchainRepo.GetBlockByHeight(block.Height); err == nil && bytes.Equal(onChain.Hash, block.Hash) {
+			return blockchainRepo.GetBlockByHeight(height)
+		}
+		parent, err := knownBlock(blockchainRepo, block.PrevHash)
+		if err != nil {
+			return reps.Block{}, err
+		}
+		block = parent
+	}
+	return block, nil
+}
+
+// The hash has to be under the target for the block's difficulty, which on top of its parent is the difficulty the
+// chain calls for there. Mined blocks have no proposer
+func (e *powEngine) ValidateHeader(block reps.Block, parent *reps.Block) error {
+	// Every block on a chain is hashed with the algorithm it was created with
+	hasher, err := GetBlockHasher(e.params.HashAlgorithm)
+	if err != nil {
+		return err
+	}
+	pow := NewProofOfWorkService(&block, hasher)
+	if !bytes.Equal(pow.HashData(), block.Hash) {
+		return fmt.Errorf("%w: %s hash of block %s doesn't match its contents", ErrInvalidBlock, hasher.Algorithm(), block.ID)
+	}
+	if len(block.Proposer) > 0 || len(block.ProposerSig) > 0 || block.Round != 0 {
+		return fmt.Errorf("%w: block %s has a proposer, but the chain is proof of work", ErrInvalidBlock, block.ID)
+	}
+
+	difficulty := BlockDifficulty(block)
+	if parent != nil {
+		expected, err := e.NextDifficulty(*parent, block.Height)
+		if err != nil {
+			return err
+		}
+		if difficulty != expected {
+			return fmt.Errorf("%w: block %s has difficulty %d, expected %d", ErrInvalidBlock, block.ID, difficulty, expected)
+		}
+	}
+
+	if !pow.ValidateProof() {
+		return fmt.Errorf("%w: hash of block %s doesn't meet its difficulty of %d", ErrInvalidBlock, block.ID, difficulty)
+	}
+
+	return nil
+}
+
+// Solve the block's proof of work. Anyone can mine a block, so it doesn't matter who producer is
+func (e *powEngine) Seal(block reps.Block, producer string) (reps.Block, error) {
+	proof := NewProofOfWorkService(&block, chainHasher(e.params))
+	block.Nounce, block.Hash = proof.Solve()
+
+	return block, nil
+}
+
+// The first chain seen keeps the node when they're tied
+func (e *powEngine) CompareChains(current reps.Block, candidate reps.Block) int {
+	return ChainWork(candidate).Cmp(ChainWork(current))
+}
@@ -0,0 +1,84 @@
+package services_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/brucetieu/blockchain/repository"
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/stretchr/testify/assert"
+)
+
+// Seals blocks with a nounce of its own, and only accepts blocks that carry it
+type fixedNounceEngine struct {
+	nounce int64
+	sealed int
+}
+
+func (e *fixedNounceEngine) NextDifficulty(parent reps.Block, height int) (int, error) {
+	return 0, nil
+}
+
+func (e *fixedNounceEngine) ValidateHeader(block reps.Block, parent *reps.Block) error {
+	if block.Nounce != e.nounce || !bytes.Equal(services.NewProofOfWorkService(&block, sha256Hasher).HashData(), block.Hash) {
+		return services.ErrInvalidBlock
+	}
+	return nil
+}
+
+func (e *fixedNounceEngine) Seal(block reps.Block, producer string) (reps.Block, error) {
+	e.sealed++
+	block.Nounce = e.nounce
+	block.Hash = services.NewProofOfWorkService(&block, sha256Hasher).HashData()
+	return block, nil
+}
+
+func (e *fixedNounceEngine) CompareChains(current reps.Block, candidate reps.Block) int {
+	return services.ChainWork(candidate).Cmp(services.ChainWork(current))
+}
+
+func TestBlocksAreProducedAndAcceptedThroughRegisteredEngine(t *testing.T) {
+	engine := &fixedNounceEngine{nounce: 42}
+	services.RegisterConsensusEngine("fixed", func(repository.BlockchainRepository, services.WalletService, services.BlockSigner,
+		*reps.ChainParams) services.ConsensusEngine {
+		return engine
+	})
+	assert.True(t, services.IsConsensusEngine("fixed"))
+	assert.False(t, services.IsConsensusEngine("unknown"))
+
+	params := mainnet
+	params.Consensus = "fixed"
+	ts := newTestServicesWithParams(t, &params)
+	walletService, blockchainService := ts.walletService, ts.blockchainService
+
+	miner, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+
+	genesis, _, err := blockchainService.CreateBlockchain(miner.Address, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), genesis.Nounce)
+
+	block, err := blockchainService.MineTransactions(nil, miner.Address, "")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), block.Nounce)
+	assert.Equal(t, 2, engine.sealed)
+
+	// A block the engine didn't seal doesn't get on the chain
+	next, err := blockchainService.AssembleBlock(nil, miner.Address, "")
+	assert.NoError(t, err)
+	next.Nounce = 41
+	next.Hash = services.NewProofOfWorkService(&next, sha256Hasher).HashData()
+	_, err = blockchainService.ProcessBlock(next)
+	assert.True(t, errors.Is(err, services.ErrInvalidBlock))
+
+	sealed, err := engine.Seal(next, miner.Address)
+	assert.NoError(t, err)
+	_, err = blockchainService.ProcessBlock(sealed)
+	assert.NoError(t, err)
+
+	last, err := blockchainService.GetLastBlock()
+	assert.NoError(t, err)
+	assert.Equal(t, sealed.Hash, last.Hash)
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	reps "github.com/brucetieu/blockchain/representations"
+)
+
+// Consensus rules that turn on at the height the chain params activate them at, on every node at once
+const (
+	RuleEd25519 = "ed25519" // Transactions and blocks can be signed with Ed25519
+)
+
+// What each consensus rule does
+var ConsensusRules = map[string]string{
+	RuleEd25519: "Transactions and blocks can be signed with Ed25519",
+}
+
+// Rule a signature scheme is only allowed under, if any
+var sigAlgorithmRules = map[string]string{
+	SigAlgorithmEd25519: RuleEd25519,
+}
+
+// Parse comma separated activations, each a rule and the height it turns on at joined by a colon, e.g. ed25519:1000
+func ParseActivations(activations string) (map[string]int, error) {
+	parsed := make(map[string]int)
+	for _, field := range strings.Split(activations, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		parts := strings.Split(field, ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid activation %s, expected <rule>:<height>", field)
+		}
+
+		rule := strings.TrimSpace(parts[0])
+		if _, ok := ConsensusRules[rule]; !ok {
+			return nil, fmt.Errorf("unknown consensus rule %s in activation %s", rule, field)
+		}
+
+		height, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || height < 0 {
+			return nil, fmt.Errorf("invalid height in activation %s", field)
+		}
+
+		if _, ok := parsed[rule]; ok {
+			return nil, fmt.Errorf("rule %s is activated twice", rule)
+		}
+		parsed[rule] = height
+	}
+
+	return parsed, nil
+}
+
+// Activations as the chain params store them, in order of rule
+func FormatActivations(activations map[string]int) string {
+	rules := make([]string, 0, len(activations))
+	for rule := range activations {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+
+	fields := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		fields = append(fields, fmt.Sprintf("%s:%d", rule, activations[rule]))
+	}
+	return strings.Join(fields, ",")
+}
+
+// Height rule turns on at. Rules the chain params don't activate have been on since the genesis block, so chains
+// created before a rule could be scheduled keep the behaviour they always had
+func ActivationHeight(params *reps.ChainParams, rule string) int {
+	activations, err := ParseActivations(params.Activations)
+	if err != nil {
+		return 0
+	}
+	return activations[rule]
+}
+
+// Whether rule is on for the block at height
+func RuleActive(params *reps.ChainParams, rule string, height int) bool {
+	return height >= ActivationHeight(params, rule)
+}
+
+// Every consensus rule, when it turns on and whether it's on for the block at height
+func ConsensusRuleStatus(params *reps.ChainParams, height int) []reps.ConsensusRule {
+	status := make([]reps.ConsensusRule, 0, len(ConsensusRules))
+	for rule, description := range ConsensusRules {
+		status = append(status, reps.ConsensusRule{
+			Name:             rule,
+			Description:      description,
+			ActivationHeight: ActivationHeight(params, rule),
+			Active:           RuleActive(params, rule, height),
+		})
+	}
+	sort.Slice(status, func(i, j int) bool { return status[i].Name < status[j].Name })
+	return status
+}
+
+// Check signatures with algorithm are allowed on the block at height. Algorithms no rule is needed for always are
+func checkSigAlgorithmActive(params *reps.ChainParams, algorithm string, height int) error {
+	rule, ok := sigAlgorithmRules[algorithm]
+	if !ok || RuleActive(params, rule, height) {
+		return nil
+	}
+	return fmt.Errorf("%s signatures aren't allowed until rule %s activates at height %d", algorithm, rule, ActivationHeight(params, rule))
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	reps "github.com/brucetieu/blockchain/representations"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	DefaultConsolidationMaxValue = 10  // Outputs worth less than this are swept, unless the caller says otherwise
+	MinConsolidationInputs       = 10  // Fewest small outputs worth sweeping
+	MaxConsolidationInputs       = 100 // Most outputs swept by one transaction, to keep it a reasonable size
+	ConsolidationMaxMempoolSize  = 10  // Most pending transactions for the network to count as quiet
+)
+
+// Sweeps an address's many small outputs into one while the network is quiet and fees are low,
+// so later transactions from it need fewer inputs
+type ConsolidationService interface {
+	ConsolidateAddress(address string, maxValue int, force bool, opts reps.TxnOptions) (reps.Transaction, error)
+}
+
+type consolidationService struct {
+	transactionService TransactionService
+	mempoolService     MempoolService
+	feeService         FeeService
+}
+
+func NewConsolidationService(transactionService TransactionService, mempoolService MempoolService, feeService FeeService) ConsolidationService {
+	return &consolidationService{
+		transactionService: transactionService,
+		mempoolService:     mempoolService,
+		feeService:         feeService,
+	}
+}
+
+// Queue a transaction sweeping address's outputs worth less than maxValue back to it. Unless forced, it waits for the
+// mempool to quieten down. Without a fee or fee rate, it pays the low rate from the fee estimate
+func (cs *consolidationService) ConsolidateAddress(address string, maxValue int, force bool, opts reps.TxnOptions) (reps.Transaction, error) {
+	log.WithFields(log.Fields{"address": address, "maxValue": maxValue, "force": force}).Info("Consolidating unspent outputs")
+
+	if size := cs.mempoolService.Size(); !force && size > ConsolidationMaxMempoolSize {
+		return reps.Transaction{}, fmt.Errorf("%w: %d transactions are pending, consolidating waits for at most %d", ErrNetworkBusy, size, ConsolidationMaxMempoolSize)
+	}
+
+	if maxValue == 0 {
+		maxValue = DefaultConsolidationMaxValue
+	}
+
+	if opts.Fee == 0 && opts.FeeRate == 0 {
+		estimate, err := cs.feeService.EstimateFees()
+		if err != nil {
+			return reps.Transaction{}, err
+		}
+		opts.FeeRate = estimate.Low
+	}
+
+	txn, err := cs.transactionService.CreateConsolidationTransaction(address, maxValue, opts)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	if _, err := cs.mempoolService.AddTransaction(txn); err != nil {
+		return reps.Transaction{}, err
+	}
+
+	return txn, nil
+}
+
+// Create and sign a transaction spending up to MaxConsolidationInputs of an address's outputs worth less than maxValue,
+// smallest first, into a single output back to it
+func (ts *transactionService) CreateConsolidationTransaction(address string, maxValue int, opts reps.TxnOptions) (reps.Transaction, error) {
+	if err := validateOptions(opts); err != nil {
+		return reps.Transaction{}, err
+	}
+	if maxValue <= 0 {
+		return reps.Transaction{}, fmt.Errorf("max value of outputs to consolidate must be positive, not %d", maxValue)
+	}
+
+	wallet, err := ts.walletService.GetWallet(address)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+	if wallet.WatchOnly {
+		err := fmt.Errorf("%w: %s", ErrWatchOnly, address)
+		log.Error(err)
+		return reps.Transaction{}, err
+	}
+
+	scheme, err := GetSignatureScheme(wallet.SigAlgorithm)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	pubKey, _ := hex.DecodeString(wallet.PublicKey)
+	pubKeyHash, _ := createPubKeyHash(pubKey)
+
+	small := make([]reps.UnspentOutput, 0)
+	for _, unspent := range sortByValue(ts.selectOutputs(pubKeyHash, 0, &allSelector{}), false) {
+		if unspent.Value >= maxValue || len(small) == MaxConsolidationInputs {
+			break
+		}
+		small = append(small, unspent)
+	}
+	if len(small) < MinConsolidationInputs {
+		return reps.Transaction{}, fmt.Errorf("%w: %s has %d spendable outputs worth less than %d, at least %d are needed",
+			ErrNothingToConsolidate, address, len(small), maxValue, MinConsolidationInputs)
+	}
+
+	txn := reps.Transaction{
+		SigAlgorithm: scheme.Algorithm(),
+		Memo:         opts.Memo,
+		LockTime:     opts.LockTime,
+		Replaceable:  opts.Replaceable,
+	}
+	totalIn := 0
+	for _, unspent := range small {
+		txn.Inputs = append(txn.Inputs, newTxnInput(unspent, pubKey))
+		totalIn += unspent.Value
+	}
+
+	txn.Outputs = []reps.TxnOutput{ts.NewTxnOutput(totalIn, address)}
+	txn.Fee = opts.Fee
+	if opts.FeeRate > 0 {
+		txn.Fee = ts.sizedFee(txn, opts.FeeRate)
+	}
+	if totalIn-txn.Fee < ts.params.DustThreshold || totalIn-txn.Fee <= 0 {
+		return reps.Transaction{}, fmt.Errorf("a fee of %d would use up most of the %d held by the outputs, not consolidating", txn.Fee, totalIn)
+	}
+	txn.Outputs[0].Value = totalIn - txn.Fee
+
+	ts.setID(&txn)
+
+	prevTxns, err := ts.GetPrevTransactions(txn)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	return ts.Sign(wallet, txn, prevTxns)
+}
@@ -0,0 +1,58 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsolidateAddressSweepsSmallOutputs(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+	mempoolService := ts.mempoolService
+	consolidationService := services.NewConsolidationService(txnService, mempoolService, services.NewFeeService(repo, mempoolService))
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	dusty, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	// A single output isn't worth sweeping
+	_, err = consolidationService.ConsolidateAddress(from.Address, 100, false, reps.TxnOptions{})
+	assert.True(t, errors.Is(err, services.ErrNothingToConsolidate))
+
+	recipients := make([]reps.Recipient, 0)
+	for i := 0; i < 12; i++ {
+		recipients = append(recipients, reps.Recipient{To: dusty.Address, Amount: 2})
+	}
+	payouts, err := txnService.CreateTransactionToRecipients(from.Address, recipients, reps.TxnOptions{})
+	assert.NoError(t, err)
+	repo.blocks = append(repo.blocks, reps.Block{ID: "second", PrevHash: []byte("genesis"), Timestamp: 1, Transactions: []reps.Transaction{payouts}})
+
+	// Waits for the mempool to quieten down unless forced
+	busy, err := txnService.CreateTransaction(from.Address, dusty.Address, 20)
+	assert.NoError(t, err)
+	_, err = mempoolService.AddTransaction(busy)
+	assert.NoError(t, err)
+	defer func(size int) { services.ConsolidationMaxMempoolSize = size }(services.ConsolidationMaxMempoolSize)
+	services.ConsolidationMaxMempoolSize = 0
+
+	_, err = consolidationService.ConsolidateAddress(dusty.Address, 0, false, reps.TxnOptions{})
+	assert.True(t, errors.Is(err, services.ErrNetworkBusy))
+
+	txn, err := consolidationService.ConsolidateAddress(dusty.Address, 0, true, reps.TxnOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, txn.Inputs, 12)
+	assert.Len(t, txn.Outputs, 1)
+	assert.Greater(t, txn.Fee, 0)
+	assert.Equal(t, 24-txn.Fee, txn.Outputs[0].Value)
+	assert.Equal(t, 2, mempoolService.Size())
+
+	valid, err := txnService.VerifyTransaction(txn)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
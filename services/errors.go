@@ -0,0 +1,93 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// Returned when asked to sign with a watch-only address
+	ErrWatchOnly = errors.New("address is watch-only, there is no private key to sign with")
+
+	// Returned when a private key is needed while the wallet file is locked
+	ErrWalletLocked = errors.New("wallet file is locked")
+
+	// Returned when no vanity address was found before the timeout
+	ErrVanityTimeout = errors.New("timed out searching for vanity address")
+
+	// Returned when the mempool is full of transactions paying at least as much as a new one
+	ErrMempoolFull = errors.New("mempool is full")
+
+	// Returned when maintenance that waits for a quiet network is asked for while it's busy
+	ErrNetworkBusy = errors.New("network is busy")
+
+	// Returned when an address doesn't have enough small outputs to be worth consolidating
+	ErrNothingToConsolidate = errors.New("nothing to consolidate")
+
+	// Returned when a block's hash or proof of work doesn't check out
+	ErrInvalidBlock = errors.New("invalid block")
+
+	// Returned when a transaction is looked for on a block it isn't on
+	ErrTxnNotOnBlock = errors.New("transaction is not on block")
+
+	// Returned when the background miner is started or resumed while it's running, stopped, paused or
+	// changed while it isn't, or paused while it already is
+	ErrMinerRunning = errors.New("miner is already running")
+	ErrMinerStopped = errors.New("miner is not running")
+	ErrMinerPaused  = errors.New("miner is already paused")
+
+	// Returned when a solved block is submitted for a template that's unknown, or no longer builds on the last block
+	ErrStaleTemplate = errors.New("block template is unknown or stale")
+
+	// Returned when a block received from elsewhere is already on the chain, or already waiting on its parent
+	ErrKnownBlock = errors.New("block is already known")
+
+	// Returned when a block on a proof of stake or BFT chain is produced by anyone other than the validator elected for it
+	ErrNotProposer = errors.New("not the elected block proposer")
+
+	// Returned when a vote for a block on a BFT chain is cast by an address that isn't one of its validators
+	ErrNotValidator = errors.New("not a validator")
+
+	// Returned when a validator votes for a block at a height it already voted for another block at
+	ErrConflictingVote = errors.New("already voted for another block at this height")
+)
+
+// Reasons a transaction can fail verification
+const (
+	InvalidTxnMissingSignature = "missing_signature"
+	InvalidTxnUnknownOutput    = "unknown_output"
+	InvalidTxnPubKeyMismatch   = "pubkey_mismatch"
+	InvalidTxnBadSignature     = "invalid_signature"
+	InvalidTxnUnknownAlgorithm = "unknown_algorithm"
+	InvalidTxnDoubleSpend      = "double_spend"
+	InvalidTxnValue            = "invalid_value"
+	InvalidTxnCoinbase         = "invalid_coinbase"
+	InvalidTxnMempoolConflict  = "mempool_conflict"
+	InvalidTxnMemo             = "invalid_memo"
+	InvalidTxnLocked           = "locked"
+	InvalidTxnImmatureCoinbase = "immature_coinbase"
+	InvalidTxnReplacementFee   = "replacement_fee_too_low"
+	InvalidTxnID               = "invalid_id"
+	InvalidTxnDust             = "dust"
+	InvalidTxnAsset            = "invalid_asset"
+	InvalidTxnStake            = "invalid_stake"
+	InvalidTxnInactiveRule     = "inactive_rule"
+	InvalidTxnEvidence         = "invalid_evidence"
+	InvalidTxnValidatorChange  = "invalid_validator_change"
+)
+
+// Returned when a transaction input can't prove ownership of the output it spends.
+// InputIndex -> Index of the offending input in the transaction, -1 when it's not down to a single input
+type TxnVerificationError struct {
+	TxnID      string `json:"txnId"`
+	InputIndex int    `json:"inputIndex"`
+	Reason     string `json:"reason"`
+	Message    string `json:"message"`
+}
+
+func (e *TxnVerificationError) Error() string {
+	if e.InputIndex < 0 {
+		return fmt.Sprintf("invalid transaction %s: %s", e.TxnID, e.Message)
+	}
+	return fmt.Sprintf("invalid transaction %s, input %d: %s", e.TxnID, e.InputIndex, e.Message)
+}
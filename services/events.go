@@ -0,0 +1,127 @@
+package services
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	reps "github.com/brucetieu/blockchain/representations"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Types of chain events
+const (
+	EventDeepReorg = "deep_reorg" // A reorg took more blocks off the chain than ReorgAlertDepth
+)
+
+var ReorgAlertDepth = 3               // Blocks a reorg can take off the chain before it raises an alert
+var Webhooks = []string{}             // URLs chain events are POSTed to
+var WebhookTimeout = 10 * time.Second // How long to wait on each webhook
+
+var metrics = struct {
+	sync.Mutex
+	reps.Metrics
+}{}
+
+// Alert on reorgs deeper than REORG_ALERT_DEPTH, and POST chain events to the comma separated WEBHOOK_URLS, if they're set
+func EventsAtStartup() {
+	if envDepth := os.Getenv("REORG_ALERT_DEPTH"); envDepth != "" {
+		depth, err := strconv.Atoi(envDepth)
+		if err != nil || depth < 0 {
+			log.Warn("Invalid REORG_ALERT_DEPTH, using default of ", ReorgAlertDepth)
+		} else {
+			ReorgAlertDepth = depth
+		}
+	}
+
+	for _, url := range strings.Split(os.Getenv("WEBHOOK_URLS"), ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			Webhooks = append(Webhooks, url)
+		}
+	}
+	if len(Webhooks) > 0 {
+		log.Info("Sending chain events to webhooks ", Webhooks)
+	}
+}
+
+// Counters of what the node has seen since it started
+func GetMetrics() reps.Metrics {
+	metrics.Lock()
+	defer metrics.Unlock()
+	return metrics.Metrics
+}
+
+// Log an event and POST it to every webhook. Webhooks are called in the background, so a slow one doesn't hold up
+// the chain, and an event one doesn't take is not retried
+func PublishEvent(eventType string, data interface{}) {
+	event := reps.ChainEvent{Type: eventType, Timestamp: time.Now().UnixMilli(), Data: data}
+	log.WithFields(log.Fields{"type": eventType, "data": data}).Warn("Chain event")
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Error("Error encoding chain event: ", err.Error())
+		return
+	}
+
+	for _, url := range Webhooks {
+		go postEvent(url, body)
+	}
+}
+
+func postEvent(url string, body []byte) {
+	client := &http.Client{Timeout: WebhookTimeout}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err == nil {
+		resp.Body.Close()
+	}
+
+	metrics.Lock()
+	defer metrics.Unlock()
+	if err != nil || resp.StatusCode >= 300 {
+		metrics.WebhookFailures++
+		log.WithField("url", url).Error("Webhook didn't take chain event")
+		return
+	}
+	metrics.EventsSent++
+}
+
+// Count a reorg taking disconnected off the chain in favour of branch, and raise an alert if it's deeper than ReorgAlertDepth
+func reportReorg(fork reps.Block, disconnected []reps.Block, branch []reps.Block) {
+	depth := len(disconnected)
+	deep := depth > ReorgAlertDepth
+
+	metrics.Lock()
+	metrics.Reorgs++
+	if deep {
+		metrics.DeepReorgs++
+	}
+	if depth > metrics.DeepestReorg {
+		metrics.DeepestReorg = depth
+	}
+	metrics.Unlock()
+
+	if !deep {
+		return
+	}
+
+	event := reps.ReorgEvent{
+		ForkHeight:   fork.Height,
+		Depth:        depth,
+		Threshold:    ReorgAlertDepth,
+		NewTip:       hex.EncodeToString(branch[len(branch)-1].Hash),
+		Disconnected: make([]string, 0, depth),
+	}
+	for _, block := range disconnected {
+		event.Disconnected = append(event.Disconnected, hex.EncodeToString(block.Hash))
+	}
+	event.OldTip = event.Disconnected[depth-1]
+
+	PublishEvent(EventDeepReorg, event)
+}
@@ -0,0 +1,92 @@
+package services_test
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeepReorgIsPostedToWebhooks(t *testing.T) {
+	events := make(chan reps.ChainEvent, 1)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event reps.ChainEvent
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		events <- event
+	}))
+	defer webhook.Close()
+
+	defer func(depth int, webhooks []string) {
+		services.ReorgAlertDepth, services.Webhooks = depth, webhooks
+	}(services.ReorgAlertDepth, services.Webhooks)
+	services.ReorgAlertDepth = 1
+	services.Webhooks = []string{webhook.URL}
+
+	ts := newTestServices(t)
+	repo, keystore, walletService := ts.repo, ts.keystore, ts.walletService
+	txnService, blockchainService := ts.txnService, ts.blockchainService
+	miner, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, miner.Address)
+
+	// A peer with the same genesis mines a longer branch than the chain has
+	peerRepo := newFakeBlockchainRepository()
+	peerRepo.blocks = append(peerRepo.blocks, repo.blocks...)
+	peerTxnService := services.NewTransactionService(peerRepo, walletService, nil, services.NewLocalSigner(keystore), &mainnet)
+	peer := services.NewBlockchainService(peerRepo, services.NewBlockService(peerRepo, &mainnet), peerTxnService, walletService, &mainnet)
+	branch := make([]reps.Block, 0)
+	for i := 0; i < 3; i++ {
+		block, err := peer.MineTransactions([]reps.Transaction{}, miner.Address, "peer")
+		assert.NoError(t, err)
+		branch = append(branch, block)
+	}
+
+	// Taking one block off isn't deeper than the threshold
+	before := services.GetMetrics()
+	_, err = blockchainService.MineTransactions([]reps.Transaction{}, miner.Address, "")
+	assert.NoError(t, err)
+	for _, block := range branch[:2] {
+		_, err = blockchainService.ProcessBlock(block)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, before.Reorgs+1, services.GetMetrics().Reorgs)
+	assert.Equal(t, before.DeepReorgs, services.GetMetrics().DeepReorgs)
+
+	// Taking three off is
+	local := make([]reps.Block, 0)
+	for i := 0; i < 3; i++ {
+		block, err := blockchainService.MineTransactions([]reps.Transaction{}, miner.Address, "")
+		assert.NoError(t, err)
+		local = append(local, block)
+	}
+	for i := 0; i < 3; i++ {
+		block, err := peer.MineTransactions([]reps.Transaction{}, miner.Address, "peer")
+		assert.NoError(t, err)
+		branch = append(branch, block)
+	}
+	for _, block := range branch[2:] {
+		_, err = blockchainService.ProcessBlock(block)
+		assert.NoError(t, err)
+	}
+
+	select {
+	case event := <-events:
+		assert.Equal(t, services.EventDeepReorg, event.Type)
+		data := event.Data.(map[string]interface{})
+		assert.Equal(t, float64(2), data["forkHeight"])
+		assert.Equal(t, float64(3), data["depth"])
+		assert.Equal(t, hex.EncodeToString(local[2].Hash), data["oldTip"])
+		assert.Equal(t, hex.EncodeToString(branch[5].Hash), data["newTip"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook got no event")
+	}
+	assert.Equal(t, before.Reorgs+2, services.GetMetrics().Reorgs)
+	assert.Equal(t, before.DeepReorgs+1, services.GetMetrics().DeepReorgs)
+	assert.GreaterOrEqual(t, services.GetMetrics().DeepestReorg, 3)
+}
@@ -0,0 +1,52 @@
+package services_test
+
+import (
+	"fmt"
+
+	reps "github.com/brucetieu/blockchain/representations"
+)
+
+// In memory AddressBookRepository
+type fakeAddressBookRepository struct {
+	entries map[string]reps.AddressBookEntry
+}
+
+func newFakeAddressBookRepository() *fakeAddressBookRepository {
+	return &fakeAddressBookRepository{
+		entries: make(map[string]reps.AddressBookEntry),
+	}
+}
+
+func (repo *fakeAddressBookRepository) CreateEntry(entry reps.AddressBookEntry) error {
+	repo.entries[entry.Name] = entry
+	return nil
+}
+
+func (repo *fakeAddressBookRepository) UpdateEntry(entry reps.AddressBookEntry) error {
+	repo.entries[entry.Name] = entry
+	return nil
+}
+
+func (repo *fakeAddressBookRepository) GetEntry(name string) (reps.AddressBookEntry, error) {
+	entry, ok := repo.entries[name]
+	if !ok {
+		return reps.AddressBookEntry{}, fmt.Errorf("record not found")
+	}
+	return entry, nil
+}
+
+func (repo *fakeAddressBookRepository) GetEntries() ([]reps.AddressBookEntry, error) {
+	entries := make([]reps.AddressBookEntry, 0)
+	for _, entry := range repo.entries {
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (repo *fakeAddressBookRepository) DeleteEntry(name string) error {
+	if _, ok := repo.entries[name]; !ok {
+		return fmt.Errorf("record not found")
+	}
+	delete(repo.entries, name)
+	return nil
+}
@@ -0,0 +1,120 @@
+package services_test
+
+import (
+	"fmt"
+	"sort"
+
+	reps "github.com/brucetieu/blockchain/representations"
+)
+
+func (repo *fakeBlockchainRepository) GetValidators() ([]reps.Validator, error) {
+	byPubKeyHash := make(map[string]*reps.Validator)
+	validators := make([]reps.Validator, 0)
+	for _, unspentOutput := range repo.unspentOutputs() {
+		if !unspentOutput.Staked {
+			continue
+		}
+		if _, ok := byPubKeyHash[unspentOutput.PubKeyHash]; !ok {
+			byPubKeyHash[unspentOutput.PubKeyHash] = &reps.Validator{PubKeyHash: unspentOutput.PubKeyHash}
+		}
+		byPubKeyHash[unspentOutput.PubKeyHash].Bonded += unspentOutput.Value
+		byPubKeyHash[unspentOutput.PubKeyHash].Deposits++
+	}
+	for _, validator := range byPubKeyHash {
+		validators = append(validators, *validator)
+	}
+	sort.Slice(validators, func(i, j int) bool {
+		if validators[i].Bonded != validators[j].Bonded {
+			return validators[i].Bonded > validators[j].Bonded
+		}
+		return validators[i].PubKeyHash < validators[j].PubKeyHash
+	})
+	return validators, nil
+}
+
+func (repo *fakeBlockchainRepository) CreateBlockVote(vote reps.BlockVote) error {
+	repo.votes = append(repo.votes, vote)
+	return nil
+}
+
+func (repo *fakeBlockchainRepository) GetBlockVotes(blockId string) ([]reps.BlockVote, error) {
+	votes := make([]reps.BlockVote, 0)
+	for _, vote := range repo.votes {
+		if vote.BlockID == blockId {
+			votes = append(votes, vote)
+		}
+	}
+	return votes, nil
+}
+
+func (repo *fakeBlockchainRepository) GetValidatorVote(height int, validator string) (reps.BlockVote, error) {
+	for _, vote := range repo.votes {
+		if vote.Height == height && vote.Validator == validator {
+			return vote, nil
+		}
+	}
+	return reps.BlockVote{}, fmt.Errorf("record not found")
+}
+
+func (repo *fakeBlockchainRepository) FinalizeBlock(blockId string) error {
+	for i := range repo.blocks {
+		if repo.blocks[i].ID == blockId {
+			repo.blocks[i].Finalized = true
+		}
+	}
+	return nil
+}
+
+func (repo *fakeBlockchainRepository) GetLastFinalizedBlock() (reps.Block, error) {
+	chain := repo.chain()
+	for i := len(chain) - 1; i >= 0; i-- {
+		if chain[i].Finalized {
+			return chain[i], nil
+		}
+	}
+	return reps.Block{}, fmt.Errorf("record not found")
+}
+
+func (repo *fakeBlockchainRepository) CreateEvidence(evidence reps.Evidence) error {
+	repo.evidence = append(repo.evidence, evidence)
+	return nil
+}
+
+func (repo *fakeBlockchainRepository) GetEvidence(id string) (reps.Evidence, error) {
+	for _, evidence := range repo.evidence {
+		if evidence.ID == id {
+			return evidence, nil
+		}
+	}
+	return reps.Evidence{}, fmt.Errorf("record not found")
+}
+
+func (repo *fakeBlockchainRepository) GetAllEvidence() ([]reps.Evidence, error) {
+	evidence := make([]reps.Evidence, 0, len(repo.evidence))
+	for i := len(repo.evidence) - 1; i >= 0; i-- {
+		evidence = append(evidence, repo.evidence[i])
+	}
+	return evidence, nil
+}
+
+// Validator changes as the real repository records them, worked out from whatever blocks a test put in place
+func (repo *fakeBlockchainRepository) GetValidatorChanges() ([]reps.ValidatorChangeRecord, error) {
+	changes := append([]reps.ValidatorChangeRecord{}, repo.snapshotChanges...)
+	for _, block := range repo.chain() {
+		for position, txn := range block.Transactions {
+			if change, ok := reps.NewValidatorChangeRecord(txn, block, block.Height, position); ok {
+				changes = append(changes, change)
+			}
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Height != changes[j].Height {
+			return changes[i].Height < changes[j].Height
+		}
+		if changes[i].MinedHeight != changes[j].MinedHeight {
+			return changes[i].MinedHeight < changes[j].MinedHeight
+		}
+		return changes[i].Position < changes[j].Position
+	})
+	return changes, nil
+}
@@ -0,0 +1,146 @@
+package services_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	reps "github.com/brucetieu/blockchain/representations"
+)
+
+// The UTXO set as the real repository keeps it, worked out from whatever blocks a test put in place
+func (repo *fakeBlockchainRepository) unspentOutputs() []reps.UnspentOutput {
+	spent := make(map[string]bool)
+	for _, block := range repo.blocks {
+		for _, txn := range block.Transactions {
+			for _, input := range txn.Inputs {
+				spent[reps.OutpointID(input.PrevTxnID, input.OutIdx)] = true
+			}
+		}
+	}
+
+	unspentOutputs := make([]reps.UnspentOutput, 0)
+	for _, unspentOutput := range repo.snapshotUTXO {
+		if !spent[unspentOutput.ID] {
+			unspentOutputs = append(unspentOutputs, unspentOutput)
+		}
+	}
+	for _, block := range repo.chain() {
+		for _, txn := range block.Transactions {
+			for outIdx := range txn.Outputs {
+				if !spent[reps.OutpointID(txn.ID, outIdx)] {
+					unspentOutputs = append(unspentOutputs, reps.NewUnspentOutput(txn, outIdx, block.ID, block.Height))
+				}
+			}
+		}
+	}
+	return unspentOutputs
+}
+
+func (repo *fakeBlockchainRepository) GetUnspentOutputs(pubKeyHash []byte) ([]reps.UnspentOutput, error) {
+	unspentOutputs := make([]reps.UnspentOutput, 0)
+	for _, unspentOutput := range repo.unspentOutputs() {
+		if unspentOutput.PubKeyHash == hex.EncodeToString(pubKeyHash) {
+			unspentOutputs = append(unspentOutputs, unspentOutput)
+		}
+	}
+	return unspentOutputs, nil
+}
+
+func (repo *fakeBlockchainRepository) GetUnspentAssetOutputs(assetId string) ([]reps.UnspentOutput, error) {
+	unspentOutputs := make([]reps.UnspentOutput, 0)
+	for _, unspentOutput := range repo.unspentOutputs() {
+		if unspentOutput.AssetID == assetId {
+			unspentOutputs = append(unspentOutputs, unspentOutput)
+		}
+	}
+	return unspentOutputs, nil
+}
+
+func (repo *fakeBlockchainRepository) GetUnspentOutput(txnId []byte, outIdx int) (reps.UnspentOutput, error) {
+	for _, unspentOutput := range repo.unspentOutputs() {
+		if unspentOutput.ID == reps.OutpointID(txnId, outIdx) {
+			return unspentOutput, nil
+		}
+	}
+	return reps.UnspentOutput{}, fmt.Errorf("record not found")
+}
+
+func (repo *fakeBlockchainRepository) ReplaceUnspentOutputs(unspentOutputs []reps.UnspentOutput) error {
+	repo.reindexed = unspentOutputs
+	return nil
+}
+
+// The transaction index as the real repository keeps it, worked out from whatever blocks a test put in place
+func (repo *fakeBlockchainRepository) GetTxnLocation(txnId []byte) (reps.TxnLocation, error) {
+	for _, block := range repo.chain() {
+		for position, txn := range block.Transactions {
+			if bytes.Equal(txn.ID, txnId) {
+				return reps.NewTxnLocation(txn, block, block.Height, position), nil
+			}
+		}
+	}
+	return reps.TxnLocation{}, fmt.Errorf("record not found")
+}
+
+func (repo *fakeBlockchainRepository) ReplaceTxnLocations(locations []reps.TxnLocation) error {
+	repo.txnLocations = locations
+	return nil
+}
+
+// The address index as the real repository keeps it, worked out from whatever blocks a test put in place
+func (repo *fakeBlockchainRepository) GetAddressIndex(pubKeyHash []byte) ([]reps.AddressIndexEntry, error) {
+	outputs := make(map[string]reps.UnspentOutput)
+	entries := make([]reps.AddressIndexEntry, 0)
+	for _, unspentOutput := range repo.snapshotUTXO {
+		outputs[unspentOutput.ID] = unspentOutput
+	}
+	for _, block := range repo.chain() {
+		height := block.Height
+		for position, txn := range block.Transactions {
+			for _, entry := range reps.NewAddressIndexEntries(txn, block, height, position, outputs) {
+				if entry.PubKeyHash == hex.EncodeToString(pubKeyHash) {
+					entries = append(entries, entry)
+				}
+			}
+			for outIdx := range txn.Outputs {
+				output := reps.NewUnspentOutput(txn, outIdx, block.ID, height)
+				outputs[output.ID] = output
+			}
+		}
+	}
+	return entries, nil
+}
+
+func (repo *fakeBlockchainRepository) ReplaceAddressIndex(entries []reps.AddressIndexEntry) error {
+	repo.addressIndex = entries
+	return nil
+}
+
+func (repo *fakeBlockchainRepository) CreateAsset(asset reps.Asset) error {
+	repo.assets[asset.ID] = asset
+	return nil
+}
+
+func (repo *fakeBlockchainRepository) GetAsset(assetId string) (reps.Asset, error) {
+	asset, ok := repo.assets[assetId]
+	if !ok {
+		return reps.Asset{}, fmt.Errorf("record not found")
+	}
+	return asset, nil
+}
+
+func (repo *fakeBlockchainRepository) GetAssets() ([]reps.Asset, error) {
+	assets := make([]reps.Asset, 0)
+	for _, asset := range repo.assets {
+		assets = append(assets, asset)
+	}
+	return assets, nil
+}
+
+func (repo *fakeBlockchainRepository) GetAllUnspentOutputs() ([]reps.UnspentOutput, error) {
+	unspentOutputs := repo.unspentOutputs()
+	sort.Slice(unspentOutputs, func(i, j int) bool { return unspentOutputs[i].ID < unspentOutputs[j].ID })
+	return unspentOutputs, nil
+}
@@ -0,0 +1,51 @@
+package services_test
+
+import (
+	"fmt"
+
+	reps "github.com/brucetieu/blockchain/representations"
+)
+
+// In memory MempoolRepository
+type fakeMempoolRepository struct {
+	entries      map[string]reps.MempoolEntry
+	replacements map[string]reps.MempoolReplacement
+}
+
+func newFakeMempoolRepository() *fakeMempoolRepository {
+	return &fakeMempoolRepository{
+		entries:      make(map[string]reps.MempoolEntry),
+		replacements: make(map[string]reps.MempoolReplacement),
+	}
+}
+
+func (repo *fakeMempoolRepository) CreateEntry(entry reps.MempoolEntry) error {
+	repo.entries[entry.TxnID] = entry
+	return nil
+}
+
+func (repo *fakeMempoolRepository) GetEntries() ([]reps.MempoolEntry, error) {
+	entries := make([]reps.MempoolEntry, 0)
+	for _, entry := range repo.entries {
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (repo *fakeMempoolRepository) DeleteEntry(txnId string) error {
+	delete(repo.entries, txnId)
+	return nil
+}
+
+func (repo *fakeMempoolRepository) CreateReplacement(replacement reps.MempoolReplacement) error {
+	repo.replacements[replacement.TxnID] = replacement
+	return nil
+}
+
+func (repo *fakeMempoolRepository) GetReplacement(txnId string) (reps.MempoolReplacement, error) {
+	replacement, ok := repo.replacements[txnId]
+	if !ok {
+		return reps.MempoolReplacement{}, fmt.Errorf("record not found")
+	}
+	return replacement, nil
+}
@@ -0,0 +1,238 @@
+package services_test
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/brucetieu/blockchain/repository"
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/jinzhu/gorm"
+)
+
+// In memory BlockchainRepository. Methods a test doesn't need fall through to the embedded nil interface and panic
+type fakeBlockchainRepository struct {
+	repository.BlockchainRepository
+
+	wallets         map[string]reps.Wallet
+	hdWallets       map[string]reps.HDWallet
+	blocks          []reps.Block
+	accounts        map[string]reps.Account
+	reindexed       []reps.UnspentOutput     // Last UTXO set passed to ReplaceUnspentOutputs
+	addressIndex    []reps.AddressIndexEntry // Last address index passed to ReplaceAddressIndex
+	txnLocations    []reps.TxnLocation       // Last transaction index passed to ReplaceTxnLocations
+	staleBlocks     []reps.StaleBlock
+	sideBlocks      map[string]reps.SideBlock
+	votes           []reps.BlockVote
+	evidence        []reps.Evidence
+	snapshot        *reps.SnapshotBase
+	snapshotUTXO    []reps.UnspentOutput         // UTXO set of the snapshot the chain was started from
+	snapshotChanges []reps.ValidatorChangeRecord // Validator changes of the snapshot
+	first           int                          // Height of the first block, above 0 on a chain started from a snapshot
+
+	multisigAddresses map[string]reps.MultisigAddress
+	multisigTxns      map[string]reps.MultisigTransaction
+	assets            map[string]reps.Asset
+	params            *reps.ChainParams
+}
+
+func newFakeBlockchainRepository() *fakeBlockchainRepository {
+	return &fakeBlockchainRepository{
+		wallets:   make(map[string]reps.Wallet),
+		hdWallets: make(map[string]reps.HDWallet),
+		accounts:  make(map[string]reps.Account),
+
+		multisigAddresses: make(map[string]reps.MultisigAddress),
+		multisigTxns:      make(map[string]reps.MultisigTransaction),
+		assets:            make(map[string]reps.Asset),
+		sideBlocks:        make(map[string]reps.SideBlock),
+	}
+}
+
+func (repo *fakeBlockchainRepository) GetBlockchain() ([]reps.Block, error) {
+	return repo.chain(), nil
+}
+
+// The blocks a test put in place, with their heights filled in
+func (repo *fakeBlockchainRepository) chain() []reps.Block {
+	blocks := make([]reps.Block, 0, len(repo.blocks))
+	for i, block := range repo.blocks {
+		block.Height = repo.first + i
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+func (repo *fakeBlockchainRepository) GetTransactions() ([]reps.Transaction, error) {
+	txns := make([]reps.Transaction, 0)
+	for _, block := range repo.blocks {
+		txns = append(txns, block.Transactions...)
+	}
+	return txns, nil
+}
+
+func (repo *fakeBlockchainRepository) GetTransaction(txnId []byte) (reps.Transaction, error) {
+	for _, block := range repo.blocks {
+		for _, txn := range block.Transactions {
+			if bytes.Equal(txn.ID, txnId) {
+				return txn, nil
+			}
+		}
+	}
+	return reps.Transaction{}, gorm.ErrRecordNotFound
+}
+
+func (repo *fakeBlockchainRepository) GetLastBlock() (reps.Block, error) {
+	if len(repo.blocks) == 0 {
+		return reps.Block{}, fmt.Errorf("record not found")
+	}
+	return repo.chain()[len(repo.blocks)-1], nil
+}
+
+func (repo *fakeBlockchainRepository) GetGenesisBlock() (reps.Block, error) {
+	if len(repo.blocks) == 0 {
+		return reps.Block{}, fmt.Errorf("record not found")
+	}
+	return repo.chain()[0], nil
+}
+
+func (repo *fakeBlockchainRepository) GetBlockById(blockId string) (reps.Block, error) {
+	for _, block := range repo.chain() {
+		if block.ID == blockId {
+			return block, nil
+		}
+	}
+	return reps.Block{}, fmt.Errorf("record not found")
+}
+
+func (repo *fakeBlockchainRepository) GetBlockByHash(hash []byte) (reps.Block, error) {
+	for _, block := range repo.chain() {
+		if bytes.Equal(block.Hash, hash) {
+			return block, nil
+		}
+	}
+	return reps.Block{}, fmt.Errorf("record not found")
+}
+
+func (repo *fakeBlockchainRepository) GetBlocksByHeight(from int, count int) ([]reps.Block, error) {
+	blocks := make([]reps.Block, 0)
+	for _, block := range repo.chain() {
+		if block.Height >= from && block.Height < from+count {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks, nil
+}
+
+func (repo *fakeBlockchainRepository) GetBlockHeader(blockId string) (reps.Block, error) {
+	block, err := repo.GetBlockById(blockId)
+	block.Transactions = nil
+	return block, err
+}
+
+func (repo *fakeBlockchainRepository) GetBlockHeadersByHeight(from int, count int) ([]reps.Block, error) {
+	blocks, _ := repo.GetBlocksByHeight(from, count)
+	for i := range blocks {
+		blocks[i].Transactions = nil
+	}
+	return blocks, nil
+}
+
+func (repo *fakeBlockchainRepository) GetBlockByHeight(height int) (reps.Block, error) {
+	if height < repo.first || height >= repo.first+len(repo.blocks) {
+		return reps.Block{}, fmt.Errorf("record not found")
+	}
+	return repo.chain()[height-repo.first], nil
+}
+
+func (repo *fakeBlockchainRepository) CountBlocks() (int, error) {
+	return len(repo.blocks), nil
+}
+
+func (repo *fakeBlockchainRepository) CreateBlock(block reps.Block) error {
+	repo.blocks = append(repo.blocks, block)
+	return nil
+}
+
+func (repo *fakeBlockchainRepository) DeleteBlocksFrom(height int) error {
+	if height-repo.first < len(repo.blocks) {
+		repo.blocks = repo.blocks[:height-repo.first]
+	}
+	return nil
+}
+
+func (repo *fakeBlockchainRepository) CreateChainParams(params reps.ChainParams) error {
+	repo.params = &params
+	return nil
+}
+
+func (repo *fakeBlockchainRepository) GetChainParams() (reps.ChainParams, error) {
+	if repo.params == nil {
+		return reps.ChainParams{}, fmt.Errorf("record not found")
+	}
+	return *repo.params, nil
+}
+
+func (repo *fakeBlockchainRepository) CreateStaleBlock(staleBlock reps.StaleBlock) error {
+	repo.staleBlocks = append(repo.staleBlocks, staleBlock)
+	return nil
+}
+
+func (repo *fakeBlockchainRepository) GetStaleBlocks(count int) ([]reps.StaleBlock, error) {
+	staleBlocks := make([]reps.StaleBlock, 0)
+	for i := len(repo.staleBlocks) - 1; i >= 0 && len(staleBlocks) < count; i-- {
+		staleBlocks = append(staleBlocks, repo.staleBlocks[i])
+	}
+	return staleBlocks, nil
+}
+
+func (repo *fakeBlockchainRepository) CountStaleBlocks() (int, error) {
+	return len(repo.staleBlocks), nil
+}
+
+func (repo *fakeBlockchainRepository) CreateSideBlock(sideBlock reps.SideBlock) error {
+	repo.sideBlocks[sideBlock.Hash] = sideBlock
+	return nil
+}
+
+func (repo *fakeBlockchainRepository) GetSideBlock(hash string) (reps.SideBlock, error) {
+	sideBlock, ok := repo.sideBlocks[hash]
+	if !ok {
+		return reps.SideBlock{}, fmt.Errorf("record not found")
+	}
+	return sideBlock, nil
+}
+
+func (repo *fakeBlockchainRepository) GetSideBlocks() ([]reps.SideBlock, error) {
+	sideBlocks := make([]reps.SideBlock, 0)
+	for _, sideBlock := range repo.sideBlocks {
+		sideBlocks = append(sideBlocks, sideBlock)
+	}
+	sort.Slice(sideBlocks, func(i, j int) bool {
+		return sideBlocks[i].Height < sideBlocks[j].Height
+	})
+	return sideBlocks, nil
+}
+
+func (repo *fakeBlockchainRepository) DeleteSideBlock(hash string) error {
+	delete(repo.sideBlocks, hash)
+	return nil
+}
+
+func (repo *fakeBlockchainRepository) LoadSnapshot(base reps.SnapshotBase, params reps.ChainParams, headers []reps.Block, unspentOutputs []reps.UnspentOutput,
+	validatorChanges []reps.ValidatorChangeRecord) error {
+	repo.snapshot = &base
+	repo.params = &params
+	repo.blocks = append(repo.blocks, headers...)
+	repo.first = headers[0].Height
+	repo.snapshotUTXO = unspentOutputs
+	repo.snapshotChanges = validatorChanges
+	return nil
+}
+
+func (repo *fakeBlockchainRepository) GetSnapshotBase() (reps.SnapshotBase, error) {
+	if repo.snapshot == nil {
+		return reps.SnapshotBase{}, fmt.Errorf("record not found")
+	}
+	return *repo.snapshot, nil
+}
@@ -0,0 +1,44 @@
+package services_test
+
+import (
+	"fmt"
+
+	reps "github.com/brucetieu/blockchain/representations"
+)
+
+// In memory ScheduleRepository
+type fakeScheduleRepository struct {
+	schedules []reps.Schedule
+}
+
+func newFakeScheduleRepository() *fakeScheduleRepository {
+	return &fakeScheduleRepository{}
+}
+
+func (repo *fakeScheduleRepository) CreateSchedule(schedule reps.Schedule) error {
+	repo.schedules = append(repo.schedules, schedule)
+	return nil
+}
+
+func (repo *fakeScheduleRepository) UpdateSchedule(schedule reps.Schedule) error {
+	for i := range repo.schedules {
+		if repo.schedules[i].ID == schedule.ID {
+			repo.schedules[i] = schedule
+			return nil
+		}
+	}
+	return fmt.Errorf("record not found")
+}
+
+func (repo *fakeScheduleRepository) GetSchedule(id string) (reps.Schedule, error) {
+	for _, schedule := range repo.schedules {
+		if schedule.ID == id {
+			return schedule, nil
+		}
+	}
+	return reps.Schedule{}, fmt.Errorf("record not found")
+}
+
+func (repo *fakeScheduleRepository) GetSchedules() ([]reps.Schedule, error) {
+	return append([]reps.Schedule{}, repo.schedules...), nil
+}
@@ -0,0 +1,129 @@
+package services_test
+
+import (
+	"fmt"
+
+	reps "github.com/brucetieu/blockchain/representations"
+)
+
+func (repo *fakeBlockchainRepository) CreateWallet(wallet reps.Wallet) error {
+	repo.wallets[wallet.Address] = wallet
+	return nil
+}
+
+func (repo *fakeBlockchainRepository) GetWallet(address string) (reps.Wallet, error) {
+	wallet, ok := repo.wallets[address]
+	if !ok {
+		return reps.Wallet{}, fmt.Errorf("record not found")
+	}
+	return wallet, nil
+}
+
+func (repo *fakeBlockchainRepository) UpdateWallet(wallet reps.Wallet) error {
+	repo.wallets[wallet.Address] = wallet
+	return nil
+}
+
+func (repo *fakeBlockchainRepository) GetWallets() ([]reps.Wallet, error) {
+	wallets := make([]reps.Wallet, 0)
+	for _, wallet := range repo.wallets {
+		wallets = append(wallets, wallet)
+	}
+	return wallets, nil
+}
+
+func (repo *fakeBlockchainRepository) GetWalletsByHDWalletId(hdWalletId string) ([]reps.Wallet, error) {
+	wallets := make([]reps.Wallet, 0)
+	for _, wallet := range repo.wallets {
+		if wallet.HDWalletID == hdWalletId {
+			wallets = append(wallets, wallet)
+		}
+	}
+	return wallets, nil
+}
+
+func (repo *fakeBlockchainRepository) GetWalletsByAccountId(accountId string) ([]reps.Wallet, error) {
+	wallets := make([]reps.Wallet, 0)
+	for _, wallet := range repo.wallets {
+		if wallet.AccountID == accountId {
+			wallets = append(wallets, wallet)
+		}
+	}
+	return wallets, nil
+}
+
+func (repo *fakeBlockchainRepository) CreateAccount(account reps.Account) error {
+	repo.accounts[account.Name] = account
+	return nil
+}
+
+func (repo *fakeBlockchainRepository) UpdateAccount(account reps.Account) error {
+	repo.accounts[account.Name] = account
+	return nil
+}
+
+func (repo *fakeBlockchainRepository) GetAccount(name string) (reps.Account, error) {
+	account, ok := repo.accounts[name]
+	if !ok {
+		return reps.Account{}, fmt.Errorf("record not found")
+	}
+	return account, nil
+}
+
+func (repo *fakeBlockchainRepository) CreateHDWallet(hdWallet reps.HDWallet) error {
+	repo.hdWallets[hdWallet.ID] = hdWallet
+	return nil
+}
+
+func (repo *fakeBlockchainRepository) UpdateHDWallet(hdWallet reps.HDWallet) error {
+	repo.hdWallets[hdWallet.ID] = hdWallet
+	return nil
+}
+
+func (repo *fakeBlockchainRepository) GetHDWallet(hdWalletId string) (reps.HDWallet, error) {
+	hdWallet, ok := repo.hdWallets[hdWalletId]
+	if !ok {
+		return reps.HDWallet{}, fmt.Errorf("record not found")
+	}
+	return hdWallet, nil
+}
+
+func (repo *fakeBlockchainRepository) GetHDWalletByFingerprint(fingerprint string) (reps.HDWallet, error) {
+	for _, hdWallet := range repo.hdWallets {
+		if hdWallet.Fingerprint == fingerprint {
+			return hdWallet, nil
+		}
+	}
+	return reps.HDWallet{}, fmt.Errorf("record not found")
+}
+
+func (repo *fakeBlockchainRepository) CreateMultisigAddress(multisigAddress reps.MultisigAddress) error {
+	repo.multisigAddresses[multisigAddress.Address] = multisigAddress
+	return nil
+}
+
+func (repo *fakeBlockchainRepository) GetMultisigAddress(address string) (reps.MultisigAddress, error) {
+	multisigAddress, ok := repo.multisigAddresses[address]
+	if !ok {
+		return reps.MultisigAddress{}, fmt.Errorf("record not found")
+	}
+	return multisigAddress, nil
+}
+
+func (repo *fakeBlockchainRepository) CreateMultisigTransaction(multisigTxn reps.MultisigTransaction) error {
+	repo.multisigTxns[multisigTxn.ID] = multisigTxn
+	return nil
+}
+
+func (repo *fakeBlockchainRepository) UpdateMultisigTransaction(multisigTxn reps.MultisigTransaction) error {
+	repo.multisigTxns[multisigTxn.ID] = multisigTxn
+	return nil
+}
+
+func (repo *fakeBlockchainRepository) GetMultisigTransaction(txnId string) (reps.MultisigTransaction, error) {
+	multisigTxn, ok := repo.multisigTxns[txnId]
+	if !ok {
+		return reps.MultisigTransaction{}, fmt.Errorf("record not found")
+	}
+	return multisigTxn, nil
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"sort"
+
+	"github.com/brucetieu/blockchain/repository"
+	reps "github.com/brucetieu/blockchain/representations"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	FeeEstimateBlocks = 10 // How many of the latest blocks fee estimates are based on
+	MinFeeRate        = 1  // Lowest rate ever suggested, and what's suggested with nothing to go on
+)
+
+type FeeService interface {
+	EstimateFees() (reps.FeeEstimate, error)
+}
+
+type feeService struct {
+	blockchainRepo repository.BlockchainRepository
+	mempoolService MempoolService
+	txnAssembler   TxnAssemblerFac
+}
+
+func NewFeeService(blockchainRepo repository.BlockchainRepository, mempoolService MempoolService) FeeService {
+	return &feeService{
+		blockchainRepo: blockchainRepo,
+		mempoolService: mempoolService,
+		txnAssembler:   TxnAssembler,
+	}
+}
+
+// Suggest low, medium and high fee rates from what transactions in recent blocks paid.
+// They're the 25th, 50th and 90th percentiles of those rates, each bumped up a level
+// when more transactions are waiting than a recent block usually holds
+func (fs *feeService) EstimateFees() (reps.FeeEstimate, error) {
+	log.Info("Estimating fees")
+	blocks, err := fs.blockchainRepo.GetBlockchain()
+	if err != nil {
+		return reps.FeeEstimate{}, err
+	}
+
+	// Newest first
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].Height > blocks[j].Height
+	})
+	if len(blocks) > FeeEstimateBlocks {
+		blocks = blocks[:FeeEstimateBlocks]
+	}
+
+	rates := make([]int, 0)
+	for _, block := range blocks {
+		for _, txn := range block.Transactions {
+			// Coinbase transactions have no inputs to pay a fee from
+			if len(txn.Inputs) == 1 && len(txn.Inputs[0].PrevTxnID) == 0 {
+				continue
+			}
+			rates = append(rates, fs.feeRate(txn))
+		}
+	}
+	sort.Ints(rates)
+
+	estimate := reps.FeeEstimate{
+		Low:          percentile(rates, 25),
+		Medium:       percentile(rates, 50),
+		High:         percentile(rates, 90),
+		Blocks:       len(blocks),
+		Samples:      len(rates),
+		MempoolDepth: fs.mempoolService.Size(),
+	}
+
+	// Not everything waiting makes the next block, so outbid what got in before
+	if len(blocks) > 0 && estimate.MempoolDepth > (len(rates)+len(blocks)-1)/len(blocks) {
+		estimate.Low = estimate.Medium
+		estimate.Medium = estimate.High
+		estimate.High = percentile(rates, 100)
+	}
+
+	return estimate, nil
+}
+
+// Fee a transaction paid per 1000 bytes, rounded down
+func (fs *feeService) feeRate(txn reps.Transaction) int {
+	size := len(fs.txnAssembler.ToTxnBytes(txn))
+	if size == 0 {
+		return 0
+	}
+	return txn.Fee * 1000 / size
+}
+
+// The rate p percent of sorted rates are at or below, never less than MinFeeRate
+func percentile(rates []int, p int) int {
+	if len(rates) == 0 {
+		return MinFeeRate
+	}
+
+	// Nearest rank
+	rank := (len(rates)*p + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	rate := rates[rank-1]
+	if rate < MinFeeRate {
+		return MinFeeRate
+	}
+	return rate
+}
@@ -0,0 +1,58 @@
+package services_test
+
+import (
+	"testing"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimateFeesFromRecentBlocks(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+	mempoolService := ts.mempoolService
+	feeService := services.NewFeeService(repo, mempoolService)
+
+	// Nothing to go on yet
+	estimate, err := feeService.EstimateFees()
+	assert.NoError(t, err)
+	assert.Equal(t, services.MinFeeRate, estimate.Low)
+	assert.Equal(t, services.MinFeeRate, estimate.High)
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	cheap, err := txnService.CreateTransactionToRecipients(from.Address, []reps.Recipient{{To: to.Address, Amount: 10}}, reps.TxnOptions{FeeRate: 2})
+	assert.NoError(t, err)
+	pricey, err := txnService.CreateTransactionToRecipients(from.Address, []reps.Recipient{{To: to.Address, Amount: 10}}, reps.TxnOptions{FeeRate: 20})
+	assert.NoError(t, err)
+	repo.blocks = append(repo.blocks, reps.Block{ID: "next", PrevHash: []byte("genesis"), Timestamp: 1, Transactions: []reps.Transaction{cheap, pricey}})
+
+	estimate, err = feeService.EstimateFees()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, estimate.Blocks)
+	assert.Equal(t, 2, estimate.Samples)
+	assert.LessOrEqual(t, estimate.Low, estimate.High)
+	assert.GreaterOrEqual(t, estimate.Low, 1)
+	assert.GreaterOrEqual(t, estimate.High, 10)
+	assert.Equal(t, 0, estimate.MempoolDepth)
+
+	// More waiting than a block holds on average, so even the low rate has to outbid the cheap one.
+	// Sent from different addresses so they don't spend the same outputs
+	for _, pair := range [][2]string{{from.Address, to.Address}, {to.Address, from.Address}} {
+		txn, err := txnService.CreateTransactionToRecipients(pair[0], []reps.Recipient{{To: pair[1], Amount: 1}}, reps.TxnOptions{})
+		assert.NoError(t, err)
+		_, err = mempoolService.AddTransaction(txn)
+		assert.NoError(t, err)
+	}
+
+	congested, err := feeService.EstimateFees()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, congested.MempoolDepth)
+	assert.Equal(t, estimate.Medium, congested.Low)
+	assert.Equal(t, estimate.High, congested.Medium)
+}
@@ -0,0 +1,280 @@
+package services
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/brucetieu/blockchain/repository"
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/google/uuid"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Confirmations that make a block final on a chain without BFT finality, counting the block itself. A reorg
+// deeper than that is taken not to happen
+var FinalityDepth = 6
+
+// Whether a block on the chain can still be reorganized away
+const (
+	BlockFinal     = "final"
+	BlockTentative = "tentative"
+)
+
+// Whether the block at height is final, when the last final block is at finalizedHeight
+func BlockFinalityStatus(height int, finalizedHeight int) string {
+	if height <= finalizedHeight {
+		return BlockFinal
+	}
+	return BlockTentative
+}
+
+// Count blocks as final once they have FINALITY_DEPTH confirmations, if it's set, on chains without BFT finality
+func FinalityAtStartup() {
+	envFinalityDepth := os.Getenv("FINALITY_DEPTH")
+	if envFinalityDepth == "" {
+		return
+	}
+
+	depth, err := strconv.Atoi(envFinalityDepth)
+	if err != nil || depth < 1 {
+		log.Warn("Invalid FINALITY_DEPTH, using default of ", FinalityDepth)
+		return
+	}
+	FinalityDepth = depth
+}
+
+// Collects the validators' votes for blocks on a BFT chain, and finalizes a block once a quorum of them voted for it
+type FinalityService interface {
+	Vote(blockId string, input reps.VoteInput) (reps.BlockFinality, error)
+	GetBlockFinality(blockId string) (reps.BlockFinality, error)
+}
+
+type finalityService struct {
+	blockchainRepo repository.BlockchainRepository
+	walletService  WalletService
+	signer         Signer
+	params         *reps.ChainParams
+}
+
+func NewFinalityService(blockchainRepo repository.BlockchainRepository, walletService WalletService, signer Signer,
+	params *reps.ChainParams) FinalityService {
+	return &finalityService{
+		blockchainRepo: blockchainRepo,
+		walletService:  walletService,
+		signer:         signer,
+		params:         params,
+	}
+}
+
+// Add a validator's vote for a block on the chain, signed here with the wallet of input's address, or signed
+// elsewhere. Each validator votes for one block at each height, voting for the same one again changes nothing.
+// The block is final once a quorum of validators voted for it
+func (fs *finalityService) Vote(blockId string, input reps.VoteInput) (reps.BlockFinality, error) {
+	if !IsBFT(fs.params) {
+		return reps.BlockFinality{}, fmt.Errorf("blocks are only voted for on a BFT chain, not a %s chain", fs.params.Consensus)
+	}
+
+	block, err := fs.blockchainRepo.GetBlockHeader(blockId)
+	if err != nil {
+		return reps.BlockFinality{}, fmt.Errorf("%s, block id: %s", err.Error(), blockId)
+	}
+
+	var vote reps.BlockVote
+	if input.Address != "" {
+		vote, err = fs.signVote(block, input.Address)
+	} else {
+		vote, err = fs.readVote(block, input)
+	}
+	if err != nil {
+		return reps.BlockFinality{}, err
+	}
+
+	// Validators of the block's height, whatever changes the chain makes after it
+	validators, err := ValidatorsAt(fs.blockchainRepo, fs.params, block.Height)
+	if err != nil {
+		return reps.BlockFinality{}, err
+	}
+	if !isValidator(validators, vote.Validator) {
+		return reps.BlockFinality{}, fmt.Errorf("%w: %s", ErrNotValidator, vote.Validator)
+	}
+
+	if cast, err := fs.blockchainRepo.GetValidatorVote(block.Height, vote.Validator); err == nil {
+		if !bytes.Equal(cast.BlockHash, block.Hash) {
+			// A vote signed here never gets out, so only one signed elsewhere is evidence
+			if input.Address == "" {
+				fs.recordEquivocation(cast, vote, block)
+			}
+			return reps.BlockFinality{}, fmt.Errorf("%w: %s voted for block %s at height %d", ErrConflictingVote, vote.Validator, cast.BlockID, block.Height)
+		}
+		return fs.GetBlockFinality(blockId)
+	}
+
+	if err := fs.blockchainRepo.CreateBlockVote(vote); err != nil {
+		return reps.BlockFinality{}, err
+	}
+	log.WithFields(log.Fields{"block": block.ID, "height": block.Height, "validator": vote.Validator}).Info("Validator voted for block")
+
+	finality, err := fs.GetBlockFinality(blockId)
+	if err != nil {
+		return reps.BlockFinality{}, err
+	}
+	if finality.Final && !block.Finalized {
+		if err := fs.blockchainRepo.FinalizeBlock(block.ID); err != nil {
+			return reps.BlockFinality{}, err
+		}
+		log.WithFields(log.Fields{"block": block.ID, "height": block.Height, "votes": len(finality.Votes)}).Info("Block finalized")
+	}
+
+	return finality, nil
+}
+
+// Keep evidence of a validator voting for block after voting for another block at the same height. Validators of a
+// BFT chain have no stake, so there's nothing to slash
+func (fs *finalityService) recordEquivocation(cast reps.BlockVote, vote reps.BlockVote, block reps.Block) {
+	castBlock, err := knownBlock(fs.blockchainRepo, cast.BlockHash)
+	if err != nil || !bytes.Equal(cast.PublicKey, vote.PublicKey) {
+		return
+	}
+
+	equivocation := reps.Equivocation{
+		Kind:         EquivocationVote,
+		PublicKey:    vote.PublicKey,
+		SigAlgorithm: vote.SigAlgorithm,
+		First:        equivocationHeader(castBlock),
+		FirstSig:     cast.Signature,
+		Second:       equivocationHeader(block),
+		SecondSig:    vote.Signature,
+	}
+	if _, err := fs.blockchainRepo.GetEvidence(EvidenceID(equivocation)); err == nil {
+		return
+	}
+	height, err := VerifyEquivocation(fs.blockchainRepo, fs.params, equivocation)
+	if err != nil {
+		log.WithField("error", err.Error()).Warn("Conflicting votes aren't an equivocation")
+		return
+	}
+
+	if _, err := storeEvidence(fs.blockchainRepo, fs.params, equivocation, height, nil); err != nil {
+		log.Error("Error storing evidence of equivocation: ", err.Error())
+	}
+}
+
+// Vote for block with the key of a validator's wallet on this node
+func (fs *finalityService) signVote(block reps.Block, address string) (reps.BlockVote, error) {
+	wallet, err := fs.walletService.GetWallet(address)
+	if err != nil {
+		return reps.BlockVote{}, err
+	}
+	if wallet.WatchOnly {
+		return reps.BlockVote{}, fmt.Errorf("%w: %s", ErrWatchOnly, address)
+	}
+
+	scheme, err := GetSignatureScheme(wallet.SigAlgorithm)
+	if err != nil {
+		return reps.BlockVote{}, err
+	}
+
+	pubKey, err := hex.DecodeString(wallet.PublicKey)
+	if err != nil {
+		return reps.BlockVote{}, fmt.Errorf("%s, unable to read public key of %s", err.Error(), address)
+	}
+
+	signature, err := fs.signer.Sign(wallet, VoteHash(block))
+	if err != nil {
+		return reps.BlockVote{}, err
+	}
+
+	return fs.newVote(block, address, pubKey, scheme.Algorithm(), signature), nil
+}
+
+// Vote for block signed elsewhere, whose signature has to check out
+func (fs *finalityService) readVote(block reps.Block, input reps.VoteInput) (reps.BlockVote, error) {
+	pubKey, err := hex.DecodeString(input.PublicKey)
+	if err != nil || len(pubKey) == 0 {
+		return reps.BlockVote{}, fmt.Errorf("a vote needs the address of a validator's wallet, or a hex public key and signature")
+	}
+	signature, err := hex.DecodeString(input.Signature)
+	if err != nil {
+		return reps.BlockVote{}, fmt.Errorf("%s, signature isn't hex encoded", err.Error())
+	}
+
+	scheme, err := GetSignatureScheme(input.SigAlgorithm)
+	if err != nil {
+		return reps.BlockVote{}, err
+	}
+	if !scheme.ValidPublicKey(pubKey) || !scheme.Verify(pubKey, signature, VoteHash(block)) {
+		return reps.BlockVote{}, fmt.Errorf("vote signature for block %s doesn't check out", block.ID)
+	}
+
+	address, err := AddressFromPubKey(pubKey, fs.params.NetworkByte)
+	if err != nil {
+		return reps.BlockVote{}, err
+	}
+
+	return fs.newVote(block, string(address), pubKey, scheme.Algorithm(), signature), nil
+}
+
+func (fs *finalityService) newVote(block reps.Block, validator string, pubKey []byte, sigAlgorithm string, signature []byte) reps.BlockVote {
+	return reps.BlockVote{
+		ID:           uuid.Must(uuid.NewRandom()).String(),
+		BlockID:      block.ID,
+		BlockHash:    block.Hash,
+		Height:       block.Height,
+		Validator:    validator,
+		PublicKey:    pubKey,
+		SigAlgorithm: sigAlgorithm,
+		Signature:    signature,
+		Timestamp:    time.Now().UnixMilli(),
+	}
+}
+
+// The votes a block on the chain has, and whether it's final: it has a quorum of votes, or a block after it was finalized
+func (fs *finalityService) GetBlockFinality(blockId string) (reps.BlockFinality, error) {
+	block, err := fs.blockchainRepo.GetBlockHeader(blockId)
+	if err != nil {
+		return reps.BlockFinality{}, fmt.Errorf("%s, block id: %s", err.Error(), blockId)
+	}
+
+	votes, err := fs.blockchainRepo.GetBlockVotes(blockId)
+	if err != nil {
+		return reps.BlockFinality{}, err
+	}
+
+	set, err := ValidatorsAt(fs.blockchainRepo, fs.params, block.Height)
+	if err != nil {
+		return reps.BlockFinality{}, err
+	}
+	validators := len(set)
+	finality := reps.BlockFinality{
+		BlockID:    block.ID,
+		Hash:       hex.EncodeToString(block.Hash),
+		Height:     block.Height,
+		Round:      block.Round,
+		Validators: validators,
+		Quorum:     BFTQuorum(validators),
+		Votes:      make([]reps.ReadableBlockVote, 0, len(votes)),
+	}
+	for _, vote := range votes {
+		finality.Votes = append(finality.Votes, reps.ReadableBlockVote{
+			Validator:    vote.Validator,
+			PublicKey:    hex.EncodeToString(vote.PublicKey),
+			SigAlgorithm: vote.SigAlgorithm,
+			Signature:    hex.EncodeToString(vote.Signature),
+			Timestamp:    vote.Timestamp,
+		})
+	}
+
+	finality.Final = IsBFT(fs.params) && (block.Finalized || len(votes) >= finality.Quorum)
+	if !finality.Final && IsBFT(fs.params) {
+		if finalized, err := fs.blockchainRepo.GetLastFinalizedBlock(); err == nil && finalized.Height >= block.Height {
+			finality.Final = true
+		}
+	}
+
+	return finality, nil
+}
@@ -0,0 +1,121 @@
+package services_test
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBFTBlocksAreProposedInTurnAndFinalizedByQuorum(t *testing.T) {
+	params := mainnet
+	params.Consensus = services.ConsensusBFT
+	params.TargetBlockTime = 60
+	ts := newTestServicesWithParams(t, &params)
+	repo, signer, walletService := ts.repo, ts.signer, ts.walletService
+	txnService, blockService, blockchainService := ts.txnService, ts.blockService, ts.blockchainService
+	finalityService := services.NewFinalityService(repo, walletService, signer, &params)
+
+	validators := make([]reps.Wallet, 4)
+	addresses := make([]string, 4)
+	for i := range validators {
+		wallet, err := walletService.CreateWallet()
+		assert.NoError(t, err)
+		validators[i], addresses[i] = wallet, wallet.Address
+	}
+	outsider, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+
+	// Nobody to take turns
+	_, _, err = blockchainService.CreateBlockchain(outsider.Address, nil)
+	assert.Error(t, err)
+
+	params.Validators = strings.Join(addresses, ",")
+	genesis, _, err := blockchainService.CreateBlockchain(outsider.Address, nil)
+	assert.NoError(t, err)
+
+	// It's the second validator's turn at height 1
+	_, err = blockchainService.MineTransactions(nil, validators[0].Address, "")
+	assert.True(t, errors.Is(err, services.ErrNotProposer))
+
+	block, err := blockchainService.MineTransactions(nil, validators[1].Address, "")
+	assert.NoError(t, err)
+	assert.Equal(t, validators[1].PublicKey, hex.EncodeToString(block.Proposer))
+	assert.Zero(t, block.Round)
+
+	// The turn only moves on to the fourth validator once a block interval has gone by without the third proposing
+	next, err := blockchainService.AssembleBlock(nil, validators[3].Address, "")
+	assert.NoError(t, err)
+	next.Round = 1
+	next.Hash = services.NewProofOfWorkService(&next, sha256Hasher).HashData()
+	early, err := txnService.SignBlock(next, validators[3])
+	assert.NoError(t, err)
+	assert.True(t, errors.Is(blockService.ValidateBlock(early), services.ErrInvalidBlock))
+
+	next.Timestamp = block.Timestamp + int64(params.TargetBlockTime)*1000
+	next.Hash = services.NewProofOfWorkService(&next, sha256Hasher).HashData()
+	late, err := txnService.SignBlock(next, validators[3])
+	assert.NoError(t, err)
+	assert.NoError(t, blockService.ValidateBlock(late))
+
+	// However deep it is, a block on a BFT chain is only final once voted for
+	height, err := blockchainService.GetFinalizedHeight()
+	assert.NoError(t, err)
+	assert.Equal(t, -1, height)
+
+	// 3 of 4 votes finalize a block
+	finality, err := finalityService.Vote(block.ID, reps.VoteInput{Address: validators[0].Address})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, finality.Validators)
+	assert.Equal(t, 3, finality.Quorum)
+	assert.Len(t, finality.Votes, 1)
+	assert.False(t, finality.Final)
+
+	_, err = finalityService.Vote(block.ID, reps.VoteInput{Address: outsider.Address})
+	assert.True(t, errors.Is(err, services.ErrNotValidator))
+
+	// Voting again changes nothing
+	finality, err = finalityService.Vote(block.ID, reps.VoteInput{Address: validators[0].Address})
+	assert.NoError(t, err)
+	assert.Len(t, finality.Votes, 1)
+
+	// Votes can be signed elsewhere
+	signature, err := signer.Sign(validators[1], services.VoteHash(block))
+	assert.NoError(t, err)
+	forged := reps.VoteInput{PublicKey: validators[2].PublicKey, SigAlgorithm: validators[2].SigAlgorithm, Signature: hex.EncodeToString(signature)}
+	_, err = finalityService.Vote(block.ID, forged)
+	assert.Error(t, err)
+
+	finality, err = finalityService.Vote(block.ID, reps.VoteInput{PublicKey: validators[1].PublicKey, SigAlgorithm: validators[1].SigAlgorithm, Signature: hex.EncodeToString(signature)})
+	assert.NoError(t, err)
+	assert.Equal(t, validators[1].Address, finality.Votes[1].Validator)
+	assert.False(t, finality.Final)
+
+	// A validator can't vote for two blocks at the same height
+	repo.votes = append(repo.votes, reps.BlockVote{BlockID: "other", BlockHash: []byte("other"), Height: block.Height, Validator: validators[3].Address})
+	_, err = finalityService.Vote(block.ID, reps.VoteInput{Address: validators[3].Address})
+	assert.True(t, errors.Is(err, services.ErrConflictingVote))
+
+	finality, err = finalityService.Vote(block.ID, reps.VoteInput{Address: validators[2].Address})
+	assert.NoError(t, err)
+	assert.True(t, finality.Final)
+
+	finalized, err := blockchainService.GetLastFinalizedBlock()
+	assert.NoError(t, err)
+	assert.Equal(t, block.ID, finalized.ID)
+
+	// Blocks before it are final too, and nothing can branch off below it
+	finality, err = finalityService.GetBlockFinality(genesis.ID)
+	assert.NoError(t, err)
+	assert.True(t, finality.Final)
+
+	sibling, err := blockService.AssembleBlock(append([]reps.Transaction{}, block.Transactions...), genesis.Hash)
+	assert.NoError(t, err)
+	err = blockService.ValidateBlock(sibling)
+	assert.True(t, errors.Is(err, services.ErrInvalidBlock))
+	assert.Contains(t, err.Error(), "finalized")
+}
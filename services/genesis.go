@@ -0,0 +1,123 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"gopkg.in/yaml.v3"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	GenesisFile    = ""                                // Path of the genesis spec new chains are created from. Empty means the node's defaults
+	GenesisMessage = "First transaction in Blockchain" // Data in the genesis coinbase when the spec doesn't have a message
+)
+
+// Read a genesis spec from a .json, .yaml or .yml file. Fields it doesn't know about are rejected, so typos don't go unnoticed
+func LoadGenesisSpec(path string) (reps.GenesisSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return reps.GenesisSpec{}, fmt.Errorf("%s, reading genesis spec", err.Error())
+	}
+
+	var spec reps.GenesisSpec
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&spec)
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&spec)
+	default:
+		return reps.GenesisSpec{}, fmt.Errorf("genesis spec %s isn't a .json, .yaml or .yml file", path)
+	}
+	if err != nil {
+		return reps.GenesisSpec{}, fmt.Errorf("%s, parsing genesis spec %s", err.Error(), path)
+	}
+
+	return spec, nil
+}
+
+// Check spec and set the chain params it defines, including the coins it allocates in all. Allocations have to be
+// to addresses on the chain's network
+func ApplyGenesisSpec(params *reps.ChainParams, spec reps.GenesisSpec) error {
+	if spec.Difficulty != 0 && (spec.Difficulty < MinDifficulty || spec.Difficulty > MaxDifficulty) {
+		return fmt.Errorf("genesis difficulty must be between %d and %d, not %d", MinDifficulty, MaxDifficulty, spec.Difficulty)
+	}
+	if spec.BlockInterval < 0 {
+		return fmt.Errorf("genesis block interval can't be negative, not %d", spec.BlockInterval)
+	}
+	for _, allocation := range spec.Allocations {
+		if !IsValidAddress(allocation.Address, params.NetworkByte) {
+			return fmt.Errorf("error: genesis allocation address of %s is not valid", allocation.Address)
+		}
+		if allocation.Amount <= 0 {
+			return fmt.Errorf("genesis allocation to %s must be positive, not %d", allocation.Address, allocation.Amount)
+		}
+	}
+
+	for _, validator := range spec.Validators {
+		if !IsValidAddress(validator, params.NetworkByte) {
+			return fmt.Errorf("error: genesis validator address of %s is not valid", validator)
+		}
+	}
+	if IsBFT(params) && len(spec.Validators) == 0 && len(BFTValidators(params)) == 0 {
+		return fmt.Errorf("a BFT chain needs validators, from VALIDATORS or the genesis spec")
+	}
+
+	for rule, height := range spec.Activations {
+		if _, ok := ConsensusRules[rule]; !ok {
+			return fmt.Errorf("genesis spec activates unknown consensus rule %s", rule)
+		}
+		if height < 0 {
+			return fmt.Errorf("activation height of %s can't be negative, not %d", rule, height)
+		}
+	}
+
+	premine := 0
+	for _, allocation := range spec.Allocations {
+		total, err := addAmount(premine, allocation.Amount)
+		if err != nil {
+			return fmt.Errorf("genesis allocations come to a %s", err.Error())
+		}
+		premine = total
+	}
+
+	if spec.ChainID != "" {
+		params.ChainID = spec.ChainID
+	}
+	params.InitialDifficulty = spec.Difficulty
+	params.Premine = premine
+	if spec.BlockInterval > 0 {
+		params.TargetBlockTime = spec.BlockInterval
+	}
+	if len(spec.Validators) > 0 {
+		params.Validators = strings.Join(spec.Validators, ",")
+	}
+	if len(spec.Activations) > 0 {
+		params.Activations = FormatActivations(spec.Activations)
+	}
+
+	return nil
+}
+
+// Create new chains from the genesis spec at GENESIS_FILE, if it's set. It's only read when the genesis block is mined
+func GenesisFileAtStartup() {
+	envGenesisFile := os.Getenv("GENESIS_FILE")
+	if envGenesisFile == "" {
+		return
+	}
+
+	if _, err := os.Stat(envGenesisFile); err != nil {
+		log.Warn("GENESIS_FILE can't be read, new chains will fail to be created: ", err.Error())
+	}
+	GenesisFile = envGenesisFile
+}
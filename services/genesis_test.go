@@ -0,0 +1,126 @@
+package services_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateBlockchainFromGenesisSpec(t *testing.T) {
+	params := mainnet
+	params.TargetBlockTime = 60
+	ts := newTestServicesWithParams(t, &params)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+	blockchainService := ts.blockchainService
+
+	miner, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	funded, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	defer func(genesisFile string) { services.GenesisFile = genesisFile }(services.GenesisFile)
+
+	// Typos and bad allocations are caught before anything is mined
+	services.GenesisFile = filepath.Join(dir, "genesis.json")
+	assert.NoError(t, os.WriteFile(services.GenesisFile, []byte(`{"chainId": "testnet", "dificulty": 6}`), 0600))
+	_, _, err = blockchainService.CreateBlockchain(miner.Address, nil)
+	assert.Error(t, err)
+
+	services.GenesisFile = filepath.Join(dir, "genesis.yaml")
+	assert.NoError(t, os.WriteFile(services.GenesisFile, []byte("allocations:\n  - address: nowhere\n    amount: 10\n"), 0600))
+	_, _, err = blockchainService.CreateBlockchain(miner.Address, nil)
+	assert.Error(t, err)
+	assert.Empty(t, repo.blocks)
+
+	spec := "chainId: testnet\ndifficulty: 6\nblockInterval: 30\nmessage: hello testnet\nallocations:\n" +
+		"  - address: " + funded.Address + "\n    amount: 1000\n"
+	assert.NoError(t, os.WriteFile(services.GenesisFile, []byte(spec), 0600))
+	genesis, exists, err := blockchainService.CreateBlockchain(miner.Address, nil)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	assert.Equal(t, 6, genesis.Difficulty)
+	assert.Equal(t, "hello testnet", string(genesis.Transactions[0].Inputs[0].PubKey))
+
+	stored, err := repo.GetChainParams()
+	assert.NoError(t, err)
+	assert.Equal(t, "testnet", stored.ChainID)
+	assert.Equal(t, 6, stored.InitialDifficulty)
+	assert.Equal(t, 30, stored.TargetBlockTime)
+
+	balance, err := txnService.GetBalance(funded.Address)
+	assert.NoError(t, err)
+	assert.Equal(t, 1000, balance)
+	balance, err = txnService.GetBalance(miner.Address)
+	assert.NoError(t, err)
+	assert.Equal(t, services.Reward, balance)
+
+	// Allocations can be spent like any other output
+	txn, err := txnService.CreateTransactionToRecipients(funded.Address, []reps.Recipient{{To: miner.Address, Amount: 400}}, reps.TxnOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, blockchainService.VerifyTransactions([]reps.Transaction{txn}, 1))
+}
+
+func TestCreateBlockchainWithPremineAllocations(t *testing.T) {
+	params := mainnet
+	ts := newTestServicesWithParams(t, &params)
+	repo, keystore, walletService := ts.repo, ts.keystore, ts.walletService
+	txnService, blockchainService := ts.txnService, ts.blockchainService
+
+	miner, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	funded, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+
+	// Addresses don't need a wallet on this node to be funded
+	elsewhere, err := services.NewWalletService(newFakeBlockchainRepository(), keystore, &params).CreateWallet()
+	assert.NoError(t, err)
+
+	_, _, err = blockchainService.CreateBlockchain(miner.Address, []reps.GenesisAllocation{{Address: funded.Address, Amount: 0}})
+	assert.Error(t, err)
+	assert.Empty(t, repo.blocks)
+
+	allocations := []reps.GenesisAllocation{{Address: funded.Address, Amount: 300}, {Address: elsewhere.Address, Amount: 700}}
+	genesis, _, err := blockchainService.CreateBlockchain(miner.Address, allocations)
+	assert.NoError(t, err)
+	assert.Len(t, genesis.Transactions, 1)
+	assert.Len(t, genesis.Transactions[0].Outputs, 3)
+	assert.Equal(t, 700, genesis.Transactions[0].Outputs[2].Value)
+
+	for address, expected := range map[string]int{miner.Address: services.Reward, funded.Address: 300} {
+		balance, err := txnService.GetBalance(address)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, balance)
+	}
+
+	stored, err := repo.GetChainParams()
+	assert.NoError(t, err)
+	assert.Equal(t, 1000, stored.Premine)
+	info, err := blockchainService.GetChainInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, services.Reward+1000, info.Supply)
+
+	// The genesis block checks out again with its allocations, but no other block's coinbase can pay out more than one
+	// output, and the genesis block's can't pay out more than the premine
+	assert.NoError(t, blockchainService.VerifyTransactions(genesis.Transactions, 0))
+	var verificationErr *services.TxnVerificationError
+	err = blockchainService.VerifyTransactions(genesis.Transactions, 1)
+	assert.True(t, errors.As(err, &verificationErr))
+	assert.Equal(t, services.InvalidTxnCoinbase, verificationErr.Reason)
+
+	overpaid := txnService.CreateGenesisTxn(miner.Address, "", []reps.GenesisAllocation{{Address: funded.Address, Amount: 1001}})
+	err = blockchainService.VerifyTransactions([]reps.Transaction{overpaid}, 0)
+	assert.True(t, errors.As(err, &verificationErr))
+	assert.Equal(t, services.InvalidTxnCoinbase, verificationErr.Reason)
+
+	// Once there's a chain, it's only handed back
+	_, exists, err := blockchainService.CreateBlockchain(miner.Address, allocations)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Len(t, repo.blocks, 1)
+}
@@ -0,0 +1,379 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"github.com/brucetieu/blockchain/repository"
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/google/uuid"
+	"github.com/tyler-smith/go-bip39"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	HDCoinType          = 0   // BIP44 coin type used in derivation paths
+	HDGapLimit          = 20  // Recovery stops after this many unused addresses in a row
+	MnemonicEntropyBits = 128 // 12 word mnemonics
+
+	hardenedOffset = uint32(0x80000000)
+	externalChain  = 0                        // BIP44 chain of receiving addresses
+	internalChain  = 1                        // BIP44 chain of change addresses
+	masterKeySalt  = []byte("Nist256p1 seed") // SLIP-0010 key for the P-256 curve
+)
+
+type HDWalletService interface {
+	CreateHDWallet(passphrase string) (reps.HDWallet, string, reps.Wallet, error)
+	DeriveNextWallet(hdWalletId string) (reps.Wallet, error)
+	DeriveChangeWallet(hdWalletId string) (reps.Wallet, error)
+	RecoverHDWallet(mnemonic string, passphrase string) (reps.HDWallet, []reps.Wallet, error)
+	GetHDWallet(hdWalletId string) (reps.HDWallet, []reps.Wallet, error)
+}
+
+type hdWalletService struct {
+	blockchainRepo  repository.BlockchainRepository
+	walletService   WalletService
+	keystoreService KeystoreService
+}
+
+func NewHDWalletService(blockchainRepo repository.BlockchainRepository, walletService WalletService, keystoreService KeystoreService) HDWalletService {
+	return &hdWalletService{
+		blockchainRepo:  blockchainRepo,
+		walletService:   walletService,
+		keystoreService: keystoreService,
+	}
+}
+
+// Private key and chain code of a node in the HD tree
+type extendedKey struct {
+	key       []byte
+	chainCode []byte
+}
+
+// Create an HD wallet from a fresh BIP39 mnemonic, and derive its first address.
+// The mnemonic is only returned here, only the seed is kept in the encrypted wallet file
+func (hs *hdWalletService) CreateHDWallet(passphrase string) (reps.HDWallet, string, reps.Wallet, error) {
+	log.Info("Creating HD wallet")
+	entropy, err := bip39.NewEntropy(MnemonicEntropyBits)
+	if err != nil {
+		return reps.HDWallet{}, "", reps.Wallet{}, err
+	}
+
+	mnemonic, err := bip39.NewMnemonic(entropy)
+	if err != nil {
+		return reps.HDWallet{}, "", reps.Wallet{}, err
+	}
+
+	seed := bip39.NewSeed(mnemonic, passphrase)
+	hdWallet, err := hs.saveHDWallet(seed)
+	if err != nil {
+		return reps.HDWallet{}, "", reps.Wallet{}, err
+	}
+
+	wallet, err := hs.DeriveNextWallet(hdWallet.ID)
+	if err != nil {
+		return reps.HDWallet{}, "", reps.Wallet{}, err
+	}
+	hdWallet.NextIndex++
+
+	return hdWallet, mnemonic, wallet, nil
+}
+
+// Derive the next receiving address of an HD wallet
+func (hs *hdWalletService) DeriveNextWallet(hdWalletId string) (reps.Wallet, error) {
+	hdWallet, err := hs.blockchainRepo.GetHDWallet(hdWalletId)
+	if err != nil {
+		return reps.Wallet{}, fmt.Errorf("%s, hd wallet with id %s does not exist", err.Error(), hdWalletId)
+	}
+
+	seed, err := hs.keystoreService.GetSeed(hdWalletId)
+	if err != nil {
+		return reps.Wallet{}, err
+	}
+
+	wallet, err := hs.importWallet(hdWallet, seed, externalChain, hdWallet.NextIndex)
+	if err != nil {
+		return reps.Wallet{}, err
+	}
+
+	hdWallet.NextIndex++
+	err = hs.blockchainRepo.UpdateHDWallet(hdWallet)
+	if err != nil {
+		return reps.Wallet{}, err
+	}
+
+	return wallet, nil
+}
+
+// Derive the next change address of an HD wallet, so change from a spend never goes back to an address that's been used
+func (hs *hdWalletService) DeriveChangeWallet(hdWalletId string) (reps.Wallet, error) {
+	hdWallet, err := hs.blockchainRepo.GetHDWallet(hdWalletId)
+	if err != nil {
+		return reps.Wallet{}, fmt.Errorf("%s, hd wallet with id %s does not exist", err.Error(), hdWalletId)
+	}
+
+	seed, err := hs.keystoreService.GetSeed(hdWalletId)
+	if err != nil {
+		return reps.Wallet{}, err
+	}
+
+	wallet, err := hs.importWallet(hdWallet, seed, internalChain, hdWallet.NextChangeIndex)
+	if err != nil {
+		return reps.Wallet{}, err
+	}
+
+	hdWallet.NextChangeIndex++
+	err = hs.blockchainRepo.UpdateHDWallet(hdWallet)
+	if err != nil {
+		return reps.Wallet{}, err
+	}
+
+	return wallet, nil
+}
+
+// Restore an HD wallet and all of its addresses from its mnemonic. On both the receiving and change chains, addresses are
+// derived in order until HDGapLimit addresses in a row have never received coins, and every address up to the last used one is restored
+func (hs *hdWalletService) RecoverHDWallet(mnemonic string, passphrase string) (reps.HDWallet, []reps.Wallet, error) {
+	log.Info("Recovering HD wallet from mnemonic")
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, passphrase)
+	if err != nil {
+		return reps.HDWallet{}, []reps.Wallet{}, fmt.Errorf("%s, invalid mnemonic", err.Error())
+	}
+
+	hdWallet, err := hs.blockchainRepo.GetHDWalletByFingerprint(fingerprint(newMasterKey(seed)))
+	if err != nil {
+		hdWallet, err = hs.saveHDWallet(seed)
+		if err != nil {
+			return reps.HDWallet{}, []reps.Wallet{}, err
+		}
+	} else if _, err := hs.keystoreService.GetSeed(hdWallet.ID); err != nil {
+		// Known HD wallet, but its seed isn't in this wallet file
+		if err := hs.keystoreService.StoreSeed(hdWallet.ID, seed); err != nil {
+			return reps.HDWallet{}, []reps.Wallet{}, err
+		}
+	}
+
+	usedPubKeyHashes, err := hs.getUsedPubKeyHashes()
+	if err != nil {
+		return reps.HDWallet{}, []reps.Wallet{}, err
+	}
+
+	// Always restore at least the first receiving address, and any addresses handed out before
+	receiveCount := hdWallet.NextIndex
+	if receiveCount < 1 {
+		receiveCount = 1
+	}
+
+	receiveCount, err = hs.scanChain(seed, hdWallet.Account, externalChain, receiveCount, usedPubKeyHashes)
+	if err != nil {
+		return reps.HDWallet{}, []reps.Wallet{}, err
+	}
+	changeCount, err := hs.scanChain(seed, hdWallet.Account, internalChain, hdWallet.NextChangeIndex, usedPubKeyHashes)
+	if err != nil {
+		return reps.HDWallet{}, []reps.Wallet{}, err
+	}
+
+	wallets := make([]reps.Wallet, 0)
+	for _, chain := range []struct{ chain, count int }{{externalChain, receiveCount}, {internalChain, changeCount}} {
+		for index := 0; index < chain.count; index++ {
+			wallet, err := hs.importWallet(hdWallet, seed, chain.chain, index)
+			if err != nil {
+				return reps.HDWallet{}, []reps.Wallet{}, err
+			}
+			wallets = append(wallets, wallet)
+		}
+	}
+
+	hdWallet.NextIndex = receiveCount
+	hdWallet.NextChangeIndex = changeCount
+	err = hs.blockchainRepo.UpdateHDWallet(hdWallet)
+	if err != nil {
+		return reps.HDWallet{}, []reps.Wallet{}, err
+	}
+
+	log.Infof("Recovered %d addresses for hd wallet %s", len(wallets), hdWallet.ID)
+	return hdWallet, wallets, nil
+}
+
+// Get an HD wallet and the addresses derived from it
+func (hs *hdWalletService) GetHDWallet(hdWalletId string) (reps.HDWallet, []reps.Wallet, error) {
+	hdWallet, err := hs.blockchainRepo.GetHDWallet(hdWalletId)
+	if err != nil {
+		return reps.HDWallet{}, []reps.Wallet{}, fmt.Errorf("%s, hd wallet with id %s does not exist", err.Error(), hdWalletId)
+	}
+
+	wallets, err := hs.blockchainRepo.GetWalletsByHDWalletId(hdWalletId)
+	if err != nil {
+		return reps.HDWallet{}, []reps.Wallet{}, err
+	}
+
+	return hdWallet, wallets, nil
+}
+
+// Persist a new HD wallet, with its seed going to the encrypted wallet file
+func (hs *hdWalletService) saveHDWallet(seed []byte) (reps.HDWallet, error) {
+	hdWallet := reps.HDWallet{
+		ID:          uuid.Must(uuid.NewRandom()).String(),
+		Fingerprint: fingerprint(newMasterKey(seed)),
+		Account:     0,
+		NextIndex:   0,
+	}
+
+	err := hs.keystoreService.StoreSeed(hdWallet.ID, seed)
+	if err != nil {
+		return reps.HDWallet{}, err
+	}
+
+	err = hs.blockchainRepo.CreateHDWallet(hdWallet)
+	if err != nil {
+		return reps.HDWallet{}, err
+	}
+
+	return hdWallet, nil
+}
+
+// Number of addresses to restore on a chain: at least restoreCount, and every address up to the last one that received coins
+func (hs *hdWalletService) scanChain(seed []byte, account int, chain int, restoreCount int, usedPubKeyHashes map[string]bool) (int, error) {
+	for index, unused := 0, 0; unused < HDGapLimit; index++ {
+		privKey := deriveAddressKey(seed, account, chain, index).privateKey()
+		pubKeyHash, err := createPubKeyHash(hs.walletService.DerivePubKey(privKey))
+		if err != nil {
+			return 0, err
+		}
+
+		if usedPubKeyHashes[hex.EncodeToString(pubKeyHash)] {
+			unused = 0
+			if index+1 > restoreCount {
+				restoreCount = index + 1
+			}
+		} else {
+			unused++
+		}
+	}
+
+	return restoreCount, nil
+}
+
+// Derive the key at index on a chain and save it as a wallet
+func (hs *hdWalletService) importWallet(hdWallet reps.HDWallet, seed []byte, chain int, index int) (reps.Wallet, error) {
+	privKey := deriveAddressKey(seed, hdWallet.Account, chain, index).privateKey()
+	path := fmt.Sprintf("m/44'/%d'/%d'/%d/%d", HDCoinType, hdWallet.Account, chain, index)
+
+	return hs.walletService.ImportWallet(privKey, hdWallet.ID, path)
+}
+
+// Every pubKeyHash that has received coins on the blockchain
+func (hs *hdWalletService) getUsedPubKeyHashes() (map[string]bool, error) {
+	txns, err := hs.blockchainRepo.GetTransactions()
+	if err != nil {
+		return map[string]bool{}, err
+	}
+
+	used := make(map[string]bool)
+	for _, txn := range txns {
+		for _, output := range txn.Outputs {
+			used[hex.EncodeToString(output.PubKeyHash)] = true
+		}
+	}
+
+	return used, nil
+}
+
+// BIP44 key m/44'/coinType'/account'/chain/index
+func deriveAddressKey(seed []byte, account int, chain int, index int) extendedKey {
+	return newMasterKey(seed).
+		child(44 + hardenedOffset).
+		child(uint32(HDCoinType) + hardenedOffset).
+		child(uint32(account) + hardenedOffset).
+		child(uint32(chain)).
+		child(uint32(index))
+}
+
+// Master key of the HD tree, as defined by SLIP-0010 for P-256
+func newMasterKey(seed []byte) extendedKey {
+	curveOrder := elliptic.P256().Params().N
+
+	data := seed
+	for {
+		mac := hmac.New(sha512.New, masterKeySalt)
+		mac.Write(data)
+		digest := mac.Sum(nil)
+
+		// Retry with the digest as input if it isn't a valid private key
+		key := new(big.Int).SetBytes(digest[:32])
+		if key.Sign() != 0 && key.Cmp(curveOrder) < 0 {
+			return extendedKey{key: digest[:32], chainCode: digest[32:]}
+		}
+		data = digest
+	}
+}
+
+// Derive a child key. Indices from hardenedOffset up are hardened, so they can't be derived from the public key
+func (k extendedKey) child(index uint32) extendedKey {
+	curve := elliptic.P256()
+	curveOrder := curve.Params().N
+
+	var data []byte
+	if index >= hardenedOffset {
+		data = append([]byte{0x00}, k.key...)
+	} else {
+		x, y := curve.ScalarBaseMult(k.key)
+		data = elliptic.MarshalCompressed(curve, x, y)
+	}
+	data = append(data, ser32(index)...)
+
+	for {
+		mac := hmac.New(sha512.New, k.chainCode)
+		mac.Write(data)
+		digest := mac.Sum(nil)
+
+		tweak := new(big.Int).SetBytes(digest[:32])
+		childKey := new(big.Int).Add(tweak, new(big.Int).SetBytes(k.key))
+		childKey.Mod(childKey, curveOrder)
+
+		if tweak.Cmp(curveOrder) < 0 && childKey.Sign() != 0 {
+			return extendedKey{key: childKey.FillBytes(make([]byte, 32)), chainCode: digest[32:]}
+		}
+
+		// Invalid key, retry as SLIP-0010 describes
+		data = append([]byte{0x01}, digest[32:]...)
+		data = append(data, ser32(index)...)
+	}
+}
+
+func (k extendedKey) privateKey() ecdsa.PrivateKey {
+	curve := elliptic.P256()
+	x, y := curve.ScalarBaseMult(k.key)
+
+	return ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{Curve: curve, X: x, Y: y},
+		D:         new(big.Int).SetBytes(k.key),
+	}
+}
+
+// First 4 bytes of the hash of the compressed master public key
+func fingerprint(master extendedKey) string {
+	curve := elliptic.P256()
+	x, y := curve.ScalarBaseMult(master.key)
+
+	pubKeyHash, err := createPubKeyHash(elliptic.MarshalCompressed(curve, x, y))
+	if err != nil {
+		log.Error("error creating fingerprint: ", err.Error())
+	}
+
+	return hex.EncodeToString(pubKeyHash[:4])
+}
+
+// 32 bit big endian index
+func ser32(index uint32) []byte {
+	buf := make([]byte, 4)
+	binary.BigEndian.PutUint32(buf, index)
+	return buf
+}
@@ -0,0 +1,60 @@
+package services_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoverHDWalletRestoresUsedAddresses(t *testing.T) {
+	repo := newFakeBlockchainRepository()
+	keystore := newUnlockedKeystore(t)
+	walletService := services.NewWalletService(repo, keystore, &mainnet)
+	hdWalletService := services.NewHDWalletService(repo, walletService, keystore)
+
+	_, mnemonic, wallet, err := hdWalletService.CreateHDWallet("")
+	assert.NoError(t, err)
+	assert.Equal(t, "m/44'/0'/0'/0/0", wallet.DerivationPath)
+
+	addresses := []string{wallet.Address}
+	for i := 0; i < 2; i++ {
+		wallet, err = hdWalletService.DeriveNextWallet(wallet.HDWalletID)
+		assert.NoError(t, err)
+		addresses = append(addresses, wallet.Address)
+	}
+
+	// Only the last derived address has received coins
+	pubKey, err := hex.DecodeString(wallet.PublicKey)
+	assert.NoError(t, err)
+	pubKeyHash, err := walletService.CreatePubKeyHash(pubKey)
+	assert.NoError(t, err)
+
+	// Recover on a node that has seen the chain but never had this wallet
+	recoveredRepo := newFakeBlockchainRepository()
+	recoveredRepo.blocks = []reps.Block{{Transactions: []reps.Transaction{{Outputs: []reps.TxnOutput{{Value: 10, PubKeyHash: pubKeyHash}}}}}}
+	recoveredKeystore := newUnlockedKeystore(t)
+	recoveredWallets := services.NewWalletService(recoveredRepo, recoveredKeystore, &mainnet)
+	recoveredHDWallets := services.NewHDWalletService(recoveredRepo, recoveredWallets, recoveredKeystore)
+
+	_, wallets, err := recoveredHDWallets.RecoverHDWallet(mnemonic, "")
+	assert.NoError(t, err)
+
+	recoveredAddresses := make([]string, 0)
+	for _, wallet := range wallets {
+		recoveredAddresses = append(recoveredAddresses, wallet.Address)
+	}
+	assert.Equal(t, addresses, recoveredAddresses)
+}
+
+func TestRecoverHDWalletRejectsInvalidMnemonic(t *testing.T) {
+	repo := newFakeBlockchainRepository()
+	keystore := newUnlockedKeystore(t)
+	walletService := services.NewWalletService(repo, keystore, &mainnet)
+	hdWalletService := services.NewHDWalletService(repo, walletService, keystore)
+
+	_, _, err := hdWalletService.RecoverHDWallet("not a real mnemonic", "")
+	assert.Error(t, err)
+}
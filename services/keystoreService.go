@@ -0,0 +1,380 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/brucetieu/blockchain/repository"
+	reps "github.com/brucetieu/blockchain/representations"
+	"golang.org/x/crypto/scrypt"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	KeystoreVersion = 1
+
+	// scrypt parameters recommended for interactive logins
+	ScryptN      = 32768
+	ScryptR      = 8
+	ScryptP      = 1
+	ScryptKeyLen = 32 // AES-256
+
+	keystoreCheck  = []byte("blockchain keystore")
+	keystoreBackup = []byte("blockchain wallet backup") // Authenticated with every backup, so keys sealed for the wallet file can't pass as one
+)
+
+// Holds wallet private keys. Keys are only ever written to disk encrypted, and are decrypted into memory on unlock
+type KeystoreService interface {
+	Unlock(passphrase string) error
+	Lock()
+	IsUnlocked() bool
+
+	StoreKey(address string, privKey []byte) error
+	GetKey(address string) ([]byte, error)
+	StoreSeed(hdWalletId string, seed []byte) error
+	GetSeed(hdWalletId string) ([]byte, error)
+
+	SealBackup(plaintext []byte) (reps.WalletBackup, error)
+}
+
+type keystoreService struct {
+	keystoreRepo repository.KeystoreRepository
+
+	mu       sync.RWMutex
+	keystore reps.Keystore
+	aead     cipher.AEAD
+	keys     map[string][]byte // address -> decrypted private key
+	seeds    map[string][]byte // hd wallet id -> decrypted seed
+}
+
+func NewKeystoreService(keystoreRepo repository.KeystoreRepository) KeystoreService {
+	return &keystoreService{
+		keystoreRepo: keystoreRepo,
+		keys:         make(map[string][]byte),
+		seeds:        make(map[string][]byte),
+	}
+}
+
+// Derive the encryption key from the passphrase and decrypt every key in the wallet file.
+// A new wallet file is created if one doesn't exist yet
+func (ks *keystoreService) Unlock(passphrase string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	keystore, exists, err := ks.keystoreRepo.GetKeystore()
+	if err != nil {
+		return fmt.Errorf("%s, unable to read wallet file", err.Error())
+	}
+
+	if !exists {
+		log.Info("Wallet file doesn't exist, so creating it now...")
+		keystore, err = ks.newKeystore(passphrase)
+		if err != nil {
+			return err
+		}
+	}
+
+	aead, err := deriveAEAD(passphrase, keystore)
+	if err != nil {
+		return err
+	}
+
+	// Wrong passphrases fail to open the check value
+	if _, err := aead.Open(nil, keystore.Check.Nonce, keystore.Check.Ciphertext, nil); err != nil {
+		return fmt.Errorf("incorrect passphrase for wallet file")
+	}
+
+	keys := make(map[string][]byte)
+	for _, encryptedKey := range keystore.Keys {
+		privKey, err := aead.Open(nil, encryptedKey.Nonce, encryptedKey.Ciphertext, []byte(encryptedKey.Address))
+		if err != nil {
+			return fmt.Errorf("%s, unable to decrypt key for address %s", err.Error(), encryptedKey.Address)
+		}
+		keys[encryptedKey.Address] = privKey
+	}
+
+	seeds := make(map[string][]byte)
+	for _, encryptedSeed := range keystore.Seeds {
+		seed, err := aead.Open(nil, encryptedSeed.Nonce, encryptedSeed.Ciphertext, []byte(encryptedSeed.HDWalletID))
+		if err != nil {
+			return fmt.Errorf("%s, unable to decrypt seed for hd wallet %s", err.Error(), encryptedSeed.HDWalletID)
+		}
+		seeds[encryptedSeed.HDWalletID] = seed
+	}
+
+	if !exists {
+		if err := ks.keystoreRepo.SaveKeystore(keystore); err != nil {
+			return err
+		}
+	}
+
+	ks.keystore = keystore
+	ks.aead = aead
+	ks.keys = keys
+	ks.seeds = seeds
+
+	log.Infof("Wallet file unlocked with %d keys and %d seeds", len(keys), len(seeds))
+	return nil
+}
+
+// Forget the decrypted keys and the encryption key. Nothing can be signed until the wallet file is unlocked again.
+// Only the keystore's own copies are zeroed, keys already handed out stay as they were
+func (ks *keystoreService) Lock() {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	for _, privKey := range ks.keys {
+		zero(privKey)
+	}
+	for _, seed := range ks.seeds {
+		zero(seed)
+	}
+
+	ks.aead = nil
+	ks.keys = make(map[string][]byte)
+	ks.seeds = make(map[string][]byte)
+
+	log.Info("Wallet file locked")
+}
+
+func (ks *keystoreService) IsUnlocked() bool {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	return ks.aead != nil
+}
+
+// Encrypt a private key and persist it to the wallet file
+func (ks *keystoreService) StoreKey(address string, privKey []byte) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if ks.aead == nil {
+		return fmt.Errorf("%w, cannot store key for address %s", ErrWalletLocked, address)
+	}
+
+	encryptedKey, err := seal(ks.aead, privKey, []byte(address))
+	if err != nil {
+		return err
+	}
+	encryptedKey.Address = address
+
+	keystore := ks.keystore
+	keystore.Keys = append(append([]reps.EncryptedKey{}, ks.keystore.Keys...), encryptedKey)
+
+	if err := ks.keystoreRepo.SaveKeystore(keystore); err != nil {
+		return err
+	}
+
+	ks.keystore = keystore
+	ks.keys[address] = clone(privKey)
+
+	return nil
+}
+
+// Get the decrypted private key for an address
+func (ks *keystoreService) GetKey(address string) ([]byte, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	if ks.aead == nil {
+		return nil, fmt.Errorf("%w, cannot get key for address %s", ErrWalletLocked, address)
+	}
+
+	privKey, ok := ks.keys[address]
+	if !ok {
+		return nil, fmt.Errorf("no private key for address %s", address)
+	}
+
+	return clone(privKey), nil
+}
+
+// Encrypt an HD wallet seed and persist it to the wallet file
+func (ks *keystoreService) StoreSeed(hdWalletId string, seed []byte) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if ks.aead == nil {
+		return fmt.Errorf("%w, cannot store seed for hd wallet %s", ErrWalletLocked, hdWalletId)
+	}
+
+	encryptedSeed, err := seal(ks.aead, seed, []byte(hdWalletId))
+	if err != nil {
+		return err
+	}
+	encryptedSeed.HDWalletID = hdWalletId
+
+	keystore := ks.keystore
+	keystore.Seeds = append(append([]reps.EncryptedKey{}, ks.keystore.Seeds...), encryptedSeed)
+
+	if err := ks.keystoreRepo.SaveKeystore(keystore); err != nil {
+		return err
+	}
+
+	ks.keystore = keystore
+	ks.seeds[hdWalletId] = clone(seed)
+
+	return nil
+}
+
+// Get the decrypted seed of an HD wallet
+func (ks *keystoreService) GetSeed(hdWalletId string) ([]byte, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	if ks.aead == nil {
+		return nil, fmt.Errorf("%w, cannot get seed for hd wallet %s", ErrWalletLocked, hdWalletId)
+	}
+
+	seed, ok := ks.seeds[hdWalletId]
+	if !ok {
+		return nil, fmt.Errorf("no seed for hd wallet %s", hdWalletId)
+	}
+
+	return clone(seed), nil
+}
+
+// Encrypt a wallet backup under the same passphrase as the wallet file
+func (ks *keystoreService) SealBackup(plaintext []byte) (reps.WalletBackup, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	if ks.aead == nil {
+		return reps.WalletBackup{}, fmt.Errorf("%w, cannot create a backup", ErrWalletLocked)
+	}
+
+	sealed, err := seal(ks.aead, plaintext, keystoreBackup)
+	if err != nil {
+		return reps.WalletBackup{}, err
+	}
+
+	return reps.WalletBackup{
+		Salt:       ks.keystore.Salt,
+		ScryptN:    ks.keystore.ScryptN,
+		ScryptR:    ks.keystore.ScryptR,
+		ScryptP:    ks.keystore.ScryptP,
+		Nonce:      sealed.Nonce,
+		Ciphertext: sealed.Ciphertext,
+	}, nil
+}
+
+// Decrypt a wallet backup with the passphrase of the wallet file it was made from
+func openBackup(backup reps.WalletBackup, passphrase string) ([]byte, error) {
+	aead, err := deriveAEAD(passphrase, reps.Keystore{
+		Salt:    backup.Salt,
+		ScryptN: backup.ScryptN,
+		ScryptR: backup.ScryptR,
+		ScryptP: backup.ScryptP,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := aead.Open(nil, backup.Nonce, backup.Ciphertext, keystoreBackup)
+	if err != nil {
+		return nil, fmt.Errorf("incorrect passphrase for wallet backup")
+	}
+
+	return plaintext, nil
+}
+
+// Overwrite key material before dropping it
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// Copy of key material going in or out of the keystore, so zeroing the keystore's own on lock doesn't wipe one still in use
+func clone(b []byte) []byte {
+	return append([]byte{}, b...)
+}
+
+// Create an empty wallet file protected by the passphrase
+func (ks *keystoreService) newKeystore(passphrase string) (reps.Keystore, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return reps.Keystore{}, err
+	}
+
+	keystore := reps.Keystore{
+		Version: KeystoreVersion,
+		Salt:    salt,
+		ScryptN: ScryptN,
+		ScryptR: ScryptR,
+		ScryptP: ScryptP,
+		Keys:    []reps.EncryptedKey{},
+	}
+
+	aead, err := deriveAEAD(passphrase, keystore)
+	if err != nil {
+		return reps.Keystore{}, err
+	}
+
+	keystore.Check, err = seal(aead, keystoreCheck, nil)
+	if err != nil {
+		return reps.Keystore{}, err
+	}
+
+	return keystore, nil
+}
+
+// AES-GCM cipher keyed with scrypt(passphrase, salt)
+func deriveAEAD(passphrase string, keystore reps.Keystore) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), keystore.Salt, keystore.ScryptN, keystore.ScryptR, keystore.ScryptP, ScryptKeyLen)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// Encrypt plaintext under a fresh random nonce. additionalData is authenticated but not encrypted
+func seal(aead cipher.AEAD, plaintext []byte, additionalData []byte) (reps.EncryptedKey, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return reps.EncryptedKey{}, err
+	}
+
+	return reps.EncryptedKey{
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, additionalData),
+	}, nil
+}
+
+// Unlock the wallet file with WALLET_PASSPHRASE, then move any plaintext keys left in the db into it.
+// Without a passphrase the node starts locked, and can't create wallets or sign transactions
+func UnlockWalletsAtStartup(keystoreService KeystoreService, walletService WalletService) {
+	passphrase := os.Getenv("WALLET_PASSPHRASE")
+	if passphrase == "" {
+		log.Warn("WALLET_PASSPHRASE not set, wallet file stays locked")
+		return
+	}
+
+	if err := keystoreService.Unlock(passphrase); err != nil {
+		log.Fatal("Error unlocking wallet file: ", err.Error())
+	}
+
+	if err := walletService.EncryptStoredKeys(); err != nil {
+		log.Fatal("Error moving private keys into wallet file: ", err.Error())
+	}
+}
+
+// Location of the encrypted wallet file, WALLET_FILE or wallet.dat in the working directory by default
+func WalletFilePath() string {
+	path := os.Getenv("WALLET_FILE")
+	if path == "" {
+		path = "wallet.dat"
+	}
+	return path
+}
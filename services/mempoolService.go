@@ -0,0 +1,950 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brucetieu/blockchain/repository"
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/google/uuid"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	MaxBlockTxns       = 100  // Most transactions mined from the mempool into one block, not counting the coinbase
+	MaxBlockTemplates  = 16   // Most block templates kept for mining elsewhere. Past that, the oldest can't be submitted
+	BlockSizeAllowance = 1000 // Bytes of a block kept for its header and coinbase when filling it from the mempool
+	MaxBatchTransfers  = 100  // Most transfers submitted in one batch
+
+	MempoolTTL     = 72 * time.Hour // How long a transaction can wait in the mempool before it's evicted
+	MaxMempoolSize = 5000           // Most transactions the mempool holds. Past that, the lowest fee rates are evicted
+)
+
+// Called with each transaction let into the mempool, wherever it came from
+type TxnListener func(txn reps.Transaction)
+
+// Holds transactions that passed verification but aren't on a block yet. Kept in memory,
+// and written through to the db so they're still pending after a restart
+type MempoolService interface {
+	AddTransaction(txn reps.Transaction) (reps.MempoolEntry, error)
+	ValidateTransaction(txn reps.Transaction) (reps.TxnValidation, error)
+	SubmitBatch(transfers []reps.Transfer, opts reps.TxnOptions) ([]reps.BatchResult, error)
+	GetEntries() []reps.MempoolEntry
+	GetEntry(txnId string) (reps.MempoolEntry, bool)
+	Size() int
+	GetStats() reps.MempoolStats
+
+	MinePendingTransactions(miner string, message string) (reps.Block, error)
+	GetBlockTemplate(miner string, message string) (reps.BlockTemplate, error)
+	SubmitBlock(input reps.SubmitBlockInput) (reps.Block, error)
+	ReceiveBlock(block reps.Block) (reps.ChainUpdate, error)
+	GetAddressBalance(address string) (reps.AddressBalanceSummary, error)
+	GetTransactionStatus(txnId string) (reps.TxnStatus, error)
+
+	Restore() error
+	OnTransactionAccepted(listener TxnListener)
+}
+
+type mempoolService struct {
+	mempoolRepo        repository.MempoolRepository
+	transactionService TransactionService
+	blockchainService  BlockchainService
+	txnAssembler       TxnAssemblerFac
+	params             *reps.ChainParams
+
+	mu       sync.Mutex
+	entries  map[string]reps.MempoolEntry // By hex transaction id
+	spending map[string]string            // Hex id of the pending transaction spending each outpoint
+
+	// Evictions since the node started
+	expired         int
+	evictedForSpace int
+	replaced        int
+
+	miningMu      sync.Mutex            // Only one block is mined from the mempool at a time
+	templates     map[string]reps.Block // Templates handed out for mining elsewhere, by block id
+	templateOrder []string              // Ids of templates, oldest first
+
+	listenersMu  sync.RWMutex
+	txnListeners []TxnListener
+}
+
+func NewMempoolService(mempoolRepo repository.MempoolRepository, transactionService TransactionService,
+	blockchainService BlockchainService, params *reps.ChainParams) MempoolService {
+	return &mempoolService{
+		mempoolRepo:        mempoolRepo,
+		transactionService: transactionService,
+		blockchainService:  blockchainService,
+		txnAssembler:       TxnAssembler,
+		params:             params,
+		entries:            make(map[string]reps.MempoolEntry),
+		spending:           make(map[string]string),
+		templates:          make(map[string]reps.Block),
+	}
+}
+
+// Verify a transaction and queue it to be mined. Transactions spending an output another
+// pending transaction already spends are rejected, unless they replace it, see replaceable
+func (ms *mempoolService) AddTransaction(txn reps.Transaction) (reps.MempoolEntry, error) {
+	txnId := hex.EncodeToString(txn.ID)
+	log.Info("Adding transaction to mempool: ", txnId)
+
+	if ms.transactionService.IsCoinbaseTransaction(txn) {
+		return reps.MempoolEntry{}, fmt.Errorf("coinbase transaction %s can't be queued, only mined", txnId)
+	}
+
+	// Also rejects inputs already spent by a confirmed transaction
+	if _, err := ms.transactionService.VerifyTransaction(txn); err != nil {
+		return reps.MempoolEntry{}, err
+	}
+
+	data, err := json.Marshal(txn)
+	if err != nil {
+		return reps.MempoolEntry{}, err
+	}
+
+	entry := reps.MempoolEntry{
+		TxnID:       txnId,
+		Data:        data,
+		Fee:         txn.Fee,
+		Size:        len(ms.txnAssembler.ToTxnBytes(txn)),
+		AddedAt:     time.Now().UnixMilli(),
+		Transaction: txn,
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.expireLocked(entry.AddedAt)
+
+	if _, ok := ms.entries[txnId]; ok {
+		return reps.MempoolEntry{}, fmt.Errorf("transaction %s is already in the mempool", txnId)
+	}
+
+	replaced, err := ms.replaceable(txn)
+	if err != nil {
+		return reps.MempoolEntry{}, err
+	}
+
+	evicted, err := ms.makeRoom(entry, replaced)
+	if err != nil {
+		return reps.MempoolEntry{}, err
+	}
+
+	if err := ms.mempoolRepo.CreateEntry(entry); err != nil {
+		return reps.MempoolEntry{}, err
+	}
+
+	for _, old := range evicted {
+		log.Infof("Evicting transaction %s from the full mempool for %s", old.TxnID, txnId)
+		ms.removeLocked(old.TxnID)
+		ms.evictedForSpace++
+	}
+
+	for _, old := range replaced {
+		log.Infof("Transaction %s replaces %s in the mempool", txnId, old.TxnID)
+		ms.removeLocked(old.TxnID)
+		ms.replaced++
+		err := ms.mempoolRepo.CreateReplacement(reps.MempoolReplacement{TxnID: old.TxnID, ReplacedBy: txnId, ReplacedAt: entry.AddedAt})
+		if err != nil {
+			log.Error("error recording mempool replacement: ", err.Error())
+		}
+	}
+	ms.add(entry)
+	ms.transactionAccepted(txn)
+
+	log.Infof("Mempool holds %d transactions", len(ms.entries))
+	return entry, nil
+}
+
+// Have listener called with every transaction let into the mempool from now on, e.g. to relay it to peers. It's
+// called while the mempool is locked, so it mustn't hold things up
+func (ms *mempoolService) OnTransactionAccepted(listener TxnListener) {
+	ms.listenersMu.Lock()
+	defer ms.listenersMu.Unlock()
+	ms.txnListeners = append(ms.txnListeners, listener)
+}
+
+func (ms *mempoolService) transactionAccepted(txn reps.Transaction) {
+	ms.listenersMu.RLock()
+	defer ms.listenersMu.RUnlock()
+	for _, listener := range ms.txnListeners {
+		listener(txn)
+	}
+}
+
+// Decode a hex or base64 encoded transaction, in the JSON form the node stores it in
+func DecodeRawTransaction(raw string) (reps.Transaction, error) {
+	raw = strings.TrimSpace(raw)
+
+	data, err := hex.DecodeString(raw)
+	if err != nil {
+		data, err = base64.StdEncoding.DecodeString(raw)
+		if err != nil {
+			return reps.Transaction{}, fmt.Errorf("raw transaction is neither hex nor base64")
+		}
+	}
+
+	var txn reps.Transaction
+	if err := json.Unmarshal(data, &txn); err != nil {
+		return reps.Transaction{}, fmt.Errorf("%s, malformed raw transaction", err.Error())
+	}
+	if len(txn.ID) == 0 {
+		return reps.Transaction{}, fmt.Errorf("raw transaction has no id")
+	}
+
+	return txn, nil
+}
+
+// Run every check a transaction goes through before it's queued and mined, without queueing it
+func (ms *mempoolService) ValidateTransaction(txn reps.Transaction) (reps.TxnValidation, error) {
+	txnId := hex.EncodeToString(txn.ID)
+	log.Info("Validating transaction: ", txnId)
+
+	if ms.transactionService.IsCoinbaseTransaction(txn) {
+		return reps.TxnValidation{}, &TxnVerificationError{TxnID: txnId, InputIndex: -1, Reason: InvalidTxnCoinbase, Message: "coinbase transactions can only be mined"}
+	}
+
+	// Balance, signatures and inputs already spent on the chain
+	if _, err := ms.transactionService.VerifyTransaction(txn); err != nil {
+		return reps.TxnValidation{}, err
+	}
+
+	ms.mu.Lock()
+	_, err := ms.replaceable(txn)
+	ms.mu.Unlock()
+	if err != nil {
+		return reps.TxnValidation{}, err
+	}
+
+	height, err := ms.blockchainService.GetNextBlockHeight()
+	if err != nil {
+		return reps.TxnValidation{}, err
+	}
+	if !IsFinalTransaction(txn, height, time.Now().UnixMilli()) {
+		return reps.TxnValidation{}, &TxnVerificationError{TxnID: txnId, InputIndex: -1, Reason: InvalidTxnLocked, Message: fmt.Sprintf("locked until %d, can't go on block %d", txn.LockTime, height)}
+	}
+
+	prevTxns, err := ms.transactionService.GetPrevTransactions(txn)
+	if err != nil {
+		return reps.TxnValidation{}, err
+	}
+
+	validation := reps.TxnValidation{
+		TxnID:    txnId,
+		Valid:    true,
+		Fee:      txn.Fee,
+		Size:     len(ms.txnAssembler.ToTxnBytes(txn)),
+		Consumed: make([]reps.OutputStatus, 0, len(txn.Inputs)),
+	}
+	for _, input := range txn.Inputs {
+		output := prevTxns[hex.EncodeToString(input.PrevTxnID)].Outputs[input.OutIdx]
+		validation.Consumed = append(validation.Consumed, reps.OutputStatus{
+			TxnID:      hex.EncodeToString(input.PrevTxnID),
+			OutIdx:     input.OutIdx,
+			Status:     reps.OutputUnspent,
+			Value:      output.Value,
+			PubKeyHash: hex.EncodeToString(output.PubKeyHash),
+			AssetID:    output.AssetID,
+		})
+	}
+
+	return validation, nil
+}
+
+// Fails if a pending transaction already spends one of txn's inputs. Must hold mu
+func (ms *mempoolService) checkConflicts(txn reps.Transaction) error {
+	for inIdx, input := range txn.Inputs {
+		outpoint := reps.OutpointID(input.PrevTxnID, input.OutIdx)
+		if spender, ok := ms.spending[outpoint]; ok {
+			return &TxnVerificationError{TxnID: hex.EncodeToString(txn.ID), InputIndex: inIdx, Reason: InvalidTxnMempoolConflict, Message: fmt.Sprintf("output %s is already spent by pending transaction %s", outpoint, spender)}
+		}
+	}
+	return nil
+}
+
+// Pending transactions txn would replace, if it conflicts with any. It can replace them only if they all opted in
+// by being replaceable, it spends every input they do, and it pays more than all of them together. Must hold mu
+func (ms *mempoolService) replaceable(txn reps.Transaction) ([]reps.MempoolEntry, error) {
+	txnId := hex.EncodeToString(txn.ID)
+
+	spends := make(map[string]bool)
+	for _, input := range txn.Inputs {
+		spends[reps.OutpointID(input.PrevTxnID, input.OutIdx)] = true
+	}
+
+	replaced := make([]reps.MempoolEntry, 0)
+	seen := make(map[string]bool)
+	replacedFees := 0
+
+	for inIdx, input := range txn.Inputs {
+		outpoint := reps.OutpointID(input.PrevTxnID, input.OutIdx)
+		spender, ok := ms.spending[outpoint]
+		if !ok || seen[spender] {
+			continue
+		}
+		seen[spender] = true
+
+		old := ms.entries[spender]
+		if !old.Transaction.Replaceable {
+			return nil, &TxnVerificationError{TxnID: txnId, InputIndex: inIdx, Reason: InvalidTxnMempoolConflict, Message: fmt.Sprintf("output %s is already spent by pending transaction %s", outpoint, spender)}
+		}
+		for _, oldInput := range old.Transaction.Inputs {
+			if !spends[reps.OutpointID(oldInput.PrevTxnID, oldInput.OutIdx)] {
+				return nil, &TxnVerificationError{TxnID: txnId, InputIndex: inIdx, Reason: InvalidTxnMempoolConflict, Message: fmt.Sprintf("replacing pending transaction %s means spending all of its inputs", spender)}
+			}
+		}
+
+		replaced = append(replaced, old)
+		replacedFees += old.Fee
+	}
+
+	if len(replaced) > 0 && txn.Fee <= replacedFees {
+		return nil, &TxnVerificationError{TxnID: txnId, InputIndex: -1, Reason: InvalidTxnReplacementFee, Message: fmt.Sprintf("fee of %d doesn't beat the %d paid by the transactions it replaces", txn.Fee, replacedFees)}
+	}
+
+	return replaced, nil
+}
+
+// Whether a pays a higher fee rate than b
+func higherFeeRate(a reps.MempoolEntry, b reps.MempoolEntry) bool {
+	return a.Fee*b.Size > b.Fee*a.Size
+}
+
+// Pending transactions to evict so entry fits in the mempool, besides the ones it replaces. Only ones paying
+// a lower fee rate than entry are evicted, lowest first, and the mempool is full if that's not enough. Must hold mu
+func (ms *mempoolService) makeRoom(entry reps.MempoolEntry, replaced []reps.MempoolEntry) ([]reps.MempoolEntry, error) {
+	excess := len(ms.entries) - len(replaced) + 1 - MaxMempoolSize
+	if excess <= 0 {
+		return nil, nil
+	}
+
+	skip := make(map[string]bool)
+	for _, old := range replaced {
+		skip[old.TxnID] = true
+	}
+
+	candidates := make([]reps.MempoolEntry, 0, len(ms.entries))
+	for _, candidate := range ms.entries {
+		if !skip[candidate.TxnID] {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	// Lowest fee rate first, then newest first
+	sort.Slice(candidates, func(i, j int) bool {
+		if higherFeeRate(candidates[j], candidates[i]) || higherFeeRate(candidates[i], candidates[j]) {
+			return higherFeeRate(candidates[j], candidates[i])
+		}
+		return candidates[i].AddedAt > candidates[j].AddedAt
+	})
+
+	if excess > len(candidates) {
+		excess = len(candidates)
+	}
+	for _, candidate := range candidates[:excess] {
+		if !higherFeeRate(entry, candidate) {
+			return nil, fmt.Errorf("%w, transaction %s needs a higher fee rate to get in", ErrMempoolFull, entry.TxnID)
+		}
+	}
+
+	return candidates[:excess], nil
+}
+
+// Evict every transaction that's waited longer than MempoolTTL as of now, in unix milliseconds. Must hold mu
+func (ms *mempoolService) expireLocked(now int64) {
+	for txnId, entry := range ms.entries {
+		if now-entry.AddedAt > MempoolTTL.Milliseconds() {
+			log.Infof("Evicting transaction %s from the mempool, it's waited longer than %s", txnId, MempoolTTL)
+			ms.removeLocked(txnId)
+			ms.expired++
+		}
+	}
+}
+
+// Must hold mu
+func (ms *mempoolService) add(entry reps.MempoolEntry) {
+	ms.entries[entry.TxnID] = entry
+	for _, input := range entry.Transaction.Inputs {
+		ms.spending[reps.OutpointID(input.PrevTxnID, input.OutIdx)] = entry.TxnID
+	}
+}
+
+// Queue several transfers at once. Transfers from the same address share one transaction, so they don't
+// compete for the same inputs, and each is only accepted if the address can cover it on top of the ones before it.
+// Every transfer gets its own result, in the order given
+func (ms *mempoolService) SubmitBatch(transfers []reps.Transfer, opts reps.TxnOptions) ([]reps.BatchResult, error) {
+	log.Infof("Submitting batch of %d transfers", len(transfers))
+	if len(transfers) == 0 {
+		return nil, fmt.Errorf("a batch needs at least one transfer")
+	}
+	if len(transfers) > MaxBatchTransfers {
+		return nil, fmt.Errorf("a batch can have at most %d transfers, not %d", MaxBatchTransfers, len(transfers))
+	}
+
+	results := make([]reps.BatchResult, len(transfers))
+	for i := range results {
+		results[i].Index = i
+	}
+
+	// Senders in the order they first appear, and the transfers each one can cover
+	senders := make([]string, 0)
+	accepted := make(map[string][]int)
+	committed := make(map[string]int)
+	balances := make(map[string]int)
+
+	for i, transfer := range transfers {
+		if !IsValidAddress(transfer.From, ms.params.NetworkByte) {
+			results[i].Error = fmt.Sprintf("malformed address: %s", transfer.From)
+			continue
+		}
+		if !IsValidAddress(transfer.To, ms.params.NetworkByte) {
+			results[i].Error = fmt.Sprintf("malformed address: %s", transfer.To)
+			continue
+		}
+		if transfer.Amount <= 0 {
+			results[i].Error = fmt.Sprintf("amount sent to %s must be positive, not %d", transfer.To, transfer.Amount)
+			continue
+		}
+
+		if _, ok := balances[transfer.From]; !ok {
+			balance, err := ms.transactionService.GetAddressBalance(transfer.From)
+			if err != nil {
+				results[i].Error = err.Error()
+				continue
+			}
+			balances[transfer.From] = balance.Confirmed
+			senders = append(senders, transfer.From)
+		}
+
+		if committed[transfer.From]+transfer.Amount > balances[transfer.From] {
+			results[i].Error = fmt.Sprintf("not enough funds: %s has %d, and earlier transfers in the batch already send %d", transfer.From, balances[transfer.From], committed[transfer.From])
+			continue
+		}
+		committed[transfer.From] += transfer.Amount
+		accepted[transfer.From] = append(accepted[transfer.From], i)
+	}
+
+	for _, from := range senders {
+		idxs := accepted[from]
+		if len(idxs) == 0 {
+			continue
+		}
+
+		recipients := make([]reps.Recipient, 0, len(idxs))
+		for _, i := range idxs {
+			recipients = append(recipients, reps.Recipient{To: transfers[i].To, Amount: transfers[i].Amount})
+		}
+
+		txn, err := ms.transactionService.CreateTransactionToRecipients(from, recipients, opts)
+		if err == nil {
+			_, err = ms.AddTransaction(txn)
+		}
+
+		for _, i := range idxs {
+			if err != nil {
+				results[i].Error = err.Error()
+			} else {
+				results[i].Success = true
+				results[i].TxnID = hex.EncodeToString(txn.ID)
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// Every pending transaction, oldest first
+func (ms *mempoolService) GetEntries() []reps.MempoolEntry {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.expireLocked(time.Now().UnixMilli())
+
+	entries := make([]reps.MempoolEntry, 0, len(ms.entries))
+	for _, entry := range ms.entries {
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].AddedAt < entries[j].AddedAt
+	})
+
+	return entries
+}
+
+func (ms *mempoolService) GetEntry(txnId string) (reps.MempoolEntry, bool) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	entry, ok := ms.entries[txnId]
+	return entry, ok
+}
+
+func (ms *mempoolService) Size() int {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	return len(ms.entries)
+}
+
+// What's pending, and how much has been evicted since the node started
+func (ms *mempoolService) GetStats() reps.MempoolStats {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.expireLocked(time.Now().UnixMilli())
+
+	stats := reps.MempoolStats{
+		Count:           len(ms.entries),
+		MaxSize:         MaxMempoolSize,
+		TTL:             int64(MempoolTTL.Seconds()),
+		Expired:         ms.expired,
+		EvictedForSpace: ms.evictedForSpace,
+		Replaced:        ms.replaced,
+	}
+	for _, entry := range ms.entries {
+		stats.TotalFees += entry.Fee
+		stats.TotalSize += entry.Size
+	}
+
+	return stats
+}
+
+// Drop a transaction from the mempool, because it was mined or can no longer be
+func (ms *mempoolService) remove(txnId string) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.removeLocked(txnId)
+}
+
+// Must hold mu
+func (ms *mempoolService) removeLocked(txnId string) {
+	if entry, ok := ms.entries[txnId]; ok {
+		for _, input := range entry.Transaction.Inputs {
+			delete(ms.spending, reps.OutpointID(input.PrevTxnID, input.OutIdx))
+		}
+		delete(ms.entries, txnId)
+	}
+
+	if err := ms.mempoolRepo.DeleteEntry(txnId); err != nil {
+		log.Error("error removing transaction from persisted mempool: ", err.Error())
+	}
+}
+
+// Mine a block from the pending transactions paying the highest fee rates, up to MaxBlockTxns of them.
+// Transactions that stopped being valid since they were queued, e.g. because a block spent their inputs, are dropped.
+// The coinbase carries message, or CoinbaseMessage without one
+func (ms *mempoolService) MinePendingTransactions(miner string, message string) (reps.Block, error) {
+	log.Info("Mining mempool into a block for miner: ", miner)
+	if !IsValidAddress(miner, ms.params.NetworkByte) {
+		return reps.Block{}, fmt.Errorf("malformed address: %s", miner)
+	}
+
+	ms.miningMu.Lock()
+	defer ms.miningMu.Unlock()
+
+	selected, err := ms.selectTransactions()
+	if err != nil {
+		return reps.Block{}, err
+	}
+
+	block, err := ms.blockchainService.MineTransactions(selected, miner, message)
+	if err != nil {
+		return reps.Block{}, err
+	}
+
+	ms.confirmBlock(block)
+
+	log.Infof("Mined %d transactions from the mempool, %d still pending", len(selected), ms.Size())
+	return block, nil
+}
+
+// Template of the block MinePendingTransactions would mine, for mining elsewhere. Kept until it's submitted, or
+// MaxBlockTemplates newer ones are handed out
+func (ms *mempoolService) GetBlockTemplate(miner string, message string) (reps.BlockTemplate, error) {
+	log.Info("Assembling block template for miner: ", miner)
+	if !IsValidAddress(miner, ms.params.NetworkByte) {
+		return reps.BlockTemplate{}, fmt.Errorf("malformed address: %s", miner)
+	}
+	if HasProposers(ms.params) {
+		return reps.BlockTemplate{}, fmt.Errorf("there's no proof of work to solve on a %s chain, its blocks are signed by elected validators", ms.params.Consensus)
+	}
+
+	ms.miningMu.Lock()
+	defer ms.miningMu.Unlock()
+
+	selected, err := ms.selectTransactions()
+	if err != nil {
+		return reps.BlockTemplate{}, err
+	}
+
+	template, err := ms.blockchainService.AssembleBlock(selected, miner, message)
+	if err != nil {
+		return reps.BlockTemplate{}, err
+	}
+
+	// Templates on top of an older block are kept, so if they're solved they're recorded as stale
+	ms.templates[template.ID] = template
+	ms.templateOrder = append(ms.templateOrder, template.ID)
+	for len(ms.templates) > MaxBlockTemplates {
+		delete(ms.templates, ms.templateOrder[0])
+		ms.templateOrder = ms.templateOrder[1:]
+	}
+
+	return BlockAssembler.ToBlockTemplate(template, chainHasher(ms.params)), nil
+}
+
+// Add the block for a template handed out by GetBlockTemplate, with the nounce solving it. A non-zero
+// timestamp replaces the template's, for miners that run through every nounce
+func (ms *mempoolService) SubmitBlock(input reps.SubmitBlockInput) (reps.Block, error) {
+	log.WithFields(log.Fields{"templateId": input.TemplateID, "nounce": input.Nounce}).Info("Block submitted")
+
+	ms.miningMu.Lock()
+	defer ms.miningMu.Unlock()
+
+	block, ok := ms.templates[input.TemplateID]
+	if !ok {
+		return reps.Block{}, fmt.Errorf("%w: template id %s", ErrStaleTemplate, input.TemplateID)
+	}
+
+	block.Nounce = input.Nounce
+	if input.Timestamp != 0 {
+		block.Timestamp = input.Timestamp
+	}
+	block.Hash = NewProofOfWorkService(&block, chainHasher(ms.params)).HashData()
+
+	if err := ms.blockchainService.SubmitBlock(block); err != nil {
+		return reps.Block{}, err
+	}
+
+	ms.dropTemplate(block.ID)
+	ms.confirmBlock(block)
+
+	log.Infof("Added submitted block %s with %d transactions, %d still pending", block.ID, len(block.Transactions)-1, ms.Size())
+	return block, nil
+}
+
+// Add a block received from elsewhere, along with any orphans that were waiting on it, and take their transactions
+// out of the mempool. If the chain is reorganized, transactions on the blocks taken off it go back in the mempool,
+// unless the new chain has them too or they no longer check out. A proposer caught signing another block on the same
+// parent has its stake slashed by a transaction queued here
+func (ms *mempoolService) ReceiveBlock(block reps.Block) (reps.ChainUpdate, error) {
+	log.WithFields(log.Fields{"hash": hex.EncodeToString(block.Hash), "height": block.Height}).Info("Block received")
+
+	ms.miningMu.Lock()
+	defer ms.miningMu.Unlock()
+
+	update, err := ms.blockchainService.ProcessBlock(block)
+	if err != nil {
+		return reps.ChainUpdate{}, err
+	}
+
+	confirmed := make(map[string]bool)
+	for _, block := range update.Connected {
+		ms.confirmBlock(block)
+		for _, txn := range block.Transactions {
+			confirmed[hex.EncodeToString(txn.ID)] = true
+		}
+	}
+
+	for _, block := range update.Disconnected {
+		for _, txn := range block.Transactions {
+			if ms.transactionService.IsCoinbaseTransaction(txn) || confirmed[hex.EncodeToString(txn.ID)] {
+				continue
+			}
+			if _, err := ms.AddTransaction(txn); err != nil {
+				log.WithFields(log.Fields{"txnId": hex.EncodeToString(txn.ID), "error": err.Error()}).Info("Dropping transaction from block taken off the chain")
+			}
+		}
+	}
+
+	for _, txn := range update.Slashings {
+		if _, err := ms.AddTransaction(txn); err != nil {
+			log.WithFields(log.Fields{"txnId": hex.EncodeToString(txn.ID), "error": err.Error()}).Error("error queueing slashing transaction")
+		}
+	}
+
+	return update, nil
+}
+
+// Pending transactions paying the highest fee rates, up to MaxBlockTxns of them and the chain's MaxBlockSize,
+// that can go on the next block together.
+// Must hold miningMu
+func (ms *mempoolService) selectTransactions() ([]reps.Transaction, error) {
+	entries := ms.GetEntries()
+
+	// Highest fee rate first, then oldest first
+	sort.SliceStable(entries, func(i, j int) bool {
+		return higherFeeRate(entries[i], entries[j])
+	})
+
+	height, err := ms.blockchainService.GetNextBlockHeight()
+	if err != nil {
+		return nil, err
+	}
+	blockTime := time.Now().UnixMilli()
+
+	selected := make([]reps.Transaction, 0)
+	spending := make(map[string]bool)
+	issued := make(map[string]int)   // Units of each asset the selected transactions issue
+	changed := make(map[string]bool) // Validators the selected transactions change
+	size := BlockSizeAllowance
+
+Entries:
+	for _, entry := range entries {
+		if len(selected) >= MaxBlockTxns {
+			break
+		}
+
+		if _, err := ms.transactionService.VerifyTransaction(entry.Transaction); err != nil {
+			log.Warnf("Dropping transaction %s from mempool: %s", entry.TxnID, err.Error())
+			ms.remove(entry.TxnID)
+			continue
+		}
+
+		// Stays queued until its lock time passes
+		if !IsFinalTransaction(entry.Transaction, height, blockTime) {
+			continue
+		}
+
+		// A smaller one further down might still fit
+		txnSize := blockTxnSize(entry.Transaction)
+		if ms.params.MaxBlockSize > 0 && size+txnSize > ms.params.MaxBlockSize {
+			continue
+		}
+
+		// Both can't go on the same block, the other one waits
+		for _, input := range entry.Transaction.Inputs {
+			if spending[reps.OutpointID(input.PrevTxnID, input.OutIdx)] {
+				continue Entries
+			}
+		}
+
+		// Nor can two changes to the same validator
+		validator := changedValidator(entry.Transaction)
+		if validator != "" && changed[validator] {
+			continue
+		}
+
+		// Nor can issuances that together go past an asset's supply controls
+		txnIssued, err := ms.transactionService.GetIssuedAssets(entry.Transaction)
+		if err != nil {
+			continue
+		}
+		for assetId, amount := range txnIssued {
+			if issued[assetId] > 0 && ms.transactionService.VerifyIssuance(assetId, issued[assetId]+amount) != nil {
+				continue Entries
+			}
+		}
+		for assetId, amount := range txnIssued {
+			issued[assetId] += amount
+		}
+
+		for _, input := range entry.Transaction.Inputs {
+			spending[reps.OutpointID(input.PrevTxnID, input.OutIdx)] = true
+		}
+		if validator != "" {
+			changed[validator] = true
+		}
+
+		selected = append(selected, entry.Transaction)
+		size += txnSize
+	}
+
+	return selected, nil
+}
+
+// Bytes txn adds to a block once it's on one and has the block's id
+func blockTxnSize(txn reps.Transaction) int {
+	txn.BlockID = uuid.Nil.String()
+	return len(TxnAssembler.ToTxnBytes(txn)) + 1
+}
+
+// Forget a template once its block is on the chain. Must hold miningMu
+func (ms *mempoolService) dropTemplate(id string) {
+	delete(ms.templates, id)
+	for i, other := range ms.templateOrder {
+		if other == id {
+			ms.templateOrder = append(ms.templateOrder[:i], ms.templateOrder[i+1:]...)
+			break
+		}
+	}
+}
+
+// Take transactions that made it onto block out of the mempool. Every template builds on an older block now,
+// so any submitted from here on is stale.
+// Must hold miningMu
+func (ms *mempoolService) confirmBlock(block reps.Block) {
+	for _, txn := range block.Transactions {
+		if !ms.transactionService.IsCoinbaseTransaction(txn) {
+			ms.remove(hex.EncodeToString(txn.ID))
+		}
+	}
+}
+
+// Confirmed balance of an address, and how pending transactions would change it
+func (ms *mempoolService) GetAddressBalance(address string) (reps.AddressBalanceSummary, error) {
+	summary, err := ms.transactionService.GetAddressBalance(address)
+	if err != nil {
+		return reps.AddressBalanceSummary{}, err
+	}
+
+	decoded := base58Decode([]byte(address))
+	pubKeyHash := decoded[1 : len(decoded)-ChecksumLen]
+
+	for _, entry := range ms.GetEntries() {
+		txn := entry.Transaction
+
+		for _, output := range txn.Outputs {
+			if !bytes.Equal(output.PubKeyHash, pubKeyHash) {
+				continue
+			}
+			if output.AssetID == "" {
+				summary.Pending += output.Value
+			} else {
+				ms.transactionService.AssetBalance(&summary, output.AssetID).Pending += output.Value
+			}
+		}
+
+		// Pending transactions only spend confirmed outputs, which the UTXO set has
+		prevTxns, err := ms.transactionService.GetPrevTransactions(txn)
+		if err != nil {
+			continue
+		}
+		for _, input := range txn.Inputs {
+			prevTxn := prevTxns[hex.EncodeToString(input.PrevTxnID)]
+			if input.OutIdx < 0 || input.OutIdx >= len(prevTxn.Outputs) || !bytes.Equal(prevTxn.Outputs[input.OutIdx].PubKeyHash, pubKeyHash) {
+				continue
+			}
+			if prevOutput := prevTxn.Outputs[input.OutIdx]; prevOutput.AssetID == "" {
+				summary.Pending -= prevOutput.Value
+			} else {
+				ms.transactionService.AssetBalance(&summary, prevOutput.AssetID).Pending -= prevOutput.Value
+			}
+		}
+	}
+
+	return summary, nil
+}
+
+// Whether a transaction is waiting in the mempool or on a block, and if so how deep that block is
+func (ms *mempoolService) GetTransactionStatus(txnId string) (reps.TxnStatus, error) {
+	log.Info("Getting status of transaction: ", txnId)
+	status := reps.TxnStatus{TxnID: txnId, Status: reps.TxnNotFound}
+
+	if _, err := hex.DecodeString(txnId); err != nil {
+		return reps.TxnStatus{}, fmt.Errorf("%s, invalid transaction id: %s", err.Error(), txnId)
+	}
+
+	if _, ok := ms.GetEntry(txnId); ok {
+		status.Status = reps.TxnPending
+		return status, nil
+	}
+
+	if replacement, err := ms.mempoolRepo.GetReplacement(txnId); err == nil {
+		status.Status = reps.TxnReplaced
+		status.ReplacedBy = replacement.ReplacedBy
+		return status, nil
+	}
+
+	location, err := ms.transactionService.GetTransactionLocation(txnId)
+	if err != nil {
+		return status, nil
+	}
+
+	nextHeight, err := ms.blockchainService.GetNextBlockHeight()
+	if err != nil {
+		return reps.TxnStatus{}, err
+	}
+
+	status.Status = reps.TxnConfirmed
+	status.BlockHash = hex.EncodeToString(location.BlockHash)
+	status.Height = location.Height
+	status.Confirmations = nextHeight - 1 - location.Height
+	return status, nil
+}
+
+// Load the persisted mempool, dropping anything that was mined or became invalid while the node was down
+func (ms *mempoolService) Restore() error {
+	entries, err := ms.mempoolRepo.GetEntries()
+	if err != nil {
+		return err
+	}
+
+	restored := 0
+	for _, entry := range entries {
+		var txn reps.Transaction
+		if err := json.Unmarshal(entry.Data, &txn); err != nil {
+			log.Warnf("Dropping malformed transaction %s from mempool: %s", entry.TxnID, err.Error())
+			ms.mempoolRepo.DeleteEntry(entry.TxnID)
+			continue
+		}
+
+		if _, err := ms.transactionService.VerifyTransaction(txn); err != nil {
+			log.Warnf("Dropping transaction %s from mempool: %s", entry.TxnID, err.Error())
+			ms.mempoolRepo.DeleteEntry(entry.TxnID)
+			continue
+		}
+
+		entry.Transaction = txn
+		ms.mu.Lock()
+		err := ms.checkConflicts(txn)
+		if err == nil {
+			ms.add(entry)
+		}
+		ms.mu.Unlock()
+		if err != nil {
+			log.Warnf("Dropping transaction %s from mempool: %s", entry.TxnID, err.Error())
+			ms.mempoolRepo.DeleteEntry(entry.TxnID)
+			continue
+		}
+		restored++
+	}
+
+	log.Infof("Restored %d of %d pending transactions to the mempool", restored, len(entries))
+	return nil
+}
+
+// Reload the mempool saved before the last shutdown. MEMPOOL_TTL, e.g. 24h, and MEMPOOL_MAX_SIZE
+// override how long transactions can wait and how many can wait at once, and MAX_BLOCK_TXNS how many are mined per block
+func RestoreMempoolAtStartup(mempoolService MempoolService) {
+	if envTTL := os.Getenv("MEMPOOL_TTL"); envTTL != "" {
+		ttl, err := time.ParseDuration(envTTL)
+		if err != nil || ttl <= 0 {
+			log.Warn("Invalid MEMPOOL_TTL, using default of ", MempoolTTL)
+		} else {
+			MempoolTTL = ttl
+		}
+	}
+
+	if envMaxSize := os.Getenv("MEMPOOL_MAX_SIZE"); envMaxSize != "" {
+		maxSize, err := strconv.Atoi(envMaxSize)
+		if err != nil || maxSize <= 0 {
+			log.Warn("Invalid MEMPOOL_MAX_SIZE, using default of ", MaxMempoolSize)
+		} else {
+			MaxMempoolSize = maxSize
+		}
+	}
+
+	if envMaxBlockTxns := os.Getenv("MAX_BLOCK_TXNS"); envMaxBlockTxns != "" {
+		maxBlockTxns, err := strconv.Atoi(envMaxBlockTxns)
+		if err != nil || maxBlockTxns <= 0 {
+			log.Warn("Invalid MAX_BLOCK_TXNS, using default of ", MaxBlockTxns)
+		} else {
+			MaxBlockTxns = maxBlockTxns
+		}
+	}
+
+	if err := mempoolService.Restore(); err != nil {
+		log.Fatal("Error restoring mempool: ", err.Error())
+	}
+}
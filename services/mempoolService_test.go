@@ -0,0 +1,844 @@
+package services_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMinePendingTransactionsConfirmsMempool(t *testing.T) {
+	ts := newTestServices(t)
+	repo, mempoolRepo, walletService := ts.repo, ts.mempoolRepo, ts.walletService
+	txnService, blockchainService, mempoolService := ts.txnService, ts.blockchainService, ts.mempoolService
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	txn, err := txnService.CreateTransaction(from.Address, to.Address, 10)
+	assert.NoError(t, err)
+	_, err = mempoolService.AddTransaction(txn)
+	assert.NoError(t, err)
+
+	// Queued once only, and nothing is confirmed until it's mined
+	_, err = mempoolService.AddTransaction(txn)
+	assert.Error(t, err)
+	assert.Len(t, mempoolRepo.entries, 1)
+
+	balance, err := mempoolService.GetAddressBalance(to.Address)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, balance.Confirmed)
+	assert.Equal(t, 10, balance.Pending)
+
+	balance, err = mempoolService.GetAddressBalance(from.Address)
+	assert.NoError(t, err)
+	assert.Equal(t, services.Reward, balance.Confirmed)
+	assert.Equal(t, -10, balance.Pending)
+
+	// A restarted node picks up where it left off
+	restarted := services.NewMempoolService(mempoolRepo, txnService, blockchainService, &mainnet)
+	assert.NoError(t, restarted.Restore())
+	_, ok := restarted.GetEntry(hex.EncodeToString(txn.ID))
+	assert.True(t, ok)
+
+	status, err := mempoolService.GetTransactionStatus(hex.EncodeToString(txn.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, reps.TxnPending, status.Status)
+
+	block, err := mempoolService.MinePendingTransactions(from.Address, "")
+	assert.NoError(t, err)
+	assert.Len(t, block.Transactions, 2)
+	assert.Equal(t, 0, mempoolService.Size())
+	assert.Empty(t, mempoolRepo.entries)
+
+	balance, err = mempoolService.GetAddressBalance(to.Address)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, balance.Confirmed)
+	assert.Equal(t, 0, balance.Pending)
+
+	status, err = mempoolService.GetTransactionStatus(hex.EncodeToString(txn.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, reps.TxnConfirmed, status.Status)
+	assert.Equal(t, hex.EncodeToString(block.Hash), status.BlockHash)
+	assert.Equal(t, 1, status.Height)
+	assert.Equal(t, 0, status.Confirmations)
+
+	status, err = mempoolService.GetTransactionStatus(hex.EncodeToString([]byte("unknown")))
+	assert.NoError(t, err)
+	assert.Equal(t, reps.TxnNotFound, status.Status)
+}
+
+func TestAddTransactionRejectsMempoolConflict(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+	mempoolService := ts.mempoolService
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	accepted := make([]reps.Transaction, 0)
+	mempoolService.OnTransactionAccepted(func(txn reps.Transaction) {
+		accepted = append(accepted, txn)
+	})
+
+	// Both spend the one output from has
+	first, err := txnService.CreateTransaction(from.Address, to.Address, 10)
+	assert.NoError(t, err)
+	second, err := txnService.CreateTransaction(from.Address, to.Address, 20)
+	assert.NoError(t, err)
+
+	_, err = mempoolService.AddTransaction(first)
+	assert.NoError(t, err)
+
+	_, err = mempoolService.AddTransaction(second)
+	var verificationErr *services.TxnVerificationError
+	assert.ErrorAs(t, err, &verificationErr)
+	assert.Equal(t, services.InvalidTxnMempoolConflict, verificationErr.Reason)
+	assert.Equal(t, 0, verificationErr.InputIndex)
+	assert.Equal(t, 1, mempoolService.Size())
+
+	// Only the transaction let in is passed on
+	assert.Len(t, accepted, 1)
+	assert.Equal(t, first.ID, accepted[0].ID)
+}
+
+func TestSubmitBatchCombinesTransfersFromOneAddress(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+	mempoolService := ts.mempoolService
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	other, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	results, err := mempoolService.SubmitBatch([]reps.Transfer{
+		{From: from.Address, To: to.Address, Amount: 10},
+		{From: from.Address, To: other.Address, Amount: services.Reward},
+		{From: from.Address, To: "not an address", Amount: 1},
+		{From: from.Address, To: other.Address, Amount: 20},
+	}, reps.TxnOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, results, 4)
+
+	// The second is more than what's left after the first, the third goes nowhere
+	assert.True(t, results[0].Success)
+	assert.False(t, results[1].Success)
+	assert.NotEmpty(t, results[1].Error)
+	assert.False(t, results[2].Success)
+	assert.True(t, results[3].Success)
+	assert.Equal(t, results[0].TxnID, results[3].TxnID)
+	assert.Equal(t, 1, mempoolService.Size())
+
+	balance, err := mempoolService.GetAddressBalance(other.Address)
+	assert.NoError(t, err)
+	assert.Equal(t, 20, balance.Pending)
+}
+
+func TestMinePendingTransactionsWaitsForLockTime(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+	blockchainService, mempoolService := ts.blockchainService, ts.mempoolService
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	// The next block is at height 1, so this has to wait for the one after
+	txn, err := txnService.CreateTransactionToRecipients(from.Address, []reps.Recipient{{To: to.Address, Amount: 10}}, reps.TxnOptions{LockTime: 2})
+	assert.NoError(t, err)
+	_, err = mempoolService.AddTransaction(txn)
+	assert.NoError(t, err)
+
+	// Mined directly, it's rejected outright
+	_, err = blockchainService.MineTransactions([]reps.Transaction{txn}, from.Address, "")
+	var verificationErr *services.TxnVerificationError
+	assert.ErrorAs(t, err, &verificationErr)
+	assert.Equal(t, services.InvalidTxnLocked, verificationErr.Reason)
+
+	block, err := mempoolService.MinePendingTransactions(from.Address, "")
+	assert.NoError(t, err)
+	assert.Len(t, block.Transactions, 1)
+	assert.Equal(t, 1, mempoolService.Size())
+
+	block, err = mempoolService.MinePendingTransactions(from.Address, "")
+	assert.NoError(t, err)
+	assert.Len(t, block.Transactions, 2)
+	assert.Equal(t, 0, mempoolService.Size())
+}
+
+func TestValidateTransactionLeavesMempoolAlone(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+	mempoolService := ts.mempoolService
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	txn, err := txnService.CreateTransactionToRecipients(from.Address, []reps.Recipient{{To: to.Address, Amount: 10}}, reps.TxnOptions{Fee: 2})
+	assert.NoError(t, err)
+
+	validation, err := mempoolService.ValidateTransaction(txn)
+	assert.NoError(t, err)
+	assert.True(t, validation.Valid)
+	assert.Equal(t, 2, validation.Fee)
+	assert.Len(t, validation.Consumed, 1)
+	assert.Equal(t, services.Reward, validation.Consumed[0].Value)
+	assert.Equal(t, 0, mempoolService.Size())
+
+	// Once it's pending, anything else spending the same output fails
+	_, err = mempoolService.AddTransaction(txn)
+	assert.NoError(t, err)
+	other, err := txnService.CreateTransaction(from.Address, to.Address, 5)
+	assert.NoError(t, err)
+
+	_, err = mempoolService.ValidateTransaction(other)
+	var verificationErr *services.TxnVerificationError
+	assert.ErrorAs(t, err, &verificationErr)
+	assert.Equal(t, services.InvalidTxnMempoolConflict, verificationErr.Reason)
+}
+
+func TestAddTransactionReplacesForHigherFee(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+	mempoolService := ts.mempoolService
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+	recipients := []reps.Recipient{{To: to.Address, Amount: 10}}
+
+	original, err := txnService.CreateTransactionToRecipients(from.Address, recipients, reps.TxnOptions{Fee: 2, Replaceable: true})
+	assert.NoError(t, err)
+	_, err = mempoolService.AddTransaction(original)
+	assert.NoError(t, err)
+
+	// Has to pay more than the original
+	cheaper, err := txnService.CreateTransactionToRecipients(from.Address, recipients, reps.TxnOptions{Fee: 2})
+	assert.NoError(t, err)
+	_, err = mempoolService.AddTransaction(cheaper)
+	var verificationErr *services.TxnVerificationError
+	assert.ErrorAs(t, err, &verificationErr)
+	assert.Equal(t, services.InvalidTxnReplacementFee, verificationErr.Reason)
+
+	replacement, err := txnService.CreateTransactionToRecipients(from.Address, recipients, reps.TxnOptions{Fee: 5})
+	assert.NoError(t, err)
+	_, err = mempoolService.AddTransaction(replacement)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, mempoolService.Size())
+
+	status, err := mempoolService.GetTransactionStatus(hex.EncodeToString(original.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, reps.TxnReplaced, status.Status)
+	assert.Equal(t, hex.EncodeToString(replacement.ID), status.ReplacedBy)
+
+	// The replacement didn't opt in, so it stays
+	another, err := txnService.CreateTransactionToRecipients(from.Address, recipients, reps.TxnOptions{Fee: 9})
+	assert.NoError(t, err)
+	_, err = mempoolService.AddTransaction(another)
+	assert.ErrorAs(t, err, &verificationErr)
+	assert.Equal(t, services.InvalidTxnMempoolConflict, verificationErr.Reason)
+}
+
+func TestMempoolEvictsLowestFeeRateWhenFull(t *testing.T) {
+	defer func(maxSize int) { services.MaxMempoolSize = maxSize }(services.MaxMempoolSize)
+	services.MaxMempoolSize = 1
+
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+	mempoolService := ts.mempoolService
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+	repo.blocks = append(repo.blocks, reps.Block{ID: "next", PrevHash: []byte("genesis"), Timestamp: 1, Transactions: []reps.Transaction{txnService.CreateCoinbaseTxn(to.Address, "")}})
+
+	cheap, err := txnService.CreateTransactionToRecipients(from.Address, []reps.Recipient{{To: to.Address, Amount: 10}}, reps.TxnOptions{Fee: 1})
+	assert.NoError(t, err)
+	_, err = mempoolService.AddTransaction(cheap)
+	assert.NoError(t, err)
+
+	// Paying no more than what's there doesn't get in
+	free, err := txnService.CreateTransactionToRecipients(to.Address, []reps.Recipient{{To: from.Address, Amount: 10}}, reps.TxnOptions{})
+	assert.NoError(t, err)
+	_, err = mempoolService.AddTransaction(free)
+	assert.ErrorIs(t, err, services.ErrMempoolFull)
+
+	pricey, err := txnService.CreateTransactionToRecipients(to.Address, []reps.Recipient{{To: from.Address, Amount: 10}}, reps.TxnOptions{Fee: 20})
+	assert.NoError(t, err)
+	_, err = mempoolService.AddTransaction(pricey)
+	assert.NoError(t, err)
+
+	_, ok := mempoolService.GetEntry(hex.EncodeToString(cheap.ID))
+	assert.False(t, ok)
+	stats := mempoolService.GetStats()
+	assert.Equal(t, 1, stats.Count)
+	assert.Equal(t, 20, stats.TotalFees)
+	assert.Equal(t, 1, stats.EvictedForSpace)
+}
+
+func TestMempoolExpiresOldTransactions(t *testing.T) {
+	defer func(ttl time.Duration) { services.MempoolTTL = ttl }(services.MempoolTTL)
+	services.MempoolTTL = time.Millisecond
+
+	ts := newTestServices(t)
+	repo, mempoolRepo, walletService := ts.repo, ts.mempoolRepo, ts.walletService
+	txnService, mempoolService := ts.txnService, ts.mempoolService
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	txn, err := txnService.CreateTransaction(from.Address, to.Address, 10)
+	assert.NoError(t, err)
+	_, err = mempoolService.AddTransaction(txn)
+	assert.NoError(t, err)
+
+	time.Sleep(5 * time.Millisecond)
+	stats := mempoolService.GetStats()
+	assert.Equal(t, 0, stats.Count)
+	assert.Equal(t, 1, stats.Expired)
+	assert.Empty(t, mempoolRepo.entries)
+}
+
+func TestDecodeRawTransactionAcceptsHexAndBase64(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	txn, err := txnService.CreateTransaction(from.Address, to.Address, 10)
+	assert.NoError(t, err)
+	data, err := json.Marshal(txn)
+	assert.NoError(t, err)
+
+	for _, raw := range []string{hex.EncodeToString(data), base64.StdEncoding.EncodeToString(data)} {
+		decoded, err := services.DecodeRawTransaction(raw)
+		assert.NoError(t, err)
+		assert.Equal(t, txn.ID, decoded.ID)
+
+		valid, err := txnService.VerifyTransaction(decoded)
+		assert.NoError(t, err)
+		assert.True(t, valid)
+	}
+
+	_, err = services.DecodeRawTransaction("not a transaction")
+	assert.Error(t, err)
+}
+
+func TestMinePendingTransactionsFillsBlockByFeeRate(t *testing.T) {
+	defer func(maxBlockTxns int) { services.MaxBlockTxns = maxBlockTxns }(services.MaxBlockTxns)
+	services.MaxBlockTxns = 2
+
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+	mempoolService := ts.mempoolService
+
+	senders := make([]reps.Wallet, 0)
+	for i := 0; i < 3; i++ {
+		sender, err := walletService.CreateWallet()
+		assert.NoError(t, err)
+		senders = append(senders, sender)
+	}
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, senders[0].Address)
+	repo.blocks = append(repo.blocks,
+		reps.Block{ID: "second", Hash: []byte("second"), PrevHash: []byte("genesis"), Timestamp: 1, Transactions: []reps.Transaction{txnService.CreateCoinbaseTxn(senders[1].Address, "")}},
+		reps.Block{ID: "third", Hash: []byte("third"), PrevHash: []byte("second"), Timestamp: 2, Transactions: []reps.Transaction{txnService.CreateCoinbaseTxn(senders[2].Address, "")}})
+
+	// Senders don't share outputs, so only the fees decide what's mined first
+	txns := make([]reps.Transaction, 0)
+	for i, fee := range []int{1, 5, 3} {
+		txn, err := txnService.CreateTransactionToRecipients(senders[i].Address, []reps.Recipient{{To: to.Address, Amount: 10}}, reps.TxnOptions{Fee: fee})
+		assert.NoError(t, err)
+		_, err = mempoolService.AddTransaction(txn)
+		assert.NoError(t, err)
+		txns = append(txns, txn)
+	}
+
+	block, err := mempoolService.MinePendingTransactions(to.Address, "")
+	assert.NoError(t, err)
+	assert.Len(t, block.Transactions, 3)
+	assert.Equal(t, txns[1].ID, block.Transactions[1].ID)
+	assert.Equal(t, txns[2].ID, block.Transactions[2].ID)
+	assert.Equal(t, services.Reward+8, block.Transactions[0].Outputs[0].Value)
+
+	// The cheapest waits for the next block
+	_, ok := mempoolService.GetEntry(hex.EncodeToString(txns[0].ID))
+	assert.True(t, ok)
+
+	block, err = mempoolService.MinePendingTransactions(to.Address, "")
+	assert.NoError(t, err)
+	assert.Len(t, block.Transactions, 2)
+	assert.Equal(t, txns[0].ID, block.Transactions[1].ID)
+	assert.Equal(t, 0, mempoolService.Size())
+}
+
+func TestSubmitBlockSolvedFromTemplate(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+	mempoolService := ts.mempoolService
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	txn, err := txnService.CreateTransactionToRecipients(from.Address, []reps.Recipient{{To: to.Address, Amount: 10}}, reps.TxnOptions{Fee: 2})
+	assert.NoError(t, err)
+	_, err = mempoolService.AddTransaction(txn)
+	assert.NoError(t, err)
+
+	template, err := mempoolService.GetBlockTemplate(to.Address, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, template.Height)
+	assert.Equal(t, services.HashSHA256, template.HashAlgorithm)
+	assert.Equal(t, services.Reward+2, template.CoinbaseValue)
+	assert.Len(t, template.Transactions, 2)
+	assert.Len(t, repo.blocks, 1)
+
+	// Solved with nothing but the template
+	headerPrefix, err := hex.DecodeString(template.HeaderPrefix)
+	assert.NoError(t, err)
+	target, err := hex.DecodeString(template.Target)
+	assert.NoError(t, err)
+	nounce := int64(0)
+	for {
+		hash := sha256.Sum256(append(append([]byte{}, headerPrefix...), []byte(strconv.FormatInt(nounce, 10))...))
+		if bytes.Compare(hash[:], target) < 0 {
+			break
+		}
+		nounce++
+	}
+
+	if nounce > 0 {
+		_, err = mempoolService.SubmitBlock(reps.SubmitBlockInput{TemplateID: template.TemplateID, Nounce: nounce - 1})
+		assert.ErrorIs(t, err, services.ErrInvalidBlock)
+	}
+
+	submitted, err := mempoolService.SubmitBlock(reps.SubmitBlockInput{TemplateID: template.TemplateID, Nounce: nounce})
+	assert.NoError(t, err)
+	assert.Equal(t, template.TemplateID, submitted.ID)
+	assert.Len(t, repo.blocks, 2)
+	assert.Equal(t, 0, mempoolService.Size())
+
+	// Its template, like any other built on the old last block, is spent
+	_, err = mempoolService.SubmitBlock(reps.SubmitBlockInput{TemplateID: template.TemplateID, Nounce: nounce})
+	assert.ErrorIs(t, err, services.ErrStaleTemplate)
+}
+
+// Nounce that solves template, found with nothing but the template
+func solveTemplate(t *testing.T, template reps.BlockTemplate) int64 {
+	headerPrefix, err := hex.DecodeString(template.HeaderPrefix)
+	assert.NoError(t, err)
+	target, err := hex.DecodeString(template.Target)
+	assert.NoError(t, err)
+
+	for nounce := int64(0); ; nounce++ {
+		hash := sha256.Sum256(append(append([]byte{}, headerPrefix...), []byte(strconv.FormatInt(nounce, 10))...))
+		if bytes.Compare(hash[:], target) < 0 {
+			return nounce
+		}
+	}
+}
+
+func TestSubmitBlockRecordsBlocksThatLoseForkRace(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+	blockchainService, mempoolService := ts.blockchainService, ts.mempoolService
+
+	miner, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, miner.Address)
+
+	// Two miners working on the same parent
+	first, err := mempoolService.GetBlockTemplate(miner.Address, "")
+	assert.NoError(t, err)
+	second, err := mempoolService.GetBlockTemplate(miner.Address, "")
+	assert.NoError(t, err)
+	assert.Equal(t, first.PrevHash, second.PrevHash)
+
+	winner, err := mempoolService.SubmitBlock(reps.SubmitBlockInput{TemplateID: first.TemplateID, Nounce: solveTemplate(t, first)})
+	assert.NoError(t, err)
+
+	_, err = mempoolService.SubmitBlock(reps.SubmitBlockInput{TemplateID: second.TemplateID, Nounce: solveTemplate(t, second)})
+	assert.ErrorIs(t, err, services.ErrStaleTemplate)
+	assert.Len(t, repo.blocks, 2)
+
+	staleBlocks, total, err := blockchainService.GetStaleBlocks(0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Len(t, staleBlocks, 1)
+	assert.Equal(t, second.TemplateID, staleBlocks[0].BlockID)
+	assert.Equal(t, 1, staleBlocks[0].Height)
+	assert.Equal(t, hex.EncodeToString(winner.Hash), staleBlocks[0].WinnerHash)
+	assert.Equal(t, first.PrevHash, staleBlocks[0].PrevHash)
+
+	// The winner's template is spent, but it didn't lose anything
+	_, err = mempoolService.SubmitBlock(reps.SubmitBlockInput{TemplateID: first.TemplateID, Nounce: solveTemplate(t, first)})
+	assert.ErrorIs(t, err, services.ErrStaleTemplate)
+	_, total, err = blockchainService.GetStaleBlocks(0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, total)
+
+	_, _, err = blockchainService.GetStaleBlocks(services.MaxBlocksPerPage + 1)
+	assert.Error(t, err)
+}
+
+func TestMinedBlocksCarryCoinbaseMessage(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+	mempoolService := ts.mempoolService
+
+	miner, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, miner.Address)
+
+	first, err := mempoolService.MinePendingTransactions(miner.Address, "mined by pool #1")
+	assert.NoError(t, err)
+	second, err := mempoolService.MinePendingTransactions(miner.Address, "mined by pool #1")
+	assert.NoError(t, err)
+	assert.Equal(t, "mined by pool #1", services.BlockAssembler.ToReadableBlock(first).CoinbaseMessage)
+	assert.Equal(t, "mined by pool #1", services.BlockAssembler.ToReadableBlock(second).CoinbaseMessage)
+
+	// The extranonce keeps coinbases with the same message apart
+	assert.NotEqual(t, first.Transactions[0].ID, second.Transactions[0].ID)
+
+	// The node's own message, unless the miner gives one
+	defer func(message string) { services.CoinbaseMessage = message }(services.CoinbaseMessage)
+	services.CoinbaseMessage = "node operator"
+	template, err := mempoolService.GetBlockTemplate(miner.Address, "")
+	assert.NoError(t, err)
+	solved, err := mempoolService.SubmitBlock(reps.SubmitBlockInput{TemplateID: template.TemplateID, Nounce: solveTemplate(t, template)})
+	assert.NoError(t, err)
+	assert.Equal(t, "node operator", services.BlockAssembler.ToReadableBlock(solved).CoinbaseMessage)
+
+	_, err = mempoolService.MinePendingTransactions(miner.Address, strings.Repeat("x", services.MaxCoinbaseMessageLen+1))
+	assert.Error(t, err)
+
+	// Coinbases with nothing but random data have no message
+	assert.Empty(t, services.CoinbaseMessageOf(txnService.CreateCoinbaseTxn(miner.Address, "")))
+	assert.Equal(t, "First transaction in Blockchain", services.CoinbaseMessageOf(txnService.CreateCoinbaseTxn(miner.Address, "First transaction in Blockchain")))
+}
+
+func TestReceiveBlockHoldsOrphansUntilParentArrives(t *testing.T) {
+	ts := newTestServices(t)
+	repo, keystore, walletService := ts.repo, ts.keystore, ts.walletService
+	txnService, blockchainService, mempoolService := ts.txnService, ts.blockchainService, ts.mempoolService
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	txn, err := txnService.CreateTransactionToRecipients(from.Address, []reps.Recipient{{To: to.Address, Amount: 10}}, reps.TxnOptions{Fee: 2})
+	assert.NoError(t, err)
+	_, err = mempoolService.AddTransaction(txn)
+	assert.NoError(t, err)
+
+	// A peer with the same genesis mines two blocks, which show up out of order
+	peerRepo := newFakeBlockchainRepository()
+	peerRepo.blocks = append(peerRepo.blocks, repo.blocks...)
+	peerTxnService := services.NewTransactionService(peerRepo, walletService, nil, services.NewLocalSigner(keystore), &mainnet)
+	peer := services.NewBlockchainService(peerRepo, services.NewBlockService(peerRepo, &mainnet), peerTxnService, walletService, &mainnet)
+	first, err := peer.MineTransactions([]reps.Transaction{txn}, to.Address, "")
+	assert.NoError(t, err)
+	second, err := peer.MineTransactions([]reps.Transaction{}, to.Address, "")
+	assert.NoError(t, err)
+
+	update, err := mempoolService.ReceiveBlock(second)
+	assert.NoError(t, err)
+	assert.True(t, update.Orphaned)
+	assert.Empty(t, update.Connected)
+	assert.Len(t, repo.blocks, 1)
+	info, err := blockchainService.GetChainInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, info.Orphans)
+
+	_, err = mempoolService.ReceiveBlock(second)
+	assert.ErrorIs(t, err, services.ErrKnownBlock)
+
+	// Orphans still have to do the work they claim to
+	tampered := second
+	tampered.ID = "tampered"
+	tampered.Nounce++
+	tampered.Hash = []byte("tampered")
+	_, err = mempoolService.ReceiveBlock(tampered)
+	assert.ErrorIs(t, err, services.ErrInvalidBlock)
+
+	// Its parent brings it along
+	update, err = mempoolService.ReceiveBlock(first)
+	assert.NoError(t, err)
+	assert.Len(t, update.Connected, 2)
+	assert.Equal(t, first.ID, update.Connected[0].ID)
+	assert.Equal(t, second.ID, update.Connected[1].ID)
+	assert.Len(t, repo.blocks, 3)
+	assert.Equal(t, 0, mempoolService.Size())
+
+	info, err = blockchainService.GetChainInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, info.Orphans)
+	assert.Equal(t, 2, info.Height)
+
+	_, err = mempoolService.ReceiveBlock(first)
+	assert.ErrorIs(t, err, services.ErrKnownBlock)
+}
+
+func TestReceiveBlockRejectsBlocksWithNothingToHash(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService, mempoolService := ts.repo, ts.walletService, ts.txnService, ts.mempoolService
+
+	miner, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, miner.Address)
+
+	empty := reps.Block{ID: "empty", PrevHash: []byte("genesis"), Height: 1, Hash: []byte("empty"), ChainID: services.ChainIdentifier(&mainnet)}
+	_, err = mempoolService.ReceiveBlock(empty)
+	assert.ErrorIs(t, err, services.ErrInvalidBlock)
+	assert.Contains(t, err.Error(), "no transactions")
+
+	empty.Transactions = []reps.Transaction{txnService.CreateCoinbaseTxn(miner.Address, "")}
+	_, err = mempoolService.ReceiveBlock(empty)
+	assert.ErrorIs(t, err, services.ErrInvalidBlock)
+	assert.Contains(t, err.Error(), "no merkle root")
+	assert.Len(t, repo.blocks, 1)
+}
+
+func TestReceiveBlockRejectsSideBlockClaimingMoreWorkThanCalledFor(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+	blockchainService, mempoolService := ts.blockchainService, ts.mempoolService
+
+	miner, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, miner.Address)
+	_, err = blockchainService.MineTransactions([]reps.Transaction{}, miner.Address, "")
+	assert.NoError(t, err)
+	_, err = blockchainService.MineTransactions([]reps.Transaction{}, miner.Address, "")
+	assert.NoError(t, err)
+
+	// Mined at a higher difficulty than the chain calls for, it'd weigh as much as both blocks on the chain on its own
+	peerRepo := newFakeBlockchainRepository()
+	peerRepo.blocks = append(peerRepo.blocks, repo.blocks[0])
+	heavy, err := services.NewBlockService(peerRepo, &mainnet).CreateBlock([]reps.Transaction{txnService.CreateCoinbaseTxn(miner.Address, "heavy")}, repo.blocks[0].Hash)
+	assert.NoError(t, err)
+	heavy.Difficulty = services.TargetBits + 1
+	heavy.Nounce, heavy.Hash = services.NewProofOfWorkService(&heavy, sha256Hasher).Solve()
+
+	update, err := mempoolService.ReceiveBlock(heavy)
+	assert.ErrorIs(t, err, services.ErrInvalidBlock)
+	assert.Contains(t, err.Error(), "expected "+strconv.Itoa(services.TargetBits))
+	assert.Empty(t, update.Side)
+	assert.Empty(t, repo.sideBlocks)
+	assert.Len(t, repo.blocks, 3)
+}
+
+func TestReceiveBlockReorganizesOntoBranchWithMoreWork(t *testing.T) {
+	ts := newTestServices(t)
+	repo, keystore, walletService := ts.repo, ts.keystore, ts.walletService
+	txnService, blockchainService, mempoolService := ts.txnService, ts.blockchainService, ts.mempoolService
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	heard := make([]string, 0)
+	blockchainService.OnBlockConnected(func(block reps.Block) {
+		heard = append(heard, block.ID)
+	})
+
+	// A peer with the same genesis mines a branch of its own
+	peerRepo := newFakeBlockchainRepository()
+	peerRepo.blocks = append(peerRepo.blocks, repo.blocks...)
+	peerTxnService := services.NewTransactionService(peerRepo, walletService, nil, services.NewLocalSigner(keystore), &mainnet)
+	peer := services.NewBlockchainService(peerRepo, services.NewBlockService(peerRepo, &mainnet), peerTxnService, walletService, &mainnet)
+	first, err := peer.MineTransactions([]reps.Transaction{}, to.Address, "")
+	assert.NoError(t, err)
+	second, err := peer.MineTransactions([]reps.Transaction{}, to.Address, "")
+	assert.NoError(t, err)
+
+	txn, err := txnService.CreateTransactionToRecipients(from.Address, []reps.Recipient{{To: to.Address, Amount: 10}}, reps.TxnOptions{Fee: 2})
+	assert.NoError(t, err)
+	_, err = mempoolService.AddTransaction(txn)
+	assert.NoError(t, err)
+	mined, err := mempoolService.MinePendingTransactions(from.Address, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, mempoolService.Size())
+
+	// Tied on work, the chain seen first stays
+	assert.Equal(t, "1000", mined.ChainWork)
+	update, err := mempoolService.ReceiveBlock(first)
+	assert.NoError(t, err)
+	assert.Empty(t, update.Connected)
+	assert.Len(t, update.Side, 1)
+	assert.Len(t, repo.blocks, 2)
+	assert.Equal(t, mined.ID, repo.blocks[1].ID)
+
+	_, err = mempoolService.ReceiveBlock(first)
+	assert.ErrorIs(t, err, services.ErrKnownBlock)
+
+	// Until the branch pulls ahead
+	update, err = mempoolService.ReceiveBlock(second)
+	assert.NoError(t, err)
+	assert.Len(t, update.Connected, 2)
+	assert.Equal(t, first.ID, update.Connected[0].ID)
+	assert.Equal(t, second.ID, update.Connected[1].ID)
+	assert.Len(t, update.Disconnected, 1)
+	assert.Equal(t, mined.ID, update.Disconnected[0].ID)
+	assert.Len(t, repo.blocks, 3)
+	assert.Equal(t, second.ID, repo.blocks[2].ID)
+
+	// Listeners hear of blocks as they make it onto the chain, not while they're on a side branch
+	assert.Equal(t, []string{mined.ID, first.ID, second.ID}, heard)
+
+	// The transaction on the block taken off goes back in the mempool, and that block is kept on a side branch
+	assert.Equal(t, 1, mempoolService.Size())
+	_, err = mempoolService.ReceiveBlock(mined)
+	assert.ErrorIs(t, err, services.ErrKnownBlock)
+
+	balance, err := txnService.GetBalance(from.Address)
+	assert.NoError(t, err)
+	assert.Equal(t, services.Reward, balance)
+
+	// Both children of the genesis block are still known, each leading a branch
+	tips, err := blockchainService.GetChainTips()
+	assert.NoError(t, err)
+	assert.Equal(t, []reps.ChainTip{
+		{Hash: hex.EncodeToString(second.Hash), Height: 2, ForkHeight: 2, ChainWork: "2000", Status: reps.ChainTipActive},
+		{Hash: hex.EncodeToString(mined.Hash), Height: 1, ForkHeight: 0, BranchLength: 1, ChainWork: "1000", Status: reps.ChainTipSide},
+	}, tips)
+}
+
+func TestReorgRequeuesOrphanedTransactionsNotOnNewChain(t *testing.T) {
+	ts := newTestServices(t)
+	repo, keystore, walletService := ts.repo, ts.keystore, ts.walletService
+	txnService, mempoolService := ts.txnService, ts.mempoolService
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	other, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	genesis := txnService.CreateGenesisTxn(from.Address, "", []reps.GenesisAllocation{{Address: other.Address, Amount: services.Reward}})
+	repo.blocks = []reps.Block{{ID: "genesis", Hash: []byte("genesis"), Transactions: []reps.Transaction{genesis}}}
+
+	orphaned, err := txnService.CreateTransactionToRecipients(from.Address, []reps.Recipient{{To: to.Address, Amount: 10}}, reps.TxnOptions{Fee: 2})
+	assert.NoError(t, err)
+	confirmed, err := txnService.CreateTransactionToRecipients(other.Address, []reps.Recipient{{To: to.Address, Amount: 10}}, reps.TxnOptions{Fee: 2})
+	assert.NoError(t, err)
+	for _, txn := range []reps.Transaction{orphaned, confirmed} {
+		_, err = mempoolService.AddTransaction(txn)
+		assert.NoError(t, err)
+	}
+	mined, err := mempoolService.MinePendingTransactions(to.Address, "")
+	assert.NoError(t, err)
+	assert.Len(t, mined.Transactions, 3)
+	assert.Equal(t, 0, mempoolService.Size())
+
+	// A peer with the same genesis mines only one of them, on a branch that pulls ahead
+	peerRepo := newFakeBlockchainRepository()
+	peerRepo.blocks = append(peerRepo.blocks, repo.blocks[0])
+	peerTxnService := services.NewTransactionService(peerRepo, walletService, nil, services.NewLocalSigner(keystore), &mainnet)
+	peer := services.NewBlockchainService(peerRepo, services.NewBlockService(peerRepo, &mainnet), peerTxnService, walletService, &mainnet)
+	first, err := peer.MineTransactions([]reps.Transaction{confirmed}, to.Address, "")
+	assert.NoError(t, err)
+	second, err := peer.MineTransactions([]reps.Transaction{}, to.Address, "")
+	assert.NoError(t, err)
+
+	_, err = mempoolService.ReceiveBlock(first)
+	assert.NoError(t, err)
+	update, err := mempoolService.ReceiveBlock(second)
+	assert.NoError(t, err)
+	assert.Len(t, update.Disconnected, 1)
+	assert.Equal(t, mined.ID, update.Disconnected[0].ID)
+
+	// The transaction only the block taken off had is queued to be mined again, the one the new chain has isn't
+	assert.Equal(t, 1, mempoolService.Size())
+	status, err := mempoolService.GetTransactionStatus(hex.EncodeToString(orphaned.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, reps.TxnPending, status.Status)
+	status, err = mempoolService.GetTransactionStatus(hex.EncodeToString(confirmed.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, reps.TxnConfirmed, status.Status)
+	assert.Equal(t, hex.EncodeToString(first.Hash), status.BlockHash)
+}
+
+func TestMinePendingTransactionsKeepsUnderMaxBlockSize(t *testing.T) {
+	params := mainnet
+	ts := newTestServicesWithParams(t, &params)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+	blockService, mempoolService := ts.blockService, ts.mempoolService
+
+	senders := make([]reps.Wallet, 0)
+	for i := 0; i < 2; i++ {
+		sender, err := walletService.CreateWallet()
+		assert.NoError(t, err)
+		senders = append(senders, sender)
+	}
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, senders[0].Address)
+	repo.blocks = append(repo.blocks, reps.Block{ID: "second", Hash: []byte("second"), PrevHash: []byte("genesis"), Transactions: []reps.Transaction{txnService.CreateCoinbaseTxn(senders[1].Address, "")}})
+
+	txns := make([]reps.Transaction, 0)
+	for i, sender := range senders {
+		txn, err := txnService.CreateTransactionToRecipients(sender.Address, []reps.Recipient{{To: to.Address, Amount: 10}}, reps.TxnOptions{Fee: 2 - i})
+		assert.NoError(t, err)
+		_, err = mempoolService.AddTransaction(txn)
+		assert.NoError(t, err)
+		txns = append(txns, txn)
+	}
+
+	// Room for one of them only
+	params.MaxBlockSize = services.BlockSizeAllowance + len(services.TxnAssembler.ToTxnBytes(txns[0]))*3/2
+
+	block, err := mempoolService.MinePendingTransactions(to.Address, "")
+	assert.NoError(t, err)
+	assert.Len(t, block.Transactions, 2)
+	assert.Equal(t, txns[0].ID, block.Transactions[1].ID)
+	assert.LessOrEqual(t, services.BlockSize(block), params.MaxBlockSize)
+	assert.Equal(t, 1, mempoolService.Size())
+
+	// Nor is anything bigger valid
+	params.MaxBlockSize = services.BlockSize(block) - 1
+	assert.ErrorIs(t, blockService.ValidateBlock(block), services.ErrInvalidBlock)
+}
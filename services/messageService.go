@@ -0,0 +1,122 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+
+	reps "github.com/brucetieu/blockchain/representations"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Prepended to every message before it's hashed, so a signed message can never double as a transaction signature
+var signedMessagePrefix = []byte("Blockchain Signed Message:\n")
+
+// Public key length of each scheme, so the key can be split from the signature
+var messagePubKeyLens = map[string]int{
+	SigAlgorithmECDSA:   64,
+	SigAlgorithmEd25519: 32,
+}
+
+// Signs arbitrary messages with wallet keys, so the owner of an address can prove it off-chain
+type MessageService interface {
+	SignMessage(address string, message string) (string, error)
+	VerifyMessage(address string, message string, signature string) (bool, error)
+}
+
+type messageService struct {
+	walletService WalletService
+	signer        Signer
+	params        *reps.ChainParams
+}
+
+func NewMessageService(walletService WalletService, signer Signer, params *reps.ChainParams) MessageService {
+	return &messageService{
+		walletService: walletService,
+		signer:        signer,
+		params:        params,
+	}
+}
+
+// Sign a message with the key of a wallet on this node.
+// The signature is base64(algorithm flag + public key + signature), so it can be verified with just the address
+func (ms *messageService) SignMessage(address string, message string) (string, error) {
+	log.Info("Signing message with address: ", address)
+	wallet, err := ms.walletService.GetWallet(address)
+	if err != nil {
+		return "", err
+	}
+
+	if wallet.WatchOnly {
+		return "", fmt.Errorf("%w: %s", ErrWatchOnly, address)
+	}
+
+	scheme, err := GetSignatureScheme(wallet.SigAlgorithm)
+	if err != nil {
+		return "", err
+	}
+
+	pubKey, err := hex.DecodeString(wallet.PublicKey)
+	if err != nil {
+		return "", fmt.Errorf("%s, unable to read public key of %s", err.Error(), address)
+	}
+
+	signature, err := ms.signer.Sign(wallet, messageHash(message))
+	if err != nil {
+		return "", err
+	}
+
+	encoded := append([]byte{wifAlgorithmFlags[scheme.Algorithm()]}, pubKey...)
+	encoded = append(encoded, signature...)
+
+	return base64.StdEncoding.EncodeToString(encoded), nil
+}
+
+// Check a message was signed by the key of address. Anyone can verify, the address doesn't need to be a wallet on this node
+func (ms *messageService) VerifyMessage(address string, message string, signature string) (bool, error) {
+	log.Info("Verifying message signed by address: ", address)
+	if !IsValidAddress(address, ms.params.NetworkByte) {
+		return false, fmt.Errorf("malformed address: %s", address)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil || len(decoded) == 0 {
+		return false, fmt.Errorf("signature is not valid base64")
+	}
+
+	var scheme SignatureScheme
+	for sigAlgorithm, flag := range wifAlgorithmFlags {
+		if decoded[0] == flag {
+			scheme, _ = GetSignatureScheme(sigAlgorithm)
+		}
+	}
+	if scheme == nil {
+		return false, fmt.Errorf("signature has unknown algorithm flag %d", decoded[0])
+	}
+
+	pubKeyLen := messagePubKeyLens[scheme.Algorithm()]
+	if len(decoded) < 1+pubKeyLen {
+		return false, fmt.Errorf("signature is too short")
+	}
+	pubKey := decoded[1 : 1+pubKeyLen]
+	sig := decoded[1+pubKeyLen:]
+
+	// The key has to be the one the address was made from
+	signerAddress, err := AddressFromPubKey(pubKey, ms.params.NetworkByte)
+	if err != nil {
+		return false, err
+	}
+	if !bytes.Equal(signerAddress, []byte(address)) {
+		return false, nil
+	}
+
+	return scheme.Verify(pubKey, sig, messageHash(message)), nil
+}
+
+func messageHash(message string) []byte {
+	hash := sha256.Sum256(append(append([]byte{}, signedMessagePrefix...), message...))
+	return hash[:]
+}
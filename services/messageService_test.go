@@ -0,0 +1,36 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/brucetieu/blockchain/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignedMessageVerifiesOnlyForSignersAddress(t *testing.T) {
+	keystore := newUnlockedKeystore(t)
+	walletService := services.NewWalletService(newFakeBlockchainRepository(), keystore, &mainnet)
+	messageService := services.NewMessageService(walletService, services.NewLocalSigner(keystore), &mainnet)
+
+	for _, sigAlgorithm := range []string{services.SigAlgorithmECDSA, services.SigAlgorithmEd25519} {
+		signer, err := walletService.CreateWalletWithAlgorithm(sigAlgorithm)
+		assert.NoError(t, err)
+		other, err := walletService.CreateWalletWithAlgorithm(sigAlgorithm)
+		assert.NoError(t, err)
+
+		signature, err := messageService.SignMessage(signer.Address, "I own this address")
+		assert.NoError(t, err)
+
+		valid, err := messageService.VerifyMessage(signer.Address, "I own this address", signature)
+		assert.NoError(t, err)
+		assert.True(t, valid)
+
+		valid, err = messageService.VerifyMessage(signer.Address, "I own another address", signature)
+		assert.NoError(t, err)
+		assert.False(t, valid)
+
+		valid, err = messageService.VerifyMessage(other.Address, "I own this address", signature)
+		assert.NoError(t, err)
+		assert.False(t, valid)
+	}
+}
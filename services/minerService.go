@@ -0,0 +1,226 @@
+package services
+
+import (
+	"encoding/hex"
+	"sync"
+	"time"
+
+	reps "github.com/brucetieu/blockchain/representations"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	MinerIdleWait = time.Second // How long the background miner waits between blocks when there's nothing new to mine
+)
+
+// Mines blocks from the mempool in the background, for as long as it's running
+type MinerService interface {
+	Start(miner string) (reps.MinerStatus, error)
+	Stop() (reps.MinerStatus, error)
+	Pause() (reps.MinerStatus, error)
+	Resume() (reps.MinerStatus, error)
+	SetPayoutAddress(miner string) (reps.MinerStatus, error)
+	GetStatus() reps.MinerStatus
+}
+
+type minerService struct {
+	mempoolService MempoolService
+
+	controlMu sync.Mutex // Starting and stopping happen one at a time
+	mu        sync.Mutex
+	status    reps.MinerStatus
+	resume    chan struct{} // Closed to resume mining. Nil unless paused
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+func NewMinerService(mempoolService MempoolService) MinerService {
+	return &minerService{
+		mempoolService: mempoolService,
+		status:         reps.MinerStatus{Status: reps.MinerStopped},
+	}
+}
+
+// Start mining blocks from the mempool, paying their coinbases to miner
+func (ms *minerService) Start(miner string) (reps.MinerStatus, error) {
+	ms.controlMu.Lock()
+	defer ms.controlMu.Unlock()
+
+	if ms.stop != nil {
+		return ms.GetStatus(), ErrMinerRunning
+	}
+
+	log.WithField("miner", miner).Info("Starting background miner")
+
+	ms.stop = make(chan struct{})
+	ms.done = make(chan struct{})
+
+	ms.mu.Lock()
+	ms.status = reps.MinerStatus{Status: reps.MinerRunning, Miner: miner, StartedAt: time.Now().UnixMilli()}
+	ms.mu.Unlock()
+
+	go ms.run(ms.stop, ms.done)
+
+	return ms.GetStatus(), nil
+}
+
+// Stop mining. A block already being mined is finished first
+func (ms *minerService) Stop() (reps.MinerStatus, error) {
+	ms.controlMu.Lock()
+	defer ms.controlMu.Unlock()
+
+	if ms.stop == nil {
+		return ms.GetStatus(), ErrMinerStopped
+	}
+
+	log.Info("Stopping background miner")
+
+	close(ms.stop)
+	<-ms.done
+	ms.stop, ms.done = nil, nil
+
+	ms.mu.Lock()
+	ms.status.Status = reps.MinerStopped
+	ms.status.PausedAt = 0
+	ms.resume = nil
+	ms.mu.Unlock()
+
+	return ms.GetStatus(), nil
+}
+
+// Stop mining blocks without stopping the miner, until it's resumed. A block already being mined is finished first
+func (ms *minerService) Pause() (reps.MinerStatus, error) {
+	ms.controlMu.Lock()
+	defer ms.controlMu.Unlock()
+
+	if ms.stop == nil {
+		return ms.GetStatus(), ErrMinerStopped
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if ms.resume != nil {
+		return ms.withThrottle(ms.status), ErrMinerPaused
+	}
+
+	log.Info("Pausing background miner")
+	ms.resume = make(chan struct{})
+	ms.status.Status = reps.MinerPaused
+	ms.status.PausedAt = time.Now().UnixMilli()
+
+	return ms.withThrottle(ms.status), nil
+}
+
+// Pick mining back up where a pause left off
+func (ms *minerService) Resume() (reps.MinerStatus, error) {
+	ms.controlMu.Lock()
+	defer ms.controlMu.Unlock()
+
+	if ms.stop == nil {
+		return ms.GetStatus(), ErrMinerStopped
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if ms.resume == nil {
+		return ms.withThrottle(ms.status), ErrMinerRunning
+	}
+
+	log.Info("Resuming background miner")
+	close(ms.resume)
+	ms.resume = nil
+	ms.status.Status = reps.MinerRunning
+	ms.status.PausedAt = 0
+
+	return ms.withThrottle(ms.status), nil
+}
+
+// Pay the coinbase of every block mined from now on to miner. A block already being mined still pays the old address
+func (ms *minerService) SetPayoutAddress(miner string) (reps.MinerStatus, error) {
+	ms.controlMu.Lock()
+	defer ms.controlMu.Unlock()
+
+	if ms.stop == nil {
+		return ms.GetStatus(), ErrMinerStopped
+	}
+
+	log.WithField("miner", miner).Info("Changing background miner's payout address")
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.status.Miner = miner
+
+	return ms.withThrottle(ms.status), nil
+}
+
+func (ms *minerService) GetStatus() reps.MinerStatus {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	return ms.withThrottle(ms.status)
+}
+
+// Status along with how hard the miner's working, which comes from MINING_WORKERS and MINING_DUTY_CYCLE
+func (ms *minerService) withThrottle(status reps.MinerStatus) reps.MinerStatus {
+	status.Workers = MiningWorkers
+	status.DutyCycle = MiningDutyCycle
+	return status
+}
+
+// Mine a block whenever the mempool has transactions, until stopped. Waits MinerIdleWait after a block without any,
+// e.g. when everything pending is still locked, so it doesn't fill the chain with empty blocks, and for as long as it's paused
+func (ms *minerService) run(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		ms.mu.Lock()
+		miner, resume := ms.status.Miner, ms.resume
+		ms.mu.Unlock()
+
+		if resume != nil {
+			select {
+			case <-stop:
+				return
+			case <-resume:
+			}
+			continue
+		}
+
+		wait := MinerIdleWait
+		if ms.mempoolService.Size() > 0 {
+			block, err := ms.mempoolService.MinePendingTransactions(miner, "")
+
+			ms.mu.Lock()
+			if err != nil {
+				log.Error("Background miner failed to mine a block: ", err.Error())
+				ms.status.LastError = err.Error()
+			} else {
+				ms.status.BlocksMined++
+				ms.status.LastBlockHash = hex.EncodeToString(block.Hash)
+				ms.status.LastBlockHeight = block.Height
+				ms.status.LastBlockAt = time.Now().UnixMilli()
+				ms.status.LastError = ""
+			}
+			ms.mu.Unlock()
+
+			if err == nil && len(block.Transactions) > 1 {
+				wait = 0
+			}
+		}
+
+		if wait > 0 {
+			select {
+			case <-stop:
+				return
+			case <-time.After(wait):
+			}
+		}
+	}
+}
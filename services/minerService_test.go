@@ -0,0 +1,112 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMinerMinesMempoolUntilStopped(t *testing.T) {
+	defer func(wait time.Duration) { services.MinerIdleWait = wait }(services.MinerIdleWait)
+	services.MinerIdleWait = 10 * time.Millisecond
+
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+	mempoolService := ts.mempoolService
+	minerService := services.NewMinerService(mempoolService)
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	_, err = minerService.Stop()
+	assert.ErrorIs(t, err, services.ErrMinerStopped)
+
+	status, err := minerService.Start(to.Address)
+	assert.NoError(t, err)
+	assert.Equal(t, reps.MinerRunning, status.Status)
+	_, err = minerService.Start(to.Address)
+	assert.ErrorIs(t, err, services.ErrMinerRunning)
+
+	txn, err := txnService.CreateTransaction(from.Address, to.Address, 10)
+	assert.NoError(t, err)
+	_, err = mempoolService.AddTransaction(txn)
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool { return minerService.GetStatus().BlocksMined == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0, mempoolService.Size())
+
+	// Nothing more is mined while the mempool is empty
+	time.Sleep(5 * services.MinerIdleWait)
+
+	status, err = minerService.Stop()
+	assert.NoError(t, err)
+	assert.Equal(t, reps.MinerStopped, status.Status)
+	assert.Equal(t, 1, status.BlocksMined)
+	assert.Equal(t, 1, status.LastBlockHeight)
+	assert.Len(t, repo.blocks, 2)
+	assert.Equal(t, txn.ID, repo.blocks[1].Transactions[1].ID)
+}
+
+func TestMinerPausesAndChangesPayoutAddress(t *testing.T) {
+	defer func(wait time.Duration) { services.MinerIdleWait = wait }(services.MinerIdleWait)
+	services.MinerIdleWait = 10 * time.Millisecond
+
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+	mempoolService := ts.mempoolService
+	minerService := services.NewMinerService(mempoolService)
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	payout, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	_, err = minerService.Pause()
+	assert.ErrorIs(t, err, services.ErrMinerStopped)
+	_, err = minerService.SetPayoutAddress(payout.Address)
+	assert.ErrorIs(t, err, services.ErrMinerStopped)
+
+	_, err = minerService.Start(to.Address)
+	assert.NoError(t, err)
+	defer minerService.Stop()
+	_, err = minerService.Resume()
+	assert.ErrorIs(t, err, services.ErrMinerRunning)
+
+	status, err := minerService.Pause()
+	assert.NoError(t, err)
+	assert.Equal(t, reps.MinerPaused, status.Status)
+	assert.NotZero(t, status.PausedAt)
+	_, err = minerService.Pause()
+	assert.ErrorIs(t, err, services.ErrMinerPaused)
+
+	// Nothing is mined while paused, however much is pending
+	txn, err := txnService.CreateTransaction(from.Address, to.Address, 10)
+	assert.NoError(t, err)
+	_, err = mempoolService.AddTransaction(txn)
+	assert.NoError(t, err)
+	time.Sleep(5 * services.MinerIdleWait)
+	assert.Len(t, repo.blocks, 1)
+
+	status, err = minerService.SetPayoutAddress(payout.Address)
+	assert.NoError(t, err)
+	assert.Equal(t, payout.Address, status.Miner)
+
+	status, err = minerService.Resume()
+	assert.NoError(t, err)
+	assert.Equal(t, reps.MinerRunning, status.Status)
+	assert.Zero(t, status.PausedAt)
+
+	assert.Eventually(t, func() bool { return minerService.GetStatus().BlocksMined == 1 }, 5*time.Second, 10*time.Millisecond)
+	balance, err := txnService.GetBalance(payout.Address)
+	assert.NoError(t, err)
+	assert.Equal(t, services.Reward, balance)
+}
@@ -0,0 +1,437 @@
+package services
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/brucetieu/blockchain/repository"
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/google/uuid"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	MaxMultisigKeys = 15
+
+	multisigPubKeyLen = 64                    // x and y, 32 bytes each
+	multisigSigLen    = 1 + multisigPubKeyLen // key index + r and s
+)
+
+type MultisigService interface {
+	CreateMultisigAddress(requiredSigs int, pubKeys []string) (reps.MultisigAddress, error)
+	GetMultisigAddress(address string) (reps.MultisigAddress, error)
+
+	CreateMultisigTransaction(from string, to string, amount int) (reps.MultisigTransaction, error)
+	SignMultisigTransaction(txnId string, signer string) (reps.MultisigTransaction, error)
+	GetMultisigTransaction(txnId string) (reps.MultisigTransaction, error)
+	BroadcastMultisigTransaction(txnId string, miner string) (reps.Block, error)
+}
+
+type multisigService struct {
+	blockchainRepo     repository.BlockchainRepository
+	transactionService TransactionService
+	walletService      WalletService
+	blockchainService  BlockchainService
+	signer             Signer
+	txnAssembler       TxnAssemblerFac
+	walletAssembler    WalletAssemblerFac
+	params             *reps.ChainParams
+}
+
+func NewMultisigService(blockchainRepo repository.BlockchainRepository, transactionService TransactionService,
+	walletService WalletService, blockchainService BlockchainService, signer Signer, params *reps.ChainParams,
+) MultisigService {
+	return &multisigService{
+		blockchainRepo:     blockchainRepo,
+		transactionService: transactionService,
+		walletService:      walletService,
+		blockchainService:  blockchainService,
+		signer:             signer,
+		txnAssembler:       TxnAssembler,
+		walletAssembler:    WalletAssembler,
+		params:             params,
+	}
+}
+
+// M, N and the N public keys that can sign for a multisig address
+type redeemScript struct {
+	requiredSigs int
+	pubKeys      [][]byte
+}
+
+// Create an M-of-N address from N public keys
+func (ms *multisigService) CreateMultisigAddress(requiredSigs int, pubKeys []string) (reps.MultisigAddress, error) {
+	log.WithFields(log.Fields{"requiredSigs": requiredSigs, "pubKeys": len(pubKeys)}).Info("Creating multisig address")
+	if len(pubKeys) == 0 || len(pubKeys) > MaxMultisigKeys {
+		return reps.MultisigAddress{}, fmt.Errorf("a multisig address needs between 1 and %d public keys, got %d", MaxMultisigKeys, len(pubKeys))
+	}
+	if requiredSigs < 1 || requiredSigs > len(pubKeys) {
+		return reps.MultisigAddress{}, fmt.Errorf("required signatures must be between 1 and %d, got %d", len(pubKeys), requiredSigs)
+	}
+
+	script := redeemScript{requiredSigs: requiredSigs}
+	seen := make(map[string]bool)
+	for _, pubKey := range pubKeys {
+		pubKeyBytes, err := hex.DecodeString(pubKey)
+		if err != nil || !isValidPubKey(pubKeyBytes) {
+			return reps.MultisigAddress{}, fmt.Errorf("%s is not a valid public key", pubKey)
+		}
+		if seen[pubKey] {
+			return reps.MultisigAddress{}, fmt.Errorf("public key %s is listed more than once", pubKey)
+		}
+		seen[pubKey] = true
+		script.pubKeys = append(script.pubKeys, pubKeyBytes)
+	}
+
+	scriptBytes := script.encode()
+	address, err := AddressFromPubKey(scriptBytes, ms.params.NetworkByte)
+	if err != nil {
+		return reps.MultisigAddress{}, err
+	}
+
+	// Same keys and threshold always give the same address
+	if existing, err := ms.GetMultisigAddress(string(address)); err == nil {
+		return existing, nil
+	}
+
+	multisigAddress := reps.MultisigAddress{
+		ID:           uuid.Must(uuid.NewRandom()).String(),
+		Address:      string(address),
+		RequiredSigs: requiredSigs,
+		RedeemScript: hex.EncodeToString(scriptBytes),
+	}
+
+	err = ms.blockchainRepo.CreateMultisigAddress(multisigAddress)
+	if err != nil {
+		return reps.MultisigAddress{}, err
+	}
+
+	multisigAddress.PublicKeys = pubKeys
+	return multisigAddress, nil
+}
+
+// Get a multisig address along with its keys and balance
+func (ms *multisigService) GetMultisigAddress(address string) (reps.MultisigAddress, error) {
+	multisigAddress, err := ms.blockchainRepo.GetMultisigAddress(address)
+	if err != nil {
+		return reps.MultisigAddress{}, fmt.Errorf("%s, multisig address %s does not exist", err.Error(), address)
+	}
+
+	script, err := ms.getRedeemScript(multisigAddress)
+	if err != nil {
+		return reps.MultisigAddress{}, err
+	}
+
+	multisigAddress.PublicKeys = make([]string, 0)
+	for _, pubKey := range script.pubKeys {
+		multisigAddress.PublicKeys = append(multisigAddress.PublicKeys, hex.EncodeToString(pubKey))
+	}
+
+	pubKeyHash, _ := createPubKeyHash(script.encode())
+	for _, output := range ms.transactionService.GetUnspentTxnOutputs(pubKeyHash) {
+		multisigAddress.Balance += output.Value
+	}
+
+	return multisigAddress, nil
+}
+
+// Create an unsigned transaction spending from a multisig address. It's kept until enough signatures are collected
+func (ms *multisigService) CreateMultisigTransaction(from string, to string, amount int) (reps.MultisigTransaction, error) {
+	log.WithFields(log.Fields{"from": from, "to": to, "amount": amount}).Info("Creating multisig transaction...")
+	multisigAddress, err := ms.blockchainRepo.GetMultisigAddress(from)
+	if err != nil {
+		return reps.MultisigTransaction{}, fmt.Errorf("%s, multisig address %s does not exist", err.Error(), from)
+	}
+
+	script, err := ms.getRedeemScript(multisigAddress)
+	if err != nil {
+		return reps.MultisigTransaction{}, err
+	}
+
+	// The redeem script takes the place of the public key in every input
+	txn, err := ms.transactionService.CreateUnsignedTransaction(from, script.encode(), SigAlgorithmECDSA, to, amount, from)
+	if err != nil {
+		return reps.MultisigTransaction{}, err
+	}
+
+	multisigTxn := reps.MultisigTransaction{
+		ID:           hex.EncodeToString(txn.ID),
+		Address:      from,
+		Status:       reps.MultisigPending,
+		RequiredSigs: script.requiredSigs,
+	}
+
+	err = ms.saveMultisigTransaction(&multisigTxn, txn, true)
+	if err != nil {
+		return reps.MultisigTransaction{}, err
+	}
+
+	return ms.toMultisigTransaction(multisigTxn, txn, script), nil
+}
+
+// Add the signature of one of the multisig keys. signer is the address of a wallet on this node holding that key
+func (ms *multisigService) SignMultisigTransaction(txnId string, signer string) (reps.MultisigTransaction, error) {
+	log.WithFields(log.Fields{"txnId": txnId, "signer": signer}).Info("Signing multisig transaction...")
+	multisigTxn, txn, script, err := ms.getMultisigTransaction(txnId)
+	if err != nil {
+		return reps.MultisigTransaction{}, err
+	}
+
+	if multisigTxn.Status == reps.MultisigBroadcast {
+		return reps.MultisigTransaction{}, fmt.Errorf("multisig transaction %s was already broadcast", txnId)
+	}
+
+	wallet, err := ms.walletService.GetWallet(signer)
+	if err != nil {
+		return reps.MultisigTransaction{}, err
+	}
+
+	if wallet.WatchOnly {
+		return reps.MultisigTransaction{}, fmt.Errorf("%w: %s", ErrWatchOnly, signer)
+	}
+
+	if wallet.SigAlgorithm != "" && wallet.SigAlgorithm != SigAlgorithmECDSA {
+		return reps.MultisigTransaction{}, fmt.Errorf("multisig transactions can only be signed with %s keys, %s has a %s key", SigAlgorithmECDSA, signer, wallet.SigAlgorithm)
+	}
+
+	pubKey, _ := hex.DecodeString(wallet.PublicKey)
+	keyIdx := script.keyIndex(pubKey)
+	if keyIdx < 0 {
+		return reps.MultisigTransaction{}, fmt.Errorf("%s is not one of the keys of multisig address %s", signer, multisigTxn.Address)
+	}
+
+	if script.hasSigned(txn.Inputs[0].Signature, keyIdx) {
+		return reps.MultisigTransaction{}, fmt.Errorf("%s already signed multisig transaction %s", signer, txnId)
+	}
+
+	prevTxns, err := ms.transactionService.GetPrevTransactions(txn)
+	if err != nil {
+		return reps.MultisigTransaction{}, err
+	}
+
+	for inIdx := range txn.Inputs {
+		signingHash := ms.transactionService.SigningHash(txn, inIdx, prevTxns)
+
+		sig, err := ms.signer.Sign(wallet, signingHash)
+		if err != nil {
+			log.Error("error signing transaction: ", err.Error())
+			return reps.MultisigTransaction{}, err
+		}
+
+		// Each signature is prefixed with the index of its key in the redeem script
+		signature := append([]byte{byte(keyIdx)}, sig...)
+		txn.Inputs[inIdx].Signature = append(txn.Inputs[inIdx].Signature, signature...)
+	}
+
+	multisigTxn.Signatures++
+	if multisigTxn.Signatures >= multisigTxn.RequiredSigs {
+		multisigTxn.Status = reps.MultisigReady
+	}
+
+	err = ms.saveMultisigTransaction(&multisigTxn, txn, false)
+	if err != nil {
+		return reps.MultisigTransaction{}, err
+	}
+
+	return ms.toMultisigTransaction(multisigTxn, txn, script), nil
+}
+
+func (ms *multisigService) GetMultisigTransaction(txnId string) (reps.MultisigTransaction, error) {
+	multisigTxn, txn, script, err := ms.getMultisigTransaction(txnId)
+	if err != nil {
+		return reps.MultisigTransaction{}, err
+	}
+
+	return ms.toMultisigTransaction(multisigTxn, txn, script), nil
+}
+
+// Mine a fully signed multisig transaction into a new block
+func (ms *multisigService) BroadcastMultisigTransaction(txnId string, miner string) (reps.Block, error) {
+	log.WithFields(log.Fields{"txnId": txnId, "miner": miner}).Info("Broadcasting multisig transaction...")
+	multisigTxn, txn, _, err := ms.getMultisigTransaction(txnId)
+	if err != nil {
+		return reps.Block{}, err
+	}
+
+	switch multisigTxn.Status {
+	case reps.MultisigBroadcast:
+		return reps.Block{}, fmt.Errorf("multisig transaction %s was already broadcast", txnId)
+	case reps.MultisigPending:
+		return reps.Block{}, fmt.Errorf("multisig transaction %s has %d of %d required signatures", txnId, multisigTxn.Signatures, multisigTxn.RequiredSigs)
+	}
+
+	// Another transaction may have spent the same outputs since this one was created
+	blocks, err := ms.blockchainRepo.GetBlockchain()
+	if err != nil {
+		return reps.Block{}, err
+	}
+	spentOutputs := ms.transactionService.GetSpentOutputs(blocks)
+	for _, input := range txn.Inputs {
+		if _, spent := spentOutputs[hex.EncodeToString(input.PrevTxnID)][input.OutIdx]; spent {
+			return reps.Block{}, fmt.Errorf("output %d of transaction %x was already spent", input.OutIdx, input.PrevTxnID)
+		}
+	}
+
+	block, err := ms.blockchainService.MineTransactions([]reps.Transaction{txn}, miner, "")
+	if err != nil {
+		return reps.Block{}, err
+	}
+
+	multisigTxn.Status = reps.MultisigBroadcast
+	err = ms.saveMultisigTransaction(&multisigTxn, txn, false)
+	if err != nil {
+		return reps.Block{}, err
+	}
+
+	return block, nil
+}
+
+func (ms *multisigService) getMultisigTransaction(txnId string) (reps.MultisigTransaction, reps.Transaction, redeemScript, error) {
+	multisigTxn, err := ms.blockchainRepo.GetMultisigTransaction(txnId)
+	if err != nil {
+		return reps.MultisigTransaction{}, reps.Transaction{}, redeemScript{}, fmt.Errorf("%s, multisig transaction %s does not exist", err.Error(), txnId)
+	}
+
+	var txn reps.Transaction
+	err = json.Unmarshal(multisigTxn.RawTxn, &txn)
+	if err != nil {
+		return reps.MultisigTransaction{}, reps.Transaction{}, redeemScript{}, err
+	}
+
+	script, ok := parseRedeemScript(txn.Inputs[0].PubKey)
+	if !ok {
+		return reps.MultisigTransaction{}, reps.Transaction{}, redeemScript{}, fmt.Errorf("multisig transaction %s has an invalid redeem script", txnId)
+	}
+
+	return multisigTxn, txn, script, nil
+}
+
+func (ms *multisigService) saveMultisigTransaction(multisigTxn *reps.MultisigTransaction, txn reps.Transaction, create bool) error {
+	rawTxn, err := json.Marshal(txn)
+	if err != nil {
+		return err
+	}
+	multisigTxn.RawTxn = rawTxn
+
+	if create {
+		return ms.blockchainRepo.CreateMultisigTransaction(*multisigTxn)
+	}
+	return ms.blockchainRepo.UpdateMultisigTransaction(*multisigTxn)
+}
+
+// Fill in the readable transaction and which keys have signed so far
+func (ms *multisigService) toMultisigTransaction(multisigTxn reps.MultisigTransaction, txn reps.Transaction, script redeemScript) reps.MultisigTransaction {
+	multisigTxn.Transaction = ms.txnAssembler.ToReadableTransaction(txn)
+	multisigTxn.Signers = make([]string, 0)
+
+	for keyIdx, pubKey := range script.pubKeys {
+		if script.hasSigned(txn.Inputs[0].Signature, keyIdx) {
+			multisigTxn.Signers = append(multisigTxn.Signers, hex.EncodeToString(pubKey))
+		}
+	}
+
+	return multisigTxn
+}
+
+func (ms *multisigService) getRedeemScript(multisigAddress reps.MultisigAddress) (redeemScript, error) {
+	scriptBytes, err := hex.DecodeString(multisigAddress.RedeemScript)
+	if err != nil {
+		return redeemScript{}, err
+	}
+
+	script, ok := parseRedeemScript(scriptBytes)
+	if !ok {
+		return redeemScript{}, fmt.Errorf("multisig address %s has an invalid redeem script", multisigAddress.Address)
+	}
+
+	return script, nil
+}
+
+// redeemScript = M + N + N public keys
+func (script redeemScript) encode() []byte {
+	encoded := []byte{byte(script.requiredSigs), byte(len(script.pubKeys))}
+	for _, pubKey := range script.pubKeys {
+		encoded = append(encoded, pubKey...)
+	}
+	return encoded
+}
+
+// Decode a redeem script. A plain public key is never mistaken for one, since its length can't match
+func parseRedeemScript(encoded []byte) (redeemScript, bool) {
+	if len(encoded) < 2 {
+		return redeemScript{}, false
+	}
+
+	requiredSigs, keyCount := int(encoded[0]), int(encoded[1])
+	if keyCount == 0 || keyCount > MaxMultisigKeys || requiredSigs < 1 || requiredSigs > keyCount {
+		return redeemScript{}, false
+	}
+	if len(encoded) != 2+keyCount*multisigPubKeyLen {
+		return redeemScript{}, false
+	}
+
+	script := redeemScript{requiredSigs: requiredSigs}
+	for i := 0; i < keyCount; i++ {
+		start := 2 + i*multisigPubKeyLen
+		script.pubKeys = append(script.pubKeys, encoded[start:start+multisigPubKeyLen])
+	}
+
+	return script, true
+}
+
+// Index of pubKey in the script, -1 if it's not one of its keys
+func (script redeemScript) keyIndex(pubKey []byte) int {
+	for i, scriptPubKey := range script.pubKeys {
+		if bytes.Equal(scriptPubKey, pubKey) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (script redeemScript) hasSigned(signatures []byte, keyIdx int) bool {
+	for i := 0; i+multisigSigLen <= len(signatures); i += multisigSigLen {
+		if int(signatures[i]) == keyIdx {
+			return true
+		}
+	}
+	return false
+}
+
+// Number of distinct keys with a valid signature of hash. Signatures are concatenated key index + signature pairs
+func (script redeemScript) countValidSignatures(signatures []byte, hash []byte) int {
+	if len(signatures)%multisigSigLen != 0 {
+		return 0
+	}
+
+	signed := make(map[int]bool)
+	for i := 0; i < len(signatures); i += multisigSigLen {
+		keyIdx := int(signatures[i])
+		if keyIdx >= len(script.pubKeys) || signed[keyIdx] {
+			continue
+		}
+
+		if verifyECDSASignature(script.pubKeys[keyIdx], signatures[i+1:i+multisigSigLen], hash) {
+			signed[keyIdx] = true
+		}
+	}
+
+	return len(signed)
+}
+
+// Check a public key is a point on the P-256 curve
+func isValidPubKey(pubKey []byte) bool {
+	if len(pubKey) != multisigPubKeyLen {
+		return false
+	}
+
+	x := new(big.Int).SetBytes(pubKey[:multisigPubKeyLen/2])
+	y := new(big.Int).SetBytes(pubKey[multisigPubKeyLen/2:])
+
+	return elliptic.P256().IsOnCurve(x, y)
+}
@@ -0,0 +1,83 @@
+package services_test
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultisigTransactionNeedsRequiredSignatures(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, signer := ts.repo, ts.walletService, ts.signer
+	txnService := ts.txnService
+	multisigService := services.NewMultisigService(repo, txnService, walletService, nil, signer, &mainnet)
+
+	signers := make([]reps.Wallet, 0)
+	pubKeys := make([]string, 0)
+	for i := 0; i < 3; i++ {
+		wallet, err := walletService.CreateWallet()
+		assert.NoError(t, err)
+		signers = append(signers, wallet)
+		pubKeys = append(pubKeys, wallet.PublicKey)
+	}
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+
+	multisigAddress, err := multisigService.CreateMultisigAddress(2, pubKeys)
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, multisigAddress.Address)
+
+	multisigAddress, err = multisigService.GetMultisigAddress(multisigAddress.Address)
+	assert.NoError(t, err)
+	assert.Equal(t, services.Reward, multisigAddress.Balance)
+
+	multisigTxn, err := multisigService.CreateMultisigTransaction(multisigAddress.Address, to.Address, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, reps.MultisigPending, multisigTxn.Status)
+
+	multisigTxn, err = multisigService.SignMultisigTransaction(multisigTxn.ID, signers[0].Address)
+	assert.NoError(t, err)
+	assert.Equal(t, reps.MultisigPending, multisigTxn.Status)
+
+	// One signature isn't enough to spend
+	valid, _ := txnService.VerifyTransaction(storedMultisigTxn(t, repo, multisigTxn.ID))
+	assert.False(t, valid)
+
+	// Signing twice with the same key doesn't count twice
+	_, err = multisigService.SignMultisigTransaction(multisigTxn.ID, signers[0].Address)
+	assert.Error(t, err)
+
+	multisigTxn, err = multisigService.SignMultisigTransaction(multisigTxn.ID, signers[2].Address)
+	assert.NoError(t, err)
+	assert.Equal(t, reps.MultisigReady, multisigTxn.Status)
+	assert.Equal(t, []string{signers[0].PublicKey, signers[2].PublicKey}, multisigTxn.Signers)
+
+	valid, err = txnService.VerifyTransaction(storedMultisigTxn(t, repo, multisigTxn.ID))
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestCreateMultisigAddressRejectsBadThreshold(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, signer := ts.repo, ts.walletService, ts.signer
+	txnService := ts.txnService
+	multisigService := services.NewMultisigService(repo, txnService, walletService, nil, signer, &mainnet)
+
+	wallet, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+
+	_, err = multisigService.CreateMultisigAddress(2, []string{wallet.PublicKey})
+	assert.Error(t, err)
+	_, err = multisigService.CreateMultisigAddress(1, []string{hex.EncodeToString(make([]byte, 64))})
+	assert.Error(t, err)
+}
+
+func storedMultisigTxn(t *testing.T, repo *fakeBlockchainRepository, txnId string) reps.Transaction {
+	var txn reps.Transaction
+	assert.NoError(t, json.Unmarshal(repo.multisigTxns[txnId].RawTxn, &txn))
+	return txn
+}
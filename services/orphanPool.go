@@ -0,0 +1,81 @@
+package services
+
+import (
+	"bytes"
+	"encoding/hex"
+	"sync"
+
+	reps "github.com/brucetieu/blockchain/representations"
+)
+
+var MaxOrphanBlocks = 100 // Most blocks held while waiting for their parent. Past that, the oldest are dropped
+
+// Blocks received before their parent, held in memory until it shows up
+type orphanPool struct {
+	mu     sync.Mutex
+	blocks map[string]reps.Block // By hex hash
+	order  []string              // Hex hashes, oldest first
+}
+
+func newOrphanPool() *orphanPool {
+	return &orphanPool{
+		blocks: make(map[string]reps.Block),
+	}
+}
+
+// Hold block until its parent shows up. False if it's already held
+func (op *orphanPool) add(block reps.Block) bool {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+
+	hash := hex.EncodeToString(block.Hash)
+	if _, ok := op.blocks[hash]; ok {
+		return false
+	}
+
+	op.blocks[hash] = block
+	op.order = append(op.order, hash)
+	for len(op.blocks) > MaxOrphanBlocks {
+		delete(op.blocks, op.order[0])
+		op.order = op.order[1:]
+	}
+
+	return true
+}
+
+// Take the blocks building on the one with hash out of the pool
+func (op *orphanPool) takeChildren(hash []byte) []reps.Block {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+
+	children := make([]reps.Block, 0)
+	order := make([]string, 0, len(op.order))
+	for _, orphanHash := range op.order {
+		orphan := op.blocks[orphanHash]
+		if bytes.Equal(orphan.PrevHash, hash) {
+			children = append(children, orphan)
+			delete(op.blocks, orphanHash)
+		} else {
+			order = append(order, orphanHash)
+		}
+	}
+	op.order = order
+
+	return children
+}
+
+// Whether the block with hash is held
+func (op *orphanPool) has(hash []byte) bool {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+
+	_, ok := op.blocks[hex.EncodeToString(hash)]
+	return ok
+}
+
+func (op *orphanPool) size() int {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+
+	return len(op.blocks)
+}
@@ -2,17 +2,33 @@ package services
 
 import (
 	"bytes"
-	"crypto/sha256"
 	"math/big"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/brucetieu/blockchain/representations"
 	"github.com/brucetieu/blockchain/utils"
+
+	log "github.com/sirupsen/logrus"
 )
 
 var (
-	TargetBits = 12
+	TargetBits    = 12               // Difficulty the genesis block is mined at: leading zero bits its hash needs
+	MinDifficulty = 1                // Easiest retargeting can make mining
+	MaxDifficulty = 255              // Hardest retargeting can make mining
+	MiningWorkers = runtime.NumCPU() // Goroutines the nounce space is split across when solving a block
+
+	MiningDutyCycle     = 100  // Percent of the time each mining goroutine spends hashing. It sleeps the rest, to leave CPU for others
+	MiningThrottleBatch = 1000 // Hashes each mining goroutine tries between sleeps, when the duty cycle is under 100
 )
 
+// Difficulty of blocks mined before it was recorded on them
+const legacyDifficulty = 12
+
 type PowService interface {
 	Solve() (int64, []byte)
 	HashData() []byte
@@ -22,56 +38,165 @@ type PowService interface {
 type powService struct {
 	Block          *representations.Block
 	Target         *big.Int
+	hasher         BlockHasher
 	blockAssembler BlockAssemblerFac
-	txnAssembler   TxnAssemblerFac
 }
 
-func NewProofOfWorkService(block *representations.Block) PowService {
-	target := big.NewInt(1)
-
-	// means the first TargetBits number of bits will be 0. e.g. 0000000000001...
-	target.Lsh(target, uint(256-TargetBits))
-
+// Proof of work for block, at the block's difficulty, with hashes taken by hasher
+func NewProofOfWorkService(block *representations.Block, hasher BlockHasher) PowService {
 	return &powService{
-		Target:         target,
+		Target:         DifficultyTarget(BlockDifficulty(*block)),
 		Block:          block,
+		hasher:         hasher,
 		blockAssembler: BlockAssembler,
-		txnAssembler:   TxnAssembler,
 	}
 }
 
+// Number a hash has to be under to have difficulty leading zero bits
+func DifficultyTarget(difficulty int) *big.Int {
+	target := big.NewInt(1)
+
+	// means the first difficulty number of bits will be 0. e.g. 0000000000001...
+	target.Lsh(target, uint(256-difficulty))
+
+	return target
+}
+
+// Difficulty block was mined at
+func BlockDifficulty(block representations.Block) int {
+	if block.Difficulty == 0 {
+		return legacyDifficulty
+	}
+	return block.Difficulty
+}
+
+// Hashes it takes on average to mine block: 2 to the power of its difficulty. Blocks signed by a proposer take none,
+// and count for 1 each, so the longer proof of stake or BFT chain has more work
+func BlockWork(block representations.Block) *big.Int {
+	if len(block.Proposer) > 0 {
+		return big.NewInt(1)
+	}
+	return new(big.Int).Lsh(big.NewInt(1), uint(BlockDifficulty(block)))
+}
+
+// Work done on the chain up to and including block. 0 if it isn't known
+func ChainWork(block representations.Block) *big.Int {
+	work, ok := new(big.Int).SetString(block.ChainWork, 16)
+	if !ok {
+		return new(big.Int)
+	}
+	return work
+}
+
+// Chain work of block, on top of parent's
+func nextChainWork(parent representations.Block, block representations.Block) string {
+	return new(big.Int).Add(ChainWork(parent), BlockWork(block)).Text(16)
+}
+
+// Find a nounce the block hashes under the target with, and set it on the block. The nounce space is split across
+// MiningWorkers goroutines, worker i trying i, i+MiningWorkers, i+2*MiningWorkers and so on, and all of them
+// stop as soon as any finds one, so it isn't necessarily the lowest nounce that works. Under a MiningDutyCycle of
+// 100, each sleeps after every MiningThrottleBatch hashes for as long as it'll have been hashing for the percent
 func (pow *powService) Solve() (int64, []byte) {
-	nounce := 0
-	var solvedHash []byte
-	solvedHashInt := new(big.Int)
-
-	for {
-		pow.Block.Nounce = int64(nounce)
-		solvedHash = pow.HashData()
-		solvedHashInt.SetBytes(solvedHash)
-
-		// Check if HASH(data + nounce) < target number
-		if solvedHashInt.Cmp(pow.Target) == -1 {
-			break
-		} else {
-			nounce++
-		}
+	workers := MiningWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	dutyCycle := MiningDutyCycle
+	if dutyCycle < 1 || dutyCycle > 100 {
+		dutyCycle = 100
+	}
+	headerPrefix := HeaderPrefix(*pow.Block)
+
+	type solution struct {
+		nounce int64
+		hash   []byte
+	}
+	solved := make(chan solution, 1)
+	var found int32
+	var wg sync.WaitGroup
+
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func(nounce int64) {
+			defer wg.Done()
+			hashInt := new(big.Int)
+			hashes, batchStart := 0, time.Now()
+
+			for ; atomic.LoadInt32(&found) == 0; nounce += int64(workers) {
+				// Check if HASH(data + nounce) < target number
+				hash := pow.hashNounce(headerPrefix, nounce)
+				if hashInt.SetBytes(hash).Cmp(pow.Target) == -1 {
+					if atomic.CompareAndSwapInt32(&found, 0, 1) {
+						solved <- solution{nounce: nounce, hash: hash}
+					}
+					return
+				}
+
+				if hashes++; dutyCycle < 100 && hashes%MiningThrottleBatch == 0 {
+					busy := time.Since(batchStart)
+					time.Sleep(busy * time.Duration(100-dutyCycle) / time.Duration(dutyCycle))
+					batchStart = time.Now()
+				}
+			}
+		}(int64(worker))
 	}
 
+	winner := <-solved
+	wg.Wait()
+
 	// miner is basically trying to solve for nounce.
-	return int64(nounce), solvedHash
+	pow.Block.Nounce = winner.nounce
+	return winner.nounce, winner.hash
 }
 
-// sha256 hash the block data and nounce
+// Hash the block header and nounce
 func (pow *powService) HashData() []byte {
+	return pow.hashNounce(HeaderPrefix(*pow.Block), pow.Block.Nounce)
+}
+
+func (pow *powService) hashNounce(headerPrefix []byte, nounce int64) []byte {
 	joined := bytes.Join([][]byte{
-		pow.txnAssembler.HashTransactions(pow.Block.Transactions),
-		pow.Block.PrevHash,
-		utils.Int64ToByte(pow.Block.Timestamp),
-		utils.Int64ToByte(pow.Block.Nounce),
+		headerPrefix,
+		utils.Int64ToByte(nounce),
+	}, []byte{})
+	return pow.hasher.Hash(joined)
+}
+
+// Block header the nounce is appended to before hashing: version, merkle root, previous hash, timestamp, difficulty,
+// height, round and chain id, numbers in decimal digits. Blocks from before versions leave the version, difficulty and
+// height out, blocks proposed in the first round, or not proposed in rounds at all, the round, and blocks on chains
+// without replay protection the chain id
+func HeaderPrefix(block representations.Block) []byte {
+	// A block with neither a merkle root nor transactions hashes without one. It's rejected before it gets this far
+	merkleRoot := block.MerkleRoot
+	if len(merkleRoot) == 0 {
+		merkleRoot, _ = TxnAssembler.HashTransactions(block.Transactions)
+	}
+
+	// Otherwise a block could claim more work than went into it
+	var version, difficulty, height []byte
+	if block.Version != 0 {
+		version = utils.Int64ToByte(int64(block.Version))
+		difficulty = utils.Int64ToByte(int64(block.Difficulty))
+		height = utils.Int64ToByte(int64(block.Height))
+	}
+
+	var round []byte
+	if block.Round != 0 {
+		round = utils.Int64ToByte(int64(block.Round))
+	}
+
+	return bytes.Join([][]byte{
+		version,
+		merkleRoot,
+		block.PrevHash,
+		utils.Int64ToByte(block.Timestamp),
+		difficulty,
+		height,
+		round,
+		[]byte(block.ChainID),
 	}, []byte{})
-	hash := sha256.Sum256(joined)
-	return hash[:]
 }
 
 func (pow *powService) ValidateProof() bool {
@@ -81,3 +206,34 @@ func (pow *powService) ValidateProof() bool {
 
 	return proposedHashInt.Cmp(pow.Target) == -1
 }
+
+// Split the nounce space across MINING_WORKERS goroutines, if it's set, instead of one per CPU, and have them hash
+// MINING_DUTY_CYCLE percent of the time, if it's set, so mining doesn't take every CPU on a shared machine.
+// COINBASE_MESSAGE sets the message in the coinbase of blocks mined here
+func MiningAtStartup() {
+	if envMiningWorkers := os.Getenv("MINING_WORKERS"); envMiningWorkers != "" {
+		workers, err := strconv.Atoi(envMiningWorkers)
+		if err != nil || workers < 1 {
+			log.Warn("Invalid MINING_WORKERS, using default of ", MiningWorkers)
+		} else {
+			MiningWorkers = workers
+		}
+	}
+
+	if envMiningDutyCycle := os.Getenv("MINING_DUTY_CYCLE"); envMiningDutyCycle != "" {
+		dutyCycle, err := strconv.Atoi(envMiningDutyCycle)
+		if err != nil || dutyCycle < 1 || dutyCycle > 100 {
+			log.Warn("Invalid MINING_DUTY_CYCLE, using default of ", MiningDutyCycle)
+		} else {
+			MiningDutyCycle = dutyCycle
+		}
+	}
+
+	if envCoinbaseMessage := os.Getenv("COINBASE_MESSAGE"); envCoinbaseMessage != "" {
+		if len(envCoinbaseMessage) > MaxCoinbaseMessageLen {
+			log.Warnf("COINBASE_MESSAGE is longer than %d bytes, leaving it out of coinbases", MaxCoinbaseMessageLen)
+		} else {
+			CoinbaseMessage = envCoinbaseMessage
+		}
+	}
+}
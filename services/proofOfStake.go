@@ -0,0 +1,244 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/brucetieu/blockchain/repository"
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/utils"
+)
+
+// How a chain picks who produces its blocks
+const (
+	ConsensusPoW = "pow" // Whoever solves the proof of work first
+	ConsensusPoS = "pos" // A validator elected by stake weight, who signs the block
+	ConsensusBFT = "bft" // Validators of a fixed set in turn, who sign the block and vote it final
+)
+
+// Prepended to a block's hash before its proposer signs it, so a block signature can never double as any other
+var blockProposalPrefix = []byte("Blockchain Block Proposal:\n")
+
+// Whether blocks on the chain are produced by validators elected by stake weight, instead of by proof of work
+func IsProofOfStake(params *reps.ChainParams) bool {
+	return params.Consensus == ConsensusPoS
+}
+
+// Every validator in order of public key hash, so every node walks them the same way, and their total stake
+func bondedStakes(blockchainRepo repository.BlockchainRepository) ([]reps.Validator, int, error) {
+	validators, err := blockchainRepo.GetValidators()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total := 0
+	for _, validator := range validators {
+		total += validator.Bonded
+	}
+	sort.Slice(validators, func(i, j int) bool { return validators[i].PubKeyHash < validators[j].PubKeyHash })
+
+	return validators, total, nil
+}
+
+// Public key hash of the validator elected to produce the block at height on top of the one with prevHash, out of
+// those with stake bonded on the chain as it is. Each is elected with odds in proportion to its stake, by a draw
+// seeded with prevHash and height, so every node elects the same one. Nil while nothing is staked, when anyone can
+// produce blocks, so the chain can get going
+func ElectProposer(blockchainRepo repository.BlockchainRepository, prevHash []byte, height int) ([]byte, error) {
+	validators, total, err := bondedStakes(blockchainRepo)
+	if err != nil || total == 0 {
+		return nil, err
+	}
+
+	seed := sha256.Sum256(bytes.Join([][]byte{prevHash, utils.Int64ToByte(int64(height))}, []byte{}))
+	draw := new(big.Int).Mod(new(big.Int).SetBytes(seed[:]), big.NewInt(int64(total))).Int64()
+	for _, validator := range validators {
+		if draw < int64(validator.Bonded) {
+			return hex.DecodeString(validator.PubKeyHash)
+		}
+		draw -= int64(validator.Bonded)
+	}
+
+	return nil, fmt.Errorf("no validator drawn out of %d staked", total)
+}
+
+// What a block's proposer signs
+func ProposalHash(block reps.Block) []byte {
+	hash := sha256.Sum256(append(append([]byte{}, blockProposalPrefix...), block.Hash...))
+	return hash[:]
+}
+
+// Check a block carries its proposer's signature of its hash. Whether the proposer was the one elected is up to
+// the chain it's added to
+func VerifyProposerSignature(block reps.Block) error {
+	if len(block.Proposer) == 0 || len(block.ProposerSig) == 0 {
+		return fmt.Errorf("%w: block %s isn't signed by a proposer", ErrInvalidBlock, block.ID)
+	}
+
+	scheme, err := GetSignatureScheme(block.SigAlgorithm)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidBlock, err.Error())
+	}
+	if !scheme.ValidPublicKey(block.Proposer) || !scheme.Verify(block.Proposer, block.ProposerSig, ProposalHash(block)) {
+		return fmt.Errorf("%w: proposer signature of block %s doesn't check out", ErrInvalidBlock, block.ID)
+	}
+
+	return nil
+}
+
+// Blocks on a proof of stake or BFT chain are signed by the validator elected for them instead of mined, and have no
+// difficulty
+type proposerEngine struct {
+	blockchainRepo repository.BlockchainRepository
+	walletService  WalletService
+	signer         BlockSigner
+	params         *reps.ChainParams
+}
+
+func newProposerEngine(blockchainRepo repository.BlockchainRepository, walletService WalletService, signer BlockSigner,
+	params *reps.ChainParams) ConsensusEngine {
+	return &proposerEngine{
+		blockchainRepo: blockchainRepo,
+		walletService:  walletService,
+		signer:         signer,
+		params:         params,
+	}
+}
+
+// Nothing to mine
+func (e *proposerEngine) NextDifficulty(parent reps.Block, height int) (int, error) {
+	return 0, nil
+}
+
+// The hash has to be signed by a proposer. On top of its parent, a block past the genesis has to be signed by the
+// validator elected for it, and the genesis by nobody, since there's nobody to elect yet
+func (e *proposerEngine) ValidateHeader(block reps.Block, parent *reps.Block) error {
+	hasher, err := GetBlockHasher(e.params.HashAlgorithm)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(NewProofOfWorkService(&block, hasher).HashData(), block.Hash) {
+		return fmt.Errorf("%w: %s hash of block %s doesn't match its contents", ErrInvalidBlock, hasher.Algorithm(), block.ID)
+	}
+
+	if parent == nil {
+		return VerifyProposerSignature(block)
+	}
+	if block.Height == 0 {
+		return nil
+	}
+	return e.checkProposer(*parent, block)
+}
+
+// A block past the genesis has to be signed by the validator elected for it
+func (e *proposerEngine) checkProposer(parent reps.Block, block reps.Block) error {
+	if err := VerifyProposerSignature(block); err != nil {
+		return err
+	}
+	if err := checkSigAlgorithmActive(e.params, block.SigAlgorithm, block.Height); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidBlock, err.Error())
+	}
+
+	elected, err := electedProposer(e.blockchainRepo, e.params, parent, block)
+	if err != nil {
+		return err
+	}
+
+	pubKeyHash, _ := createPubKeyHash(block.Proposer)
+	if elected != nil && !bytes.Equal(pubKeyHash, elected) {
+		return fmt.Errorf("%w: block %s is proposed by %x, but %x is elected for height %d", ErrInvalidBlock, block.ID, pubKeyHash, elected, block.Height)
+	}
+
+	return nil
+}
+
+// Hash the block as it is, without searching for a nounce, and sign the hash with the key of producer, which has to
+// be the validator elected for the block. On a BFT chain it's proposed in the first round that's the validator's
+// turn. The genesis is only hashed
+func (e *proposerEngine) Seal(block reps.Block, producer string) (reps.Block, error) {
+	if block.Height == 0 {
+		block.Hash = NewProofOfWorkService(&block, chainHasher(e.params)).HashData()
+		return block, nil
+	}
+	if e.walletService == nil || e.signer == nil {
+		return reps.Block{}, fmt.Errorf("block %d has to be signed by its proposer, and there are no wallets to sign it with", block.Height)
+	}
+
+	wallet, err := e.walletService.GetWallet(producer)
+	if err != nil {
+		return reps.Block{}, err
+	}
+	if wallet.WatchOnly {
+		return reps.Block{}, fmt.Errorf("%w: %s", ErrWatchOnly, producer)
+	}
+
+	pubKey, err := hex.DecodeString(wallet.PublicKey)
+	if err != nil {
+		return reps.Block{}, fmt.Errorf("%s, unable to read public key of %s", err.Error(), producer)
+	}
+	pubKeyHash, _ := createPubKeyHash(pubKey)
+
+	parent, err := e.blockchainRepo.GetBlockByHash(block.PrevHash)
+	if err != nil {
+		return reps.Block{}, err
+	}
+	validators, err := ValidatorsAt(e.blockchainRepo, e.params, block.Height)
+	if err != nil {
+		return reps.Block{}, err
+	}
+	if round := bftTurn(e.params, validators, parent, block.Timestamp, block.Height, pubKeyHash); round > 0 {
+		block.Round = round
+	}
+
+	elected, err := electedProposer(e.blockchainRepo, e.params, parent, block)
+	if err != nil {
+		return reps.Block{}, err
+	}
+	if elected != nil && !bytes.Equal(pubKeyHash, elected) {
+		return reps.Block{}, fmt.Errorf("%w: %s isn't elected to produce block %d", ErrNotProposer, producer, block.Height)
+	}
+
+	block.Hash = NewProofOfWorkService(&block, chainHasher(e.params)).HashData()
+	block, err = e.signer.SignBlock(block, wallet)
+	if err != nil {
+		return reps.Block{}, err
+	}
+
+	// Signed, it counts as a block produced by a proposer
+	block.ChainWork = nextChainWork(parent, block)
+
+	return block, nil
+}
+
+// The longer chain wins, every signed block counting for the same work. The first seen keeps the node when they're tied
+func (e *proposerEngine) CompareChains(current reps.Block, candidate reps.Block) int {
+	return ChainWork(candidate).Cmp(ChainWork(current))
+}
+
+// Sign a block's hash as its proposer, with the wallet's key, through the configured signer
+func (ts *transactionService) SignBlock(block reps.Block, wallet reps.Wallet) (reps.Block, error) {
+	scheme, err := GetSignatureScheme(wallet.SigAlgorithm)
+	if err != nil {
+		return reps.Block{}, err
+	}
+
+	pubKey, err := hex.DecodeString(wallet.PublicKey)
+	if err != nil {
+		return reps.Block{}, fmt.Errorf("%s, unable to read public key of %s", err.Error(), wallet.Address)
+	}
+
+	signature, err := ts.signer.Sign(wallet, ProposalHash(block))
+	if err != nil {
+		return reps.Block{}, err
+	}
+
+	block.Proposer = pubKey
+	block.SigAlgorithm = scheme.Algorithm()
+	block.ProposerSig = signature
+
+	return block, nil
+}
@@ -0,0 +1,294 @@
+package services
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	reps "github.com/brucetieu/blockchain/representations"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Add a block whose parent is known to the chain if it builds on the last block. Otherwise it goes on a side branch,
+// and if that branch now beats the chain by the consensus engine's reckoning, the chain is reorganized onto it
+func (bc *blockchainService) connectBlock(block reps.Block, update *reps.ChainUpdate) error {
+	lastBlock, err := bc.blockchainRepo.GetLastBlock()
+	if err != nil {
+		return fmt.Errorf("%s, cannot add a block without genesis", err.Error())
+	}
+
+	if bytes.Equal(block.PrevHash, lastBlock.Hash) {
+		block.ChainWork = nextChainWork(lastBlock, block)
+		if err := bc.addReceivedBlock(block); err != nil {
+			return err
+		}
+		update.Connected = append(update.Connected, block)
+		return nil
+	}
+
+	fork, branch, err := bc.sideBranch(block)
+	if err != nil {
+		return err
+	}
+	parent := fork
+	if len(branch) > 1 {
+		parent = branch[len(branch)-2]
+	}
+	if err := bc.checkDifficulty(parent, block); err != nil {
+		return err
+	}
+	block.ChainWork = nextChainWork(parent, block)
+	branch[len(branch)-1] = block
+	if err := bc.storeSideBlock(block); err != nil {
+		return err
+	}
+
+	// The first branch seen keeps the chain when they're tied
+	if bc.engine.CompareChains(lastBlock, block) <= 0 {
+		log.WithFields(log.Fields{"hash": hex.EncodeToString(block.Hash), "height": block.Height, "forkHeight": fork.Height}).Info("Stored block on a side branch")
+		update.Side = append(update.Side, block)
+		return nil
+	}
+
+	return bc.reorganize(fork, branch, update)
+}
+
+// Side blocks count toward their branch's work by the difficulty they claim, before they're fully validated, so it
+// has to be the one the chain calls for on top of parent. Blocks signed by a proposer count the same whatever it is
+func (bc *blockchainService) checkDifficulty(parent reps.Block, block reps.Block) error {
+	if len(block.Proposer) > 0 {
+		return nil
+	}
+	expected, err := bc.engine.NextDifficulty(parent, block.Height)
+	if err != nil {
+		return err
+	}
+	if difficulty := BlockDifficulty(block); difficulty != expected {
+		return fmt.Errorf("%w: block %s has difficulty %d, expected %d", ErrInvalidBlock, block.ID, difficulty, expected)
+	}
+	return nil
+}
+
+// The side branch block is on, oldest first and ending with block, and the block on the chain it forks from.
+// Every block on it has to be one higher than its parent
+func (bc *blockchainService) sideBranch(block reps.Block) (reps.Block, []reps.Block, error) {
+	branch := []reps.Block{block}
+	for {
+		child := branch[0]
+		if parent, err := bc.blockchainRepo.GetBlockByHash(child.PrevHash); err == nil {
+			if child.Height != parent.Height+1 {
+				return reps.Block{}, nil, fmt.Errorf("%w: block %s has height %d, expected %d", ErrInvalidBlock, child.ID, child.Height, parent.Height+1)
+			}
+			return parent, branch, nil
+		}
+
+		parent, err := bc.getSideBlock(child.PrevHash)
+		if err != nil {
+			return reps.Block{}, nil, fmt.Errorf("%w: no block with hash %x to build on", ErrInvalidBlock, child.PrevHash)
+		}
+		if child.Height != parent.Height+1 {
+			return reps.Block{}, nil, fmt.Errorf("%w: block %s has height %d, expected %d", ErrInvalidBlock, child.ID, child.Height, parent.Height+1)
+		}
+		branch = append([]reps.Block{parent}, branch...)
+	}
+}
+
+// Take the blocks above fork off the chain and add branch in their place, validating each block as it's added.
+// If one doesn't check out, the chain is put back the way it was and the rest of the branch is thrown away.
+// The blocks taken off are kept on a side branch, so the chain can go back to them if it overtakes again. Reorgs
+// deeper than ReorgAlertDepth raise an alert
+func (bc *blockchainService) reorganize(fork reps.Block, branch []reps.Block, update *reps.ChainUpdate) error {
+	lastBlock, err := bc.blockchainRepo.GetLastBlock()
+	if err != nil {
+		return err
+	}
+
+	// Once the chain is past the last checkpoint, nothing below it can change
+	if last := LastCheckpoint(); fork.Height < last && lastBlock.Height >= last {
+		return fmt.Errorf("%w: branch forks off at height %d, below the checkpoint at height %d", ErrInvalidBlock, fork.Height, last)
+	}
+
+	// Nor can a block validators voted final
+	if IsBFT(bc.params) {
+		if finalized, err := bc.blockchainRepo.GetLastFinalizedBlock(); err == nil && fork.Height < finalized.Height {
+			return fmt.Errorf("%w: branch forks off at height %d, below the block finalized at height %d", ErrInvalidBlock, fork.Height, finalized.Height)
+		}
+	}
+
+	// Nor anything up to the snapshot the chain was started from, since the node doesn't have the blocks before it
+	if base, err := bc.blockchainRepo.GetSnapshotBase(); err == nil && fork.Height < base.Height {
+		return fmt.Errorf("%w: branch forks off at height %d, below the snapshot the chain was started from at height %d", ErrInvalidBlock, fork.Height, base.Height)
+	}
+
+	disconnected, err := bc.blockchainRepo.GetBlocksByHeight(fork.Height+1, lastBlock.Height-fork.Height)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{"forkHeight": fork.Height, "depth": len(disconnected), "newTip": hex.EncodeToString(branch[len(branch)-1].Hash)}).Warn("Reorganizing chain onto a branch with more work")
+	if err := bc.rewindTo(fork.Height); err != nil {
+		return err
+	}
+
+	for i, block := range branch {
+		if err := bc.addReceivedBlock(block); err != nil {
+			log.WithFields(log.Fields{"hash": hex.EncodeToString(block.Hash), "error": err.Error()}).Error("Invalid block on branch, reverting reorg")
+			for _, invalid := range branch[i:] {
+				bc.dropSideBlock(invalid)
+			}
+			if err := bc.restore(fork.Height, disconnected); err != nil {
+				return fmt.Errorf("%s, cannot put back the chain after an invalid branch", err.Error())
+			}
+			return err
+		}
+	}
+
+	for _, block := range branch {
+		bc.dropSideBlock(block)
+	}
+	for _, block := range disconnected {
+		if err := bc.storeSideBlock(block); err != nil {
+			log.WithField("hash", hex.EncodeToString(block.Hash)).Error("Error keeping block taken off the chain: ", err.Error())
+		}
+	}
+
+	// Blocks connected earlier on can have been taken off again
+	connected := make([]reps.Block, 0, len(update.Connected)+len(branch))
+	for _, block := range update.Connected {
+		if block.Height <= fork.Height {
+			connected = append(connected, block)
+		}
+	}
+	update.Connected = append(connected, branch...)
+	update.Disconnected = append(update.Disconnected, disconnected...)
+	reportReorg(fork, disconnected, branch)
+
+	return nil
+}
+
+// Take every block above height off the chain, and rebuild the UTXO set and indexes from what's left
+func (bc *blockchainService) rewindTo(height int) error {
+	if err := bc.blockchainRepo.DeleteBlocksFrom(height + 1); err != nil {
+		return err
+	}
+	if _, err := bc.transactionService.ReindexUnspentOutputs(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Put back blocks that were on the chain above height, which were validated when they were first added
+func (bc *blockchainService) restore(height int, blocks []reps.Block) error {
+	if err := bc.rewindTo(height); err != nil {
+		return err
+	}
+
+	for _, block := range blocks {
+		if err := bc.blockchainRepo.CreateBlock(block); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (bc *blockchainService) storeSideBlock(block reps.Block) error {
+	data, err := json.Marshal(block)
+	if err != nil {
+		return err
+	}
+
+	return bc.blockchainRepo.CreateSideBlock(reps.SideBlock{
+		Hash:       hex.EncodeToString(block.Hash),
+		PrevHash:   hex.EncodeToString(block.PrevHash),
+		Height:     block.Height,
+		Data:       data,
+		ReceivedAt: time.Now().UnixMilli(),
+	})
+}
+
+func (bc *blockchainService) getSideBlock(hash []byte) (reps.Block, error) {
+	sideBlock, err := bc.blockchainRepo.GetSideBlock(hex.EncodeToString(hash))
+	if err != nil {
+		return reps.Block{}, err
+	}
+
+	return decodeSideBlock(sideBlock)
+}
+
+func decodeSideBlock(sideBlock reps.SideBlock) (reps.Block, error) {
+	var block reps.Block
+	if err := json.Unmarshal(sideBlock.Data, &block); err != nil {
+		return reps.Block{}, fmt.Errorf("%s, side block %s", err.Error(), sideBlock.Hash)
+	}
+
+	return block, nil
+}
+
+func (bc *blockchainService) dropSideBlock(block reps.Block) {
+	if err := bc.blockchainRepo.DeleteSideBlock(hex.EncodeToString(block.Hash)); err != nil {
+		log.WithField("hash", hex.EncodeToString(block.Hash)).Error("Error deleting side block: ", err.Error())
+	}
+}
+
+// The last block of the chain and of every side branch, the chain first and then side branches from the most recent fork
+func (bc *blockchainService) GetChainTips() ([]reps.ChainTip, error) {
+	lastBlock, err := bc.blockchainRepo.GetLastBlock()
+	if err != nil {
+		return nil, fmt.Errorf("%s, there's no chain yet", err.Error())
+	}
+	tips := []reps.ChainTip{{
+		Hash:       hex.EncodeToString(lastBlock.Hash),
+		Height:     lastBlock.Height,
+		ForkHeight: lastBlock.Height,
+		ChainWork:  lastBlock.ChainWork,
+		Status:     reps.ChainTipActive,
+	}}
+
+	sideBlocks, err := bc.blockchainRepo.GetSideBlocks()
+	if err != nil {
+		return nil, err
+	}
+
+	// A side block no other side block builds on ends its branch
+	parents := make(map[string]bool)
+	for _, sideBlock := range sideBlocks {
+		parents[sideBlock.PrevHash] = true
+	}
+
+	sideTips := make([]reps.ChainTip, 0)
+	for _, sideBlock := range sideBlocks {
+		if parents[sideBlock.Hash] {
+			continue
+		}
+
+		block, err := decodeSideBlock(sideBlock)
+		if err != nil {
+			return nil, err
+		}
+		fork, branch, err := bc.sideBranch(block)
+		if err != nil {
+			log.WithField("hash", sideBlock.Hash).Warn("Side branch no longer builds on the chain: ", err.Error())
+			continue
+		}
+
+		sideTips = append(sideTips, reps.ChainTip{
+			Hash:         sideBlock.Hash,
+			Height:       sideBlock.Height,
+			ForkHeight:   fork.Height,
+			BranchLength: len(branch),
+			ChainWork:    block.ChainWork,
+			Status:       reps.ChainTipSide,
+		})
+	}
+	sort.SliceStable(sideTips, func(i, j int) bool {
+		return sideTips[i].ForkHeight > sideTips[j].ForkHeight
+	})
+
+	return append(tips, sideTips...), nil
+}
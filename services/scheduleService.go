@@ -0,0 +1,256 @@
+package services
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brucetieu/blockchain/repository"
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/google/uuid"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	SchedulerTick       = 30 * time.Second // How often the scheduler looks for payments that are due
+	MinScheduleInterval = time.Minute      // Shortest interval a payment can recur at
+)
+
+// Shorthands for common intervals
+var scheduleIntervals = map[string]time.Duration{
+	"@hourly": time.Hour,
+	"@daily":  24 * time.Hour,
+	"@weekly": 7 * 24 * time.Hour,
+}
+
+// Recurring payments, which the node creates and queues in the mempool whenever they're due
+type ScheduleService interface {
+	CreateSchedule(input reps.ScheduleInput) (reps.Schedule, error)
+	GetSchedule(id string) (reps.Schedule, error)
+	GetSchedules() ([]reps.Schedule, error)
+	CancelSchedule(id string) (reps.Schedule, error)
+
+	RunDueSchedules(now time.Time) ([]reps.Schedule, error)
+}
+
+type scheduleService struct {
+	scheduleRepo       repository.ScheduleRepository
+	transactionService TransactionService
+	mempoolService     MempoolService
+	walletService      WalletService
+	runMu              sync.Mutex
+}
+
+func NewScheduleService(scheduleRepo repository.ScheduleRepository, transactionService TransactionService,
+	mempoolService MempoolService, walletService WalletService) ScheduleService {
+	return &scheduleService{
+		scheduleRepo:       scheduleRepo,
+		transactionService: transactionService,
+		mempoolService:     mempoolService,
+		walletService:      walletService,
+	}
+}
+
+// How often a payment with interval recurs. Either @every and a duration such as 36h, or @hourly, @daily or @weekly
+func ParseScheduleInterval(interval string) (time.Duration, error) {
+	interval = strings.TrimSpace(interval)
+
+	every, ok := scheduleIntervals[interval]
+	if !ok {
+		if !strings.HasPrefix(interval, "@every ") {
+			return 0, fmt.Errorf("invalid interval %s, expected @every <duration>, @hourly, @daily or @weekly", interval)
+		}
+
+		var err error
+		every, err = time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(interval, "@every ")))
+		if err != nil {
+			return 0, fmt.Errorf("%s, invalid interval %s", err.Error(), interval)
+		}
+	}
+
+	if every < MinScheduleInterval {
+		return 0, fmt.Errorf("interval %s is shorter than the minimum of %s", interval, MinScheduleInterval)
+	}
+
+	return every, nil
+}
+
+// Schedule a payment of amount from one address to another, every interval
+func (ss *scheduleService) CreateSchedule(input reps.ScheduleInput) (reps.Schedule, error) {
+	log.WithFields(log.Fields{"from": input.From, "to": input.To, "amount": input.Amount, "interval": input.Interval}).Info("Scheduling recurring payment")
+
+	every, err := ParseScheduleInterval(input.Interval)
+	if err != nil {
+		return reps.Schedule{}, err
+	}
+
+	if input.Amount <= 0 {
+		return reps.Schedule{}, fmt.Errorf("amount must be positive, not %d", input.Amount)
+	}
+
+	if err := validateOptions(reps.TxnOptions{Fee: input.Fee, FeeRate: input.FeeRate, Memo: input.Memo}); err != nil {
+		return reps.Schedule{}, err
+	}
+
+	// It would only ever fail
+	wallet, err := ss.walletService.GetWallet(input.From)
+	if err != nil {
+		return reps.Schedule{}, err
+	}
+	if wallet.WatchOnly {
+		return reps.Schedule{}, fmt.Errorf("%w: %s", ErrWatchOnly, input.From)
+	}
+
+	now := time.Now()
+	nextRunAt := now.Add(every).UnixMilli()
+	if input.StartAt != 0 {
+		if input.StartAt < now.UnixMilli() {
+			return reps.Schedule{}, fmt.Errorf("start %d is in the past", input.StartAt)
+		}
+		nextRunAt = input.StartAt
+	}
+
+	schedule := reps.Schedule{
+		ID:        uuid.Must(uuid.NewRandom()).String(),
+		From:      input.From,
+		To:        input.To,
+		Amount:    input.Amount,
+		Fee:       input.Fee,
+		FeeRate:   input.FeeRate,
+		Memo:      input.Memo,
+		Interval:  strings.TrimSpace(input.Interval),
+		Status:    reps.ScheduleActive,
+		CreatedAt: now.UnixMilli(),
+		NextRunAt: nextRunAt,
+	}
+
+	if err := ss.scheduleRepo.CreateSchedule(schedule); err != nil {
+		return reps.Schedule{}, err
+	}
+
+	return schedule, nil
+}
+
+func (ss *scheduleService) GetSchedule(id string) (reps.Schedule, error) {
+	schedule, err := ss.scheduleRepo.GetSchedule(id)
+	if err != nil {
+		return reps.Schedule{}, fmt.Errorf("%s, schedule id: %s", err.Error(), id)
+	}
+
+	return schedule, nil
+}
+
+// Get every schedule, cancelled ones included
+func (ss *scheduleService) GetSchedules() ([]reps.Schedule, error) {
+	return ss.scheduleRepo.GetSchedules()
+}
+
+// Stop making a payment. The schedule is kept, so its history can still be looked at
+func (ss *scheduleService) CancelSchedule(id string) (reps.Schedule, error) {
+	log.WithField("id", id).Info("Cancelling schedule")
+
+	ss.runMu.Lock()
+	defer ss.runMu.Unlock()
+
+	schedule, err := ss.GetSchedule(id)
+	if err != nil {
+		return reps.Schedule{}, err
+	}
+
+	if schedule.Status == reps.ScheduleCancelled {
+		return reps.Schedule{}, fmt.Errorf("schedule %s is already cancelled", id)
+	}
+
+	schedule.Status = reps.ScheduleCancelled
+	if err := ss.scheduleRepo.UpdateSchedule(schedule); err != nil {
+		return reps.Schedule{}, err
+	}
+
+	return schedule, nil
+}
+
+// Make every active payment due by now. A failed payment, e.g. while the wallet file is locked or the address
+// is short of coins, is skipped until its next run. Runs missed while the node was down aren't made up for
+func (ss *scheduleService) RunDueSchedules(now time.Time) ([]reps.Schedule, error) {
+	ss.runMu.Lock()
+	defer ss.runMu.Unlock()
+
+	schedules, err := ss.scheduleRepo.GetSchedules()
+	if err != nil {
+		return []reps.Schedule{}, err
+	}
+
+	ran := make([]reps.Schedule, 0)
+	for _, schedule := range schedules {
+		if schedule.Status != reps.ScheduleActive || schedule.NextRunAt > now.UnixMilli() {
+			continue
+		}
+
+		every, err := ParseScheduleInterval(schedule.Interval)
+		if err != nil {
+			log.WithField("id", schedule.ID).Error("Skipping schedule: ", err.Error())
+			continue
+		}
+
+		schedule.Runs++
+		schedule.LastRunAt = now.UnixMilli()
+		schedule.LastTxnID, schedule.LastError = "", ""
+		if txn, err := ss.pay(schedule); err != nil {
+			log.WithFields(log.Fields{"id": schedule.ID, "error": err.Error()}).Error("Scheduled payment failed")
+			schedule.LastError = err.Error()
+		} else {
+			schedule.LastTxnID = hex.EncodeToString(txn.ID)
+		}
+
+		for schedule.NextRunAt <= now.UnixMilli() {
+			schedule.NextRunAt += every.Milliseconds()
+		}
+
+		if err := ss.scheduleRepo.UpdateSchedule(schedule); err != nil {
+			return ran, err
+		}
+		ran = append(ran, schedule)
+	}
+
+	return ran, nil
+}
+
+// Create the payment and queue it in the mempool
+func (ss *scheduleService) pay(schedule reps.Schedule) (reps.Transaction, error) {
+	opts := reps.TxnOptions{Fee: schedule.Fee, FeeRate: schedule.FeeRate, Memo: schedule.Memo}
+	txn, err := ss.transactionService.CreateTransactionToRecipients(schedule.From, []reps.Recipient{{To: schedule.To, Amount: schedule.Amount}}, opts)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	if _, err := ss.mempoolService.AddTransaction(txn); err != nil {
+		return reps.Transaction{}, err
+	}
+
+	return txn, nil
+}
+
+// Look for due payments every SchedulerTick for as long as the node runs. SCHEDULER_TICK, e.g. 1m, overrides how often
+func StartSchedulerAtStartup(scheduleService ScheduleService) {
+	if envTick := os.Getenv("SCHEDULER_TICK"); envTick != "" {
+		tick, err := time.ParseDuration(envTick)
+		if err != nil || tick <= 0 {
+			log.Warn("Invalid SCHEDULER_TICK, using default of ", SchedulerTick)
+		} else {
+			SchedulerTick = tick
+		}
+	}
+
+	ticker := time.NewTicker(SchedulerTick)
+	go func() {
+		for now := range ticker.C {
+			if _, err := scheduleService.RunDueSchedules(now); err != nil {
+				log.Error("Error running scheduled payments: ", err.Error())
+			}
+		}
+	}()
+}
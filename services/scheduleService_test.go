@@ -0,0 +1,87 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseScheduleInterval(t *testing.T) {
+	every, err := services.ParseScheduleInterval("@every 36h")
+	assert.NoError(t, err)
+	assert.Equal(t, 36*time.Hour, every)
+
+	every, err = services.ParseScheduleInterval("@daily")
+	assert.NoError(t, err)
+	assert.Equal(t, 24*time.Hour, every)
+
+	for _, interval := range []string{"36h", "@every soon", "@every 1s", "@monthly"} {
+		_, err = services.ParseScheduleInterval(interval)
+		assert.Error(t, err, interval)
+	}
+}
+
+func TestScheduledPaymentRunsUntilCancelled(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+	mempoolService := ts.mempoolService
+	scheduleService := services.NewScheduleService(newFakeScheduleRepository(), txnService, mempoolService, walletService)
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	_, err = scheduleService.CreateSchedule(reps.ScheduleInput{From: from.Address, To: to.Address, Amount: 0, Interval: "@hourly"})
+	assert.Error(t, err)
+
+	schedule, err := scheduleService.CreateSchedule(reps.ScheduleInput{From: from.Address, To: to.Address, Amount: 5, Interval: "@hourly"})
+	assert.NoError(t, err)
+	assert.Equal(t, reps.ScheduleActive, schedule.Status)
+	firstRun := time.UnixMilli(schedule.NextRunAt)
+
+	// Not due yet
+	ran, err := scheduleService.RunDueSchedules(firstRun.Add(-time.Minute))
+	assert.NoError(t, err)
+	assert.Len(t, ran, 0)
+	assert.Equal(t, 0, mempoolService.Size())
+
+	ran, err = scheduleService.RunDueSchedules(firstRun)
+	assert.NoError(t, err)
+	assert.Len(t, ran, 1)
+	assert.Equal(t, 1, ran[0].Runs)
+	assert.Equal(t, "", ran[0].LastError)
+	assert.Equal(t, firstRun.Add(time.Hour).UnixMilli(), ran[0].NextRunAt)
+
+	entry, ok := mempoolService.GetEntry(ran[0].LastTxnID)
+	assert.True(t, ok)
+	assert.Equal(t, 5, entry.Transaction.Outputs[0].Value)
+
+	// Missed runs aren't made up for
+	_, err = mempoolService.MinePendingTransactions(to.Address, "")
+	assert.NoError(t, err)
+	ran, err = scheduleService.RunDueSchedules(firstRun.Add(3*time.Hour + time.Minute))
+	assert.NoError(t, err)
+	assert.Len(t, ran, 1)
+	assert.Equal(t, 2, ran[0].Runs)
+	assert.Equal(t, firstRun.Add(4*time.Hour).UnixMilli(), ran[0].NextRunAt)
+
+	schedule, err = scheduleService.CancelSchedule(schedule.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, reps.ScheduleCancelled, schedule.Status)
+	_, err = scheduleService.CancelSchedule(schedule.ID)
+	assert.Error(t, err)
+
+	ran, err = scheduleService.RunDueSchedules(firstRun.Add(10 * time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, ran, 0)
+
+	schedules, err := scheduleService.GetSchedules()
+	assert.NoError(t, err)
+	assert.Len(t, schedules, 1)
+	assert.Equal(t, 2, schedules[0].Runs)
+}
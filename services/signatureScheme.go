@@ -0,0 +1,141 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+)
+
+// Identifiers carried on wallets and transactions, so verification knows which scheme to use
+const (
+	SigAlgorithmECDSA   = "ecdsa-p256"
+	SigAlgorithmEd25519 = "ed25519"
+)
+
+var (
+	DefaultSigAlgorithm = SigAlgorithmECDSA
+
+	signatureSchemes = map[string]SignatureScheme{
+		SigAlgorithmECDSA:   &ecdsaScheme{},
+		SigAlgorithmEd25519: &ed25519Scheme{},
+	}
+)
+
+// A signature algorithm wallets can hold keys for and transactions can be signed with.
+// Private and public keys are passed around in the byte format stored on wallets
+type SignatureScheme interface {
+	Algorithm() string
+	GenerateKey() ([]byte, []byte, error)
+	PublicKey(privKey []byte) ([]byte, error)
+	ValidPublicKey(pubKey []byte) bool
+	Sign(privKey []byte, hash []byte) ([]byte, error)
+	Verify(pubKey []byte, signature []byte, hash []byte) bool
+}
+
+// Get the scheme for an algorithm identifier. Wallets and transactions from before
+// the identifier existed have none, and are ECDSA
+func GetSignatureScheme(algorithm string) (SignatureScheme, error) {
+	if algorithm == "" {
+		algorithm = SigAlgorithmECDSA
+	}
+
+	scheme, ok := signatureSchemes[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("unsupported signature algorithm: %s", algorithm)
+	}
+
+	return scheme, nil
+}
+
+// P-256 ECDSA. Public keys are x + y, signatures r + s, each padded to 32 bytes
+type ecdsaScheme struct{}
+
+func (e *ecdsaScheme) Algorithm() string {
+	return SigAlgorithmECDSA
+}
+
+func (e *ecdsaScheme) GenerateKey() ([]byte, []byte, error) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return toPrivateKeyBytes(*privKey), toPubKeyBytes(*privKey), nil
+}
+
+func (e *ecdsaScheme) PublicKey(privKey []byte) ([]byte, error) {
+	key := toECDSAPrivateKey(privKey)
+	if key.D == nil || key.D.Sign() == 0 || key.D.Cmp(elliptic.P256().Params().N) >= 0 {
+		return nil, fmt.Errorf("unable to read ecdsa private key")
+	}
+
+	return toPubKeyBytes(key), nil
+}
+
+func (e *ecdsaScheme) ValidPublicKey(pubKey []byte) bool {
+	return isValidPubKey(pubKey)
+}
+
+func (e *ecdsaScheme) Sign(privKey []byte, hash []byte) ([]byte, error) {
+	key := toECDSAPrivateKey(privKey)
+	if key.D == nil {
+		return nil, fmt.Errorf("unable to read ecdsa private key")
+	}
+
+	r, s, err := ecdsa.Sign(rand.Reader, &key, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeSignature(key.Curve, r, s), nil
+}
+
+func (e *ecdsaScheme) Verify(pubKey []byte, signature []byte, hash []byte) bool {
+	return verifyECDSASignature(pubKey, signature, hash)
+}
+
+// Ed25519. Private keys are the 32 byte seed, public keys 32 bytes and signatures 64 bytes
+type ed25519Scheme struct{}
+
+func (e *ed25519Scheme) Algorithm() string {
+	return SigAlgorithmEd25519
+}
+
+func (e *ed25519Scheme) GenerateKey() ([]byte, []byte, error) {
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return privKey.Seed(), pubKey, nil
+}
+
+func (e *ed25519Scheme) PublicKey(privKey []byte) ([]byte, error) {
+	if len(privKey) != ed25519.SeedSize {
+		return nil, fmt.Errorf("ed25519 private key must be %d bytes, got %d", ed25519.SeedSize, len(privKey))
+	}
+
+	return ed25519.NewKeyFromSeed(privKey).Public().(ed25519.PublicKey), nil
+}
+
+func (e *ed25519Scheme) ValidPublicKey(pubKey []byte) bool {
+	return len(pubKey) == ed25519.PublicKeySize
+}
+
+func (e *ed25519Scheme) Sign(privKey []byte, hash []byte) ([]byte, error) {
+	if len(privKey) != ed25519.SeedSize {
+		return nil, fmt.Errorf("ed25519 private key must be %d bytes, got %d", ed25519.SeedSize, len(privKey))
+	}
+
+	return ed25519.Sign(ed25519.NewKeyFromSeed(privKey), hash), nil
+}
+
+func (e *ed25519Scheme) Verify(pubKey []byte, signature []byte, hash []byte) bool {
+	if len(pubKey) != ed25519.PublicKeySize {
+		return false
+	}
+
+	return ed25519.Verify(pubKey, hash, signature)
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	reps "github.com/brucetieu/blockchain/representations"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var RemoteSignerTimeout = 10 * time.Second // How long to wait on the remote signer for each signature
+
+// Produces signatures with a wallet's private key. Keys can live in this process's wallet file,
+// or somewhere else entirely, like an HSM or a separate signing service
+type Signer interface {
+	Sign(wallet reps.Wallet, hash []byte) ([]byte, error)
+}
+
+// Signs with keys from the encrypted wallet file
+type localSigner struct {
+	keystoreService KeystoreService
+}
+
+func NewLocalSigner(keystoreService KeystoreService) Signer {
+	return &localSigner{
+		keystoreService: keystoreService,
+	}
+}
+
+func (ls *localSigner) Sign(wallet reps.Wallet, hash []byte) ([]byte, error) {
+	scheme, err := GetSignatureScheme(wallet.SigAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	privKey, err := ls.keystoreService.GetKey(wallet.Address)
+	if err != nil {
+		return nil, fmt.Errorf("%w, no private key available to sign for %s", err, wallet.Address)
+	}
+
+	return scheme.Sign(privKey, hash)
+}
+
+// Payload sent to the remote signer. Hash is hex encoded
+type remoteSignRequest struct {
+	Address      string `json:"address"`
+	PublicKey    string `json:"publicKey"`
+	SigAlgorithm string `json:"sigAlgorithm"`
+	Hash         string `json:"hash"`
+}
+
+// Response from the remote signer. Signature is hex encoded, in the same format the local scheme produces
+type remoteSignResponse struct {
+	Signature string `json:"signature"`
+	Error     string `json:"error"`
+}
+
+// Signs by POSTing each hash to a signing service over HTTP. The node never sees the private keys
+type remoteSigner struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func NewRemoteSigner(url string, token string) Signer {
+	return &remoteSigner{
+		url:    url,
+		token:  token,
+		client: &http.Client{Timeout: RemoteSignerTimeout},
+	}
+}
+
+func (rs *remoteSigner) Sign(wallet reps.Wallet, hash []byte) ([]byte, error) {
+	scheme, err := GetSignatureScheme(wallet.SigAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(remoteSignRequest{
+		Address:      wallet.Address,
+		PublicKey:    wallet.PublicKey,
+		SigAlgorithm: scheme.Algorithm(),
+		Hash:         hex.EncodeToString(hash),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, rs.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if rs.token != "" {
+		req.Header.Set("Authorization", "Bearer "+rs.token)
+	}
+
+	resp, err := rs.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s, unable to reach remote signer", err.Error())
+	}
+	defer resp.Body.Close()
+
+	var signResp remoteSignResponse
+	if err := json.NewDecoder(resp.Body).Decode(&signResp); err != nil {
+		return nil, fmt.Errorf("%s, unable to read remote signer response", err.Error())
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("remote signer refused to sign for %s with status %d: %s", wallet.Address, resp.StatusCode, signResp.Error)
+	}
+
+	signature, err := hex.DecodeString(signResp.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("%s, remote signer returned a malformed signature", err.Error())
+	}
+
+	// Don't trust the signer blindly, a bad signature would only be caught once the block is verified
+	pubKey, _ := hex.DecodeString(wallet.PublicKey)
+	if !scheme.Verify(pubKey, signature, hash) {
+		return nil, fmt.Errorf("remote signer returned an invalid signature for %s", wallet.Address)
+	}
+
+	return signature, nil
+}
+
+// Use the remote signer at SIGNER_URL if it's set, authenticating with SIGNER_TOKEN if that is too.
+// Otherwise sign with keys from the wallet file
+func SignerAtStartup(keystoreService KeystoreService) Signer {
+	url := os.Getenv("SIGNER_URL")
+	if url == "" {
+		return NewLocalSigner(keystoreService)
+	}
+
+	log.Info("Signing transactions with remote signer at ", url)
+	return NewRemoteSigner(url, os.Getenv("SIGNER_TOKEN"))
+}
@@ -0,0 +1,84 @@
+package services_test
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/brucetieu/blockchain/services"
+	"github.com/stretchr/testify/assert"
+)
+
+// Remote signer holding a single key, signing whatever hash it's sent
+func newRemoteSignerServer(t *testing.T, privKey []byte) *httptest.Server {
+	scheme, err := services.GetSignatureScheme(services.SigAlgorithmECDSA)
+	assert.NoError(t, err)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Hash string `json:"hash"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		hash, _ := hex.DecodeString(req.Hash)
+		signature, err := scheme.Sign(privKey, hash)
+		assert.NoError(t, err)
+
+		json.NewEncoder(w).Encode(map[string]string{"signature": hex.EncodeToString(signature)})
+	}))
+}
+
+func TestCreateTransactionSignsWithRemoteSigner(t *testing.T) {
+	scheme, err := services.GetSignatureScheme(services.SigAlgorithmECDSA)
+	assert.NoError(t, err)
+	privKey, pubKey, err := scheme.GenerateKey()
+	assert.NoError(t, err)
+
+	server := newRemoteSignerServer(t, privKey)
+	defer server.Close()
+
+	repo := newFakeBlockchainRepository()
+	keystore := newUnlockedKeystore(t)
+	walletService := services.NewWalletService(repo, keystore, &mainnet)
+	txnService := services.NewTransactionService(repo, walletService, nil, services.NewRemoteSigner(server.URL, "token"), &mainnet)
+
+	// Only the public key is ever given to the node
+	from, err := walletService.ImportPublicKey(pubKey, services.SigAlgorithmECDSA)
+	assert.NoError(t, err)
+	_, err = keystore.GetKey(from.Address)
+	assert.Error(t, err)
+
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	txn, err := txnService.CreateTransaction(from.Address, to.Address, 10)
+	assert.NoError(t, err)
+
+	valid, err := txnService.VerifyTransaction(txn)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestRemoteSignerRejectsSignatureFromWrongKey(t *testing.T) {
+	scheme, err := services.GetSignatureScheme(services.SigAlgorithmECDSA)
+	assert.NoError(t, err)
+	_, pubKey, err := scheme.GenerateKey()
+	assert.NoError(t, err)
+	otherPrivKey, _, err := scheme.GenerateKey()
+	assert.NoError(t, err)
+
+	server := newRemoteSignerServer(t, otherPrivKey)
+	defer server.Close()
+
+	repo := newFakeBlockchainRepository()
+	walletService := services.NewWalletService(repo, newUnlockedKeystore(t), &mainnet)
+	wallet, err := walletService.ImportPublicKey(pubKey, services.SigAlgorithmECDSA)
+	assert.NoError(t, err)
+
+	_, err = services.NewRemoteSigner(server.URL, "token").Sign(wallet, make([]byte, 32))
+	assert.Error(t, err)
+}
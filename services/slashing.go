@@ -0,0 +1,306 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/brucetieu/blockchain/repository"
+	reps "github.com/brucetieu/blockchain/representations"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// What a validator signed twice at the same height
+const (
+	EquivocationProposal = "proposal" // Two blocks, as their proposer
+	EquivocationVote     = "vote"     // Votes for two blocks, on a BFT chain
+)
+
+// What came of evidence of an equivocation
+const (
+	EvidenceRecorded = "recorded" // The offender had no stake to slash
+	EvidencePending  = "pending"  // The transaction slashing its stake isn't on the chain yet
+	EvidenceSlashed  = "slashed"  // Its stake is burnt
+)
+
+// Whether txn slashes a validator's stake, instead of moving coins
+func IsSlashingTransaction(txn reps.Transaction) bool {
+	return len(txn.Evidence) > 0
+}
+
+// Id of evidence of an equivocation, which doesn't depend on which of the two blocks was seen first
+func EvidenceID(equivocation reps.Equivocation) string {
+	first, second := equivocation.First.Hash, equivocation.Second.Hash
+	if bytes.Compare(first, second) > 0 {
+		first, second = second, first
+	}
+
+	hash := sha256.Sum256(bytes.Join([][]byte{[]byte(equivocation.Kind), equivocation.PublicKey, first, second}, []byte{}))
+	return hex.EncodeToString(hash[:])
+}
+
+// What the validator signs over block, for the kind of equivocation
+func equivocationHash(kind string, block reps.Block) []byte {
+	if kind == EquivocationVote {
+		return VoteHash(block)
+	}
+	return ProposalHash(block)
+}
+
+// Header of block, as an equivocation carries it
+func equivocationHeader(block reps.Block) reps.Block {
+	block.Transactions = nil
+	block.ChainWork = ""
+	block.Finalized = false
+	return block
+}
+
+// Block with hash, on the chain or a side branch
+func knownBlock(blockchainRepo repository.BlockchainRepository, hash []byte) (reps.Block, error) {
+	if block, err := blockchainRepo.GetBlockByHash(hash); err == nil {
+		return block, nil
+	}
+
+	sideBlock, err := blockchainRepo.GetSideBlock(hex.EncodeToString(hash))
+	if err != nil {
+		return reps.Block{}, fmt.Errorf("%s, block %x isn't known", err.Error(), hash)
+	}
+	return decodeSideBlock(sideBlock)
+}
+
+// Check the validator really signed two different blocks, both building on blocks known at the same height, and
+// return that height. The blocks' hashes have to match their headers, which commit to their parents
+func VerifyEquivocation(blockchainRepo repository.BlockchainRepository, params *reps.ChainParams, equivocation reps.Equivocation) (int, error) {
+	if equivocation.Kind != EquivocationProposal && equivocation.Kind != EquivocationVote {
+		return 0, fmt.Errorf("unknown kind of equivocation %s", equivocation.Kind)
+	}
+
+	scheme, err := GetSignatureScheme(equivocation.SigAlgorithm)
+	if err != nil {
+		return 0, err
+	}
+	if !scheme.ValidPublicKey(equivocation.PublicKey) {
+		return 0, fmt.Errorf("equivocation has no valid public key")
+	}
+
+	first, second := equivocation.First, equivocation.Second
+	if bytes.Equal(first.Hash, second.Hash) {
+		return 0, fmt.Errorf("signing block %x twice isn't an equivocation", first.Hash)
+	}
+
+	heights := make([]int, 0, 2)
+	signed := []struct {
+		block     reps.Block
+		signature []byte
+	}{{first, equivocation.FirstSig}, {second, equivocation.SecondSig}}
+	for _, header := range signed {
+		block := header.block
+		if !bytes.Equal(NewProofOfWorkService(&block, chainHasher(params)).HashData(), block.Hash) {
+			return 0, fmt.Errorf("hash of block %x doesn't match its header", block.Hash)
+		}
+		if !scheme.Verify(equivocation.PublicKey, header.signature, equivocationHash(equivocation.Kind, block)) {
+			return 0, fmt.Errorf("%s signature of block %x doesn't check out", equivocation.Kind, block.Hash)
+		}
+
+		parent, err := knownBlock(blockchainRepo, block.PrevHash)
+		if err != nil {
+			return 0, err
+		}
+		heights = append(heights, parent.Height+1)
+	}
+	if heights[0] != heights[1] {
+		return 0, fmt.Errorf("blocks %x and %x are at heights %d and %d", first.Hash, second.Hash, heights[0], heights[1])
+	}
+
+	return heights[0], nil
+}
+
+// Store evidence of a verified equivocation at height, with the transaction slashing the offender, if it had stake
+func storeEvidence(blockchainRepo repository.BlockchainRepository, params *reps.ChainParams, equivocation reps.Equivocation,
+	height int, slashing *reps.Transaction) (reps.Evidence, error) {
+	proof, err := json.Marshal(equivocation)
+	if err != nil {
+		return reps.Evidence{}, err
+	}
+
+	offender, err := AddressFromPubKey(equivocation.PublicKey, params.NetworkByte)
+	if err != nil {
+		return reps.Evidence{}, err
+	}
+
+	evidence := reps.Evidence{
+		ID:         EvidenceID(equivocation),
+		Kind:       equivocation.Kind,
+		Height:     height,
+		Offender:   string(offender),
+		FirstHash:  hex.EncodeToString(equivocation.First.Hash),
+		SecondHash: hex.EncodeToString(equivocation.Second.Hash),
+		Proof:      proof,
+		ReportedAt: time.Now().UnixMilli(),
+	}
+	if slashing != nil {
+		evidence.TxnID = hex.EncodeToString(slashing.ID)
+		for _, input := range slashing.Inputs {
+			if unspent, err := blockchainRepo.GetUnspentOutput(input.PrevTxnID, input.OutIdx); err == nil {
+				evidence.Stake += unspent.Value
+			}
+		}
+	}
+
+	if err := blockchainRepo.CreateEvidence(evidence); err != nil {
+		return reps.Evidence{}, err
+	}
+	log.WithFields(log.Fields{"offender": evidence.Offender, "height": height, "kind": evidence.Kind, "stake": evidence.Stake}).Warn("Validator equivocated")
+
+	return evidence, nil
+}
+
+// Catch the proposer of block signing another block on the same parent, which the node has on the chain or a side
+// branch, and store the evidence once. On a proof of stake chain, the transaction slashing the proposer's stake is
+// returned, to be queued
+func (bc *blockchainService) checkEquivocation(block reps.Block) []reps.Transaction {
+	if !HasProposers(bc.params) || len(block.Proposer) == 0 {
+		return nil
+	}
+
+	siblings := make([]reps.Block, 0)
+	if parent, err := bc.blockchainRepo.GetBlockByHash(block.PrevHash); err == nil {
+		if child, err := bc.blockchainRepo.GetBlockByHeight(parent.Height + 1); err == nil {
+			siblings = append(siblings, child)
+		}
+	}
+	if sideBlocks, err := bc.blockchainRepo.GetSideBlocks(); err == nil {
+		for _, sideBlock := range sideBlocks {
+			if sibling, err := decodeSideBlock(sideBlock); err == nil {
+				siblings = append(siblings, sibling)
+			}
+		}
+	}
+
+	slashings := make([]reps.Transaction, 0)
+	for _, sibling := range siblings {
+		if !bytes.Equal(sibling.PrevHash, block.PrevHash) || !bytes.Equal(sibling.Proposer, block.Proposer) || bytes.Equal(sibling.Hash, block.Hash) {
+			continue
+		}
+
+		equivocation := reps.Equivocation{
+			Kind:         EquivocationProposal,
+			PublicKey:    block.Proposer,
+			SigAlgorithm: block.SigAlgorithm,
+			First:        equivocationHeader(sibling),
+			FirstSig:     sibling.ProposerSig,
+			Second:       equivocationHeader(block),
+			SecondSig:    block.ProposerSig,
+		}
+		if _, err := bc.blockchainRepo.GetEvidence(EvidenceID(equivocation)); err == nil {
+			continue
+		}
+		height, err := VerifyEquivocation(bc.blockchainRepo, bc.params, equivocation)
+		if err != nil {
+			log.WithField("error", err.Error()).Warn("Blocks by the same proposer on the same parent aren't an equivocation")
+			continue
+		}
+
+		var slashing *reps.Transaction
+		if IsProofOfStake(bc.params) {
+			if txn, err := bc.transactionService.CreateSlashingTransaction(equivocation, height); err == nil {
+				slashing = &txn
+				slashings = append(slashings, txn)
+			} else {
+				log.WithField("error", err.Error()).Info("Nothing to slash")
+			}
+		}
+		if _, err := storeEvidence(bc.blockchainRepo, bc.params, equivocation, height, slashing); err != nil {
+			log.Error("Error storing evidence of equivocation: ", err.Error())
+		}
+	}
+
+	return slashings
+}
+
+// Create a transaction burning the stake an equivocating validator had bonded by height. It spends every one of the
+// validator's staked outputs from up to then, without a signature, since the evidence it carries is what entitles it
+// to. Stake bonded later can't be slashed for it
+func (ts *transactionService) CreateSlashingTransaction(equivocation reps.Equivocation, height int) (reps.Transaction, error) {
+	if !IsProofOfStake(ts.params) {
+		return reps.Transaction{}, fmt.Errorf("only stake on a proof of stake chain can be slashed")
+	}
+
+	evidence, err := json.Marshal(equivocation)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	pubKeyHash, _ := createPubKeyHash(equivocation.PublicKey)
+	unspentOutputs, err := ts.blockchainRepo.GetUnspentOutputs(pubKeyHash)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	txn := reps.Transaction{Evidence: evidence}
+	for _, unspent := range unspentOutputs {
+		if unspent.Staked && unspent.Height <= height {
+			txn.Inputs = append(txn.Inputs, newTxnInput(unspent, equivocation.PublicKey))
+		}
+	}
+	if len(txn.Inputs) == 0 {
+		return reps.Transaction{}, fmt.Errorf("validator %x has no stake from up to height %d to slash", pubKeyHash, height)
+	}
+
+	ts.setID(&txn)
+
+	return txn, nil
+}
+
+// A slashing transaction checks out if its evidence does, and it burns stake of the offender bonded by the height
+// of the equivocation, paying nothing out
+func (ts *transactionService) verifySlashingTransaction(txn reps.Transaction) (bool, error) {
+	txnId := hex.EncodeToString(txn.ID)
+	invalid := func(inputIndex int, reason string, message string) (bool, error) {
+		return false, &TxnVerificationError{TxnID: txnId, InputIndex: inputIndex, Reason: reason, Message: message}
+	}
+
+	if !IsProofOfStake(ts.params) {
+		return invalid(-1, InvalidTxnEvidence, "only stake on a proof of stake chain can be slashed")
+	}
+	if len(txn.Inputs) == 0 || len(txn.Outputs) > 0 || txn.Fee != 0 {
+		return invalid(-1, InvalidTxnEvidence, "a slashing transaction spends stake and burns all of it")
+	}
+
+	var equivocation reps.Equivocation
+	if err := json.Unmarshal(txn.Evidence, &equivocation); err != nil {
+		return invalid(-1, InvalidTxnEvidence, fmt.Sprintf("%s, malformed evidence", err.Error()))
+	}
+	height, err := VerifyEquivocation(ts.blockchainRepo, ts.params, equivocation)
+	if err != nil {
+		return invalid(-1, InvalidTxnEvidence, err.Error())
+	}
+
+	pubKeyHash, _ := createPubKeyHash(equivocation.PublicKey)
+	spending := make(map[string]bool)
+	for inIdx, input := range txn.Inputs {
+		outpoint := reps.OutpointID(input.PrevTxnID, input.OutIdx)
+		unspent, err := ts.blockchainRepo.GetUnspentOutput(input.PrevTxnID, input.OutIdx)
+		if err != nil || spending[outpoint] {
+			return invalid(inIdx, InvalidTxnDoubleSpend, fmt.Sprintf("output %s was already spent", outpoint))
+		}
+		spending[outpoint] = true
+
+		if !unspent.Staked || unspent.PubKeyHash != hex.EncodeToString(pubKeyHash) || !bytes.Equal(input.PubKey, equivocation.PublicKey) {
+			return invalid(inIdx, InvalidTxnEvidence, fmt.Sprintf("output %s isn't stake of the offender", outpoint))
+		}
+		if unspent.Height > height {
+			return invalid(inIdx, InvalidTxnEvidence, fmt.Sprintf("output %s was staked at height %d, after the equivocation at %d", outpoint, unspent.Height, height))
+		}
+	}
+
+	if !ts.hasValidID(txn) {
+		return invalid(-1, InvalidTxnID, "id is not the hash of the transaction")
+	}
+
+	return true, nil
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/brucetieu/blockchain/repository"
+	reps "github.com/brucetieu/blockchain/representations"
+)
+
+// Hands out the evidence of validators caught equivocating, and whether their stake was slashed for it
+type SlashingService interface {
+	GetEvidence() ([]reps.Evidence, error)
+	GetEvidenceById(id string) (reps.Evidence, error)
+}
+
+type slashingService struct {
+	blockchainRepo repository.BlockchainRepository
+}
+
+func NewSlashingService(blockchainRepo repository.BlockchainRepository) SlashingService {
+	return &slashingService{
+		blockchainRepo: blockchainRepo,
+	}
+}
+
+// Every equivocation caught, latest first
+func (ss *slashingService) GetEvidence() ([]reps.Evidence, error) {
+	evidence, err := ss.blockchainRepo.GetAllEvidence()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range evidence {
+		ss.setStatus(&evidence[i])
+	}
+
+	return evidence, nil
+}
+
+func (ss *slashingService) GetEvidenceById(id string) (reps.Evidence, error) {
+	evidence, err := ss.blockchainRepo.GetEvidence(id)
+	if err != nil {
+		return reps.Evidence{}, fmt.Errorf("%s, evidence id: %s", err.Error(), id)
+	}
+
+	ss.setStatus(&evidence)
+	return evidence, nil
+}
+
+// Whether the offender's stake was slashed, i.e. the slashing transaction is on the chain, and on which block
+func (ss *slashingService) setStatus(evidence *reps.Evidence) {
+	if evidence.TxnID == "" {
+		evidence.Status = EvidenceRecorded
+		return
+	}
+
+	evidence.Status = EvidencePending
+	txnId, _ := hex.DecodeString(evidence.TxnID)
+	if location, err := ss.blockchainRepo.GetTxnLocation(txnId); err == nil {
+		evidence.Status = EvidenceSlashed
+		evidence.BlockID = location.BlockID
+	}
+}
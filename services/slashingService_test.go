@@ -0,0 +1,80 @@
+package services_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProposerSigningTwoBlocksOnTheSameParentIsSlashed(t *testing.T) {
+	params := mainnet
+	params.Consensus = services.ConsensusPoS
+	ts := newTestServicesWithParams(t, &params)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+	blockchainService, mempoolService := ts.blockchainService, ts.mempoolService
+	stakingService := services.NewStakingService(repo, txnService, mempoolService, &params)
+	slashingService := services.NewSlashingService(repo)
+
+	validator, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	_, _, err = blockchainService.CreateBlockchain(validator.Address, nil)
+	assert.NoError(t, err)
+	stake, err := stakingService.Stake(validator.Address, 30, reps.TxnOptions{})
+	assert.NoError(t, err)
+	_, err = blockchainService.MineTransactions([]reps.Transaction{stake}, validator.Address, "")
+	assert.NoError(t, err)
+
+	block, err := blockchainService.MineTransactions([]reps.Transaction{}, validator.Address, "")
+	assert.NoError(t, err)
+
+	// The same validator signs another block on the same parent
+	wallet, err := walletService.GetWallet(validator.Address)
+	assert.NoError(t, err)
+	sibling := block
+	sibling.Timestamp++
+	sibling.Hash = services.NewProofOfWorkService(&sibling, sha256Hasher).HashData()
+	sibling, err = txnService.SignBlock(sibling, wallet)
+	assert.NoError(t, err)
+
+	update, err := mempoolService.ReceiveBlock(sibling)
+	assert.NoError(t, err)
+	assert.Len(t, update.Side, 1)
+	assert.Len(t, update.Slashings, 1)
+	_, queued := mempoolService.GetEntry(hex.EncodeToString(update.Slashings[0].ID))
+	assert.True(t, queued)
+
+	evidence, err := slashingService.GetEvidence()
+	assert.NoError(t, err)
+	assert.Len(t, evidence, 1)
+	assert.Equal(t, services.EquivocationProposal, evidence[0].Kind)
+	assert.Equal(t, validator.Address, evidence[0].Offender)
+	assert.Equal(t, 2, evidence[0].Height)
+	assert.Equal(t, 30, evidence[0].Stake)
+	assert.Equal(t, services.EvidencePending, evidence[0].Status)
+
+	// Seeing it again doesn't catch it twice
+	_, err = mempoolService.ReceiveBlock(sibling)
+	assert.Error(t, err)
+
+	slashing := update.Slashings[0]
+	assert.True(t, services.IsSlashingTransaction(slashing))
+	assert.Empty(t, slashing.Outputs)
+	_, err = blockchainService.MineTransactions([]reps.Transaction{slashing}, validator.Address, "")
+	assert.NoError(t, err)
+
+	slashed, err := slashingService.GetEvidenceById(evidence[0].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, services.EvidenceSlashed, slashed.Status)
+	assert.NotEmpty(t, slashed.BlockID)
+
+	set, err := stakingService.GetValidators()
+	assert.NoError(t, err)
+	assert.Empty(t, set.Validators)
+
+	// Its stake is gone, so the evidence can't slash it again
+	valid, _ := txnService.VerifyTransaction(slashing)
+	assert.False(t, valid)
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/brucetieu/blockchain/repository"
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/google/uuid"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Headers a snapshot carries up to its block, so validating the blocks after it has the timestamps and
+// difficulties it looks back at. More are carried if a difficulty retarget looks back further
+var SnapshotHeaders = 100
+
+// Hex hash of a UTXO set, taken over its outputs in order of outpoint
+func UTXOSetHash(unspentOutputs []reps.UnspentOutput) string {
+	sorted := append([]reps.UnspentOutput{}, unspentOutputs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	hasher := sha256.New()
+	for _, unspentOutput := range sorted {
+		data, _ := json.Marshal(unspentOutput)
+		hasher.Write(data)
+	}
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// Snapshot of the chain at its last block, for a new node to start from
+func CreateSnapshot(blockchainRepo repository.BlockchainRepository, params *reps.ChainParams) (reps.ChainSnapshot, error) {
+	lastBlock, err := blockchainRepo.GetLastBlock()
+	if err != nil {
+		return reps.ChainSnapshot{}, fmt.Errorf("%s, there's no chain to take a snapshot of", err.Error())
+	}
+
+	count := SnapshotHeaders
+	if params.DifficultyInterval+1 > count {
+		count = params.DifficultyInterval + 1
+	}
+	from := lastBlock.Height - count + 1
+	if from < 0 {
+		from = 0
+	}
+	headers, err := blockchainRepo.GetBlockHeadersByHeight(from, lastBlock.Height-from+1)
+	if err != nil {
+		return reps.ChainSnapshot{}, err
+	}
+
+	if err := fillMerkleRoots(blockchainRepo, headers); err != nil {
+		return reps.ChainSnapshot{}, err
+	}
+
+	unspentOutputs, err := blockchainRepo.GetAllUnspentOutputs()
+	if err != nil {
+		return reps.ChainSnapshot{}, err
+	}
+
+	validatorChanges, err := blockchainRepo.GetValidatorChanges()
+	if err != nil {
+		return reps.ChainSnapshot{}, err
+	}
+
+	return reps.ChainSnapshot{
+		Hash:             hex.EncodeToString(lastBlock.Hash),
+		Height:           lastBlock.Height,
+		Params:           *params,
+		Headers:          headers,
+		UnspentOutputs:   unspentOutputs,
+		UTXOHash:         UTXOSetHash(unspentOutputs),
+		ValidatorChanges: validatorChanges,
+	}, nil
+}
+
+// Snapshot of the chain at its last block, for a new node to start from
+func (bc *blockchainService) GetSnapshot() (reps.ChainSnapshot, error) {
+	return CreateSnapshot(bc.blockchainRepo, bc.params)
+}
+
+// Start a node without a chain from snapshot, if its block has the hash the operator trusts. Its headers have to
+// hash to what they claim and each build on the one before, so they all check out once the last one does, and its
+// UTXO set has to match its hash. The UTXO set and validator changes aren't committed to by any block, so they're
+// only as trustworthy as where the snapshot came from
+func LoadSnapshot(blockchainRepo repository.BlockchainRepository, snapshot reps.ChainSnapshot, trustedHash string) error {
+	if _, err := blockchainRepo.GetLastBlock(); err == nil {
+		return fmt.Errorf("node already has a chain, a snapshot can only start a new one")
+	}
+
+	if len(snapshot.Headers) == 0 {
+		return fmt.Errorf("snapshot has no headers")
+	}
+	block := snapshot.Headers[len(snapshot.Headers)-1]
+	hash := hex.EncodeToString(block.Hash)
+	if !strings.EqualFold(hash, trustedHash) || hash != snapshot.Hash || block.Height != snapshot.Height {
+		return fmt.Errorf("snapshot is of block %s at height %d, not the trusted block %s", hash, block.Height, trustedHash)
+	}
+
+	params := snapshot.Params
+	hasher, err := GetBlockHasher(params.HashAlgorithm)
+	if err != nil {
+		return err
+	}
+	for i, header := range snapshot.Headers {
+		if !bytes.Equal(NewProofOfWorkService(&header, hasher).HashData(), header.Hash) {
+			return fmt.Errorf("hash of header %x at height %d doesn't match its contents", header.Hash, header.Height)
+		}
+		if i == 0 {
+			continue
+		}
+		if prev := snapshot.Headers[i-1]; !bytes.Equal(header.PrevHash, prev.Hash) || header.Height != prev.Height+1 {
+			return fmt.Errorf("header %x at height %d doesn't build on the header before it", header.Hash, header.Height)
+		}
+	}
+
+	if UTXOSetHash(snapshot.UnspentOutputs) != snapshot.UTXOHash {
+		return fmt.Errorf("snapshot's UTXO set doesn't match its hash %s", snapshot.UTXOHash)
+	}
+	seen := make(map[string]bool)
+	for _, unspent := range snapshot.UnspentOutputs {
+		if unspent.ID != reps.OutpointID(unspent.TxnID, unspent.OutIdx) || seen[unspent.ID] || unspent.Height > snapshot.Height {
+			return fmt.Errorf("snapshot's UTXO set has an invalid output %s", unspent.ID)
+		}
+		seen[unspent.ID] = true
+	}
+	for _, change := range snapshot.ValidatorChanges {
+		if change.MinedHeight > snapshot.Height {
+			return fmt.Errorf("snapshot has validator change %s from after its block", change.TxnID)
+		}
+	}
+
+	outputs, err := json.Marshal(snapshot.UnspentOutputs)
+	if err != nil {
+		return err
+	}
+	base := reps.SnapshotBase{
+		Hash:     hash,
+		Height:   snapshot.Height,
+		UTXOHash: snapshot.UTXOHash,
+		Outputs:  outputs,
+		LoadedAt: time.Now().UnixMilli(),
+	}
+
+	headers := make([]reps.Block, 0, len(snapshot.Headers))
+	for _, header := range snapshot.Headers {
+		header.Transactions = nil
+		headers = append(headers, header)
+	}
+
+	params.ID = uuid.Must(uuid.NewRandom()).String()
+	if err := blockchainRepo.LoadSnapshot(base, params, headers, snapshot.UnspentOutputs, snapshot.ValidatorChanges); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{"hash": hash, "height": snapshot.Height, "unspentOutputs": len(snapshot.UnspentOutputs)}).Info("Started chain from snapshot")
+
+	return nil
+}
+
+// The UTXO set of the snapshot the chain was started from, and its height. -1 if it wasn't started from one
+func snapshotOutputs(blockchainRepo repository.BlockchainRepository) ([]reps.UnspentOutput, int, error) {
+	base, err := blockchainRepo.GetSnapshotBase()
+	if err != nil {
+		return nil, -1, nil
+	}
+
+	var unspentOutputs []reps.UnspentOutput
+	if err := json.Unmarshal(base.Outputs, &unspentOutputs); err != nil {
+		return nil, -1, fmt.Errorf("%s, UTXO set of snapshot %s", err.Error(), base.Hash)
+	}
+	return unspentOutputs, base.Height, nil
+}
+
+// Transaction input spends from. On a chain started from a snapshot, transactions from before it are only known by
+// their unspent outputs, so the one it spends is filled in at its index, onto what prevTxns already has of it
+func (ts *transactionService) prevTransaction(input reps.TxnInput, prevTxns map[string]reps.Transaction) (reps.Transaction, error) {
+	prevTxn, err := ts.blockchainRepo.GetTransaction(input.PrevTxnID)
+	if err == nil {
+		return prevTxn, nil
+	}
+
+	base, baseErr := ts.blockchainRepo.GetSnapshotBase()
+	unspent, unspentErr := ts.blockchainRepo.GetUnspentOutput(input.PrevTxnID, input.OutIdx)
+	if baseErr != nil || unspentErr != nil || unspent.Height > base.Height {
+		return reps.Transaction{}, err
+	}
+
+	known := prevTxns[hex.EncodeToString(input.PrevTxnID)]
+	prevTxn = reps.Transaction{ID: input.PrevTxnID, Outputs: append([]reps.TxnOutput{}, known.Outputs...)}
+	for len(prevTxn.Outputs) <= input.OutIdx {
+		prevTxn.Outputs = append(prevTxn.Outputs, reps.TxnOutput{})
+	}
+	prevTxn.Outputs[input.OutIdx] = unspent.TxnOutput()
+
+	return prevTxn, nil
+}
+
+// Start a new node's chain from the snapshot in SNAPSHOT_FILE, taken at the block with hash SNAPSHOT_HASH, if they're
+// set. Once the node has a chain they're ignored. Has to happen before the chain params are loaded, since they
+// come with the snapshot
+func SnapshotAtStartup(blockchainRepo repository.BlockchainRepository) {
+	path := os.Getenv("SNAPSHOT_FILE")
+	if path == "" {
+		return
+	}
+	if _, err := blockchainRepo.GetLastBlock(); err == nil {
+		log.Info("Node already has a chain, ignoring SNAPSHOT_FILE")
+		return
+	}
+
+	trustedHash := os.Getenv("SNAPSHOT_HASH")
+	if trustedHash == "" {
+		log.Fatal("SNAPSHOT_FILE needs the SNAPSHOT_HASH of the block it's trusted to be taken at")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatal("Error reading SNAPSHOT_FILE: ", err.Error())
+	}
+	var snapshot reps.ChainSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		log.Fatal("Invalid SNAPSHOT_FILE: ", err.Error())
+	}
+
+	if err := LoadSnapshot(blockchainRepo, snapshot, trustedHash); err != nil {
+		log.Fatal("Error loading snapshot: ", err.Error())
+	}
+}
@@ -0,0 +1,85 @@
+package services_test
+
+import (
+	"testing"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeStartedFromSnapshotValidatesBlocksAfterIt(t *testing.T) {
+	defer func(headers int) { services.SnapshotHeaders = headers }(services.SnapshotHeaders)
+	services.SnapshotHeaders = 2
+
+	// Coinbases need a few blocks on top, so the node has to know how high it is without the blocks below the snapshot
+	params := reps.ChainParams{NetworkByte: mainnet.NetworkByte, CoinbaseMaturity: 3}
+	ts := newTestServicesWithParams(t, &params)
+	repo, keystore, walletService := ts.repo, ts.keystore, ts.walletService
+	txnService, blockchainService := ts.txnService, ts.blockchainService
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+	for i := 0; i < 3; i++ {
+		_, err := blockchainService.MineTransactions([]reps.Transaction{}, to.Address, "")
+		assert.NoError(t, err)
+	}
+
+	snapshot, err := blockchainService.GetSnapshot()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, snapshot.Height)
+	assert.Len(t, snapshot.Headers, 2)
+	assert.Len(t, snapshot.UnspentOutputs, 4)
+
+	// A new node only takes the snapshot of the block its operator trusts, as it was taken
+	node := newFakeBlockchainRepository()
+	assert.Error(t, services.LoadSnapshot(node, snapshot, "00ab"))
+	tampered := snapshot
+	tampered.UnspentOutputs = append([]reps.UnspentOutput{}, snapshot.UnspentOutputs...)
+	tampered.UnspentOutputs[0].Value++
+	assert.Error(t, services.LoadSnapshot(node, tampered, snapshot.Hash))
+
+	assert.NoError(t, services.LoadSnapshot(node, snapshot, snapshot.Hash))
+	assert.Error(t, services.LoadSnapshot(node, snapshot, snapshot.Hash))
+	nodeTxnService := services.NewTransactionService(node, walletService, nil, services.NewLocalSigner(keystore), &params)
+	nodeBlockchainService := services.NewBlockchainService(node, services.NewBlockService(node, &params), nodeTxnService, walletService, &params)
+	_, _, err = nodeBlockchainService.CreateBlockchain(from.Address, nil)
+	assert.Error(t, err)
+	height, err := nodeBlockchainService.GetNextBlockHeight()
+	assert.NoError(t, err)
+	assert.Equal(t, 4, height)
+
+	// Coins from the genesis block, which the node never saw, are spent in a block after the snapshot,
+	// along with the coinbase of the first block after it, which has only just matured
+	txn, err := txnService.CreateTransaction(from.Address, to.Address, 10)
+	assert.NoError(t, err)
+	coinbaseSpend, err := txnService.CreateTransaction(to.Address, from.Address, services.Reward)
+	assert.NoError(t, err)
+	assert.Equal(t, repo.blocks[1].Transactions[0].ID, coinbaseSpend.Inputs[0].PrevTxnID)
+	block, err := blockchainService.MineTransactions([]reps.Transaction{txn, coinbaseSpend}, to.Address, "")
+	assert.NoError(t, err)
+
+	update, err := nodeBlockchainService.ProcessBlock(block)
+	assert.NoError(t, err)
+	assert.Len(t, update.Connected, 1)
+	height, err = nodeBlockchainService.GetNextBlockHeight()
+	assert.NoError(t, err)
+	assert.Equal(t, 5, height)
+	for _, address := range []string{from.Address, to.Address} {
+		expected, err := txnService.GetBalance(address)
+		assert.NoError(t, err)
+		balance, err := nodeTxnService.GetBalance(address)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, balance)
+	}
+
+	// Rebuilding the UTXO set starts from the snapshot's
+	count, err := nodeTxnService.ReindexUnspentOutputs()
+	assert.NoError(t, err)
+	expected, err := repo.GetAllUnspentOutputs()
+	assert.NoError(t, err)
+	assert.Equal(t, len(expected), count)
+}
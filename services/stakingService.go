@@ -0,0 +1,199 @@
+package services
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/brucetieu/blockchain/repository"
+	reps "github.com/brucetieu/blockchain/representations"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Bonds and unbonds coins as stake on a proof of stake chain, through transactions queued in the mempool, and
+// hands out the validator set from the staking index
+type StakingService interface {
+	Stake(address string, amount int, opts reps.TxnOptions) (reps.Transaction, error)
+	Unstake(address string, amount int, opts reps.TxnOptions) (reps.Transaction, error)
+	GetValidators() (reps.ValidatorSet, error)
+}
+
+type stakingService struct {
+	blockchainRepo     repository.BlockchainRepository
+	transactionService TransactionService
+	mempoolService     MempoolService
+	params             *reps.ChainParams
+}
+
+func NewStakingService(blockchainRepo repository.BlockchainRepository, transactionService TransactionService,
+	mempoolService MempoolService, params *reps.ChainParams) StakingService {
+	return &stakingService{
+		blockchainRepo:     blockchainRepo,
+		transactionService: transactionService,
+		mempoolService:     mempoolService,
+		params:             params,
+	}
+}
+
+// Queue a transaction bonding amount of address's coins as its stake. It counts once it's on a block
+func (ss *stakingService) Stake(address string, amount int, opts reps.TxnOptions) (reps.Transaction, error) {
+	log.WithFields(log.Fields{"address": address, "amount": amount}).Info("Staking coins")
+
+	txn, err := ss.transactionService.CreateStakeTransaction(address, amount, opts)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	return ss.queue(txn)
+}
+
+// Queue a transaction unbonding amount of address's stake, or all of it for 0
+func (ss *stakingService) Unstake(address string, amount int, opts reps.TxnOptions) (reps.Transaction, error) {
+	log.WithFields(log.Fields{"address": address, "amount": amount}).Info("Unstaking coins")
+
+	txn, err := ss.transactionService.CreateUnstakeTransaction(address, amount, opts)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	return ss.queue(txn)
+}
+
+func (ss *stakingService) queue(txn reps.Transaction) (reps.Transaction, error) {
+	if _, err := ss.mempoolService.AddTransaction(txn); err != nil {
+		return reps.Transaction{}, err
+	}
+
+	return txn, nil
+}
+
+// Every validator with its stake and share of all of it, and who's elected to produce the next block
+func (ss *stakingService) GetValidators() (reps.ValidatorSet, error) {
+	if !IsProofOfStake(ss.params) {
+		return reps.ValidatorSet{}, fmt.Errorf("a proof of work chain has no validators")
+	}
+
+	lastBlock, err := ss.blockchainRepo.GetLastBlock()
+	if err != nil {
+		return reps.ValidatorSet{}, fmt.Errorf("%s, there's no chain yet", err.Error())
+	}
+
+	validators, err := ss.blockchainRepo.GetValidators()
+	if err != nil {
+		return reps.ValidatorSet{}, err
+	}
+
+	set := reps.ValidatorSet{Height: lastBlock.Height, Validators: validators}
+	for _, validator := range validators {
+		set.TotalBonded += validator.Bonded
+	}
+	for i := range set.Validators {
+		pubKeyHash, _ := hex.DecodeString(set.Validators[i].PubKeyHash)
+		set.Validators[i].Address = addressFromPubKeyHash(pubKeyHash, ss.params.NetworkByte)
+		set.Validators[i].Share = float64(set.Validators[i].Bonded) / float64(set.TotalBonded)
+	}
+
+	elected, err := ElectProposer(ss.blockchainRepo, lastBlock.Hash, lastBlock.Height+1)
+	if err != nil {
+		return reps.ValidatorSet{}, err
+	}
+	if elected != nil {
+		set.NextProposer = addressFromPubKeyHash(elected, ss.params.NetworkByte)
+	}
+
+	return set, nil
+}
+
+// Create a transaction bonding amount of address's coins as its stake, which makes it a validator on a proof of stake
+// chain once it's on a block. Any change comes back unbonded
+func (ts *transactionService) CreateStakeTransaction(address string, amount int, opts reps.TxnOptions) (reps.Transaction, error) {
+	if !IsProofOfStake(ts.params) {
+		return reps.Transaction{}, fmt.Errorf("coins can only be staked on a proof of stake chain")
+	}
+
+	return ts.CreateTransactionToRecipients(address, []reps.Recipient{{To: address, Amount: amount, Stake: true}}, opts)
+}
+
+// Create and sign a transaction spending every staked output of address, paying amount back to it unbonded, less the
+// fee, and bonding the rest again. 0 unbonds all of it, as does leaving less than the dust threshold bonded
+func (ts *transactionService) CreateUnstakeTransaction(address string, amount int, opts reps.TxnOptions) (reps.Transaction, error) {
+	if !IsProofOfStake(ts.params) {
+		return reps.Transaction{}, fmt.Errorf("coins can only be unstaked on a proof of stake chain")
+	}
+	if err := validateOptions(opts); err != nil {
+		return reps.Transaction{}, err
+	}
+	if amount < 0 {
+		return reps.Transaction{}, fmt.Errorf("amount to unstake can't be negative")
+	}
+
+	wallet, err := ts.walletService.GetWallet(address)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+	if wallet.WatchOnly {
+		err := fmt.Errorf("%w: %s", ErrWatchOnly, address)
+		log.Error(err)
+		return reps.Transaction{}, err
+	}
+
+	scheme, err := GetSignatureScheme(wallet.SigAlgorithm)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	pubKey, _ := hex.DecodeString(wallet.PublicKey)
+	pubKeyHash, _ := createPubKeyHash(pubKey)
+
+	unspentOutputs, err := ts.blockchainRepo.GetUnspentOutputs(pubKeyHash)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	txn := reps.Transaction{
+		SigAlgorithm: scheme.Algorithm(),
+		Memo:         opts.Memo,
+		LockTime:     opts.LockTime,
+		Replaceable:  opts.Replaceable,
+	}
+	totalIn := 0
+	for _, unspent := range unspentOutputs {
+		if unspent.Staked {
+			txn.Inputs = append(txn.Inputs, newTxnInput(unspent, pubKey))
+			totalIn += unspent.Value
+		}
+	}
+	if totalIn == 0 {
+		return reps.Transaction{}, fmt.Errorf("%s has no stake to unbond", address)
+	}
+	if amount > totalIn {
+		return reps.Transaction{}, fmt.Errorf("%s only has %d staked, not %d", address, totalIn, amount)
+	}
+
+	if rest := totalIn - amount; amount == 0 || rest < ts.params.DustThreshold {
+		amount = totalIn
+	}
+	txn.Outputs = []reps.TxnOutput{ts.NewTxnOutput(amount, address)}
+	if amount < totalIn {
+		txn.Outputs = append(txn.Outputs, ts.recipientOutput(reps.Recipient{To: address, Amount: totalIn - amount, Stake: true}))
+	}
+
+	// The fee comes out of what's unbonded
+	txn.Fee = opts.Fee
+	if opts.FeeRate > 0 {
+		txn.Fee = ts.sizedFee(txn, opts.FeeRate)
+	}
+	if amount-txn.Fee < ts.params.DustThreshold || amount-txn.Fee <= 0 {
+		return reps.Transaction{}, fmt.Errorf("a fee of %d would use up the %d unbonded, not unstaking", txn.Fee, amount)
+	}
+	txn.Outputs[0].Value = amount - txn.Fee
+
+	ts.setID(&txn)
+
+	prevTxns, err := ts.GetPrevTransactions(txn)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	return ts.Sign(wallet, txn, prevTxns)
+}
@@ -0,0 +1,84 @@
+package services_test
+
+import (
+	"testing"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStakeAndUnstakeUpdateValidatorSet(t *testing.T) {
+	params := mainnet
+	params.Consensus = services.ConsensusPoS
+	ts := newTestServicesWithParams(t, &params)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+	blockchainService, mempoolService := ts.blockchainService, ts.mempoolService
+	stakingService := services.NewStakingService(repo, txnService, mempoolService, &params)
+
+	validator, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	_, _, err = blockchainService.CreateBlockchain(validator.Address, nil)
+	assert.NoError(t, err)
+
+	set, err := stakingService.GetValidators()
+	assert.NoError(t, err)
+	assert.Empty(t, set.Validators)
+	assert.Empty(t, set.NextProposer)
+
+	// Nothing to unbond yet
+	_, err = stakingService.Unstake(validator.Address, 0, reps.TxnOptions{})
+	assert.Error(t, err)
+
+	stake, err := stakingService.Stake(validator.Address, 30, reps.TxnOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, mempoolService.Size())
+
+	_, err = blockchainService.MineTransactions([]reps.Transaction{stake}, validator.Address, "")
+	assert.NoError(t, err)
+
+	set, err = stakingService.GetValidators()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, set.Height)
+	assert.Equal(t, 30, set.TotalBonded)
+	assert.Len(t, set.Validators, 1)
+	assert.Equal(t, validator.Address, set.Validators[0].Address)
+	assert.Equal(t, 30, set.Validators[0].Bonded)
+	assert.Equal(t, 1.0, set.Validators[0].Share)
+	assert.Equal(t, validator.Address, set.NextProposer)
+
+	// Can't unbond more than is staked
+	_, err = stakingService.Unstake(validator.Address, 31, reps.TxnOptions{})
+	assert.Error(t, err)
+
+	unstake, err := stakingService.Unstake(validator.Address, 10, reps.TxnOptions{Fee: 1})
+	assert.NoError(t, err)
+	assert.Len(t, unstake.Outputs, 2)
+	assert.False(t, unstake.Outputs[0].Staked)
+	assert.Equal(t, 9, unstake.Outputs[0].Value)
+	assert.True(t, unstake.Outputs[1].Staked)
+	assert.Equal(t, 20, unstake.Outputs[1].Value)
+
+	valid, err := txnService.VerifyTransaction(unstake)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	_, err = blockchainService.MineTransactions([]reps.Transaction{unstake}, validator.Address, "")
+	assert.NoError(t, err)
+
+	set, err = stakingService.GetValidators()
+	assert.NoError(t, err)
+	assert.Equal(t, 20, set.TotalBonded)
+	assert.Equal(t, 20, set.Validators[0].Bonded)
+
+	// Unbonding the rest leaves nobody staked
+	unstake, err = stakingService.Unstake(validator.Address, 0, reps.TxnOptions{Fee: 1})
+	assert.NoError(t, err)
+	assert.Len(t, unstake.Outputs, 1)
+	_, err = blockchainService.MineTransactions([]reps.Transaction{unstake}, validator.Address, "")
+	assert.NoError(t, err)
+
+	set, err = stakingService.GetValidators()
+	assert.NoError(t, err)
+	assert.Empty(t, set.Validators)
+}
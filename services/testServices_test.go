@@ -0,0 +1,48 @@
+package services_test
+
+import (
+	"testing"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+)
+
+// Services wired together over a fake repository and an unlocked keystore, the way routes wires the real ones
+type testServices struct {
+	repo              *fakeBlockchainRepository
+	keystore          services.KeystoreService
+	signer            services.Signer
+	walletService     services.WalletService
+	txnService        services.TransactionService
+	blockService      services.BlockService
+	blockchainService services.BlockchainService
+	mempoolRepo       *fakeMempoolRepository
+	mempoolService    services.MempoolService
+}
+
+func newTestServices(t *testing.T) testServices {
+	return newTestServicesWithParams(t, &mainnet)
+}
+
+func newTestServicesWithParams(t *testing.T, params *reps.ChainParams) testServices {
+	repo := newFakeBlockchainRepository()
+	keystore := newUnlockedKeystore(t)
+	signer := services.NewLocalSigner(keystore)
+	walletService := services.NewWalletService(repo, keystore, params)
+	txnService := services.NewTransactionService(repo, walletService, nil, signer, params)
+	blockService := services.NewBlockService(repo, params)
+	blockchainService := services.NewBlockchainService(repo, blockService, txnService, walletService, params)
+	mempoolRepo := newFakeMempoolRepository()
+
+	return testServices{
+		repo:              repo,
+		keystore:          keystore,
+		signer:            signer,
+		walletService:     walletService,
+		txnService:        txnService,
+		blockService:      blockService,
+		blockchainService: blockchainService,
+		mempoolRepo:       mempoolRepo,
+		mempoolService:    services.NewMempoolService(mempoolRepo, txnService, blockchainService, params),
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"fmt"
 	"math/big"
 	"sort"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/akamensky/base58"
 	"github.com/brucetieu/blockchain/repository"
@@ -21,14 +23,38 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-var Reward = 50 // Initial reward miner gets for mining the first block
+var (
+	Reward = 50 // Initial reward miner gets for mining the first block, unless the chain params say otherwise
+
+	// Bytes each input's signature is expected to add to a transaction, for fees paid by rate
+	SignatureSizeAllowance = 200
+
+	MaxMemoLen = 256 // Most bytes of memo a transaction can carry
+
+	CoinbaseMessage       = ""  // Message in the coinbase of blocks mined here, unless whoever's mining gives another
+	MaxCoinbaseMessageLen = 100 // Most bytes of message a coinbase can carry
+
+	LockTimeThreshold int64 = 500000000 // Lock times below this are block heights, the rest are unix times in seconds
+)
+
+// Most coins, or units of an asset, any one amount or sum of amounts can come to. Totals are checked against it as they're
+// added up, so they can't wrap past what an int holds
+const MaxMoney = 21000000 * 100000000
+
+// Prepended to the chain's identifier and an input's signing hash on a chain with replay protection
+var txnReplayPrefix = []byte("Blockchain Transaction:\n")
 
 type TransactionService interface {
 	NewTxnOutput(value int, address string) reps.TxnOutput
 
 	// SetID(txnRep reps.Transaction) []byte
 	CreateCoinbaseTxn(to string, data string) reps.Transaction
+	CreateCoinbaseTxnWithFees(to string, data string, height int, fees int) reps.Transaction
+	CreateGenesisTxn(to string, data string, allocations []reps.GenesisAllocation) reps.Transaction
 	CreateTransaction(from string, to string, amount int) (reps.Transaction, error)
+	CreateTransactionToRecipients(from string, recipients []reps.Recipient, opts reps.TxnOptions) (reps.Transaction, error)
+	CreateTransactionFromWallets(wallets []reps.Wallet, to string, amount int, opts reps.TxnOptions) (reps.Transaction, error)
+	CreateUnsignedTransaction(from string, inputPubKey []byte, sigAlgorithm string, to string, amount int, changeAddress string) (reps.Transaction, error)
 	CreateTrimmedTxnCopy(txn reps.Transaction) reps.Transaction
 
 	GetTransactions() ([]reps.Transaction, error)
@@ -36,6 +62,8 @@ type TransactionService interface {
 	GetUnspentTransactions(address []byte) []reps.Transaction
 	GetUnspentTxnOutputs(address []byte) []reps.TxnOutput
 	GetSpendableOutputs(pubKeyHash []byte, amount int) (int, map[string][]int)
+	GetSpentOutputs(blocks []reps.Block) map[string]map[int]reps.Transaction
+	ReindexUnspentOutputs() (int, error)
 
 	// CanUnlock(input reps.TxnInput, data string) bool
 	// CanBeUnlockedWith(output reps.TxnOutput, data string) bool
@@ -43,23 +71,49 @@ type TransactionService interface {
 
 	VerifyTransaction(txn reps.Transaction) (bool, error)
 	VerifySignature(currTxn reps.Transaction, prevTxns map[string]reps.Transaction) (bool, error)
+	GetPrevTransactions(txn reps.Transaction) (map[string]reps.Transaction, error)
+	SigningHash(txn reps.Transaction, inIdx int, prevTxns map[string]reps.Transaction) []byte
+
+	GetTransactionLocation(txnId string) (reps.TxnLocation, error)
+	GetTransactionReceipt(txnId string) (reps.TxnReceipt, error)
+	GetAddressUnspentOutputs(address string) ([]reps.AddressUnspentOutput, error)
+	GetAddressHistory(address string) ([]reps.AddressTransaction, error)
 
 	GetBalances() ([]reps.AddressBalance, error)
 	GetBalance(address string) (int, error)
+	GetAddressBalance(address string) (reps.AddressBalanceSummary, error)
+	AssetBalance(summary *reps.AddressBalanceSummary, assetId string) *reps.AssetBalance
+
+	CreateAssetTransaction(from string, assetId string, recipients []reps.Recipient, issue int, opts reps.TxnOptions) (reps.Transaction, error)
+	GetIssuedAssets(txn reps.Transaction) (map[string]int, error)
+	VerifyIssuance(assetId string, amount int) error
+	GetAssetSupply(assetId string) (int, error)
+	CreateConsolidationTransaction(address string, maxValue int, opts reps.TxnOptions) (reps.Transaction, error)
+	CreateStakeTransaction(address string, amount int, opts reps.TxnOptions) (reps.Transaction, error)
+	CreateUnstakeTransaction(address string, amount int, opts reps.TxnOptions) (reps.Transaction, error)
+	SignBlock(block reps.Block, wallet reps.Wallet) (reps.Block, error)
+	CreateSlashingTransaction(equivocation reps.Equivocation, height int) (reps.Transaction, error)
+	CreateValidatorChangeTransaction(action string, validator string, height int, authority string) (reps.Transaction, error)
 }
 
 type transactionService struct {
 	blockchainRepo  repository.BlockchainRepository
 	walletService   WalletService
+	hdWalletService HDWalletService
+	signer          Signer
+	params          *reps.ChainParams
 	blockAssembler  BlockAssemblerFac
 	txnAssembler    TxnAssemblerFac
 	walletAssembler WalletAssemblerFac
 }
 
-func NewTransactionService(blockchainRepo repository.BlockchainRepository, walletService WalletService) TransactionService {
+func NewTransactionService(blockchainRepo repository.BlockchainRepository, walletService WalletService, hdWalletService HDWalletService, signer Signer, params *reps.ChainParams) TransactionService {
 	return &transactionService{
 		blockchainRepo:  blockchainRepo,
 		walletService:   walletService,
+		hdWalletService: hdWalletService,
+		signer:          signer,
+		params:          params,
 		blockAssembler:  BlockAssembler,
 		txnAssembler:    TxnAssembler,
 		walletAssembler: WalletAssembler,
@@ -75,9 +129,15 @@ func NewTransactionService(blockchainRepo repository.BlockchainRepository, walle
 // 	return hashID[:]
 // }
 
-// A coinbase transaction is a special type of transaction which doesn’t require previously existing outputs. It creates the output
+// A coinbase transaction is a special type of transaction which doesn’t require previously existing outputs. It creates the output.
+// Pays the genesis block's reward
 func (ts *transactionService) CreateCoinbaseTxn(to string, data string) reps.Transaction {
-	log.WithFields(log.Fields{"to": to, "data": data}).Info("Creating coinbase transaction")
+	return ts.CreateCoinbaseTxnWithFees(to, data, 0, 0)
+}
+
+// Coinbase transaction for the block at height, paying its reward plus the fees of the block's other transactions
+func (ts *transactionService) CreateCoinbaseTxnWithFees(to string, data string, height int, fees int) reps.Transaction {
+	log.WithFields(log.Fields{"to": to, "data": data, "height": height, "fees": fees}).Info("Creating coinbase transaction")
 	if data == "" {
 		randData := make([]byte, 24)
 		_, err := rand.Read(randData)
@@ -87,14 +147,76 @@ func (ts *transactionService) CreateCoinbaseTxn(to string, data string) reps.Tra
 		data = fmt.Sprintf("%x", randData)
 	}
 
-	txnRep := ts.ToCoinbaseTxn(to, data)
+	txnRep := ts.ToCoinbaseTxn(to, data, BlockReward(ts.params, height)+fees)
 	log.Info("txnRep in CreateCoinbaseTxn: ", utils.Pretty(txnRep))
 
 	return txnRep
 }
 
-// Given an address, create a coinbase transaction representation
-func (ts *transactionService) ToCoinbaseTxn(to string, data string) reps.Transaction {
+// Data for a coinbase carrying message: the message, then a space and a random extranonce, so coinbases paying the
+// same miner the same amount with the same message still get different ids. Empty without a message, so the coinbase
+// gets nothing but random data
+func CoinbaseData(message string) (string, error) {
+	if message == "" {
+		return "", nil
+	}
+	if len(message) > MaxCoinbaseMessageLen {
+		return "", fmt.Errorf("coinbase message is %d bytes, more than the limit of %d", len(message), MaxCoinbaseMessageLen)
+	}
+
+	extranonce := make([]byte, 8)
+	if _, err := rand.Read(extranonce); err != nil {
+		return "", fmt.Errorf("%s, cannot generate extranonce", err.Error())
+	}
+
+	return fmt.Sprintf("%s %x", message, extranonce), nil
+}
+
+// Message a coinbase carries, without the extranonce CoinbaseData put after it. Empty if it carries nothing but
+// random data, or isn't a coinbase
+func CoinbaseMessageOf(txn reps.Transaction) string {
+	if len(txn.Inputs) != 1 || len(txn.Inputs[0].PrevTxnID) != 0 || txn.Inputs[0].OutIdx != -1 {
+		return ""
+	}
+
+	data := string(txn.Inputs[0].PubKey)
+	if !utf8.ValidString(data) || isHex(data, 48) {
+		return ""
+	}
+	if i := strings.LastIndex(data, " "); i >= 0 && isHex(data[i+1:], 16) {
+		return data[:i]
+	}
+
+	return data
+}
+
+// Whether s is length hex digits
+func isHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// Coinbase transaction for the genesis block, paying the reward to an address and each allocation on top of it
+func (ts *transactionService) CreateGenesisTxn(to string, data string, allocations []reps.GenesisAllocation) reps.Transaction {
+	txn := ts.CreateCoinbaseTxnWithFees(to, data, 0, 0)
+	if len(allocations) == 0 {
+		return txn
+	}
+
+	for _, allocation := range allocations {
+		txn.Outputs = append(txn.Outputs, ts.NewTxnOutput(allocation.Amount, allocation.Address))
+	}
+	txn.ID = ts.txnAssembler.TxnID(txn)
+	txn.Inputs[0].CurrTxnID = txn.ID
+
+	return txn
+}
+
+// Given an address, create a coinbase transaction representation paying it value
+func (ts *transactionService) ToCoinbaseTxn(to string, data string, value int) reps.Transaction {
 	var txnOut reps.TxnOutput
 	var txnIn reps.TxnInput
 	var txnRep reps.Transaction
@@ -102,7 +224,7 @@ func (ts *transactionService) ToCoinbaseTxn(to string, data string) reps.Transac
 	txnInputId := uuid.Must(uuid.NewRandom()).String()
 	// txnOutputId := uuid.Must(uuid.NewRandom()).String()
 
-	txnOut = ts.NewTxnOutput(Reward, to)
+	txnOut = ts.NewTxnOutput(value, to)
 	// txnOut.OutputID = txnOutputId
 	// txnOut.Value = Reward
 	// txnOut.PubKeyHash = to
@@ -116,9 +238,9 @@ func (ts *transactionService) ToCoinbaseTxn(to string, data string) reps.Transac
 	txnRep.Outputs = []reps.TxnOutput{txnOut}
 	txnRep.Inputs = []reps.TxnInput{txnIn}
 
-	// Put this here to ensure we get a different hash each time
+	// The random data in the input makes sure every coinbase gets a different id
 	// currTxnID := ts.txnAssembler.SetID(txnRep)
-	currTxnID := ts.txnAssembler.HashTransaction(txnRep)
+	currTxnID := ts.txnAssembler.TxnID(txnRep)
 
 	txnRep.ID = currTxnID
 	txnOut.CurrTxnID = currTxnID
@@ -127,17 +249,22 @@ func (ts *transactionService) ToCoinbaseTxn(to string, data string) reps.Transac
 	return txnRep
 }
 
+// Create a transaction sending amount to a single address
+func (ts *transactionService) CreateTransaction(from string, to string, amount int) (reps.Transaction, error) {
+	return ts.CreateTransactionToRecipients(from, []reps.Recipient{{To: to, Amount: amount}}, reps.TxnOptions{})
+}
+
 // Create a transaction. This does the following:
-// 1. Create locked outputs (populate PubKeyHash in the output)
+// 1. Create locked outputs (populate PubKeyHash in the output), one per recipient
 // 2. Create new input referencing locked outputs
 // 3. sign the transaction
-func (ts *transactionService) CreateTransaction(from string, to string, amount int) (reps.Transaction, error) {
-	log.WithFields(log.Fields{"from": from, "to": to, "amount": amount}).Info("Creating transaction...")
+// Whatever the sender's outputs hold beyond the amounts sent and the fee comes back as change
+func (ts *transactionService) CreateTransactionToRecipients(from string, recipients []reps.Recipient, opts reps.TxnOptions) (reps.Transaction, error) {
+	log.WithFields(log.Fields{"from": from, "recipients": utils.Pretty(recipients)}).Info("Creating transaction...")
 
-	var transaction reps.Transaction
-	txnOutput := ts.NewTxnOutput(amount, to)
-	txnInputs := make([]reps.TxnInput, 0)
-	txnOutputs := make([]reps.TxnOutput, 0)
+	if _, err := totalAmount(recipients); err != nil {
+		return reps.Transaction{}, err
+	}
 
 	// Check that a wallet exists to send coins from
 	wallet, err := ts.walletService.GetWallet(from)
@@ -145,71 +272,405 @@ func (ts *transactionService) CreateTransaction(from string, to string, amount i
 		return reps.Transaction{}, err
 	}
 
+	if wallet.WatchOnly {
+		err := fmt.Errorf("%w: %s", ErrWatchOnly, from)
+		log.Error(err)
+		return reps.Transaction{}, err
+	}
+
+	// Signed with whichever scheme the wallet's key pair is for
+	scheme, err := GetSignatureScheme(wallet.SigAlgorithm)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
 	pubKeyBytes, _ := hex.DecodeString(wallet.PublicKey)
-	pubKeyHash, _ := ts.walletService.CreatePubKeyHash(pubKeyBytes)
-	privKey := ts.walletAssembler.ToECDSAPrivateKey(wallet.PrivateKey)
 
-	totalUnspentAmount, validOutputs := ts.GetSpendableOutputs(pubKeyHash, amount)
-	log.WithFields(log.Fields{"totalUnspentAmount": totalUnspentAmount, "validOutputs": utils.Pretty(validOutputs)}).Info("Got spendable outputs")
+	transaction, err := ts.createUnsignedTransaction(from, pubKeyBytes, scheme.Algorithm(), recipients, opts, func() (string, error) {
+		return ts.getChangeAddress(wallet)
+	})
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	// sign transaction
+	transaction, err = ts.SignTransaction(transaction, wallet)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	return transaction, nil
+}
+
+// Where change from a spend goes. Wallets derived from an HD wallet send it to a fresh change address,
+// so it can't be linked back to the sender. Any other wallet gets its change back.
+// Only asked for when there is change, so no derived addresses are skipped over
+func (ts *transactionService) getChangeAddress(wallet reps.Wallet) (string, error) {
+	if wallet.HDWalletID == "" {
+		return wallet.Address, nil
+	}
+
+	return ts.deriveChangeAddress(wallet)
+}
+
+// Derive a fresh change address from the wallet's HD wallet. It joins the same account as the wallet,
+// so the account's balance doesn't drop by the change
+func (ts *transactionService) deriveChangeAddress(wallet reps.Wallet) (string, error) {
+	changeWallet, err := ts.hdWalletService.DeriveChangeWallet(wallet.HDWalletID)
+	if err != nil {
+		return "", fmt.Errorf("%w, unable to derive a change address for %s", err, wallet.Address)
+	}
+
+	if wallet.AccountID != "" {
+		changeWallet.AccountID = wallet.AccountID
+		if err := ts.blockchainRepo.UpdateWallet(changeWallet); err != nil {
+			return "", fmt.Errorf("%s, unable to add change address %s to account", err.Error(), changeWallet.Address)
+		}
+	}
+
+	return changeWallet.Address, nil
+}
+
+// Create and sign a transaction spending from several wallets at once, e.g. every address of an account.
+// Wallets are spent in order until amount and the fee are covered, and each input is signed by the wallet owning it.
+// Any change goes to the first wallet spent from, or a fresh change address if it's from an HD wallet
+func (ts *transactionService) CreateTransactionFromWallets(wallets []reps.Wallet, to string, amount int, opts reps.TxnOptions) (reps.Transaction, error) {
+	log.WithFields(log.Fields{"wallets": len(wallets), "to": to, "amount": amount}).Info("Creating transaction from wallets...")
+
+	if !IsValidAddress(to, ts.params.NetworkByte) {
+		err := fmt.Errorf("address %s is not a valid address for network %d, cancelling transaction", to, ts.params.NetworkByte)
+		log.Error(err)
+		return reps.Transaction{}, err
+	}
+
+	if len(wallets) == 0 {
+		return reps.Transaction{}, fmt.Errorf("no wallets to send %d coins from", amount)
+	}
+
+	// A transaction is signed with a single scheme
+	scheme, err := GetSignatureScheme(wallets[0].SigAlgorithm)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	selector, err := GetCoinSelector(opts.CoinSelection)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	var owners []reps.Wallet // owners[i] signs the transaction's inputs[i]
+	var spentFrom []reps.Wallet
+	recipients := []reps.Recipient{{To: to, Amount: amount}}
+
+	transaction, totalUnspentAmount, err := ts.selectInputs(recipients, opts, func(target int) (reps.Transaction, int, error) {
+		transaction := reps.Transaction{SigAlgorithm: scheme.Algorithm(), Inputs: make([]reps.TxnInput, 0)}
+		owners = make([]reps.Wallet, 0)
+		spentFrom = make([]reps.Wallet, 0)
+		totalUnspentAmount := 0
+
+		for _, wallet := range wallets {
+			if totalUnspentAmount >= target {
+				break
+			}
+
+			if wallet.WatchOnly {
+				err := fmt.Errorf("%w: %s", ErrWatchOnly, wallet.Address)
+				log.Error(err)
+				return reps.Transaction{}, 0, err
+			}
+
+			walletScheme, err := GetSignatureScheme(wallet.SigAlgorithm)
+			if err != nil {
+				return reps.Transaction{}, 0, err
+			}
+			if walletScheme.Algorithm() != scheme.Algorithm() {
+				return reps.Transaction{}, 0, fmt.Errorf("%s uses %s signatures, not %s, cancelling transaction", wallet.Address, walletScheme.Algorithm(), scheme.Algorithm())
+			}
+
+			pubKey, _ := hex.DecodeString(wallet.PublicKey)
+			pubKeyHash, _ := createPubKeyHash(pubKey)
+
+			selected := ts.selectOutputs(pubKeyHash, target-totalUnspentAmount, selector)
+			if len(selected) == 0 {
+				continue
+			}
+			spentFrom = append(spentFrom, wallet)
+
+			for _, unspent := range selected {
+				transaction.Inputs = append(transaction.Inputs, newTxnInput(unspent, pubKey))
+				owners = append(owners, wallet)
+				totalUnspentAmount += unspent.Value
+			}
+		}
+
+		return transaction, totalUnspentAmount, nil
+	})
+	if err != nil {
+		return reps.Transaction{}, err
+	}
 
 	// Not enough coins to send
 	if amount > totalUnspentAmount {
-		err := fmt.Errorf("%s only has %d coins to send to %s, not %d, Cancelling transaction", from, totalUnspentAmount, to, amount)
+		err := fmt.Errorf("wallets only have %d coins to send to %s, not %d, Cancelling transaction", totalUnspentAmount, to, amount)
 		log.Error(err)
 		return reps.Transaction{}, err
 	}
 
-	// For each found unspent output an input referencing it is created
-	for txnId, outputIndices := range validOutputs {
-		decodedTxnId, err := hex.DecodeString(txnId)
+	err = ts.addOutputs(&transaction, recipients, totalUnspentAmount, opts, func() (string, error) {
+		return ts.getChangeAddress(spentFrom[0])
+	})
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	prevTxns, err := ts.GetPrevTransactions(transaction)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	return ts.signInputs(transaction, prevTxns, owners)
+}
+
+// Create a transaction spending outputs locked to from, without signing it.
+// inputPubKey is what unlocks those outputs: the sender's public key, or the redeem script of a multisig address
+// sigAlgorithm is the scheme the inputs will be signed with, and any change goes to changeAddress
+func (ts *transactionService) CreateUnsignedTransaction(from string, inputPubKey []byte, sigAlgorithm string, to string, amount int, changeAddress string) (reps.Transaction, error) {
+	return ts.createUnsignedTransaction(from, inputPubKey, sigAlgorithm, []reps.Recipient{{To: to, Amount: amount}}, reps.TxnOptions{}, func() (string, error) {
+		return changeAddress, nil
+	})
+}
+
+// Sum of what's sent to recipients. Every recipient must be an address on this chain's network, getting a positive amount
+func totalAmount(recipients []reps.Recipient) (int, error) {
+	if len(recipients) == 0 {
+		return 0, fmt.Errorf("a transaction needs at least one recipient")
+	}
+
+	amount := 0
+	for _, recipient := range recipients {
+		if recipient.Amount <= 0 {
+			return 0, fmt.Errorf("amount sent to %s must be positive, not %d", recipient.To, recipient.Amount)
+		}
+		if recipient.Amount > MaxMoney {
+			return 0, fmt.Errorf("amount sent to %s is %d, more than the most of %d", recipient.To, recipient.Amount, MaxMoney)
+		}
+		total, err := addAmount(amount, recipient.Amount)
 		if err != nil {
-			log.WithField("error", err.Error()).Error("Error decoding transactionId to bytes")
+			return 0, fmt.Errorf("amounts sent come to a %s", err.Error())
 		}
+		amount = total
+	}
+
+	return amount, nil
+}
 
-		for _, outputIdx := range outputIndices {
-			input := reps.TxnInput{}
-			inputID := uuid.Must(uuid.NewRandom()).String()
-			input.InputID = inputID
-			input.PrevTxnID = decodedTxnId
-			input.OutIdx = outputIdx
-			input.PubKey = pubKeyBytes
-			txnInputs = append(txnInputs, input)
+// changeAddress is only asked for when there is change
+func (ts *transactionService) createUnsignedTransaction(from string, inputPubKey []byte, sigAlgorithm string, recipients []reps.Recipient, opts reps.TxnOptions,
+	changeAddress func() (string, error)) (reps.Transaction, error) {
+	amount, err := totalAmount(recipients)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	// Coins can only be sent to addresses on this chain's network
+	for _, recipient := range recipients {
+		if !IsValidAddress(recipient.To, ts.params.NetworkByte) {
+			err := fmt.Errorf("address %s is not a valid address for network %d, cancelling transaction", recipient.To, ts.params.NetworkByte)
+			log.Error(err)
+			return reps.Transaction{}, err
 		}
 	}
 
-	transaction.Inputs = txnInputs
+	selector, err := GetCoinSelector(opts.CoinSelection)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	pubKeyHash, _ := createPubKeyHash(inputPubKey)
 
-	// Amount sender gave to receiver
-	txnOutputs = append(txnOutputs, txnOutput)
+	// For each selected unspent output an input referencing it is created
+	transaction, totalUnspentAmount, err := ts.selectInputs(recipients, opts, func(target int) (reps.Transaction, int, error) {
+		transaction := reps.Transaction{SigAlgorithm: sigAlgorithm, Inputs: make([]reps.TxnInput, 0)}
+		totalUnspentAmount := 0
+		for _, unspent := range ts.selectOutputs(pubKeyHash, target, selector) {
+			transaction.Inputs = append(transaction.Inputs, newTxnInput(unspent, inputPubKey))
+			totalUnspentAmount += unspent.Value
+		}
+		return transaction, totalUnspentAmount, nil
+	})
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+	log.WithFields(log.Fields{"totalUnspentAmount": totalUnspentAmount, "strategy": selector.Strategy(), "inputs": len(transaction.Inputs)}).Info("Selected spendable outputs")
 
-	// Any change associated with sender
-	if totalUnspentAmount > amount {
-		txnOutputChange := ts.NewTxnOutput(totalUnspentAmount-amount, from)
-		txnOutputs = append(txnOutputs, txnOutputChange)
+	// Not enough coins to send
+	if amount > totalUnspentAmount {
+		err := fmt.Errorf("%s only has %d coins to send, not %d, Cancelling transaction", from, totalUnspentAmount, amount)
+		log.Error(err)
+		return reps.Transaction{}, err
 	}
 
-	transaction.Outputs = txnOutputs
+	// Any change goes back to the sender
+	err = ts.addOutputs(&transaction, recipients, totalUnspentAmount, opts, changeAddress)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
 
-	// txnId := ts.txnAssembler.SetID(transaction)
-	txnId := ts.txnAssembler.HashTransaction(transaction)
+	return transaction, nil
+}
 
-	for i := 0; i < len(txnInputs); i++ {
-		txnInputs[i].CurrTxnID = txnId
+// Pick the inputs of a transaction to recipients. selectFor returns the transaction with inputs covering target, if there's enough
+// to, and what they hold. A fee paid by rate isn't known until the inputs are, so target is raised to cover it and inputs selected again,
+// until they cover it or run out
+func (ts *transactionService) selectInputs(recipients []reps.Recipient, opts reps.TxnOptions,
+	selectFor func(target int) (reps.Transaction, int, error)) (reps.Transaction, int, error) {
+	amount, err := totalAmount(recipients)
+	if err != nil {
+		return reps.Transaction{}, 0, err
 	}
 
-	for j := 0; j < len(txnOutputs); j++ {
-		txnOutputs[j].CurrTxnID = txnId
+	target, err := addAmount(amount, opts.Fee)
+	if err != nil {
+		return reps.Transaction{}, 0, fmt.Errorf("amount and fee come to a %s", err.Error())
 	}
+	for {
+		txn, totalIn, err := selectFor(target)
+		if err != nil || opts.FeeRate == 0 || totalIn < target {
+			return txn, totalIn, err
+		}
 
-	transaction.ID = txnId
+		fee := ts.rateFee(txn, recipients, totalIn, opts)
+		if amount+fee <= totalIn {
+			return txn, totalIn, nil
+		}
+		target = amount + fee
+	}
+}
 
-	// sign transaction
-	transaction, err = ts.SignTransaction(transaction, privKey)
+// Input spending an unspent output, unlocked with pubKey
+func newTxnInput(unspent reps.UnspentOutput, pubKey []byte) reps.TxnInput {
+	return reps.TxnInput{
+		InputID:   uuid.Must(uuid.NewRandom()).String(),
+		PrevTxnID: unspent.TxnID,
+		OutIdx:    unspent.OutIdx,
+		PubKey:    pubKey,
+	}
+}
+
+// Give txn, whose inputs hold totalIn, an output for each recipient and one for any change, work out its fee and set its id.
+// changeAddress is only asked for when there is change
+func (ts *transactionService) addOutputs(txn *reps.Transaction, recipients []reps.Recipient, totalIn int, opts reps.TxnOptions,
+	changeAddress func() (string, error)) error {
+	if err := validateOptions(opts); err != nil {
+		return err
+	}
+	txn.Memo = opts.Memo
+	txn.LockTime = opts.LockTime
+	txn.Replaceable = opts.Replaceable
+
+	amount, err := totalAmount(recipients)
 	if err != nil {
-		return reps.Transaction{}, err
+		return err
 	}
 
-	return transaction, nil
+	// Amount sender gave to each receiver
+	txnOutputs := make([]reps.TxnOutput, 0, len(recipients)+1)
+	for _, recipient := range recipients {
+		if recipient.Amount < ts.params.DustThreshold {
+			return fmt.Errorf("amount sent to %s is %d, below the dust threshold of %d", recipient.To, recipient.Amount, ts.params.DustThreshold)
+		}
+		txnOutputs = append(txnOutputs, ts.recipientOutput(recipient))
+	}
+
+	txn.Fee = opts.Fee
+	if opts.FeeRate > 0 {
+		txn.Fee = ts.rateFee(*txn, recipients, totalIn, opts)
+	}
+
+	// Not enough coins to send
+	if amount+txn.Fee > totalIn {
+		err := fmt.Errorf("only %d coins to spend, not %d plus a fee of %d, Cancelling transaction", totalIn, amount, txn.Fee)
+		log.Error(err)
+		return err
+	}
+
+	// Change too small to be worth an output goes to the miner instead
+	if change := totalIn - amount - txn.Fee; change > 0 && change < ts.params.DustThreshold {
+		txn.Fee += change
+	} else if change > 0 {
+		address, err := changeAddress()
+		if err != nil {
+			return err
+		}
+		txnOutputs = append(txnOutputs, ts.NewTxnOutput(change, address))
+	}
+
+	txn.Outputs = txnOutputs
+
+	ts.setID(txn)
+
+	return nil
+}
+
+// Output paying a recipient, or bonding the amount as their stake
+func (ts *transactionService) recipientOutput(recipient reps.Recipient) reps.TxnOutput {
+	output := ts.NewTxnOutput(recipient.Amount, recipient.To)
+	output.Staked = recipient.Stake
+	return output
+}
+
+func validateOptions(opts reps.TxnOptions) error {
+	if opts.Fee < 0 || opts.FeeRate < 0 {
+		return fmt.Errorf("fee can't be negative")
+	}
+	if opts.Fee > 0 && opts.FeeRate > 0 {
+		return fmt.Errorf("give either a fee or a fee rate, not both")
+	}
+	if len(opts.Memo) > MaxMemoLen {
+		return fmt.Errorf("memo can be at most %d bytes, not %d", MaxMemoLen, len(opts.Memo))
+	}
+	if opts.LockTime < 0 {
+		return fmt.Errorf("lock time can't be negative")
+	}
+	return nil
+}
+
+// Set the id of a transaction that's complete but for its signatures, on it and its inputs and outputs
+func (ts *transactionService) setID(txn *reps.Transaction) {
+	// txnId := ts.txnAssembler.SetID(transaction)
+	txnId := ts.txnAssembler.TxnID(*txn)
+
+	for i := 0; i < len(txn.Inputs); i++ {
+		txn.Inputs[i].CurrTxnID = txnId
+	}
+
+	for j := 0; j < len(txn.Outputs); j++ {
+		txn.Outputs[j].CurrTxnID = txnId
+	}
+
+	txn.ID = txnId
+}
+
+// Fee txn pays at opts.FeeRate once it has outputs to recipients. It's sized with a change output,
+// which is the larger of the two ways the transaction can turn out
+func (ts *transactionService) rateFee(txn reps.Transaction, recipients []reps.Recipient, totalIn int, opts reps.TxnOptions) int {
+	sized := txn
+	sized.Memo = opts.Memo
+	sized.LockTime = opts.LockTime
+	sized.Replaceable = opts.Replaceable
+	sized.Outputs = make([]reps.TxnOutput, 0, len(recipients)+1)
+	for _, recipient := range recipients {
+		sized.Outputs = append(sized.Outputs, ts.recipientOutput(recipient))
+	}
+	sized.Outputs = append(sized.Outputs, ts.NewTxnOutput(totalIn, recipients[0].To))
+
+	return ts.sizedFee(sized, opts.FeeRate)
+}
+
+// Fee txn pays at feeRate once its inputs are signed
+func (ts *transactionService) sizedFee(txn reps.Transaction, feeRate int) int {
+	size := len(ts.txnAssembler.ToTxnBytes(txn)) + len(txn.Inputs)*SignatureSizeAllowance
+	return (size*feeRate + 999) / 1000
 }
 
 // Get transaction on a block by transactionId
@@ -229,6 +690,102 @@ func (tx *transactionService) GetTransaction(txnId string) (reps.Transaction, er
 	return txn, nil
 }
 
+// Get where on the chain a transaction is, from the transaction index
+func (ts *transactionService) GetTransactionLocation(txnId string) (reps.TxnLocation, error) {
+	txnIdBytes, err := hex.DecodeString(txnId)
+	if err != nil {
+		return reps.TxnLocation{}, fmt.Errorf("%s, invalid transaction id: %s", err.Error(), txnId)
+	}
+
+	location, err := ts.blockchainRepo.GetTxnLocation(txnIdBytes)
+	if err != nil {
+		return reps.TxnLocation{}, fmt.Errorf("%s, id: %s", err.Error(), txnId)
+	}
+
+	return location, nil
+}
+
+// Receipt for a transaction on the chain: where it is, and a merkle proof from it up to its block's merkle root.
+// With the block's other header fields, anyone can check the block hash covers it without downloading the block
+func (ts *transactionService) GetTransactionReceipt(txnId string) (reps.TxnReceipt, error) {
+	location, err := ts.GetTransactionLocation(txnId)
+	if err != nil {
+		return reps.TxnReceipt{}, err
+	}
+
+	block, err := ts.blockchainRepo.GetBlockById(location.BlockID)
+	if err != nil {
+		return reps.TxnReceipt{}, fmt.Errorf("%s, block: %s", err.Error(), location.BlockID)
+	}
+
+	branch, err := merkleBranch(block, location.TxnID, chainHasher(ts.params))
+	if err != nil {
+		return reps.TxnReceipt{}, err
+	}
+
+	nextHeight, err := nextBlockHeight(ts.blockchainRepo)
+	if err != nil {
+		return reps.TxnReceipt{}, err
+	}
+
+	return reps.TxnReceipt{
+		MerkleBranch:  branch,
+		Height:        location.Height,
+		Confirmations: nextHeight - 1 - location.Height,
+		Version:       block.Version,
+		PrevHash:      hex.EncodeToString(block.PrevHash),
+		Timestamp:     block.Timestamp,
+		Difficulty:    block.Difficulty,
+		Nounce:        block.Nounce,
+	}, nil
+}
+
+// Merkle branch from the transaction with hex id txnId up to block's merkle root. hasher takes the chain's block hashes
+func merkleBranch(block reps.Block, txnId string, hasher BlockHasher) (reps.MerkleBranch, error) {
+	position := -1
+	for i, txn := range block.Transactions {
+		if hex.EncodeToString(txn.ID) == txnId {
+			position = i
+		}
+	}
+	if position == -1 {
+		return reps.MerkleBranch{}, fmt.Errorf("%w: %s isn't on block %s", ErrTxnNotOnBlock, txnId, block.ID)
+	}
+
+	leaves := MerkleLeaves(block)
+	merkleTree, err := reps.NewMerkleTree(leaves)
+	if err != nil {
+		return reps.MerkleBranch{}, err
+	}
+	if !bytes.Equal(NewProofOfWorkService(&block, hasher).HashData(), block.Hash) {
+		return reps.MerkleBranch{}, fmt.Errorf("transactions of block %s don't hash to its block hash, can't prove %s is on it", block.ID, txnId)
+	}
+
+	steps, err := reps.NewMerkleProof(leaves, position)
+	if err != nil {
+		return reps.MerkleBranch{}, err
+	}
+	proof := make([]reps.ReadableProofStep, 0)
+	for _, step := range steps {
+		side := "right"
+		if step.Left {
+			side = "left"
+		}
+		proof = append(proof, reps.ReadableProofStep{Hash: hex.EncodeToString(step.Hash), Side: side})
+	}
+
+	leafHash := sha256.Sum256(leaves[position])
+	return reps.MerkleBranch{
+		TxnID:      txnId,
+		BlockHash:  hex.EncodeToString(block.Hash),
+		Position:   position,
+		LeafData:   hex.EncodeToString(leaves[position]),
+		LeafHash:   hex.EncodeToString(leafHash[:]),
+		MerkleRoot: hex.EncodeToString(merkleTree.Root.Data),
+		Proof:      proof,
+	}, nil
+}
+
 // Get all transactions that exist on blockchain
 func (ts *transactionService) GetTransactions() ([]reps.Transaction, error) {
 	log.Info("Attempting to get all transactions on the blockchain")
@@ -240,6 +797,41 @@ func (ts *transactionService) GetTransactions() ([]reps.Transaction, error) {
 	return txns, nil
 }
 
+// Get every transaction an address appears in, oldest first, with the block it's on and
+// whether the address sent or received coins. Looked up in the address index rather than scanning the chain
+func (ts *transactionService) GetAddressHistory(address string) ([]reps.AddressTransaction, error) {
+	log.Info("Attempting to get transaction history for address: ", address)
+	if !IsValidAddress(address, ts.params.NetworkByte) {
+		return []reps.AddressTransaction{}, fmt.Errorf("malformed address: %s", address)
+	}
+
+	decoded := base58Decode([]byte(address))
+	pubKeyHash := decoded[1 : len(decoded)-ChecksumLen]
+
+	entries, err := ts.blockchainRepo.GetAddressIndex(pubKeyHash)
+	if err != nil {
+		return []reps.AddressTransaction{}, err
+	}
+
+	history := make([]reps.AddressTransaction, 0, len(entries))
+	for _, entry := range entries {
+		txn, err := ts.blockchainRepo.GetTransaction(entry.TxnID)
+		if err != nil {
+			return []reps.AddressTransaction{}, fmt.Errorf("%s, address index refers to missing transaction %x", err.Error(), entry.TxnID)
+		}
+
+		history = append(history, reps.AddressTransaction{
+			Transaction: txn,
+			BlockHash:   entry.BlockHash,
+			Height:      entry.Height,
+			Direction:   entry.Direction,
+			Amount:      entry.Amount,
+		})
+	}
+
+	return history, nil
+}
+
 // Get balances for each address / wallet
 func (ts *transactionService) GetBalances() ([]reps.AddressBalance, error) {
 	log.Info("Attempting to get the balance for each wallet / address")
@@ -292,6 +884,53 @@ func (ts *transactionService) GetBalance(address string) (int, error) {
 	return balance, nil
 }
 
+// Get the confirmed balance of any address, whether or not it's a wallet on this node.
+// Pending is left for the mempool to fill in
+func (ts *transactionService) GetAddressBalance(address string) (reps.AddressBalanceSummary, error) {
+	log.Info("Attempting to get the confirmed and pending balance of address: ", address)
+	if !IsValidAddress(address, ts.params.NetworkByte) {
+		return reps.AddressBalanceSummary{}, fmt.Errorf("malformed address: %s", address)
+	}
+
+	decoded := base58Decode([]byte(address))
+	pubKeyHash := decoded[1 : len(decoded)-ChecksumLen]
+
+	unspentOutputs, err := ts.blockchainRepo.GetUnspentOutputs(pubKeyHash)
+	if err != nil {
+		return reps.AddressBalanceSummary{}, err
+	}
+
+	summary := reps.AddressBalanceSummary{Address: address, Assets: make([]reps.AssetBalance, 0)}
+	for _, unspent := range unspentOutputs {
+		if unspent.Staked {
+			summary.Staked += unspent.Value
+		} else if unspent.AssetID == "" {
+			summary.Confirmed += unspent.Value
+		} else {
+			ts.AssetBalance(&summary, unspent.AssetID).Confirmed += unspent.Value
+		}
+	}
+
+	return summary, nil
+}
+
+// Balance of an asset in summary, added if the summary doesn't have it yet
+func (ts *transactionService) AssetBalance(summary *reps.AddressBalanceSummary, assetId string) *reps.AssetBalance {
+	for i := range summary.Assets {
+		if summary.Assets[i].AssetID == assetId {
+			return &summary.Assets[i]
+		}
+	}
+
+	balance := reps.AssetBalance{AssetID: assetId}
+	if asset, err := ts.blockchainRepo.GetAsset(assetId); err == nil {
+		balance.Symbol = asset.Symbol
+	}
+	summary.Assets = append(summary.Assets, balance)
+
+	return &summary.Assets[len(summary.Assets)-1]
+}
+
 // Find out how much of the unspendable outputs from the sender can be spent given an amount
 func (ts *transactionService) GetSpendableOutputs(pubKeyHash []byte, amount int) (int, map[string][]int) {
 	log.WithFields(log.Fields{"from": hex.EncodeToString(pubKeyHash), "amount": amount}).Info("Calling GetSpendableOutputs")
@@ -300,97 +939,261 @@ func (ts *transactionService) GetSpendableOutputs(pubKeyHash []byte, amount int)
 	// <key>: transactionIds associated with spender
 	// <value> list of all unspent output indices associated with sender for each transaction
 	unspentOutIdxs := make(map[string][]int)
-	unspentTxns := ts.GetUnspentTransactions(pubKeyHash)
 
-	// Outer:
-	for _, unspentTxn := range unspentTxns {
-		txnId := hex.EncodeToString(unspentTxn.ID)
+	selector, _ := GetCoinSelector("")
+	for _, unspent := range ts.selectOutputs(pubKeyHash, amount, selector) {
+		txnId := hex.EncodeToString(unspent.TxnID)
+		unspentOutIdxs[txnId] = append(unspentOutIdxs[txnId], unspent.OutIdx)
+		totalUnspentAmount += unspent.Value
+	}
+	return totalUnspentAmount, unspentOutIdxs
+}
+
+// Every unspent output of any address, of the chain's own coin and of assets, oldest first
+func (ts *transactionService) GetAddressUnspentOutputs(address string) ([]reps.AddressUnspentOutput, error) {
+	log.Info("Attempting to get unspent outputs for address: ", address)
+	if !IsValidAddress(address, ts.params.NetworkByte) {
+		return []reps.AddressUnspentOutput{}, fmt.Errorf("malformed address: %s", address)
+	}
 
-		for outputIdx, output := range unspentTxn.Outputs {
-			if ts.IsLockedWithKey(output, pubKeyHash) /*&& totalUnspentAmount < amount*/ {
-				unspentOutIdxs[txnId] = append(unspentOutIdxs[txnId], outputIdx)
-				totalUnspentAmount += output.Value
+	decoded := base58Decode([]byte(address))
+	pubKeyHash := decoded[1 : len(decoded)-ChecksumLen]
 
-				// if totalUnspentAmount >= amount {
-				// 	break Outer
-				// }
-			}
+	unspentOutputs, err := ts.blockchainRepo.GetUnspentOutputs(pubKeyHash)
+	if err != nil {
+		return []reps.AddressUnspentOutput{}, err
+	}
+
+	nextHeight, err := nextBlockHeight(ts.blockchainRepo)
+	if err != nil {
+		return []reps.AddressUnspentOutput{}, err
+	}
+
+	sort.Slice(unspentOutputs, func(i, j int) bool {
+		if unspentOutputs[i].Height != unspentOutputs[j].Height {
+			return unspentOutputs[i].Height < unspentOutputs[j].Height
 		}
+		return unspentOutputs[i].ID < unspentOutputs[j].ID
+	})
+
+	utxos := make([]reps.AddressUnspentOutput, 0, len(unspentOutputs))
+	for _, unspent := range unspentOutputs {
+		utxos = append(utxos, reps.AddressUnspentOutput{
+			TxnID:         hex.EncodeToString(unspent.TxnID),
+			Vout:          unspent.OutIdx,
+			Value:         unspent.Value,
+			AssetID:       unspent.AssetID,
+			PubKeyHash:    unspent.PubKeyHash,
+			Height:        unspent.Height,
+			Confirmations: nextHeight - 1 - unspent.Height,
+			Coinbase:      unspent.Coinbase,
+			Staked:        unspent.Staked,
+			Spendable:     ts.isMature(unspent, nextHeight),
+		})
 	}
-	return totalUnspentAmount, unspentOutIdxs
+
+	return utxos, nil
 }
 
-// Get all transactions whose outputs aren't referenced in inputs
+// Outputs locked with pubKeyHash that selector picks to cover amount, out of those that can go on the next block
+func (ts *transactionService) selectOutputs(pubKeyHash []byte, amount int, selector CoinSelector) []reps.UnspentOutput {
+	// Coinbase outputs that aren't mature yet can't be spent on the next block
+	nextHeight, err := nextBlockHeight(ts.blockchainRepo)
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error getting the next block height")
+		return []reps.UnspentOutput{}
+	}
+
+	spendable := make([]reps.UnspentOutput, 0)
+	for _, unspent := range ts.findUnspentOutputs(pubKeyHash) {
+		if ts.isMature(unspent, nextHeight) {
+			spendable = append(spendable, unspent)
+		}
+	}
+
+	return selector.Select(spendable, amount)
+}
+
+// Get all transactions with at least one output locked with pubKeyHash that isn't referenced in an input
 func (ts *transactionService) GetUnspentTransactions(pubKeyHash []byte) []reps.Transaction {
 	var unspentTxns []reps.Transaction
 
-	// key: transaction id, value: list of output indices
-	spentTxns := make(map[string][]int)
+	seen := make(map[string]bool)
+	for _, unspent := range ts.findUnspentOutputs(pubKeyHash) {
+		txnId := hex.EncodeToString(unspent.TxnID)
+		if seen[txnId] {
+			continue
+		}
+		seen[txnId] = true
+
+		txn, err := ts.blockchainRepo.GetTransaction(unspent.TxnID)
+		if err != nil {
+			log.WithField("error", err.Error()).Error("Error getting transaction of unspent output ", unspent.ID)
+			continue
+		}
+		unspentTxns = append(unspentTxns, txn)
+	}
+
+	return unspentTxns
+}
+
+// Every output of the chain's own coin locked with pubKeyHash that hasn't been spent, from the UTXO set
+func (ts *transactionService) findUnspentOutputs(pubKeyHash []byte) []reps.UnspentOutput {
+	return ts.findAssetOutputs(pubKeyHash, "")
+}
 
-	// TODO: Need to put the sorting in blockchainrepo perhaps
+// Every output holding units of an asset locked with pubKeyHash that hasn't been spent. An empty assetId is the chain's own coin.
+// Stake is left out, it's only spent by unbonding it
+func (ts *transactionService) findAssetOutputs(pubKeyHash []byte, assetId string) []reps.UnspentOutput {
+	unspentOutputs, err := ts.blockchainRepo.GetUnspentOutputs(pubKeyHash)
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Error getting unspent outputs")
+		return []reps.UnspentOutput{}
+	}
+
+	found := make([]reps.UnspentOutput, 0, len(unspentOutputs))
+	for _, unspent := range unspentOutputs {
+		if unspent.AssetID == assetId && !unspent.Staked {
+			found = append(found, unspent)
+		}
+	}
+
+	return found
+}
+
+// Whether an unspent output can go on a block at height. Coinbase outputs need CoinbaseMaturity confirmations first
+func (ts *transactionService) isMature(unspent reps.UnspentOutput, height int) bool {
+	return !unspent.Coinbase || height-unspent.Height >= ts.params.CoinbaseMaturity
+}
+
+// Rebuild the UTXO set, transaction index and address index by scanning the whole chain. Returns the number of unspent outputs found
+func (ts *transactionService) ReindexUnspentOutputs() (int, error) {
+	log.Info("Reindexing unspent outputs, transactions and addresses")
 	blocks, err := ts.blockchainRepo.GetBlockchain()
 	if err != nil {
-		log.WithField("error", err.Error()).Error("Error getting blocks in blockchain")
+		return 0, err
 	}
 
-	// Need to process genesis block last
+	// Oldest first, so a block's position is its height
 	sort.Slice(blocks, func(i, j int) bool {
-		return blocks[i].Timestamp > blocks[j].Timestamp
+		return blocks[i].Height < blocks[j].Height
 	})
 
-	for _, block := range blocks {
+	// A chain started from a snapshot is rebuilt from the snapshot's UTXO set, and the blocks after it
+	seeded, snapshotHeight, err := snapshotOutputs(ts.blockchainRepo)
+	if err != nil {
+		return 0, err
+	}
+	for len(blocks) > 0 && blocks[0].Height <= snapshotHeight {
+		blocks = blocks[1:]
+	}
 
-		for _, txn := range block.Transactions {
-			txnId := hex.EncodeToString(txn.ID)
+	spentOutputs := ts.GetSpentOutputs(blocks)
+	unspentOutputs := make([]reps.UnspentOutput, 0)
 
-		Outputs:
-			for outputIdx, output := range txn.Outputs {
-				// if spentTxns[txnId] != nil {
-				if _, ok := spentTxns[txnId]; ok {
-					for _, inputOutIdx := range spentTxns[txnId] {
-						if outputIdx == inputOutIdx {
-							continue Outputs // Skip this spent transaction
-						}
-					}
-				}
+	// Every output so far, spent or not, for the address index to tell who each input spends from
+	outputs := make(map[string]reps.UnspentOutput)
+	entries := make([]reps.AddressIndexEntry, 0)
+	locations := make([]reps.TxnLocation, 0)
 
-				// If we get to here no OutIdx is referenced in an input; ie unspent
-				if ts.IsLockedWithKey(output, pubKeyHash) {
-					unspentTxns = append(unspentTxns, txn)
-				}
-			}
+	for _, output := range seeded {
+		outputs[output.ID] = output
+		if _, spent := spentOutputs[hex.EncodeToString(output.TxnID)][output.OutIdx]; !spent {
+			unspentOutputs = append(unspentOutputs, output)
+		}
+	}
 
-			// Non coinbase transaction will have an input with non negative OutIdx. Find all of them for a given transaction, these are the spent txns
-			if !ts.IsCoinbaseTransaction(txn) {
-				for _, input := range txn.Inputs {
-					if ts.UsesKey(input, pubKeyHash) {
-						txnId := hex.EncodeToString(input.PrevTxnID)
-						spentTxns[txnId] = append(spentTxns[txnId], input.OutIdx)
-					}
+	for i, block := range blocks {
+		height := snapshotHeight + 1 + i
+		for position, txn := range block.Transactions {
+			txnId := hex.EncodeToString(txn.ID)
+			locations = append(locations, reps.NewTxnLocation(txn, block, height, position))
+			entries = append(entries, reps.NewAddressIndexEntries(txn, block, height, position, outputs)...)
+
+			for outputIdx := range txn.Outputs {
+				output := reps.NewUnspentOutput(txn, outputIdx, block.ID, height)
+				outputs[output.ID] = output
+				if _, spent := spentOutputs[txnId][outputIdx]; spent {
+					continue
 				}
+				unspentOutputs = append(unspentOutputs, output)
 			}
 		}
+	}
+
+	if err := ts.blockchainRepo.ReplaceUnspentOutputs(unspentOutputs); err != nil {
+		return 0, err
+	}
+	if err := ts.blockchainRepo.ReplaceAddressIndex(entries); err != nil {
+		return 0, err
+	}
+	if err := ts.blockchainRepo.ReplaceTxnLocations(locations); err != nil {
+		return 0, err
+	}
+
+	log.Infof("Indexed %d unspent outputs and %d address entries across %d blocks", len(unspentOutputs), len(entries), len(blocks))
+	return len(unspentOutputs), nil
+}
+
+// Build the UTXO set, transaction index and address index if there's a chain but any of them is missing, e.g. on a db from before they existed
+func IndexUnspentOutputsAtStartup(blockchainRepo repository.BlockchainRepository, transactionService TransactionService) {
+	count, err := blockchainRepo.CountUnspentOutputs()
+	if err != nil {
+		log.Fatal("Error reading UTXO set: ", err.Error())
+	}
+	indexed, err := blockchainRepo.CountAddressIndex()
+	if err != nil {
+		log.Fatal("Error reading address index: ", err.Error())
+	}
+	located, err := blockchainRepo.CountTxnLocations()
+	if err != nil {
+		log.Fatal("Error reading transaction index: ", err.Error())
+	}
+	if count > 0 && indexed > 0 && located > 0 {
+		return
+	}
+
+	if _, err := blockchainRepo.GetLastBlock(); err != nil {
+		return
+	}
+
+	if _, err := transactionService.ReindexUnspentOutputs(); err != nil {
+		log.Fatal("Error building UTXO set: ", err.Error())
+	}
+}
 
-		// Don't process the Transactions in the Genesis block.
-		if len(block.PrevHash) == 0 {
-			break
+// Find every output on the given blocks that is referenced by an input.
+// <key>: transactionId of the spent output
+// <value>: output index -> transaction whose input spends that output
+func (ts *transactionService) GetSpentOutputs(blocks []reps.Block) map[string]map[int]reps.Transaction {
+	spentOutputs := make(map[string]map[int]reps.Transaction)
+
+	for _, block := range blocks {
+		for _, txn := range block.Transactions {
+			// Coinbase transactions don't reference previous outputs
+			if ts.IsCoinbaseTransaction(txn) {
+				continue
+			}
+
+			for _, input := range txn.Inputs {
+				prevTxnId := hex.EncodeToString(input.PrevTxnID)
+				if _, ok := spentOutputs[prevTxnId]; !ok {
+					spentOutputs[prevTxnId] = make(map[int]reps.Transaction)
+				}
+				spentOutputs[prevTxnId][input.OutIdx] = txn
+			}
 		}
 	}
-	return unspentTxns
+
+	return spentOutputs
 }
 
-// Get the outputs from unspent transactions
+// Get the unspent outputs locked with a pubKeyHash
 func (ts *transactionService) GetUnspentTxnOutputs(address []byte) []reps.TxnOutput {
 	unspentTxnOutputs := make([]reps.TxnOutput, 0)
-	unspentTxns := ts.GetUnspentTransactions(address)
-	// log.Info("Got unspentTxns in GetUnspentTxnOutputs", utils.Pretty(unspentTxns))
 
-	for _, unspentTxn := range unspentTxns {
-		for _, output := range unspentTxn.Outputs {
-			if ts.IsLockedWithKey(output, address) {
-				unspentTxnOutputs = append(unspentTxnOutputs, output)
-			}
-		}
+	for _, unspent := range ts.findUnspentOutputs(address) {
+		unspentTxnOutputs = append(unspentTxnOutputs, unspent.TxnOutput())
 	}
 
 	return unspentTxnOutputs
@@ -407,121 +1210,386 @@ func (ts *transactionService) NewTxnOutput(value int, address string) reps.TxnOu
 	return txnOutput
 }
 
-func (ts *transactionService) SignTransaction(txn reps.Transaction, privKey ecdsa.PrivateKey) (reps.Transaction, error) {
+func (ts *transactionService) SignTransaction(txn reps.Transaction, wallet reps.Wallet) (reps.Transaction, error) {
 	log.Info("Attempting to sign transaction: ", hex.EncodeToString(txn.ID))
+	prevTxns, err := ts.GetPrevTransactions(txn)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	return ts.Sign(wallet, txn, prevTxns)
+}
+
+// Get the transactions whose outputs are spent by txn's inputs, keyed by transaction id
+func (ts *transactionService) GetPrevTransactions(txn reps.Transaction) (map[string]reps.Transaction, error) {
 	prevTxns := make(map[string]reps.Transaction)
 
 	for _, input := range txn.Inputs {
-		prevTxn, err := ts.blockchainRepo.GetTransaction(input.PrevTxnID)
+		prevTxn, err := ts.prevTransaction(input, prevTxns)
 		if err != nil {
 			log.Error("error finding previous transaction with id: ", input.PrevTxnID)
-			return reps.Transaction{}, err
+			return map[string]reps.Transaction{}, err
 		}
 		prevTxns[hex.EncodeToString(prevTxn.ID)] = prevTxn
 	}
 
-	return ts.Sign(privKey, txn, prevTxns)
+	return prevTxns, nil
+}
+
+// Hash that gets signed for an input. It's the hash of a trimmed copy of the transaction
+// where only this input's PubKey is set, to the pubKeyHash of the output it spends. On a chain with replay
+// protection it's hashed again along with the chain's identifier, so the signature doesn't check out on any other
+func (ts *transactionService) SigningHash(txn reps.Transaction, inIdx int, prevTxns map[string]reps.Transaction) []byte {
+	txnCopy := ts.CreateTrimmedTxnCopy(txn)
+	input := txnCopy.Inputs[inIdx]
+	prevTxn := prevTxns[hex.EncodeToString(input.PrevTxnID)]
+
+	txnCopy.Inputs[inIdx].PubKey = prevTxn.Outputs[input.OutIdx].PubKeyHash
+
+	hash := ts.txnAssembler.HashTransaction(txnCopy)
+	if chainId := ChainIdentifier(ts.params); chainId != "" {
+		replayProtected := sha256.Sum256(bytes.Join([][]byte{txnReplayPrefix, []byte(chainId), hash}, []byte{}))
+		hash = replayProtected[:]
+	}
+
+	return hash
 }
 
+// Check a transaction can be added to the chain: a coinbase transaction's outputs pay the chain's own coin,
+// and any other transaction spends existing, unspent outputs it can unlock, with what they hold beyond its outputs being its fee.
+// How many outputs a coinbase has and how much it can pay depend on its block, so that's checked with the block
 func (ts *transactionService) VerifyTransaction(txn reps.Transaction) (bool, error) {
 	log.Info("Attempting to verify transaction: ", hex.EncodeToString(txn.ID))
+	txnId := hex.EncodeToString(txn.ID)
+
 	if ts.IsCoinbaseTransaction(txn) {
+		if len(txn.Outputs) == 0 {
+			return false, &TxnVerificationError{TxnID: txnId, InputIndex: -1, Reason: InvalidTxnCoinbase, Message: "coinbase must have an output"}
+		}
+		for _, output := range txn.Outputs {
+			if output.Value <= 0 || output.Value > MaxMoney || output.AssetID != "" || output.Staked {
+				return false, &TxnVerificationError{TxnID: txnId, InputIndex: -1, Reason: InvalidTxnCoinbase, Message: "coinbase outputs must pay a positive amount of the chain's own coin"}
+			}
+		}
+		if !ts.hasValidID(txn) {
+			return false, &TxnVerificationError{TxnID: txnId, InputIndex: -1, Reason: InvalidTxnID, Message: "id is not the hash of the transaction"}
+		}
 		return true, nil
 	}
 
+	if IsSlashingTransaction(txn) {
+		return ts.verifySlashingTransaction(txn)
+	}
+	if IsValidatorChangeTransaction(txn) {
+		return ts.verifyValidatorChangeTransaction(txn)
+	}
+
+	if len(txn.Inputs) == 0 || len(txn.Outputs) == 0 {
+		return false, &TxnVerificationError{TxnID: txnId, InputIndex: -1, Reason: InvalidTxnValue, Message: "transaction needs at least one input and one output"}
+	}
+
+	if len(txn.Memo) > MaxMemoLen {
+		return false, &TxnVerificationError{TxnID: txnId, InputIndex: -1, Reason: InvalidTxnMemo, Message: fmt.Sprintf("memo is %d bytes, more than the %d allowed", len(txn.Memo), MaxMemoLen)}
+	}
+
+	// Spent on the next block at the earliest
+	nextHeight, err := nextBlockHeight(ts.blockchainRepo)
+	if err != nil {
+		return false, err
+	}
+	if err := checkSigAlgorithmActive(ts.params, txn.SigAlgorithm, nextHeight); err != nil {
+		return false, &TxnVerificationError{TxnID: txnId, InputIndex: -1, Reason: InvalidTxnInactiveRule, Message: err.Error()}
+	}
+
 	prevTxns := make(map[string]reps.Transaction)
+	spending := make(map[string]bool)
+	inputTotal := 0
 
-	for _, input := range txn.Inputs {
-		prevTxn, err := ts.blockchainRepo.GetTransaction(input.PrevTxnID)
+	// Units of each asset spent and created. Fees are only paid in the chain's own coin
+	assetsIn := make(map[string]int)
+	assetsOut := make(map[string]int)
+
+	for inIdx, input := range txn.Inputs {
+		prevTxn, err := ts.prevTransaction(input, prevTxns)
 		if err != nil {
 			log.Error("error finding previous transaction with id: ", input.PrevTxnID)
-			return false, err
+			return false, &TxnVerificationError{
+				TxnID:      txnId,
+				InputIndex: inIdx,
+				Reason:     InvalidTxnUnknownOutput,
+				Message:    fmt.Sprintf("previous transaction %x does not exist", input.PrevTxnID),
+			}
+		}
+		if input.OutIdx < 0 || input.OutIdx >= len(prevTxn.Outputs) {
+			return false, &TxnVerificationError{TxnID: txnId, InputIndex: inIdx, Reason: InvalidTxnUnknownOutput, Message: fmt.Sprintf("output %d of transaction %x does not exist", input.OutIdx, input.PrevTxnID)}
 		}
+
+		// An output can only ever be spent once, on the chain or within this transaction
+		outpoint := reps.OutpointID(input.PrevTxnID, input.OutIdx)
+		unspent, err := ts.blockchainRepo.GetUnspentOutput(input.PrevTxnID, input.OutIdx)
+		if err != nil {
+			return false, &TxnVerificationError{TxnID: txnId, InputIndex: inIdx, Reason: InvalidTxnDoubleSpend, Message: fmt.Sprintf("output %s was already spent", outpoint)}
+		}
+		if !ts.isMature(unspent, nextHeight) {
+			return false, &TxnVerificationError{TxnID: txnId, InputIndex: inIdx, Reason: InvalidTxnImmatureCoinbase, Message: fmt.Sprintf("coinbase output %s needs %d confirmations before it can be spent", outpoint, ts.params.CoinbaseMaturity)}
+		}
+		if spending[outpoint] {
+			return false, &TxnVerificationError{TxnID: txnId, InputIndex: inIdx, Reason: InvalidTxnDoubleSpend, Message: fmt.Sprintf("output %s is spent twice", outpoint)}
+		}
+		spending[outpoint] = true
+
 		prevTxns[hex.EncodeToString(prevTxn.ID)] = prevTxn
+		prevOutput := prevTxn.Outputs[input.OutIdx]
+		if prevOutput.AssetID == "" {
+			inputTotal, err = addAmount(inputTotal, prevOutput.Value)
+		} else {
+			assetsIn[prevOutput.AssetID], err = addAmount(assetsIn[prevOutput.AssetID], prevOutput.Value)
+		}
+		if err != nil {
+			return false, &TxnVerificationError{TxnID: txnId, InputIndex: inIdx, Reason: InvalidTxnValue, Message: fmt.Sprintf("inputs %s", err.Error())}
+		}
+	}
+
+	outputTotal := 0
+	for _, output := range txn.Outputs {
+		if output.Value <= 0 {
+			return false, &TxnVerificationError{TxnID: txnId, InputIndex: -1, Reason: InvalidTxnValue, Message: fmt.Sprintf("output value %d must be positive", output.Value)}
+		}
+		if output.Value > MaxMoney {
+			return false, &TxnVerificationError{TxnID: txnId, InputIndex: -1, Reason: InvalidTxnValue, Message: fmt.Sprintf("output value %d is more than the most of %d", output.Value, MaxMoney)}
+		}
+		if output.Staked && (output.AssetID != "" || !IsProofOfStake(ts.params)) {
+			return false, &TxnVerificationError{TxnID: txnId, InputIndex: -1, Reason: InvalidTxnStake, Message: "only the chain's own coin can be staked, and only on a proof of stake chain"}
+		}
+		if output.AssetID != "" {
+			if assetsOut[output.AssetID], err = addAmount(assetsOut[output.AssetID], output.Value); err != nil {
+				return false, &TxnVerificationError{TxnID: txnId, InputIndex: -1, Reason: InvalidTxnValue, Message: fmt.Sprintf("outputs %s", err.Error())}
+			}
+			continue
+		}
+		if output.Value < ts.params.DustThreshold {
+			return false, &TxnVerificationError{TxnID: txnId, InputIndex: -1, Reason: InvalidTxnDust, Message: fmt.Sprintf("output value %d is below the dust threshold of %d", output.Value, ts.params.DustThreshold)}
+		}
+		if outputTotal, err = addAmount(outputTotal, output.Value); err != nil {
+			return false, &TxnVerificationError{TxnID: txnId, InputIndex: -1, Reason: InvalidTxnValue, Message: fmt.Sprintf("outputs %s", err.Error())}
+		}
+	}
+	if outputTotal > inputTotal {
+		return false, &TxnVerificationError{TxnID: txnId, InputIndex: -1, Reason: InvalidTxnValue, Message: fmt.Sprintf("outputs total %d but inputs only hold %d", outputTotal, inputTotal)}
+	}
+	if txn.Fee != inputTotal-outputTotal {
+		return false, &TxnVerificationError{TxnID: txnId, InputIndex: -1, Reason: InvalidTxnValue, Message: fmt.Sprintf("fee is %d but inputs hold %d more than outputs", txn.Fee, inputTotal-outputTotal)}
+	}
+
+	// Signatures first, they say more about what's wrong with a tampered input
+	if valid, err := ts.VerifySignature(txn, prevTxns); !valid {
+		return valid, err
+	}
+	if !ts.hasValidID(txn) {
+		return false, &TxnVerificationError{TxnID: txnId, InputIndex: -1, Reason: InvalidTxnID, Message: "id is not the hash of the transaction"}
+	}
+
+	// Now the inputs are known to be signed by their owners, asset units created out of nothing have to be issued by the asset's issuer
+	for assetId, in := range assetsIn {
+		if assetsOut[assetId] < in {
+			return false, &TxnVerificationError{TxnID: txnId, InputIndex: -1, Reason: InvalidTxnAsset, Message: fmt.Sprintf("spends %d units of asset %s but only sends on %d", in, assetId, assetsOut[assetId])}
+		}
+	}
+	for assetId, out := range assetsOut {
+		if issued := out - assetsIn[assetId]; issued > 0 {
+			if err := ts.verifyIssuer(txn, prevTxns, assetId); err != nil {
+				return false, &TxnVerificationError{TxnID: txnId, InputIndex: -1, Reason: InvalidTxnAsset, Message: err.Error()}
+			}
+			if err := ts.VerifyIssuance(assetId, issued); err != nil {
+				return false, &TxnVerificationError{TxnID: txnId, InputIndex: -1, Reason: InvalidTxnAsset, Message: err.Error()}
+			}
+		}
+	}
+
+	return true, nil
+}
+
+// total plus amount, so long as amount isn't negative and neither it nor the sum comes to more than MaxMoney
+func addAmount(total, amount int) (int, error) {
+	if amount < 0 || amount > MaxMoney || total+amount > MaxMoney {
+		return total, fmt.Errorf("total more than the most of %d", MaxMoney)
+	}
+	return total + amount, nil
+}
+
+// Whether txn's id is the hash of its contents. Transactions created before ids were deterministic were
+// hashed with their input and output ids, before they were signed or put in a block
+func (ts *transactionService) hasValidID(txn reps.Transaction) bool {
+	if bytes.Equal(txn.ID, ts.txnAssembler.TxnID(txn)) {
+		return true
+	}
+
+	legacy := txn
+	legacy.BlockID = ""
+	legacy.Inputs = make([]reps.TxnInput, len(txn.Inputs))
+	for i, input := range txn.Inputs {
+		input.CurrTxnID = nil
+		input.Signature = nil
+		legacy.Inputs[i] = input
+	}
+	legacy.Outputs = make([]reps.TxnOutput, len(txn.Outputs))
+	for i, output := range txn.Outputs {
+		output.CurrTxnID = nil
+		legacy.Outputs[i] = output
+	}
+
+	return bytes.Equal(txn.ID, ts.txnAssembler.HashTransaction(legacy))
+}
+
+// Sign every input of txn with the wallet's key, through the configured signer
+func (ts *transactionService) Sign(wallet reps.Wallet, txn reps.Transaction, prevTxns map[string]reps.Transaction) (reps.Transaction, error) {
+	owners := make([]reps.Wallet, len(txn.Inputs))
+	for i := range owners {
+		owners[i] = wallet
 	}
 
-	return ts.VerifySignature(txn, prevTxns)
+	return ts.signInputs(txn, prevTxns, owners)
 }
 
-func (ts *transactionService) Sign(privKey ecdsa.PrivateKey, txn reps.Transaction, prevTxns map[string]reps.Transaction) (reps.Transaction, error) {
+// Sign each input of txn with the key of the wallet at the same index of owners
+func (ts *transactionService) signInputs(txn reps.Transaction, prevTxns map[string]reps.Transaction, owners []reps.Wallet) (reps.Transaction, error) {
 	log.Info("Attempting to sign: ", hex.EncodeToString(txn.ID))
 	if ts.IsCoinbaseTransaction(txn) {
-		return reps.Transaction{}, nil
+		return txn, nil
 	}
 
-	for _, in := range txn.Inputs {
-		if prevTxns[hex.EncodeToString(in.PrevTxnID)].ID == nil {
-			log.WithField("input currTxnID", in.PrevTxnID).Error("error: previous transaction does not exist")
-			// return reps.Transaction{}, fmt.Errorf("error: previous transaction does not exist with id: %s", in.PrevTxnID)
-		}
+	if len(owners) != len(txn.Inputs) {
+		return reps.Transaction{}, fmt.Errorf("transaction %x has %d inputs but %d signers", txn.ID, len(txn.Inputs), len(owners))
 	}
 
-	// trimmed txn copy is signed, not a full one
-	txnCopy := ts.CreateTrimmedTxnCopy(txn)
+	signerPubKeys := make([][]byte, len(owners))
+	for i, wallet := range owners {
+		scheme, err := GetSignatureScheme(wallet.SigAlgorithm)
+		if err != nil {
+			return reps.Transaction{}, err
+		}
+
+		if txn.SigAlgorithm != scheme.Algorithm() {
+			return reps.Transaction{}, fmt.Errorf("transaction %x is for %s signatures, not %s", txn.ID, txn.SigAlgorithm, scheme.Algorithm())
+		}
 
-	for inIdx, input := range txnCopy.Inputs {
-		prevTxn := prevTxns[hex.EncodeToString(input.PrevTxnID)]
-		txnCopy.Inputs[inIdx].Signature = nil
+		signerPubKeys[i], err = hex.DecodeString(wallet.PublicKey)
+		if err != nil {
+			return reps.Transaction{}, fmt.Errorf("%s, unable to read public key of %s", err.Error(), wallet.Address)
+		}
+	}
 
-		// This txns input's pubKey is set to the outputs pubKeyHash from previous transaction
-		txnCopy.Inputs[inIdx].PubKey = prevTxn.Outputs[input.OutIdx].PubKeyHash
+	// Every input must point at an existing output owned by its signer, or there's nothing to prove ownership of
+	for inIdx, in := range txn.Inputs {
+		prevTxn := prevTxns[hex.EncodeToString(in.PrevTxnID)]
+		if prevTxn.ID == nil {
+			log.WithField("input prevTxnID", hex.EncodeToString(in.PrevTxnID)).Error("error: previous transaction does not exist")
+			return reps.Transaction{}, fmt.Errorf("previous transaction does not exist with id: %x", in.PrevTxnID)
+		}
+		if in.OutIdx < 0 || in.OutIdx >= len(prevTxn.Outputs) {
+			return reps.Transaction{}, fmt.Errorf("previous transaction %x has no output at index %d", in.PrevTxnID, in.OutIdx)
+		}
+		if !ts.UsesKey(reps.TxnInput{PubKey: signerPubKeys[inIdx]}, prevTxn.Outputs[in.OutIdx].PubKeyHash) {
+			return reps.Transaction{}, fmt.Errorf("output %d of transaction %x is not locked with the signing key", in.OutIdx, in.PrevTxnID)
+		}
+	}
 
+	// trimmed txn copy is signed, not a full one
+	for inIdx := range txn.Inputs {
 		// Sign the Public key hashes stored in unlocked outputs. This identifies “sender” of a transaction.
-		txnCopy.ID = ts.txnAssembler.HashTransaction(txnCopy)
-		txnCopy.Inputs[inIdx].PubKey = nil // don't affect further iterations
+		signingHash := ts.SigningHash(txn, inIdx, prevTxns)
 
-		// sign txnCopy.Id with privKey
-		r, s, err := ecdsa.Sign(rand.Reader, &privKey, txnCopy.ID)
+		// sign signingHash with the owning wallet's private key, wherever it's kept
+		signature, err := ts.signer.Sign(owners[inIdx], signingHash)
 		if err != nil {
 			log.Error("error signing transaction: ", err.Error())
+			return reps.Transaction{}, err
 		}
 
-		signature := append(r.Bytes(), s.Bytes()...)
+		// Signature goes with the public key that unlocks the referenced output
 		txn.Inputs[inIdx].Signature = signature
+		txn.Inputs[inIdx].PubKey = signerPubKeys[inIdx]
 	}
 
 	return txn, nil
-	// Update db with signature
+}
+
+// Check a P-256 signature of hash. Signature and pubKey are both a pair of numbers, split in half
+func verifyECDSASignature(pubKey []byte, signature []byte, hash []byte) bool {
+	// Unpack signature, signature is a pair of numbers
+	r := big.Int{}
+	s := big.Int{}
+	sigLen := len(signature)
+	r.SetBytes(signature[:(sigLen / 2)])
+	s.SetBytes(signature[(sigLen / 2):])
+
+	// Unpack pubKey, pubKey is a pair of points
+	x := big.Int{}
+	y := big.Int{}
+	pubKeyLen := len(pubKey)
+	x.SetBytes(pubKey[:(pubKeyLen / 2)])
+	y.SetBytes(pubKey[(pubKeyLen / 2):])
+
+	rawPubKey := ecdsa.PublicKey{Curve: elliptic.P256(), X: &x, Y: &y}
+
+	return ecdsa.Verify(&rawPubKey, hash, &r, &s)
+}
+
+// Signature is r and s, each padded to the curve size so it can always be split in half again
+func encodeSignature(curve elliptic.Curve, r *big.Int, s *big.Int) []byte {
+	coordLen := (curve.Params().BitSize + 7) / 8
+
+	signature := make([]byte, 2*coordLen)
+	r.FillBytes(signature[:coordLen])
+	s.FillBytes(signature[coordLen:])
+
+	return signature
 }
 
 func (ts *transactionService) VerifySignature(currTxn reps.Transaction, prevTxns map[string]reps.Transaction) (bool, error) {
 	log.Info("Attempting to verify signature of transaction: "+hex.EncodeToString(currTxn.ID)+" with inputs: ", utils.Pretty(currTxn.Inputs))
-	txnCopy := ts.CreateTrimmedTxnCopy(currTxn)
-	curve := elliptic.P256()
+
+	// The transaction says which scheme its inputs are signed with
+	scheme, err := GetSignatureScheme(currTxn.SigAlgorithm)
+	if err != nil {
+		return false, &TxnVerificationError{TxnID: hex.EncodeToString(currTxn.ID), InputIndex: 0, Reason: InvalidTxnUnknownAlgorithm, Message: err.Error()}
+	}
 
 	for inIdx, in := range currTxn.Inputs {
+		invalid := func(reason string, message string) (bool, error) {
+			return false, &TxnVerificationError{TxnID: hex.EncodeToString(currTxn.ID), InputIndex: inIdx, Reason: reason, Message: message}
+		}
 
 		if in.Signature == nil {
 			log.Error("Signature cannot be null, cannot verify.")
-			return false, fmt.Errorf("cannot verify a null signature. Invalid transaction")
+			return invalid(InvalidTxnMissingSignature, "cannot verify a null signature")
 		}
 
-		// need same data that was signed
 		prevTxn := prevTxns[hex.EncodeToString(in.PrevTxnID)]
-		txnCopy.Inputs[inIdx].Signature = nil
-		txnCopy.Inputs[inIdx].PubKey = prevTxn.Outputs[in.OutIdx].PubKeyHash
-		txnCopy.ID = ts.txnAssembler.HashTransaction(txnCopy)
-		txnCopy.Inputs[inIdx].PubKey = nil
+		if prevTxn.ID == nil || in.OutIdx < 0 || in.OutIdx >= len(prevTxn.Outputs) {
+			return invalid(InvalidTxnUnknownOutput, fmt.Sprintf("output %d of transaction %x does not exist", in.OutIdx, in.PrevTxnID))
+		}
 
-		// Unpack signature, signature is a pair of numbers
-		r := big.Int{}
-		s := big.Int{}
-		sigLen := len(in.Signature)
-		r.SetBytes(in.Signature[:(sigLen / 2)])
-		s.SetBytes(in.Signature[(sigLen / 2):])
+		// The public key has to be the one the referenced output was locked with
+		if !ts.UsesKey(in, prevTxn.Outputs[in.OutIdx].PubKeyHash) {
+			return invalid(InvalidTxnPubKeyMismatch, fmt.Sprintf("public key does not match the pubKeyHash locking output %d of transaction %x", in.OutIdx, in.PrevTxnID))
+		}
 
-		// Unpack pubKey, pubKey is a pair of points
-		x := big.Int{}
-		y := big.Int{}
-		pubKeyLen := len(in.PubKey)
-		x.SetBytes(in.PubKey[:(pubKeyLen / 2)])
-		y.SetBytes(in.PubKey[(pubKeyLen / 2):])
+		// need same data that was signed
+		signingHash := ts.SigningHash(currTxn, inIdx, prevTxns)
 
-		rawPubKey := ecdsa.PublicKey{curve, &x, &y}
+		// Outputs sent to a multisig address are unlocked by the redeem script and enough of its keys' signatures.
+		// Multisig keys are always ECDSA
+		if script, ok := parseRedeemScript(in.PubKey); ok && scheme.Algorithm() == SigAlgorithmECDSA {
+			if validSigs := script.countValidSignatures(in.Signature, signingHash); validSigs < script.requiredSigs {
+				return invalid(InvalidTxnBadSignature, fmt.Sprintf("only %d of the %d required multisig signatures are valid", validSigs, script.requiredSigs))
+			}
+			continue
+		}
 
-		// verifies the signature in r, s of hash (txnCopy.ID) using the public key.
-		if !ecdsa.Verify(&rawPubKey, txnCopy.ID, &r, &s) {
-			return false, fmt.Errorf("Signature: %x could not be verified", in.Signature)
+		// verifies the signature of signingHash using the public key.
+		if !scheme.Verify(in.PubKey, in.Signature, signingHash) {
+			return invalid(InvalidTxnBadSignature, fmt.Sprintf("signature %x could not be verified", in.Signature))
 		}
 	}
 
@@ -535,17 +1603,34 @@ func (ts *transactionService) CreateTrimmedTxnCopy(txn reps.Transaction) reps.Tr
 	var outputs []reps.TxnOutput
 
 	for _, in := range txn.Inputs {
-		inputs = append(inputs, reps.TxnInput{in.InputID, in.CurrTxnID, in.PrevTxnID, in.OutIdx, nil, nil})
+		inputs = append(inputs, reps.TxnInput{
+			InputID:   in.InputID,
+			CurrTxnID: in.CurrTxnID,
+			PrevTxnID: in.PrevTxnID,
+			OutIdx:    in.OutIdx,
+		})
 	}
 
 	for _, out := range txn.Outputs {
-		outputs = append(outputs, reps.TxnOutput{out.OutputID, out.CurrTxnID, out.Value, out.PubKeyHash})
+		outputs = append(outputs, reps.TxnOutput{
+			OutputID:   out.OutputID,
+			CurrTxnID:  out.CurrTxnID,
+			Value:      out.Value,
+			PubKeyHash: out.PubKeyHash,
+			AssetID:    out.AssetID,
+			Staked:     out.Staked,
+		})
 	}
 
 	txnCopy := reps.Transaction{
-		ID:      txn.ID,
-		Inputs:  inputs,
-		Outputs: outputs,
+		ID:           txn.ID,
+		SigAlgorithm: txn.SigAlgorithm,
+		Fee:          txn.Fee,
+		Memo:         txn.Memo,
+		LockTime:     txn.LockTime,
+		Replaceable:  txn.Replaceable,
+		Inputs:       inputs,
+		Outputs:      outputs,
 	}
 
 	return txnCopy
@@ -571,7 +1656,7 @@ func (ts *transactionService) Lock(output *reps.TxnOutput, address string) {
 
 	output.PubKeyHash = pubKeyHash[1 : len(pubKeyHash)-4] // remove version and checksum
 
-	log.Infof("Locking output with address: %s with PubKeyHash of: ", address, hex.EncodeToString(output.PubKeyHash))
+	log.Infof("Locking output with address: %s with PubKeyHash of: %s", address, hex.EncodeToString(output.PubKeyHash))
 }
 
 // checks if provided public key hash was used to lock the output
@@ -583,6 +1668,17 @@ func (ts *transactionService) IsCoinbaseTransaction(txn reps.Transaction) bool {
 	return len(txn.Inputs) == 1 && len(txn.Inputs[0].PrevTxnID) == 0 && txn.Inputs[0].OutIdx == -1
 }
 
+// Whether txn's lock time allows it onto a block at height, mined at blockTime in unix milliseconds
+func IsFinalTransaction(txn reps.Transaction, height int, blockTime int64) bool {
+	if txn.LockTime == 0 {
+		return true
+	}
+	if txn.LockTime < LockTimeThreshold {
+		return int64(height) >= txn.LockTime
+	}
+	return blockTime/1000 >= txn.LockTime
+}
+
 func createPubKeyHash(pubKey []byte) ([]byte, error) {
 	pubHash := sha256.Sum256(pubKey)
 
@@ -0,0 +1,781 @@
+package services_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/brucetieu/blockchain/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// Give repo a single genesis block paying the coinbase reward to address
+func fundAddress(repo *fakeBlockchainRepository, txnService services.TransactionService, address string) {
+	coinbase := txnService.CreateCoinbaseTxn(address, "")
+	repo.blocks = []reps.Block{{ID: "genesis", Hash: []byte("genesis"), Transactions: []reps.Transaction{coinbase}}}
+}
+
+func TestCreateTransactionSignsInputsWithSendersKey(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	txn, err := txnService.CreateTransaction(from.Address, to.Address, 10)
+	assert.NoError(t, err)
+
+	for _, input := range txn.Inputs {
+		assert.Len(t, input.Signature, 64)
+		assert.Equal(t, from.PublicKey, hex.EncodeToString(input.PubKey))
+	}
+
+	valid, err := txnService.VerifyTransaction(txn)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	// Changing an output after signing invalidates the signature
+	txn.Outputs[0].Value = 20
+	valid, _ = txnService.VerifyTransaction(txn)
+	assert.False(t, valid)
+}
+
+func TestVerifyTransactionRejectsKeyThatDoesNotOwnOutput(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	thief, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	txn, err := txnService.CreateTransaction(from.Address, to.Address, 10)
+	assert.NoError(t, err)
+
+	// Claim the input with a key that didn't lock the output
+	txn.Inputs[0].PubKey, err = hex.DecodeString(thief.PublicKey)
+	assert.NoError(t, err)
+
+	valid, err := txnService.VerifyTransaction(txn)
+	assert.False(t, valid)
+
+	var verificationErr *services.TxnVerificationError
+	assert.True(t, errors.As(err, &verificationErr))
+	assert.Equal(t, services.InvalidTxnPubKeyMismatch, verificationErr.Reason)
+	assert.Equal(t, 0, verificationErr.InputIndex)
+}
+
+func TestCreateTransactionSignsWithWalletsAlgorithm(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+
+	from, err := walletService.CreateWalletWithAlgorithm(services.SigAlgorithmEd25519)
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	txn, err := txnService.CreateTransaction(from.Address, to.Address, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, services.SigAlgorithmEd25519, txn.SigAlgorithm)
+
+	valid, err := txnService.VerifyTransaction(txn)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	// Verifying as another scheme fails, since the algorithm is part of what's signed
+	txn.SigAlgorithm = services.SigAlgorithmECDSA
+	valid, _ = txnService.VerifyTransaction(txn)
+	assert.False(t, valid)
+}
+
+func TestEd25519SignaturesWaitForTheirActivationHeight(t *testing.T) {
+	params := mainnet
+	params.Activations = services.FormatActivations(map[string]int{services.RuleEd25519: 2})
+
+	ts := newTestServicesWithParams(t, &params)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+
+	from, err := walletService.CreateWalletWithAlgorithm(services.SigAlgorithmEd25519)
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	txn, err := txnService.CreateTransaction(from.Address, to.Address, 10)
+	assert.NoError(t, err)
+
+	// The next block is at height 1, before the rule is on
+	valid, err := txnService.VerifyTransaction(txn)
+	assert.False(t, valid)
+	var verificationErr *services.TxnVerificationError
+	assert.True(t, errors.As(err, &verificationErr))
+	assert.Equal(t, services.InvalidTxnInactiveRule, verificationErr.Reason)
+	assert.False(t, services.RuleActive(&params, services.RuleEd25519, 1))
+
+	repo.blocks = append(repo.blocks, reps.Block{ID: "second", Hash: []byte("second"), PrevHash: []byte("genesis"), Height: 1})
+	valid, err = txnService.VerifyTransaction(txn)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	// Unknown rules can't be scheduled, and rules that aren't are on from the genesis block
+	_, err = services.ParseActivations("schnorr:10")
+	assert.Error(t, err)
+	assert.True(t, services.RuleActive(&mainnet, services.RuleEd25519, 0))
+}
+
+func TestTransactionSignedForOneChainIsRejectedOnAnother(t *testing.T) {
+	testnet := mainnet
+	testnet.ReplayProtection = true
+	testnet.ChainID = "testnet"
+	devnet := testnet
+	devnet.ChainID = "devnet"
+	assert.NotEqual(t, services.ChainIdentifier(&testnet), services.ChainIdentifier(&devnet))
+
+	// Both chains have the same coins at the same addresses, e.g. one was forked off the other
+	repo := newFakeBlockchainRepository()
+	keystore := newUnlockedKeystore(t)
+	walletService := services.NewWalletService(repo, keystore, &testnet)
+	testnetTxns := services.NewTransactionService(repo, walletService, nil, services.NewLocalSigner(keystore), &testnet)
+	devnetTxns := services.NewTransactionService(repo, walletService, nil, services.NewLocalSigner(keystore), &devnet)
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, testnetTxns, from.Address)
+
+	txn, err := testnetTxns.CreateTransaction(from.Address, to.Address, 10)
+	assert.NoError(t, err)
+
+	valid, err := testnetTxns.VerifyTransaction(txn)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, err = devnetTxns.VerifyTransaction(txn)
+	assert.False(t, valid)
+	var verificationErr *services.TxnVerificationError
+	assert.True(t, errors.As(err, &verificationErr))
+	assert.Equal(t, services.InvalidTxnBadSignature, verificationErr.Reason)
+
+	// Chains created before replay protection keep signing as they always did
+	assert.Equal(t, "", services.ChainIdentifier(&mainnet))
+}
+
+func TestWatchOnlyAddressTracksBalanceButCannotSend(t *testing.T) {
+	// The address's key lives on another node, e.g. cold storage
+	coldWallets := newTestServices(t).walletService
+	cold, err := coldWallets.CreateWallet()
+	assert.NoError(t, err)
+
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+
+	watched, err := walletService.WatchAddress(cold.Address)
+	assert.NoError(t, err)
+	assert.True(t, watched.WatchOnly)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, cold.Address)
+
+	balance, err := txnService.GetBalance(cold.Address)
+	assert.NoError(t, err)
+	assert.Equal(t, services.Reward, balance)
+
+	history, err := txnService.GetAddressHistory(cold.Address)
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+
+	_, err = txnService.CreateTransaction(cold.Address, to.Address, 10)
+	assert.True(t, errors.Is(err, services.ErrWatchOnly))
+}
+
+func TestCreateTransactionSendsHDWalletChangeToFreshAddress(t *testing.T) {
+	repo := newFakeBlockchainRepository()
+	keystore := newUnlockedKeystore(t)
+	walletService := services.NewWalletService(repo, keystore, &mainnet)
+	hdWalletService := services.NewHDWalletService(repo, walletService, keystore)
+	txnService := services.NewTransactionService(repo, walletService, hdWalletService, services.NewLocalSigner(keystore), &mainnet)
+
+	_, _, from, err := hdWalletService.CreateHDWallet("")
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	txn, err := txnService.CreateTransaction(from.Address, to.Address, 10)
+	assert.NoError(t, err)
+	assert.Len(t, txn.Outputs, 2)
+
+	hdWallet, wallets, err := hdWalletService.GetHDWallet(from.HDWalletID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, hdWallet.NextChangeIndex)
+
+	var change reps.Wallet
+	for _, wallet := range wallets {
+		if wallet.DerivationPath == "m/44'/0'/0'/1/0" {
+			change = wallet
+		}
+	}
+	assert.NotEmpty(t, change.Address)
+	assert.NotEqual(t, from.Address, change.Address)
+
+	changePubKey, err := hex.DecodeString(change.PublicKey)
+	assert.NoError(t, err)
+	changePubKeyHash, err := walletService.CreatePubKeyHash(changePubKey)
+	assert.NoError(t, err)
+	assert.Equal(t, services.Reward-10, txn.Outputs[1].Value)
+	assert.Equal(t, changePubKeyHash, txn.Outputs[1].PubKeyHash)
+}
+
+func TestCreateTransactionFailsOnceUnlockExpires(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	walletService.Lock()
+	_, err = txnService.CreateTransaction(from.Address, to.Address, 10)
+	assert.True(t, errors.Is(err, services.ErrWalletLocked))
+
+	_, err = walletService.Unlock("wrong passphrase", time.Minute)
+	assert.Error(t, err)
+	assert.True(t, walletService.IsLocked())
+
+	_, err = walletService.Unlock("passphrase", 200*time.Millisecond)
+	assert.NoError(t, err)
+	_, err = txnService.CreateTransaction(from.Address, to.Address, 10)
+	assert.NoError(t, err)
+
+	assert.Eventually(t, walletService.IsLocked, 2*time.Second, 10*time.Millisecond)
+	_, err = txnService.CreateTransaction(from.Address, to.Address, 10)
+	assert.True(t, errors.Is(err, services.ErrWalletLocked))
+}
+
+func TestVerifyTransactionRejectsDoubleSpend(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	// Both spend the coinbase output
+	first, err := txnService.CreateTransaction(from.Address, to.Address, 10)
+	assert.NoError(t, err)
+	second, err := txnService.CreateTransaction(from.Address, to.Address, 20)
+	assert.NoError(t, err)
+
+	repo.blocks = append(repo.blocks, reps.Block{ID: "next", PrevHash: []byte("genesis"), Timestamp: 1, Transactions: []reps.Transaction{first}})
+
+	_, err = txnService.VerifyTransaction(second)
+	var verificationErr *services.TxnVerificationError
+	assert.True(t, errors.As(err, &verificationErr))
+	assert.Equal(t, services.InvalidTxnDoubleSpend, verificationErr.Reason)
+
+	// Only the change from the first transaction is left
+	balance, err := txnService.GetBalance(from.Address)
+	assert.NoError(t, err)
+	assert.Equal(t, services.Reward-10, balance)
+}
+
+func TestVerifyTransactionRejectsOutputsWorthMoreThanInputs(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	pubKey, _ := hex.DecodeString(from.PublicKey)
+	txn, err := txnService.CreateUnsignedTransaction(from.Address, pubKey, services.SigAlgorithmECDSA, to.Address, 10, from.Address)
+	assert.NoError(t, err)
+	txn.Outputs[0].Value = services.Reward + 1
+
+	_, err = txnService.VerifyTransaction(txn)
+	var verificationErr *services.TxnVerificationError
+	assert.True(t, errors.As(err, &verificationErr))
+	assert.Equal(t, services.InvalidTxnValue, verificationErr.Reason)
+}
+
+func TestVerifyTransactionRejectsOutputsThatWrapPastMaxInt(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	pubKey, _ := hex.DecodeString(from.PublicKey)
+	txn, err := txnService.CreateUnsignedTransaction(from.Address, pubKey, services.SigAlgorithmECDSA, to.Address, services.Reward, from.Address)
+	assert.NoError(t, err)
+
+	// Four of them add up to 0 in an int, leaving the whole input as fee
+	output := txn.Outputs[0]
+	output.Value = 1 << 62
+	txn.Outputs = []reps.TxnOutput{output, output, output, output}
+	txn.Fee = services.Reward
+
+	_, err = txnService.VerifyTransaction(txn)
+	var verificationErr *services.TxnVerificationError
+	assert.True(t, errors.As(err, &verificationErr))
+	assert.Equal(t, services.InvalidTxnValue, verificationErr.Reason)
+
+	// Each within MaxMoney, but not once they're added up
+	output.Value = services.MaxMoney
+	txn.Outputs = []reps.TxnOutput{output, output}
+
+	_, err = txnService.VerifyTransaction(txn)
+	assert.True(t, errors.As(err, &verificationErr))
+	assert.Equal(t, services.InvalidTxnValue, verificationErr.Reason)
+}
+
+func TestReindexUnspentOutputsSkipsSpentOutputs(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	txn, err := txnService.CreateTransaction(from.Address, to.Address, 10)
+	assert.NoError(t, err)
+	repo.blocks = append(repo.blocks, reps.Block{ID: "next", PrevHash: []byte("genesis"), Timestamp: 1, Transactions: []reps.Transaction{txn}})
+
+	count, err := txnService.ReindexUnspentOutputs()
+	assert.NoError(t, err)
+
+	// The coinbase output is spent, leaving the payment and its change
+	assert.Equal(t, 2, count)
+	for _, unspentOutput := range repo.reindexed {
+		assert.Equal(t, txn.ID, unspentOutput.TxnID)
+		assert.Equal(t, "next", unspentOutput.BlockID)
+	}
+
+	// The address index is rebuilt along with it: the coinbase, then the payment for each of the sender and recipient
+	assert.Len(t, repo.addressIndex, 3)
+	for _, entry := range repo.addressIndex[1:] {
+		assert.Equal(t, txn.ID, entry.TxnID)
+		assert.Equal(t, 1, entry.Height)
+	}
+	assert.Equal(t, reps.DirectionSent, repo.addressIndex[1].Direction)
+	assert.Equal(t, -10, repo.addressIndex[1].Amount)
+	assert.Equal(t, reps.DirectionReceived, repo.addressIndex[2].Direction)
+	assert.Equal(t, 10, repo.addressIndex[2].Amount)
+
+	// As is the transaction index
+	assert.Len(t, repo.txnLocations, 2)
+	assert.Equal(t, hex.EncodeToString(txn.ID), repo.txnLocations[1].TxnID)
+	assert.Equal(t, "next", repo.txnLocations[1].BlockID)
+	assert.Equal(t, 1, repo.txnLocations[1].Height)
+}
+
+func TestGetTransactionLocationGivesBlockHashAndHeight(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	txn, err := txnService.CreateTransaction(from.Address, to.Address, 10)
+	assert.NoError(t, err)
+	repo.blocks = append(repo.blocks, reps.Block{ID: "next", Hash: []byte("next"), PrevHash: []byte("genesis"), Timestamp: 1, Transactions: []reps.Transaction{txn}})
+
+	location, err := txnService.GetTransactionLocation(hex.EncodeToString(txn.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("next"), location.BlockHash)
+	assert.Equal(t, 1, location.Height)
+	assert.Equal(t, 0, location.Position)
+
+	_, err = txnService.GetTransactionLocation(hex.EncodeToString([]byte("unknown")))
+	assert.Error(t, err)
+	_, err = txnService.GetTransactionLocation("not hex")
+	assert.Error(t, err)
+}
+
+func TestGetAddressBalanceOfAddressWithoutWallet(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+
+	// Paper wallets aren't stored on the node
+	paperWallet, err := walletService.CreatePaperWallet(services.SigAlgorithmECDSA, false)
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, paperWallet.Address)
+
+	balance, err := txnService.GetAddressBalance(paperWallet.Address)
+	assert.NoError(t, err)
+	assert.Equal(t, services.Reward, balance.Confirmed)
+	assert.Equal(t, 0, balance.Pending)
+}
+
+func TestGetAddressHistoryGivesDirectionAndHeight(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	txn, err := txnService.CreateTransaction(from.Address, to.Address, 10)
+	assert.NoError(t, err)
+	repo.blocks = append(repo.blocks, reps.Block{ID: "next", Hash: []byte("next"), PrevHash: []byte("genesis"), Timestamp: 1, Transactions: []reps.Transaction{txn}})
+
+	history, err := txnService.GetAddressHistory(from.Address)
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	assert.Equal(t, reps.DirectionReceived, history[0].Direction)
+	assert.Equal(t, 0, history[0].Height)
+	assert.Equal(t, services.Reward, history[0].Amount)
+	assert.Equal(t, reps.DirectionSent, history[1].Direction)
+	assert.Equal(t, 1, history[1].Height)
+	assert.Equal(t, []byte("next"), history[1].BlockHash)
+	assert.Equal(t, -10, history[1].Amount)
+
+	history, err = txnService.GetAddressHistory(to.Address)
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+	assert.Equal(t, reps.DirectionReceived, history[0].Direction)
+	assert.Equal(t, 10, history[0].Amount)
+}
+
+func TestCreateTransactionToRecipientsPaysEachInOneTransaction(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	first, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	second, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	txn, err := txnService.CreateTransactionToRecipients(from.Address, []reps.Recipient{
+		{To: first.Address, Amount: 10},
+		{To: second.Address, Amount: 15},
+	}, reps.TxnOptions{})
+	assert.NoError(t, err)
+
+	// One output per recipient, then the change
+	assert.Len(t, txn.Outputs, 3)
+	assert.Equal(t, 10, txn.Outputs[0].Value)
+	assert.Equal(t, 15, txn.Outputs[1].Value)
+	assert.Equal(t, services.Reward-25, txn.Outputs[2].Value)
+
+	valid, err := txnService.VerifyTransaction(txn)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	_, err = txnService.CreateTransactionToRecipients(from.Address, []reps.Recipient{
+		{To: first.Address, Amount: 30},
+		{To: second.Address, Amount: 30},
+	}, reps.TxnOptions{})
+	assert.Error(t, err)
+
+	// Amounts that would add up past the int range, to something the inputs could cover
+	_, err = txnService.CreateTransactionToRecipients(from.Address, []reps.Recipient{
+		{To: first.Address, Amount: 1 << 62},
+		{To: second.Address, Amount: 1 << 62},
+	}, reps.TxnOptions{})
+	assert.Contains(t, err.Error(), "more than the most")
+
+	_, err = txnService.CreateTransactionToRecipients(from.Address, []reps.Recipient{
+		{To: first.Address, Amount: services.MaxMoney},
+		{To: second.Address, Amount: 1},
+	}, reps.TxnOptions{})
+	assert.Contains(t, err.Error(), "more than the most")
+}
+
+func TestCreateTransactionPaysFeeOutOfChange(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+	recipients := []reps.Recipient{{To: to.Address, Amount: 10}}
+
+	txn, err := txnService.CreateTransactionToRecipients(from.Address, recipients, reps.TxnOptions{Fee: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, txn.Fee)
+	assert.Equal(t, services.Reward-10-3, txn.Outputs[1].Value)
+
+	valid, err := txnService.VerifyTransaction(txn)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	// A fee that isn't what the inputs leave over is rejected
+	txn.Fee = 4
+	_, err = txnService.VerifyTransaction(txn)
+	var verificationErr *services.TxnVerificationError
+	assert.True(t, errors.As(err, &verificationErr))
+	assert.Equal(t, services.InvalidTxnValue, verificationErr.Reason)
+
+	// Paid by rate, the fee grows with the size of the transaction
+	txn, err = txnService.CreateTransactionToRecipients(from.Address, recipients, reps.TxnOptions{FeeRate: 1})
+	assert.NoError(t, err)
+	assert.Greater(t, txn.Fee, 0)
+	assert.Equal(t, services.Reward-10-txn.Fee, txn.Outputs[1].Value)
+
+	_, err = txnService.CreateTransactionToRecipients(from.Address, recipients, reps.TxnOptions{Fee: services.Reward})
+	assert.Error(t, err)
+
+	_, err = txnService.CreateTransactionToRecipients(from.Address, recipients, reps.TxnOptions{Fee: services.MaxMoney})
+	assert.Contains(t, err.Error(), "more than the most")
+}
+
+func TestCreateTransactionSignsMemo(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+	recipients := []reps.Recipient{{To: to.Address, Amount: 10}}
+
+	txn, err := txnService.CreateTransactionToRecipients(from.Address, recipients, reps.TxnOptions{Memo: "invoice 42"})
+	assert.NoError(t, err)
+	assert.Equal(t, "invoice 42", txn.Memo)
+
+	plain, err := txnService.CreateTransactionToRecipients(from.Address, recipients, reps.TxnOptions{})
+	assert.NoError(t, err)
+	assert.NotEqual(t, plain.ID, txn.ID)
+
+	valid, err := txnService.VerifyTransaction(txn)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	// The signatures cover the memo
+	txn.Memo = "invoice 43"
+	_, err = txnService.VerifyTransaction(txn)
+	assert.Error(t, err)
+
+	_, err = txnService.CreateTransactionToRecipients(from.Address, recipients, reps.TxnOptions{Memo: strings.Repeat("a", services.MaxMemoLen+1)})
+	assert.Error(t, err)
+}
+
+func TestCoinbaseOutputsMatureBeforeSpending(t *testing.T) {
+	params := mainnet
+	params.CoinbaseMaturity = 2
+
+	ts := newTestServicesWithParams(t, &params)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	// The next block is only the coinbase's second confirmation
+	_, err = txnService.CreateTransaction(from.Address, to.Address, 10)
+	assert.Error(t, err)
+
+	other := txnService.CreateCoinbaseTxn(to.Address, "")
+	repo.blocks = append(repo.blocks, reps.Block{ID: "next", PrevHash: []byte("genesis"), Timestamp: 1, Transactions: []reps.Transaction{other}})
+
+	txn, err := txnService.CreateTransaction(from.Address, to.Address, 10)
+	assert.NoError(t, err)
+
+	// Verified against a chain where it isn't mature yet
+	repo.blocks = repo.blocks[:1]
+	_, err = txnService.VerifyTransaction(txn)
+	var verificationErr *services.TxnVerificationError
+	assert.ErrorAs(t, err, &verificationErr)
+	assert.Equal(t, services.InvalidTxnImmatureCoinbase, verificationErr.Reason)
+}
+
+func TestTransactionIDIsHashOfContents(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+	txnAssembler := services.NewTxnAssemblerFac()
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	txn, err := txnService.CreateTransaction(from.Address, to.Address, 10)
+	assert.NoError(t, err)
+
+	// Row ids, block and signatures don't change the id
+	stored := txn
+	stored.BlockID = "block"
+	stored.Inputs = append([]reps.TxnInput{}, txn.Inputs...)
+	stored.Inputs[0].InputID = "another input id"
+	stored.Inputs[0].Signature = nil
+	assert.Equal(t, txn.ID, txnAssembler.TxnID(stored))
+
+	// The contents do
+	changed := txn
+	changed.Memo = "changed"
+	assert.NotEqual(t, txn.ID, txnAssembler.TxnID(changed))
+
+	coinbase := repo.blocks[0].Transactions[0]
+	assert.Equal(t, coinbase.ID, txnAssembler.TxnID(coinbase))
+
+	coinbase.ID = txnAssembler.TxnID(txn)
+	_, err = txnService.VerifyTransaction(coinbase)
+	var verificationErr *services.TxnVerificationError
+	assert.True(t, errors.As(err, &verificationErr))
+	assert.Equal(t, services.InvalidTxnID, verificationErr.Reason)
+}
+
+func TestDustOutputsAreRejected(t *testing.T) {
+	params := mainnet
+	params.DustThreshold = 5
+
+	ts := newTestServicesWithParams(t, &params)
+	repo, keystore, walletService := ts.repo, ts.keystore, ts.walletService
+	txnService := ts.txnService
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	_, err = txnService.CreateTransaction(from.Address, to.Address, 4)
+	assert.Error(t, err)
+
+	// Change of 2 isn't worth an output, so it's paid as fee
+	txn, err := txnService.CreateTransaction(from.Address, to.Address, services.Reward-2)
+	assert.NoError(t, err)
+	assert.Len(t, txn.Outputs, 1)
+	assert.Equal(t, 2, txn.Fee)
+	valid, err := txnService.VerifyTransaction(txn)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	// Created without a threshold, it has a dust output
+	mainnetTxnService := services.NewTransactionService(repo, walletService, nil, services.NewLocalSigner(keystore), &mainnet)
+	txn, err = mainnetTxnService.CreateTransaction(from.Address, to.Address, 4)
+	assert.NoError(t, err)
+
+	_, err = txnService.VerifyTransaction(txn)
+	var verificationErr *services.TxnVerificationError
+	assert.True(t, errors.As(err, &verificationErr))
+	assert.Equal(t, services.InvalidTxnDust, verificationErr.Reason)
+}
+
+func TestGetAddressUnspentOutputsGivesOutpointsAndConfirmations(t *testing.T) {
+	params := mainnet
+	params.CoinbaseMaturity = 2
+
+	ts := newTestServicesWithParams(t, &params)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+
+	from, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	to, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, from.Address)
+
+	utxos, err := txnService.GetAddressUnspentOutputs(from.Address)
+	assert.NoError(t, err)
+	assert.Len(t, utxos, 1)
+	assert.Equal(t, hex.EncodeToString(repo.blocks[0].Transactions[0].ID), utxos[0].TxnID)
+	assert.Equal(t, 0, utxos[0].Vout)
+	assert.Equal(t, services.Reward, utxos[0].Value)
+	assert.Equal(t, 0, utxos[0].Confirmations)
+	assert.True(t, utxos[0].Coinbase)
+	assert.False(t, utxos[0].Spendable)
+
+	other := txnService.CreateCoinbaseTxn(to.Address, "")
+	repo.blocks = append(repo.blocks, reps.Block{ID: "next", PrevHash: []byte("genesis"), Timestamp: 1, Transactions: []reps.Transaction{other}})
+
+	utxos, err = txnService.GetAddressUnspentOutputs(from.Address)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, utxos[0].Confirmations)
+	assert.True(t, utxos[0].Spendable)
+
+	_, err = txnService.GetAddressUnspentOutputs("not an address")
+	assert.Error(t, err)
+}
+
+func TestGetTransactionReceiptProvesInclusion(t *testing.T) {
+	ts := newTestServices(t)
+	repo, walletService, txnService := ts.repo, ts.walletService, ts.txnService
+
+	miner, err := walletService.CreateWallet()
+	assert.NoError(t, err)
+	fundAddress(repo, txnService, miner.Address)
+
+	// Odd number of transactions, so some levels of the merkle tree are padded
+	txns := make([]reps.Transaction, 0)
+	for _, data := range []string{"a", "b", "c", "d", "e"} {
+		txns = append(txns, txnService.CreateCoinbaseTxn(miner.Address, data))
+	}
+	block, err := services.NewBlockService(repo, &mainnet).CreateBlock(txns, repo.blocks[0].Hash)
+	assert.NoError(t, err)
+
+	for position, txn := range block.Transactions {
+		receipt, err := txnService.GetTransactionReceipt(hex.EncodeToString(txn.ID))
+		assert.NoError(t, err)
+		assert.Equal(t, hex.EncodeToString(block.Hash), receipt.BlockHash)
+		assert.Equal(t, 1, receipt.Height)
+		assert.Equal(t, position, receipt.Position)
+		assert.Equal(t, 0, receipt.Confirmations)
+
+		// Check it the way a third party would, from the receipt alone
+		assert.Equal(t, hex.EncodeToString(txn.ID), receipt.LeafData)
+		leafData, _ := hex.DecodeString(receipt.LeafData)
+		hash := sha256.Sum256(leafData)
+		path := hash[:]
+		assert.Equal(t, receipt.LeafHash, hex.EncodeToString(path))
+		for _, step := range receipt.Proof {
+			sibling, _ := hex.DecodeString(step.Hash)
+			if step.Side == "left" {
+				hash = sha256.Sum256(append(sibling, path...))
+			} else {
+				hash = sha256.Sum256(append(append([]byte{}, path...), sibling...))
+			}
+			path = hash[:]
+		}
+		assert.Equal(t, receipt.MerkleRoot, hex.EncodeToString(path))
+
+		prevHash, _ := hex.DecodeString(receipt.PrevHash)
+		header := sha256.Sum256(bytes.Join([][]byte{
+			utils.Int64ToByte(int64(receipt.Version)), path, prevHash, utils.Int64ToByte(receipt.Timestamp),
+			utils.Int64ToByte(int64(receipt.Difficulty)), utils.Int64ToByte(int64(receipt.Height)), utils.Int64ToByte(receipt.Nounce),
+		}, []byte{}))
+		assert.Equal(t, receipt.BlockHash, hex.EncodeToString(header[:]))
+	}
+
+	_, err = txnService.GetTransactionReceipt(hex.EncodeToString([]byte("unknown")))
+	assert.Error(t, err)
+}
@@ -0,0 +1,342 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/brucetieu/blockchain/repository"
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// How a validator change alters the validator set of a BFT chain
+const (
+	ValidatorAdd    = "add"    // Takes its turn proposing blocks after the validators already in the set
+	ValidatorRemove = "remove" // Takes no more turns, and its votes stop counting
+)
+
+// Whether a validator change has taken effect as of the last block
+const (
+	ValidatorChangeScheduled = "scheduled"
+	ValidatorChangeActive    = "active"
+)
+
+// Blocks after the next one a validator change takes effect at when the admin doesn't say, giving every validator
+// time to see it on the chain before it does
+var ValidatorChangeDelay = 10
+
+// Prepended to a validator change before a validator signs it, so its signature can never double as any other
+var validatorChangePrefix = []byte("Blockchain Validator Change:\n")
+
+// Whether txn changes the validator set of a BFT chain, instead of moving coins
+func IsValidatorChangeTransaction(txn reps.Transaction) bool {
+	return len(txn.ValidatorChange) > 0
+}
+
+// What a validator signs to make change, on the chain with params
+func ValidatorChangeHash(params *reps.ChainParams, change reps.ValidatorChange) []byte {
+	hash := sha256.Sum256(bytes.Join([][]byte{
+		validatorChangePrefix,
+		[]byte(ChainIdentifier(params)),
+		[]byte(change.Action),
+		[]byte(change.Validator),
+		utils.Int64ToByte(int64(change.Height)),
+		change.Authority,
+	}, []byte{}))
+	return hash[:]
+}
+
+// Validators of a BFT chain for the block at height, in the order they take turns: the ones its params start it
+// with, as changed by every change on the chain that's taken effect by then. Adding a validator already in the set,
+// removing one that isn't, or removing the last one, changes nothing
+func ValidatorsAt(blockchainRepo repository.BlockchainRepository, params *reps.ChainParams, height int) ([]string, error) {
+	validators := BFTValidators(params)
+	if !IsBFT(params) {
+		return validators, nil
+	}
+
+	changes, err := blockchainRepo.GetValidatorChanges()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, change := range changes {
+		if change.Height > height {
+			break
+		}
+		validators = applyValidatorChange(validators, change.Action, change.Validator)
+	}
+
+	return validators, nil
+}
+
+func applyValidatorChange(validators []string, action string, validator string) []string {
+	index := -1
+	for i, address := range validators {
+		if address == validator {
+			index = i
+		}
+	}
+
+	switch {
+	case action == ValidatorAdd && index < 0:
+		return append(append([]string{}, validators...), validator)
+	case action == ValidatorRemove && index >= 0 && len(validators) > 1:
+		return append(append([]string{}, validators[:index]...), validators[index+1:]...)
+	}
+	return validators
+}
+
+// Whether address is one of validators
+func isValidator(validators []string, address string) bool {
+	for _, validator := range validators {
+		if validator == address {
+			return true
+		}
+	}
+	return false
+}
+
+// Create a transaction making a change to the validator set of a BFT chain, which takes effect at height. It's
+// signed with the key of authority, which has to be a validator when the transaction is mined
+func (ts *transactionService) CreateValidatorChangeTransaction(action string, validator string, height int, authority string) (reps.Transaction, error) {
+	if !IsBFT(ts.params) {
+		return reps.Transaction{}, fmt.Errorf("validators can only be added and removed on a BFT chain, not a %s chain", ts.params.Consensus)
+	}
+
+	wallet, err := ts.walletService.GetWallet(authority)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+	if wallet.WatchOnly {
+		return reps.Transaction{}, fmt.Errorf("%w: %s", ErrWatchOnly, authority)
+	}
+
+	scheme, err := GetSignatureScheme(wallet.SigAlgorithm)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+	pubKey, err := hex.DecodeString(wallet.PublicKey)
+	if err != nil {
+		return reps.Transaction{}, fmt.Errorf("%s, unable to read public key of %s", err.Error(), authority)
+	}
+
+	change := reps.ValidatorChange{
+		Action:       action,
+		Validator:    validator,
+		Height:       height,
+		Authority:    pubKey,
+		SigAlgorithm: scheme.Algorithm(),
+	}
+	change.Signature, err = ts.signer.Sign(wallet, ValidatorChangeHash(ts.params, change))
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	data, err := json.Marshal(change)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	txn := reps.Transaction{ValidatorChange: data}
+	ts.setID(&txn)
+
+	return txn, nil
+}
+
+// A validator change checks out if it's signed by a validator in the set as of the next block, and takes effect
+// after it, adding a validator that won't be in the set by then or removing one that will. It moves no coins
+func (ts *transactionService) verifyValidatorChangeTransaction(txn reps.Transaction) (bool, error) {
+	txnId := hex.EncodeToString(txn.ID)
+	invalid := func(message string) (bool, error) {
+		return false, &TxnVerificationError{TxnID: txnId, InputIndex: -1, Reason: InvalidTxnValidatorChange, Message: message}
+	}
+
+	if !IsBFT(ts.params) {
+		return invalid("validators can only be changed on a BFT chain")
+	}
+	if len(txn.Inputs) > 0 || len(txn.Outputs) > 0 || txn.Fee != 0 || IsSlashingTransaction(txn) {
+		return invalid("a validator change moves no coins")
+	}
+
+	var change reps.ValidatorChange
+	if err := json.Unmarshal(txn.ValidatorChange, &change); err != nil {
+		return invalid(fmt.Sprintf("%s, malformed validator change", err.Error()))
+	}
+	if change.Action != ValidatorAdd && change.Action != ValidatorRemove {
+		return invalid(fmt.Sprintf("unknown validator change %s", change.Action))
+	}
+	if !IsValidAddress(change.Validator, ts.params.NetworkByte) {
+		return invalid(fmt.Sprintf("%s isn't an address on network %d", change.Validator, ts.params.NetworkByte))
+	}
+
+	lastBlock, err := ts.blockchainRepo.GetLastBlock()
+	if err != nil {
+		return false, err
+	}
+	nextHeight := lastBlock.Height + 1
+	if change.Height <= nextHeight {
+		return invalid(fmt.Sprintf("change takes effect at height %d, it has to be after the next block at %d", change.Height, nextHeight))
+	}
+
+	scheme, err := GetSignatureScheme(change.SigAlgorithm)
+	if err != nil {
+		return invalid(err.Error())
+	}
+	if err := checkSigAlgorithmActive(ts.params, scheme.Algorithm(), nextHeight); err != nil {
+		return false, &TxnVerificationError{TxnID: txnId, InputIndex: -1, Reason: InvalidTxnInactiveRule, Message: err.Error()}
+	}
+	if !scheme.ValidPublicKey(change.Authority) || !scheme.Verify(change.Authority, change.Signature, ValidatorChangeHash(ts.params, change)) {
+		return invalid("signature of validator change doesn't check out")
+	}
+
+	validators, err := ValidatorsAt(ts.blockchainRepo, ts.params, nextHeight)
+	if err != nil {
+		return false, err
+	}
+	authority, err := AddressFromPubKey(change.Authority, ts.params.NetworkByte)
+	if err != nil {
+		return false, err
+	}
+	if !isValidator(validators, string(authority)) {
+		return invalid(fmt.Sprintf("%s isn't a validator, only validators can change the set", authority))
+	}
+
+	validators, err = ValidatorsAt(ts.blockchainRepo, ts.params, change.Height)
+	if err != nil {
+		return false, err
+	}
+	member := isValidator(validators, change.Validator)
+	if change.Action == ValidatorAdd && member {
+		return invalid(fmt.Sprintf("%s is already a validator at height %d", change.Validator, change.Height))
+	}
+	if change.Action == ValidatorRemove && !member {
+		return invalid(fmt.Sprintf("%s isn't a validator at height %d", change.Validator, change.Height))
+	}
+	if change.Action == ValidatorRemove && len(validators) == 1 {
+		return invalid(fmt.Sprintf("%s is the last validator, the chain can't be left without any", change.Validator))
+	}
+
+	if !ts.hasValidID(txn) {
+		return invalid("id is not the hash of the transaction")
+	}
+
+	return true, nil
+}
+
+// Validator a validator change transaction changes. Empty for any other transaction
+func changedValidator(txn reps.Transaction) string {
+	var change reps.ValidatorChange
+	if !IsValidatorChangeTransaction(txn) || json.Unmarshal(txn.ValidatorChange, &change) != nil {
+		return ""
+	}
+	return change.Validator
+}
+
+// Adds and removes validators of a BFT chain, through transactions queued in the mempool so every change is on the
+// chain, and hands out the validator set with every change made to it
+type ValidatorSetService interface {
+	AddValidator(input reps.ValidatorChangeInput) (reps.Transaction, error)
+	RemoveValidator(input reps.ValidatorChangeInput) (reps.Transaction, error)
+	GetValidatorSchedule() (reps.ValidatorSchedule, error)
+}
+
+type validatorSetService struct {
+	blockchainRepo     repository.BlockchainRepository
+	transactionService TransactionService
+	mempoolService     MempoolService
+	params             *reps.ChainParams
+}
+
+func NewValidatorSetService(blockchainRepo repository.BlockchainRepository, transactionService TransactionService,
+	mempoolService MempoolService, params *reps.ChainParams) ValidatorSetService {
+	return &validatorSetService{
+		blockchainRepo:     blockchainRepo,
+		transactionService: transactionService,
+		mempoolService:     mempoolService,
+		params:             params,
+	}
+}
+
+// Queue a transaction adding input's address to the validators at input's height
+func (vs *validatorSetService) AddValidator(input reps.ValidatorChangeInput) (reps.Transaction, error) {
+	return vs.change(ValidatorAdd, input)
+}
+
+// Queue a transaction removing input's address from the validators at input's height
+func (vs *validatorSetService) RemoveValidator(input reps.ValidatorChangeInput) (reps.Transaction, error) {
+	return vs.change(ValidatorRemove, input)
+}
+
+func (vs *validatorSetService) change(action string, input reps.ValidatorChangeInput) (reps.Transaction, error) {
+	lastBlock, err := vs.blockchainRepo.GetLastBlock()
+	if err != nil {
+		return reps.Transaction{}, fmt.Errorf("%s, there's no chain yet", err.Error())
+	}
+
+	// Only a validator can sign a change, so there's no point queueing anyone else's
+	validators, err := ValidatorsAt(vs.blockchainRepo, vs.params, lastBlock.Height+1)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+	if !isValidator(validators, input.Authority) {
+		return reps.Transaction{}, fmt.Errorf("%w: %s", ErrNotValidator, input.Authority)
+	}
+
+	height := input.Height
+	if height == 0 {
+		height = lastBlock.Height + 1 + ValidatorChangeDelay
+	}
+	log.WithFields(log.Fields{"action": action, "validator": input.Address, "height": height, "authority": input.Authority}).Info("Changing validators")
+
+	txn, err := vs.transactionService.CreateValidatorChangeTransaction(action, input.Address, height, input.Authority)
+	if err != nil {
+		return reps.Transaction{}, err
+	}
+
+	if _, err := vs.mempoolService.AddTransaction(txn); err != nil {
+		return reps.Transaction{}, err
+	}
+
+	return txn, nil
+}
+
+// Validators as of the last block, and every change on the chain, in the order they take effect
+func (vs *validatorSetService) GetValidatorSchedule() (reps.ValidatorSchedule, error) {
+	if !IsBFT(vs.params) {
+		return reps.ValidatorSchedule{}, fmt.Errorf("only a BFT chain has a set of validators, not a %s chain", vs.params.Consensus)
+	}
+
+	lastBlock, err := vs.blockchainRepo.GetLastBlock()
+	if err != nil {
+		return reps.ValidatorSchedule{}, fmt.Errorf("%s, there's no chain yet", err.Error())
+	}
+
+	validators, err := ValidatorsAt(vs.blockchainRepo, vs.params, lastBlock.Height)
+	if err != nil {
+		return reps.ValidatorSchedule{}, err
+	}
+
+	changes, err := vs.blockchainRepo.GetValidatorChanges()
+	if err != nil {
+		return reps.ValidatorSchedule{}, err
+	}
+	for i := range changes {
+		changes[i].Status = ValidatorChangeScheduled
+		if changes[i].Height <= lastBlock.Height {
+			changes[i].Status = ValidatorChangeActive
+		}
+		if pubKey, err := hex.DecodeString(changes[i].Authority); err == nil {
+			if address, err := AddressFromPubKey(pubKey, vs.params.NetworkByte); err == nil {
+				changes[i].AuthorityAddress = string(address)
+			}
+		}
+	}
+
+	return reps.ValidatorSchedule{Height: lastBlock.Height, Validators: validators, Changes: changes}, nil
+}
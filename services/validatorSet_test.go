@@ -0,0 +1,97 @@
+package services_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatorChangesTakeEffectAtTheirHeight(t *testing.T) {
+	params := mainnet
+	params.Consensus = services.ConsensusBFT
+	params.TargetBlockTime = 60
+	ts := newTestServicesWithParams(t, &params)
+	repo, signer, walletService := ts.repo, ts.signer, ts.walletService
+	txnService, blockchainService, mempoolService := ts.txnService, ts.blockchainService, ts.mempoolService
+	finalityService := services.NewFinalityService(repo, walletService, signer, &params)
+	validatorSetService := services.NewValidatorSetService(repo, txnService, mempoolService, &params)
+
+	wallets := make([]reps.Wallet, 3)
+	for i := range wallets {
+		wallet, err := walletService.CreateWallet()
+		assert.NoError(t, err)
+		wallets[i] = wallet
+	}
+	first, second, newcomer := wallets[0], wallets[1], wallets[2]
+
+	params.Validators = strings.Join([]string{first.Address, second.Address}, ",")
+	_, _, err := blockchainService.CreateBlockchain(first.Address, nil)
+	assert.NoError(t, err)
+
+	// Only a validator can change the set, and only from the block after next on
+	_, err = validatorSetService.AddValidator(reps.ValidatorChangeInput{Address: newcomer.Address, Authority: newcomer.Address, Height: 3})
+	assert.True(t, errors.Is(err, services.ErrNotValidator))
+	_, err = validatorSetService.AddValidator(reps.ValidatorChangeInput{Address: newcomer.Address, Authority: first.Address, Height: 1})
+	assert.Error(t, err)
+
+	txn, err := validatorSetService.AddValidator(reps.ValidatorChangeInput{Address: newcomer.Address, Authority: first.Address, Height: 3})
+	assert.NoError(t, err)
+	assert.True(t, services.IsValidatorChangeTransaction(txn))
+	assert.Equal(t, 1, mempoolService.Size())
+
+	// Signed for the add, the signature doesn't carry over to a removal
+	forged := txn
+	forged.ValidatorChange = []byte(strings.Replace(string(txn.ValidatorChange), services.ValidatorAdd, services.ValidatorRemove, 1))
+	_, err = txnService.VerifyTransaction(forged)
+	var verificationErr *services.TxnVerificationError
+	assert.True(t, errors.As(err, &verificationErr))
+	assert.Equal(t, services.InvalidTxnValidatorChange, verificationErr.Reason)
+
+	block, err := mempoolService.MinePendingTransactions(second.Address, "")
+	assert.NoError(t, err)
+	assert.Len(t, block.Transactions, 2)
+
+	schedule, err := validatorSetService.GetValidatorSchedule()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{first.Address, second.Address}, schedule.Validators)
+	assert.Len(t, schedule.Changes, 1)
+	assert.Equal(t, services.ValidatorChangeScheduled, schedule.Changes[0].Status)
+	assert.Equal(t, first.Address, schedule.Changes[0].AuthorityAddress)
+	assert.Equal(t, block.ID, schedule.Changes[0].BlockID)
+
+	// Already on its way in
+	_, err = validatorSetService.AddValidator(reps.ValidatorChangeInput{Address: newcomer.Address, Authority: second.Address, Height: 5})
+	assert.Error(t, err)
+
+	_, err = blockchainService.MineTransactions(nil, first.Address, "")
+	assert.NoError(t, err)
+
+	// From height 3 there are three validators taking turns, and the newcomer's votes count
+	_, err = blockchainService.MineTransactions(nil, first.Address, "")
+	assert.NoError(t, err)
+	_, err = blockchainService.MineTransactions(nil, newcomer.Address, "")
+	assert.True(t, errors.Is(err, services.ErrNotProposer))
+	_, err = blockchainService.MineTransactions(nil, second.Address, "")
+	assert.NoError(t, err)
+	block, err = blockchainService.MineTransactions(nil, newcomer.Address, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, block.Height)
+
+	finality, err := finalityService.Vote(block.ID, reps.VoteInput{Address: newcomer.Address})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, finality.Validators)
+
+	schedule, err = validatorSetService.GetValidatorSchedule()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{first.Address, second.Address, newcomer.Address}, schedule.Validators)
+	assert.Equal(t, services.ValidatorChangeActive, schedule.Changes[0].Status)
+
+	// Earlier blocks keep the validators they had
+	validators, err := services.ValidatorsAt(repo, &params, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{first.Address, second.Address}, validators)
+}
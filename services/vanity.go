@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	reps "github.com/brucetieu/blockchain/representations"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	VanityTimeout      = 60 * time.Second // Default time to search for a vanity address
+	MaxVanityTimeout   = 10 * time.Minute
+	VanityWorkers      = runtime.NumCPU() // Default number of goroutines generating key pairs
+	MaxVanityWorkers   = 4 * runtime.NumCPU()
+	MaxVanityPrefixLen = 8 // Every extra character makes the search ~58 times longer
+
+	base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+)
+
+// Generate key pairs on workers goroutines until one's address starts with prefix, and save it as a wallet.
+// Gives up with ErrVanityTimeout once timeout passes. Zero timeout or workers use the defaults
+func (ws *walletService) CreateVanityWallet(prefix string, sigAlgorithm string, timeout time.Duration, workers int) (reps.Wallet, error) {
+	scheme, err := GetSignatureScheme(sigAlgorithm)
+	if err != nil {
+		return reps.Wallet{}, err
+	}
+
+	if prefix == "" || len(prefix) > MaxVanityPrefixLen {
+		return reps.Wallet{}, fmt.Errorf("vanity prefix must be between 1 and %d characters", MaxVanityPrefixLen)
+	}
+	for _, c := range prefix {
+		if !strings.ContainsRune(base58Alphabet, c) {
+			return reps.Wallet{}, fmt.Errorf("vanity prefix can't contain %q, addresses are base58", c)
+		}
+	}
+
+	if timeout <= 0 {
+		timeout = VanityTimeout
+	}
+	if timeout > MaxVanityTimeout {
+		timeout = MaxVanityTimeout
+	}
+	if workers <= 0 {
+		workers = VanityWorkers
+	}
+	if workers > MaxVanityWorkers {
+		workers = MaxVanityWorkers
+	}
+
+	log.WithFields(log.Fields{"prefix": prefix, "timeout": timeout, "workers": workers}).Info("Searching for vanity address...")
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	type keyPair struct {
+		privKey []byte
+		pubKey  []byte
+	}
+
+	found := make(chan keyPair, 1)
+	errs := make(chan error, workers)
+	var attempts int64
+
+	// Workers stop once the context is done, which is also when this returns
+	for i := 0; i < workers; i++ {
+		go func() {
+			for ctx.Err() == nil {
+				privKey, pubKey, err := scheme.GenerateKey()
+				if err != nil {
+					errs <- err
+					return
+				}
+				atomic.AddInt64(&attempts, 1)
+
+				address, err := AddressFromPubKey(pubKey, ws.params.NetworkByte)
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				if strings.HasPrefix(string(address), prefix) {
+					select {
+					case found <- keyPair{privKey: privKey, pubKey: pubKey}:
+						cancel()
+					default:
+					}
+					return
+				}
+			}
+		}()
+	}
+
+	select {
+	case pair := <-found:
+		log.Infof("Found vanity address after %d attempts", atomic.LoadInt64(&attempts))
+		return ws.saveWallet(pair.privKey, pair.pubKey, scheme.Algorithm(), "", "")
+	case <-ctx.Done():
+		// Finding an address cancels the context too, or one may have been found just as time ran out
+		select {
+		case pair := <-found:
+			return ws.saveWallet(pair.privKey, pair.pubKey, scheme.Algorithm(), "", "")
+		default:
+		}
+		return reps.Wallet{}, fmt.Errorf("%w: no address starting with %s after %d attempts in %s", ErrVanityTimeout, prefix, atomic.LoadInt64(&attempts), timeout)
+	case err := <-errs:
+		return reps.Wallet{}, err
+	}
+}
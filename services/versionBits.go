@@ -0,0 +1,98 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	reps "github.com/brucetieu/blockchain/representations"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	VersionBitsTopBits  = 0x20000000 // Top bits every version that signals has set, so older versions aren't read as signals
+	VersionBits         = 29         // Bits below the top bits, each of which a rule change can be signalled with
+	SignalBits          = []int{}    // Bits set in the version of blocks this node mines, for the rule changes it's ready for
+	DefaultSignalWindow = 100        // Recent blocks signals are counted over when the caller doesn't say
+	MaxSignalWindow     = 2016       // Most recent blocks signals can be counted over
+)
+
+// Version of blocks this node mines: the top bits, and a bit for each rule change it signals being ready for
+func BlockVersion() int {
+	version := VersionBitsTopBits
+	for _, bit := range SignalBits {
+		version |= 1 << bit
+	}
+	return version
+}
+
+// Whether version signals with bit. Blocks from before versions, and versions without the top bits, signal nothing
+func SignalsBit(version int, bit int) bool {
+	if version&^(1<<VersionBits-1) != VersionBitsTopBits {
+		return false
+	}
+	return version&(1<<bit) != 0
+}
+
+// Parse a comma separated list of version bits, e.g. 1,4
+func ParseSignalBits(bits string) ([]int, error) {
+	parsed := make([]int, 0)
+	seen := make(map[int]bool)
+	for _, field := range strings.Split(bits, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		bit, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("%s, invalid version bit %s", err.Error(), field)
+		}
+		if bit < 0 || bit >= VersionBits {
+			return nil, fmt.Errorf("version bit %d isn't between 0 and %d", bit, VersionBits-1)
+		}
+		if !seen[bit] {
+			seen[bit] = true
+			parsed = append(parsed, bit)
+		}
+	}
+
+	sort.Ints(parsed)
+	return parsed, nil
+}
+
+// How many of blocks signal with each bit. Bits no block signals with are left out
+func CountSignals(blocks []reps.Block) []reps.VersionBitCount {
+	counts := make([]reps.VersionBitCount, 0)
+	for bit := 0; bit < VersionBits; bit++ {
+		count := 0
+		for _, block := range blocks {
+			if SignalsBit(block.Version, bit) {
+				count++
+			}
+		}
+		if count > 0 {
+			counts = append(counts, reps.VersionBitCount{Bit: bit, Count: count, Share: float64(count) / float64(len(blocks))})
+		}
+	}
+
+	return counts
+}
+
+// Signal with the bits in SIGNAL_BITS, e.g. 1,4, if it's set
+func SignalBitsAtStartup() {
+	envSignalBits := os.Getenv("SIGNAL_BITS")
+	if envSignalBits == "" {
+		return
+	}
+
+	bits, err := ParseSignalBits(envSignalBits)
+	if err != nil {
+		log.Warn("Invalid SIGNAL_BITS, not signalling: ", err.Error())
+		return
+	}
+	SignalBits = bits
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/google/uuid"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Version of the sealed backup contents written by BackupWallets. Bump it when the contents change,
+// and keep decoding the older versions in decodeBackup
+var WalletBackupVersion = 1
+
+// Sealed contents of a version 1 backup
+type walletBackupV1 struct {
+	Wallets   []backedUpWallet   `json:"wallets"`
+	HDWallets []backedUpHDWallet `json:"hdWallets"`
+}
+
+// PrivateKey is empty for watch-only wallets and keys held by a remote signer
+type backedUpWallet struct {
+	Address        string `json:"address"`
+	PublicKey      string `json:"publicKey,omitempty"`
+	PrivateKey     []byte `json:"privateKey,omitempty"`
+	SigAlgorithm   string `json:"sigAlgorithm,omitempty"`
+	HDWalletID     string `json:"hdWalletId,omitempty"`
+	DerivationPath string `json:"derivationPath,omitempty"`
+	WatchOnly      bool   `json:"watchOnly,omitempty"`
+}
+
+type backedUpHDWallet struct {
+	ID              string `json:"id"`
+	Account         int    `json:"account"`
+	NextIndex       int    `json:"nextIndex"`
+	NextChangeIndex int    `json:"nextChangeIndex"`
+	Seed            []byte `json:"seed"`
+}
+
+// Archive every wallet on this node, with their private keys and HD wallet seeds,
+// encrypted under the wallet file passphrase
+func (ws *walletService) BackupWallets() (reps.WalletBackup, error) {
+	log.Info("Backing up wallets")
+	wallets, err := ws.blockchainRepo.GetWallets()
+	if err != nil {
+		return reps.WalletBackup{}, err
+	}
+
+	contents := walletBackupV1{
+		Wallets:   make([]backedUpWallet, 0),
+		HDWallets: make([]backedUpHDWallet, 0),
+	}
+
+	hdWalletIds := make(map[string]bool)
+	for _, wallet := range wallets {
+		backedUp := backedUpWallet{
+			Address:        wallet.Address,
+			PublicKey:      wallet.PublicKey,
+			SigAlgorithm:   wallet.SigAlgorithm,
+			HDWalletID:     wallet.HDWalletID,
+			DerivationPath: wallet.DerivationPath,
+			WatchOnly:      wallet.WatchOnly,
+		}
+
+		// Wallets without a key in the wallet file are watch-only or held by a remote signer
+		if !wallet.WatchOnly {
+			if privKey, err := ws.keystoreService.GetKey(wallet.Address); err == nil {
+				backedUp.PrivateKey = privKey
+			}
+		}
+		contents.Wallets = append(contents.Wallets, backedUp)
+
+		if wallet.HDWalletID != "" {
+			hdWalletIds[wallet.HDWalletID] = true
+		}
+	}
+
+	for hdWalletId := range hdWalletIds {
+		hdWallet, err := ws.blockchainRepo.GetHDWallet(hdWalletId)
+		if err != nil {
+			return reps.WalletBackup{}, fmt.Errorf("%s, hd wallet with id %s does not exist", err.Error(), hdWalletId)
+		}
+
+		seed, err := ws.keystoreService.GetSeed(hdWalletId)
+		if err != nil {
+			return reps.WalletBackup{}, err
+		}
+
+		contents.HDWallets = append(contents.HDWallets, backedUpHDWallet{
+			ID:              hdWallet.ID,
+			Account:         hdWallet.Account,
+			NextIndex:       hdWallet.NextIndex,
+			NextChangeIndex: hdWallet.NextChangeIndex,
+			Seed:            seed,
+		})
+	}
+
+	plaintext, err := json.Marshal(contents)
+	if err != nil {
+		return reps.WalletBackup{}, err
+	}
+
+	backup, err := ws.keystoreService.SealBackup(plaintext)
+	if err != nil {
+		return reps.WalletBackup{}, err
+	}
+	backup.Version = WalletBackupVersion
+
+	log.Infof("Backed up %d wallets and %d hd wallets", len(contents.Wallets), len(contents.HDWallets))
+	return backup, nil
+}
+
+// Load every wallet in a backup onto this node. Wallets that already exist are left as they are.
+// Returns the number of wallets in the backup
+func (ws *walletService) RestoreWallets(backup reps.WalletBackup, passphrase string) (int, error) {
+	log.Info("Restoring wallets from backup version ", backup.Version)
+	plaintext, err := openBackup(backup, passphrase)
+	if err != nil {
+		return 0, err
+	}
+
+	contents, err := decodeBackup(backup.Version, plaintext)
+	if err != nil {
+		return 0, err
+	}
+
+	// HD wallets might already exist here under another id
+	hdWalletIds := make(map[string]string)
+	for _, backedUp := range contents.HDWallets {
+		hdWalletId, err := ws.restoreHDWallet(backedUp)
+		if err != nil {
+			return 0, err
+		}
+		hdWalletIds[backedUp.ID] = hdWalletId
+	}
+
+	for _, backedUp := range contents.Wallets {
+		if err := ws.restoreWallet(backedUp, hdWalletIds[backedUp.HDWalletID]); err != nil {
+			return 0, fmt.Errorf("%s, unable to restore wallet %s", err.Error(), backedUp.Address)
+		}
+	}
+
+	log.Infof("Restored %d wallets and %d hd wallets", len(contents.Wallets), len(contents.HDWallets))
+	return len(contents.Wallets), nil
+}
+
+// Decode the sealed contents of a backup according to its version
+func decodeBackup(version int, plaintext []byte) (walletBackupV1, error) {
+	switch version {
+	case 1:
+		var contents walletBackupV1
+		if err := json.Unmarshal(plaintext, &contents); err != nil {
+			return walletBackupV1{}, fmt.Errorf("%s, malformed wallet backup", err.Error())
+		}
+		return contents, nil
+	default:
+		return walletBackupV1{}, fmt.Errorf("unsupported wallet backup version %d", version)
+	}
+}
+
+// Returns the id the HD wallet has on this node
+func (ws *walletService) restoreHDWallet(backedUp backedUpHDWallet) (string, error) {
+	hdWallet, err := ws.blockchainRepo.GetHDWalletByFingerprint(fingerprint(newMasterKey(backedUp.Seed)))
+	if err == nil {
+		// Don't hand out addresses the backup already had
+		if backedUp.NextIndex > hdWallet.NextIndex {
+			hdWallet.NextIndex = backedUp.NextIndex
+		}
+		if backedUp.NextChangeIndex > hdWallet.NextChangeIndex {
+			hdWallet.NextChangeIndex = backedUp.NextChangeIndex
+		}
+		if err := ws.blockchainRepo.UpdateHDWallet(hdWallet); err != nil {
+			return "", err
+		}
+	} else {
+		hdWallet = reps.HDWallet{
+			ID:              uuid.Must(uuid.NewRandom()).String(),
+			Fingerprint:     fingerprint(newMasterKey(backedUp.Seed)),
+			Account:         backedUp.Account,
+			NextIndex:       backedUp.NextIndex,
+			NextChangeIndex: backedUp.NextChangeIndex,
+		}
+		if err := ws.blockchainRepo.CreateHDWallet(hdWallet); err != nil {
+			return "", err
+		}
+	}
+
+	if _, err := ws.keystoreService.GetSeed(hdWallet.ID); err != nil {
+		if err := ws.keystoreService.StoreSeed(hdWallet.ID, backedUp.Seed); err != nil {
+			return "", err
+		}
+	}
+
+	return hdWallet.ID, nil
+}
+
+func (ws *walletService) restoreWallet(backedUp backedUpWallet, hdWalletId string) error {
+	if backedUp.WatchOnly {
+		if _, err := ws.blockchainRepo.GetWallet(backedUp.Address); err == nil {
+			return nil
+		}
+		_, err := ws.WatchAddress(backedUp.Address)
+		return err
+	}
+
+	pubKey, err := hex.DecodeString(backedUp.PublicKey)
+	if err != nil {
+		return err
+	}
+
+	// Key held by a remote signer
+	if len(backedUp.PrivateKey) == 0 {
+		_, err := ws.ImportPublicKey(pubKey, backedUp.SigAlgorithm)
+		return err
+	}
+
+	scheme, err := GetSignatureScheme(backedUp.SigAlgorithm)
+	if err != nil {
+		return err
+	}
+
+	_, err = ws.saveWallet(backedUp.PrivateKey, pubKey, scheme.Algorithm(), hdWalletId, backedUp.DerivationPath)
+	return err
+}
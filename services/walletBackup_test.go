@@ -0,0 +1,73 @@
+package services_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/brucetieu/blockchain/repository"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestoreWalletsFromBackup(t *testing.T) {
+	repo := newFakeBlockchainRepository()
+	keystore := newUnlockedKeystore(t)
+	walletService := services.NewWalletService(repo, keystore, &mainnet)
+	hdWalletService := services.NewHDWalletService(repo, walletService, keystore)
+
+	wallet, err := walletService.CreateWalletWithAlgorithm(services.SigAlgorithmEd25519)
+	assert.NoError(t, err)
+	hdWallet, _, hdAddress, err := hdWalletService.CreateHDWallet("")
+	assert.NoError(t, err)
+	cold, err := walletService.CreatePaperWallet(services.SigAlgorithmECDSA, false)
+	assert.NoError(t, err)
+	_, err = walletService.WatchAddress(cold.Address)
+	assert.NoError(t, err)
+
+	backup, err := walletService.BackupWallets()
+	assert.NoError(t, err)
+	assert.Equal(t, services.WalletBackupVersion, backup.Version)
+
+	// Restore onto a node whose wallet file has a different passphrase
+	restoredRepo := newFakeBlockchainRepository()
+	restoredKeystore := services.NewKeystoreService(repository.NewKeystoreRepository(filepath.Join(t.TempDir(), "wallet.dat")))
+	assert.NoError(t, restoredKeystore.Unlock("another passphrase"))
+	restoredWallets := services.NewWalletService(restoredRepo, restoredKeystore, &mainnet)
+	restoredHDWallets := services.NewHDWalletService(restoredRepo, restoredWallets, restoredKeystore)
+
+	_, err = restoredWallets.RestoreWallets(backup, "wrong passphrase")
+	assert.Error(t, err)
+
+	restored, err := restoredWallets.RestoreWallets(backup, "passphrase")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, restored)
+
+	restoredWallet, err := restoredWallets.GetWallet(wallet.Address)
+	assert.NoError(t, err)
+	assert.Equal(t, wallet.PrivateKey, restoredWallet.PrivateKey)
+	assert.Equal(t, services.SigAlgorithmEd25519, restoredWallet.SigAlgorithm)
+
+	watched, err := restoredWallets.GetWallet(cold.Address)
+	assert.NoError(t, err)
+	assert.True(t, watched.WatchOnly)
+
+	// The HD wallet carries on deriving where it left off
+	restoredHDAddress, err := restoredWallets.GetWallet(hdAddress.Address)
+	assert.NoError(t, err)
+	next, err := restoredHDWallets.DeriveNextWallet(restoredHDAddress.HDWalletID)
+	assert.NoError(t, err)
+	expected, err := hdWalletService.DeriveNextWallet(hdWallet.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, expected.Address, next.Address)
+}
+
+func TestRestoreWalletsRejectsUnknownVersion(t *testing.T) {
+	walletService := newTestServices(t).walletService
+
+	backup, err := walletService.BackupWallets()
+	assert.NoError(t, err)
+
+	backup.Version = services.WalletBackupVersion + 1
+	_, err = walletService.RestoreWallets(backup, "passphrase")
+	assert.Error(t, err)
+}
@@ -8,58 +8,106 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/brucetieu/blockchain/repository"
 	reps "github.com/brucetieu/blockchain/representations"
 	// "github.com/brucetieu/blockchain/utils"
-	"golang.org/x/crypto/ripemd160"
 
 	"github.com/akamensky/base58"
 	"github.com/google/uuid"
+	"github.com/skip2/go-qrcode"
 
 	log "github.com/sirupsen/logrus"
 )
 
 var (
-	ChecksumLen = 4
-	Version     = byte(0)
+	ChecksumLen       = 4
+	PaperWalletQRSize = 256             // Width and height of paper wallet QR codes, in pixels
+	WalletUnlockTTL   = 5 * time.Minute // How long an unlock lasts when no ttl is given
 )
 
 type WalletService interface {
 	CreateWallet() (reps.Wallet, error)
+	CreateWalletWithAlgorithm(sigAlgorithm string) (reps.Wallet, error)
+	ImportWallet(privKey ecdsa.PrivateKey, hdWalletId string, derivationPath string) (reps.Wallet, error)
+	ExportWIF(address string) (string, error)
+	WatchAddress(address string) (reps.Wallet, error)
+	ImportPublicKey(pubKey []byte, sigAlgorithm string) (reps.Wallet, error)
+	CreatePaperWallet(sigAlgorithm string, withQRCode bool) (reps.PaperWallet, error)
+	CreateVanityWallet(prefix string, sigAlgorithm string, timeout time.Duration, workers int) (reps.Wallet, error)
+	ImportWIF(wif string) (reps.Wallet, error)
 	GetWallet(address string) (reps.Wallet, error)
 	// GetWalletGorm(address string) (reps.WalletGorm, error)
 	GetWallets() ([]reps.Wallet, error)
 
-	CreateKeyPair() (ecdsa.PrivateKey, []byte)
+	CreateKeyPair() (ecdsa.PrivateKey, []byte, error)
+	DerivePubKey(privKey ecdsa.PrivateKey) []byte
 	CreatePubKeyHash(pubKey []byte) ([]byte, error)
 	CreateChecksum(pubKeyHash []byte) []byte
 	CreateAddress(pubKey []byte) ([]byte, error)
 
 	ValidateAddress(address string) (bool, error)
+	IsValidAddress(address string) bool
+
+	Unlock(passphrase string, ttl time.Duration) (time.Time, error)
+	Lock()
+	IsLocked() bool
+
+	EncryptStoredKeys() error
+	BackupWallets() (reps.WalletBackup, error)
+	RestoreWallets(backup reps.WalletBackup, passphrase string) (int, error)
 }
 
 type walletService struct {
-	blockchainRepo repository.BlockchainRepository
-	walletAssember WalletAssemblerFac
+	blockchainRepo  repository.BlockchainRepository
+	keystoreService KeystoreService
+	walletAssember  WalletAssemblerFac
+	params          *reps.ChainParams
+
+	lockMu    sync.Mutex
+	lockTimer *time.Timer // Locks the wallet file again once an unlock's ttl is up
 }
 
-func NewWalletService(blockchainRepo repository.BlockchainRepository) WalletService {
+func NewWalletService(blockchainRepo repository.BlockchainRepository, keystoreService KeystoreService, params *reps.ChainParams) WalletService {
 	return &walletService{
-		blockchainRepo: blockchainRepo,
-		walletAssember: WalletAssembler,
+		blockchainRepo:  blockchainRepo,
+		keystoreService: keystoreService,
+		walletAssember:  WalletAssembler,
+		params:          params,
 	}
 }
 
-func (ws *walletService) CreateKeyPair() (ecdsa.PrivateKey, []byte) {
+// Generate a new P-256 private key along with its public key
+func (ws *walletService) CreateKeyPair() (ecdsa.PrivateKey, []byte, error) {
 	curve := elliptic.P256()
-	privKey, _ := ecdsa.GenerateKey(curve, rand.Reader)
+	privKey, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		log.Error("error generating key pair: ", err.Error())
+		return ecdsa.PrivateKey{}, nil, err
+	}
 
-	// Public key is a combination of x and y coordinates on elliptic curve
-	pubKey := append(privKey.X.Bytes(), privKey.Y.Bytes()...)
+	pubKey := ws.DerivePubKey(*privKey)
 
 	// log.Info(fmt.Sprintf("pubKey: %x\n", pubKey))
-	return *privKey, pubKey
+	return *privKey, pubKey, nil
+}
+
+// Public key is a combination of x and y coordinates on elliptic curve.
+// Each coordinate is padded to the curve size so the key can always be split in half again
+func (ws *walletService) DerivePubKey(privKey ecdsa.PrivateKey) []byte {
+	return toPubKeyBytes(privKey)
+}
+
+func toPubKeyBytes(privKey ecdsa.PrivateKey) []byte {
+	coordLen := (privKey.Curve.Params().BitSize + 7) / 8
+
+	pubKey := make([]byte, 2*coordLen)
+	privKey.X.FillBytes(pubKey[:coordLen])
+	privKey.Y.FillBytes(pubKey[coordLen:])
+
+	return pubKey
 }
 
 func (ws *walletService) GetWallet(address string) (reps.Wallet, error) {
@@ -69,13 +117,225 @@ func (ws *walletService) GetWallet(address string) (reps.Wallet, error) {
 		return reps.Wallet{}, errMsg
 	}
 
+	// Watch-only addresses have no private key anywhere
+	if wallet.WatchOnly {
+		return wallet, nil
+	}
+
+	// Private keys only live in the encrypted wallet file. Left empty if it's locked
+	privKey, err := ws.keystoreService.GetKey(address)
+	if err != nil {
+		log.Warn(err.Error())
+	}
+	wallet.PrivateKey = privKey
+
 	// utils.PrettyPrintln("Got wallet in ws.GetWallet: ", wallet.PublicKey)
 	return wallet, nil
 }
 
 func (ws *walletService) CreateWallet() (reps.Wallet, error) {
-	privKey, pubKey := ws.CreateKeyPair()
+	return ws.CreateWalletWithAlgorithm(DefaultSigAlgorithm)
+}
+
+// Create a wallet with a new key pair for the given signature algorithm
+func (ws *walletService) CreateWalletWithAlgorithm(sigAlgorithm string) (reps.Wallet, error) {
+	scheme, err := GetSignatureScheme(sigAlgorithm)
+	if err != nil {
+		return reps.Wallet{}, err
+	}
+
+	privKey, pubKey, err := scheme.GenerateKey()
+	if err != nil {
+		log.Error("error generating key pair: ", err.Error())
+		return reps.Wallet{}, err
+	}
+
+	return ws.saveWallet(privKey, pubKey, scheme.Algorithm(), "", "")
+}
+
+// Create a wallet for an existing private key. If a wallet with the key's address already exists, it's returned as is.
+// hdWalletId and derivationPath are only set for keys derived from an HD wallet
+func (ws *walletService) ImportWallet(privKey ecdsa.PrivateKey, hdWalletId string, derivationPath string) (reps.Wallet, error) {
+	pubKey := ws.DerivePubKey(privKey)
+	privKeyBytes := ws.walletAssember.ToPrivateKeyBytes(privKey)
+
+	return ws.saveWallet(privKeyBytes, pubKey, SigAlgorithmECDSA, hdWalletId, derivationPath)
+}
+
+// Export the private key of a wallet in Wallet Import Format
+func (ws *walletService) ExportWIF(address string) (string, error) {
+	wallet, err := ws.GetWallet(address)
+	if err != nil {
+		return "", err
+	}
+
+	if wallet.WatchOnly {
+		return "", fmt.Errorf("%w: %s", ErrWatchOnly, address)
+	}
+
+	if !ws.keystoreService.IsUnlocked() {
+		return "", fmt.Errorf("%w, cannot export key for %s", ErrWalletLocked, address)
+	}
+
+	if len(wallet.PrivateKey) == 0 {
+		return "", fmt.Errorf("no private key available to export for %s", address)
+	}
+
+	privKey := wallet.PrivateKey
+	scheme, err := GetSignatureScheme(wallet.SigAlgorithm)
+	if err != nil {
+		return "", err
+	}
+
+	// Keys from before the fixed width encoding are re-encoded first
+	if scheme.Algorithm() == SigAlgorithmECDSA {
+		ecdsaKey := toECDSAPrivateKey(privKey)
+		if ecdsaKey.D == nil {
+			return "", fmt.Errorf("unable to read private key for %s", address)
+		}
+		privKey = toPrivateKeyBytes(ecdsaKey)
+	}
+
+	return encodeWIF(privKey, scheme.Algorithm(), ws.params.NetworkByte)
+}
+
+// Import a private key in Wallet Import Format. Importing a key that's already here returns its wallet
+func (ws *walletService) ImportWIF(wif string) (reps.Wallet, error) {
+	privKey, sigAlgorithm, err := decodeWIF(wif, ws.params.NetworkByte)
+	if err != nil {
+		return reps.Wallet{}, err
+	}
+
+	scheme, err := GetSignatureScheme(sigAlgorithm)
+	if err != nil {
+		return reps.Wallet{}, err
+	}
+
+	pubKey, err := scheme.PublicKey(privKey)
+	if err != nil {
+		return reps.Wallet{}, err
+	}
+
+	return ws.saveWallet(privKey, pubKey, sigAlgorithm, "", "")
+}
+
+// Track an address without its private key, so its balance and transactions can be looked up
+func (ws *walletService) WatchAddress(address string) (reps.Wallet, error) {
+	log.Info("Watching address: ", address)
+	if !ws.IsValidAddress(address) {
+		return reps.Wallet{}, fmt.Errorf("malformed address: %s", address)
+	}
+
+	if existing, err := ws.blockchainRepo.GetWallet(address); err == nil {
+		if existing.WatchOnly {
+			return existing, nil
+		}
+		return reps.Wallet{}, fmt.Errorf("%s is already a wallet on this node", address)
+	}
+
+	wallet := reps.Wallet{
+		ID:        uuid.Must(uuid.NewRandom()).String(),
+		Address:   address,
+		WatchOnly: true,
+	}
+
+	err := ws.blockchainRepo.CreateWallet(wallet)
+	if err != nil {
+		return reps.Wallet{}, err
+	}
+
+	return wallet, nil
+}
+
+// Generate a key pair for cold storage without storing anything, neither in the db nor the wallet file.
+// The private key is returned in Wallet Import Format, optionally along with QR codes to print
+func (ws *walletService) CreatePaperWallet(sigAlgorithm string, withQRCode bool) (reps.PaperWallet, error) {
+	scheme, err := GetSignatureScheme(sigAlgorithm)
+	if err != nil {
+		return reps.PaperWallet{}, err
+	}
+
+	privKey, pubKey, err := scheme.GenerateKey()
+	if err != nil {
+		log.Error("error generating key pair: ", err.Error())
+		return reps.PaperWallet{}, err
+	}
+
+	address, err := ws.CreateAddress(pubKey)
+	if err != nil {
+		return reps.PaperWallet{}, err
+	}
+
+	wif, err := encodeWIF(privKey, scheme.Algorithm(), ws.params.NetworkByte)
+	if err != nil {
+		return reps.PaperWallet{}, err
+	}
+
+	paperWallet := reps.PaperWallet{
+		Address:      string(address),
+		PublicKey:    hex.EncodeToString(pubKey),
+		SigAlgorithm: scheme.Algorithm(),
+		WIF:          wif,
+	}
+
+	if withQRCode {
+		paperWallet.AddressQR, err = qrcode.Encode(paperWallet.Address, qrcode.Medium, PaperWalletQRSize)
+		if err != nil {
+			return reps.PaperWallet{}, err
+		}
+
+		paperWallet.WIFQR, err = qrcode.Encode(paperWallet.WIF, qrcode.Medium, PaperWalletQRSize)
+		if err != nil {
+			return reps.PaperWallet{}, err
+		}
+	}
+
+	return paperWallet, nil
+}
+
+// Create a wallet for a public key whose private key is held by a remote signer, and never enters this node
+func (ws *walletService) ImportPublicKey(pubKey []byte, sigAlgorithm string) (reps.Wallet, error) {
+	scheme, err := GetSignatureScheme(sigAlgorithm)
+	if err != nil {
+		return reps.Wallet{}, err
+	}
+
+	if !scheme.ValidPublicKey(pubKey) {
+		return reps.Wallet{}, fmt.Errorf("not a valid %s public key: %x", scheme.Algorithm(), pubKey)
+	}
+
+	walletAddress, err := ws.CreateAddress(pubKey)
+	if err != nil {
+		return reps.Wallet{}, err
+	}
+
+	log.Info("Importing public key for address: ", string(walletAddress))
+	wallet := reps.Wallet{
+		ID:           uuid.Must(uuid.NewRandom()).String(),
+		Address:      string(walletAddress),
+		PublicKey:    hex.EncodeToString(pubKey),
+		SigAlgorithm: scheme.Algorithm(),
+	}
+
+	if existing, err := ws.blockchainRepo.GetWallet(wallet.Address); err == nil {
+		if !existing.WatchOnly {
+			return existing, nil
+		}
+
+		// Watched addresses keep their record, they just become spendable
+		wallet.ID = existing.ID
+		return wallet, ws.blockchainRepo.UpdateWallet(wallet)
+	}
+
+	err = ws.blockchainRepo.CreateWallet(wallet)
+	if err != nil {
+		return reps.Wallet{}, err
+	}
 
+	return wallet, nil
+}
+
+func (ws *walletService) saveWallet(privKeyBytes []byte, pubKey []byte, sigAlgorithm string, hdWalletId string, derivationPath string) (reps.Wallet, error) {
 	walletAddress, err := ws.CreateAddress(pubKey)
 	if err != nil {
 		return reps.Wallet{}, err
@@ -83,8 +343,42 @@ func (ws *walletService) CreateWallet() (reps.Wallet, error) {
 
 	log.Info("wallet address: ", string(walletAddress))
 
-	privKeyBytes := ws.walletAssember.ToPrivateKeyBytes(privKey)
-	wallet := reps.Wallet{uuid.Must(uuid.NewRandom()).String(), string(walletAddress), privKeyBytes, hex.EncodeToString(pubKey)}
+	if existing, err := ws.blockchainRepo.GetWallet(string(walletAddress)); err == nil {
+		// Importing the key of a watched address turns it into a full wallet
+		if existing.WatchOnly {
+			existing.WatchOnly = false
+			existing.PublicKey = hex.EncodeToString(pubKey)
+			existing.SigAlgorithm = sigAlgorithm
+			if err := ws.blockchainRepo.UpdateWallet(existing); err != nil {
+				return reps.Wallet{}, err
+			}
+		}
+
+		if _, err := ws.keystoreService.GetKey(existing.Address); err != nil {
+			err = ws.keystoreService.StoreKey(existing.Address, privKeyBytes)
+			if err != nil {
+				return reps.Wallet{}, err
+			}
+		}
+
+		existing.PrivateKey = privKeyBytes
+		return existing, nil
+	}
+
+	wallet := reps.Wallet{
+		ID:             uuid.Must(uuid.NewRandom()).String(),
+		Address:        string(walletAddress),
+		PublicKey:      hex.EncodeToString(pubKey),
+		SigAlgorithm:   sigAlgorithm,
+		HDWalletID:     hdWalletId,
+		DerivationPath: derivationPath,
+	}
+
+	// Private key goes to the encrypted wallet file, never to the db
+	err = ws.keystoreService.StoreKey(wallet.Address, privKeyBytes)
+	if err != nil {
+		return reps.Wallet{}, err
+	}
 
 	// utils.PrettyPrintln("wallet: ", wallet)
 	// Persist
@@ -93,9 +387,93 @@ func (ws *walletService) CreateWallet() (reps.Wallet, error) {
 		return reps.Wallet{}, err
 	}
 
+	wallet.PrivateKey = privKeyBytes
 	return wallet, nil
 }
 
+// Decrypt the wallet file's keys into memory for ttl, after which it locks itself again.
+// Unlocking while already unlocked restarts the countdown. Returns when the wallet file will lock
+func (ws *walletService) Unlock(passphrase string, ttl time.Duration) (time.Time, error) {
+	if ttl <= 0 {
+		ttl = WalletUnlockTTL
+	}
+
+	ws.lockMu.Lock()
+	defer ws.lockMu.Unlock()
+
+	if err := ws.keystoreService.Unlock(passphrase); err != nil {
+		return time.Time{}, err
+	}
+
+	if ws.lockTimer != nil {
+		ws.lockTimer.Stop()
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(ttl, func() {
+		ws.lockMu.Lock()
+		defer ws.lockMu.Unlock()
+
+		// Unlocked again while this was waiting to run
+		if ws.lockTimer != timer {
+			return
+		}
+		ws.lockTimer = nil
+		ws.keystoreService.Lock()
+	})
+	ws.lockTimer = timer
+
+	log.Info("Wallet file unlocked for ", ttl)
+	return time.Now().Add(ttl), nil
+}
+
+// Lock the wallet file straight away. Spending fails with ErrWalletLocked until it's unlocked again
+func (ws *walletService) Lock() {
+	ws.lockMu.Lock()
+	defer ws.lockMu.Unlock()
+
+	if ws.lockTimer != nil {
+		ws.lockTimer.Stop()
+		ws.lockTimer = nil
+	}
+
+	ws.keystoreService.Lock()
+}
+
+func (ws *walletService) IsLocked() bool {
+	return !ws.keystoreService.IsUnlocked()
+}
+
+// Move any private keys still stored in plaintext in the db into the encrypted wallet file
+func (ws *walletService) EncryptStoredKeys() error {
+	wallets, err := ws.blockchainRepo.GetWallets()
+	if err != nil {
+		return err
+	}
+
+	for _, wallet := range wallets {
+		if len(wallet.PrivateKey) == 0 {
+			continue
+		}
+
+		log.Info("Moving private key into wallet file for address: ", wallet.Address)
+		if _, err := ws.keystoreService.GetKey(wallet.Address); err != nil {
+			err = ws.keystoreService.StoreKey(wallet.Address, wallet.PrivateKey)
+			if err != nil {
+				return err
+			}
+		}
+
+		wallet.PrivateKey = nil
+		err = ws.blockchainRepo.UpdateWallet(wallet)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (ws *walletService) GetWallets() ([]reps.Wallet, error) {
 	wallets, err := ws.blockchainRepo.GetWallets()
 	if err != nil {
@@ -107,40 +485,52 @@ func (ws *walletService) GetWallets() ([]reps.Wallet, error) {
 
 // pubKeyHash = ripemd160(sha256(pubKey))
 func (ws *walletService) CreatePubKeyHash(pubKey []byte) ([]byte, error) {
-	pubHash := sha256.Sum256(pubKey)
-
-	ripemdHasher := ripemd160.New()
-	_, err := ripemdHasher.Write(pubHash[:])
-	if err != nil {
-		log.Error(err.Error())
-	}
-
-	pubKeyHash := ripemdHasher.Sum(nil)
-
-	// log.Info(fmt.Sprintf("pubKeyHash: %x\n", pubKeyHash))
-	return pubKeyHash, err
+	return createPubKeyHash(pubKey)
 }
 
 // checksum = sha256(sha256(pubKeyHash))
 func (ws *walletService) CreateChecksum(pubKeyHash []byte) []byte {
-	pubKeyHashSum := sha256.Sum256(pubKeyHash)
-	pubKeyHashSum2 := sha256.Sum256(pubKeyHashSum[:])
+	checksum := createChecksum(pubKeyHash)
 
-	log.Info(fmt.Sprintf("checksum: %x\n", pubKeyHashSum2[:ChecksumLen]))
-	return pubKeyHashSum2[:ChecksumLen] // checksum is first 4 bytes of second hash
+	log.Info(fmt.Sprintf("checksum: %x\n", checksum))
+	return checksum
 }
 
+// Create an address for this chain's network
 func (ws *walletService) CreateAddress(pubKey []byte) ([]byte, error) {
-	pubKeyHash, err := ws.CreatePubKeyHash(pubKey)
+	return AddressFromPubKey(pubKey, ws.params.NetworkByte)
+}
+
+func (ws *walletService) ValidateAddress(address string) (bool, error) {
+	log.Info("Validating address: ", address)
+	// Check if address exists in db first
+	_, err := ws.GetWallet(address)
+	if err != nil {
+		errMsg := fmt.Errorf("%s: wallet with address %s does not exist", err.Error(), address)
+		return false, errMsg
+	}
+
+	// Deconstruct address and get the pubKeyHash to check if it's actually valid
+	return ws.IsValidAddress(address), nil
+}
+
+// Check an address is well formed and belongs to this chain's network. Doesn't require a wallet to exist
+func (ws *walletService) IsValidAddress(address string) bool {
+	return IsValidAddress(address, ws.params.NetworkByte)
+}
+
+// address = base58(networkByte + pubKeyHash + checksum)
+func AddressFromPubKey(pubKey []byte, networkByte byte) ([]byte, error) {
+	pubKeyHash, err := createPubKeyHash(pubKey)
 	if err != nil {
 		return []byte{}, err
 	}
 
 	// Version + pubKeyHash
-	versionedPubKeyHash := append([]byte{Version}, pubKeyHash...)
+	versionedPubKeyHash := append([]byte{networkByte}, pubKeyHash...)
 	log.Info(fmt.Sprintf("versionedPubKeyHash: %x", versionedPubKeyHash))
 
-	checksum := ws.CreateChecksum(pubKeyHash)
+	checksum := createChecksum(pubKeyHash)
 
 	// version + pubKeyHash + checksum
 	finalHash := append(versionedPubKeyHash, checksum...)
@@ -151,26 +541,44 @@ func (ws *walletService) CreateAddress(pubKey []byte) ([]byte, error) {
 	return address, nil
 }
 
-func (ws *walletService) ValidateAddress(address string) (bool, error) {
-	log.Info("Validating address: ", address)
-	// Check if address exists in db first
-	_, err := ws.GetWallet(address)
-	if err != nil {
-		errMsg := fmt.Errorf("%s: wallet with address %s does not exist", err.Error(), address)
-		return false, errMsg
+// Address outputs locked to pubKeyHash pay to
+func addressFromPubKeyHash(pubKeyHash []byte, networkByte byte) string {
+	versionedPubKeyHash := append([]byte{networkByte}, pubKeyHash...)
+	return string(base58Encode(append(versionedPubKeyHash, createChecksum(pubKeyHash)...)))
+}
+
+// Public key hash an address was made from, without its version byte and checksum. Nil if it doesn't decode
+func pubKeyHashFromAddress(address string) []byte {
+	decoded, err := base58.Decode(address)
+	if err != nil || len(decoded) <= 1+ChecksumLen {
+		return nil
 	}
+	return decoded[1 : len(decoded)-ChecksumLen]
+}
 
-	// Deconstruct address and get the pubKeyHash to check if it's actually valid
-	pubKeyHash := base58Decode([]byte(address))
-	actualChecksum := pubKeyHash[len(pubKeyHash)-ChecksumLen:]
-	pubKeyHash = pubKeyHash[1 : len(pubKeyHash)-ChecksumLen]
-	expectedChecksum := ws.CreateChecksum(pubKeyHash)
+// Check that an address decodes, its checksum matches, and it was created for the given network
+func IsValidAddress(address string, networkByte byte) bool {
+	decoded, err := base58.Decode(address)
+	if err != nil || len(decoded) <= 1+ChecksumLen {
+		return false
+	}
 
-	if bytes.Compare(actualChecksum, expectedChecksum) == 0 {
-		return true, nil
+	if decoded[0] != networkByte {
+		return false
 	}
 
-	return false, nil
+	actualChecksum := decoded[len(decoded)-ChecksumLen:]
+	pubKeyHash := decoded[1 : len(decoded)-ChecksumLen]
+	expectedChecksum := createChecksum(pubKeyHash)
+
+	return bytes.Equal(actualChecksum, expectedChecksum)
+}
+
+func createChecksum(pubKeyHash []byte) []byte {
+	pubKeyHashSum := sha256.Sum256(pubKeyHash)
+	pubKeyHashSum2 := sha256.Sum256(pubKeyHashSum[:])
+
+	return pubKeyHashSum2[:ChecksumLen] // checksum is first 4 bytes of second hash
 }
 
 func base58Decode(address []byte) []byte {
@@ -0,0 +1,188 @@
+package services_test
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brucetieu/blockchain/repository"
+	reps "github.com/brucetieu/blockchain/representations"
+	"github.com/brucetieu/blockchain/services"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	mainnet = reps.ChainParams{NetworkByte: 0x00}
+	testnet = reps.ChainParams{NetworkByte: 0x6f}
+)
+
+func init() {
+	services.BlockAssembler = services.NewBlockAssemblerFac()
+	services.TxnAssembler = services.NewTxnAssemblerFac()
+	services.WalletAssembler = services.NewWalletAssemblerFac()
+	services.HeaderAssembler = services.NewHeaderAssemblerFac()
+
+	// Keep key derivation cheap in tests
+	services.ScryptN = 1024
+}
+
+// Keystore backed by a wallet file in a temp dir, already unlocked
+func newUnlockedKeystore(t *testing.T) services.KeystoreService {
+	keystoreRepo := repository.NewKeystoreRepository(filepath.Join(t.TempDir(), "wallet.dat"))
+	keystoreService := services.NewKeystoreService(keystoreRepo)
+	assert.NoError(t, keystoreService.Unlock("passphrase"))
+	return keystoreService
+}
+
+func TestAddressFromPubKeyIsOnlyValidForItsNetwork(t *testing.T) {
+	walletService := newTestServices(t).walletService
+	_, pubKey, err := walletService.CreateKeyPair()
+	assert.NoError(t, err)
+
+	mainnetAddress, err := services.AddressFromPubKey(pubKey, mainnet.NetworkByte)
+	assert.NoError(t, err)
+	testnetAddress, err := services.AddressFromPubKey(pubKey, testnet.NetworkByte)
+	assert.NoError(t, err)
+
+	assert.NotEqual(t, string(mainnetAddress), string(testnetAddress))
+	assert.True(t, services.IsValidAddress(string(mainnetAddress), mainnet.NetworkByte))
+	assert.False(t, services.IsValidAddress(string(mainnetAddress), testnet.NetworkByte))
+	assert.True(t, services.IsValidAddress(string(testnetAddress), testnet.NetworkByte))
+	assert.False(t, services.IsValidAddress(string(testnetAddress), mainnet.NetworkByte))
+}
+
+func TestIsValidAddressRejectsMalformedAddresses(t *testing.T) {
+	assert.False(t, services.IsValidAddress("", mainnet.NetworkByte))
+	assert.False(t, services.IsValidAddress("1", mainnet.NetworkByte))
+	assert.False(t, services.IsValidAddress("0OIl", mainnet.NetworkByte))
+}
+
+func TestValidateAddressRejectsWalletFromOtherNetwork(t *testing.T) {
+	repo := newFakeBlockchainRepository()
+	keystore := newUnlockedKeystore(t)
+	mainnetWallets := services.NewWalletService(repo, keystore, &mainnet)
+	testnetWallets := services.NewWalletService(repo, keystore, &testnet)
+
+	wallet, err := mainnetWallets.CreateWallet()
+	assert.NoError(t, err)
+
+	valid, err := mainnetWallets.ValidateAddress(wallet.Address)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, err = testnetWallets.ValidateAddress(wallet.Address)
+	assert.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestCreateTransactionRejectsRecipientFromOtherNetwork(t *testing.T) {
+	repo := newFakeBlockchainRepository()
+	keystore := newUnlockedKeystore(t)
+	mainnetWallets := services.NewWalletService(repo, keystore, &mainnet)
+	testnetWallets := services.NewWalletService(repo, keystore, &testnet)
+	testnetTxns := services.NewTransactionService(repo, testnetWallets, nil, services.NewLocalSigner(keystore), &testnet)
+
+	from, err := testnetWallets.CreateWallet()
+	assert.NoError(t, err)
+	to, err := mainnetWallets.CreateWallet()
+	assert.NoError(t, err)
+
+	_, err = testnetTxns.CreateTransaction(from.Address, to.Address, 10)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not a valid address for network")
+}
+
+func TestWIFRoundTrip(t *testing.T) {
+	for _, sigAlgorithm := range []string{services.SigAlgorithmECDSA, services.SigAlgorithmEd25519} {
+		wallets := newTestServices(t).walletService
+		wallet, err := wallets.CreateWalletWithAlgorithm(sigAlgorithm)
+		assert.NoError(t, err)
+
+		wif, err := wallets.ExportWIF(wallet.Address)
+		assert.NoError(t, err)
+
+		// Import into a different node
+		otherWallets := newTestServices(t).walletService
+		imported, err := otherWallets.ImportWIF(wif)
+		assert.NoError(t, err)
+		assert.Equal(t, wallet.Address, imported.Address)
+		assert.Equal(t, sigAlgorithm, imported.SigAlgorithm)
+
+		// Keys are tied to their network
+		testnetWallets := newTestServicesWithParams(t, &testnet).walletService
+		_, err = testnetWallets.ImportWIF(wif)
+		assert.Error(t, err)
+	}
+}
+
+func TestImportWIFAcceptsBitcoinStyleKeys(t *testing.T) {
+	wallets := newTestServices(t).walletService
+
+	// Bitcoin's well known example key, uncompressed. It imports as a P-256 key
+	_, err := wallets.ImportWIF("5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTJ")
+	assert.NoError(t, err)
+
+	_, err = wallets.ImportWIF("5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTK")
+	assert.Error(t, err)
+}
+
+func TestCreatePaperWalletStoresNothing(t *testing.T) {
+	repo := newFakeBlockchainRepository()
+	keystore := newUnlockedKeystore(t)
+	walletService := services.NewWalletService(repo, keystore, &mainnet)
+
+	paperWallet, err := walletService.CreatePaperWallet(services.SigAlgorithmEd25519, true)
+	assert.NoError(t, err)
+	assert.True(t, walletService.IsValidAddress(paperWallet.Address))
+	assert.Equal(t, []byte("\x89PNG"), paperWallet.AddressQR[:4])
+	assert.Equal(t, []byte("\x89PNG"), paperWallet.WIFQR[:4])
+
+	assert.Empty(t, repo.wallets)
+	_, err = keystore.GetKey(paperWallet.Address)
+	assert.Error(t, err)
+
+	// Sweeping it later gives back the same address
+	wallet, err := walletService.ImportWIF(paperWallet.WIF)
+	assert.NoError(t, err)
+	assert.Equal(t, paperWallet.Address, wallet.Address)
+	assert.Equal(t, services.SigAlgorithmEd25519, wallet.SigAlgorithm)
+}
+
+func TestCreateVanityWalletMatchesPrefix(t *testing.T) {
+	walletService := newTestServices(t).walletService
+
+	wallet, err := walletService.CreateVanityWallet("1A", services.SigAlgorithmEd25519, 10*time.Second, 2)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(wallet.Address, "1A"))
+
+	// Mainnet addresses always start with 1
+	_, err = walletService.CreateVanityWallet("2", services.SigAlgorithmEd25519, 100*time.Millisecond, 2)
+	assert.True(t, errors.Is(err, services.ErrVanityTimeout))
+
+	_, err = walletService.CreateVanityWallet("10OIl", services.SigAlgorithmEd25519, 0, 0)
+	assert.Error(t, err)
+}
+
+func TestLockingKeystoreLeavesKeysInUseAlone(t *testing.T) {
+	keystore := newUnlockedKeystore(t)
+
+	privKey := []byte("private key")
+	assert.NoError(t, keystore.StoreKey("address", privKey))
+	held, err := keystore.GetKey("address")
+	assert.NoError(t, err)
+
+	// Changing a key handed out doesn't change the keystore's
+	held[0] = 'P'
+	stored, err := keystore.GetKey("address")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("private key"), stored)
+
+	keystore.Lock()
+	assert.Equal(t, []byte("private key"), privKey)
+	assert.Equal(t, []byte("Private key"), held)
+	assert.Equal(t, []byte("private key"), stored)
+	_, err = keystore.GetKey("address")
+	assert.Error(t, err)
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/akamensky/base58"
+)
+
+// Trailing byte of a WIF payload saying which scheme the key is for. Bitcoin uses 0x01 here
+// to flag compressed keys, so keys from other tools with that flag import as ECDSA
+var wifAlgorithmFlags = map[string]byte{
+	SigAlgorithmECDSA:   0x01,
+	SigAlgorithmEd25519: 0x02,
+}
+
+// Version byte of WIF keys for a network. As with bitcoin it's the address network byte + 0x80,
+// so mainnet keys start with 0x80 and testnet (0x6f) keys with 0xef
+func wifVersion(networkByte byte) byte {
+	return networkByte + 0x80
+}
+
+// WIF = base58(version + privKey + algorithm flag + checksum)
+func encodeWIF(privKey []byte, sigAlgorithm string, networkByte byte) (string, error) {
+	flag, ok := wifAlgorithmFlags[sigAlgorithm]
+	if !ok {
+		return "", fmt.Errorf("unsupported signature algorithm: %s", sigAlgorithm)
+	}
+
+	payload := append([]byte{wifVersion(networkByte)}, privKey...)
+	payload = append(payload, flag)
+	payload = append(payload, createChecksum(payload)...)
+
+	return base58.Encode(payload), nil
+}
+
+// Decode a WIF key for the given network, returning the private key and its signature algorithm
+func decodeWIF(wif string, networkByte byte) ([]byte, string, error) {
+	decoded, err := base58.Decode(wif)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s, wif key is not valid base58", err.Error())
+	}
+
+	// version + 32 byte key + optional flag + checksum
+	if len(decoded) != 1+32+ChecksumLen && len(decoded) != 1+32+1+ChecksumLen {
+		return nil, "", fmt.Errorf("wif key has invalid length")
+	}
+
+	payload := decoded[:len(decoded)-ChecksumLen]
+	if !bytes.Equal(decoded[len(decoded)-ChecksumLen:], createChecksum(payload)) {
+		return nil, "", fmt.Errorf("wif key has invalid checksum")
+	}
+
+	if payload[0] != wifVersion(networkByte) {
+		return nil, "", fmt.Errorf("wif key is for another network, expected version %d, got %d", wifVersion(networkByte), payload[0])
+	}
+
+	privKey := payload[1:33]
+
+	// Uncompressed bitcoin keys have no flag
+	if len(payload) == 33 {
+		return privKey, SigAlgorithmECDSA, nil
+	}
+
+	for sigAlgorithm, flag := range wifAlgorithmFlags {
+		if payload[33] == flag {
+			return privKey, sigAlgorithm, nil
+		}
+	}
+
+	return nil, "", fmt.Errorf("wif key has unknown algorithm flag %d", payload[33])
+}